@@ -11,21 +11,26 @@ import (
 	"time"
 
 	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/dao"
 	"github.com/content-services/content-sources-backend/pkg/db"
 	"github.com/content-services/content-sources-backend/pkg/event"
 	eventHandler "github.com/content-services/content-sources-backend/pkg/event/handler"
 	"github.com/content-services/content-sources-backend/pkg/handler"
 	m "github.com/content-services/content-sources-backend/pkg/instrumentation"
 	custom_collector "github.com/content-services/content-sources-backend/pkg/instrumentation/custom"
+	"github.com/content-services/content-sources-backend/pkg/middleware"
+	"github.com/content-services/content-sources-backend/pkg/notifications"
 	"github.com/content-services/content-sources-backend/pkg/pulp_client"
 	"github.com/content-services/content-sources-backend/pkg/router"
 	"github.com/content-services/content-sources-backend/pkg/tasks"
 	"github.com/content-services/content-sources-backend/pkg/tasks/queue"
 	"github.com/content-services/content-sources-backend/pkg/tasks/worker"
 	mocks_rbac "github.com/content-services/content-sources-backend/pkg/test/mocks/rbac"
+	"github.com/content-services/content-sources-backend/pkg/webhooks"
 	"github.com/labstack/echo/v4"
 	echo_middleware "github.com/labstack/echo/v4/middleware"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog/log"
 )
@@ -41,6 +46,9 @@ func main() {
 
 	config.Load()
 	config.ConfigureLogging()
+	if err := config.Get().Validate(); err != nil {
+		log.Fatal().Err(err).Msg("Invalid configuration")
+	}
 	err := db.Connect()
 	defer db.Close()
 
@@ -58,6 +66,8 @@ func main() {
 		cancel()
 	}()
 
+	logLevelSync(ctx, &wg)
+
 	// If we're not running an api server, still listen for ping requests for liveliness probes
 	apiServer(ctx, &wg, argsContain(args, "api"), metrics)
 
@@ -67,6 +77,9 @@ func main() {
 
 	if argsContain(args, "instrumentation") {
 		instrumentation(ctx, &wg, metrics)
+	} else if argsContain(args, "consumer") {
+		// Workers serve their own metrics, such as the duration of their tasks
+		metricsServer(ctx, &wg, metrics)
 	}
 
 	if argsContain(args, "mock_rbac") {
@@ -74,6 +87,11 @@ func main() {
 	}
 	config.SetupNotifications()
 
+	if argsContain(args, "api") {
+		notificationsRelay(ctx, &wg)
+		webhooksRelay(ctx, &wg)
+	}
+
 	config := pulp_client.S3StorageConfiguration()
 	config["secret_key"] = "HIDDEN"
 	log.Logger.Warn().Interface("S3StorageConfig", config).Msg("Storage")
@@ -99,10 +117,18 @@ func kafkaConsumer(ctx context.Context, wg *sync.WaitGroup, metrics *m.Metrics)
 			if err != nil {
 				panic(err)
 			}
+			pgqueue.SetConcurrencyLimit(config.RepositorySnapshotTask, queue.ConcurrencyLimit{
+				PerOrg: config.Get().Tasking.SnapshotConcurrency.PerOrg,
+				Global: config.Get().Tasking.SnapshotConcurrency.Global,
+			})
 			wrk := worker.NewTaskWorkerPool(&pgqueue, metrics)
 			wrk.RegisterHandler(config.IntrospectTask, tasks.IntrospectHandler)
 			wrk.RegisterHandler(config.RepositorySnapshotTask, tasks.SnapshotHandler)
 			wrk.RegisterHandler(config.DeleteRepositorySnapshotsTask, tasks.DeleteSnapshotHandler)
+			wrk.RegisterHandler(config.VerifySnapshotsTask, tasks.VerifySnapshotHandler)
+			wrk.RegisterHandler(config.MigrateOrgTask, tasks.MigrateOrgHandler)
+			wrk.RegisterHandler(config.RepairReferencesTask, tasks.RepairReferencesHandler)
+			wrk.RegisterHandler(config.BootstrapOrgTask, tasks.BootstrapOrgHandler)
 			wrk.HeartbeatListener()
 			go wrk.StartWorkers(ctx)
 			<-ctx.Done()
@@ -125,6 +151,8 @@ func apiServer(ctx context.Context, wg *sync.WaitGroup, allRoutes bool, metrics
 	handler.RegisterPing(echo)
 	if allRoutes {
 		handler.RegisterRoutes(echo)
+		apiUsage(ctx, wg, echo)
+		echo.Use(middleware.Idempotency(dao.GetIdempotencyDao(db.DB), config.Get().Options.IdempotencyKeyTTL, handler.IsUploadRoute))
 	}
 
 	go func() {
@@ -146,8 +174,51 @@ func apiServer(ctx context.Context, wg *sync.WaitGroup, allRoutes bool, metrics
 	}()
 }
 
+// apiUsage counts requests per org and route, see GET /admin/usage/
+func apiUsage(ctx context.Context, wg *sync.WaitGroup, e *echo.Echo) {
+	usage := middleware.NewUsageRecorder(dao.GetUsageDao(db.DB))
+	e.Use(usage.Middleware)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		usage.Run(ctx)
+		log.Logger.Info().Msgf("api usage recorder stopped")
+	}()
+}
+
+// logLevelSync applies the log levels changed through PUT /admin/logging/
+func logLevelSync(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		handler.SyncLogLevels(ctx, dao.GetLogLevelsDao(db.DB), config.Get().Options.LogLevelSyncInterval)
+		log.Logger.Info().Msgf("log level sync stopped")
+	}()
+}
+
 func instrumentation(ctx context.Context, wg *sync.WaitGroup, metrics *m.Metrics) {
-	wg.Add(2)
+	metricsServer(ctx, wg, metrics)
+
+	// Custom go routine
+	wg.Add(1)
+	custom_ctx, custom_cancel := context.WithCancel(ctx)
+	custom := custom_collector.NewCollector(custom_ctx, metrics, db.DB)
+	go func() {
+		defer wg.Done()
+		log.Logger.Info().Msgf("Starting custom metrics go routine")
+		custom.Run()
+		log.Logger.Info().Msgf("custom metrics stopped")
+	}()
+
+	go func() {
+		<-custom_ctx.Done()
+		custom_cancel()
+	}()
+}
+
+// metricsServer serves the metrics of the process on the metrics port
+func metricsServer(ctx context.Context, wg *sync.WaitGroup, metrics *m.Metrics) {
+	wg.Add(1)
 	e := router.ConfigureEcho(false)
 
 	metricsPath := config.Get().Metrics.Path
@@ -163,6 +234,15 @@ func instrumentation(ctx context.Context, wg *sync.WaitGroup, metrics *m.Metrics
 		},
 	)))
 	e.HideBanner = true
+
+	// Connection pool statistics, to detect pool saturation
+	if sqlDb, err := db.DB.DB(); err == nil {
+		metrics.Registry().MustRegister(collectors.NewDBStatsCollector(sqlDb, config.Get().Database.Name))
+	} else {
+		log.Logger.Error().Err(err).Msg("error registering database pool metrics")
+	}
+	metrics.Registry().MustRegister(db.SlowQueriesCollector())
+
 	go func() {
 		defer wg.Done()
 		log.Logger.Info().Msgf("Starting instrumentation")
@@ -180,20 +260,25 @@ func instrumentation(ctx context.Context, wg *sync.WaitGroup, metrics *m.Metrics
 		}
 		cancel()
 	}()
+}
 
-	// Custom go routine
-	custom_ctx, custom_cancel := context.WithCancel(ctx)
-	custom := custom_collector.NewCollector(custom_ctx, metrics, db.DB)
+func notificationsRelay(ctx context.Context, wg *sync.WaitGroup) {
+	relay := notifications.NewOutboxRelay(ctx, db.DB)
+	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		log.Logger.Info().Msgf("Starting custom metrics go routine")
-		custom.Run()
-		log.Logger.Info().Msgf("custom metrics stopped")
+		relay.Run()
+		log.Logger.Info().Msgf("notifications outbox relay stopped")
 	}()
+}
 
+func webhooksRelay(ctx context.Context, wg *sync.WaitGroup) {
+	relay := webhooks.NewRelay(ctx, db.DB)
+	wg.Add(1)
 	go func() {
-		<-custom_ctx.Done()
-		custom_cancel()
+		defer wg.Done()
+		relay.Run()
+		log.Logger.Info().Msgf("webhooks relay stopped")
 	}()
 }
 
@@ -7,7 +7,6 @@ import (
 	"time"
 
 	"github.com/content-services/content-sources-backend/pkg/db"
-	"github.com/content-services/content-sources-backend/pkg/models"
 	"github.com/content-services/content-sources-backend/pkg/seeds"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/lib/pq"
@@ -70,11 +69,18 @@ func main() {
 	downMigrationCmd := flag.NewFlagSet("down", flag.ExitOnError)
 	downMigrationSteps := downMigrationCmd.Int("steps", 0, "migrate down")
 
+	seedCmd := flag.NewFlagSet("seed", flag.ExitOnError)
+	seedOrgID := seedCmd.String("org", "acme", "org id of the first seeded org")
+	seedOrgs := seedCmd.Int("orgs", 1, "number of orgs to seed")
+	seedRepos := seedCmd.Int("repos", 1000, "number of repositories per org")
+	seedRpms := seedCmd.Int("rpms", 50, "number of rpms per repository")
+	seedSnapshots := seedCmd.Int("snapshots", 2, "number of snapshots per repository")
+
 	dbURL := db.GetUrl()
 
 	args := os.Args
 	if len(args) < 2 {
-		log.Fatal().Msg("Requires arguments: up, down, or new.")
+		log.Fatal().Msg("Requires arguments: up, down, new, or seed.")
 	}
 	if args[1] == "new" {
 		if err := createMigrationFile(args[2]); err != nil {
@@ -98,25 +104,28 @@ func main() {
 		}
 		log.Debug().Msg("Successfully migrated down")
 	} else if args[1] == "seed" {
-		err := db.Connect()
-		if err != nil {
-			panic(err)
+		if err := seedCmd.Parse(args[2:]); err != nil {
+			log.Fatal().Err(err).Msg("Failed to seed")
 		}
-		if err = seeds.SeedRepositoryConfigurations(db.DB, 1000, seeds.SeedOptions{
-			OrgID: "acme",
-		}); err != nil {
-			panic(err)
+		if err := db.Connect(); err != nil {
+			log.Fatal().Err(err).Msg("Failed to connect to the database")
 		}
-
-		var dataRepo []models.Repository
-		if err := db.DB.Find(&dataRepo).Error; err != nil {
-			panic(err)
-		}
-		for _, repo := range dataRepo {
-			if err = seeds.SeedRpms(db.DB, &repo, 50); err != nil {
-				panic(err)
-			}
+		result, err := seeds.SeedDemoData(db.DB, seeds.DemoOptions{
+			OrgID:     *seedOrgID,
+			Orgs:      *seedOrgs,
+			Repos:     *seedRepos,
+			Rpms:      *seedRpms,
+			Snapshots: *seedSnapshots,
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to seed")
 		}
-		log.Debug().Msg("Successfully seeded")
+		log.Info().
+			Strs("orgs", result.Orgs).
+			Int("repositories", result.Repos).
+			Int("rpms", result.Rpms).
+			Int("snapshots", result.Snapshots).
+			Int("tasks", result.Tasks).
+			Msg("Successfully seeded")
 	}
 }
@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"time"
 
 	"github.com/content-services/content-sources-backend/pkg/config"
 	"github.com/content-services/content-sources-backend/pkg/dao"
 	"github.com/content-services/content-sources-backend/pkg/db"
 	"github.com/content-services/content-sources-backend/pkg/external_repos"
+	"github.com/content-services/content-sources-backend/pkg/notifications"
+	"github.com/content-services/content-sources-backend/pkg/tasks"
 	"github.com/content-services/content-sources-backend/pkg/tasks/client"
 	"github.com/content-services/content-sources-backend/pkg/tasks/payloads"
 	"github.com/content-services/content-sources-backend/pkg/tasks/queue"
@@ -23,16 +26,25 @@ var (
 	forceIntrospect bool = false
 )
 
+// webhookDeliveryRetention is how long the delivery log of webhooks is kept
+const webhookDeliveryRetention = 30 * 24 * time.Hour
+
+// outboxEventRetention is how long notification events are kept, and so listed by the repository change feed
+const outboxEventRetention = 30 * 24 * time.Hour
+
 func main() {
 	args := os.Args
 	config.Load()
+	if err := config.Get().Validate(); err != nil {
+		log.Fatal().Err(err).Msg("Invalid configuration")
+	}
 	err := db.Connect()
 	if err != nil {
 		log.Panic().Err(err).Msg("Failed to connect to database")
 	}
 
 	if len(args) < 2 {
-		log.Fatal().Msg("Requires arguments: download, import, introspect, nightly-jobs")
+		log.Fatal().Msg("Requires arguments: download, import, introspect, nightly-jobs, weekly-jobs")
 	}
 	if args[1] == "download" {
 		if len(args) < 3 {
@@ -73,11 +85,46 @@ func main() {
 		}
 		log.Debug().Msgf("Inserted %d packages", count)
 	} else if args[1] == "nightly-jobs" {
+		deleted, err := dao.GetIdempotencyDao(db.DB).DeleteExpired(context.Background())
+		if err != nil {
+			log.Error().Err(err).Msg("error deleting expired idempotency keys")
+		} else {
+			log.Debug().Msgf("Deleted %d expired idempotency keys", deleted)
+		}
+		deleted, err = dao.GetWebhookDao(db.DB).DeleteDeliveriesBefore(context.Background(), time.Now().Add(-webhookDeliveryRetention))
+		if err != nil {
+			log.Error().Err(err).Msg("error deleting old webhook deliveries")
+		} else {
+			log.Debug().Msgf("Deleted %d old webhook deliveries", deleted)
+		}
+		deleted, err = notifications.DeleteOutboxEventsBefore(context.Background(), db.DB, time.Now().Add(-outboxEventRetention))
+		if err != nil {
+			log.Error().Err(err).Msg("error deleting old outbox events")
+		} else {
+			log.Debug().Msgf("Deleted %d old outbox events", deleted)
+		}
+		historyDays := config.Get().Options.IntrospectionHistoryDays
+		deleted, err = dao.GetRepositoryDao(db.DB).DeleteIntrospectionsBefore(context.Background(), time.Now().AddDate(0, 0, -historyDays))
+		if err != nil {
+			log.Error().Err(err).Msg("error deleting old introspections")
+		} else {
+			log.Debug().Msgf("Deleted %d old introspections", deleted)
+		}
+		count, err := external_repos.ReportTelemetry(context.Background(), dao.GetDaoRegistry(db.DB), time.Now())
+		if err != nil {
+			log.Error().Err(err).Msg("error reporting telemetry")
+		} else if count > 0 {
+			log.Debug().Msgf("Reported %d telemetry statistics", count)
+		}
 		if config.Get().NewTaskingSystem {
 			err = enqueueIntrospectAllRepos()
 			if err != nil {
 				log.Error().Err(err).Msg("error queueing introspection tasks")
 			}
+			err = enqueuePendingDeletes()
+			if err != nil {
+				log.Error().Err(err).Msg("error queueing delete tasks")
+			}
 		} else {
 			count, introErrors, errors := external_repos.IntrospectAll(context.Background(), nil, forceIntrospect)
 			for i := 0; i < len(introErrors); i++ {
@@ -89,6 +136,14 @@ func main() {
 
 			log.Debug().Msgf("Inserted %d packages", count)
 		}
+	} else if args[1] == "weekly-jobs" {
+		config.SetupNotifications()
+		count, err := external_repos.SendIntrospectionDigests(context.Background(), dao.GetDaoRegistry(db.DB))
+		if err != nil {
+			log.Error().Err(err).Msg("error sending introspection digests")
+		} else {
+			log.Debug().Msgf("Sent introspection digests to %d orgs", count)
+		}
 	}
 }
 
@@ -102,7 +157,7 @@ func saveToDB(db *gorm.DB) error {
 
 	if err == nil {
 		urls = external_repos.GetBaseURLs(extRepos)
-		err = dao.GetRepositoryConfigDao(db).SavePublicRepos(urls)
+		err = dao.GetRepositoryConfigDao(db).SavePublicRepos(context.Background(), urls)
 	}
 	return err
 }
@@ -128,7 +183,7 @@ func enqueueIntrospectAllRepos() error {
 	c := client.NewTaskClient(&q)
 
 	repoDao := dao.GetRepositoryDao(db.DB)
-	repos, err := repoDao.List(true)
+	repos, err := repoDao.List(context.Background(), true)
 	if err != nil {
 		return fmt.Errorf("error getting repositories: %w", err)
 	}
@@ -145,9 +200,37 @@ func enqueueIntrospectAllRepos() error {
 			log.Err(err).Msgf("error enqueueing introspecting for repository %v", repo.URL)
 		}
 	}
-	err = repoDao.OrphanCleanup()
+	err = repoDao.OrphanCleanup(context.Background())
 	if err != nil {
 		log.Err(err).Msg("error during orphan cleanup")
 	}
 	return nil
 }
+
+// enqueuePendingDeletes queues the snapshot cleanup of deleted repositories again,
+// when it was never queued or failed
+func enqueuePendingDeletes() error {
+	q, err := queue.NewPgQueue(db.GetUrl())
+	if err != nil {
+		return fmt.Errorf("error getting new task queue: %w", err)
+	}
+	c := client.NewTaskClient(&q)
+
+	repoConfigs, err := dao.GetRepositoryConfigDao(db.DB).InternalOnly_FetchPendingDelete(context.Background())
+	if err != nil {
+		return fmt.Errorf("error getting deleted repositories: %w", err)
+	}
+	for _, repoConfig := range repoConfigs {
+		t := queue.Task{
+			Typename:       config.DeleteRepositorySnapshotsTask,
+			Payload:        tasks.DeleteRepositorySnapshotsPayload{RepoConfigUUID: repoConfig.UUID},
+			OrgId:          repoConfig.OrgID,
+			RepositoryUUID: repoConfig.RepositoryUUID,
+		}
+		_, err = c.Enqueue(t)
+		if err != nil {
+			log.Err(err).Msgf("error enqueueing delete for repository %v", repoConfig.UUID)
+		}
+	}
+	return nil
+}
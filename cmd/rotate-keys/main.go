@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	"github.com/content-services/content-sources-backend/pkg/db"
+	"github.com/rs/zerolog/log"
+)
+
+// rotate-keys re-encrypts the stored secrets with the first key of encryption.keys.
+// Secrets encrypted with the other configured keys keep being readable while it runs,
+// so the previous keys can be removed from the configuration once it completes.
+func main() {
+	config.Load()
+	config.ConfigureLogging()
+	if err := config.Get().Validate(); err != nil {
+		log.Fatal().Err(err).Msg("Invalid configuration")
+	}
+	err := db.Connect()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to connect to database.")
+	}
+	defer db.Close()
+
+	rotated, err := dao.GetDaoRegistry(db.DB).RepositoryConfig.InternalOnly_RotateSecrets(context.Background())
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to rotate secrets after rotating %d of them.", rotated)
+	}
+	log.Info().Msgf("Rotated %d secrets.", rotated)
+}
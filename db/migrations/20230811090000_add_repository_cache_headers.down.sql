@@ -0,0 +1,5 @@
+BEGIN;
+alter table repositories drop column repomd_revision;
+alter table repositories drop column etag;
+alter table repositories drop column last_modified;
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+alter table repositories add column repomd_revision varchar not null default '';
+alter table repositories add column etag varchar not null default '';
+alter table repositories add column last_modified varchar not null default '';
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS api_usage (
+    org_id VARCHAR(255) NOT NULL,
+    method VARCHAR(16) NOT NULL,
+    route VARCHAR(255) NOT NULL,
+    day DATE NOT NULL,
+    request_count BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (org_id, method, route, day)
+);
+
+CREATE INDEX IF NOT EXISTS api_usage_day_idx ON api_usage(day);
+
+COMMIT;
@@ -0,0 +1,26 @@
+BEGIN;
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE TABLE IF NOT EXISTS rpm_names (
+    repository_uuid UUID NOT NULL,
+    name TEXT NOT NULL,
+    summary TEXT NOT NULL,
+    PRIMARY KEY (repository_uuid, name),
+    CONSTRAINT fk_rpm_names_repository
+        FOREIGN KEY (repository_uuid)
+        REFERENCES repositories(uuid)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS rpm_names_name_trgm_idx ON rpm_names USING gin (name gin_trgm_ops);
+
+INSERT INTO rpm_names (repository_uuid, name, summary)
+SELECT DISTINCT ON (repositories_rpms.repository_uuid, rpms.name)
+       repositories_rpms.repository_uuid, rpms.name, rpms.summary
+FROM rpms
+     INNER JOIN repositories_rpms ON repositories_rpms.rpm_uuid = rpms.uuid
+ORDER BY repositories_rpms.repository_uuid, rpms.name, rpms.epoch DESC
+ON CONFLICT DO NOTHING;
+
+COMMIT;
@@ -0,0 +1,20 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS rpm_capabilities (
+    rpm_uuid UUID NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    name TEXT NOT NULL,
+    flags VARCHAR(4) NOT NULL DEFAULT '',
+    epoch INTEGER NOT NULL DEFAULT 0,
+    version TEXT NOT NULL DEFAULT '',
+    release TEXT NOT NULL DEFAULT '',
+    CONSTRAINT fk_rpm_capabilities_rpm
+        FOREIGN KEY (rpm_uuid)
+        REFERENCES rpms(uuid)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS rpm_capabilities_rpm_uuid_idx ON rpm_capabilities(rpm_uuid);
+CREATE INDEX IF NOT EXISTS rpm_capabilities_kind_name_idx ON rpm_capabilities(kind, name);
+
+COMMIT;
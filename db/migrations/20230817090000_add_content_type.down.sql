@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE repository_configurations
+DROP CONSTRAINT IF EXISTS repository_configurations_content_type_check;
+
+ALTER TABLE repository_configurations
+DROP COLUMN IF EXISTS content_type;
+
+COMMIT;
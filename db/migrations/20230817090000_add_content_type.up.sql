@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE repository_configurations
+ADD COLUMN IF NOT EXISTS content_type VARCHAR(16) NOT NULL DEFAULT 'binary';
+
+ALTER TABLE repository_configurations
+ADD CONSTRAINT repository_configurations_content_type_check CHECK (content_type IN ('binary', 'source', 'debug'));
+
+COMMIT;
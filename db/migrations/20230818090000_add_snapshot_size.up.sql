@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE snapshots
+ADD COLUMN IF NOT EXISTS size_bytes BIGINT NOT NULL DEFAULT 0;
+
+COMMIT;
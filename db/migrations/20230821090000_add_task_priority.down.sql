@@ -0,0 +1,22 @@
+BEGIN;
+
+DROP INDEX IF EXISTS tasks_ready_priority_idx;
+
+DROP VIEW IF EXISTS ready_tasks;
+
+ALTER TABLE tasks
+DROP COLUMN IF EXISTS priority;
+
+CREATE VIEW ready_tasks AS
+SELECT *
+FROM tasks
+WHERE started_at IS NULL
+  AND (status != 'canceled' OR status is null)
+  AND id NOT IN (
+    SELECT task_id
+    FROM task_dependencies JOIN tasks ON dependency_id = id
+    WHERE finished_at IS NULL
+)
+ORDER BY queued_at ASC;
+
+COMMIT;
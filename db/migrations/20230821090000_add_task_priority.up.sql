@@ -0,0 +1,21 @@
+BEGIN;
+
+ALTER TABLE tasks
+ADD COLUMN IF NOT EXISTS priority INTEGER NOT NULL DEFAULT 0;
+
+-- the columns of the view are fixed when it is created, recreate it to add priority
+CREATE OR REPLACE VIEW ready_tasks AS
+SELECT *
+FROM tasks
+WHERE started_at IS NULL
+  AND (status != 'canceled' OR status is null)
+  AND id NOT IN (
+    SELECT task_id
+    FROM task_dependencies JOIN tasks ON dependency_id = id
+    WHERE finished_at IS NULL
+)
+ORDER BY queued_at ASC;
+
+CREATE INDEX IF NOT EXISTS tasks_ready_priority_idx ON tasks(priority DESC, queued_at) WHERE started_at IS NULL;
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS org_settings (
+    org_id VARCHAR(255) PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    introspection_digest_opt_out BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+COMMIT;
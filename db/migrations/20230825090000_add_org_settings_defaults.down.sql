@@ -0,0 +1,10 @@
+BEGIN;
+
+ALTER TABLE org_settings
+DROP COLUMN IF EXISTS default_distribution_arch,
+DROP COLUMN IF EXISTS default_distribution_versions,
+DROP COLUMN IF EXISTS default_metadata_verification,
+DROP COLUMN IF EXISTS snapshot_retention,
+DROP COLUMN IF EXISTS notification_opt_outs;
+
+COMMIT;
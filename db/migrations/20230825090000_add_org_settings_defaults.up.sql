@@ -0,0 +1,10 @@
+BEGIN;
+
+ALTER TABLE org_settings
+ADD COLUMN IF NOT EXISTS default_distribution_arch VARCHAR(255) NOT NULL DEFAULT 'any',
+ADD COLUMN IF NOT EXISTS default_distribution_versions TEXT[] NOT NULL DEFAULT '{any}',
+ADD COLUMN IF NOT EXISTS default_metadata_verification BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN IF NOT EXISTS snapshot_retention INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS notification_opt_outs TEXT[] NOT NULL DEFAULT '{}';
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE repository_configurations
+DROP COLUMN IF EXISTS proxy_url,
+DROP COLUMN IF EXISTS proxy_username,
+DROP COLUMN IF EXISTS proxy_password;
+
+COMMIT;
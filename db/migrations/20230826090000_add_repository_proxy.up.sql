@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE repository_configurations
+ADD COLUMN IF NOT EXISTS proxy_url VARCHAR(255) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS proxy_username VARCHAR(255) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS proxy_password VARCHAR(255) NOT NULL DEFAULT '';
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE repository_configurations
+DROP COLUMN IF EXISTS username,
+DROP COLUMN IF EXISTS password;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE repository_configurations
+ADD COLUMN IF NOT EXISTS username VARCHAR(255) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS password TEXT NOT NULL DEFAULT '';
+
+COMMIT;
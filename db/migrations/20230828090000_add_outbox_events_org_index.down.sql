@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP INDEX IF EXISTS outbox_events_org_id_created_at_idx;
+
+COMMIT;
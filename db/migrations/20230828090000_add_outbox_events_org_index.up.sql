@@ -0,0 +1,5 @@
+BEGIN;
+
+CREATE INDEX IF NOT EXISTS outbox_events_org_id_created_at_idx ON outbox_events(org_id, created_at);
+
+COMMIT;
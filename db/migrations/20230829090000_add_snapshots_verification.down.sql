@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE snapshots
+DROP COLUMN IF EXISTS verification_error,
+DROP COLUMN IF EXISTS verified_at;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE snapshots
+ADD COLUMN IF NOT EXISTS verification_error TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS verified_at TIMESTAMP WITH TIME ZONE DEFAULT NULL;
+
+COMMIT;
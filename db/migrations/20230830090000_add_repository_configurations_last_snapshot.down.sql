@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE repository_configurations
+DROP CONSTRAINT IF EXISTS fk_repository_configurations_last_snapshot,
+DROP COLUMN IF EXISTS last_snapshot_uuid,
+DROP COLUMN IF EXISTS last_snapshot_task_uuid,
+DROP COLUMN IF EXISTS last_snapshot_at;
+
+COMMIT;
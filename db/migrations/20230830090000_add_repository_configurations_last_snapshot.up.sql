@@ -0,0 +1,25 @@
+BEGIN;
+
+ALTER TABLE repository_configurations
+ADD COLUMN IF NOT EXISTS last_snapshot_uuid UUID DEFAULT NULL,
+ADD COLUMN IF NOT EXISTS last_snapshot_task_uuid UUID DEFAULT NULL,
+ADD COLUMN IF NOT EXISTS last_snapshot_at TIMESTAMP WITH TIME ZONE DEFAULT NULL;
+
+ALTER TABLE repository_configurations
+DROP CONSTRAINT IF EXISTS fk_repository_configurations_last_snapshot,
+ADD CONSTRAINT fk_repository_configurations_last_snapshot
+    FOREIGN KEY (last_snapshot_uuid)
+    REFERENCES snapshots(uuid)
+    ON DELETE SET NULL;
+
+UPDATE repository_configurations
+SET last_snapshot_uuid = latest.uuid,
+    last_snapshot_at = latest.created_at
+FROM (
+    SELECT DISTINCT ON (repository_configuration_uuid) repository_configuration_uuid, uuid, created_at
+    FROM snapshots
+    ORDER BY repository_configuration_uuid, created_at DESC
+) AS latest
+WHERE latest.repository_configuration_uuid = repository_configurations.uuid;
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+ALTER TABLE repositories ALTER COLUMN uuid DROP DEFAULT;
+ALTER TABLE repository_configurations ALTER COLUMN uuid DROP DEFAULT;
+ALTER TABLE rpms ALTER COLUMN uuid DROP DEFAULT;
+ALTER TABLE snapshots ALTER COLUMN uuid DROP DEFAULT;
+ALTER TABLE outbox_events ALTER COLUMN uuid DROP DEFAULT;
+ALTER TABLE webhooks ALTER COLUMN uuid DROP DEFAULT;
+ALTER TABLE webhook_deliveries ALTER COLUMN uuid DROP DEFAULT;
+ALTER TABLE introspections ALTER COLUMN uuid DROP DEFAULT;
+
+COMMIT;
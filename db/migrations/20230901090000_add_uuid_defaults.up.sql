@@ -0,0 +1,14 @@
+BEGIN;
+
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+ALTER TABLE repositories ALTER COLUMN uuid SET DEFAULT gen_random_uuid();
+ALTER TABLE repository_configurations ALTER COLUMN uuid SET DEFAULT gen_random_uuid();
+ALTER TABLE rpms ALTER COLUMN uuid SET DEFAULT gen_random_uuid();
+ALTER TABLE snapshots ALTER COLUMN uuid SET DEFAULT gen_random_uuid();
+ALTER TABLE outbox_events ALTER COLUMN uuid SET DEFAULT gen_random_uuid();
+ALTER TABLE webhooks ALTER COLUMN uuid SET DEFAULT gen_random_uuid();
+ALTER TABLE webhook_deliveries ALTER COLUMN uuid SET DEFAULT gen_random_uuid();
+ALTER TABLE introspections ALTER COLUMN uuid SET DEFAULT gen_random_uuid();
+
+COMMIT;
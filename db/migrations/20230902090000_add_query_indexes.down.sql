@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP INDEX IF EXISTS repository_configurations_org_id_name_idx;
+DROP INDEX IF EXISTS repository_configurations_org_id_repository_uuid_idx;
+DROP INDEX IF EXISTS repositories_rpms_rpm_uuid_idx;
+DROP INDEX IF EXISTS tasks_status_queued_at_idx;
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+-- Listing the repositories of an org, filtered or sorted by name
+CREATE INDEX IF NOT EXISTS repository_configurations_org_id_name_idx ON repository_configurations(org_id, name);
+
+-- Looking up the repositories of an org by url, which joins the repositories with a unique url
+CREATE INDEX IF NOT EXISTS repository_configurations_org_id_repository_uuid_idx ON repository_configurations(org_id, repository_uuid);
+
+-- Listing the rpms of a repository uses the primary key (repository_uuid, rpm_uuid) and the index
+-- on rpms(name), while removing rpms follows the foreign key to the repositories containing them
+CREATE INDEX IF NOT EXISTS repositories_rpms_rpm_uuid_idx ON repositories_rpms(rpm_uuid);
+
+-- Listing tasks filtered by status, oldest first
+CREATE INDEX IF NOT EXISTS tasks_status_queued_at_idx ON tasks(status, queued_at);
+
+COMMIT;
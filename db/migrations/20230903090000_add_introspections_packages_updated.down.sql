@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE introspections
+DROP COLUMN IF EXISTS packages_updated;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE introspections
+ADD COLUMN IF NOT EXISTS packages_updated INTEGER NOT NULL DEFAULT 0;
+
+COMMIT;
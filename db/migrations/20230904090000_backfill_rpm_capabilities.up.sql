@@ -0,0 +1,11 @@
+BEGIN;
+
+--- Rpms introspected before capabilities were stored have none.  Forget the repomd.xml of their
+--- repositories, so that the next introspection fetches the packages again and stores them.
+UPDATE repositories SET repomd_checksum = NULL, etag = NULL, last_modified = NULL
+    WHERE repositories.uuid IN (
+        SELECT DISTINCT repositories_rpms.repository_uuid FROM repositories_rpms
+            WHERE NOT EXISTS (
+                SELECT 1 FROM rpm_capabilities WHERE rpm_capabilities.rpm_uuid = repositories_rpms.rpm_uuid));
+
+COMMIT;
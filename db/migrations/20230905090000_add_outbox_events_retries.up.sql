@@ -0,0 +1,15 @@
+BEGIN;
+
+ALTER TABLE outbox_events
+    ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS failed_at TIMESTAMP WITH TIME ZONE;
+
+DROP INDEX IF EXISTS outbox_events_unpublished_idx;
+
+CREATE INDEX IF NOT EXISTS outbox_events_pending_idx
+    ON outbox_events(next_attempt_at)
+    WHERE published_at IS NULL AND failed_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS outbox_events_created_at_idx ON outbox_events(created_at);
+
+COMMIT;
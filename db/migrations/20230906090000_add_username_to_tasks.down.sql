@@ -0,0 +1,5 @@
+BEGIN;
+
+alter table tasks drop column username;
+
+COMMIT;
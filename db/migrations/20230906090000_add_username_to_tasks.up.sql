@@ -0,0 +1,5 @@
+BEGIN;
+
+alter table tasks add column username varchar;
+
+COMMIT;
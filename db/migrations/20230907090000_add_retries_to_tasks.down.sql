@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS tasks_dead_idx;
+
+ALTER TABLE tasks
+DROP COLUMN IF EXISTS retries,
+DROP COLUMN IF EXISTS error_history;
+
+COMMIT;
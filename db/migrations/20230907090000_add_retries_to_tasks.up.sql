@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE tasks
+ADD COLUMN IF NOT EXISTS retries INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS error_history TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS tasks_dead_idx ON tasks(finished_at) WHERE status = 'dead';
+
+COMMIT;
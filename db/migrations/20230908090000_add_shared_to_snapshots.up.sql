@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE snapshots
+ADD COLUMN IF NOT EXISTS shared BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS telemetry_stats (
+    day DATE NOT NULL,
+    metric VARCHAR(64) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    value BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, metric, key)
+);
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS log_levels (
+    id INTEGER PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    level VARCHAR(16) NOT NULL,
+    debug_modules TEXT[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_by VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+COMMIT;
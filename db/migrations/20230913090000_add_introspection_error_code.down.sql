@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE repositories
+DROP COLUMN IF EXISTS last_introspection_error_code;
+
+COMMIT;
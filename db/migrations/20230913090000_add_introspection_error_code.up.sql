@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE repositories
+ADD COLUMN IF NOT EXISTS last_introspection_error_code VARCHAR(32) DEFAULT NULL;
+
+COMMIT;
@@ -19,8 +19,11 @@ db-migrate-up: $(GO_OUTPUT)/dbmigrate ## Run dbmigrate up
 	$(GO_OUTPUT)/dbmigrate up
 
 .PHONY: db-migrate-seed
-db-migrate-seed: $(GO_OUTPUT)/dbmigrate ## Run dbmigrate seed
-	$(GO_OUTPUT)/dbmigrate seed
+db-migrate-seed: $(GO_OUTPUT)/dbmigrate ## Run dbmigrate seed, pass flags such as --orgs 2 --repos 50 with SEED_ARGS
+	$(GO_OUTPUT)/dbmigrate seed $(SEED_ARGS)
+
+.PHONY: seed
+seed: db-migrate-seed ## Seed demo repositories, snapshots, rpms and tasks, alias of db-migrate-seed
 
 .PHONY: db-cli-connect
 db-cli-connect: ## Open a postgres cli in the container (it requires db-up)
@@ -23,6 +23,7 @@ SCHEMA_JSON_FILES := $(patsubst $(EVENT_SCHEMA_DIR)/%.yaml,$(EVENT_SCHEMA_DIR)/%
 gen-event-messages: $(GOJSONSCHEMA) $(SCHEMA_JSON_FILES)  ## Generate event messages from schemas
 	@[ -e "$(EVENT_MESSAGE_DIR)" ] || mkdir -p "$(EVENT_MESSAGE_DIR)"
 	$(GOJSONSCHEMA) -p message "$(EVENT_SCHEMA_DIR)/introspectRequest.message.json" -o "$(EVENT_MESSAGE_DIR)/introspect_request.types.gen.go"
+	$(GOJSONSCHEMA) -p message "$(EVENT_SCHEMA_DIR)/repositoryChanges.message.json" -o "$(EVENT_MESSAGE_DIR)/repository_changes.types.gen.go"
 
 $(EVENT_SCHEMA_DIR)/%.json: $(EVENT_SCHEMA_DIR)/%.yaml
 	@[ -e "$(EVENT_MESSAGE_DIR)" ] || mkdir -p "$(EVENT_MESSAGE_DIR)"
@@ -52,6 +52,11 @@ test-unit: ## Run tests for ci
 test-integration: ## Run tests for ci
 	CONFIG_PATH="$(PROJECT_DIR)/configs/" go test $(MOD_VENDOR) ./test/integration/...
 
+PERF_BASE_URL ?= http://localhost:8000/api/content-sources/v1
+.PHONY: perf
+perf: ## Run the load tests against a running and seeded api, set PERF_UPDATE_BASELINE=true to record a new baseline
+	PERF_BASE_URL="$(PERF_BASE_URL)" go test $(MOD_VENDOR) -count=1 -v -run TestLoad ./test/load/...
+
 # Add dependencies from binaries to all the the sources
 # so any change is detected for the build rule
 $(patsubst cmd/%,$(GO_OUTPUT)/%,$(wildcard cmd/*)): $(shell find $(PROJECT_DIR)/cmd -type f -name '*.go') $(shell find $(PROJECT_DIR)/pkg -type f -name '*.go')
@@ -9,7 +9,7 @@ endif
 KAFKA_COMPOSE_OPTIONS=KAFKA_CONFIG_DIR=$(KAFKA_CONFIG_DIR) \
 						KAFKA_DATA_DIR=$(KAFKA_DATA_DIR) \
 						ZOOKEEPER_CLIENT_PORT=$(ZOOKEEPER_CLIENT_PORT) \
-						KAFKA_TOPICS=$(KAFKA_TOPICS) \
+						KAFKA_TOPICS="$(KAFKA_TOPICS)" \
 
 .PHONY: kafka-shell
 kafka-shell:  ## Open an interactive shell in the kafka container
@@ -0,0 +1,19 @@
+package api
+
+import "time"
+
+// AdminLoggingRequest changes the log levels of every process until the ttl elapses
+type AdminLoggingRequest struct {
+	Level        string   `json:"level"`         // Log level (trace, debug, info, warn, error), the configured level if omitted
+	DebugModules []string `json:"debug_modules"` // Modules whose debug logs are written whatever the level (pulp_client, candlepin_client, external_repos, tasks)
+	TTL          string   `json:"ttl"`           // Time after which the configured level is restored, e.g. 30m
+}
+
+// AdminLoggingResponse holds the log levels applied by the processes
+type AdminLoggingResponse struct {
+	Level           string     `json:"level"`                // Log level
+	DebugModules    []string   `json:"debug_modules"`        // Modules whose debug logs are written whatever the level
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"` // Time at which the configured level is restored, omitted when it is in use
+	UpdatedBy       string     `json:"updated_by,omitempty"` // Username of the admin who changed the levels
+	ConfiguredLevel string     `json:"configured_level"`     // Log level of the configuration
+}
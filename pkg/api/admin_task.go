@@ -10,7 +10,7 @@ import (
 // AdminTaskInfoResponse holds data returned by a admin tasks API response
 type AdminTaskInfoResponse struct {
 	UUID       string          `json:"uuid"`           // UUID of the object
-	Status     string          `json:"status"`         // Status of task (running, failed, completed, canceled, pending)
+	Status     string          `json:"status"`         // Status of task (running, failed, completed, canceled, pending, dead)
 	Typename   string          `json:"typename"`       // Type of task (e.g. introspect, completed)
 	QueuedAt   string          `json:"queued_at"`      // Timestamp task was queued at
 	StartedAt  string          `json:"started_at"`     // Timestamp task started running at
@@ -18,10 +18,41 @@ type AdminTaskInfoResponse struct {
 	Error      string          `json:"error"`          // Error thrown while running task
 	OrgId      string          `json:"org_id"`         // Organization ID of the owner
 	AccountId  string          `json:"account_id"`     // Account ID of the owner
+	RequestId  string          `json:"request_id"`     // ID of the request that enqueued the task
+	Username   string          `json:"username"`       // Username of the user who triggered the task, empty for background tasks
+	Retries    int             `json:"retries"`        // Times the task was requeued after its worker stopped
+	Errors     []string        `json:"error_history"`  // Errors of the previous runs of the task, oldest first
 	Payload    json.RawMessage `json:"payload"`        // Payload of task (only returned in fetch)
 	Pulp       PulpResponse    `json:"pulp,omitempty"` // Pulp data for snapshot tasks (only returned in fetch)
 }
 
+// AdminVerifySnapshotsRequest selects the snapshots to verify, all the snapshots of the org
+// are verified when no repository is given
+type AdminVerifySnapshotsRequest struct {
+	OrgID          string `json:"org_id"`          // Organization ID of the owner of the snapshots
+	RepositoryUUID string `json:"repository_uuid"` // Identifier of the repository whose snapshots to verify
+}
+
+// AdminRepairReferencesRequest selects the org whose pulp references to repair
+type AdminRepairReferencesRequest struct {
+	OrgID string `json:"org_id"` // Organization ID of the owner of the snapshots
+}
+
+// AdminMigrateOrgRequest selects the org whose repositories to move to another org
+type AdminMigrateOrgRequest struct {
+	SourceOrgID string `json:"source_org_id"` // Organization ID the repositories are moved from
+	TargetOrgID string `json:"target_org_id"` // Organization ID the repositories are moved to
+	AccountID   string `json:"account_id"`    // Account ID of the target organization, left unchanged if omitted
+}
+
+// AdminOrgBootstrapResponse holds what was prepared for a new org
+type AdminOrgBootstrapResponse struct {
+	OrgID      string                 `json:"org_id"`         // Organization ID of the bootstrapped org
+	DomainName string                 `json:"domain_name"`    // Name of the pulp domain of the org
+	Settings   OrgSettingsResponse    `json:"settings"`       // Settings of the org, the defaults unless it saved some
+	Task       *AdminTaskInfoResponse `json:"task,omitempty"` // Task creating the pulp domain and candlepin owner, omitted when neither is configured
+}
+
 type AdminTaskInfoCollectionResponse struct {
 	Data  []AdminTaskInfoResponse `json:"data"`  // Requested Data
 	Meta  ResponseMetadata        `json:"meta"`  // Metadata about the request
@@ -7,10 +7,15 @@ type CollectionMetadataSettable interface {
 	SetMetadata(meta ResponseMetadata, links Links)
 }
 
+// UncountedTotal is the count of a collection response whose total was not counted
+const UncountedTotal int64 = -1
+
 type PaginationData struct {
-	Limit  int    `query:"limit" json:"limit" `    // Number of results to return
-	Offset int    `query:"offset" json:"offset"`   // Offset into the total results
-	SortBy string `query:"sort_by" json:"sort_by"` // SortBy sets the sort order of the results
+	Limit     int      `query:"limit" json:"limit" `    // Number of results to return
+	Offset    int      `query:"offset" json:"offset"`   // Offset into the total results
+	SortBy    string   `query:"sort_by" json:"sort_by"` // SortBy sets the sort order of the results
+	Fields    []string `query:"fields" json:"fields"`   // Fields of the results to return, all fields if empty
+	SkipCount bool     `json:"-"`                       // Whether to skip counting the total results, set by count=false
 }
 
 type FilterData struct {
@@ -22,19 +27,21 @@ type FilterData struct {
 	Name                string `query:"name" json:"name"`                                   // Filter repositories by name using an exact match.
 	URL                 string `query:"url" json:"url"`                                     // Filter repositories by URL using an exact match.
 	Status              string `query:"status" json:"status"`                               // Comma separated list of statuses to optionally filter on.
+	ContentType         string `query:"content_type" json:"content_type"`                   // Comma separated list of content types (binary, source, debug) to optionally filter on.
+	Snapshot            string `query:"snapshot" json:"snapshot"`                           // Filter repositories by whether they are snapshotted (true or false).
 }
 
 type ResponseMetadata struct {
 	Limit  int   `query:"limit" json:"limit"`   // Limit of results used for the request
 	Offset int   `query:"offset" json:"offset"` // Offset into results used for the request
-	Count  int64 `json:"count"`                 // Total count of results
+	Count  int64 `json:"count"`                 // Total count of results, -1 when requested with count=false
 }
 
 type Links struct {
 	First string `json:"first"`          // Path to first page of results
 	Next  string `json:"next,omitempty"` // Path to next page of results
 	Prev  string `json:"prev,omitempty"` // Path to previous page of results
-	Last  string `json:"last"`           // Path to last page of results
+	Last  string `json:"last,omitempty"` // Path to last page of results, omitted when the results were not counted
 }
 
 type UUIDListRequest struct {
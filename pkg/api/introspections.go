@@ -0,0 +1,26 @@
+package api
+
+import "time"
+
+type IntrospectionResponse struct {
+	UUID            string    `json:"uuid"`             // Identifier of the introspection
+	StartedAt       time.Time `json:"started_at"`       // Datetime the introspection started
+	FinishedAt      time.Time `json:"finished_at"`      // Datetime the introspection finished
+	Duration        float64   `json:"duration"`         // Duration of the introspection in seconds
+	Status          string    `json:"status"`           // Status of the introspection (succeeded, unchanged, failed)
+	PackagesAdded   int64     `json:"packages_added"`   // Number of packages added to the repository
+	PackagesRemoved int64     `json:"packages_removed"` // Number of packages removed from the repository
+	PackagesUpdated int64     `json:"packages_updated"` // Number of packages updated to another version
+	Error           string    `json:"error,omitempty"`  // Error of a failed introspection
+}
+
+type IntrospectionCollectionResponse struct {
+	Data  []IntrospectionResponse `json:"data"`  // Requested Data
+	Meta  ResponseMetadata        `json:"meta"`  // Metadata about the request
+	Links Links                   `json:"links"` // Links to other pages of results
+}
+
+func (r *IntrospectionCollectionResponse) SetMetadata(meta ResponseMetadata, links Links) {
+	r.Meta = meta
+	r.Links = links
+}
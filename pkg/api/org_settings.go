@@ -0,0 +1,20 @@
+package api
+
+// OrgSettingsRequest holds the settings saved by a PUT to /settings/, unset settings are reset to their default
+type OrgSettingsRequest struct {
+	DefaultDistributionArch     *string   `json:"default_distribution_arch" example:"x86_64"`  // Architecture of repositories created without one
+	DefaultDistributionVersions *[]string `json:"default_distribution_versions" example:"7,8"` // Versions of repositories created without them
+	DefaultMetadataVerification *bool     `json:"default_metadata_verification"`               // Metadata verification of repositories created without it
+	SnapshotRetention           *int      `json:"snapshot_retention"`                          // Number of snapshots to retain per repository, 0 to retain all of them
+	NotificationOptOuts         *[]string `json:"notification_opt_outs"`                       // Notification events not sent to the organization
+	IntrospectionDigestOptOut   *bool     `json:"introspection_digest_opt_out"`                // Do not send the weekly digest of repositories failing to introspect
+}
+
+type OrgSettingsResponse struct {
+	DefaultDistributionArch     string   `json:"default_distribution_arch" example:"x86_64"`  // Architecture of repositories created without one
+	DefaultDistributionVersions []string `json:"default_distribution_versions" example:"7,8"` // Versions of repositories created without them
+	DefaultMetadataVerification bool     `json:"default_metadata_verification"`               // Metadata verification of repositories created without it
+	SnapshotRetention           int      `json:"snapshot_retention"`                          // Number of snapshots to retain per repository, 0 to retain all of them
+	NotificationOptOuts         []string `json:"notification_opt_outs"`                       // Notification events not sent to the organization
+	IntrospectionDigestOptOut   bool     `json:"introspection_digest_opt_out"`                // Do not send the weekly digest of repositories failing to introspect
+}
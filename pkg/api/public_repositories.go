@@ -9,6 +9,7 @@ type PublicRepositoryResponse struct {
 	LastIntrospectionSuccessTime string `json:"last_success_introspection_time"` // Timestamp of last successful introspection
 	LastIntrospectionUpdateTime  string `json:"last_update_introspection_time"`  // Timestamp of last introspection that had updates
 	LastIntrospectionError       string `json:"last_introspection_error"`        // Error of last attempted introspection
+	LastIntrospectionErrorCode   string `json:"last_introspection_error_code"`   // Code of the error of last attempted introspection, empty when it succeeded
 	PackageCount                 int    `json:"package_count"`                   // Number of packages last read in the repository
 }
 
@@ -2,26 +2,50 @@ package api
 
 // RepositoryResponse holds data returned by a repositories API response
 type RepositoryResponse struct {
-	UUID                         string   `json:"uuid" readonly:"true"`                // UUID of the object
-	Name                         string   `json:"name"`                                // Name of the remote yum repository
-	URL                          string   `json:"url"`                                 // URL of the remote yum repository
-	DistributionVersions         []string `json:"distribution_versions" example:"7,8"` // Versions to restrict client usage to
-	DistributionArch             string   `json:"distribution_arch" example:"x86_64"`  // Architecture to restrict client usage to
-	AccountID                    string   `json:"account_id" readonly:"true"`          // Account ID of the owner
-	OrgID                        string   `json:"org_id" readonly:"true"`              // Organization ID of the owner
-	LastIntrospectionTime        string   `json:"last_introspection_time"`             // Timestamp of last attempted introspection
-	LastIntrospectionSuccessTime string   `json:"last_success_introspection_time"`     // Timestamp of last successful introspection
-	LastIntrospectionUpdateTime  string   `json:"last_update_introspection_time"`      // Timestamp of last introspection that had updates
-	LastIntrospectionError       string   `json:"last_introspection_error"`            // Error of last attempted introspection
-	FailedIntrospectionsCount    int      `json:"failed_introspections_count"`         // Number of consecutive failed introspections
-	PackageCount                 int      `json:"package_count"`                       // Number of packages last read in the repository
-	Status                       string   `json:"status"`                              // Status of repository introspection (Valid, Invalid, Unavailable, Pending)
-	GpgKey                       string   `json:"gpg_key"`                             // GPG key for repository
-	MetadataVerification         bool     `json:"metadata_verification"`               // Verify packages
-	RepositoryUUID               string   `json:"-" swaggerignore:"true"`              // UUID of the dao.Repository
-	Snapshot                     bool     `json:"snapshot"`                            // Enable snapshotting and hosting of this repository
+	UUID                         string            `json:"uuid" readonly:"true"`                // UUID of the object
+	Name                         string            `json:"name"`                                // Name of the remote yum repository
+	URL                          string            `json:"url"`                                 // URL of the remote yum repository
+	DistributionVersions         []string          `json:"distribution_versions" example:"7,8"` // Versions to restrict client usage to
+	DistributionArch             string            `json:"distribution_arch" example:"x86_64"`  // Architecture to restrict client usage to
+	AccountID                    string            `json:"account_id" readonly:"true"`          // Account ID of the owner
+	OrgID                        string            `json:"org_id" readonly:"true"`              // Organization ID of the owner
+	LastIntrospectionTime        string            `json:"last_introspection_time"`             // Timestamp of last attempted introspection
+	LastIntrospectionSuccessTime string            `json:"last_success_introspection_time"`     // Timestamp of last successful introspection
+	LastIntrospectionUpdateTime  string            `json:"last_update_introspection_time"`      // Timestamp of last introspection that had updates
+	LastIntrospectionError       string            `json:"last_introspection_error"`            // Error of last attempted introspection
+	LastIntrospectionErrorCode   string            `json:"last_introspection_error_code"`       // Code of the error of last attempted introspection, empty when it succeeded
+	FailedIntrospectionsCount    int               `json:"failed_introspections_count"`         // Number of consecutive failed introspections
+	PackageCount                 int               `json:"package_count"`                       // Number of packages last read in the repository
+	Status                       string            `json:"status"`                              // Status of the repository rolled up from its introspection and snapshots (Valid, Invalid, Unavailable, Pending)
+	IntrospectionStatus          string            `json:"introspection_status"`                // Status of repository introspection (Valid, Invalid, Unavailable, Pending)
+	GpgKey                       string            `json:"gpg_key"`                             // GPG key for repository
+	MetadataVerification         bool              `json:"metadata_verification"`               // Verify packages
+	RepositoryUUID               string            `json:"-" swaggerignore:"true"`              // UUID of the dao.Repository
+	Snapshot                     bool              `json:"snapshot"`                            // Enable snapshotting and hosting of this repository
+	ContentType                  string            `json:"content_type" example:"binary"`       // Content of the repository (binary, source, debug)
+	LastSnapshotUUID             string            `json:"last_snapshot_uuid,omitempty"`        // UUID of the latest snapshot of the repository
+	LastSnapshotTaskUUID         string            `json:"last_snapshot_task_uuid,omitempty"`   // UUID of the latest task snapshotting the repository
+	LastSnapshotAt               string            `json:"last_snapshot_at,omitempty"`          // Timestamp of the latest snapshot of the repository
+	LastSnapshot                 *SnapshotResponse `json:"last_snapshot,omitempty"`             // Latest snapshot of the repository, returned with include=last_snapshot
+	TaskCounts                   TaskCounts        `json:"task_counts,omitempty"`               // Number of tasks of the repository by status, returned with include=task_counts
+	ProxyURL                     string            `json:"proxy_url"`                           // URL of the proxy used to reach the repository
+	ProxyUsername                string            `json:"proxy_username"`                      // Username to authenticate with the proxy
+	Username                     string            `json:"username"`                            // Username to authenticate with the repository
 }
 
+// Codes of the error of the last introspection of a repository
+const (
+	IntrospectionErrorDNSFailure       = "DNS_FAILURE"       // The host of the repository cannot be resolved
+	IntrospectionErrorTLS              = "TLS_ERROR"         // The TLS handshake with the host failed, for instance on an untrusted certificate
+	IntrospectionErrorHTTP4XX          = "HTTP_4XX"          // The host responded with a client error other than 404, for instance 401 or 403
+	IntrospectionErrorHTTP5XX          = "HTTP_5XX"          // The host responded with a server error
+	IntrospectionErrorMetadataMissing  = "METADATA_MISSING"  // repodata/repomd.xml or the primary metadata does not exist or is empty
+	IntrospectionErrorSignatureInvalid = "SIGNATURE_INVALID" // The signature of the metadata does not match the gpg key
+	IntrospectionErrorTimeout          = "TIMEOUT"           // The host did not respond in time
+	IntrospectionErrorURLDenied        = "URL_DENIED"        // The host of the url, or of a url redirected to, is denied by the url deny or allow list, or resolves to a private address
+	IntrospectionErrorUnknown          = "UNKNOWN"           // Any other error, see last_introspection_error
+)
+
 // RepositoryRequest holds data received from request to create/update repository
 type RepositoryRequest struct {
 	UUID                 *string   `json:"uuid" readonly:"true" swaggerignore:"true"`
@@ -32,8 +56,14 @@ type RepositoryRequest struct {
 	GpgKey               *string   `json:"gpg_key"`                                         // GPG key for repository
 	MetadataVerification *bool     `json:"metadata_verification"`                           // Verify packages
 	Snapshot             *bool     `json:"snapshot"`                                        // Enable snapshotting and hosting of this repository
+	ContentType          *string   `json:"content_type" example:"binary"`                   // Content of the repository (binary, source, debug), defaults to binary
 	AccountID            *string   `json:"account_id" readonly:"true" swaggerignore:"true"` // Account ID of the owner
 	OrgID                *string   `json:"org_id" readonly:"true" swaggerignore:"true"`     // Organization ID of the owner
+	ProxyURL             *string   `json:"proxy_url"`                                       // URL of the proxy used to reach the repository, when only reachable through it
+	ProxyUsername        *string   `json:"proxy_username"`                                  // Username to authenticate with the proxy
+	ProxyPassword        *string   `json:"proxy_password"`                                  // Password to authenticate with the proxy, left unchanged if omitted
+	Username             *string   `json:"username"`                                        // Username to authenticate with the repository using basic auth
+	Password             *string   `json:"password"`                                        // Password to authenticate with the repository, stored encrypted and left unchanged if omitted
 
 }
 
@@ -45,6 +75,9 @@ func (r *RepositoryRequest) FillDefaults() {
 	defaultArch := "any"
 	defaultGpgKey := ""
 	defaultMetadataVerification := false
+	defaultProxyURL := ""
+	defaultProxyUsername := ""
+	defaultUsername := ""
 	if r.Name == nil {
 		r.Name = &defaultName
 	}
@@ -63,12 +96,58 @@ func (r *RepositoryRequest) FillDefaults() {
 	if r.MetadataVerification == nil {
 		r.MetadataVerification = &defaultMetadataVerification
 	}
+	if r.ProxyURL == nil {
+		r.ProxyURL = &defaultProxyURL
+	}
+	if r.ProxyUsername == nil {
+		r.ProxyUsername = &defaultProxyUsername
+	}
+	if r.Username == nil {
+		r.Username = &defaultUsername
+	}
+}
+
+// FillOrgDefaults fills the values an org set defaults for in its settings, then the other default values
+func (r *RepositoryRequest) FillOrgDefaults(settings OrgSettingsResponse) {
+	if r.DistributionVersions == nil && len(settings.DefaultDistributionVersions) > 0 {
+		versions := settings.DefaultDistributionVersions
+		r.DistributionVersions = &versions
+	}
+	if r.DistributionArch == nil && settings.DefaultDistributionArch != "" {
+		arch := settings.DefaultDistributionArch
+		r.DistributionArch = &arch
+	}
+	if r.MetadataVerification == nil {
+		metadataVerification := settings.DefaultMetadataVerification
+		r.MetadataVerification = &metadataVerification
+	}
+	r.FillDefaults()
 }
 
 type RepositoryIntrospectRequest struct {
 	ResetCount bool `json:"reset_count"` // Reset the failed introspections count
 }
 
+// RepositoryBulkIntrospectResponse holds what was queued by introspecting the repositories of an org
+type RepositoryBulkIntrospectResponse struct {
+	UUIDs     []string `json:"uuids"`      // Identifiers of the repositories queued for introspection
+	TaskUUIDs []string `json:"task_uuids"` // Identifiers of the introspection tasks, empty when tasks are not used
+}
+
+// RepositoryBulkUpdateRequest holds the changes applied to all the listed repositories
+type RepositoryBulkUpdateRequest struct {
+	UUIDs      []string          `json:"uuids"`      // Identifiers of the repositories to update
+	Repository RepositoryRequest `json:"repository"` // Values to set on the repositories, other than their name and URL, the omitted values being left unchanged
+}
+
+// RepositoryCloneRequest holds the values changed in the copy of a cloned repository, the other values being copied
+type RepositoryCloneRequest struct {
+	Name                 *string   `json:"name"`                                // Name of the copy, defaults to the name of the repository followed by " (copy)"
+	URL                  *string   `json:"url"`                                 // URL of the copy, defaults to the URL of the repository which can only be added once per organization
+	DistributionVersions *[]string `json:"distribution_versions" example:"7,8"` // Versions to restrict client usage to, defaults to the versions of the repository
+	DistributionArch     *string   `json:"distribution_arch" example:"x86_64"`  // Architecture to restrict client usage to, defaults to the architecture of the repository
+}
+
 type RepositoryCollectionResponse struct {
 	Data  []RepositoryResponse `json:"data"`  // Requested Data
 	Meta  ResponseMetadata     `json:"meta"`  // Metadata about the request
@@ -0,0 +1,13 @@
+package api
+
+// DuplicateRepositoriesResponse is a group of repositories of the organization serving the same content
+type DuplicateRepositoriesResponse struct {
+	RepomdChecksum string                `json:"repomd_checksum"` // Checksum of the repomd.xml served by each repository at its last introspection
+	Repositories   []DuplicateRepository `json:"repositories"`    // Repositories serving that repomd.xml, which could be consolidated
+}
+
+type DuplicateRepository struct {
+	UUID string `json:"uuid"` // UUID of the repository
+	Name string `json:"name"` // Name of the repository
+	URL  string `json:"url"`  // URL of the repository
+}
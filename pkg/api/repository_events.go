@@ -0,0 +1,14 @@
+package api
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// RepositoryEventResponse is sent by the repository events stream for each change of the repositories of the organization
+type RepositoryEventResponse struct {
+	UUID      string          `json:"uuid"`       // UUID of the event
+	Type      string          `json:"type"`       // Type of the event (repository-created, repository-updated, repository-deleted, repository-introspected, repository-introspection-failure)
+	CreatedAt time.Time       `json:"created_at"` // Timestamp of the change
+	Data      json.RawMessage `json:"data"`       // Repositories changed, as sent in notifications
+}
@@ -0,0 +1,27 @@
+package api
+
+// RepositoryExportRequest holds the repositories to export
+type RepositoryExportRequest struct {
+	RepositoryUuids []string `json:"repository_uuids"` // List of repository uuids to export
+}
+
+// RepositoryExportResponse is the portable representation of a repository
+type RepositoryExportResponse struct {
+	UUID                 string   `json:"uuid" readonly:"true"`                          // UUID of the object
+	Name                 string   `json:"name"`                                          // Name of the remote yum repository
+	URL                  string   `json:"url"`                                           // URL of the remote yum repository
+	DistributionVersions []string `json:"distribution_versions" example:"7,8"`           // Versions to restrict client usage to
+	DistributionArch     string   `json:"distribution_arch" example:"x86_64"`            // Architecture to restrict client usage to
+	GpgKey               string   `json:"gpg_key"`                                       // GPG key for repository
+	MetadataVerification bool     `json:"metadata_verification"`                         // Verify packages
+	Snapshot             bool     `json:"snapshot"`                                      // Enable snapshotting and hosting of this repository
+	ContentType          string   `json:"content_type" example:"binary"`                 // Content of the repository (binary, source, debug)
+	LatestSnapshotURL    string   `json:"latest_snapshot_url,omitempty" readonly:"true"` // URL of the latest snapshot of the repository, if any
+}
+
+// RepositoryImportResponse is a repository recreated by an import, or the existing
+// repository of the org it conflicted with
+type RepositoryImportResponse struct {
+	RepositoryResponse
+	Warnings []string `json:"warnings"` // Conflicts with existing repositories, the repository was not imported if any
+}
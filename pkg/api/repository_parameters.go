@@ -14,14 +14,45 @@ type FetchGPGKeyRequest struct {
 type RepositoryParameterResponse struct {
 	DistributionVersions []config.DistributionVersion `json:"distribution_versions" ` // Versions available for repository creation
 	DistributionArches   []config.DistributionArch    `json:"distribution_arches"`    // Architectures available for repository creation
+	ContentTypes         []config.ContentType         `json:"content_types"`          // Content types available for repository creation
+}
+
+// RepositoryDefaultsResponse holds the values used for a repository created without them
+type RepositoryDefaultsResponse struct {
+	DistributionVersions []string         `json:"distribution_versions" example:"any"` // Versions of a repository created without them
+	DistributionArch     string           `json:"distribution_arch" example:"any"`     // Architecture of a repository created without one
+	MetadataVerification bool             `json:"metadata_verification"`               // Metadata verification of a repository created without it
+	ContentType          string           `json:"content_type" example:"binary"`       // Content type of a repository created without one
+	Snapshot             bool             `json:"snapshot"`                            // Whether a repository created without snapshot is snapshotted
+	SnapshotsAllowed     bool             `json:"snapshots_allowed"`                   // Whether the user can enable snapshots of repositories
+	Limits               RepositoryLimits `json:"limits"`                              // Limits of the repositories API
+}
+
+// RepositoryLimits holds the maximum number of items accepted by the repositories API
+type RepositoryLimits struct {
+	BulkCreate      int `json:"bulk_create"`       // Repositories created by a bulk create request
+	BulkDelete      int `json:"bulk_delete"`       // Repositories deleted by a bulk delete request
+	BulkExport      int `json:"bulk_export"`       // Repositories exported by a bulk export request
+	BulkImport      int `json:"bulk_import"`       // Repositories imported by a bulk import request
+	BulkUpdate      int `json:"bulk_update"`       // Repositories updated by a bulk update request
+	DefaultPageSize int `json:"default_page_size"` // Items returned by a list request without limit
+	MaxPageSize     int `json:"max_page_size"`     // Items returned by a list request at most
 }
 
 type RepositoryValidationRequest struct {
-	Name                 *string `json:"name"`                  // Name of the remote yum repository
-	URL                  *string `json:"url"`                   // URL of the remote yum repository
-	GPGKey               *string `json:"gpg_key"`               // GPGKey of the remote yum repository
-	UUID                 *string `json:"uuid"`                  // If set, this is an "Update" validation
-	MetadataVerification bool    `json:"metadata_verification"` // If set, attempt to validate the yum metadata with the specified GPG Key
+	Name                 *string  `json:"name"`                  // Name of the remote yum repository
+	URL                  *string  `json:"url"`                   // URL of the remote yum repository
+	DistributionVersions []string `json:"distribution_versions"` // Versions to expand $releasever in the url with
+	DistributionArch     string   `json:"distribution_arch"`     // Architecture to expand $basearch in the url with
+	GPGKey               *string  `json:"gpg_key"`               // GPGKey of the remote yum repository
+	UUID                 *string  `json:"uuid"`                  // If set, this is an "Update" validation
+	MetadataVerification bool     `json:"metadata_verification"` // If set, attempt to validate the yum metadata with the specified GPG Key
+	DiscoverSiblings     bool     `json:"discover_siblings"`     // If set, look for the source and debug repositories published next to the repository
+	ProxyURL             *string  `json:"proxy_url"`             // If set, reach the url through this proxy
+	ProxyUsername        *string  `json:"proxy_username"`        // Username to authenticate with the proxy
+	ProxyPassword        *string  `json:"proxy_password"`        // Password to authenticate with the proxy
+	Username             *string  `json:"username"`              // If set, authenticate with the repository using basic auth
+	Password             *string  `json:"password"`              // Password to authenticate with the repository
 }
 
 type RepositoryValidationResponse struct {
@@ -30,6 +61,26 @@ type RepositoryValidationResponse struct {
 	GPGKey GenericAttributeValidationResponse `json:"gpg_key"` // Validation response for the GPG Key
 }
 
+// Codes of the errors returned when validating the url of a repository
+const (
+	UrlErrorBlank              = "blank"                // The url is empty
+	UrlErrorDuplicate          = "duplicate"            // A repository with the url already exists
+	UrlErrorWhitespace         = "whitespace"           // The url contains whitespace
+	UrlErrorInvalidProxy       = "invalid_proxy"        // The proxy to reach the url through is not a valid url
+	UrlErrorDNSFailure         = "dns_failure"          // The host of the url cannot be resolved
+	UrlErrorTLSFailure         = "tls_failure"          // The TLS handshake with the host failed, for instance on an untrusted certificate
+	UrlErrorTimeout            = "timeout"              // The host did not respond in time
+	UrlErrorConnectionFailure  = "connection_failure"   // The connection to the host failed, for instance when it was refused
+	UrlErrorHTTPStatus         = "http_status"          // The host responded with an unexpected http status, see http_code
+	UrlErrorMetadataMissing    = "metadata_missing"     // The host responded that repodata/repomd.xml does not exist
+	UrlErrorMetadataMalformed  = "metadata_malformed"   // repodata/repomd.xml was fetched but cannot be parsed
+	UrlErrorMetadataFetchError = "metadata_fetch_error" // repodata/repomd.xml cannot be fetched for another reason
+	UrlErrorReleaseVer         = "releasever"           // The url contains $releasever but no distribution version to expand it with
+	UrlErrorBaseArch           = "basearch"             // The url contains $basearch but no distribution architecture to expand it with
+	UrlErrorUnsupportedVar     = "unsupported_variable" // The url contains a variable other than $releasever and $basearch
+	UrlErrorDenied             = "denied"               // The host of the url is in the url deny list, or not in the url allow list
+)
+
 type GenericAttributeValidationResponse struct {
 	Skipped bool   `json:"skipped"` // Skipped if the attribute is not passed in for validation
 	Valid   bool   `json:"valid"`   // Valid if not skipped and the provided attribute is valid
@@ -37,10 +88,18 @@ type GenericAttributeValidationResponse struct {
 }
 
 type UrlValidationResponse struct {
-	Skipped                  bool   `json:"skipped"`                    // Skipped if the URL is not passed in for validation
-	Valid                    bool   `json:"valid"`                      // Valid if not skipped and the provided attribute is valid
-	Error                    string `json:"error"`                      // Error message if the attribute is not valid
-	HTTPCode                 int    `json:"http_code"`                  // If the metadata cannot be fetched successfully, the http code that is returned if the http request was completed
-	MetadataPresent          bool   `json:"metadata_present"`           // True if the metadata can be fetched successfully
-	MetadataSignaturePresent bool   `json:"metadata_signature_present"` // True if a repomd.xml.sig file was found in the repository
+	Skipped                  bool                `json:"skipped"`                    // Skipped if the URL is not passed in for validation
+	Valid                    bool                `json:"valid"`                      // Valid if not skipped and the provided attribute is valid
+	Error                    string              `json:"error"`                      // Error message if the attribute is not valid
+	ErrorCode                string              `json:"error_code,omitempty"`       // Code identifying the cause of the error, if any
+	HTTPCode                 int                 `json:"http_code"`                  // If the metadata cannot be fetched successfully, the http code that is returned if the http request was completed
+	MetadataPresent          bool                `json:"metadata_present"`           // True if the metadata can be fetched successfully
+	MetadataSignaturePresent bool                `json:"metadata_signature_present"` // True if a repomd.xml.sig file was found in the repository
+	Siblings                 []SiblingRepository `json:"siblings,omitempty"`         // Source and debug repositories found next to the repository, if requested
+}
+
+// SiblingRepository is a source or debug repository published next to a binary repository
+type SiblingRepository struct {
+	URL         string `json:"url"`          // URL of the repository
+	ContentType string `json:"content_type"` // Content of the repository (source, debug)
 }
@@ -38,6 +38,65 @@ type SearchRpmResponse struct {
 	Summary     string `json:"summary"`      // Summary of the package found
 }
 
+type SearchPackageRequest struct {
+	URLs     []string `json:"urls,omitempty"`     // URLs of repositories to search
+	UUIDs    []string `json:"uuids,omitempty"`    // List of RepositoryConfig UUIDs to search
+	Nevra    string   `json:"nevra,omitempty"`    // Exact name-[epoch:]version-release.arch of the package, epoch is matched only if given
+	Provides string   `json:"provides,omitempty"` // Capability provided by the package, such as 'webserver'
+	Requires string   `json:"requires,omitempty"` // Capability required by the package
+	Limit    *int     `json:"limit,omitempty"`    // Maximum number of records to return for the search
+}
+
+type SearchPackageResponse struct {
+	Name           string `json:"name"`            // The rpm package name
+	Arch           string `json:"arch"`            // The Architecture of the rpm
+	Version        string `json:"version"`         // The version of the rpm
+	Release        string `json:"release"`         // The release of the rpm
+	Epoch          int32  `json:"epoch"`           // The epoch of the rpm
+	Summary        string `json:"summary"`         // The summary of the rpm
+	Checksum       string `json:"checksum"`        // The checksum of the rpm
+	RepositoryURL  string `json:"repository_url"`  // URL of the repository containing the rpm
+	RepositoryUUID string `json:"repository_uuid"` // Identifier of the repository configuration, empty for public repositories not added by the organization
+}
+
+type DependencyClosureRequest struct {
+	URLs     []string `json:"urls,omitempty"`  // URLs of repositories to resolve dependencies against
+	UUIDs    []string `json:"uuids,omitempty"` // List of RepositoryConfig UUIDs to resolve dependencies against
+	Packages []string `json:"packages"`        // Names of the packages, or capabilities, to install
+	Arch     string   `json:"arch,omitempty"`  // Architecture of the packages to install, noarch packages are always used
+}
+
+type DependencyClosureResponse struct {
+	Packages   []SearchPackageResponse `json:"packages"`   // Packages needed to install the requested packages
+	Unresolved []UnresolvedDependency  `json:"unresolved"` // Dependencies that no package of the repositories provides
+}
+
+type UnresolvedDependency struct {
+	Name       string   `json:"name"`        // Capability that could not be resolved
+	RequiredBy []string `json:"required_by"` // Names of the packages requiring it, empty for requested packages
+}
+
+// Comparisons of the version of a package of a repository with the one of the official repositories
+const (
+	PackageOverlapOlder = "older" // dnf installs the official package instead of the one of the repository
+	PackageOverlapNewer = "newer" // the package of the repository overrides the official one
+	PackageOverlapSame  = "same"
+)
+
+type OfficialOverlapsResponse struct {
+	OfficialRepositories []string         `json:"official_repositories"` // URLs of the Red Hat repositories matching the versions and architecture of the repository
+	Overlaps             []PackageOverlap `json:"overlaps"`              // Packages of the repository also in the official repositories
+}
+
+type PackageOverlap struct {
+	Name            string `json:"name"`             // Name of the package
+	Arch            string `json:"arch"`             // Architecture of the package
+	Version         string `json:"version"`          // Highest epoch:version-release of the package in the repository
+	OfficialVersion string `json:"official_version"` // Highest epoch:version-release of the package in the official repositories
+	OfficialURL     string `json:"official_url"`     // URL of the official repository containing that version
+	Comparison      string `json:"comparison"`       // Whether the version of the repository is older, newer or the same as the official one
+}
+
 // SetMetadata Map metadata to the collection.
 // meta Metadata about the request.
 // links Links to other pages of results.
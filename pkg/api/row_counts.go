@@ -0,0 +1,13 @@
+package api
+
+// OrgRowCountsResponse holds the number of rows an org owns in the largest tables
+type OrgRowCountsResponse struct {
+	OrgID                    string `json:"org_id"`                    // Organization ID
+	RepositoryConfigurations int64  `json:"repository_configurations"` // Number of repositories, deleted ones included
+	Snapshots                int64  `json:"snapshots"`                 // Number of snapshots
+	Tasks                    int64  `json:"tasks"`                     // Number of tasks
+}
+
+type OrgRowCountsCollectionResponse struct {
+	Data []OrgRowCountsResponse `json:"data"` // Orgs owning the most rows, by the requested table
+}
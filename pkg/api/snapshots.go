@@ -3,10 +3,14 @@ package api
 import "time"
 
 type SnapshotResponse struct {
-	CreatedAt      time.Time        `json:"created_at"`      // Datetime the snapshot was created
-	RepositoryPath string           `json:"repository_path"` // Path to repository snapshot contents
-	ContentCounts  map[string]int64 `json:"content_counts"`  // Count of each content type
-
+	UUID              string           `json:"uuid"`                         // Identifier of the snapshot
+	CreatedAt         time.Time        `json:"created_at"`                   // Datetime the snapshot was created
+	RepositoryPath    string           `json:"repository_path"`              // Path to repository snapshot contents
+	URL               string           `json:"url"`                          // URL the snapshot contents are served from
+	ContentCounts     map[string]int64 `json:"content_counts"`               // Count of each content type
+	SizeBytes         int64            `json:"size_bytes"`                   // Bytes stored for the artifacts of the snapshot
+	VerificationError string           `json:"verification_error,omitempty"` // Why the last verification found the snapshot corrupted
+	VerifiedAt        *time.Time       `json:"verified_at,omitempty"`        // Datetime of the last verification of the snapshot
 }
 
 type SnapshotCollectionResponse struct {
@@ -19,3 +23,31 @@ func (r *SnapshotCollectionResponse) SetMetadata(meta ResponseMetadata, links Li
 	r.Meta = meta
 	r.Links = links
 }
+
+type ListSnapshotByDateRequest struct {
+	RepositoryUUIDS []string  `json:"repository_uuids"` // Repository UUIDs to find snapshots for
+	Date            time.Time `json:"date"`             // Find the latest snapshot taken at or before this time
+}
+
+type SnapshotForDate struct {
+	RepositoryUUID string            `json:"repository_uuid"` // Repository uuid for associated snapshot
+	IsExactMatch   bool              `json:"is_exact_match"`  // Whether the snapshot was taken on the day of the requested time
+	Match          *SnapshotResponse `json:"match,omitempty"` // Latest snapshot taken on or before the date, empty if there is none
+}
+
+type ListSnapshotByDateResponse struct {
+	Data []SnapshotForDate `json:"data"` // Requested Data
+}
+
+type SnapshotStorageResponse struct {
+	SizeBytes    int64               `json:"size_bytes"`   // Estimated bytes stored for the snapshots of the organization
+	Snapshots    int64               `json:"snapshots"`    // Number of snapshots of the organization
+	Repositories []RepositoryStorage `json:"repositories"` // Storage used by each snapshotted repository, largest first
+}
+
+type RepositoryStorage struct {
+	UUID      string `json:"uuid"`       // Identifier of the repository
+	Name      string `json:"name"`       // Name of the repository
+	SizeBytes int64  `json:"size_bytes"` // Estimated bytes stored for the snapshots of the repository
+	Snapshots int64  `json:"snapshots"`  // Number of snapshots of the repository
+}
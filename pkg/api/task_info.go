@@ -1,13 +1,27 @@
 package api
 
+// TaskCounts is the number of tasks by status
+type TaskCounts map[string]int64
+
 // TaskInfoResponse holds data returned by a tasks API response
 type TaskInfoResponse struct {
 	UUID      string `json:"uuid"`       // UUID of the object
-	Status    string `json:"status"`     // Status of task (running, failed, completed, canceled, pending)
+	Status    string `json:"status"`     // Status of task (running, failed, completed, canceled, pending, dead)
 	CreatedAt string `json:"created_at"` // Timestamp of task creation
 	EndedAt   string `json:"ended_at"`   // Timestamp task ended running at
 	Error     string `json:"error"`      // Error thrown while running task
 	OrgId     string `json:"org_id"`     // Organization ID of the owner
+	RequestId string `json:"request_id"` // ID of the request that enqueued the task
+	Username  string `json:"username"`   // Username of the user who triggered the task, empty for background tasks
+}
+
+// TaskEventResponse is sent by the events stream of a task when its status or progress changes
+type TaskEventResponse struct {
+	UUID     string `json:"uuid"`               // UUID of the task
+	Status   string `json:"status"`             // Status of task (running, failed, completed, canceled, pending, dead)
+	Error    string `json:"error,omitempty"`    // Error thrown while running task
+	Step     string `json:"step,omitempty"`     // Step of a running snapshot (sync, publication, distribution)
+	Progress *int   `json:"progress,omitempty"` // Percentage of the packages synced by a running snapshot, when known
 }
 
 type TaskInfoCollectionResponse struct {
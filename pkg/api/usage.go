@@ -0,0 +1,27 @@
+package api
+
+// UsageResponse holds the number of requests an org made to a route on a day
+type UsageResponse struct {
+	OrgID        string `json:"org_id"`        // Organization ID of the caller
+	Method       string `json:"method"`        // HTTP method of the route
+	Route        string `json:"route"`         // Route path, with parameters not substituted
+	Day          string `json:"day"`           // Day of the requests (YYYY-MM-DD)
+	RequestCount int64  `json:"request_count"` // Number of requests
+}
+
+type UsageCollectionResponse struct {
+	Data  []UsageResponse  `json:"data"`  // Requested Data
+	Meta  ResponseMetadata `json:"meta"`  // Metadata about the request
+	Links Links            `json:"links"` // Links to other pages of results
+}
+
+func (r *UsageCollectionResponse) SetMetadata(meta ResponseMetadata, links Links) {
+	r.Meta = meta
+	r.Links = links
+}
+
+type UsageFilterData struct {
+	OrgID string `json:"org_id"` // Only return usage of this org
+	Route string `json:"route"`  // Only return usage of this route
+	Since string `json:"since"`  // Only return usage on or after this day (YYYY-MM-DD)
+}
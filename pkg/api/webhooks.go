@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Events sent to webhooks
+const (
+	WebhookEventIntrospectionFailure = "repository-introspection-failure"
+	WebhookEventSnapshotCompleted    = "repository-snapshot-completed"
+	WebhookEventRepositoryDeleted    = "repository-deleted"
+)
+
+var WebhookEventTypes = []string{
+	WebhookEventIntrospectionFailure,
+	WebhookEventSnapshotCompleted,
+	WebhookEventRepositoryDeleted,
+}
+
+// WebhookRequest holds data received from request to create a webhook
+type WebhookRequest struct {
+	URL        *string   `json:"url"`                                         // URL receiving the events
+	Secret     *string   `json:"secret"`                                      // Secret signing the events, generated if not set
+	EventTypes *[]string `json:"event_types"`                                 // Events sent to the url, all events if empty
+	OrgID      *string   `json:"org_id" readonly:"true" swaggerignore:"true"` // Organization ID of the owner
+}
+
+type WebhookResponse struct {
+	UUID       string    `json:"uuid"`             // Identifier of the webhook
+	URL        string    `json:"url"`              // URL receiving the events
+	EventTypes []string  `json:"event_types"`      // Events sent to the url, all events if empty
+	Enabled    bool      `json:"enabled"`          // Whether events are sent to the url
+	CreatedAt  time.Time `json:"created_at"`       // Datetime the webhook was created
+	Secret     string    `json:"secret,omitempty"` // Secret signing the events, only returned on creation
+}
+
+type WebhookCollectionResponse struct {
+	Data  []WebhookResponse `json:"data"`  // Requested Data
+	Meta  ResponseMetadata  `json:"meta"`  // Metadata about the request
+	Links Links             `json:"links"` // Links to other pages of results
+}
+
+func (r *WebhookCollectionResponse) SetMetadata(meta ResponseMetadata, links Links) {
+	r.Meta = meta
+	r.Links = links
+}
+
+type WebhookDeliveryResponse struct {
+	UUID           string     `json:"uuid"`                      // Identifier of the delivery, sent in the id of the event
+	EventType      string     `json:"event_type"`                // Event delivered
+	Status         string     `json:"status"`                    // Status of the delivery (pending, delivered, failed)
+	Attempts       int        `json:"attempts"`                  // Number of attempts to deliver the event
+	CreatedAt      time.Time  `json:"created_at"`                // Datetime the event occurred
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"` // Datetime of the next attempt of a pending delivery
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`    // Datetime the event was delivered
+	ResponseStatus *int       `json:"response_status,omitempty"` // HTTP status of the response to the last attempt
+	LastError      string     `json:"last_error,omitempty"`      // Error of the last attempt
+}
+
+type WebhookDeliveryCollectionResponse struct {
+	Data  []WebhookDeliveryResponse `json:"data"`  // Requested Data
+	Meta  ResponseMetadata          `json:"meta"`  // Metadata about the request
+	Links Links                     `json:"links"` // Links to other pages of results
+}
+
+func (r *WebhookDeliveryCollectionResponse) SetMetadata(meta ResponseMetadata, links Links) {
+	r.Meta = meta
+	r.Links = links
+}
+
+// WebhookEvent is the body posted to webhooks
+type WebhookEvent struct {
+	ID        string          `json:"id"`         // Identifier of the delivery, identical on retries
+	Event     string          `json:"event"`      // Event type
+	OrgID     string          `json:"org_id"`     // Organization ID of the repository
+	CreatedAt time.Time       `json:"created_at"` // Datetime the event occurred
+	Data      json.RawMessage `json:"data"`       // WebhookEventData of the event
+}
+
+// WebhookEventData describes the repository of an event
+type WebhookEventData struct {
+	Repository WebhookRepository `json:"repository"`         // Repository of the event
+	Snapshot   *SnapshotResponse `json:"snapshot,omitempty"` // Snapshot completed, for repository-snapshot-completed events
+}
+
+type WebhookRepository struct {
+	UUID                       string `json:"uuid"`                                    // Identifier of the repository
+	Name                       string `json:"name"`                                    // Name of the repository
+	URL                        string `json:"url"`                                     // URL of the repository
+	LastIntrospectionError     string `json:"last_introspection_error,omitempty"`      // Error of the failed introspection
+	LastIntrospectionErrorCode string `json:"last_introspection_error_code,omitempty"` // Code of the error of the failed introspection
+}
+
+// NewWebhookRepository returns the repository of an event
+func NewWebhookRepository(repo RepositoryResponse) WebhookRepository {
+	return WebhookRepository{
+		UUID:                       repo.UUID,
+		Name:                       repo.Name,
+		URL:                        repo.URL,
+		LastIntrospectionError:     repo.LastIntrospectionError,
+		LastIntrospectionErrorCode: repo.LastIntrospectionErrorCode,
+	}
+}
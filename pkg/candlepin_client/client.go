@@ -0,0 +1,157 @@
+package candlepin_client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/rs/zerolog"
+)
+
+// entitlementCertificatePath is the content access certificate of an owner, candlepin names
+// owners after the org id
+const entitlementCertificatePath = "/candlepin/owners/%s/content_access_certificate"
+
+// renewBefore is how long before it expires a cached certificate is fetched again
+const renewBefore = 24 * time.Hour
+
+// EntitlementCertificate is a certificate and its key, both PEM encoded, giving an org access to
+// the content of the Red Hat CDN it is entitled to
+type EntitlementCertificate struct {
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+}
+
+// TLSCertificate returns the certificate to authenticate with the Red Hat CDN
+func (e EntitlementCertificate) TLSCertificate() (*tls.Certificate, error) {
+	cert, err := tls.X509KeyPair([]byte(e.Cert), []byte(e.Key))
+	if err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// expiration returns when the first certificate of the chain expires
+func (e EntitlementCertificate) expiration() (time.Time, error) {
+	cert, err := e.TLSCertificate()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(cert.Certificate) == 0 {
+		return time.Time{}, errors.New("no certificate found")
+	}
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return time.Time{}, err
+	}
+	return parsed.NotAfter, nil
+}
+
+type cachedCertificate struct {
+	certificate EntitlementCertificate
+	expiration  time.Time
+}
+
+// certificates caches the certificates fetched by org id, they are valid for months and
+// introspections and snapshots would otherwise fetch one for each repository
+var certificates = struct {
+	sync.Mutex
+	byOrg map[string]cachedCertificate
+}{byOrg: map[string]cachedCertificate{}}
+
+type candlepinImpl struct {
+	client *http.Client
+	ctx    context.Context
+	server string
+}
+
+// GetCandlepinClient returns a client of the configured candlepin server.  The client certificate and
+// authority are checked by Configuration.Validate, invalid ones are logged and left out.
+func GetCandlepinClient(ctx context.Context) CandlepinClient {
+	ctx = config.WithLogModule(ctx, "candlepin_client")
+	timeout := 60 * time.Second
+	transport := &http.Transport{ResponseHeaderTimeout: timeout}
+	candlepinConfig := config.Get().Clients.Candlepin
+	if candlepinConfig.ClientCert != "" || candlepinConfig.CACert != "" {
+		tlsConfig := &tls.Config{}
+		if candlepinConfig.ClientCert != "" {
+			cert, err := tls.X509KeyPair([]byte(candlepinConfig.ClientCert), []byte(candlepinConfig.ClientKey))
+			if err != nil {
+				zerolog.Ctx(ctx).Error().Err(err).Msg("invalid candlepin client certificate, connecting without it")
+			} else {
+				tlsConfig.Certificates = []tls.Certificate{cert}
+			}
+		}
+		if candlepinConfig.CACert != "" {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM([]byte(candlepinConfig.CACert)) {
+				zerolog.Ctx(ctx).Error().Msg("no certificate found in the candlepin ca certificate")
+			}
+			tlsConfig.RootCAs = pool
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	return &candlepinImpl{
+		client: &http.Client{Transport: transport, Timeout: timeout},
+		ctx:    ctx,
+		server: strings.TrimSuffix(candlepinConfig.Server, "/"),
+	}
+}
+
+// FetchEntitlementCertificate returns the certificate of the org, cached until a day before it expires
+func (c *candlepinImpl) FetchEntitlementCertificate(orgID string) (*EntitlementCertificate, error) {
+	certificates.Lock()
+	cached, ok := certificates.byOrg[orgID]
+	certificates.Unlock()
+	if ok && time.Now().Add(renewBefore).Before(cached.expiration) {
+		return &cached.certificate, nil
+	}
+
+	certificate, err := c.fetchEntitlementCertificate(orgID)
+	if err != nil {
+		return nil, err
+	}
+	expiration, err := certificate.expiration()
+	if err != nil {
+		return nil, fmt.Errorf("invalid entitlement certificate for org %v: %w", orgID, err)
+	}
+	certificates.Lock()
+	certificates.byOrg[orgID] = cachedCertificate{certificate: *certificate, expiration: expiration}
+	certificates.Unlock()
+	return certificate, nil
+}
+
+func (c *candlepinImpl) fetchEntitlementCertificate(orgID string) (*EntitlementCertificate, error) {
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet,
+		c.server+fmt.Sprintf(entitlementCertificatePath, url.PathEscape(orgID)), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("candlepin returned %v fetching the entitlement certificate of org %v", resp.StatusCode, orgID)
+	}
+
+	certificate := EntitlementCertificate{}
+	if err = json.NewDecoder(resp.Body).Decode(&certificate); err != nil {
+		return nil, err
+	}
+	if certificate.Cert == "" || certificate.Key == "" {
+		return nil, fmt.Errorf("candlepin returned no entitlement certificate for org %v", orgID)
+	}
+	return &certificate, nil
+}
@@ -0,0 +1,92 @@
+package candlepin_client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mockCertData = "../config/test_files/cert.crt"
+
+func TestFetchEntitlementCertificate(t *testing.T) {
+	pair, err := os.ReadFile(mockCertData)
+	require.NoError(t, err)
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		switch r.URL.Path {
+		case "/candlepin/owners/entitled/content_access_certificate":
+			_ = json.NewEncoder(w).Encode(EntitlementCertificate{Cert: string(pair), Key: string(pair)})
+		case "/candlepin/owners/empty/content_access_certificate":
+			_ = json.NewEncoder(w).Encode(EntitlementCertificate{})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	previous := config.Get().Clients.Candlepin
+	defer func() { config.Get().Clients.Candlepin = previous }()
+	config.Get().Clients.Candlepin = config.Candlepin{Server: server.URL + "/"}
+
+	client := GetCandlepinClient(context.Background())
+	certificate, err := client.FetchEntitlementCertificate("entitled")
+	require.NoError(t, err)
+	tlsCert, err := certificate.TLSCertificate()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tlsCert.Certificate)
+
+	// The certificate is cached until it nearly expires
+	_, err = client.FetchEntitlementCertificate("entitled")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	_, err = client.FetchEntitlementCertificate("empty")
+	assert.Error(t, err)
+
+	_, err = client.FetchEntitlementCertificate("unknown")
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestCreateOwner(t *testing.T) {
+	var created []owner
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/candlepin/owners/existing":
+			_ = json.NewEncoder(w).Encode(owner{Key: "existing"})
+		case r.Method == http.MethodGet && r.URL.Path == "/candlepin/owners/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPost && r.URL.Path == "/candlepin/owners":
+			o := owner{}
+			_ = json.NewDecoder(r.Body).Decode(&o)
+			created = append(created, o)
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	previous := config.Get().Clients.Candlepin
+	defer func() { config.Get().Clients.Candlepin = previous }()
+	config.Get().Clients.Candlepin = config.Candlepin{Server: server.URL}
+
+	client := GetCandlepinClient(context.Background())
+	assert.NoError(t, client.CreateOwner("existing"))
+	assert.Empty(t, created)
+
+	assert.NoError(t, client.CreateOwner("new"))
+	require.Len(t, created, 1)
+	assert.Equal(t, "new", created[0].Key)
+
+	assert.ErrorContains(t, client.CreateOwner("broken"), "500")
+}
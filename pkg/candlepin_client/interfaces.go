@@ -0,0 +1,10 @@
+package candlepin_client
+
+//go:generate mockery  --name CandlepinClient --filename candlepin_client_mock.go --inpackage
+type CandlepinClient interface {
+	// Certificates
+	FetchEntitlementCertificate(orgID string) (*EntitlementCertificate, error)
+
+	// Owners
+	CreateOwner(orgID string) error
+}
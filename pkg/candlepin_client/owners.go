@@ -0,0 +1,61 @@
+package candlepin_client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const (
+	ownersPath = "/candlepin/owners"
+	ownerPath  = "/candlepin/owners/%s"
+)
+
+// owner is the candlepin owner of an org, keyed by the org id
+type owner struct {
+	Key               string `json:"key"`
+	DisplayName       string `json:"displayName"`
+	ContentAccessMode string `json:"contentAccessMode,omitempty"`
+}
+
+// CreateOwner creates the candlepin owner of the org, unless it already exists
+func (c *candlepinImpl) CreateOwner(orgID string) error {
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, c.server+fmt.Sprintf(ownerPath, url.PathEscape(orgID)), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("candlepin returned %v fetching the owner of org %v", resp.StatusCode, orgID)
+	}
+
+	body, err := json.Marshal(owner{Key: orgID, DisplayName: orgID, ContentAccessMode: "org_environment"})
+	if err != nil {
+		return err
+	}
+	req, err = http.NewRequestWithContext(c.ctx, http.MethodPost, c.server+ownersPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	resp, err = c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("candlepin returned %v creating the owner of org %v", resp.StatusCode, orgID)
+	}
+	return nil
+}
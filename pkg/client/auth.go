@@ -0,0 +1,76 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/redhatinsights/platform-go-middlewares/identity"
+)
+
+// Headers of the pre shared key authentication, as read by pkg/middleware.EnforcePSK
+const (
+	HeaderPSK   = "X-Rh-Content-Sources-Psk"
+	HeaderOrgID = "X-Rh-Content-Sources-Org-Id"
+)
+
+// Auth authenticates the requests of a Client
+type Auth interface {
+	apply(req *http.Request)
+	// rootPath is the path of the api, relative to the app path, the requests are authenticated on
+	rootPath() string
+}
+
+type identityAuth struct {
+	encoded string
+}
+
+func (a identityAuth) apply(req *http.Request) {
+	req.Header.Set(api.IdentityHeader, a.encoded)
+}
+
+func (a identityAuth) rootPath() string {
+	return ""
+}
+
+// IdentityAuth authenticates requests on the public api with an identity of the org
+func IdentityAuth(orgID string, accountNumber string) Auth {
+	id := identity.XRHID{
+		Identity: identity.Identity{
+			AccountNumber: accountNumber,
+			OrgID:         orgID,
+			Internal:      identity.Internal{OrgID: orgID},
+			Type:          "Associate",
+		},
+	}
+	// Marshalling a struct of strings does not fail
+	jsonIdentity, _ := json.Marshal(id)
+	return identityAuth{encoded: base64.StdEncoding.EncodeToString(jsonIdentity)}
+}
+
+// EncodedIdentityAuth authenticates requests on the public api with an identity header as received,
+// to forward the identity of a request handled by the calling service
+func EncodedIdentityAuth(encoded string) Auth {
+	return identityAuth{encoded: encoded}
+}
+
+type pskAuth struct {
+	psk   string
+	orgID string
+}
+
+func (a pskAuth) apply(req *http.Request) {
+	req.Header.Set(HeaderPSK, a.psk)
+	req.Header.Set(HeaderOrgID, a.orgID)
+}
+
+func (a pskAuth) rootPath() string {
+	return "internal"
+}
+
+// PSKAuth authenticates requests on the internal api with a pre shared key, on behalf of the org.
+// Only the endpoints of the internal api, such as BulkExportRepositories, accept it.
+func PSKAuth(psk string, orgID string) Auth {
+	return pskAuth{psk: psk, orgID: orgID}
+}
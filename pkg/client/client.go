@@ -0,0 +1,220 @@
+// Package client is a Go client of the content-sources api, for other services to import.
+//
+// Usage example:
+//
+//	c, err := client.NewClient(client.Config{
+//		BaseURL: "https://console.redhat.com",
+//		Auth:    client.IdentityAuth("12345", "0000"),
+//	})
+//	repos := c.Repositories(api.FilterData{Arch: "x86_64"})
+//	for repos.Next(ctx) {
+//		fmt.Println(repos.Value().Name)
+//	}
+//	if err := repos.Err(); err != nil {
+//		...
+//	}
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+)
+
+const (
+	DefaultAppPath    = "/api/content-sources" // Path the api is served under, without the version
+	DefaultTimeout    = 30 * time.Second       // Timeout of each attempt of a request
+	DefaultMaxRetries = 3                      // Retries of idempotent requests failing with a transient error
+	DefaultRetryWait  = 500 * time.Millisecond // Wait before the first retry, doubled on each retry
+	apiVersionMajor   = "v1"
+)
+
+// Config configures a Client, only BaseURL and Auth are required
+type Config struct {
+	BaseURL    string        // Scheme and host of the api, e.g. https://console.redhat.com
+	AppPath    string        // Path the api is served under, DefaultAppPath if empty
+	Auth       Auth          // Authentication of the requests
+	HTTPClient *http.Client  // Client sending the requests, one with DefaultTimeout if nil
+	MaxRetries *int          // Retries of idempotent requests, DefaultMaxRetries if nil
+	RetryWait  time.Duration // Wait before the first retry, DefaultRetryWait if zero
+}
+
+// Client calls the content-sources api on behalf of an org
+type Client struct {
+	baseURL    *url.URL
+	auth       Auth
+	httpClient *http.Client
+	maxRetries int
+	retryWait  time.Duration
+}
+
+// Error is returned for a request the api answered with an error status
+type Error struct {
+	StatusCode int               // HTTP status of the response
+	Errors     []ce.HandlerError // Errors of the response, empty if its body could not be parsed
+}
+
+func (e *Error) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("content-sources api returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("content-sources api returned status %d: %s", e.StatusCode, ce.ErrorResponse{Errors: e.Errors}.Error())
+}
+
+// NewClient returns a client of the api described by config
+func NewClient(config Config) (*Client, error) {
+	if config.BaseURL == "" {
+		return nil, fmt.Errorf("BaseURL cannot be empty")
+	}
+	if config.Auth == nil {
+		return nil, fmt.Errorf("Auth cannot be nil")
+	}
+	baseURL, err := url.Parse(config.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid BaseURL: %w", err)
+	}
+	appPath := config.AppPath
+	if appPath == "" {
+		appPath = DefaultAppPath
+	}
+	baseURL.Path = path.Join("/", baseURL.Path, appPath, config.Auth.rootPath(), apiVersionMajor)
+
+	c := &Client{
+		baseURL:    baseURL,
+		auth:       config.Auth,
+		httpClient: config.HTTPClient,
+		maxRetries: DefaultMaxRetries,
+		retryWait:  config.RetryWait,
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	if config.MaxRetries != nil {
+		c.maxRetries = *config.MaxRetries
+	}
+	if c.retryWait == 0 {
+		c.retryWait = DefaultRetryWait
+	}
+	return c, nil
+}
+
+// do sends a request to the path of the api, with body marshalled as json if not nil, and unmarshals
+// the response into out if not nil.  Idempotent requests are retried on transient errors.
+func (c *Client) do(ctx context.Context, method string, apiPath string, query url.Values, body interface{}, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("error marshalling request: %w", err)
+		}
+	}
+	reqURL := *c.baseURL
+	reqURL.Path = path.Join(reqURL.Path, apiPath)
+	if len(apiPath) > 0 && apiPath[len(apiPath)-1] == '/' {
+		reqURL.Path += "/"
+	}
+	reqURL.RawQuery = query.Encode()
+
+	wait := c.retryWait
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, reqURL.String(), payload)
+		if err == nil && resp.StatusCode < http.StatusBadRequest {
+			defer resp.Body.Close()
+			if out == nil || resp.StatusCode == http.StatusNoContent {
+				return nil
+			}
+			if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+				return fmt.Errorf("error decoding response: %w", err)
+			}
+			return nil
+		}
+		if err == nil {
+			err = responseError(resp)
+		}
+		if attempt >= c.maxRetries || !idempotent(method) || !transient(err) {
+			return err
+		}
+		if retryAfter := retryAfter(resp); retryAfter > wait {
+			wait = retryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+func (c *Client) send(ctx context.Context, method string, reqURL string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.auth.apply(req)
+	return c.httpClient.Do(req)
+}
+
+// responseError reads the errors of a response with an error status, and closes its body
+func responseError(resp *http.Response) error {
+	defer resp.Body.Close()
+	apiErr := &Error{StatusCode: resp.StatusCode}
+	var errResponse ce.ErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&errResponse); err == nil {
+		apiErr.Errors = errResponse.Errors
+	}
+	return apiErr
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// transient returns whether a request failing with err may succeed when retried
+func transient(err error) bool {
+	apiErr, ok := err.(*Error)
+	if !ok {
+		// The request could not be sent or the connection failed
+		return true
+	}
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryAfter returns the delay asked by the Retry-After header of resp, if any
+func retryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/content-services/content-sources-backend/pkg/middleware"
+	"github.com/openlyinc/pointy"
+	"github.com/redhatinsights/platform-go-middlewares/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testUUID = "6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01"
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, auth Auth) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	c, err := NewClient(Config{BaseURL: server.URL, Auth: auth, RetryWait: 1})
+	require.NoError(t, err)
+	return c
+}
+
+func TestNewClient(t *testing.T) {
+	_, err := NewClient(Config{Auth: IdentityAuth("1", "2")})
+	assert.Error(t, err)
+	_, err = NewClient(Config{BaseURL: "https://example.test"})
+	assert.Error(t, err)
+
+	c, err := NewClient(Config{BaseURL: "https://example.test", Auth: IdentityAuth("1", "2")})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.test/api/content-sources/v1", c.baseURL.String())
+
+	c, err = NewClient(Config{BaseURL: "https://example.test/", Auth: PSKAuth("key", "1")})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.test/api/content-sources/internal/v1", c.baseURL.String())
+}
+
+func TestPSKHeaders(t *testing.T) {
+	assert.Equal(t, middleware.HeaderPSK, HeaderPSK)
+	assert.Equal(t, middleware.HeaderOrgID, HeaderOrgID)
+}
+
+func TestIdentityAuth(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/content-sources/v1/repositories/"+testUUID, r.URL.Path)
+		decoded, err := base64.StdEncoding.DecodeString(r.Header.Get(api.IdentityHeader))
+		require.NoError(t, err)
+		var id identity.XRHID
+		require.NoError(t, json.Unmarshal(decoded, &id))
+		assert.Equal(t, "12345", id.Identity.Internal.OrgID)
+		assert.Equal(t, "0000", id.Identity.AccountNumber)
+		_ = json.NewEncoder(w).Encode(api.RepositoryResponse{UUID: testUUID, Name: "repo"})
+	}, IdentityAuth("12345", "0000"))
+
+	repo, err := c.FetchRepository(context.Background(), testUUID)
+	require.NoError(t, err)
+	assert.Equal(t, "repo", repo.Name)
+}
+
+func TestPSKAuth(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/content-sources/internal/v1/repositories/bulk_export/", r.URL.Path)
+		assert.Equal(t, "key", r.Header.Get(HeaderPSK))
+		assert.Equal(t, "12345", r.Header.Get(HeaderOrgID))
+		var request api.RepositoryExportRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, []string{testUUID}, request.RepositoryUuids)
+		_ = json.NewEncoder(w).Encode([]api.RepositoryExportResponse{{UUID: testUUID}})
+	}, PSKAuth("key", "12345"))
+
+	exported, err := c.BulkExportRepositories(context.Background(), []string{testUUID})
+	require.NoError(t, err)
+	require.Len(t, exported, 1)
+	assert.Equal(t, testUUID, exported[0].UUID)
+}
+
+func TestErrorResponse(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ce.NewErrorResponse(http.StatusNotFound, "Error fetching repository", "not found"))
+	}, IdentityAuth("1", "2"))
+
+	_, err := c.FetchRepository(context.Background(), testUUID)
+	require.Error(t, err)
+	apiErr, ok := err.(*Error)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	require.Len(t, apiErr.Errors, 1)
+	assert.Equal(t, "not found", apiErr.Errors[0].Detail)
+}
+
+func TestRetries(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}, IdentityAuth("1", "2"))
+
+	// Idempotent requests are retried on transient errors
+	require.NoError(t, c.DeleteRepository(context.Background(), testUUID))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// Other requests are not
+	atomic.StoreInt32(&calls, 0)
+	_, err := c.CreateRepository(context.Background(), api.RepositoryRequest{Name: pointy.String("repo")})
+	require.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// Nor are errors of the request
+	c = newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}, IdentityAuth("1", "2"))
+	atomic.StoreInt32(&calls, 0)
+	require.Error(t, c.DeleteRepository(context.Background(), testUUID))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestRepositoriesIterator(t *testing.T) {
+	total := DefaultPageSize + 5
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "x86_64", r.URL.Query().Get("arch"))
+		assert.Equal(t, "false", r.URL.Query().Get("count"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		response := api.RepositoryCollectionResponse{Data: []api.RepositoryResponse{}}
+		for i := offset; i < total && i < offset+limit; i++ {
+			response.Data = append(response.Data, api.RepositoryResponse{Name: fmt.Sprintf("repo-%d", i)})
+		}
+		if offset+limit < total {
+			response.Links.Next = "next"
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	}, IdentityAuth("1", "2"))
+
+	names := []string{}
+	repos := c.Repositories(api.FilterData{Arch: "x86_64"})
+	for repos.Next(context.Background()) {
+		names = append(names, repos.Value().Name)
+	}
+	require.NoError(t, repos.Err())
+	require.Len(t, names, total)
+	assert.Equal(t, "repo-0", names[0])
+	assert.Equal(t, fmt.Sprintf("repo-%d", total-1), names[total-1])
+}
+
+func TestIteratorError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}, IdentityAuth("1", "2"))
+
+	snapshots := c.Snapshots(testUUID)
+	assert.False(t, snapshots.Next(context.Background()))
+	require.Error(t, snapshots.Err())
+	assert.Equal(t, http.StatusForbidden, snapshots.Err().(*Error).StatusCode)
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+)
+
+// DefaultPageSize is the number of items an Iterator fetches per request
+const DefaultPageSize = 100
+
+// pageFunc fetches the page of a collection starting at offset
+type pageFunc[T any] func(ctx context.Context, page api.PaginationData) ([]T, api.Links, error)
+
+// Iterator goes through all the items of a collection, fetching a page at a time:
+//
+//	for it.Next(ctx) {
+//		item := it.Value()
+//	}
+//	err := it.Err()
+type Iterator[T any] struct {
+	fetch  pageFunc[T]
+	page   api.PaginationData
+	items  []T
+	index  int
+	last   bool
+	err    error
+	loaded bool
+}
+
+func newIterator[T any](fetch pageFunc[T]) *Iterator[T] {
+	// The total is not needed to iterate, so it is not counted
+	return &Iterator[T]{fetch: fetch, page: api.PaginationData{Limit: DefaultPageSize, SkipCount: true}}
+}
+
+// Next advances to the next item, fetching the next page when needed.  It returns false
+// once all items were seen or an error occurred, see Err.
+func (it *Iterator[T]) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	if it.loaded && it.index+1 < len(it.items) {
+		it.index++
+		return true
+	}
+	if it.loaded && it.last {
+		return false
+	}
+	items, links, err := it.fetch(ctx, it.page)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.loaded = true
+	it.items = items
+	it.index = 0
+	it.page.Offset += len(items)
+	it.last = links.Next == "" || len(items) < it.page.Limit
+	return len(items) > 0
+}
+
+// Value returns the current item
+func (it *Iterator[T]) Value() T {
+	return it.items[it.index]
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
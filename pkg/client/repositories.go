@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+)
+
+// pageQuery returns the query parameters of a page of a collection
+func pageQuery(page api.PaginationData) url.Values {
+	query := url.Values{}
+	if page.Limit > 0 {
+		query.Set("limit", strconv.Itoa(page.Limit))
+	}
+	if page.Offset > 0 {
+		query.Set("offset", strconv.Itoa(page.Offset))
+	}
+	if page.SortBy != "" {
+		query.Set("sort_by", page.SortBy)
+	}
+	if page.SkipCount {
+		query.Set("count", "false")
+	}
+	return query
+}
+
+// filterQuery adds the non-empty filters to query
+func filterQuery(query url.Values, filter api.FilterData) url.Values {
+	filters := map[string]string{
+		"search":                filter.Search,
+		"arch":                  filter.Arch,
+		"version":               filter.Version,
+		"available_for_arch":    filter.AvailableForArch,
+		"available_for_version": filter.AvailableForVersion,
+		"name":                  filter.Name,
+		"url":                   filter.URL,
+		"status":                filter.Status,
+		"content_type":          filter.ContentType,
+	}
+	for key, value := range filters {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	return query
+}
+
+// ListRepositories returns a page of the repositories of the org matching filter
+func (c *Client) ListRepositories(ctx context.Context, page api.PaginationData, filter api.FilterData) (api.RepositoryCollectionResponse, error) {
+	var response api.RepositoryCollectionResponse
+	err := c.do(ctx, http.MethodGet, "/repositories/", filterQuery(pageQuery(page), filter), nil, &response)
+	return response, err
+}
+
+// Repositories iterates over all the repositories of the org matching filter
+func (c *Client) Repositories(filter api.FilterData) *Iterator[api.RepositoryResponse] {
+	return newIterator(func(ctx context.Context, page api.PaginationData) ([]api.RepositoryResponse, api.Links, error) {
+		response, err := c.ListRepositories(ctx, page, filter)
+		return response.Data, response.Links, err
+	})
+}
+
+// FetchRepository returns the repository of the org with the given uuid
+func (c *Client) FetchRepository(ctx context.Context, uuid string) (api.RepositoryResponse, error) {
+	var response api.RepositoryResponse
+	err := c.do(ctx, http.MethodGet, "/repositories/"+url.PathEscape(uuid), nil, nil, &response)
+	return response, err
+}
+
+// CreateRepository creates a repository in the org.  It is not retried, as it is not idempotent.
+func (c *Client) CreateRepository(ctx context.Context, request api.RepositoryRequest) (api.RepositoryResponse, error) {
+	var response api.RepositoryResponse
+	err := c.do(ctx, http.MethodPost, "/repositories/", nil, request, &response)
+	return response, err
+}
+
+// DeleteRepository deletes the repository of the org with the given uuid
+func (c *Client) DeleteRepository(ctx context.Context, uuid string) error {
+	return c.do(ctx, http.MethodDelete, "/repositories/"+url.PathEscape(uuid), nil, nil, nil)
+}
+
+// BulkExportRepositories returns the portable representation of the repositories with the given uuids,
+// including the url of their latest snapshot.  It is served by both the public and the internal api.
+func (c *Client) BulkExportRepositories(ctx context.Context, uuids []string) ([]api.RepositoryExportResponse, error) {
+	var response []api.RepositoryExportResponse
+	err := c.do(ctx, http.MethodPost, "/repositories/bulk_export/", nil, api.RepositoryExportRequest{RepositoryUuids: uuids}, &response)
+	return response, err
+}
+
+// ListRepositoryRpms returns a page of the rpms of the repository with the given uuid, optionally filtered by name
+func (c *Client) ListRepositoryRpms(ctx context.Context, uuid string, page api.PaginationData, search string) (api.RepositoryRpmCollectionResponse, error) {
+	var response api.RepositoryRpmCollectionResponse
+	query := pageQuery(page)
+	if search != "" {
+		query.Set("search", search)
+	}
+	err := c.do(ctx, http.MethodGet, "/repositories/"+url.PathEscape(uuid)+"/rpms", query, nil, &response)
+	return response, err
+}
+
+// RepositoryRpms iterates over all the rpms of the repository with the given uuid, optionally filtered by name
+func (c *Client) RepositoryRpms(uuid string, search string) *Iterator[api.RepositoryRpm] {
+	return newIterator(func(ctx context.Context, page api.PaginationData) ([]api.RepositoryRpm, api.Links, error) {
+		response, err := c.ListRepositoryRpms(ctx, uuid, page, search)
+		return response.Data, response.Links, err
+	})
+}
+
+// SearchRpms returns the names of the rpms, in the given repositories, starting with the searched string
+func (c *Client) SearchRpms(ctx context.Context, request api.SearchRpmRequest) ([]api.SearchRpmResponse, error) {
+	var response []api.SearchRpmResponse
+	err := c.do(ctx, http.MethodPost, "/rpms/names", nil, request, &response)
+	return response, err
+}
+
+// ListSnapshots returns a page of the snapshots of the repository with the given uuid
+func (c *Client) ListSnapshots(ctx context.Context, uuid string, page api.PaginationData) (api.SnapshotCollectionResponse, error) {
+	var response api.SnapshotCollectionResponse
+	err := c.do(ctx, http.MethodGet, "/repositories/"+url.PathEscape(uuid)+"/snapshots/", pageQuery(page), nil, &response)
+	return response, err
+}
+
+// Snapshots iterates over all the snapshots of the repository with the given uuid
+func (c *Client) Snapshots(uuid string) *Iterator[api.SnapshotResponse] {
+	return newIterator(func(ctx context.Context, page api.PaginationData) ([]api.SnapshotResponse, api.Links, error) {
+		response, err := c.ListSnapshots(ctx, uuid, page)
+		return response.Data, response.Links, err
+	})
+}
@@ -3,10 +3,12 @@ package config
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	tlsutils "github.com/RedHatInsights/insights-operator-utils/tls"
@@ -15,10 +17,12 @@ import (
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	ce "github.com/content-services/content-sources-backend/pkg/errors"
 	"github.com/content-services/content-sources-backend/pkg/event"
+	"github.com/content-services/content-sources-backend/pkg/ssrf"
 	"github.com/labstack/echo/v4"
 	clowder "github.com/redhatinsights/app-common-go/pkg/api/v1"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
+	"golang.org/x/exp/slices"
 )
 
 const DefaultAppName = "content-sources"
@@ -39,14 +43,62 @@ type Configuration struct {
 	NotificationsClient cloudevents.Client `mapstructure:"notification_client"`
 	Tasking             Tasking            `mapstructure:"tasking"`
 	Features            FeatureSet         `mapstructure:"features"`
+	PathPrefix          string             `mapstructure:"path_prefix"` // api paths start with /{path_prefix}/{app_name}
+	AppName             string             `mapstructure:"app_name"`
+	Deprecations        []DeprecatedRoute  `mapstructure:"deprecations"`
+	Encryption          Encryption         `mapstructure:"encryption"`
+	Telemetry           Telemetry          `mapstructure:"telemetry"`
+}
+
+// Encryption holds the keys encrypting the secrets stored in the database, such as repository passwords
+type Encryption struct {
+	// Keys are a key id and 32 base64 encoded bytes separated by a colon.  The first key encrypts new
+	// values, values encrypted by any key are decrypted, so a key is rotated by adding the new key
+	// first, running rotate-keys, then removing the old key.
+	Keys []string `mapstructure:"keys"`
+}
+
+// Telemetry configures the anonymous usage statistics computed by the nightly jobs
+type Telemetry struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Kafka topic the statistics are sent to, they are only stored in the database when empty
+	Topic string `mapstructure:"topic"`
+	// Hosts of repositories used by the most orgs reported, leaving out the ones used by less than MinOrgs orgs
+	TopHosts int `mapstructure:"top_hosts"`
+	MinOrgs  int `mapstructure:"min_orgs"`
+}
+
+// DeprecatedRoute marks a route deprecated, its responses carry Deprecation and Sunset headers
+type DeprecatedRoute struct {
+	Method  string `mapstructure:"method"`
+	Route   string `mapstructure:"route"`   // route relative to the api version, e.g. /repositories/:uuid
+	Version string `mapstructure:"version"` // major api version deprecating the route, all versions if empty
+	Since   string `mapstructure:"since"`   // day the route was deprecated, YYYY-MM-DD
+	Sunset  string `mapstructure:"sunset"`  // day the route will be removed, YYYY-MM-DD
+	Link    string `mapstructure:"link"`    // documentation of the deprecation
 }
 
 type Clients struct {
-	RbacEnabled bool   `mapstructure:"rbac_enabled"`
-	RbacBaseUrl string `mapstructure:"rbac_base_url"`
-	RbacTimeout int    `mapstructure:"rbac_timeout"`
-	Pulp        Pulp   `mapstructure:"pulp"`
-	Redis       Redis  `mapstructure:"redis"`
+	RbacEnabled  bool         `mapstructure:"rbac_enabled"`
+	RbacBaseUrl  string       `mapstructure:"rbac_base_url"`
+	RbacTimeout  int          `mapstructure:"rbac_timeout"`
+	Pulp         Pulp         `mapstructure:"pulp"`
+	Redis        Redis        `mapstructure:"redis"`
+	ImageBuilder ImageBuilder `mapstructure:"image_builder"`
+	Candlepin    Candlepin    `mapstructure:"candlepin"`
+}
+
+// Candlepin issues the entitlement certificates orgs sync content of the Red Hat CDN with, such as
+// the RHEL repositories they add as custom repositories
+type Candlepin struct {
+	Server     string
+	ClientCert string `mapstructure:"client_cert"` // PEM certificate authenticating with candlepin
+	ClientKey  string `mapstructure:"client_key"`  // PEM key of the client certificate
+	CACert     string `mapstructure:"ca_cert"`     // PEM certificate of the authority signing the server certificate
+}
+
+type ImageBuilder struct {
+	PSKs []string `mapstructure:"psks"` // pre shared keys accepted on the internal image builder endpoints
 }
 
 type Mocks struct {
@@ -78,8 +130,27 @@ type Pulp struct {
 	Server            string
 	Username          string
 	Password          string
-	StorageType       string       `mapstructure:"storage_type"` // s3 or local
-	CustomRepoObjects *ObjectStore `mapstructure:"custom_repo_objects"`
+	StorageType       string         `mapstructure:"storage_type"`   // s3 or local
+	ContentOrigin     string         `mapstructure:"content_origin"` // base url snapshots are served from, defaults to server
+	CustomRepoObjects *ObjectStore   `mapstructure:"custom_repo_objects"`
+	ContentSigning    ContentSigning `mapstructure:"content_signing"`
+	// Snapshot the public Red Hat repositories once, in the domain of SharedSnapshotsOrg, and
+	// reference these snapshots from the repositories of each org
+	SharedRedHatSnapshots bool `mapstructure:"shared_red_hat_snapshots"`
+}
+
+// SharedSnapshotsOrg owns the pulp domain the public Red Hat repositories are snapshotted in
+// for every org, no org has this id
+const SharedSnapshotsOrg = "-1"
+
+// ContentSigning configures expiring signed urls for snapshot contents.  When enabled,
+// snapshots are served by this service, which should then be the only one able to reach
+// the pulp content origin.
+type ContentSigning struct {
+	Enabled    bool
+	Keys       []string      // the first key signs new urls, urls signed by any key are accepted
+	Expiration time.Duration // how long signed urls are valid
+	BaseURL    string        `mapstructure:"base_url"` // public url of this service
 }
 
 const CustomRepoClowderBucketName = "content-sources-s3-custom-repos"
@@ -96,6 +167,17 @@ type Tasking struct {
 	PGXLogging  bool `mapstructure:"pgx_logging"`
 	Heartbeat   time.Duration
 	WorkerCount int `mapstructure:"worker_count"`
+	// Snapshot tasks running at once across every worker, 0 meaning unlimited
+	SnapshotConcurrency TaskConcurrency `mapstructure:"snapshot_concurrency"`
+	// Tasks still running after this time are failed and their worker moves on, 0 to disable
+	MaxTaskDuration time.Duration `mapstructure:"max_task_duration"`
+	// Times a task whose worker stopped sending heartbeats is requeued before it is dead-lettered
+	MaxRetries int `mapstructure:"max_retries"`
+}
+
+type TaskConcurrency struct {
+	PerOrg int `mapstructure:"per_org"`
+	Global int
 }
 
 type Database struct {
@@ -105,7 +187,30 @@ type Database struct {
 	Password   string
 	Name       string
 	CACertPath string `mapstructure:"ca_cert_path"`
-	PoolLimit  int    `mapstructure:"pool_limit"`
+	PoolLimit  int    `mapstructure:"pool_limit"` // Deprecated: use MaxOpenConns
+	// Connection pool tuning, MaxOpenConns defaults to PoolLimit when unset
+	MaxOpenConns    int           `mapstructure:"max_open_conns"`
+	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+	// Optional read replica, sharing the credentials and database name of the primary
+	ReplicaHost string `mapstructure:"replica_host"`
+	ReplicaPort int    `mapstructure:"replica_port"`
+	// Statements running longer than the timeout of their query class are canceled, 0 to disable
+	StatementTimeout       time.Duration `mapstructure:"statement_timeout"`        // queries answering requests and any other query
+	ReportStatementTimeout time.Duration `mapstructure:"report_statement_timeout"` // aggregations over whole tables
+	BulkStatementTimeout   time.Duration `mapstructure:"bulk_statement_timeout"`   // inserts and cleanups of many rows
+	// Prepare each statement once per connection and reuse it, which saves parsing and planning the
+	// hot list and search queries.  It must be disabled behind a pooler in transaction mode, such as
+	// pgbouncer, since the next transaction may run on a server connection without the statement.
+	PrepareStatements bool `mapstructure:"prepare_statements"`
+	// Idle connections are closed after this time, releasing the statements prepared on them
+	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
+	// Connecting through a pooler in transaction mode, such as pgbouncer, where each transaction
+	// may run on another server connection: no statement is prepared, whatever PrepareStatements
+	// is, and the task queue polls for new tasks since the server can not deliver notifications.
+	TransactionPooling bool `mapstructure:"transaction_pooling"`
+	// Statements running longer are logged with their values left out and counted, 0 to disable
+	SlowQueryThreshold time.Duration `mapstructure:"slow_query_threshold"`
 }
 
 type Logging struct {
@@ -145,6 +250,33 @@ type Sentry struct {
 type Options struct {
 	PagedRpmInsertsLimit      int `mapstructure:"paged_rpm_inserts_limit"`
 	IntrospectApiTimeLimitSec int `mapstructure:"introspect_api_time_limit_sec"`
+	IntrospectMemoryBudgetMB  int `mapstructure:"introspect_memory_budget_mb"` // 0 to disable
+	CompressionLevel          int `mapstructure:"compression_level"`           // gzip level of responses, 0 to disable
+	CompressionMinLength      int `mapstructure:"compression_min_length"`      // responses smaller than this are not compressed
+	// Introspections send this many requests at once to a host, spaced out by the interval
+	IntrospectHostConcurrency int           `mapstructure:"introspect_host_concurrency"`
+	IntrospectHostInterval    time.Duration `mapstructure:"introspect_host_interval"`
+	// Responses to requests with an Idempotency-Key header are replayed to retries during this time
+	IdempotencyKeyTTL time.Duration `mapstructure:"idempotency_key_ttl"`
+	// Larger request bodies are rejected, e.g. 1M.  Routes accepting uploads are given the upload body limit.
+	BodyLimit       string `mapstructure:"body_limit"`
+	UploadBodyLimit string `mapstructure:"upload_body_limit"`
+	// Requests are canceled and answered with 504 after this time, 0 to disable.  Event streams are not limited.
+	RequestTimeout time.Duration `mapstructure:"request_timeout"`
+	// The introspections of repositories are listed for this number of days
+	IntrospectionHistoryDays int `mapstructure:"introspection_history_days"`
+	// Log levels set through PUT /admin/logging/ are applied by every process within the sync interval,
+	// and revert to the configured level after their ttl, which cannot exceed the max
+	LogLevelSyncInterval time.Duration `mapstructure:"log_level_sync_interval"`
+	LogLevelMaxTTL       time.Duration `mapstructure:"log_level_max_ttl"`
+	// Repositories cannot be added with, nor introspected from, a url whose host is in the deny list, or
+	// not in the allow list unless it is empty.  See ssrf.URLFilter for the patterns.
+	URLDenyList  []string `mapstructure:"url_deny_list"`
+	URLAllowList []string `mapstructure:"url_allow_list"`
+	// Repositories are fetched from loopback, private and link-local addresses of these networks only,
+	// such as the network of the mirrors of an on-premise deployment.  The proxy of the environment may
+	// be on any network, the proxies of repositories are allowed on these networks only.
+	RepositoryAllowedNetworks []string `mapstructure:"repository_allowed_networks"`
 }
 
 type Metrics struct {
@@ -160,8 +292,33 @@ type Metrics struct {
 const (
 	DefaultPagedRpmInsertsLimit      = 500
 	DefaultIntrospectApiTimeLimitSec = 30
+	DefaultIntrospectMemoryBudgetMB  = 1024
+	DefaultIntrospectHostConcurrency = 2
+	DefaultIntrospectHostInterval    = 200 * time.Millisecond
+	DefaultCompressionLevel          = 5
+	DefaultCompressionMinLength      = 2048
+	DefaultIdempotencyKeyTTL         = 24 * time.Hour
+	DefaultBodyLimit                 = "1M"
+	DefaultUploadBodyLimit           = "32M"
+	DefaultRequestTimeout            = 30 * time.Second
+	DefaultIntrospectionHistoryDays  = 30
+	DefaultLogLevelSyncInterval      = 30 * time.Second
+	DefaultLogLevelMaxTTL            = 24 * time.Hour
+	DefaultStatementTimeout          = 15 * time.Second
+	DefaultReportStatementTimeout    = 2 * time.Minute
+	DefaultSlowQueryThreshold        = 500 * time.Millisecond
+	DefaultTelemetryTopHosts         = 20
+	DefaultTelemetryMinOrgs          = 5
 )
 
+// DefaultURLDenyList denies the urls of repositories on the loopback interface, private networks and
+// link-local addresses, which include the metadata service of cloud instances
+var DefaultURLDenyList = []string{
+	"localhost", "*.localhost", "metadata.google.internal",
+	"0.0.0.0/8", "127.0.0.0/8", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "169.254.0.0/16",
+	"::/128", "::1/128", "fc00::/7", "fe80::/10",
+}
+
 var LoadedConfig Configuration
 
 func Get() *Configuration {
@@ -193,6 +350,8 @@ func readConfigFile(v *viper.Viper) {
 
 func setDefaults(v *viper.Viper) {
 	v.SetDefault("Loaded", true)
+	v.SetDefault("path_prefix", "api")
+	v.SetDefault("app_name", DefaultAppName)
 	// In viper you have to set defaults, otherwise loading from ENV doesn't work
 	//   without a config file present
 	v.SetDefault("database.host", "")
@@ -201,9 +360,36 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("database.password", "")
 	v.SetDefault("database.name", "")
 	v.SetDefault("database.pool_limit", 20)
+	v.SetDefault("database.max_open_conns", 0)
+	v.SetDefault("database.max_idle_conns", 10)
+	v.SetDefault("database.conn_max_lifetime", 30*time.Minute)
+	v.SetDefault("database.conn_max_idle_time", 10*time.Minute)
+	v.SetDefault("database.prepare_statements", true)
+	v.SetDefault("database.transaction_pooling", false)
+	v.SetDefault("database.replica_host", "")
+	v.SetDefault("database.replica_port", 0)
+	v.SetDefault("database.statement_timeout", DefaultStatementTimeout)
+	v.SetDefault("database.report_statement_timeout", DefaultReportStatementTimeout)
+	v.SetDefault("database.bulk_statement_timeout", 0)
+	v.SetDefault("database.slow_query_threshold", DefaultSlowQueryThreshold)
 	v.SetDefault("certs.cert_path", "")
 	v.SetDefault("options.paged_rpm_inserts_limit", DefaultPagedRpmInsertsLimit)
 	v.SetDefault("options.introspect_api_time_limit_sec", DefaultIntrospectApiTimeLimitSec)
+	v.SetDefault("options.introspect_memory_budget_mb", DefaultIntrospectMemoryBudgetMB)
+	v.SetDefault("options.introspect_host_concurrency", DefaultIntrospectHostConcurrency)
+	v.SetDefault("options.introspect_host_interval", DefaultIntrospectHostInterval)
+	v.SetDefault("options.compression_level", DefaultCompressionLevel)
+	v.SetDefault("options.compression_min_length", DefaultCompressionMinLength)
+	v.SetDefault("options.idempotency_key_ttl", DefaultIdempotencyKeyTTL)
+	v.SetDefault("options.body_limit", DefaultBodyLimit)
+	v.SetDefault("options.upload_body_limit", DefaultUploadBodyLimit)
+	v.SetDefault("options.request_timeout", DefaultRequestTimeout)
+	v.SetDefault("options.introspection_history_days", DefaultIntrospectionHistoryDays)
+	v.SetDefault("options.log_level_sync_interval", DefaultLogLevelSyncInterval)
+	v.SetDefault("options.log_level_max_ttl", DefaultLogLevelMaxTTL)
+	v.SetDefault("options.url_deny_list", DefaultURLDenyList)
+	v.SetDefault("options.url_allow_list", []string{})
+	v.SetDefault("options.repository_allowed_networks", []string{})
 	v.SetDefault("logging.level", "info")
 	v.SetDefault("logging.console", true)
 	v.SetDefault("metrics.path", "/metrics")
@@ -214,7 +400,23 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("clients.pulp.server", "")
 	v.SetDefault("clients.pulp.username", "")
 	v.SetDefault("clients.pulp.password", "")
+	v.SetDefault("clients.pulp.content_origin", "")
+	v.SetDefault("clients.pulp.shared_red_hat_snapshots", false)
+	v.SetDefault("clients.pulp.content_signing.enabled", false)
+	v.SetDefault("clients.pulp.content_signing.keys", []string{})
+	v.SetDefault("clients.pulp.content_signing.expiration", 24*time.Hour)
+	v.SetDefault("clients.pulp.content_signing.base_url", "")
+	v.SetDefault("clients.image_builder.psks", []string{})
+	v.SetDefault("clients.candlepin.server", "")
+	v.SetDefault("clients.candlepin.client_cert", "")
+	v.SetDefault("clients.candlepin.client_key", "")
+	v.SetDefault("clients.candlepin.ca_cert", "")
 	v.SetDefault("sentry.dsn", "")
+	v.SetDefault("encryption.keys", []string{})
+	v.SetDefault("telemetry.enabled", false)
+	v.SetDefault("telemetry.topic", "")
+	v.SetDefault("telemetry.top_hosts", DefaultTelemetryTopHosts)
+	v.SetDefault("telemetry.min_orgs", DefaultTelemetryMinOrgs)
 	v.SetDefault("new_tasking_system", false)
 
 	v.SetDefault("cloudwatch.region", "")
@@ -233,6 +435,10 @@ func setDefaults(v *viper.Viper) {
 
 	v.SetDefault("tasking.heartbeat", 1*time.Minute)
 	v.SetDefault("tasking.worker_count", 3)
+	v.SetDefault("tasking.snapshot_concurrency.per_org", 0)
+	v.SetDefault("tasking.snapshot_concurrency.global", 0)
+	v.SetDefault("tasking.max_task_duration", 6*time.Hour)
+	v.SetDefault("tasking.max_retries", 3)
 
 	v.SetDefault("features.snapshots.enabled", false)
 	v.SetDefault("features.snapshots.accounts", nil)
@@ -342,6 +548,19 @@ func Load() {
 		log.Warn().Msg("Snapshots feature is turned on, but Pulp isn't configured, disabling snapshots.")
 		LoadedConfig.Features.Snapshots.Enabled = false
 	}
+	if LoadedConfig.Clients.Pulp.ContentSigning.Enabled && len(LoadedConfig.Clients.Pulp.ContentSigning.Keys) == 0 {
+		log.Warn().Msg("Content signing is turned on, but no signing key is configured, disabling content signing.")
+		LoadedConfig.Clients.Pulp.ContentSigning.Enabled = false
+	}
+}
+
+// PulpContentURL returns the url pulp serves the given repository path from
+func PulpContentURL(repositoryPath string) string {
+	origin := Get().Clients.Pulp.ContentOrigin
+	if origin == "" {
+		origin = Get().Clients.Pulp.Server
+	}
+	return strings.TrimSuffix(origin, "/") + "/pulp/content/" + strings.TrimPrefix(repositoryPath, "/")
 }
 
 func ClowderS3Url() string {
@@ -366,19 +585,11 @@ const RhCertEnv = "RH_CDN_CERT_PAIR"
 // however if a certificate is specified but cannot be loaded
 // an error is returned.
 func ConfigureCertificate() (*tls.Certificate, error) {
-	var (
-		err       error
-		certBytes []byte
-	)
-
-	if certString := os.Getenv(RhCertEnv); certString != "" {
-		certBytes = []byte(certString)
-	} else if Get().Certs.CertPath != "" {
-		certBytes, err = os.ReadFile(Get().Certs.CertPath)
-		if err != nil {
-			return nil, err
-		}
-	} else {
+	certBytes, err := CdnCertPEM()
+	if err != nil {
+		return nil, err
+	}
+	if certBytes == nil {
 		log.Warn().Msg("No Red Hat CDN cert pair configured.")
 		return nil, nil
 	}
@@ -389,6 +600,17 @@ func ConfigureCertificate() (*tls.Certificate, error) {
 	return &cert, nil
 }
 
+// CdnCertPEM returns the PEM encoded cert keypair of the Red Hat CDN, from the environment variable
+// if specified or the file path, nil if no certificate is specified
+func CdnCertPEM() ([]byte, error) {
+	if certString := os.Getenv(RhCertEnv); certString != "" {
+		return []byte(certString), nil
+	} else if Get().Certs.CertPath != "" {
+		return os.ReadFile(Get().Certs.CertPath)
+	}
+	return nil, nil
+}
+
 // DaysTillExpiration Finds the number of days until the specified certificate expired
 // tls.Certificate allows for multiple certs to be combined, so this takes the expiration date
 // that is coming the soonest
@@ -419,10 +641,63 @@ func ProgramString() string {
 	return strings.Join(os.Args, " ")
 }
 
+// CheckRepositoryURL returns ssrf.ErrDeniedURL when the url of a repository is denied by the url deny
+// list, or not in the url allow list
+func CheckRepositoryURL(url string) error {
+	filter, err := ssrf.NewURLFilter(Get().Options.URLDenyList, Get().Options.URLAllowList)
+	if err != nil {
+		return err
+	}
+	return filter.Check(url)
+}
+
+// repositoryGuard caches the guard of the allowed networks of repositories and the transport shared by
+// the clients fetching repositories, parsed and built again only when the allowed networks change
+var repositoryGuard struct {
+	sync.Mutex
+	networks  []string
+	guard     *ssrf.Guard
+	transport *http.Transport
+}
+
+// RepositoryGuard returns the guard refusing to connect to loopback, private and link-local addresses
+// outside of the allowed networks of repositories
+func RepositoryGuard() (*ssrf.Guard, error) {
+	guard, _, err := loadRepositoryGuard()
+	return guard, err
+}
+
+// RepositoryTransport returns the transport shared by the clients fetching repositories without a proxy
+// or certificate of their own, through the proxy of the environment if any.  It must not be modified.
+func RepositoryTransport() (*http.Transport, error) {
+	_, transport, err := loadRepositoryGuard()
+	return transport, err
+}
+
+func loadRepositoryGuard() (*ssrf.Guard, *http.Transport, error) {
+	repositoryGuard.Lock()
+	defer repositoryGuard.Unlock()
+	networks := Get().Options.RepositoryAllowedNetworks
+	if repositoryGuard.guard == nil || !slices.Equal(networks, repositoryGuard.networks) {
+		allowed, err := ssrf.ParseNetworks(networks)
+		if err != nil {
+			return nil, nil, err
+		}
+		repositoryGuard.networks = slices.Clone(networks)
+		repositoryGuard.guard = ssrf.NewGuard(allowed)
+		repositoryGuard.transport = repositoryGuard.guard.TrustedProxyTransport(http.ProxyFromEnvironment)
+	}
+	return repositoryGuard.guard, repositoryGuard.transport, nil
+}
+
 func PulpConfigured() bool {
 	return Get().Clients.Pulp.Server != ""
 }
 
+func CandlepinConfigured() bool {
+	return Get().Clients.Candlepin.Server != ""
+}
+
 func CustomHTTPErrorHandler(err error, c echo.Context) {
 	var code int
 	var message ce.ErrorResponse
@@ -443,10 +718,18 @@ func CustomHTTPErrorHandler(err error, c echo.Context) {
 		code = http.StatusInternalServerError
 		message = ce.NewErrorResponse(code, "", http.StatusText(http.StatusInternalServerError))
 	}
+	// Errors built without a constructor, e.g. by a middleware, still get a code
+	message = message.WithCodes()
 
 	// Send response
 	if c.Request().Method == http.MethodHead {
 		err = c.NoContent(code)
+	} else if acceptsProblemDetails(c.Request()) {
+		var body []byte
+		body, err = json.Marshal(message.ProblemDetails(code))
+		if err == nil {
+			err = c.Blob(code, ce.MIMEApplicationProblemJSON, body)
+		}
 	} else {
 		err = c.JSON(code, message)
 	}
@@ -455,6 +738,11 @@ func CustomHTTPErrorHandler(err error, c echo.Context) {
 	}
 }
 
+// acceptsProblemDetails returns whether the client asked for errors as RFC 7807 problem details
+func acceptsProblemDetails(req *http.Request) bool {
+	return strings.Contains(req.Header.Get(echo.HeaderAccept), ce.MIMEApplicationProblemJSON)
+}
+
 func SetupNotifications() {
 	if len(LoadedConfig.Kafka.Bootstrap.Servers) == 0 {
 		log.Warn().Msg("SetupNotifications: clowder.KafkaServers and configured broker was empty")
@@ -84,17 +84,17 @@ func TestCustomHTTPErrorHandler(t *testing.T) {
 		{
 			Name:     "ErrorResponse",
 			Given:    errors.NewErrorResponse(http.StatusBadRequest, http.StatusText(http.StatusBadRequest), ""),
-			Expected: "{\"errors\":[{\"status\":400,\"title\":\"Bad Request\"}]}\n",
+			Expected: "{\"errors\":[{\"status\":400,\"code\":\"bad_request\",\"title\":\"Bad Request\"}]}\n",
 		},
 		{
 			Name:     "echo.HTTPError",
 			Given:    echo.NewHTTPError(http.StatusBadRequest, http.StatusText(http.StatusBadRequest)),
-			Expected: "{\"errors\":[{\"status\":400,\"detail\":\"Bad Request\"}]}\n",
+			Expected: "{\"errors\":[{\"status\":400,\"code\":\"bad_request\",\"detail\":\"Bad Request\"}]}\n",
 		},
 		{
 			Name:     "http.StatusInternalServerError",
 			Given:    http.ErrAbortHandler,
-			Expected: "{\"errors\":[{\"status\":500,\"detail\":\"Internal Server Error\"}]}\n",
+			Expected: "{\"errors\":[{\"status\":500,\"code\":\"internal_error\",\"detail\":\"Internal Server Error\"}]}\n",
 		},
 	}
 
@@ -106,3 +106,46 @@ func TestCustomHTTPErrorHandler(t *testing.T) {
 		}
 	}
 }
+
+func TestCustomHTTPErrorHandlerProblemDetails(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, "/", http.NoBody)
+	req.Header.Set(echo.HeaderAccept, "application/problem+json, application/json")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	CustomHTTPErrorHandler(errors.NewErrorResponseFromError("Error creating repository", &errors.DaoError{Message: "name is required", BadValidation: true}), c)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, errors.MIMEApplicationProblemJSON, rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, "{\"title\":\"Error creating repository\",\"status\":400,\"detail\":\"name is required\",\"code\":\"validation_failed\"}", rec.Body.String())
+}
+
+func TestValidate(t *testing.T) {
+	valid := Configuration{
+		Database: Database{Host: "localhost", Port: 5432, User: "content", Name: "content"},
+		Clients:  Clients{RbacEnabled: true, RbacBaseUrl: "http://rbac", Pulp: Pulp{StorageType: STORAGE_TYPE_LOCAL}},
+		Options:  Options{CompressionLevel: DefaultCompressionLevel},
+	}
+	assert.NoError(t, valid.Validate())
+
+	invalid := valid
+	invalid.Database.Host = ""
+	invalid.Database.Port = 70000
+	invalid.Clients.Pulp.StorageType = "s3"
+	invalid.Clients.Pulp.ContentSigning = ContentSigning{Enabled: true, Keys: []string{"key"}}
+	invalid.Options.BodyLimit = "1 megabyte"
+	invalid.Options.URLDenyList = []string{"10.0.0.0/33"}
+	invalid.Options.RepositoryAllowedNetworks = []string{"mirror.example.com"}
+	invalid.Clients.Candlepin = Candlepin{ClientCert: "not a certificate", ClientKey: "not a key", CACert: "not a certificate"}
+	err := invalid.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "database.host is required")
+	assert.Contains(t, err.Error(), "database.port 70000 is not a valid port")
+	assert.Contains(t, err.Error(), "clients.pulp.storage_type")
+	assert.Contains(t, err.Error(), "clients.pulp.content_signing.base_url is required")
+	assert.Contains(t, err.Error(), "options.body_limit \"1 megabyte\" must be a size")
+	assert.Contains(t, err.Error(), "options.url_deny_list: invalid url filter pattern 10.0.0.0/33")
+	assert.Contains(t, err.Error(), "options.repository_allowed_networks: invalid network mirror.example.com")
+	assert.Contains(t, err.Error(), "clients.candlepin.client_cert and client_key: tls: failed to find any PEM data in certificate input")
+	assert.Contains(t, err.Error(), "clients.candlepin.ca_cert has no PEM encoded certificate")
+}
@@ -26,6 +26,10 @@ func addEventConfigDefaults(options *viper.Viper) {
 		// Prepare topics
 		topics := []string{}
 		for _, value := range clowder.KafkaTopics {
+			// The repository changes are produced for other services, and not consumed
+			if value.RequestedName == schema.TopicRepositoryChanges {
+				continue
+			}
 			if strings.Contains(value.Name, "content-sources") {
 				topics = append(topics, value.Name)
 			}
@@ -1,9 +1,12 @@
 package config
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"sync"
 	"time"
 
 	sentrywriter "github.com/archdx/zerolog-sentry"
@@ -19,6 +22,92 @@ import (
 
 const HeaderRequestId = "x-rh-insights-request-id" // the header that contains the request ID
 const RequestIdLoggingKey = "request_id"           // the key that represents the request ID when logged
+const ModuleLoggingKey = "module"                  // the key that represents the module logging, for debugging modules alone
+
+// LogModules are the modules whose debug logs can be enabled alone, see SetRuntimeLogLevels
+var LogModules = []string{"pulp_client", "candlepin_client", "external_repos", "tasks"}
+
+// runtimeLogLevels are the log levels changed at runtime through the logging admin endpoint.  The global
+// level of zerolog lets the debug events through while modules are debugged, and levelFilterWriter drops
+// the ones of the other modules.
+var runtimeLogLevels = struct {
+	sync.RWMutex
+	configured   zerolog.Level // level of the configuration
+	level        zerolog.Level
+	debugModules [][]byte // "module":"name" of the debugged modules, as found in an event
+}{configured: zerolog.InfoLevel, level: zerolog.InfoLevel}
+
+// SetRuntimeLogLevels changes the log level, and logs the debug events of the given modules whatever the level
+func SetRuntimeLogLevels(level zerolog.Level, debugModules []string) {
+	runtimeLogLevels.Lock()
+	defer runtimeLogLevels.Unlock()
+	runtimeLogLevels.level = level
+	runtimeLogLevels.debugModules = make([][]byte, len(debugModules))
+	for i, module := range debugModules {
+		runtimeLogLevels.debugModules[i] = []byte(fmt.Sprintf("%q:%q", ModuleLoggingKey, module))
+	}
+	global := level
+	if len(debugModules) > 0 && zerolog.DebugLevel < global {
+		global = zerolog.DebugLevel
+	}
+	zerolog.SetGlobalLevel(global)
+}
+
+// ResetRuntimeLogLevels restores the log level of the configuration
+func ResetRuntimeLogLevels() {
+	runtimeLogLevels.RLock()
+	configured := runtimeLogLevels.configured
+	runtimeLogLevels.RUnlock()
+	SetRuntimeLogLevels(configured, nil)
+}
+
+// ConfiguredLogLevel returns the log level of the configuration
+func ConfiguredLogLevel() zerolog.Level {
+	runtimeLogLevels.RLock()
+	defer runtimeLogLevels.RUnlock()
+	return runtimeLogLevels.configured
+}
+
+// logged returns whether an event of the level is written
+func logged(level zerolog.Level, event []byte) bool {
+	runtimeLogLevels.RLock()
+	defer runtimeLogLevels.RUnlock()
+	if level >= runtimeLogLevels.level {
+		return true
+	}
+	if level < zerolog.DebugLevel {
+		return false
+	}
+	for _, module := range runtimeLogLevels.debugModules {
+		if bytes.Contains(event, module) {
+			return true
+		}
+	}
+	return false
+}
+
+// levelFilterWriter drops the events below the runtime log level, except the debug events of debugged modules
+type levelFilterWriter struct {
+	w io.Writer
+}
+
+func (f levelFilterWriter) Write(p []byte) (int, error) {
+	return f.w.Write(p)
+}
+
+func (f levelFilterWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if !logged(level, p) {
+		return len(p), nil
+	}
+	return f.w.Write(p)
+}
+
+// WithLogModule returns a context whose logger marks the events of the module, so that its debug
+// logs can be enabled alone
+func WithLogModule(ctx context.Context, module string) context.Context {
+	logger := zerolog.Ctx(ctx).With().Str(ModuleLoggingKey, module).Logger()
+	return logger.WithContext(ctx)
+}
 
 func ConfigureLogging() {
 	var writers []io.Writer
@@ -49,9 +138,12 @@ func ConfigureLogging() {
 			writers = append(writers, sWriter)
 		}
 	}
-	log.Logger = zerolog.New(io.MultiWriter(writers...)).With().Timestamp().Logger()
-	log.Logger = log.Logger.Level(level)
-	zerolog.SetGlobalLevel(level)
+	// Levels are only checked globally and by levelFilterWriter, so that they can be changed at runtime
+	log.Logger = zerolog.New(levelFilterWriter{w: io.MultiWriter(writers...)}).With().Timestamp().Logger()
+	runtimeLogLevels.Lock()
+	runtimeLogLevels.configured = level
+	runtimeLogLevels.Unlock()
+	SetRuntimeLogLevels(level, nil)
 	zerolog.DefaultContextLogger = &log.Logger
 }
 
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuntimeLogLevels(t *testing.T) {
+	defer ResetRuntimeLogLevels()
+	buf := bytes.Buffer{}
+	logger := zerolog.New(levelFilterWriter{w: &buf})
+	ctx := WithLogModule(logger.WithContext(context.Background()), "pulp_client")
+
+	SetRuntimeLogLevels(zerolog.WarnLevel, nil)
+	logger.Info().Msg("dropped info")
+	logger.Warn().Msg("kept warn")
+	assert.NotContains(t, buf.String(), "dropped info")
+	assert.Contains(t, buf.String(), "kept warn")
+
+	// Debug events of the debugged modules alone are written
+	SetRuntimeLogLevels(zerolog.WarnLevel, []string{"pulp_client"})
+	assert.Equal(t, zerolog.DebugLevel, zerolog.GlobalLevel())
+	zerolog.Ctx(ctx).Debug().Msg("pulp debug")
+	zerolog.Ctx(ctx).Trace().Msg("pulp trace")
+	zerolog.Ctx(WithLogModule(logger.WithContext(context.Background()), "tasks")).Debug().Msg("tasks debug")
+	logger.Debug().Msg("other debug")
+	assert.Contains(t, buf.String(), "pulp debug")
+	assert.NotContains(t, buf.String(), "pulp trace")
+	assert.NotContains(t, buf.String(), "tasks debug")
+	assert.NotContains(t, buf.String(), "other debug")
+
+	ResetRuntimeLogLevels()
+	assert.Equal(t, ConfiguredLogLevel(), zerolog.GlobalLevel())
+}
@@ -4,6 +4,10 @@ const (
 	RepositorySnapshotTask        = "snapshot"                    // Task to create a snapshot for a repository config
 	DeleteRepositorySnapshotsTask = "delete-repository-snapshots" // Task to delete all snapshots for a repository config
 	IntrospectTask                = "introspect"                  // Task to introspect repository
+	VerifySnapshotsTask           = "verify-snapshot"             // Task to verify, and repair when possible, the snapshots of a repository config or an org
+	MigrateOrgTask                = "migrate-org"                 // Task to move the repository configs and snapshots of an org to another org
+	RepairReferencesTask          = "repair-references"           // Task to point the domain and snapshots of an org at the objects of a restored or rebuilt pulp
+	BootstrapOrgTask              = "bootstrap-org"               // Task to create the pulp domain and candlepin owner of a new org
 )
 
 const (
@@ -12,4 +16,5 @@ const (
 	TaskStatusCompleted = "completed" // Task has completed
 	TaskStatusCanceled  = "canceled"  // Task has been canceled
 	TaskStatusPending   = "pending"   // Task is waiting to be started
+	TaskStatusDead      = "dead"      // Task ran out of retries and is only run again when an admin requeues it
 )
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"compress/gzip"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/content-services/content-sources-backend/pkg/ssrf"
+	"github.com/labstack/gommon/bytes"
+)
+
+// Validate checks that the values needed to start are set and within range, and
+// returns an error listing every invalid value
+func (c *Configuration) Validate() error {
+	var problems []string
+	required := func(key string, missing bool) {
+		if missing {
+			problems = append(problems, key+" is required")
+		}
+	}
+
+	required("database.host", c.Database.Host == "")
+	required("database.port", c.Database.Port == 0)
+	required("database.user", c.Database.User == "")
+	required("database.name", c.Database.Name == "")
+	required("clients.rbac_base_url", c.Clients.RbacEnabled && c.Clients.RbacBaseUrl == "")
+	required("clients.pulp.content_signing.base_url", c.Clients.Pulp.ContentSigning.Enabled && c.Clients.Pulp.ContentSigning.BaseURL == "")
+
+	if c.Database.Port < 0 || c.Database.Port > 65535 {
+		problems = append(problems, fmt.Sprintf("database.port %d is not a valid port", c.Database.Port))
+	}
+	if level := c.Options.CompressionLevel; level != 0 && (level < gzip.HuffmanOnly || level > gzip.BestCompression) {
+		problems = append(problems, fmt.Sprintf("options.compression_level %d must be between %d and %d", level, gzip.HuffmanOnly, gzip.BestCompression))
+	}
+	size := func(key string, value string) {
+		if _, err := bytes.Parse(value); value != "" && err != nil {
+			problems = append(problems, fmt.Sprintf("%s %q must be a size, e.g. 1M", key, value))
+		}
+	}
+	size("options.body_limit", c.Options.BodyLimit)
+	size("options.upload_body_limit", c.Options.UploadBodyLimit)
+	if c.Clients.Pulp.StorageType != STORAGE_TYPE_LOCAL && c.Clients.Pulp.StorageType != STORAGE_TYPE_OBJECT {
+		problems = append(problems, fmt.Sprintf("clients.pulp.storage_type %q must be %q or %q", c.Clients.Pulp.StorageType, STORAGE_TYPE_LOCAL, STORAGE_TYPE_OBJECT))
+	}
+
+	if _, err := ssrf.NewURLFilter(c.Options.URLDenyList, nil); err != nil {
+		problems = append(problems, fmt.Sprintf("options.url_deny_list: %v", err))
+	}
+	if _, err := ssrf.NewURLFilter(nil, c.Options.URLAllowList); err != nil {
+		problems = append(problems, fmt.Sprintf("options.url_allow_list: %v", err))
+	}
+	if _, err := ssrf.ParseNetworks(c.Options.RepositoryAllowedNetworks); err != nil {
+		problems = append(problems, fmt.Sprintf("options.repository_allowed_networks: %v", err))
+	}
+
+	if candlepin := c.Clients.Candlepin; candlepin.ClientCert != "" || candlepin.ClientKey != "" {
+		if _, err := tls.X509KeyPair([]byte(candlepin.ClientCert), []byte(candlepin.ClientKey)); err != nil {
+			problems = append(problems, fmt.Sprintf("clients.candlepin.client_cert and client_key: %v", err))
+		}
+	}
+	if ca := c.Clients.Candlepin.CACert; ca != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(ca)) {
+		problems = append(problems, "clients.candlepin.ca_cert has no PEM encoded certificate")
+	}
+
+	keyIDs := map[string]bool{}
+	for _, key := range c.Encryption.Keys {
+		id, encoded, _ := strings.Cut(key, ":")
+		if decoded, err := base64.StdEncoding.DecodeString(encoded); id == "" || err != nil || len(decoded) != 32 {
+			problems = append(problems, "encryption.keys must be a key id and 32 base64 encoded bytes separated by a colon")
+		} else if keyIDs[id] {
+			problems = append(problems, fmt.Sprintf("encryption.keys has several keys with the id %q", id))
+		}
+		keyIDs[id] = true
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -69,6 +69,30 @@ var DistributionArches = [...]DistributionArch{
 	},
 }
 
+const ContentTypeBinary = "binary" // Repository of binary packages
+const ContentTypeSource = "source" // Repository of source packages
+const ContentTypeDebug = "debug"   // Repository of debuginfo and debugsource packages
+
+type ContentType struct {
+	Name  string `json:"name"`  // Human-readable form of the content type
+	Label string `json:"label"` // Static label of the content type
+}
+
+var ContentTypes = [...]ContentType{
+	{
+		Name:  "Binary",
+		Label: ContentTypeBinary,
+	},
+	{
+		Name:  "Source",
+		Label: ContentTypeSource,
+	},
+	{
+		Name:  "Debug",
+		Label: ContentTypeDebug,
+	},
+}
+
 // ValidDistributionVersionLabels Given a list of labels, return true
 // if every item of the list is a valid distribution version.  If at least one
 // is not valid, returns false and the first invalid version
@@ -98,3 +122,14 @@ func ValidArchLabel(label string) bool {
 	}
 	return false
 }
+
+// ValidContentTypeLabel Given a label, verifies that the label is a valid
+// repository content type label
+func ValidContentTypeLabel(label string) bool {
+	for i := 0; i < len(ContentTypes); i++ {
+		if ContentTypes[i].Label == label {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+)
+
+// KeySize is the size of the decoded encryption keys, selecting AES-256
+const KeySize = 32
+
+var (
+	ErrNoKey         = errors.New("no encryption key is configured")
+	ErrInvalidKey    = fmt.Errorf("encryption keys must be a key id and %d base64 encoded bytes separated by a colon", KeySize)
+	ErrUnknownKey    = errors.New("the key of the encrypted value is not configured")
+	ErrInvalidCipher = errors.New("invalid encrypted value")
+)
+
+// Key is an AES key identified by an id, the id being stored with the values it encrypts
+type Key struct {
+	ID    string
+	bytes []byte
+}
+
+// ParseKey parses a key configured as its id and its base64 encoded bytes separated by a colon
+func ParseKey(configured string) (Key, error) {
+	id, encoded, found := strings.Cut(configured, ":")
+	if !found || id == "" {
+		return Key{}, ErrInvalidKey
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(decoded) != KeySize {
+		return Key{}, ErrInvalidKey
+	}
+	return Key{ID: id, bytes: decoded}, nil
+}
+
+// Encrypt returns the AES-GCM encryption of a secret with the current key of the application,
+// the first configured key, to store it in the database. The result is the id of the key
+// followed by a colon and the base64 encoded random nonce and encrypted secret.
+// An empty secret is returned as is.
+func Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	keys, err := configuredKeys()
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(keys[0])
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return keys[0].ID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns the secret encrypted by Encrypt, with any of the configured keys
+// so that values encrypted with a previous key can still be read during a rotation
+func Decrypt(ciphertext string) (string, error) {
+	if ciphertext == "" {
+		return "", nil
+	}
+	id, encoded, found := strings.Cut(ciphertext, ":")
+	if !found {
+		return "", ErrInvalidCipher
+	}
+	keys, err := configuredKeys()
+	if err != nil {
+		return "", err
+	}
+	var gcm cipher.AEAD
+	for _, key := range keys {
+		if key.ID == id {
+			if gcm, err = newGCM(key); err != nil {
+				return "", err
+			}
+			break
+		}
+	}
+	if gcm == nil {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", ErrInvalidCipher
+	}
+	nonce, sealed := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", ErrInvalidCipher
+	}
+	return string(plaintext), nil
+}
+
+// CurrentKeyID returns the id of the key encrypting new values
+func CurrentKeyID() (string, error) {
+	keys, err := configuredKeys()
+	if err != nil {
+		return "", err
+	}
+	return keys[0].ID, nil
+}
+
+// NeedsRotation returns whether a value was encrypted with another key than the current one
+func NeedsRotation(ciphertext string) (bool, error) {
+	if ciphertext == "" {
+		return false, nil
+	}
+	current, err := CurrentKeyID()
+	if err != nil {
+		return false, err
+	}
+	return !strings.HasPrefix(ciphertext, current+":"), nil
+}
+
+// Rotate re-encrypts a value with the current key
+func Rotate(ciphertext string) (string, error) {
+	plaintext, err := Decrypt(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return Encrypt(plaintext)
+}
+
+func configuredKeys() ([]Key, error) {
+	configured := config.Get().Encryption.Keys
+	if len(configured) == 0 {
+		return nil, ErrNoKey
+	}
+	keys := make([]Key, len(configured))
+	for i := range configured {
+		key, err := ParseKey(configured[i])
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+	}
+	return keys, nil
+}
+
+func newGCM(key Key) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key.bytes)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,102 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setKeys(t *testing.T, keys ...string) {
+	previous := config.Get().Encryption.Keys
+	config.Get().Encryption.Keys = keys
+	t.Cleanup(func() { config.Get().Encryption.Keys = previous })
+}
+
+func testKey(id string, fill string) string {
+	return id + ":" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat(fill, KeySize)))
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	setKeys(t, testKey("one", "k"))
+
+	encrypted, err := Encrypt("secret")
+	require.NoError(t, err)
+	assert.NotContains(t, encrypted, "secret")
+	assert.True(t, strings.HasPrefix(encrypted, "one:"))
+
+	// A random nonce is used for each encryption
+	again, err := Encrypt("secret")
+	require.NoError(t, err)
+	assert.NotEqual(t, encrypted, again)
+
+	decrypted, err := Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", decrypted)
+
+	empty, err := Encrypt("")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestDecryptInvalid(t *testing.T) {
+	setKeys(t, testKey("one", "k"))
+	encrypted, err := Encrypt("secret")
+	require.NoError(t, err)
+
+	_, err = Decrypt("one:not base64!")
+	assert.ErrorIs(t, err, ErrInvalidCipher)
+	_, err = Decrypt("no key id")
+	assert.ErrorIs(t, err, ErrInvalidCipher)
+
+	// Encrypted with a key that is no longer configured
+	setKeys(t, testKey("two", "o"))
+	_, err = Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	// Encrypted with another key of the same id
+	setKeys(t, testKey("one", "o"))
+	_, err = Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrInvalidCipher)
+}
+
+func TestRotate(t *testing.T) {
+	setKeys(t, testKey("one", "k"))
+	encrypted, err := Encrypt("secret")
+	require.NoError(t, err)
+	needsRotation, err := NeedsRotation(encrypted)
+	require.NoError(t, err)
+	assert.False(t, needsRotation)
+
+	// A new key is added first, values of the previous key are still decrypted
+	setKeys(t, testKey("two", "o"), testKey("one", "k"))
+	needsRotation, err = NeedsRotation(encrypted)
+	require.NoError(t, err)
+	assert.True(t, needsRotation)
+
+	rotated, err := Rotate(encrypted)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(rotated, "two:"))
+
+	// The previous key is no longer needed once values are rotated
+	setKeys(t, testKey("two", "o"))
+	decrypted, err := Decrypt(rotated)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", decrypted)
+}
+
+func TestEncryptWithoutKey(t *testing.T) {
+	setKeys(t)
+	_, err := Encrypt("secret")
+	assert.ErrorIs(t, err, ErrNoKey)
+
+	setKeys(t, "one:c2hvcnQ=")
+	_, err = Encrypt("secret")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	_, err = ParseKey(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", KeySize))))
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
@@ -1,8 +1,8 @@
 package dao
 
 import (
+	"context"
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"net/http"
 	"strings"
@@ -28,9 +28,9 @@ func GetAdminTaskDao(db *gorm.DB, pulpClient pulp_client.PulpClient) AdminTaskDa
 	}
 }
 
-func (a adminTaskInfoDaoImpl) Fetch(id string) (api.AdminTaskInfoResponse, error) {
+func (a adminTaskInfoDaoImpl) Fetch(ctx context.Context, id string) (api.AdminTaskInfoResponse, error) {
 	taskInfo := models.TaskInfo{}
-	query := a.db.Where("text(id) = ?", id).Joins("LEFT JOIN repository_configurations ON (tasks.repository_uuid = repository_configurations.repository_uuid AND tasks.org_id = repository_configurations.org_id)")
+	query := a.db.WithContext(ctx).Where("text(id) = ?", id).Joins("LEFT JOIN repository_configurations ON (tasks.repository_uuid = repository_configurations.repository_uuid AND tasks.org_id = repository_configurations.org_id)")
 	result := query.First(&taskInfo)
 	var account_id sql.NullString
 	query.Select("account_id").First(&account_id)
@@ -59,6 +59,7 @@ func (a adminTaskInfoDaoImpl) Fetch(id string) (api.AdminTaskInfoResponse, error
 }
 
 func (a adminTaskInfoDaoImpl) List(
+	ctx context.Context,
 	pageData api.PaginationData,
 	filterData api.AdminTaskFilterData,
 ) (api.AdminTaskInfoCollectionResponse, int64, error) {
@@ -67,7 +68,7 @@ func (a adminTaskInfoDaoImpl) List(
 	accountIds := make([]sql.NullString, 0) // Could be nil
 
 	// Finds associated repository configuration for the task's org ID, only need account id
-	filteredDB := a.db.Select("tasks.*", "repository_configurations.account_id").Joins("LEFT JOIN repository_configurations ON (tasks.repository_uuid = repository_configurations.repository_uuid AND tasks.org_id = repository_configurations.org_id)")
+	filteredDB := a.db.WithContext(ctx).Select("tasks.*", "repository_configurations.account_id").Joins("LEFT JOIN repository_configurations ON (tasks.repository_uuid = repository_configurations.repository_uuid AND tasks.org_id = repository_configurations.org_id)")
 
 	if filterData.OrgId != "" {
 		filteredDB = filteredDB.Where("tasks.org_id = ?", filterData.OrgId)
@@ -111,6 +112,13 @@ func adminTaskInfoModelToApiFields(taskInfo *models.TaskInfo, accountId sql.Null
 	apiTaskInfo.UUID = taskInfo.Id.String()
 	apiTaskInfo.OrgId = taskInfo.OrgId
 	apiTaskInfo.Status = taskInfo.Status
+	apiTaskInfo.RequestId = taskInfo.RequestID
+	apiTaskInfo.Username = taskInfo.Username
+	apiTaskInfo.Retries = taskInfo.Retries
+	apiTaskInfo.Errors = taskInfo.ErrorHistory
+	if apiTaskInfo.Errors == nil {
+		apiTaskInfo.Errors = []string{}
+	}
 	apiTaskInfo.Typename = taskInfo.Typename
 	if accountId.Valid {
 		apiTaskInfo.AccountId = accountId.String
@@ -146,7 +154,7 @@ func getPulpData(ti models.TaskInfo, pulpClient pulp_client.PulpGlobalClient) (a
 		var payload payloads.SnapshotPayload
 		response := api.PulpResponse{}
 
-		if err := json.Unmarshal(ti.Payload, &payload); err != nil {
+		if err := payloads.Decode(ti.Typename, ti.Payload, &payload); err != nil {
 			return api.PulpResponse{}, errors.New("invalid snapshot payload")
 		}
 
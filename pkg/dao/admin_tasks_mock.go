@@ -3,6 +3,8 @@
 package dao
 
 import (
+	context "context"
+
 	api "github.com/content-services/content-sources-backend/pkg/api"
 
 	mock "github.com/stretchr/testify/mock"
@@ -13,23 +15,23 @@ type MockAdminTaskDao struct {
 	mock.Mock
 }
 
-// Fetch provides a mock function with given fields: id
-func (_m *MockAdminTaskDao) Fetch(id string) (api.AdminTaskInfoResponse, error) {
-	ret := _m.Called(id)
+// Fetch provides a mock function with given fields: ctx, id
+func (_m *MockAdminTaskDao) Fetch(ctx context.Context, id string) (api.AdminTaskInfoResponse, error) {
+	ret := _m.Called(ctx, id)
 
 	var r0 api.AdminTaskInfoResponse
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (api.AdminTaskInfoResponse, error)); ok {
-		return rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (api.AdminTaskInfoResponse, error)); ok {
+		return rf(ctx, id)
 	}
-	if rf, ok := ret.Get(0).(func(string) api.AdminTaskInfoResponse); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) api.AdminTaskInfoResponse); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Get(0).(api.AdminTaskInfoResponse)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -37,30 +39,30 @@ func (_m *MockAdminTaskDao) Fetch(id string) (api.AdminTaskInfoResponse, error)
 	return r0, r1
 }
 
-// List provides a mock function with given fields: pageData, filterData
-func (_m *MockAdminTaskDao) List(pageData api.PaginationData, filterData api.AdminTaskFilterData) (api.AdminTaskInfoCollectionResponse, int64, error) {
-	ret := _m.Called(pageData, filterData)
+// List provides a mock function with given fields: ctx, pageData, filterData
+func (_m *MockAdminTaskDao) List(ctx context.Context, pageData api.PaginationData, filterData api.AdminTaskFilterData) (api.AdminTaskInfoCollectionResponse, int64, error) {
+	ret := _m.Called(ctx, pageData, filterData)
 
 	var r0 api.AdminTaskInfoCollectionResponse
 	var r1 int64
 	var r2 error
-	if rf, ok := ret.Get(0).(func(api.PaginationData, api.AdminTaskFilterData) (api.AdminTaskInfoCollectionResponse, int64, error)); ok {
-		return rf(pageData, filterData)
+	if rf, ok := ret.Get(0).(func(context.Context, api.PaginationData, api.AdminTaskFilterData) (api.AdminTaskInfoCollectionResponse, int64, error)); ok {
+		return rf(ctx, pageData, filterData)
 	}
-	if rf, ok := ret.Get(0).(func(api.PaginationData, api.AdminTaskFilterData) api.AdminTaskInfoCollectionResponse); ok {
-		r0 = rf(pageData, filterData)
+	if rf, ok := ret.Get(0).(func(context.Context, api.PaginationData, api.AdminTaskFilterData) api.AdminTaskInfoCollectionResponse); ok {
+		r0 = rf(ctx, pageData, filterData)
 	} else {
 		r0 = ret.Get(0).(api.AdminTaskInfoCollectionResponse)
 	}
 
-	if rf, ok := ret.Get(1).(func(api.PaginationData, api.AdminTaskFilterData) int64); ok {
-		r1 = rf(pageData, filterData)
+	if rf, ok := ret.Get(1).(func(context.Context, api.PaginationData, api.AdminTaskFilterData) int64); ok {
+		r1 = rf(ctx, pageData, filterData)
 	} else {
 		r1 = ret.Get(1).(int64)
 	}
 
-	if rf, ok := ret.Get(2).(func(api.PaginationData, api.AdminTaskFilterData) error); ok {
-		r2 = rf(pageData, filterData)
+	if rf, ok := ret.Get(2).(func(context.Context, api.PaginationData, api.AdminTaskFilterData) error); ok {
+		r2 = rf(ctx, pageData, filterData)
 	} else {
 		r2 = ret.Error(2)
 	}
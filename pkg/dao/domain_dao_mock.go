@@ -2,30 +2,34 @@
 
 package dao
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
 
 // MockDomainDao is an autogenerated mock type for the DomainDao type
 type MockDomainDao struct {
 	mock.Mock
 }
 
-// FetchOrCreateDomain provides a mock function with given fields: orgId
-func (_m *MockDomainDao) FetchOrCreateDomain(orgId string) (string, error) {
-	ret := _m.Called(orgId)
+// FetchOrCreateDomain provides a mock function with given fields: ctx, orgId
+func (_m *MockDomainDao) FetchOrCreateDomain(ctx context.Context, orgId string) (string, error) {
+	ret := _m.Called(ctx, orgId)
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
-		return rf(orgId)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, orgId)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
-		r0 = rf(orgId)
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, orgId)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(orgId)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, orgId)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1,6 +1,7 @@
 package dao
 
 import (
+	"context"
 	"github.com/content-services/content-sources-backend/pkg/models"
 	uuid2 "github.com/google/uuid"
 	"gorm.io/gorm"
@@ -18,22 +19,22 @@ func GetDomainDao(db *gorm.DB) DomainDao {
 	}
 }
 
-func (dDao domainDaoImpl) FetchOrCreateDomain(orgId string) (string, error) {
-	dName, err := dDao.Fetch(orgId)
+func (dDao domainDaoImpl) FetchOrCreateDomain(ctx context.Context, orgId string) (string, error) {
+	dName, err := dDao.Fetch(ctx, orgId)
 	if err != nil {
 		return "", err
 	} else if dName != "" {
 		return dName, nil
 	}
-	return dDao.Create(orgId)
+	return dDao.Create(ctx, orgId)
 }
 
-func (dDao domainDaoImpl) Create(orgId string) (string, error) {
+func (dDao domainDaoImpl) Create(ctx context.Context, orgId string) (string, error) {
 	toCreate := models.Domain{
 		DomainName: uuid2.NewString()[0:8],
 		OrgId:      orgId,
 	}
-	result := dDao.db.Clauses(clause.OnConflict{
+	result := dDao.db.WithContext(ctx).Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "org_id"}},
 		DoNothing: true,
 	}).Create(&toCreate)
@@ -41,13 +42,13 @@ func (dDao domainDaoImpl) Create(orgId string) (string, error) {
 	if result.Error != nil {
 		return "", result.Error
 	} else {
-		return dDao.Fetch(orgId)
+		return dDao.Fetch(ctx, orgId)
 	}
 }
 
-func (dDao domainDaoImpl) Fetch(orgId string) (string, error) {
+func (dDao domainDaoImpl) Fetch(ctx context.Context, orgId string) (string, error) {
 	var found []models.Domain
-	result := dDao.db.Where("org_id = ?", orgId).Find(&found)
+	result := dDao.db.WithContext(ctx).Where("org_id = ?", orgId).Find(&found)
 	if result.Error != nil {
 		return "", result.Error
 	}
@@ -1,10 +1,11 @@
 package dao
 
 import (
+	"context"
 	"sync"
 	"testing"
 
-	"github.com/content-services/content-sources-backend/pkg/db"
+	"github.com/content-services/content-sources-backend/pkg/test/database"
 	uuid2 "github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -24,15 +25,15 @@ func (ds *DomainSuite) TestCreate() {
 	orgId := "DomainSuiteTest"
 	dd := domainDaoImpl{db: ds.tx}
 
-	name, err := dd.Create(orgId)
+	name, err := dd.Create(context.Background(), orgId)
 	assert.NoError(ds.T(), err)
 	assert.NotEmpty(ds.T(), name)
 	// try again
-	name, err = dd.Create(orgId)
+	name, err = dd.Create(context.Background(), orgId)
 	assert.NoError(ds.T(), err)
 	assert.NotEmpty(ds.T(), name)
 
-	name, err = dd.Fetch(orgId)
+	name, err = dd.Fetch(context.Background(), orgId)
 	assert.NoError(ds.T(), err)
 	assert.NotEmpty(ds.T(), name)
 }
@@ -40,13 +41,14 @@ func (ds *DomainSuite) TestCreate() {
 func TestConcurrentGetDomainName(t *testing.T) {
 	// Note, this test does not use a transaction, as it fails when multiple go routines are trying to do that
 	orgId := uuid2.NewString()
+	schema := database.NewSchema(t)
 	var wg sync.WaitGroup
 
 	for i := 0; i < 100; i++ {
 		wg.Add(1)
 		go func() {
-			dDao := GetDomainDao(db.DB)
-			dName, err := dDao.FetchOrCreateDomain(orgId)
+			dDao := GetDomainDao(schema.DB)
+			dName, err := dDao.FetchOrCreateDomain(context.Background(), orgId)
 			assert.NoError(t, err)
 			assert.NotEmpty(t, dName)
 			wg.Done()
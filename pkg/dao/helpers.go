@@ -1,6 +1,21 @@
 package dao
 
-import "strings"
+import (
+	"strings"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"gorm.io/gorm"
+)
+
+// countTotal counts the results of the query into total, unless the page skips counting, in
+// which case the total is api.UncountedTotal and no query is run
+func countTotal(query *gorm.DB, pageData api.PaginationData, total *int64) error {
+	if pageData.SkipCount {
+		*total = api.UncountedTotal
+		return nil
+	}
+	return query.Count(total).Error
+}
 
 func convertSortByToSQL(SortBy string, SortMap map[string]string) string {
 	sqlOrderBy := ""
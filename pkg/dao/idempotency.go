@@ -0,0 +1,73 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/db"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"gorm.io/gorm"
+)
+
+type idempotencyDaoImpl struct {
+	db *gorm.DB
+}
+
+func GetIdempotencyDao(db *gorm.DB) IdempotencyDao {
+	return idempotencyDaoImpl{db: db}
+}
+
+// Reserve records that a request with the key is in progress, returning false when the key
+// is already reserved, or used by a request whose response has not expired yet
+func (i idempotencyDaoImpl) Reserve(ctx context.Context, orgID, key, fingerprint string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	result := i.db.WithContext(ctx).Exec(`
+		INSERT INTO idempotency_keys (org_id, key, fingerprint, status_code, content_type, body, created_at, expires_at)
+		VALUES (?, ?, ?, 0, '', NULL, ?, ?)
+		ON CONFLICT (org_id, key) DO UPDATE
+		SET fingerprint = excluded.fingerprint, status_code = 0, content_type = '', body = NULL,
+		    created_at = excluded.created_at, expires_at = excluded.expires_at
+		WHERE idempotency_keys.expires_at < ?`,
+		orgID, key, fingerprint, now, now.Add(ttl), now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Fetch returns the request recorded for the key
+func (i idempotencyDaoImpl) Fetch(ctx context.Context, orgID, key string) (models.IdempotencyKey, error) {
+	var idempotencyKey models.IdempotencyKey
+	result := i.db.WithContext(ctx).Where("org_id = ? AND key = ?", orgID, key).First(&idempotencyKey)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return idempotencyKey, &ce.DaoError{NotFound: true, Message: "Could not find idempotency key " + key}
+		}
+		return idempotencyKey, result.Error
+	}
+	return idempotencyKey, nil
+}
+
+// Complete saves the response of the request reserving the key, to replay it to retries
+func (i idempotencyDaoImpl) Complete(ctx context.Context, orgID, key string, statusCode int, contentType string, body []byte) error {
+	return i.db.WithContext(ctx).Model(&models.IdempotencyKey{}).
+		Where("org_id = ? AND key = ?", orgID, key).
+		Updates(map[string]interface{}{
+			"status_code":  statusCode,
+			"content_type": contentType,
+			"body":         body,
+		}).Error
+}
+
+// Release deletes the key, so that a retry of a failed request runs again
+func (i idempotencyDaoImpl) Release(ctx context.Context, orgID, key string) error {
+	return i.db.WithContext(ctx).Where("org_id = ? AND key = ?", orgID, key).Delete(&models.IdempotencyKey{}).Error
+}
+
+// DeleteExpired deletes the keys of every org whose response expired, returning the number deleted
+func (i idempotencyDaoImpl) DeleteExpired(ctx context.Context) (int64, error) {
+	ctx = db.WithQueryClass(ctx, db.QueryBulk)
+	result := i.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,104 @@
+package dao
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/seeds"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type IdempotencySuite struct {
+	*DaoSuite
+}
+
+func TestIdempotencySuite(t *testing.T) {
+	m := DaoSuite{}
+	r := IdempotencySuite{DaoSuite: &m}
+	suite.Run(t, &r)
+}
+
+func (is *IdempotencySuite) TestReserveAndComplete() {
+	t := is.T()
+	orgID := seeds.RandomOrgId()
+	idempotencyDao := idempotencyDaoImpl{db: is.tx}
+
+	reserved, err := idempotencyDao.Reserve(context.Background(), orgID, "key", "fingerprint", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+
+	// The key is in use, by the same org only
+	reserved, err = idempotencyDao.Reserve(context.Background(), orgID, "key", "other", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	reserved, err = idempotencyDao.Reserve(context.Background(), seeds.RandomOrgId(), "key", "fingerprint", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+
+	saved, err := idempotencyDao.Fetch(context.Background(), orgID, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "fingerprint", saved.Fingerprint)
+	assert.Equal(t, 0, saved.StatusCode)
+
+	err = idempotencyDao.Complete(context.Background(), orgID, "key", http.StatusCreated, "application/json", []byte(`{"uuid":"abc"}`))
+	require.NoError(t, err)
+	saved, err = idempotencyDao.Fetch(context.Background(), orgID, "key")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, saved.StatusCode)
+	assert.Equal(t, "application/json", saved.ContentType)
+	assert.Equal(t, `{"uuid":"abc"}`, string(saved.Body))
+
+	err = idempotencyDao.Release(context.Background(), orgID, "key")
+	require.NoError(t, err)
+	_, err = idempotencyDao.Fetch(context.Background(), orgID, "key")
+	daoError, ok := err.(*ce.DaoError)
+	require.True(t, ok)
+	assert.True(t, daoError.NotFound)
+}
+
+func (is *IdempotencySuite) TestReserveExpired() {
+	t := is.T()
+	orgID := seeds.RandomOrgId()
+	idempotencyDao := idempotencyDaoImpl{db: is.tx}
+
+	reserved, err := idempotencyDao.Reserve(context.Background(), orgID, "key", "first", -time.Minute)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+	require.NoError(t, idempotencyDao.Complete(context.Background(), orgID, "key", http.StatusCreated, "application/json", []byte("{}")))
+
+	// An expired key is reserved again, for the new request
+	reserved, err = idempotencyDao.Reserve(context.Background(), orgID, "key", "second", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+	saved, err := idempotencyDao.Fetch(context.Background(), orgID, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "second", saved.Fingerprint)
+	assert.Equal(t, 0, saved.StatusCode)
+	assert.Empty(t, saved.Body)
+}
+
+func (is *IdempotencySuite) TestDeleteExpired() {
+	t := is.T()
+	orgID := seeds.RandomOrgId()
+	idempotencyDao := idempotencyDaoImpl{db: is.tx}
+
+	_, err := idempotencyDao.Reserve(context.Background(), orgID, "expired", "fingerprint", -time.Minute)
+	require.NoError(t, err)
+	_, err = idempotencyDao.Reserve(context.Background(), orgID, "current", "fingerprint", time.Hour)
+	require.NoError(t, err)
+
+	deleted, err := idempotencyDao.DeleteExpired(context.Background())
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, deleted, int64(1))
+
+	var keys []models.IdempotencyKey
+	require.NoError(t, is.tx.Where("org_id = ?", orgID).Find(&keys).Error)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "current", keys[0].Key)
+}
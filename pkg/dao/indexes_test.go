@@ -0,0 +1,80 @@
+package dao
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type IndexesSuite struct {
+	*DaoSuite
+}
+
+func TestIndexesSuite(t *testing.T) {
+	m := DaoSuite{}
+	r := IndexesSuite{DaoSuite: &m}
+	suite.Run(t, &r)
+}
+
+// explain returns the plan of the query.  Sequential scans are made as costly as possible, so
+// that the plan uses an index whenever one applies, even though the test tables are small.
+func (s *IndexesSuite) explain(query string, args ...interface{}) string {
+	require.NoError(s.T(), s.tx.Exec("SET LOCAL enable_seqscan = off").Error)
+	rows, err := s.tx.Raw("EXPLAIN "+query, args...).Rows()
+	require.NoError(s.T(), err)
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var line string
+		require.NoError(s.T(), rows.Scan(&line))
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (s *IndexesSuite) TestAccessPathsUseIndexes() {
+	queries := []struct {
+		name  string
+		query string
+		args  []interface{}
+	}{
+		{
+			name:  "repositories of an org by name",
+			query: "SELECT * FROM repository_configurations WHERE org_id = ? ORDER BY name",
+			args:  []interface{}{orgIDTest},
+		},
+		{
+			name: "repositories of an org by url",
+			query: `SELECT repository_configurations.* FROM repository_configurations
+				INNER JOIN repositories ON repository_configurations.repository_uuid = repositories.uuid
+				WHERE repository_configurations.org_id = ? AND repositories.url = ?`,
+			args: []interface{}{orgIDTest, "https://example.com/repo/"},
+		},
+		{
+			name: "rpms of a repository by name",
+			query: `SELECT rpms.* FROM rpms
+				INNER JOIN repositories_rpms ON repositories_rpms.rpm_uuid = rpms.uuid
+				WHERE repositories_rpms.repository_uuid = ? ORDER BY rpms.name`,
+			args: []interface{}{uuid.NewString()},
+		},
+		{
+			name:  "repositories containing an rpm",
+			query: "SELECT * FROM repositories_rpms WHERE rpm_uuid = ?",
+			args:  []interface{}{uuid.NewString()},
+		},
+		{
+			name:  "tasks by status",
+			query: "SELECT * FROM tasks WHERE status = ? ORDER BY queued_at",
+			args:  []interface{}{"pending"},
+		},
+	}
+	for _, q := range queries {
+		plan := s.explain(q.query, q.args...)
+		assert.NotContains(s.T(), plan, "Seq Scan", "%s:\n%s", q.name, plan)
+	}
+}
@@ -2,6 +2,7 @@ package dao
 
 import (
 	"context"
+	"time"
 
 	"github.com/content-services/content-sources-backend/pkg/api"
 	"github.com/content-services/content-sources-backend/pkg/models"
@@ -19,6 +20,12 @@ type DaoRegistry struct {
 	TaskInfo         TaskInfoDao
 	AdminTask        AdminTaskDao
 	Domain           DomainDao
+	Usage            UsageDao
+	Idempotency      IdempotencyDao
+	Webhook          WebhookDao
+	OrgSettings      OrgSettingsDao
+	Telemetry        TelemetryDao
+	LogLevels        LogLevelsDao
 }
 
 func GetDaoRegistry(db *gorm.DB) *DaoRegistry {
@@ -27,81 +34,167 @@ func GetDaoRegistry(db *gorm.DB) *DaoRegistry {
 			db:      db,
 			yumRepo: &yum.Repository{},
 		},
-		Rpm:        rpmDaoImpl{db: db},
-		Repository: repositoryDaoImpl{db: db},
-		Metrics:    metricsDaoImpl{db: db},
-		Snapshot:   snapshotDaoImpl{db: db},
-		TaskInfo:   taskInfoDaoImpl{db: db},
-		AdminTask:  adminTaskInfoDaoImpl{db: db, pulpClient: pulp_client.GetGlobalPulpClient(context.Background())},
-		Domain:     domainDaoImpl{db: db},
+		Rpm:         rpmDaoImpl{db: db},
+		Repository:  repositoryDaoImpl{db: db},
+		Metrics:     metricsDaoImpl{db: db},
+		Snapshot:    snapshotDaoImpl{db: db},
+		TaskInfo:    taskInfoDaoImpl{db: db, pulpClient: pulp_client.GetGlobalPulpClient(context.Background())},
+		AdminTask:   adminTaskInfoDaoImpl{db: db, pulpClient: pulp_client.GetGlobalPulpClient(context.Background())},
+		Domain:      domainDaoImpl{db: db},
+		Usage:       usageDaoImpl{db: db},
+		Idempotency: idempotencyDaoImpl{db: db},
+		Webhook:     webhookDaoImpl{db: db},
+		OrgSettings: orgSettingsDaoImpl{db: db},
+		Telemetry:   telemetryDaoImpl{db: db},
+		LogLevels:   logLevelsDaoImpl{db: db},
 	}
 	return &reg
 }
 
 //go:generate mockery --name RepositoryConfigDao --filename repository_configs_mock.go --inpackage
 type RepositoryConfigDao interface {
-	Create(newRepo api.RepositoryRequest) (api.RepositoryResponse, error)
-	BulkCreate(newRepositories []api.RepositoryRequest) ([]api.RepositoryResponse, []error)
-	Update(orgID, uuid string, repoParams api.RepositoryRequest) (bool, error)
-	Fetch(orgID string, uuid string) (api.RepositoryResponse, error)
-	List(orgID string, paginationData api.PaginationData, filterData api.FilterData) (api.RepositoryCollectionResponse, int64, error)
-	Delete(orgID string, uuid string) error
-	SoftDelete(orgID string, uuid string) error
-	BulkDelete(orgID string, uuids []string) []error
-	SavePublicRepos(urls []string) error
-	ValidateParameters(orgId string, params api.RepositoryValidationRequest, excludedUUIDS []string) (api.RepositoryValidationResponse, error)
-	FetchByRepoUuid(orgID string, repoUuid string) (api.RepositoryResponse, error)
-	InternalOnly_FetchRepoConfigsForRepoUUID(uuid string) []api.RepositoryResponse
+	Create(ctx context.Context, newRepo api.RepositoryRequest) (api.RepositoryResponse, error)
+	BulkCreate(ctx context.Context, newRepositories []api.RepositoryRequest) ([]api.RepositoryResponse, []error)
+	Update(ctx context.Context, orgID, uuid string, repoParams api.RepositoryRequest) (bool, error)
+	UpdateLastSnapshotTask(ctx context.Context, taskUUID string, orgID string, repoUUID string) error
+	Fetch(ctx context.Context, orgID string, uuid string) (api.RepositoryResponse, error)
+	List(ctx context.Context, orgID string, paginationData api.PaginationData, filterData api.FilterData) (api.RepositoryCollectionResponse, int64, error)
+	Delete(ctx context.Context, orgID string, uuid string) error
+	SoftDelete(ctx context.Context, orgID string, uuid string) error
+	BulkDelete(ctx context.Context, orgID string, uuids []string) []error
+	BulkUpdate(ctx context.Context, orgID string, uuids []string, repoParams api.RepositoryRequest) ([]api.RepositoryResponse, []error)
+	ListEvents(ctx context.Context, orgID string, since time.Time) ([]api.RepositoryEventResponse, error)
+	ListDuplicates(ctx context.Context, orgID string) ([]api.DuplicateRepositoriesResponse, error)
+	SavePublicRepos(ctx context.Context, urls []string) error
+	ValidateParameters(ctx context.Context, orgId string, params api.RepositoryValidationRequest, excludedUUIDS []string) (api.RepositoryValidationResponse, error)
+	FetchByRepoUuid(ctx context.Context, orgID string, repoUuid string) (api.RepositoryResponse, error)
+	FetchProxy(ctx context.Context, orgID string, uuid string) (RepositoryProxy, error)
+	FetchCredentials(ctx context.Context, orgID string, uuid string) (RepositoryCredentials, error)
+	BulkExport(ctx context.Context, orgID string, reposToExport api.RepositoryExportRequest) ([]api.RepositoryExportResponse, error)
+	BulkImport(ctx context.Context, reposToImport []api.RepositoryRequest) ([]api.RepositoryImportResponse, []error)
+	InternalOnly_FetchRepoConfigsForRepoUUID(ctx context.Context, uuid string) []api.RepositoryResponse
+	InternalOnly_FetchPendingDelete(ctx context.Context) ([]api.RepositoryResponse, error)
+	InternalOnly_FetchFailingByOrg(ctx context.Context) (map[string][]api.RepositoryResponse, error)
+	InternalOnly_RotateSecrets(ctx context.Context) (int64, error)
+	InternalOnly_MigrateOrg(ctx context.Context, sourceOrgID string, targetOrgID string, accountID string) (OrgMigration, error)
+	ListIntrospections(ctx context.Context, orgID string, uuid string, pageData api.PaginationData) (api.IntrospectionCollectionResponse, int64, error)
 }
 
 //go:generate mockery --name RpmDao --filename rpms_mock.go --inpackage
 type RpmDao interface {
-	List(orgID string, uuidRepo string, limit int, offset int, search string, sortBy string) (api.RepositoryRpmCollectionResponse, int64, error)
-	Search(orgID string, request api.SearchRpmRequest) ([]api.SearchRpmResponse, error)
-	InsertForRepository(repoUuid string, pkgs []yum.Package) (int64, error)
-	OrphanCleanup() error
+	List(ctx context.Context, orgID string, uuidRepo string, limit int, offset int, search string, sortBy string) (api.RepositoryRpmCollectionResponse, int64, error)
+	Search(ctx context.Context, orgID string, request api.SearchRpmRequest) ([]api.SearchRpmResponse, error)
+	SearchPackages(ctx context.Context, orgID string, request api.SearchPackageRequest) ([]api.SearchPackageResponse, error)
+	DependencyClosure(ctx context.Context, orgID string, request api.DependencyClosureRequest) (api.DependencyClosureResponse, error)
+	OfficialOverlaps(ctx context.Context, orgID string, repositoryConfigUUID string) (api.OfficialOverlapsResponse, error)
+	InsertForRepository(ctx context.Context, repoUuid string, pkgs []yum.Package) (RpmInsertResult, error)
+	PackageChanges(ctx context.Context, repoUuid string, pkgs []yum.Package) (models.PackageChanges, error)
+	InsertCapabilities(ctx context.Context, capabilities map[string][]models.RpmCapability) error
+	OrphanCleanup(ctx context.Context) error
 }
 
 //go:generate mockery --name RepositoryDao --filename repositories_mock.go --inpackage
 type RepositoryDao interface {
-	FetchForUrl(url string) (Repository, error)
-	List(ignoreFailed bool) ([]Repository, error)
-	ListPublic(paginationData api.PaginationData, _ api.FilterData) (api.PublicRepositoryCollectionResponse, int64, error)
-	Update(repo RepositoryUpdate) error
-	FetchRepositoryRPMCount(repoUUID string) (int, error)
-	OrphanCleanup() error
+	FetchForUrl(ctx context.Context, url string) (Repository, error)
+	FetchProxy(ctx context.Context, repoUUID string) (RepositoryProxy, error)
+	FetchCredentials(ctx context.Context, repoUUID string) (RepositoryCredentials, error)
+	FetchDistributions(ctx context.Context, repoUUID string) ([]RepositoryDistribution, error)
+	FetchEntitledOrg(ctx context.Context, repoUUID string) (string, error)
+	CreateIntrospection(ctx context.Context, introspection models.Introspection) error
+	DeleteIntrospectionsBefore(ctx context.Context, before time.Time) (int64, error)
+	List(ctx context.Context, ignoreFailed bool) ([]Repository, error)
+	ListPublic(ctx context.Context, paginationData api.PaginationData, _ api.FilterData) (api.PublicRepositoryCollectionResponse, int64, error)
+	Update(ctx context.Context, repo RepositoryUpdate) error
+	FetchRepositoryRPMCount(ctx context.Context, repoUUID string) (int, error)
+	OrphanCleanup(ctx context.Context) error
 }
 
 //go:generate mockery --name SnapshotDao --filename snapshots_mock.go --inpackage
 type SnapshotDao interface {
-	Create(snap *models.Snapshot) error
-	List(repoConfigUuid string, paginationData api.PaginationData, filterData api.FilterData) (api.SnapshotCollectionResponse, int64, error)
-	FetchForRepoConfigUUID(repoConfigUUID string) ([]models.Snapshot, error)
-	Delete(snapUUID string) error
+	Create(ctx context.Context, snap *models.Snapshot) error
+	List(ctx context.Context, repoConfigUuid string, paginationData api.PaginationData, filterData api.FilterData) (api.SnapshotCollectionResponse, int64, error)
+	Fetch(ctx context.Context, repoConfigUUID string, snapUUID string) (api.SnapshotResponse, error)
+	FetchForRepoConfigUUID(ctx context.Context, repoConfigUUID string) ([]models.Snapshot, error)
+	FetchForOrg(ctx context.Context, orgID string) ([]models.Snapshot, error)
+	UpdateVerification(ctx context.Context, snap models.Snapshot) error
+	Delete(ctx context.Context, snapUUID string) error
+	FetchSnapshotsByDateAndRepository(ctx context.Context, orgID string, request api.ListSnapshotByDateRequest) (api.ListSnapshotByDateResponse, error)
+	StorageUsage(ctx context.Context, orgID string) (api.SnapshotStorageResponse, error)
+	FetchLatestForRepoConfigs(ctx context.Context, orgID string, repoConfigUUIDs []string) (map[string]api.SnapshotResponse, error)
 }
 
 //go:generate mockery --name MetricsDao --filename metrics_mock.go --inpackage
 type MetricsDao interface {
-	RepositoriesCount() int
-	RepositoryConfigsCount() int
-	RepositoriesIntrospectionCount(hours int, public bool) IntrospectionCount
-	PublicRepositoriesFailedIntrospectionCount() int
-	OrganizationTotal() int64
+	RepositoriesCount(ctx context.Context) int
+	RepositoryConfigsCount(ctx context.Context) int
+	RepositoriesIntrospectionCount(ctx context.Context, hours int, public bool) IntrospectionCount
+	PublicRepositoriesFailedIntrospectionCount(ctx context.Context) int
+	OrganizationTotal(ctx context.Context) int64
+	OldestQueuedTaskAge(ctx context.Context) float64
+	RowCounts(ctx context.Context) RowCounts
+	TopOrgRowCounts(ctx context.Context, sortBy string, limit int) ([]api.OrgRowCountsResponse, error)
 }
 
 //go:generate mockery --name TaskInfoDao --filename task_info_mock.go --inpackage
 type TaskInfoDao interface {
-	Fetch(OrgID string, id string) (api.TaskInfoResponse, error)
-	List(OrgID string, pageData api.PaginationData, statusFilter string) (api.TaskInfoCollectionResponse, int64, error)
-	IsSnapshotInProgress(orgID, repoUUID string) (bool, error)
+	Fetch(ctx context.Context, OrgID string, id string) (api.TaskInfoResponse, error)
+	FetchEvent(ctx context.Context, OrgID string, id string) (api.TaskEventResponse, error)
+	List(ctx context.Context, OrgID string, pageData api.PaginationData, statusFilter string) (api.TaskInfoCollectionResponse, int64, error)
+	IsSnapshotInProgress(ctx context.Context, orgID, repoUUID string) (bool, error)
+	CountsByRepository(ctx context.Context, orgID string, repoUUIDs []string) (map[string]api.TaskCounts, error)
 }
 
 type AdminTaskDao interface {
-	Fetch(id string) (api.AdminTaskInfoResponse, error)
-	List(pageData api.PaginationData, filterData api.AdminTaskFilterData) (api.AdminTaskInfoCollectionResponse, int64, error)
+	Fetch(ctx context.Context, id string) (api.AdminTaskInfoResponse, error)
+	List(ctx context.Context, pageData api.PaginationData, filterData api.AdminTaskFilterData) (api.AdminTaskInfoCollectionResponse, int64, error)
+}
+
+//go:generate mockery --name UsageDao --filename usage_mock.go --inpackage
+type UsageDao interface {
+	Record(ctx context.Context, counts []UsageCount) error
+	List(ctx context.Context, pageData api.PaginationData, filterData api.UsageFilterData) (api.UsageCollectionResponse, int64, error)
+}
+
+//go:generate mockery --name IdempotencyDao --filename idempotency_mock.go --inpackage
+type IdempotencyDao interface {
+	Reserve(ctx context.Context, orgID, key, fingerprint string, ttl time.Duration) (bool, error)
+	Fetch(ctx context.Context, orgID, key string) (models.IdempotencyKey, error)
+	Complete(ctx context.Context, orgID, key string, statusCode int, contentType string, body []byte) error
+	Release(ctx context.Context, orgID, key string) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+//go:generate mockery --name WebhookDao --filename webhook_mock.go --inpackage
+type WebhookDao interface {
+	Create(ctx context.Context, newWebhook api.WebhookRequest) (api.WebhookResponse, error)
+	List(ctx context.Context, orgID string, pageData api.PaginationData) (api.WebhookCollectionResponse, int64, error)
+	Fetch(ctx context.Context, orgID string, uuid string) (api.WebhookResponse, error)
+	Delete(ctx context.Context, orgID string, uuid string) error
+	ListDeliveries(ctx context.Context, orgID string, uuid string, pageData api.PaginationData) (api.WebhookDeliveryCollectionResponse, int64, error)
+	QueueEvent(ctx context.Context, orgID string, eventType string, data api.WebhookEventData) error
+	DeleteDeliveriesBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+//go:generate mockery --name OrgSettingsDao --filename org_settings_mock.go --inpackage
+type OrgSettingsDao interface {
+	Fetch(ctx context.Context, orgID string) (api.OrgSettingsResponse, error)
+	Update(ctx context.Context, orgID string, request api.OrgSettingsRequest) (api.OrgSettingsResponse, error)
+	CreateDefaults(ctx context.Context, orgID string) (api.OrgSettingsResponse, error)
+}
+
+//go:generate mockery --name TelemetryDao --filename telemetry_mock.go --inpackage
+type TelemetryDao interface {
+	Compute(ctx context.Context, day time.Time, topHosts int, minOrgs int) ([]models.TelemetryStat, error)
+	Save(ctx context.Context, stats []models.TelemetryStat) error
+}
+
+//go:generate mockery --name LogLevelsDao --filename log_levels_mock.go --inpackage
+type LogLevelsDao interface {
+	Fetch(ctx context.Context) (*models.LogLevels, error)
+	Save(ctx context.Context, levels models.LogLevels) error
 }
 
 //go:generate mockery --name DomainDao --filename domain_dao_mock.go --inpackage
 type DomainDao interface {
-	FetchOrCreateDomain(orgId string) (string, error)
+	FetchOrCreateDomain(ctx context.Context, orgId string) (string, error)
 }
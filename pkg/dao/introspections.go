@@ -0,0 +1,64 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/db"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"gorm.io/gorm"
+)
+
+// CreateIntrospection records a run of the introspection of a repository
+func (p repositoryDaoImpl) CreateIntrospection(ctx context.Context, introspection models.Introspection) error {
+	if err := p.db.WithContext(ctx).Create(&introspection).Error; err != nil {
+		return DBErrorToApi(err)
+	}
+	return nil
+}
+
+// DeleteIntrospectionsBefore deletes the introspections of every repository started before the given time,
+// returning the number deleted
+func (p repositoryDaoImpl) DeleteIntrospectionsBefore(ctx context.Context, before time.Time) (int64, error) {
+	ctx = db.WithQueryClass(ctx, db.QueryBulk)
+	result := p.db.WithContext(ctx).Where("started_at < ?", before).Delete(&models.Introspection{})
+	return result.RowsAffected, result.Error
+}
+
+// ListIntrospections lists the introspections of the repository of a repository config, latest first
+func (r repositoryConfigDaoImpl) ListIntrospections(ctx context.Context, orgID string, uuid string, pageData api.PaginationData) (api.IntrospectionCollectionResponse, int64, error) {
+	repoConfig, err := r.fetchRepoConfig(ctx, orgID, uuid)
+	if err != nil {
+		return api.IntrospectionCollectionResponse{}, 0, err
+	}
+	var total int64
+	introspections := make([]models.Introspection, 0)
+	err = readOnly(ctx, r.db, func(conn *gorm.DB) error {
+		filteredDB := conn.Where("repository_uuid = ?", repoConfig.RepositoryUUID).Session(&gorm.Session{})
+		if err := countTotal(filteredDB.Model(&models.Introspection{}), pageData, &total); err != nil {
+			return err
+		}
+		return filteredDB.Order("started_at DESC").Limit(pageData.Limit).Offset(pageData.Offset).Find(&introspections).Error
+	})
+	if err != nil {
+		return api.IntrospectionCollectionResponse{}, 0, DBErrorToApi(err)
+	}
+	response := api.IntrospectionCollectionResponse{Data: make([]api.IntrospectionResponse, len(introspections))}
+	for i, introspection := range introspections {
+		response.Data[i] = api.IntrospectionResponse{
+			UUID:            introspection.UUID,
+			StartedAt:       introspection.StartedAt,
+			FinishedAt:      introspection.FinishedAt,
+			Duration:        introspection.FinishedAt.Sub(introspection.StartedAt).Seconds(),
+			Status:          introspection.Status,
+			PackagesAdded:   introspection.PackagesAdded,
+			PackagesRemoved: introspection.PackagesRemoved,
+			PackagesUpdated: introspection.PackagesUpdated,
+		}
+		if introspection.Error != nil {
+			response.Data[i].Error = *introspection.Error
+		}
+	}
+	return response, total, nil
+}
@@ -0,0 +1,74 @@
+package dao
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/seeds"
+	"github.com/openlyinc/pointy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type IntrospectionSuite struct {
+	*DaoSuite
+}
+
+func TestIntrospectionSuite(t *testing.T) {
+	m := DaoSuite{}
+	r := IntrospectionSuite{DaoSuite: &m}
+	suite.Run(t, &r)
+}
+
+func (s *IntrospectionSuite) TestIntrospections() {
+	t := s.T()
+	ctx := context.Background()
+	orgID := seeds.RandomOrgId()
+	repoConfig, err := GetRepositoryConfigDao(s.tx).Create(ctx, api.RepositoryRequest{
+		Name:      pointy.String("introspected"),
+		URL:       pointy.String("http://introspected.example.com/"),
+		OrgID:     pointy.String(orgID),
+		AccountID: pointy.String(seeds.RandomAccountId()),
+	})
+	require.NoError(t, err)
+
+	repoDao := GetRepositoryDao(s.tx)
+	now := time.Now()
+	message := "repomd.xml not found"
+	introspections := []models.Introspection{
+		{RepositoryUUID: repoConfig.RepositoryUUID, StartedAt: now.AddDate(0, 0, -40), FinishedAt: now.AddDate(0, 0, -40), Status: models.IntrospectionSucceeded},
+		{RepositoryUUID: repoConfig.RepositoryUUID, StartedAt: now.Add(-2 * time.Hour), FinishedAt: now.Add(-2 * time.Hour).Add(3 * time.Second),
+			Status: models.IntrospectionSucceeded, PackagesAdded: 4, PackagesRemoved: 2, PackagesUpdated: 3},
+		{RepositoryUUID: repoConfig.RepositoryUUID, StartedAt: now.Add(-time.Hour), FinishedAt: now.Add(-time.Hour), Status: models.IntrospectionFailed, Error: &message},
+	}
+	for _, introspection := range introspections {
+		require.NoError(t, repoDao.CreateIntrospection(ctx, introspection))
+	}
+
+	listed, total, err := GetRepositoryConfigDao(s.tx).ListIntrospections(ctx, orgID, repoConfig.UUID, api.PaginationData{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, listed.Data, 2)
+	assert.Equal(t, models.IntrospectionFailed, listed.Data[0].Status)
+	assert.Equal(t, message, listed.Data[0].Error)
+	assert.Equal(t, int64(4), listed.Data[1].PackagesAdded)
+	assert.Equal(t, int64(2), listed.Data[1].PackagesRemoved)
+	assert.Equal(t, int64(3), listed.Data[1].PackagesUpdated)
+	assert.InDelta(t, 3, listed.Data[1].Duration, 0.01)
+
+	// The introspections of repositories of other orgs are not found
+	_, _, err = GetRepositoryConfigDao(s.tx).ListIntrospections(ctx, seeds.RandomOrgId(), repoConfig.UUID, api.PaginationData{Limit: 2})
+	require.Error(t, err)
+	daoError, ok := err.(*ce.DaoError)
+	require.True(t, ok)
+	assert.True(t, daoError.NotFound)
+
+	deleted, err := repoDao.DeleteIntrospectionsBefore(ctx, now.AddDate(0, 0, -30))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
@@ -0,0 +1,46 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type logLevelsDaoImpl struct {
+	db *gorm.DB
+}
+
+func GetLogLevelsDao(db *gorm.DB) LogLevelsDao {
+	return logLevelsDaoImpl{db: db}
+}
+
+// Fetch returns the log levels set through the logging admin endpoint, or nil if they were never set
+func (l logLevelsDaoImpl) Fetch(ctx context.Context) (*models.LogLevels, error) {
+	levels := models.LogLevels{}
+	err := l.db.WithContext(ctx).Where("id = ?", models.LogLevelsID).First(&levels).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, DBErrorToApi(err)
+	}
+	return &levels, nil
+}
+
+// Save replaces the log levels
+func (l logLevelsDaoImpl) Save(ctx context.Context, levels models.LogLevels) error {
+	levels.ID = models.LogLevelsID
+	if levels.DebugModules == nil {
+		levels.DebugModules = []string{}
+	}
+	err := l.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"level", "debug_modules", "expires_at", "updated_at", "updated_by"}),
+	}).Create(&levels).Error
+	if err != nil {
+		return DBErrorToApi(err)
+	}
+	return nil
+}
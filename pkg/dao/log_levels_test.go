@@ -0,0 +1,53 @@
+package dao
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type LogLevelsSuite struct {
+	*DaoSuite
+}
+
+func TestLogLevelsSuite(t *testing.T) {
+	m := DaoSuite{}
+	r := LogLevelsSuite{DaoSuite: &m}
+	suite.Run(t, &r)
+}
+
+func (s *LogLevelsSuite) TestFetchAndSave() {
+	t := s.T()
+	logLevelsDao := logLevelsDaoImpl{db: s.tx}
+	require.NoError(t, s.tx.Where("id = ?", models.LogLevelsID).Delete(&models.LogLevels{}).Error)
+
+	levels, err := logLevelsDao.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, levels)
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	err = logLevelsDao.Save(context.Background(), models.LogLevels{Level: "debug", ExpiresAt: expiresAt, UpdatedBy: "admin"})
+	require.NoError(t, err)
+	err = logLevelsDao.Save(context.Background(), models.LogLevels{
+		Level:        "info",
+		DebugModules: []string{"pulp_client"},
+		ExpiresAt:    expiresAt,
+		UpdatedBy:    "other-admin",
+	})
+	require.NoError(t, err)
+
+	levels, err = logLevelsDao.Fetch(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, levels)
+	assert.Equal(t, "info", levels.Level)
+	assert.Equal(t, []string{"pulp_client"}, []string(levels.DebugModules))
+	assert.True(t, expiresAt.Equal(levels.ExpiresAt))
+	assert.Equal(t, "other-admin", levels.UpdatedBy)
+	assert.False(t, levels.Expired(time.Now()))
+	assert.True(t, levels.Expired(expiresAt))
+}
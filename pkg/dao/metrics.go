@@ -1,9 +1,13 @@
 package dao
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/content-services/content-sources-backend/pkg/api"
 	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/db"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
 	"github.com/content-services/content-sources-backend/pkg/models"
 	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
@@ -27,27 +31,30 @@ func GetMetricsDao(db *gorm.DB) MetricsDao {
 	}
 }
 
-func (d metricsDaoImpl) RepositoriesCount() int {
+func (d metricsDaoImpl) RepositoriesCount(ctx context.Context) int {
+	ctx = db.WithQueryClass(ctx, db.QueryReport)
 	// select COUNT(*) from repositories ;
 	var output int64 = -1
-	d.db.
+	d.db.WithContext(ctx).
 		Model(&models.Repository{}).
 		Count(&output)
 	return int(output)
 }
 
-func (d metricsDaoImpl) RepositoryConfigsCount() int {
+func (d metricsDaoImpl) RepositoryConfigsCount(ctx context.Context) int {
+	ctx = db.WithQueryClass(ctx, db.QueryReport)
 	// select COUNT(*) from repository_configurations ;
 	var output int64 = -1
-	d.db.
+	d.db.WithContext(ctx).
 		Model(&models.RepositoryConfiguration{}).
 		Count(&output)
 	return int(output)
 }
 
-func (d metricsDaoImpl) OrganizationTotal() int64 {
+func (d metricsDaoImpl) OrganizationTotal(ctx context.Context) int64 {
+	ctx = db.WithQueryClass(ctx, db.QueryReport)
 	var output int64 = -1
-	tx := d.db.
+	tx := d.db.WithContext(ctx).
 		Model(&models.RepositoryConfiguration{}).Group("org_id").
 		Count(&output)
 	if tx.Error != nil {
@@ -56,7 +63,8 @@ func (d metricsDaoImpl) OrganizationTotal() int64 {
 	return output
 }
 
-func (d metricsDaoImpl) RepositoriesIntrospectionCount(hours int, public bool) IntrospectionCount {
+func (d metricsDaoImpl) RepositoriesIntrospectionCount(ctx context.Context, hours int, public bool) IntrospectionCount {
+	ctx = db.WithQueryClass(ctx, db.QueryReport)
 	// select COUNT(*)
 	//   from repositories
 	//  where public
@@ -72,8 +80,8 @@ func (d metricsDaoImpl) RepositoriesIntrospectionCount(hours int, public bool) I
 		publicClause = "public"
 	}
 
-	tx := d.db.Model(&models.Repository{}).
-		Where(publicClause).Where(d.db.Where("last_introspection_time is NULL and status != ?", config.StatusPending).
+	tx := d.db.WithContext(ctx).Model(&models.Repository{}).
+		Where(publicClause).Where(d.db.WithContext(ctx).Where("last_introspection_time is NULL and status != ?", config.StatusPending).
 		Where("failed_introspections_count <= ?", config.FailedIntrospectionsLimit).
 		Or("last_introspection_time < NOW() - cast(? as INTERVAL)", interval)).
 		Count(&output.Missed)
@@ -81,7 +89,7 @@ func (d metricsDaoImpl) RepositoriesIntrospectionCount(hours int, public bool) I
 		log.Logger.Err(tx.Error).Msg("error")
 	}
 
-	tx = d.db.Model(&models.Repository{}).
+	tx = d.db.WithContext(ctx).Model(&models.Repository{}).
 		Where(publicClause).Where("last_introspection_time >= NOW() - cast(? as INTERVAL)", interval).
 		Count(&output.Introspected)
 	if tx.Error != nil {
@@ -90,16 +98,131 @@ func (d metricsDaoImpl) RepositoriesIntrospectionCount(hours int, public bool) I
 	return output
 }
 
-func (d metricsDaoImpl) PublicRepositoriesFailedIntrospectionCount() int {
+func (d metricsDaoImpl) PublicRepositoriesFailedIntrospectionCount(ctx context.Context) int {
+	ctx = db.WithQueryClass(ctx, db.QueryReport)
 	// select COUNT(*)
 	// from repositories
 	// where public
 	//   and status in ('Invalid','Unavailable');
 	var output int64 = -1
-	d.db.
+	d.db.WithContext(ctx).
 		Model(&models.Repository{}).
 		Where("public").
 		Where("status in (?, ?)", config.StatusInvalid, config.StatusUnavailable).
 		Count(&output)
 	return int(output)
 }
+
+// OldestQueuedTaskAge returns the seconds the oldest task ready to run has been queued, 0 if there is none
+func (d metricsDaoImpl) OldestQueuedTaskAge(ctx context.Context) float64 {
+	ctx = db.WithQueryClass(ctx, db.QueryReport)
+	// select EXTRACT(EPOCH FROM NOW() - MIN(queued_at)) from ready_tasks;
+	var output float64
+	tx := d.db.WithContext(ctx).
+		Raw("SELECT COALESCE(EXTRACT(EPOCH FROM statement_timestamp() - MIN(queued_at)), 0) FROM ready_tasks").
+		Scan(&output)
+	if tx.Error != nil {
+		log.Error().Err(tx.Error).Msg("Cannot calculate OldestQueuedTaskAge")
+	}
+	return output
+}
+
+// RowCounts are the rows of the tables tracked for capacity planning
+type RowCounts struct {
+	Tables        map[string]int64 // rows by table name
+	TasksByStatus map[string]int64 // rows of the tasks table by status
+}
+
+// rowCountTables are the tables whose rows are counted by RowCounts, the largest and fastest growing ones
+var rowCountTables = []string{"repository_configurations", "snapshots", "rpms", "tasks"}
+
+// orgRowCountsSort maps the sort_by values of TopOrgRowCounts to their columns
+var orgRowCountsSort = map[string]string{
+	"repository_configurations": "repository_configurations",
+	"snapshots":                 "snapshots",
+	"tasks":                     "tasks",
+}
+
+// RowCounts returns the number of rows of each tracked table, deleted repositories included, and
+// the number of tasks by status.  A count that could not be read is -1.
+func (d metricsDaoImpl) RowCounts(ctx context.Context) RowCounts {
+	ctx = db.WithQueryClass(ctx, db.QueryReport)
+	counts := RowCounts{
+		Tables: make(map[string]int64, len(rowCountTables)),
+		TasksByStatus: map[string]int64{
+			config.TaskStatusPending:   0,
+			config.TaskStatusRunning:   0,
+			config.TaskStatusCompleted: 0,
+			config.TaskStatusFailed:    0,
+			config.TaskStatusCanceled:  0,
+			config.TaskStatusDead:      0,
+		},
+	}
+	for _, table := range rowCountTables {
+		var output int64 = -1
+		tx := d.db.WithContext(ctx).Table(table).Count(&output)
+		if tx.Error != nil {
+			log.Error().Err(tx.Error).Msgf("Cannot count the rows of %s", table)
+			output = -1
+		}
+		counts.Tables[table] = output
+	}
+
+	// select status, COUNT(*) from tasks group by status;
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	tx := d.db.WithContext(ctx).
+		Table("tasks").
+		Select("COALESCE(status, '') AS status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows)
+	if tx.Error != nil {
+		log.Error().Err(tx.Error).Msg("Cannot count tasks by status")
+		return counts
+	}
+	for _, row := range rows {
+		counts.TasksByStatus[row.Status] = row.Count
+	}
+	return counts
+}
+
+// TopOrgRowCounts returns the limit orgs owning the most rows of the table given by sortBy,
+// repository_configurations when empty
+func (d metricsDaoImpl) TopOrgRowCounts(ctx context.Context, sortBy string, limit int) ([]api.OrgRowCountsResponse, error) {
+	ctx = db.WithQueryClass(ctx, db.QueryReport)
+	if sortBy == "" {
+		sortBy = "repository_configurations"
+	}
+	column, ok := orgRowCountsSort[sortBy]
+	if !ok {
+		return nil, &ce.DaoError{BadValidation: true, Message: fmt.Sprintf("sort_by must be one of repository_configurations, snapshots or tasks, not %q", sortBy)}
+	}
+
+	counts := []api.OrgRowCountsResponse{}
+	tx := d.db.WithContext(ctx).Raw(`
+		SELECT org_id,
+		       SUM(repository_configurations) AS repository_configurations,
+		       SUM(snapshots) AS snapshots,
+		       SUM(tasks) AS tasks
+		FROM (
+		  SELECT org_id, COUNT(*) AS repository_configurations, 0 AS snapshots, 0 AS tasks
+		  FROM repository_configurations GROUP BY org_id
+		  UNION ALL
+		  SELECT rc.org_id, 0, COUNT(*), 0
+		  FROM snapshots s JOIN repository_configurations rc ON rc.uuid = s.repository_configuration_uuid
+		  GROUP BY rc.org_id
+		  UNION ALL
+		  SELECT org_id, 0, 0, COUNT(*)
+		  FROM tasks WHERE org_id IS NOT NULL GROUP BY org_id
+		) counts
+		GROUP BY org_id
+		ORDER BY `+column+` DESC, org_id
+		LIMIT ?`, limit).
+		Scan(&counts)
+	if tx.Error != nil {
+		return nil, DBErrorToApi(tx.Error)
+	}
+	return counts, nil
+}
@@ -2,20 +2,40 @@
 
 package dao
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	api "github.com/content-services/content-sources-backend/pkg/api"
+
+	mock "github.com/stretchr/testify/mock"
+)
 
 // MockMetricsDao is an autogenerated mock type for the MetricsDao type
 type MockMetricsDao struct {
 	mock.Mock
 }
 
-// OrganizationTotal provides a mock function with given fields:
-func (_m *MockMetricsDao) OrganizationTotal() int64 {
-	ret := _m.Called()
+// OldestQueuedTaskAge provides a mock function with given fields: ctx
+func (_m *MockMetricsDao) OldestQueuedTaskAge(ctx context.Context) float64 {
+	ret := _m.Called(ctx)
+
+	var r0 float64
+	if rf, ok := ret.Get(0).(func(context.Context) float64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(float64)
+	}
+
+	return r0
+}
+
+// OrganizationTotal provides a mock function with given fields: ctx
+func (_m *MockMetricsDao) OrganizationTotal(ctx context.Context) int64 {
+	ret := _m.Called(ctx)
 
 	var r0 int64
-	if rf, ok := ret.Get(0).(func() int64); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
 	} else {
 		r0 = ret.Get(0).(int64)
 	}
@@ -23,13 +43,13 @@ func (_m *MockMetricsDao) OrganizationTotal() int64 {
 	return r0
 }
 
-// PublicRepositoriesFailedIntrospectionCount provides a mock function with given fields:
-func (_m *MockMetricsDao) PublicRepositoriesFailedIntrospectionCount() int {
-	ret := _m.Called()
+// PublicRepositoriesFailedIntrospectionCount provides a mock function with given fields: ctx
+func (_m *MockMetricsDao) PublicRepositoriesFailedIntrospectionCount(ctx context.Context) int {
+	ret := _m.Called(ctx)
 
 	var r0 int
-	if rf, ok := ret.Get(0).(func() int); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(ctx)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
@@ -37,13 +57,13 @@ func (_m *MockMetricsDao) PublicRepositoriesFailedIntrospectionCount() int {
 	return r0
 }
 
-// RepositoriesCount provides a mock function with given fields:
-func (_m *MockMetricsDao) RepositoriesCount() int {
-	ret := _m.Called()
+// RepositoriesCount provides a mock function with given fields: ctx
+func (_m *MockMetricsDao) RepositoriesCount(ctx context.Context) int {
+	ret := _m.Called(ctx)
 
 	var r0 int
-	if rf, ok := ret.Get(0).(func() int); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(ctx)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
@@ -51,13 +71,13 @@ func (_m *MockMetricsDao) RepositoriesCount() int {
 	return r0
 }
 
-// RepositoriesIntrospectionCount provides a mock function with given fields: hours, public
-func (_m *MockMetricsDao) RepositoriesIntrospectionCount(hours int, public bool) IntrospectionCount {
-	ret := _m.Called(hours, public)
+// RepositoriesIntrospectionCount provides a mock function with given fields: ctx, hours, public
+func (_m *MockMetricsDao) RepositoriesIntrospectionCount(ctx context.Context, hours int, public bool) IntrospectionCount {
+	ret := _m.Called(ctx, hours, public)
 
 	var r0 IntrospectionCount
-	if rf, ok := ret.Get(0).(func(int, bool) IntrospectionCount); ok {
-		r0 = rf(hours, public)
+	if rf, ok := ret.Get(0).(func(context.Context, int, bool) IntrospectionCount); ok {
+		r0 = rf(ctx, hours, public)
 	} else {
 		r0 = ret.Get(0).(IntrospectionCount)
 	}
@@ -65,13 +85,13 @@ func (_m *MockMetricsDao) RepositoriesIntrospectionCount(hours int, public bool)
 	return r0
 }
 
-// RepositoryConfigsCount provides a mock function with given fields:
-func (_m *MockMetricsDao) RepositoryConfigsCount() int {
-	ret := _m.Called()
+// RepositoryConfigsCount provides a mock function with given fields: ctx
+func (_m *MockMetricsDao) RepositoryConfigsCount(ctx context.Context) int {
+	ret := _m.Called(ctx)
 
 	var r0 int
-	if rf, ok := ret.Get(0).(func() int); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(ctx)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
@@ -79,6 +99,46 @@ func (_m *MockMetricsDao) RepositoryConfigsCount() int {
 	return r0
 }
 
+// RowCounts provides a mock function with given fields: ctx
+func (_m *MockMetricsDao) RowCounts(ctx context.Context) RowCounts {
+	ret := _m.Called(ctx)
+
+	var r0 RowCounts
+	if rf, ok := ret.Get(0).(func(context.Context) RowCounts); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(RowCounts)
+	}
+
+	return r0
+}
+
+// TopOrgRowCounts provides a mock function with given fields: ctx, sortBy, limit
+func (_m *MockMetricsDao) TopOrgRowCounts(ctx context.Context, sortBy string, limit int) ([]api.OrgRowCountsResponse, error) {
+	ret := _m.Called(ctx, sortBy, limit)
+
+	var r0 []api.OrgRowCountsResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) ([]api.OrgRowCountsResponse, error)); ok {
+		return rf(ctx, sortBy, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) []api.OrgRowCountsResponse); ok {
+		r0 = rf(ctx, sortBy, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]api.OrgRowCountsResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, sortBy, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 type mockConstructorTestingTNewMockMetricsDao interface {
 	mock.TestingT
 	Cleanup(func())
@@ -1,12 +1,15 @@
 package dao
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/content-services/content-sources-backend/pkg/config"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
 	"github.com/content-services/content-sources-backend/pkg/models"
 	"github.com/content-services/content-sources-backend/pkg/seeds"
+	"github.com/google/uuid"
 	"github.com/lib/pq"
 	"github.com/openlyinc/pointy"
 	"github.com/stretchr/testify/assert"
@@ -30,23 +33,23 @@ func (s *MetricsSuite) SetupTest() {
 	s.DaoSuite.SetupTest()
 	s.dao = GetMetricsDao(s.tx)
 
-	s.initialRepoCount = s.dao.RepositoriesCount()
+	s.initialRepoCount = s.dao.RepositoriesCount(context.Background())
 	if s.tx.Error != nil {
 		s.FailNow(s.tx.Error.Error())
 	}
-	s.initialRepositoryConfigsCount = s.dao.RepositoryConfigsCount()
+	s.initialRepositoryConfigsCount = s.dao.RepositoryConfigsCount(context.Background())
 	if s.tx.Error != nil {
 		s.FailNow(s.tx.Error.Error())
 	}
-	s.initialPublicRepositoriesIntrospectionCount = s.dao.RepositoriesIntrospectionCount(36, true)
+	s.initialPublicRepositoriesIntrospectionCount = s.dao.RepositoriesIntrospectionCount(context.Background(), 36, true)
 	if s.tx.Error != nil {
 		s.FailNow(s.tx.Error.Error())
 	}
-	s.initialCustomRepositoriesIntrospectionCount = s.dao.RepositoriesIntrospectionCount(36, false)
+	s.initialCustomRepositoriesIntrospectionCount = s.dao.RepositoriesIntrospectionCount(context.Background(), 36, false)
 	if s.tx.Error != nil {
 		s.FailNow(s.tx.Error.Error())
 	}
-	s.initialPublicRepositoriesFailedIntrospectionCount = s.dao.PublicRepositoriesFailedIntrospectionCount()
+	s.initialPublicRepositoriesFailedIntrospectionCount = s.dao.PublicRepositoriesFailedIntrospectionCount(context.Background())
 	if s.tx.Error != nil {
 		s.FailNow(s.tx.Error.Error())
 	}
@@ -79,7 +82,7 @@ func (s *MetricsSuite) TestOrganizationCount() {
 	assert.Nil(t, err)
 
 	// The initial state should be 0
-	result = dao.OrganizationTotal()
+	result = dao.OrganizationTotal(context.Background())
 	assert.True(t, result > 0)
 }
 
@@ -89,7 +92,7 @@ func (s *MetricsSuite) TestRepositoriesCount() {
 	var result int
 
 	// The initial state should be 0
-	result = dao.RepositoriesCount()
+	result = dao.RepositoriesCount(context.Background())
 	assert.Equal(t, 0, result-s.initialRepoCount)
 
 	// The counter is increased by 1
@@ -104,7 +107,7 @@ func (s *MetricsSuite) TestRepositoriesCount() {
 		PackageCount:                 0,
 	})
 
-	result = dao.RepositoriesCount()
+	result = dao.RepositoriesCount(context.Background())
 	assert.Equal(t, 1, result-s.initialRepoCount)
 }
 
@@ -117,7 +120,7 @@ func (s *MetricsSuite) TestRepositoryConfigsCount() {
 	)
 
 	// The initial state should be 0
-	result = dao.RepositoryConfigsCount()
+	result = dao.RepositoryConfigsCount(context.Background())
 	assert.Equal(t, 0, result-s.initialRepositoryConfigsCount)
 
 	// The counter is increased by 1
@@ -138,7 +141,7 @@ func (s *MetricsSuite) TestRepositoryConfigsCount() {
 	}).Error
 	require.NoError(t, err)
 
-	result = dao.RepositoryConfigsCount()
+	result = dao.RepositoryConfigsCount(context.Background())
 	assert.Equal(t, 1, result-s.initialRepositoryConfigsCount)
 }
 
@@ -150,7 +153,7 @@ func (s *MetricsSuite) TestPublicRepositoriesNotIntrospectedLas24HoursCount() {
 		err    error
 		repo   models.Repository
 	)
-	result = s.dao.RepositoriesIntrospectionCount(36, true)
+	result = s.dao.RepositoriesIntrospectionCount(context.Background(), 36, true)
 	assert.Equal(t, int64(0), result.Missed-s.initialPublicRepositoriesIntrospectionCount.Missed)
 
 	// This repository won't be counted for the metrics
@@ -166,7 +169,7 @@ func (s *MetricsSuite) TestPublicRepositoriesNotIntrospectedLas24HoursCount() {
 	}
 	err = tx.Create(&repo).Error
 	require.NoError(t, err)
-	result = s.dao.RepositoriesIntrospectionCount(36, true)
+	result = s.dao.RepositoriesIntrospectionCount(context.Background(), 36, true)
 	assert.Equal(t, int64(0), result.Missed-s.initialPublicRepositoriesIntrospectionCount.Missed)
 
 	lastIntrospectionTime := time.Now().Add(-37 * time.Hour)
@@ -182,7 +185,7 @@ func (s *MetricsSuite) TestPublicRepositoriesNotIntrospectedLas24HoursCount() {
 	}
 	err = tx.Create(&repo).Error
 	require.NoError(t, err)
-	result = s.dao.RepositoriesIntrospectionCount(36, true)
+	result = s.dao.RepositoriesIntrospectionCount(context.Background(), 36, true)
 	assert.Equal(t, int64(1), result.Missed-s.initialPublicRepositoriesIntrospectionCount.Missed)
 
 	repo = models.Repository{
@@ -197,7 +200,7 @@ func (s *MetricsSuite) TestPublicRepositoriesNotIntrospectedLas24HoursCount() {
 	}
 	err = tx.Create(&repo).Error
 	require.NoError(t, err)
-	result = s.dao.RepositoriesIntrospectionCount(36, true)
+	result = s.dao.RepositoriesIntrospectionCount(context.Background(), 36, true)
 	assert.Equal(t, int64(2), result.Missed-s.initialPublicRepositoriesIntrospectionCount.Missed)
 }
 
@@ -208,7 +211,7 @@ func (s *MetricsSuite) TestPublicRepositoriesFailedIntrospectionCount() {
 		err    error
 		repo   models.Repository
 	)
-	result = s.dao.PublicRepositoriesFailedIntrospectionCount()
+	result = s.dao.PublicRepositoriesFailedIntrospectionCount(context.Background())
 	assert.Equal(t, 0, result-s.initialPublicRepositoriesFailedIntrospectionCount)
 
 	lastIntrospectionTime := time.Now().Add(-37 * time.Hour)
@@ -224,7 +227,7 @@ func (s *MetricsSuite) TestPublicRepositoriesFailedIntrospectionCount() {
 	}
 	err = s.tx.Create(&repo).Error
 	require.NoError(t, err)
-	result = s.dao.PublicRepositoriesFailedIntrospectionCount()
+	result = s.dao.PublicRepositoriesFailedIntrospectionCount(context.Background())
 	assert.Equal(t, 1, result-s.initialPublicRepositoriesFailedIntrospectionCount)
 }
 
@@ -236,7 +239,7 @@ func (s *MetricsSuite) TestNonPublicRepositoriesNonIntrospectedLast24HoursCount(
 		repo   models.Repository
 	)
 
-	result = s.dao.RepositoriesIntrospectionCount(36, false)
+	result = s.dao.RepositoriesIntrospectionCount(context.Background(), 36, false)
 	assert.Equal(t, int64(0), result.Missed-s.initialCustomRepositoriesIntrospectionCount.Missed)
 
 	lastIntrospectionTime := time.Now().Add(-38 * time.Hour)
@@ -252,6 +255,67 @@ func (s *MetricsSuite) TestNonPublicRepositoriesNonIntrospectedLast24HoursCount(
 	}
 	err = s.tx.Create(&repo).Error
 	require.NoError(t, err)
-	result = s.dao.RepositoriesIntrospectionCount(36, false)
+	result = s.dao.RepositoriesIntrospectionCount(context.Background(), 36, false)
 	assert.Equal(t, int64(1), result.Missed-s.initialCustomRepositoriesIntrospectionCount.Missed)
 }
+
+func (s *MetricsSuite) TestOldestQueuedTaskAge() {
+	t := s.T()
+	queued := time.Now().Add(-time.Hour)
+	started := time.Now().Add(-2 * time.Hour)
+	err := s.tx.Create([]models.TaskInfo{
+		{Id: uuid.New(), Typename: "introspect", Queued: &queued, Status: "pending", Token: uuid.New()},
+		// Running tasks are not waiting in the queue
+		{Id: uuid.New(), Typename: "introspect", Queued: &started, Started: &started, Status: "running", Token: uuid.New()},
+	}).Error
+	require.NoError(t, err)
+
+	age := s.dao.OldestQueuedTaskAge(context.Background())
+	assert.GreaterOrEqual(t, age, time.Hour.Seconds())
+	assert.Less(t, age, (2 * time.Hour).Seconds())
+}
+
+func (s *MetricsSuite) TestRowCounts() {
+	t := s.T()
+	before := s.dao.RowCounts(context.Background())
+
+	err := seeds.SeedRepositoryConfigurations(s.tx, 2, seeds.SeedOptions{})
+	require.NoError(t, err)
+	queued := time.Now()
+	err = s.tx.Create([]models.TaskInfo{
+		{Id: uuid.New(), Typename: "introspect", Queued: &queued, Status: config.TaskStatusPending, Token: uuid.New()},
+		{Id: uuid.New(), Typename: "introspect", Queued: &queued, Status: config.TaskStatusFailed, Token: uuid.New()},
+	}).Error
+	require.NoError(t, err)
+
+	after := s.dao.RowCounts(context.Background())
+	assert.Equal(t, int64(2), after.Tables["repository_configurations"]-before.Tables["repository_configurations"])
+	assert.Equal(t, int64(2), after.Tables["tasks"]-before.Tables["tasks"])
+	assert.Equal(t, int64(1), after.TasksByStatus[config.TaskStatusPending]-before.TasksByStatus[config.TaskStatusPending])
+	assert.Equal(t, int64(1), after.TasksByStatus[config.TaskStatusFailed]-before.TasksByStatus[config.TaskStatusFailed])
+	assert.Contains(t, after.TasksByStatus, config.TaskStatusDead)
+}
+
+func (s *MetricsSuite) TestTopOrgRowCounts() {
+	t := s.T()
+	orgID := seeds.RandomOrgId()
+	err := seeds.SeedRepositoryConfigurations(s.tx, 3, seeds.SeedOptions{OrgID: orgID})
+	require.NoError(t, err)
+
+	counts, err := s.dao.TopOrgRowCounts(context.Background(), "", 1000)
+	require.NoError(t, err)
+	found := false
+	for _, count := range counts {
+		if count.OrgID == orgID {
+			found = true
+			assert.Equal(t, int64(3), count.RepositoryConfigurations)
+		}
+	}
+	assert.True(t, found)
+
+	_, err = s.dao.TopOrgRowCounts(context.Background(), "rpms", 10)
+	assert.Error(t, err)
+	daoError, ok := err.(*ce.DaoError)
+	require.True(t, ok)
+	assert.True(t, daoError.BadValidation)
+}
@@ -0,0 +1,113 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/content-services/content-sources-backend/pkg/db"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"gorm.io/gorm"
+)
+
+// OrgMigration counts the repository configurations moved by InternalOnly_MigrateOrg
+type OrgMigration struct {
+	Moved       int  // Moved to the target org
+	Merged      int  // Merged into the repository config of the target org for the same url
+	Renamed     int  // Moved under another name, the target org having a repository config of the same name
+	DomainMoved bool // Whether the pulp domain of the source org now belongs to the target org
+}
+
+// InternalOnly_MigrateOrg moves the repository configurations of an org, along with their snapshots,
+// to another org.  A repository config whose url the target org already has is merged into the
+// repository config of the target org, which takes over its snapshots, while one whose name is taken
+// in the target org is suffixed with the source org.  Repository configs pending deletion are left to
+// the delete task of the source org.
+//
+// The pulp domain of the source org moves to the target org if the target org has none.  Otherwise it
+// is kept by the source org: snapshots are served from the domain of their repository path, so the
+// moved snapshots are still served, and new snapshots are created in the domain of the target org.
+func (r repositoryConfigDaoImpl) InternalOnly_MigrateOrg(ctx context.Context, sourceOrgID string, targetOrgID string, accountID string) (OrgMigration, error) {
+	ctx = db.WithQueryClass(ctx, db.QueryBulk)
+	migration := OrgMigration{}
+	if sourceOrgID == "" || targetOrgID == "" || sourceOrgID == targetOrgID {
+		return migration, &ce.DaoError{BadValidation: true, Message: "The source and target orgs must be given and differ"}
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var sourceConfigs []models.RepositoryConfiguration
+		if err := tx.Where("org_id = ?", sourceOrgID).Find(&sourceConfigs).Error; err != nil {
+			return err
+		}
+		for _, source := range sourceConfigs {
+			var conflicts []models.RepositoryConfiguration
+			err := tx.Where("org_id = ? AND (repository_uuid = ? OR name = ?)", targetOrgID, source.RepositoryUUID, source.Name).
+				Find(&conflicts).Error
+			if err != nil {
+				return err
+			}
+			if target := findRepoConfig(conflicts, source.RepositoryUUID); target != nil {
+				if err = mergeRepoConfig(tx, source, *target); err != nil {
+					return err
+				}
+				migration.Merged++
+				continue
+			}
+
+			updates := map[string]interface{}{"org_id": targetOrgID}
+			if accountID != "" {
+				updates["account_id"] = accountID
+			}
+			if len(conflicts) > 0 {
+				updates["name"] = fmt.Sprintf("%s (%s)", source.Name, sourceOrgID)
+				migration.Renamed++
+			}
+			err = tx.Model(&models.RepositoryConfiguration{}).Where("uuid = ?", source.UUID).UpdateColumns(updates).Error
+			if err != nil {
+				return err
+			}
+			migration.Moved++
+		}
+
+		var domains []models.Domain
+		if err := tx.Where("org_id IN ?", []string{sourceOrgID, targetOrgID}).Find(&domains).Error; err != nil {
+			return err
+		}
+		if len(domains) == 1 && domains[0].OrgId == sourceOrgID {
+			err := tx.Model(&models.Domain{}).Where("org_id = ?", sourceOrgID).Update("org_id", targetOrgID).Error
+			if err != nil {
+				return err
+			}
+			migration.DomainMoved = true
+		}
+		return nil
+	})
+	if err != nil {
+		return OrgMigration{}, DBErrorToApi(err)
+	}
+	return migration, nil
+}
+
+// findRepoConfig returns the repository config of the repository, nil if there is none
+func findRepoConfig(repoConfigs []models.RepositoryConfiguration, repoUUID string) *models.RepositoryConfiguration {
+	for i := range repoConfigs {
+		if repoConfigs[i].RepositoryUUID == repoUUID {
+			return &repoConfigs[i]
+		}
+	}
+	return nil
+}
+
+// mergeRepoConfig moves the snapshots of source to target, then removes source
+func mergeRepoConfig(tx *gorm.DB, source models.RepositoryConfiguration, target models.RepositoryConfiguration) error {
+	err := tx.Model(&models.Snapshot{}).
+		Where("repository_configuration_uuid = ?", source.UUID).
+		UpdateColumn("repository_configuration_uuid", target.UUID).Error
+	if err != nil {
+		return err
+	}
+	if err = tx.Unscoped().Delete(&models.RepositoryConfiguration{}, "uuid = ?", source.UUID).Error; err != nil {
+		return err
+	}
+	return updateLastSnapshot(tx, target.UUID)
+}
@@ -0,0 +1,117 @@
+package dao
+
+import (
+	"context"
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/seeds"
+	"github.com/openlyinc/pointy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type OrgMigrationSuite struct {
+	*DaoSuite
+}
+
+func TestOrgMigrationSuite(t *testing.T) {
+	m := DaoSuite{}
+	r := OrgMigrationSuite{DaoSuite: &m}
+	suite.Run(t, &r)
+}
+
+func (s *OrgMigrationSuite) createRepoConfig(orgID string, name string, url string) api.RepositoryResponse {
+	created, err := GetRepositoryConfigDao(s.tx).Create(context.Background(), api.RepositoryRequest{
+		Name:      pointy.String(name),
+		URL:       pointy.String(url),
+		OrgID:     pointy.String(orgID),
+		AccountID: pointy.String(seeds.RandomAccountId()),
+	})
+	require.NoError(s.T(), err)
+	return created
+}
+
+func (s *OrgMigrationSuite) TestMigrateOrg() {
+	t := s.T()
+	ctx := context.Background()
+	rDao := GetRepositoryConfigDao(s.tx)
+	sourceOrg := seeds.RandomOrgId()
+	targetOrg := seeds.RandomOrgId()
+
+	merged := s.createRepoConfig(sourceOrg, "merged", "http://merged.example.com/")
+	renamed := s.createRepoConfig(sourceOrg, "renamed", "http://renamed.example.com/")
+	moved := s.createRepoConfig(sourceOrg, "moved", "http://moved.example.com/")
+	mergedInto := s.createRepoConfig(targetOrg, "merged into", "http://merged.example.com/")
+	s.createRepoConfig(targetOrg, "renamed", "http://other.example.com/")
+
+	snap := models.Snapshot{
+		VersionHref:                 "/pulp/version",
+		PublicationHref:             "/pulp/publication",
+		DistributionPath:            "/path/to/merged",
+		RepositoryConfigurationUUID: merged.UUID,
+	}
+	require.NoError(t, snapshotDaoImpl{db: s.tx}.Create(ctx, &snap))
+	domainName, err := domainDaoImpl{db: s.tx}.Create(ctx, sourceOrg)
+	require.NoError(t, err)
+
+	migration, err := rDao.InternalOnly_MigrateOrg(ctx, sourceOrg, targetOrg, "targetAccount")
+	assert.NoError(t, err)
+	assert.Equal(t, OrgMigration{Moved: 2, Merged: 1, Renamed: 1, DomainMoved: true}, migration)
+
+	// The repository config merged into the one of the target org hands over its snapshots
+	_, err = rDao.Fetch(ctx, sourceOrg, merged.UUID)
+	assert.Error(t, err)
+	response, err := rDao.Fetch(ctx, targetOrg, mergedInto.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, snap.UUID, response.LastSnapshotUUID)
+
+	response, err = rDao.Fetch(ctx, targetOrg, renamed.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, "renamed ("+sourceOrg+")", response.Name)
+	assert.Equal(t, "targetAccount", response.AccountID)
+
+	response, err = rDao.Fetch(ctx, targetOrg, moved.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, "moved", response.Name)
+
+	found, err := domainDaoImpl{db: s.tx}.Fetch(ctx, targetOrg)
+	assert.NoError(t, err)
+	assert.Equal(t, domainName, found)
+
+	// Migrating again has nothing left to move
+	migration, err = rDao.InternalOnly_MigrateOrg(ctx, sourceOrg, targetOrg, "targetAccount")
+	assert.NoError(t, err)
+	assert.Equal(t, OrgMigration{}, migration)
+}
+
+func (s *OrgMigrationSuite) TestMigrateOrgKeepsDomainOfTarget() {
+	t := s.T()
+	ctx := context.Background()
+	sourceOrg := seeds.RandomOrgId()
+	targetOrg := seeds.RandomOrgId()
+	s.createRepoConfig(sourceOrg, "moved", "http://moved.example.com/")
+	sourceDomain, err := domainDaoImpl{db: s.tx}.Create(ctx, sourceOrg)
+	require.NoError(t, err)
+	targetDomain, err := domainDaoImpl{db: s.tx}.Create(ctx, targetOrg)
+	require.NoError(t, err)
+
+	migration, err := GetRepositoryConfigDao(s.tx).InternalOnly_MigrateOrg(ctx, sourceOrg, targetOrg, "")
+	assert.NoError(t, err)
+	assert.Equal(t, OrgMigration{Moved: 1}, migration)
+
+	found, err := domainDaoImpl{db: s.tx}.Fetch(ctx, sourceOrg)
+	assert.NoError(t, err)
+	assert.Equal(t, sourceDomain, found)
+	found, err = domainDaoImpl{db: s.tx}.Fetch(ctx, targetOrg)
+	assert.NoError(t, err)
+	assert.Equal(t, targetDomain, found)
+}
+
+func (s *OrgMigrationSuite) TestMigrateOrgSameOrg() {
+	orgID := seeds.RandomOrgId()
+	_, err := GetRepositoryConfigDao(s.tx).InternalOnly_MigrateOrg(context.Background(), orgID, orgID, "")
+	assert.Error(s.T(), err)
+}
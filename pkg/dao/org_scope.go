@@ -0,0 +1,28 @@
+package dao
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrMissingOrgID is returned by queries scoped with WithOrg when no org id is given
+var ErrMissingOrgID = errors.New("org id can not be an empty string")
+
+// WithOrg scopes a query to the rows owned by orgID, using the org_id column
+// of the statement's table.  Every query against org owned tables
+// (repository_configurations, tasks) must go through it, an empty org id
+// fails the query rather than matching rows of every org.
+func WithOrg(orgID string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if orgID == "" {
+			_ = db.AddError(ErrMissingOrgID)
+			return db
+		}
+		return db.Where(clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: "org_id"},
+			Value:  orgID,
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package dao
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// orgScopedDaos lists the DAO implementations reading or writing org owned data,
+// with the exported methods that are allowed to not take an org id.
+var orgScopedDaos = map[string][]string{
+	"repositoryConfigDaoImpl": {
+		"Create",          // org id is part of the request
+		"BulkCreate",      // org id is part of each request
+		"BulkImport",      // org id is part of each request
+		"SavePublicRepos", // public repositories do not belong to an org
+		"InternalOnly_FetchRepoConfigsForRepoUUID", // used by introspection across orgs
+		"InternalOnly_FetchPendingDelete",          // used by the nightly cleanup across orgs
+		"InternalOnly_FetchFailingByOrg",           // used by the weekly digest across orgs
+		"InternalOnly_RotateSecrets",               // used by rotate-keys across orgs
+		"InternalOnly_MigrateOrg",                  // moves the data of a source org to a target org
+	},
+	"rpmDaoImpl": {
+		"RepositoryRpmListFromModelToResponse",
+		"InsertForRepository", // rpms are shared by every org
+		"InsertCapabilities",  // rpms are shared by every org
+		"PackageChanges",      // rpms are shared by every org
+		"OrphanCleanup",
+	},
+	"snapshotDaoImpl": {
+		"Create",                 // created by the snapshot task of a repository configuration
+		"List",                   // the handler fetches the repository configuration of the org first
+		"Fetch",                  // the handler fetches the repository configuration of the org first
+		"FetchForRepoConfigUUID", // used by tasks of a repository configuration
+		"UpdateVerification",     // used by the verification task
+		"Delete",                 // used by the delete task of a repository configuration
+	},
+	"webhookDaoImpl": {
+		"Create",                 // org id is part of the request
+		"DeleteDeliveriesBefore", // used by the cleanup across orgs
+	},
+	"idempotencyDaoImpl": {
+		"DeleteExpired", // used by the cleanup across orgs
+	},
+	"usageDaoImpl": {
+		"Record", // org id is part of each count
+		"List",   // internal report across orgs, optionally filtered by org
+	},
+	"domainDaoImpl":      {},
+	"taskInfoDaoImpl":    {},
+	"orgSettingsDaoImpl": {},
+}
+
+// unscopedDaos lists the DAO implementations that do not handle org owned data
+var unscopedDaos = map[string]string{
+	"repositoryDaoImpl":    "repositories and their rpms are shared by every org",
+	"metricsDaoImpl":       "metrics are aggregated over every org",
+	"adminTaskInfoDaoImpl": "admin tasks are listed across orgs for administrators",
+}
+
+// orgFilterCalls are the query builder methods passing the org id to the database
+var orgFilterCalls = map[string]bool{
+	"Where": true, "Or": true, "Not": true, "Joins": true, "Having": true, "Scopes": true, "WithOrg": true,
+	"Raw": true, "Exec": true, "First": true, "Find": true, "Take": true, "Delete": true,
+}
+
+// TestDaoMethodsAreOrgScoped parses the dao package and fails when a DAO implementation is
+// neither org scoped nor exempted, when a method of an org scoped DAO takes no org id without
+// being allowed to, or when it takes an org id but does not pass it to a query.
+func TestDaoMethodsAreOrgScoped(t *testing.T) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go") && !strings.HasSuffix(info.Name(), "_mock.go")
+	}, 0)
+	require.NoError(t, err)
+	files := pkgs["dao"].Files
+	funcs := packageFuncs(files)
+
+	daos := daoImplTypes(files)
+	require.NotEmpty(t, daos)
+	for _, dao := range daos {
+		_, scoped := orgScopedDaos[dao]
+		_, exempted := unscopedDaos[dao]
+		assert.True(t, scoped != exempted, "%s must be listed in either orgScopedDaos or unscopedDaos", dao)
+	}
+
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || len(fn.Recv.List) == 0 {
+				continue
+			}
+			receiver := receiverName(fn.Recv.List[0].Type)
+			allowed, scoped := orgScopedDaos[receiver]
+			if !scoped {
+				continue
+			}
+
+			orgParam := orgIDParam(fn)
+			method := receiver + "." + fn.Name.Name
+			if orgParam == "" {
+				if fn.Name.IsExported() {
+					assert.Contains(t, allowed, fn.Name.Name, "%s does not take an org id", method)
+				}
+				continue
+			}
+			assert.True(t, filtersOnOrg(fn, orgParam, funcs), "%s does not filter on its org id %s", method, orgParam)
+		}
+	}
+}
+
+func TestFiltersOnOrg(t *testing.T) {
+	src := `package dao
+func (r testDaoImpl) Filtered(ctx context.Context, orgID string) { r.db.Where("org_id = ?", orgID).Find(&x) }
+func (r testDaoImpl) Scoped(ctx context.Context, orgID string) { r.db.Scopes(WithOrg(orgID)).Find(&x) }
+func (r testDaoImpl) Delegated(ctx context.Context, orgID string) { r.fetch(ctx, orgID) }
+func (r testDaoImpl) Created(ctx context.Context, orgID string) { r.db.Create(&models.Domain{OrgID: orgID}) }
+func (r testDaoImpl) Defaulted(ctx context.Context, orgID string) { s := models.DefaultOrgSettings(orgID); r.db.Create(&s) }
+func (r testDaoImpl) Copied(ctx context.Context, orgID string) { readOnly(ctx, r.db, func(conn *gorm.DB) error { return testDaoImpl{db: conn}.list(ctx, orgID) }) }
+func (r testDaoImpl) Helped(ctx context.Context, orgID string) { helper(r.db, orgID) }
+func (r testDaoImpl) Unhelped(ctx context.Context, orgID string) { unfiltered(r.db, orgID) }
+func (r testDaoImpl) Logged(ctx context.Context, orgID string) { log.Info().Str("org_id", orgID).Msg("unfiltered"); r.db.Find(&x) }
+func (r testDaoImpl) Unused(ctx context.Context, orgID string) { r.db.Find(&x) }
+`
+	helpers := `package dao
+func helper(tx *gorm.DB, orgID string) { tx.Where("org_id = ?", orgID).Find(&x) }
+func unfiltered(tx *gorm.DB, orgID string) { tx.Find(&x) }
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "dao.go", src, 0)
+	require.NoError(t, err)
+	helpersFile, err := parser.ParseFile(fset, "helpers.go", helpers, 0)
+	require.NoError(t, err)
+	funcs := packageFuncs(map[string]*ast.File{"helpers.go": helpersFile})
+
+	expected := map[string]bool{"Filtered": true, "Scoped": true, "Delegated": true, "Created": true, "Defaulted": true,
+		"Copied": true, "Helped": true, "Logged": false, "Unused": false, "Unhelped": false}
+	for _, decl := range file.Decls {
+		fn := decl.(*ast.FuncDecl)
+		assert.Equal(t, expected[fn.Name.Name], filtersOnOrg(fn, orgIDParam(fn), funcs), fn.Name.Name)
+	}
+}
+
+// daoImplTypes returns the names of the struct types implementing the DAOs
+func daoImplTypes(files map[string]*ast.File) []string {
+	var names []string
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				if _, isStruct := typeSpec.Type.(*ast.StructType); isStruct && strings.HasSuffix(typeSpec.Name.Name, "DaoImpl") {
+					names = append(names, typeSpec.Name.Name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// packageFuncs returns the functions of the package, by name
+func packageFuncs(files map[string]*ast.File) map[string]*ast.FuncDecl {
+	funcs := map[string]*ast.FuncDecl{}
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil {
+				funcs[fn.Name.Name] = fn
+			}
+		}
+	}
+	return funcs
+}
+
+// filtersOnOrg returns whether the org id is passed to a query builder or set as the org of a
+// model.  Passing it on to another method of a DAO also counts, as that method is checked in
+// turn, and so does passing it to a function of the package filtering on it.
+func filtersOnOrg(fn *ast.FuncDecl, orgParam string, funcs map[string]*ast.FuncDecl) bool {
+	receiver := ""
+	if fn.Recv != nil {
+		if names := fn.Recv.List[0].Names; len(names) > 0 {
+			receiver = names[0].Name
+		}
+	}
+	found := false
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.CallExpr:
+			for i, arg := range node.Args {
+				if !usesIdent(arg, orgParam) {
+					continue
+				}
+				if isOrgFilterCall(node.Fun, receiver) || helperFiltersOnOrg(node.Fun, i, fn, funcs) {
+					found = true
+				}
+			}
+		case *ast.KeyValueExpr:
+			if key, ok := node.Key.(*ast.Ident); ok && strings.EqualFold(key.Name, "OrgID") && usesIdent(node.Value, orgParam) {
+				found = true
+			}
+		case *ast.AssignStmt:
+			for i, lhs := range node.Lhs {
+				if sel, ok := lhs.(*ast.SelectorExpr); ok && strings.EqualFold(sel.Sel.Name, "OrgID") &&
+					i < len(node.Rhs) && usesIdent(node.Rhs[i], orgParam) {
+					found = true
+				}
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// isOrgFilterCall returns whether fun is a query builder method, a method of the DAO or of
+// a copy of it, or a constructor of a model
+func isOrgFilterCall(fun ast.Expr, receiver string) bool {
+	switch f := fun.(type) {
+	case *ast.Ident:
+		return orgFilterCalls[f.Name]
+	case *ast.SelectorExpr:
+		switch x := f.X.(type) {
+		case *ast.Ident:
+			if x.Name == receiver || x.Name == "models" {
+				return true
+			}
+		case *ast.CompositeLit:
+			if typeName, ok := x.Type.(*ast.Ident); ok && strings.HasSuffix(typeName.Name, "DaoImpl") {
+				return true
+			}
+		}
+		return orgFilterCalls[f.Sel.Name]
+	}
+	return false
+}
+
+// helperFiltersOnOrg returns whether fun is a function of the package filtering on its
+// argument at index arg
+func helperFiltersOnOrg(fun ast.Expr, arg int, caller *ast.FuncDecl, funcs map[string]*ast.FuncDecl) bool {
+	ident, ok := fun.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	helper, ok := funcs[ident.Name]
+	if !ok || helper == caller {
+		return false
+	}
+	index := 0
+	for _, field := range helper.Type.Params.List {
+		for _, name := range field.Names {
+			if index == arg {
+				return filtersOnOrg(helper, name.Name, funcs)
+			}
+			index++
+		}
+	}
+	return false
+}
+
+func receiverName(expr ast.Expr) string {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+func orgIDParam(fn *ast.FuncDecl) string {
+	for _, field := range fn.Type.Params.List {
+		for _, name := range field.Names {
+			if strings.EqualFold(name.Name, "orgID") {
+				return name.Name
+			}
+		}
+	}
+	return ""
+}
+
+func usesIdent(node ast.Node, name string) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && ident.Name == name {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
@@ -0,0 +1,104 @@
+package dao
+
+import (
+	"context"
+	"github.com/content-services/content-sources-backend/pkg/api"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/notifications"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type orgSettingsDaoImpl struct {
+	db *gorm.DB
+}
+
+func GetOrgSettingsDao(db *gorm.DB) OrgSettingsDao {
+	return orgSettingsDaoImpl{db: db}
+}
+
+// Fetch returns the settings of the org, or the defaults if it has not saved any
+func (o orgSettingsDaoImpl) Fetch(ctx context.Context, orgID string) (api.OrgSettingsResponse, error) {
+	settings := models.OrgSettings{}
+	err := o.db.WithContext(ctx).Where("org_id = ?", orgID).First(&settings).Error
+	if err == gorm.ErrRecordNotFound {
+		return orgSettingsModelToApi(models.DefaultOrgSettings(orgID)), nil
+	}
+	if err != nil {
+		return api.OrgSettingsResponse{}, DBErrorToApi(err)
+	}
+	return orgSettingsModelToApi(settings), nil
+}
+
+// Update saves the settings of the org, resetting the unset ones to their default
+func (o orgSettingsDaoImpl) Update(ctx context.Context, orgID string, request api.OrgSettingsRequest) (api.OrgSettingsResponse, error) {
+	settings := models.DefaultOrgSettings(orgID)
+	if request.DefaultDistributionArch != nil {
+		settings.DefaultDistributionArch = *request.DefaultDistributionArch
+	}
+	if request.DefaultDistributionVersions != nil {
+		settings.DefaultDistributionVersions = *request.DefaultDistributionVersions
+	}
+	if request.DefaultMetadataVerification != nil {
+		settings.DefaultMetadataVerification = *request.DefaultMetadataVerification
+	}
+	if request.SnapshotRetention != nil {
+		settings.SnapshotRetention = *request.SnapshotRetention
+	}
+	if request.IntrospectionDigestOptOut != nil {
+		settings.IntrospectionDigestOptOut = *request.IntrospectionDigestOptOut
+	}
+	if request.NotificationOptOuts != nil {
+		for _, event := range *request.NotificationOptOuts {
+			if notifications.EventName(event).String() == "" {
+				return api.OrgSettingsResponse{}, &ce.DaoError{BadValidation: true, Message: "Unknown notification event " + event}
+			}
+		}
+		settings.NotificationOptOuts = *request.NotificationOptOuts
+	}
+
+	err := o.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "org_id"}},
+		DoUpdates: clause.AssignmentColumns(orgSettingsColumns),
+	}).Create(&settings).Error
+	if err != nil {
+		return api.OrgSettingsResponse{}, DBErrorToApi(err)
+	}
+	return orgSettingsModelToApi(settings), nil
+}
+
+// CreateDefaults saves the default settings for an org that has not saved any, and returns its settings
+func (o orgSettingsDaoImpl) CreateDefaults(ctx context.Context, orgID string) (api.OrgSettingsResponse, error) {
+	settings := models.DefaultOrgSettings(orgID)
+	err := o.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "org_id"}},
+		DoNothing: true,
+	}).Create(&settings).Error
+	if err != nil {
+		return api.OrgSettingsResponse{}, DBErrorToApi(err)
+	}
+	return o.Fetch(ctx, orgID)
+}
+
+// orgSettingsColumns are the columns replaced when an org updates its settings
+var orgSettingsColumns = []string{
+	"updated_at",
+	"introspection_digest_opt_out",
+	"default_distribution_arch",
+	"default_distribution_versions",
+	"default_metadata_verification",
+	"snapshot_retention",
+	"notification_opt_outs",
+}
+
+func orgSettingsModelToApi(settings models.OrgSettings) api.OrgSettingsResponse {
+	return api.OrgSettingsResponse{
+		DefaultDistributionArch:     settings.DefaultDistributionArch,
+		DefaultDistributionVersions: settings.DefaultDistributionVersions,
+		DefaultMetadataVerification: settings.DefaultMetadataVerification,
+		SnapshotRetention:           settings.SnapshotRetention,
+		NotificationOptOuts:         settings.NotificationOptOuts,
+		IntrospectionDigestOptOut:   settings.IntrospectionDigestOptOut,
+	}
+}
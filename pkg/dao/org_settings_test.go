@@ -0,0 +1,136 @@
+package dao
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/config"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/notifications"
+	"github.com/content-services/content-sources-backend/pkg/seeds"
+	"github.com/openlyinc/pointy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type OrgSettingsSuite struct {
+	*DaoSuite
+}
+
+func TestOrgSettingsSuite(t *testing.T) {
+	m := DaoSuite{}
+	r := OrgSettingsSuite{DaoSuite: &m}
+	suite.Run(t, &r)
+}
+
+func (s *OrgSettingsSuite) TestFetchDefaults() {
+	t := s.T()
+
+	settings, err := orgSettingsDaoImpl{db: s.tx}.Fetch(context.Background(), seeds.RandomOrgId())
+	require.NoError(t, err)
+	assert.Equal(t, config.ANY_ARCH, settings.DefaultDistributionArch)
+	assert.Equal(t, []string{config.ANY_VERSION}, settings.DefaultDistributionVersions)
+	assert.False(t, settings.DefaultMetadataVerification)
+	assert.Equal(t, 0, settings.SnapshotRetention)
+	assert.Empty(t, settings.NotificationOptOuts)
+}
+
+func (s *OrgSettingsSuite) TestUpdate() {
+	t := s.T()
+	orgID := seeds.RandomOrgId()
+	settingsDao := orgSettingsDaoImpl{db: s.tx}
+
+	_, err := settingsDao.Update(context.Background(), orgID, api.OrgSettingsRequest{
+		DefaultDistributionArch:     pointy.String(config.X8664),
+		DefaultDistributionVersions: &[]string{config.El8, config.El9},
+		SnapshotRetention:           pointy.Int(5),
+		NotificationOptOuts:         &[]string{notifications.RepositoryCreated.String()},
+	})
+	require.NoError(t, err)
+	settings, err := settingsDao.Fetch(context.Background(), orgID)
+	require.NoError(t, err)
+	assert.Equal(t, config.X8664, settings.DefaultDistributionArch)
+	assert.Equal(t, []string{config.El8, config.El9}, settings.DefaultDistributionVersions)
+	assert.Equal(t, 5, settings.SnapshotRetention)
+	assert.Equal(t, []string{notifications.RepositoryCreated.String()}, settings.NotificationOptOuts)
+
+	// Unset settings are reset to their default
+	_, err = settingsDao.Update(context.Background(), orgID, api.OrgSettingsRequest{DefaultMetadataVerification: pointy.Bool(true)})
+	require.NoError(t, err)
+	settings, err = settingsDao.Fetch(context.Background(), orgID)
+	require.NoError(t, err)
+	assert.Equal(t, config.ANY_ARCH, settings.DefaultDistributionArch)
+	assert.True(t, settings.DefaultMetadataVerification)
+	assert.Empty(t, settings.NotificationOptOuts)
+}
+
+func (s *OrgSettingsSuite) TestCreateDefaults() {
+	t := s.T()
+	orgID := seeds.RandomOrgId()
+	settingsDao := orgSettingsDaoImpl{db: s.tx}
+
+	settings, err := settingsDao.CreateDefaults(context.Background(), orgID)
+	require.NoError(t, err)
+	assert.Equal(t, config.ANY_ARCH, settings.DefaultDistributionArch)
+	var count int64
+	require.NoError(t, s.tx.Model(&models.OrgSettings{}).Where("org_id = ?", orgID).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	// Saved settings are left unchanged
+	_, err = settingsDao.Update(context.Background(), orgID, api.OrgSettingsRequest{DefaultDistributionArch: pointy.String(config.X8664)})
+	require.NoError(t, err)
+	settings, err = settingsDao.CreateDefaults(context.Background(), orgID)
+	require.NoError(t, err)
+	assert.Equal(t, config.X8664, settings.DefaultDistributionArch)
+}
+
+func (s *OrgSettingsSuite) TestUpdateInvalid() {
+	t := s.T()
+	orgID := seeds.RandomOrgId()
+	settingsDao := orgSettingsDaoImpl{db: s.tx}
+
+	for _, request := range []api.OrgSettingsRequest{
+		{DefaultDistributionArch: pointy.String("sparc")},
+		{DefaultDistributionVersions: &[]string{config.ANY_VERSION, config.El9}},
+		{SnapshotRetention: pointy.Int(-1)},
+		{NotificationOptOuts: &[]string{"unknown"}},
+	} {
+		_, err := settingsDao.Update(context.Background(), orgID, request)
+		daoError, ok := err.(*ce.DaoError)
+		require.True(t, ok)
+		assert.True(t, daoError.BadValidation)
+	}
+}
+
+func (s *OrgSettingsSuite) TestNotificationOptOut() {
+	t := s.T()
+	orgID := seeds.RandomOrgId()
+
+	_, err := orgSettingsDaoImpl{db: s.tx}.Update(context.Background(), orgID, api.OrgSettingsRequest{
+		NotificationOptOuts: &[]string{notifications.RepositoryCreated.String()},
+	})
+	require.NoError(t, err)
+
+	_, err = GetRepositoryConfigDao(s.tx).Create(context.Background(), api.RepositoryRequest{
+		Name:      pointy.String("opted out"),
+		URL:       pointy.String("https://example.com/opted-out/"),
+		OrgID:     &orgID,
+		AccountID: pointy.String("account"),
+	})
+	require.NoError(t, err)
+
+	// The event is not sent, but kept for the repository change feed
+	var count int64
+	err = s.tx.Model(&models.OutboxEvent{}).Where("org_id = ? AND published_at IS NULL", orgID).Count(&count).Error
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	events, err := GetRepositoryConfigDao(s.tx).ListEvents(context.Background(), orgID, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, notifications.RepositoryCreated.String(), events[0].Type)
+}
@@ -0,0 +1,47 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/db"
+	"github.com/content-services/content-sources-backend/pkg/seeds"
+	pg "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// BenchmarkListPreparedStatements compares listing and searching repositories with and
+// without prepared statements, as set by the database.prepare_statements setting
+func BenchmarkListPreparedStatements(b *testing.B) {
+	for _, prepare := range []bool{false, true} {
+		b.Run(fmt.Sprintf("prepare=%t", prepare), func(b *testing.B) {
+			conn, err := gorm.Open(pg.New(pg.Config{DSN: db.GetUrl(), DriverName: "pgx"}), &gorm.Config{
+				Logger:      logger.Discard,
+				PrepareStmt: prepare,
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+			tx := conn.Begin()
+			defer tx.Rollback()
+
+			orgID := seeds.RandomOrgId()
+			if err := seeds.SeedRepositoryConfigurations(tx, 100, seeds.SeedOptions{OrgID: orgID}); err != nil {
+				b.Fatal(err)
+			}
+			repoConfigDao := GetRepositoryConfigDao(tx)
+			pageData := api.PaginationData{Limit: 20}
+			filterData := api.FilterData{Search: "a", Arch: "x86_64"}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := repoConfigDao.List(context.Background(), orgID, pageData, filterData); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package dao
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/content-services/content-sources-backend/pkg/db"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// readOnly runs query against the read replica when the dao uses the primary
+// connection and a replica is configured, retrying on the primary if the replica
+// is unavailable.  Errors of the query itself, such as a record not found, are
+// returned as they are, the primary would fail the same way.  Only use it for
+// queries that can tolerate replication lag, a Fetch following an Update in the
+// same request must keep reading from the primary.
+func readOnly(ctx context.Context, primary *gorm.DB, query func(conn *gorm.DB) error) error {
+	if primary != db.DB || db.ReadReplica == nil || db.ReadReplica == db.DB {
+		return query(primary.WithContext(ctx))
+	}
+	if err := query(db.ReadReplica.WithContext(ctx)); err != nil {
+		if ctx.Err() != nil || !replicaUnavailable(err) {
+			return err
+		}
+		log.Warn().Err(err).Msg("Query on the read replica failed, retrying on the primary database")
+		return query(primary.WithContext(ctx))
+	}
+	return nil
+}
+
+// replicaUnavailable returns whether err means the read replica could not serve the query,
+// because it could not be reached, was shutting down or cancelled the query to replay changes
+// of the primary
+func replicaUnavailable(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || pgconn.SafeToRetry(err) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// connection_exception, too_many_connections, admin_shutdown, crash_shutdown and
+		// cannot_connect_now, and the serialization_failure of a conflict with recovery
+		return strings.HasPrefix(pgErr.Code, "08") || strings.HasPrefix(pgErr.Code, "57P") ||
+			pgErr.Code == "53300" || pgErr.Code == "40001"
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package dao
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestReplicaUnavailable(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	assert.True(t, replicaUnavailable(dialErr))
+	assert.True(t, replicaUnavailable(fmt.Errorf("query failed: %w", io.ErrUnexpectedEOF)))
+	assert.True(t, replicaUnavailable(&pgconn.PgError{Code: "57P03"}))
+	assert.True(t, replicaUnavailable(&pgconn.PgError{Code: "08006"}))
+	assert.True(t, replicaUnavailable(&pgconn.PgError{Code: "40001"}))
+
+	assert.False(t, replicaUnavailable(gorm.ErrRecordNotFound))
+	assert.False(t, replicaUnavailable(&pgconn.PgError{Code: "42703"}))
+	assert.False(t, replicaUnavailable(&pgconn.PgError{Code: "22P02"}))
+}
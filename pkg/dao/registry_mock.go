@@ -13,6 +13,12 @@ type MockDaoRegistry struct {
 	TaskInfo         MockTaskInfoDao
 	AdminTask        MockAdminTaskDao
 	Domain           MockDomainDao
+	Usage            MockUsageDao
+	Idempotency      MockIdempotencyDao
+	Webhook          MockWebhookDao
+	OrgSettings      MockOrgSettingsDao
+	Telemetry        MockTelemetryDao
+	LogLevels        MockLogLevelsDao
 }
 
 func (m *MockDaoRegistry) ToDaoRegistry() *DaoRegistry {
@@ -25,6 +31,12 @@ func (m *MockDaoRegistry) ToDaoRegistry() *DaoRegistry {
 		TaskInfo:         &m.TaskInfo,
 		AdminTask:        &m.AdminTask,
 		Domain:           &m.Domain,
+		Usage:            &m.Usage,
+		Idempotency:      &m.Idempotency,
+		Webhook:          &m.Webhook,
+		OrgSettings:      &m.OrgSettings,
+		Telemetry:        &m.Telemetry,
+		LogLevels:        &m.LogLevels,
 	}
 	return &r
 }
@@ -39,6 +51,12 @@ func GetMockDaoRegistry(t *testing.T) *MockDaoRegistry {
 		TaskInfo:         *NewMockTaskInfoDao(t),
 		AdminTask:        *NewMockAdminTaskDao(t),
 		Domain:           *NewMockDomainDao(t),
+		Usage:            *NewMockUsageDao(t),
+		Idempotency:      *NewMockIdempotencyDao(t),
+		Webhook:          *NewMockWebhookDao(t),
+		OrgSettings:      *NewMockOrgSettingsDao(t),
+		Telemetry:        *NewMockTelemetryDao(t),
+		LogLevels:        *NewMockLogLevelsDao(t),
 	}
 	return &reg
 }
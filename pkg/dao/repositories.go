@@ -1,10 +1,15 @@
 package dao
 
 import (
+	"context"
+	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/content-services/content-sources-backend/pkg/api"
 	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/crypto"
+	"github.com/content-services/content-sources-backend/pkg/db"
 	"github.com/content-services/content-sources-backend/pkg/models"
 	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
@@ -16,25 +21,158 @@ type Repository struct {
 	URL                          string
 	Public                       bool
 	RepomdChecksum               string
+	RepomdRevision               string
+	Etag                         string
+	LastModified                 string
 	LastIntrospectionTime        *time.Time
 	LastIntrospectionSuccessTime *time.Time
 	LastIntrospectionUpdateTime  *time.Time
 	LastIntrospectionError       *string
+	LastIntrospectionErrorCode   *string
 	Status                       string
 	PackageCount                 int
 	FailedIntrospectionsCount    int
 }
 
+// RepositoryProxy internal representation of the proxy a repository is reached through
+type RepositoryProxy struct {
+	URL      string
+	Username string
+	Password string
+}
+
+// ProxyFunc returns the Proxy of an http.Transport reaching the repository through the proxy,
+// or through the proxy set in the environment if the repository has none
+func (p RepositoryProxy) ProxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	if p.URL == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	proxyURL, err := url.Parse(p.URL)
+	if err != nil {
+		return nil, err
+	}
+	if p.Username != "" {
+		proxyURL.User = url.UserPassword(p.Username, p.Password)
+	}
+	return http.ProxyURL(proxyURL), nil
+}
+
+// Transport returns a new transport reaching the repository through the proxy, or through the proxy set
+// in the environment if the repository has none, connecting to the addresses repositories are allowed to
+// be fetched from only.  The proxy of a repository is given by a user, its address is checked as well.
+func (p RepositoryProxy) Transport() (*http.Transport, error) {
+	proxyFunc, err := p.ProxyFunc()
+	if err != nil {
+		return nil, err
+	}
+	guard, err := config.RepositoryGuard()
+	if err != nil {
+		return nil, err
+	}
+	if p.URL == "" {
+		return guard.TrustedProxyTransport(proxyFunc), nil
+	}
+	return guard.Transport(proxyFunc), nil
+}
+
+// RepositoryDistribution internal representation of the distribution of a repository configuration
+type RepositoryDistribution struct {
+	Versions []string
+	Arch     string
+}
+
+// RepositoryCredentials internal representation of the basic auth credentials of a repository
+type RepositoryCredentials struct {
+	Username string
+	Password string
+}
+
+// Transport returns a RoundTripper authenticating the requests to the host of the repository
+// with the credentials, other hosts such as those redirected to do not receive them.
+func (c RepositoryCredentials) Transport(base http.RoundTripper, repoURL string) http.RoundTripper {
+	if c.Username == "" {
+		return base
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	host := ""
+	if parsed, err := url.Parse(repoURL); err == nil {
+		host = parsed.Host
+	}
+	return basicAuthTransport{base: base, host: host, credentials: c}
+}
+
+type basicAuthTransport struct {
+	base        http.RoundTripper
+	host        string
+	credentials RepositoryCredentials
+}
+
+func (t basicAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != t.host {
+		return t.base.RoundTrip(req)
+	}
+	// A RoundTripper must not modify the request it is given
+	authenticated := req.Clone(req.Context())
+	authenticated.SetBasicAuth(t.credentials.Username, t.credentials.Password)
+	return t.base.RoundTrip(authenticated)
+}
+
+// withContext returns a copy of client sending its requests with ctx, so that they are canceled
+// with it, for libraries such as yummy that do not take a context.  The client must not have a
+// Timeout, as its deadline would be replaced by ctx.
+func withContext(ctx context.Context, client *http.Client) *http.Client {
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	withCtx := *client
+	withCtx.Transport = contextTransport{base: base, ctx: ctx}
+	return &withCtx
+}
+
+type contextTransport struct {
+	base http.RoundTripper
+	ctx  context.Context
+}
+
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
+
+// proxyFromModel returns the proxy of a repository configuration, decrypting its password
+func proxyFromModel(repoConfig models.RepositoryConfiguration) (RepositoryProxy, error) {
+	password, err := crypto.Decrypt(repoConfig.ProxyPassword)
+	if err != nil {
+		return RepositoryProxy{}, err
+	}
+	return RepositoryProxy{URL: repoConfig.ProxyURL, Username: repoConfig.ProxyUsername, Password: password}, nil
+}
+
+// credentialsFromModel returns the credentials of a repository configuration, decrypting its password
+func credentialsFromModel(repoConfig models.RepositoryConfiguration) (RepositoryCredentials, error) {
+	password, err := crypto.Decrypt(repoConfig.Password)
+	if err != nil {
+		return RepositoryCredentials{}, err
+	}
+	return RepositoryCredentials{Username: repoConfig.Username, Password: password}, nil
+}
+
 // RepositoryUpdate internal representation of repository, nil field value means do not change
 type RepositoryUpdate struct {
 	UUID                         string
 	URL                          *string
 	Public                       *bool
 	RepomdChecksum               *string
+	RepomdRevision               *string
+	Etag                         *string
+	LastModified                 *string
 	LastIntrospectionTime        *time.Time
 	LastIntrospectionSuccessTime *time.Time
 	LastIntrospectionUpdateTime  *time.Time
 	LastIntrospectionError       *string
+	LastIntrospectionErrorCode   *string
 	Status                       *string
 	PackageCount                 *int
 	FailedIntrospectionsCount    *int
@@ -50,21 +188,21 @@ type repositoryDaoImpl struct {
 	db *gorm.DB
 }
 
-func (p repositoryDaoImpl) FetchRepositoryRPMCount(repoUUID string) (int, error) {
+func (p repositoryDaoImpl) FetchRepositoryRPMCount(ctx context.Context, repoUUID string) (int, error) {
 	var dbRepos []models.RepositoryRpm
 	var count int64 = 0
-	result := p.db.Model(&dbRepos).Where("repository_uuid = ?", repoUUID).Count(&count)
+	result := p.db.WithContext(ctx).Model(&dbRepos).Where("repository_uuid = ?", repoUUID).Count(&count)
 	if result.Error != nil {
 		return 0, result.Error
 	}
 	return int(count), nil
 }
 
-func (p repositoryDaoImpl) FetchForUrl(url string) (Repository, error) {
+func (p repositoryDaoImpl) FetchForUrl(ctx context.Context, url string) (Repository, error) {
 	repo := models.Repository{}
 	internalRepo := Repository{}
 	url = models.CleanupURL(url)
-	result := p.db.Where("URL = ?", url).Order("url asc").First(&repo)
+	result := p.db.WithContext(ctx).Where("URL = ?", url).Order("url asc").First(&repo)
 	if result.Error != nil {
 		return Repository{}, result.Error
 	}
@@ -72,16 +210,87 @@ func (p repositoryDaoImpl) FetchForUrl(url string) (Repository, error) {
 	return internalRepo, nil
 }
 
-func (p repositoryDaoImpl) List(ignoreFailed bool) ([]Repository, error) {
+// FetchProxy returns the proxy to reach a repository through. As repositories are shared by the orgs
+// adding the same url, the proxy of the oldest repository configuration having one is used.
+func (p repositoryDaoImpl) FetchProxy(ctx context.Context, repoUUID string) (RepositoryProxy, error) {
+	var repoConfigs []models.RepositoryConfiguration
+	result := p.db.WithContext(ctx).Where("repository_uuid = ? AND proxy_url != ''", repoUUID).
+		Order("created_at ASC").
+		Limit(1).
+		Find(&repoConfigs)
+	if result.Error != nil {
+		return RepositoryProxy{}, result.Error
+	}
+	if len(repoConfigs) == 0 {
+		return RepositoryProxy{}, nil
+	}
+	return proxyFromModel(repoConfigs[0])
+}
+
+// FetchCredentials returns the credentials to authenticate with a repository, those of the
+// oldest repository configuration having some, as done for proxies
+func (p repositoryDaoImpl) FetchCredentials(ctx context.Context, repoUUID string) (RepositoryCredentials, error) {
+	var repoConfigs []models.RepositoryConfiguration
+	result := p.db.WithContext(ctx).Where("repository_uuid = ? AND username != ''", repoUUID).
+		Order("created_at ASC").
+		Limit(1).
+		Find(&repoConfigs)
+	if result.Error != nil {
+		return RepositoryCredentials{}, result.Error
+	}
+	if len(repoConfigs) == 0 {
+		return RepositoryCredentials{}, nil
+	}
+	return credentialsFromModel(repoConfigs[0])
+}
+
+// FetchEntitledOrg returns the org whose entitlement certificate a repository of the Red Hat CDN is
+// introspected with, the one of the oldest repository configuration as done for proxies, or an
+// empty string when the repository has none
+func (p repositoryDaoImpl) FetchEntitledOrg(ctx context.Context, repoUUID string) (string, error) {
+	var repoConfigs []models.RepositoryConfiguration
+	result := p.db.WithContext(ctx).Select("org_id").
+		Where("repository_uuid = ?", repoUUID).
+		Order("created_at ASC").
+		Limit(1).
+		Find(&repoConfigs)
+	if result.Error != nil {
+		return "", result.Error
+	}
+	if len(repoConfigs) == 0 {
+		return "", nil
+	}
+	return repoConfigs[0].OrgID, nil
+}
+
+// FetchDistributions returns the distribution versions and architecture of each repository configuration
+// of the repository, which its url is expanded with when it contains variables
+func (p repositoryDaoImpl) FetchDistributions(ctx context.Context, repoUUID string) ([]RepositoryDistribution, error) {
+	var repoConfigs []models.RepositoryConfiguration
+	err := p.db.WithContext(ctx).Select("versions", "arch").
+		Where("repository_uuid = ?", repoUUID).
+		Order("created_at").
+		Find(&repoConfigs).Error
+	if err != nil {
+		return nil, err
+	}
+	distributions := make([]RepositoryDistribution, len(repoConfigs))
+	for i := range repoConfigs {
+		distributions[i] = RepositoryDistribution{Versions: repoConfigs[i].Versions, Arch: repoConfigs[i].Arch}
+	}
+	return distributions, nil
+}
+
+func (p repositoryDaoImpl) List(ctx context.Context, ignoreFailed bool) ([]Repository, error) {
 	var dbRepos []models.Repository
 	var repos []Repository
 	var repo Repository
 	var result *gorm.DB
 
 	if ignoreFailed {
-		result = p.db.Where("failed_introspections_count < ?", config.FailedIntrospectionsLimit+1).Find(&dbRepos)
+		result = p.db.WithContext(ctx).Where("failed_introspections_count < ?", config.FailedIntrospectionsLimit+1).Find(&dbRepos)
 	} else {
-		result = p.db.Find(&dbRepos)
+		result = p.db.WithContext(ctx).Find(&dbRepos)
 	}
 	if result.Error != nil {
 		return repos, result.Error
@@ -93,12 +302,12 @@ func (p repositoryDaoImpl) List(ignoreFailed bool) ([]Repository, error) {
 	return repos, nil
 }
 
-func (p repositoryDaoImpl) ListPublic(paginationData api.PaginationData, _ api.FilterData) (api.PublicRepositoryCollectionResponse, int64, error) {
+func (p repositoryDaoImpl) ListPublic(ctx context.Context, paginationData api.PaginationData, _ api.FilterData) (api.PublicRepositoryCollectionResponse, int64, error) {
 	var dbRepos []models.Repository
 	var result *gorm.DB
 	var totalRepos int64
 
-	filteredDB := p.db
+	filteredDB := p.db.WithContext(ctx)
 	filteredDB.Where("public = true").
 		Limit(paginationData.Limit).
 		Offset(paginationData.Offset).
@@ -115,17 +324,17 @@ func (p repositoryDaoImpl) ListPublic(paginationData api.PaginationData, _ api.F
 	return api.PublicRepositoryCollectionResponse{Data: repos}, totalRepos, nil
 }
 
-func (p repositoryDaoImpl) Update(repoIn RepositoryUpdate) error {
+func (p repositoryDaoImpl) Update(ctx context.Context, repoIn RepositoryUpdate) error {
 	var dbRepo models.Repository
 
-	result := p.db.Where("uuid = ?", repoIn.UUID).First(&dbRepo)
+	result := p.db.WithContext(ctx).Where("uuid = ?", repoIn.UUID).First(&dbRepo)
 	if result.Error != nil {
 		return result.Error
 	}
 
 	internalToModel(repoIn, &dbRepo)
 
-	result = p.db.Model(&dbRepo).Updates(dbRepo.MapForUpdate())
+	result = p.db.WithContext(ctx).Model(&dbRepo).Updates(dbRepo.MapForUpdate())
 	if result.Error != nil {
 		return result.Error
 	}
@@ -133,9 +342,10 @@ func (p repositoryDaoImpl) Update(repoIn RepositoryUpdate) error {
 	return nil
 }
 
-func (r repositoryDaoImpl) OrphanCleanup() error {
+func (r repositoryDaoImpl) OrphanCleanup(ctx context.Context) error {
+	ctx = db.WithQueryClass(ctx, db.QueryBulk)
 	// lookup orphans.  Use unscoped to not try to delete a repo that has a 'soft deleted' repo_config
-	query := r.db.Unscoped().Model(&models.Repository{}).
+	query := r.db.WithContext(ctx).Unscoped().Model(&models.Repository{}).
 		Joins("left join repository_configurations on repositories.uuid = repository_configurations.repository_uuid").
 		Where("repository_configurations.uuid is NULL").
 		Where("repositories.public is false").
@@ -143,7 +353,7 @@ func (r repositoryDaoImpl) OrphanCleanup() error {
 		Select("repositories.uuid")
 
 	// Delete orphans
-	tx := r.db.
+	tx := r.db.WithContext(ctx).
 		Where("repositories.uuid in (?)", query).
 		Delete(&models.Repository{})
 	if tx.Error != nil {
@@ -160,7 +370,11 @@ func modelToInternal(model models.Repository, internal *Repository) {
 	internal.URL = model.URL
 	internal.Public = model.Public
 	internal.RepomdChecksum = model.RepomdChecksum
+	internal.RepomdRevision = model.RepomdRevision
+	internal.Etag = model.Etag
+	internal.LastModified = model.LastModified
 	internal.LastIntrospectionError = model.LastIntrospectionError
+	internal.LastIntrospectionErrorCode = model.LastIntrospectionErrorCode
 	internal.LastIntrospectionTime = model.LastIntrospectionTime
 	internal.LastIntrospectionUpdateTime = model.LastIntrospectionUpdateTime
 	internal.LastIntrospectionSuccessTime = model.LastIntrospectionSuccessTime
@@ -177,12 +391,24 @@ func internalToModel(internal RepositoryUpdate, model *models.Repository) {
 	if internal.RepomdChecksum != nil {
 		model.RepomdChecksum = *internal.RepomdChecksum
 	}
+	if internal.RepomdRevision != nil {
+		model.RepomdRevision = *internal.RepomdRevision
+	}
+	if internal.Etag != nil {
+		model.Etag = *internal.Etag
+	}
+	if internal.LastModified != nil {
+		model.LastModified = *internal.LastModified
+	}
 	if internal.Public != nil {
 		model.Public = *internal.Public
 	}
 	if internal.LastIntrospectionError != nil {
 		model.LastIntrospectionError = internal.LastIntrospectionError
 	}
+	if internal.LastIntrospectionErrorCode != nil {
+		model.LastIntrospectionErrorCode = internal.LastIntrospectionErrorCode
+	}
 	if internal.LastIntrospectionTime != nil {
 		model.LastIntrospectionTime = internal.LastIntrospectionTime
 	}
@@ -220,4 +446,7 @@ func repoModelToPublicRepoApi(model models.Repository, resp *api.PublicRepositor
 	if model.LastIntrospectionError != nil {
 		resp.LastIntrospectionError = *model.LastIntrospectionError
 	}
+	if model.LastIntrospectionErrorCode != nil {
+		resp.LastIntrospectionErrorCode = *model.LastIntrospectionErrorCode
+	}
 }
@@ -1,15 +1,16 @@
 package dao
 
 import (
-	"log"
-	"os"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/content-services/content-sources-backend/pkg/api"
 	"github.com/content-services/content-sources-backend/pkg/config"
-	"github.com/content-services/content-sources-backend/pkg/db"
 	"github.com/content-services/content-sources-backend/pkg/models"
 	"github.com/content-services/content-sources-backend/pkg/seeds"
 	"github.com/google/uuid"
@@ -17,8 +18,6 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
-	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 type RepositorySuite struct {
@@ -29,20 +28,7 @@ type RepositorySuite struct {
 }
 
 func (s *RepositorySuite) SetupTest() {
-	if db.DB == nil {
-		if err := db.Connect(); err != nil {
-			s.FailNow(err.Error())
-		}
-	}
-	s.db = db.DB.Session(&gorm.Session{
-		SkipDefaultTransaction: true,
-		Logger: logger.New(
-			log.New(os.Stderr, "\r\n", log.LstdFlags),
-			logger.Config{
-				LogLevel: logger.Info,
-			}),
-	})
-	s.tx = s.db.Begin()
+	s.DaoSuite.SetupTest()
 
 	repo := repoPublicTest.DeepCopy()
 	if err := s.tx.Create(repo).Error; err != nil {
@@ -81,7 +67,7 @@ func (s *RepositorySuite) TestFetchForUrl() {
 
 	urlPublic := s.repo.URL
 	dao := GetRepositoryDao(tx)
-	repo, err = dao.FetchForUrl(urlPublic)
+	repo, err = dao.FetchForUrl(context.Background(), urlPublic)
 	assert.NoError(t, err)
 	assert.Equal(t, Repository{
 		UUID:                         s.repo.UUID,
@@ -99,12 +85,12 @@ func (s *RepositorySuite) TestFetchForUrl() {
 	// Trim the trailing slash, and verify we still find the repo
 	noSlashUrl := strings.TrimSuffix(urlPublic, "/")
 	assert.NotEqual(t, noSlashUrl, urlPublic)
-	repo, err = dao.FetchForUrl(noSlashUrl)
+	repo, err = dao.FetchForUrl(context.Background(), noSlashUrl)
 	assert.NoError(t, err)
 	assert.Equal(t, s.repo.UUID, repo.UUID)
 
 	urlPrivate := s.repoPrivate.URL
-	repo, err = dao.FetchForUrl(urlPrivate)
+	repo, err = dao.FetchForUrl(context.Background(), urlPrivate)
 	assert.NoError(t, err)
 	assert.Equal(t, Repository{
 		UUID:                         s.repoPrivate.UUID,
@@ -120,7 +106,7 @@ func (s *RepositorySuite) TestFetchForUrl() {
 	}, repo)
 
 	url := "https://it-does-not-exist.com/base"
-	repo, err = dao.FetchForUrl(url)
+	repo, err = dao.FetchForUrl(context.Background(), url)
 	assert.Error(t, err)
 	assert.Equal(t, Repository{
 		UUID: "",
@@ -146,7 +132,7 @@ func (s *RepositorySuite) TestList() {
 	}
 
 	dao := GetRepositoryDao(tx)
-	repoList, err := dao.List(false)
+	repoList, err := dao.List(context.Background(), false)
 	assert.NoError(t, err)
 	assert.Contains(t, repoList, expected)
 }
@@ -187,7 +173,7 @@ func (s *RepositorySuite) TestListIgnoreFailed() {
 	require.NoError(t, err)
 
 	dao := GetRepositoryDao(tx)
-	repoList, err := dao.List(true)
+	repoList, err := dao.List(context.Background(), true)
 	assert.NoError(t, err)
 	assert.Contains(t, repoList, expectedNotIgnored)
 	assert.NotContains(t, repoList, expectedIgnored)
@@ -210,7 +196,7 @@ func (s *RepositorySuite) TestListPublic() {
 	err = tx.Create(s.repoPrivate).Error
 	require.NoError(t, err)
 
-	repos, totalRepos, err := dao.ListPublic(pageData, api.FilterData{})
+	repos, totalRepos, err := dao.ListPublic(context.Background(), pageData, api.FilterData{})
 	assert.NoError(t, err)
 	assert.Len(t, repos.Data, 1)
 	assert.Equal(t, repos.Data[0].URL, s.repo.URL)
@@ -234,7 +220,7 @@ func (s *RepositorySuite) TestListPublicNoRepositories() {
 	err := tx.Create(s.repoPrivate).Error
 	require.NoError(t, err)
 
-	repos, totalRepos, err := dao.ListPublic(pageData, api.FilterData{})
+	repos, totalRepos, err := dao.ListPublic(context.Background(), pageData, api.FilterData{})
 	assert.NoError(t, err)
 	assert.Len(t, repos.Data, 0)
 	assert.Equal(t, int64(0), totalRepos)
@@ -263,7 +249,7 @@ func (s *RepositorySuite) TestListPageLimit() {
 	err = tx.Create(s.repoPrivate).Error
 	require.NoError(t, err)
 
-	repos, totalRepos, err := dao.ListPublic(pageData, api.FilterData{})
+	repos, totalRepos, err := dao.ListPublic(context.Background(), pageData, api.FilterData{})
 	assert.NoError(t, err)
 	assert.Len(t, repos.Data, 1)
 	assert.Equal(t, int64(2), totalRepos)
@@ -319,7 +305,7 @@ func (s *RepositorySuite) TestOrphanCleanup() {
 	tx = s.tx.Create(&usedExpireConfig)
 	assert.NoError(s.T(), tx.Error)
 
-	err := dao.OrphanCleanup()
+	err := dao.OrphanCleanup(context.Background())
 	assert.NoError(s.T(), err)
 
 	count := int64(0)
@@ -345,7 +331,7 @@ func (s *RepositorySuite) TestUpdateRepository() {
 	)
 
 	dao := GetRepositoryDao(tx)
-	repo, err = dao.FetchForUrl(s.repo.URL)
+	repo, err = dao.FetchForUrl(context.Background(), s.repo.URL)
 	assert.NoError(t, err)
 
 	assert.Equal(t, Repository{
@@ -375,10 +361,10 @@ func (s *RepositorySuite) TestUpdateRepository() {
 		FailedIntrospectionsCount:    pointy.Int(30),
 	}
 
-	err = dao.Update(expected)
+	err = dao.Update(context.Background(), expected)
 	assert.NoError(t, err)
 
-	repo, err = dao.FetchForUrl(s.repo.URL)
+	repo, err = dao.FetchForUrl(context.Background(), s.repo.URL)
 	assert.NoError(t, err)
 	assert.Equal(t, expected.UUID, repo.UUID)
 	assert.Equal(t, *expected.URL, repo.URL)
@@ -398,10 +384,10 @@ func (s *RepositorySuite) TestUpdateRepository() {
 		RepomdChecksum: pointy.String(""),
 	}
 
-	err = dao.Update(zeroValues)
+	err = dao.Update(context.Background(), zeroValues)
 	assert.NoError(t, err)
 
-	repo, err = dao.FetchForUrl(s.repo.URL)
+	repo, err = dao.FetchForUrl(context.Background(), s.repo.URL)
 	assert.NoError(t, err)
 	assert.Equal(t, s.repo.UUID, repo.UUID)
 	assert.Equal(t, s.repo.URL, repo.URL)
@@ -424,7 +410,50 @@ func (s *RepositorySuite) TestFetchRpmCount() {
 	assert.Nil(t, err, "Error seeding Rpms")
 
 	dao := GetRepositoryDao(tx)
-	count, err := dao.FetchRepositoryRPMCount(s.repo.UUID)
+	count, err := dao.FetchRepositoryRPMCount(context.Background(), s.repo.UUID)
 	assert.NoError(t, err)
 	assert.Equal(t, expected, count)
 }
+
+func (s *RepositorySuite) TestFetchEntitledOrg() {
+	t := s.T()
+	dao := GetRepositoryDao(s.tx)
+
+	orgID, err := dao.FetchEntitledOrg(context.Background(), s.repo.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, s.repoConfig.OrgID, orgID)
+
+	// Nobody added the private repository
+	orgID, err = dao.FetchEntitledOrg(context.Background(), s.repoPrivate.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, "", orgID)
+}
+
+func TestRepositoryCredentialsTransport(t *testing.T) {
+	var authorizations []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	mirror := httptest.NewServer(handler)
+	defer mirror.Close()
+
+	credentials := RepositoryCredentials{Username: "user", Password: "pass"}
+	client := http.Client{Transport: credentials.Transport(nil, server.URL+"/repo/")}
+
+	resp, err := client.Get(server.URL + "/repo/repodata/repomd.xml")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// Another host, such as a mirror redirected to, does not receive the credentials
+	resp, err = client.Get(mirror.URL + "/repo/repodata/repomd.xml")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	expected := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:pass"))
+	assert.Equal(t, []string{expected, ""}, authorizations)
+
+	// Without credentials the transport is unchanged
+	assert.Equal(t, http.DefaultTransport, RepositoryCredentials{}.Transport(http.DefaultTransport, server.URL))
+}
@@ -1,25 +1,40 @@
 package dao
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/RedHatInsights/event-schemas-go/apps/repositories/v1"
 	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/crypto"
+	"github.com/content-services/content-sources-backend/pkg/db"
 	ce "github.com/content-services/content-sources-backend/pkg/errors"
 	"github.com/content-services/content-sources-backend/pkg/models"
 	"github.com/content-services/content-sources-backend/pkg/notifications"
 	"github.com/content-services/yummy/pkg/yum"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/rs/zerolog/log"
+	"golang.org/x/exp/slices"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+// bulkExportChunkSize is the number of repositories fetched per query when exporting
+const bulkExportChunkSize = 500
+
+// rotateSecretsBatchSize is the number of secrets read per query when rotating the encryption key
+const rotateSecretsBatchSize = 500
+
 type repositoryConfigDaoImpl struct {
 	db      *gorm.DB
 	yumRepo yum.YumRepository
@@ -38,8 +53,19 @@ func DBErrorToApi(e error) *ce.DaoError {
 		return nil
 	}
 
+	if errors.Is(e, context.DeadlineExceeded) {
+		return &ce.DaoError{Timeout: true, Message: "The query took too long and was canceled"}
+	}
 	pgError, ok := e.(*pgconn.PgError)
 	if ok {
+		// query_canceled, by a statement timeout or a cancel request
+		if pgError.Code == "57014" {
+			return &ce.DaoError{Timeout: true, Message: "The query took too long and was canceled"}
+		}
+		// invalid_text_representation, an identifier that is not a uuid cannot match a row
+		if pgError.Code == "22P02" {
+			return &ce.DaoError{NotFound: true, Message: "Could not find an entry with this identifier: " + pgError.Message}
+		}
 		if pgError.Code == "23505" {
 			switch pgError.ConstraintName {
 			case "repo_and_org_id_unique":
@@ -59,14 +85,12 @@ func DBErrorToApi(e error) *ce.DaoError {
 	return &ce.DaoError{Message: e.Error()}
 }
 
-func (r repositoryConfigDaoImpl) Create(newRepoReq api.RepositoryRequest) (api.RepositoryResponse, error) {
+func (r repositoryConfigDaoImpl) Create(ctx context.Context, newRepoReq api.RepositoryRequest) (api.RepositoryResponse, error) {
 	var newRepo models.Repository
 	var newRepoConfig models.RepositoryConfiguration
 	ApiFieldsToModel(newRepoReq, &newRepoConfig, &newRepo)
-
-	cleanedUrl := models.CleanupURL(newRepo.URL)
-	if err := r.db.Where("url = ?", cleanedUrl).FirstOrCreate(&newRepo).Error; err != nil {
-		return api.RepositoryResponse{}, DBErrorToApi(err)
+	if err := encryptSecrets(newRepoReq, &newRepoConfig); err != nil {
+		return api.RepositoryResponse{}, err
 	}
 
 	if newRepoReq.OrgID != nil {
@@ -75,49 +99,149 @@ func (r repositoryConfigDaoImpl) Create(newRepoReq api.RepositoryRequest) (api.R
 	if newRepoReq.AccountID != nil {
 		newRepoConfig.AccountID = *newRepoReq.AccountID
 	}
-	newRepoConfig.RepositoryUUID = newRepo.Base.UUID
-
-	if err := r.db.Create(&newRepoConfig).Error; err != nil {
-		return api.RepositoryResponse{}, DBErrorToApi(err)
+	// The repository of a url added before it was denied is not created again, so is not validated
+	if message := models.DeniedURLMessage(newRepo.URL); message != "" {
+		return api.RepositoryResponse{}, &ce.DaoError{BadValidation: true, Message: message}
 	}
 
 	var created api.RepositoryResponse
-	ModelToApiFields(newRepoConfig, &created)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		cleanedUrl := models.CleanupURL(newRepo.URL)
+		if err := tx.Where("url = ?", cleanedUrl).FirstOrCreate(&newRepo).Error; err != nil {
+			return DBErrorToApi(err)
+		}
 
-	created.URL = newRepo.URL
-	created.Status = newRepo.Status
+		newRepoConfig.RepositoryUUID = newRepo.Base.UUID
+		if err := tx.Create(&newRepoConfig).Error; err != nil {
+			return DBErrorToApi(err)
+		}
 
-	notifications.SendNotification(
-		newRepoConfig.OrgID,
-		notifications.RepositoryCreated,
-		[]repositories.Repositories{notifications.MapRepositoryResponse(created)},
-	)
+		ModelToApiFields(newRepoConfig, &created)
+		created.URL = newRepo.URL
+		created.Status = newRepo.Status
+
+		return notifications.QueueNotification(
+			tx,
+			newRepoConfig.OrgID,
+			notifications.RepositoryCreated,
+			[]repositories.Repositories{notifications.MapRepositoryResponse(created)},
+		)
+	})
+	if err != nil {
+		return api.RepositoryResponse{}, err
+	}
 
 	return created, nil
 }
 
-func (r repositoryConfigDaoImpl) BulkCreate(newRepositories []api.RepositoryRequest) ([]api.RepositoryResponse, []error) {
+func (r repositoryConfigDaoImpl) BulkCreate(ctx context.Context, newRepositories []api.RepositoryRequest) ([]api.RepositoryResponse, []error) {
 	var responses []api.RepositoryResponse
 	var errs []error
 
-	_ = r.db.Transaction(func(tx *gorm.DB) error {
+	_ = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var err error
 		responses, errs = r.bulkCreate(tx, newRepositories)
 		if len(errs) > 0 {
 			err = errors.New("rollback bulk create")
+			return err
+		}
+
+		mappedValues := []repositories.Repositories{}
+		for i := 0; i < len(responses); i++ {
+			mappedValues = append(mappedValues, notifications.MapRepositoryResponse(responses[i]))
+		}
+		if err = notifications.QueueNotification(tx, *newRepositories[0].OrgID, notifications.RepositoryCreated, mappedValues); err != nil {
+			errs = []error{DBErrorToApi(err)}
+			responses = []api.RepositoryResponse{}
 		}
 		return err
 	})
 
-	mappedValues := []repositories.Repositories{}
-	for i := 0; i < len(responses); i++ {
-		mappedValues = append(mappedValues, notifications.MapRepositoryResponse(responses[i]))
-	}
-	notifications.SendNotification(*newRepositories[0].OrgID, notifications.RepositoryCreated, mappedValues)
+	return responses, errs
+}
+
+// BulkImport recreates exported repositories.  Repositories whose URL already belongs to
+// the org are not imported, the existing repository is returned with a warning instead.
+func (r repositoryConfigDaoImpl) BulkImport(ctx context.Context, reposToImport []api.RepositoryRequest) ([]api.RepositoryImportResponse, []error) {
+	var responses []api.RepositoryImportResponse
+	var errs []error
+
+	_ = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		responses, errs = r.bulkImport(tx, reposToImport)
+		if len(errs) > 0 {
+			err = errors.New("rollback bulk import")
+			return err
+		}
+
+		mappedValues := []repositories.Repositories{}
+		for i := 0; i < len(responses); i++ {
+			if len(responses[i].Warnings) == 0 {
+				mappedValues = append(mappedValues, notifications.MapRepositoryResponse(responses[i].RepositoryResponse))
+			}
+		}
+		if err = notifications.QueueNotification(tx, *reposToImport[0].OrgID, notifications.RepositoryCreated, mappedValues); err != nil {
+			errs = []error{DBErrorToApi(err)}
+			responses = []api.RepositoryImportResponse{}
+		}
+		return err
+	})
 
 	return responses, errs
 }
 
+func (r repositoryConfigDaoImpl) bulkImport(tx *gorm.DB, reposToImport []api.RepositoryRequest) ([]api.RepositoryImportResponse, []error) {
+	responses := make([]api.RepositoryImportResponse, len(reposToImport))
+	toCreate := []api.RepositoryRequest{}
+	createdIndexes := []int{}
+
+	for i := 0; i < len(reposToImport); i++ {
+		var existing models.RepositoryConfiguration
+		var orgID string
+		if reposToImport[i].OrgID != nil {
+			orgID = *reposToImport[i].OrgID
+		}
+		var url string
+		if reposToImport[i].URL != nil {
+			url = models.CleanupURL(*reposToImport[i].URL)
+		}
+		result := tx.
+			Scopes(preloadStatus).
+			Joins("inner join repositories on repositories.uuid = repository_configurations.repository_uuid").
+			Scopes(WithOrg(orgID)).
+			Where("repositories.url = ?", url).
+			Limit(1).
+			Find(&existing)
+		if result.Error != nil {
+			return []api.RepositoryImportResponse{}, []error{DBErrorToApi(result.Error)}
+		}
+		if result.RowsAffected > 0 {
+			ModelToApiFields(existing, &responses[i].RepositoryResponse)
+			responses[i].Warnings = []string{"Repository with this URL already belongs to organization"}
+			continue
+		}
+		toCreate = append(toCreate, reposToImport[i])
+		createdIndexes = append(createdIndexes, i)
+	}
+
+	if len(toCreate) == 0 {
+		return responses, []error{}
+	}
+	created, createErrs := r.bulkCreate(tx, toCreate)
+	if len(createErrs) > 0 {
+		errs := make([]error, len(reposToImport))
+		for i, index := range createdIndexes {
+			errs[index] = createErrs[i]
+		}
+		return []api.RepositoryImportResponse{}, errs
+	}
+	for i, index := range createdIndexes {
+		responses[index].RepositoryResponse = created[i]
+		responses[index].Warnings = []string{}
+	}
+	return responses, []error{}
+}
+
 func (r repositoryConfigDaoImpl) bulkCreate(tx *gorm.DB, newRepositories []api.RepositoryRequest) ([]api.RepositoryResponse, []error) {
 	var dbErr error
 	size := len(newRepositories)
@@ -135,6 +259,16 @@ func (r repositoryConfigDaoImpl) bulkCreate(tx *gorm.DB, newRepositories []api.R
 			newRepoConfigs[i].AccountID = *(newRepositories[i].AccountID)
 		}
 		ApiFieldsToModel(newRepositories[i], &newRepoConfigs[i], &newRepos[i])
+		if err := encryptSecrets(newRepositories[i], &newRepoConfigs[i]); err != nil {
+			dbErr = err
+			errors[i] = dbErr
+			continue
+		}
+		if message := models.DeniedURLMessage(newRepos[i].URL); message != "" {
+			dbErr = &ce.DaoError{BadValidation: true, Message: message}
+			errors[i] = dbErr
+			continue
+		}
 		newRepos[i].Status = "Pending"
 		cleanedUrl := models.CleanupURL(newRepos[i].URL)
 		create := tx.Where("url = ?", cleanedUrl).FirstOrCreate(&newRepos[i])
@@ -171,6 +305,23 @@ func (r repositoryConfigDaoImpl) bulkCreate(tx *gorm.DB, newRepositories []api.R
 }
 
 func (r repositoryConfigDaoImpl) List(
+	ctx context.Context,
+	OrgID string,
+	pageData api.PaginationData,
+	filterData api.FilterData,
+) (api.RepositoryCollectionResponse, int64, error) {
+	var response api.RepositoryCollectionResponse
+	var total int64
+	err := readOnly(ctx, r.db, func(conn *gorm.DB) error {
+		var err error
+		response, total, err = repositoryConfigDaoImpl{db: conn, yumRepo: r.yumRepo}.list(ctx, OrgID, pageData, filterData)
+		return err
+	})
+	return response, total, err
+}
+
+func (r repositoryConfigDaoImpl) list(
+	ctx context.Context,
 	OrgID string,
 	pageData api.PaginationData,
 	filterData api.FilterData,
@@ -178,10 +329,11 @@ func (r repositoryConfigDaoImpl) List(
 	var totalRepos int64
 	repoConfigs := make([]models.RepositoryConfiguration, 0)
 
-	filteredDB := r.db
+	filteredDB := r.db.WithContext(ctx)
 
-	filteredDB = filteredDB.Where("org_id = ?", OrgID).
-		Joins("inner join repositories on repository_configurations.repository_uuid = repositories.uuid")
+	filteredDB = filteredDB.Scopes(WithOrg(OrgID)).
+		Joins("inner join repositories on repository_configurations.repository_uuid = repositories.uuid").
+		Joins(models.JoinLastSnapshotTask)
 
 	if filterData.Name != "" {
 		filteredDB = filteredDB.Where("name = ?", filterData.Name)
@@ -211,7 +363,7 @@ func (r repositoryConfigDaoImpl) List(
 
 	if filterData.Version != "" {
 		versions := strings.Split(filterData.Version, ",")
-		orGroup := r.db.Where("? = any (versions)", versions[0])
+		orGroup := r.db.WithContext(ctx).Where("? = any (versions)", versions[0])
 		for i := 1; i < len(versions); i++ {
 			orGroup = orGroup.Or("? = any (versions)", versions[i])
 		}
@@ -220,7 +372,20 @@ func (r repositoryConfigDaoImpl) List(
 
 	if filterData.Status != "" {
 		statuses := strings.Split(filterData.Status, ",")
-		filteredDB = filteredDB.Where("status IN ?", statuses)
+		filteredDB = filteredDB.Where(models.RollupStatusSQL+" IN ?", statuses)
+	}
+
+	if filterData.ContentType != "" {
+		contentTypes := strings.Split(filterData.ContentType, ",")
+		filteredDB = filteredDB.Where("content_type IN ?", contentTypes)
+	}
+
+	if filterData.Snapshot != "" {
+		snapshot, err := strconv.ParseBool(filterData.Snapshot)
+		if err != nil {
+			return api.RepositoryCollectionResponse{}, 0, &ce.DaoError{BadValidation: true, Message: "snapshot must be true or false"}
+		}
+		filteredDB = filteredDB.Where("snapshot = ?", snapshot)
 	}
 
 	sortMap := map[string]string{
@@ -230,13 +395,19 @@ func (r repositoryConfigDaoImpl) List(
 		"distribution_versions":   "array_to_string(versions, ',')",
 		"package_count":           "package_count",
 		"last_introspection_time": "last_introspection_time",
-		"status":                  "status",
+		"status":                  models.RollupStatusSQL,
+		"introspection_status":    "repositories.status",
+		"content_type":            "content_type",
+		"last_snapshot_at":        "last_snapshot_at",
 	}
 
 	order := convertSortByToSQL(pageData.SortBy, sortMap)
 
-	filteredDB.Order(order).Find(&repoConfigs).Count(&totalRepos)
-	filteredDB.Preload("Repository").Limit(pageData.Limit).Offset(pageData.Offset).Find(&repoConfigs)
+	filteredDB = filteredDB.Model(&models.RepositoryConfiguration{}).Order(order)
+	if err := countTotal(filteredDB, pageData, &totalRepos); err != nil {
+		return api.RepositoryCollectionResponse{}, 0, err
+	}
+	selectRepositoryFields(filteredDB, pageData.Fields).Limit(pageData.Limit).Offset(pageData.Offset).Find(&repoConfigs)
 
 	if filteredDB.Error != nil {
 		return api.RepositoryCollectionResponse{}, totalRepos, filteredDB.Error
@@ -245,12 +416,12 @@ func (r repositoryConfigDaoImpl) List(
 	return api.RepositoryCollectionResponse{Data: repos}, totalRepos, nil
 }
 
-func (r repositoryConfigDaoImpl) InternalOnly_FetchRepoConfigsForRepoUUID(uuid string) []api.RepositoryResponse {
+func (r repositoryConfigDaoImpl) InternalOnly_FetchRepoConfigsForRepoUUID(ctx context.Context, uuid string) []api.RepositoryResponse {
 	repoConfigs := make([]models.RepositoryConfiguration, 0)
-	filteredDB := r.db.Where("repositories.uuid = ?", uuid).
+	filteredDB := r.db.WithContext(ctx).Where("repositories.uuid = ?", uuid).
 		Joins("inner join repositories on repository_configurations.repository_uuid = repositories.uuid")
 
-	filteredDB.Preload("Repository").Find(&repoConfigs)
+	filteredDB.Scopes(preloadStatus).Find(&repoConfigs)
 
 	if filteredDB.Error != nil {
 		log.Error().Msgf("Unable to ListRepos: %v", uuid)
@@ -260,9 +431,50 @@ func (r repositoryConfigDaoImpl) InternalOnly_FetchRepoConfigsForRepoUUID(uuid s
 	return convertToResponses(repoConfigs)
 }
 
-func (r repositoryConfigDaoImpl) Fetch(orgID string, uuid string) (api.RepositoryResponse, error) {
+// InternalOnly_FetchPendingDelete returns the deleted repository configurations across orgs
+// whose snapshot cleanup is not queued or running, e.g. because enqueueing the cleanup failed
+// or the cleanup task failed.
+func (r repositoryConfigDaoImpl) InternalOnly_FetchPendingDelete(ctx context.Context) ([]api.RepositoryResponse, error) {
+	repoConfigs := make([]models.RepositoryConfiguration, 0)
+	result := r.db.WithContext(ctx).Unscoped().
+		Scopes(preloadStatus).
+		Where("repository_configurations.deleted_at IS NOT NULL").
+		Where(`NOT EXISTS (SELECT 1 FROM tasks WHERE tasks.type = ? AND tasks.status IN ?
+			AND tasks.payload->>'RepoConfigUUID' = text(repository_configurations.uuid))`,
+			config.DeleteRepositorySnapshotsTask, []string{config.TaskStatusPending, config.TaskStatusRunning}).
+		Find(&repoConfigs)
+	if result.Error != nil {
+		return nil, DBErrorToApi(result.Error)
+	}
+	return convertToResponses(repoConfigs), nil
+}
+
+// InternalOnly_FetchFailingByOrg returns the repository configurations whose repository fails
+// to introspect, by org, leaving out the orgs that opted out of the introspection digest.
+func (r repositoryConfigDaoImpl) InternalOnly_FetchFailingByOrg(ctx context.Context) (map[string][]api.RepositoryResponse, error) {
+	ctx = db.WithQueryClass(ctx, db.QueryReport)
+	repoConfigs := make([]models.RepositoryConfiguration, 0)
+	result := r.db.WithContext(ctx).
+		Scopes(preloadStatus).
+		Joins("inner join repositories on repository_configurations.repository_uuid = repositories.uuid").
+		Where("repositories.status IN ?", []string{config.StatusInvalid, config.StatusUnavailable}).
+		Where(`NOT EXISTS (SELECT 1 FROM org_settings WHERE org_settings.org_id = repository_configurations.org_id
+			AND org_settings.introspection_digest_opt_out)`).
+		Order("repository_configurations.org_id, repository_configurations.name").
+		Find(&repoConfigs)
+	if result.Error != nil {
+		return nil, DBErrorToApi(result.Error)
+	}
+	failing := make(map[string][]api.RepositoryResponse)
+	for _, repo := range convertToResponses(repoConfigs) {
+		failing[repo.OrgID] = append(failing[repo.OrgID], repo)
+	}
+	return failing, nil
+}
+
+func (r repositoryConfigDaoImpl) Fetch(ctx context.Context, orgID string, uuid string) (api.RepositoryResponse, error) {
 	repo := api.RepositoryResponse{}
-	repoConfig, err := r.fetchRepoConfig(orgID, uuid)
+	repoConfig, err := r.fetchRepoConfig(ctx, orgID, uuid)
 
 	if err != nil {
 		return repo, err
@@ -271,11 +483,12 @@ func (r repositoryConfigDaoImpl) Fetch(orgID string, uuid string) (api.Repositor
 	return repo, err
 }
 
-func (r repositoryConfigDaoImpl) fetchRepoConfig(orgID string, uuid string) (models.RepositoryConfiguration, error) {
+func (r repositoryConfigDaoImpl) fetchRepoConfig(ctx context.Context, orgID string, uuid string) (models.RepositoryConfiguration, error) {
 	found := models.RepositoryConfiguration{}
-	result := r.db.
-		Preload("Repository").
-		Where("text(UUID) = ? AND ORG_ID = ?", uuid, orgID).
+	result := r.db.WithContext(ctx).
+		Scopes(preloadStatus).
+		Scopes(WithOrg(orgID)).
+		Where("uuid = ?", uuid).
 		First(&found)
 
 	if result.Error != nil {
@@ -288,14 +501,33 @@ func (r repositoryConfigDaoImpl) fetchRepoConfig(orgID string, uuid string) (mod
 	return found, nil
 }
 
-func (r repositoryConfigDaoImpl) FetchByRepoUuid(orgID string, repoUuid string) (api.RepositoryResponse, error) {
+// FetchProxy returns the proxy a repository configuration is reached through, including its password
+func (r repositoryConfigDaoImpl) FetchProxy(ctx context.Context, orgID string, uuid string) (RepositoryProxy, error) {
+	found, err := r.fetchRepoConfig(ctx, orgID, uuid)
+	if err != nil {
+		return RepositoryProxy{}, err
+	}
+	return proxyFromModel(found)
+}
+
+// FetchCredentials returns the credentials to authenticate with the repository, with the password decrypted
+func (r repositoryConfigDaoImpl) FetchCredentials(ctx context.Context, orgID string, uuid string) (RepositoryCredentials, error) {
+	found, err := r.fetchRepoConfig(ctx, orgID, uuid)
+	if err != nil {
+		return RepositoryCredentials{}, err
+	}
+	return credentialsFromModel(found)
+}
+
+func (r repositoryConfigDaoImpl) FetchByRepoUuid(ctx context.Context, orgID string, repoUuid string) (api.RepositoryResponse, error) {
 	repoConfig := models.RepositoryConfiguration{}
 	repo := api.RepositoryResponse{}
 
-	result := r.db.
-		Preload("Repository").
+	result := r.db.WithContext(ctx).
+		Scopes(preloadStatus).
 		Joins("Inner join repositories on repositories.uuid = repository_configurations.repository_uuid").
-		Where("text(Repositories.UUID) = ? AND ORG_ID = ?", repoUuid, orgID).
+		Scopes(WithOrg(orgID)).
+		Where("text(Repositories.UUID) = ?", repoUuid).
 		First(&repoConfig)
 
 	if result.Error != nil {
@@ -309,24 +541,101 @@ func (r repositoryConfigDaoImpl) FetchByRepoUuid(orgID string, repoUuid string)
 	return repo, nil
 }
 
+// BulkExport returns the requested repositories of the org, with their latest snapshot.
+// Fails with a not found error if any of the repositories does not exist.
+func (r repositoryConfigDaoImpl) BulkExport(ctx context.Context, orgID string, reposToExport api.RepositoryExportRequest) ([]api.RepositoryExportResponse, error) {
+	var repoConfigs []models.RepositoryConfiguration
+	for _, uuids := range chunkStrings(reposToExport.RepositoryUuids, bulkExportChunkSize) {
+		var found []models.RepositoryConfiguration
+		result := r.db.WithContext(ctx).
+			Scopes(preloadStatus).
+			Scopes(WithOrg(orgID)).
+			Where("uuid IN ?", uuids).
+			Find(&found)
+		if result.Error != nil {
+			return nil, DBErrorToApi(result.Error)
+		}
+		repoConfigs = append(repoConfigs, found...)
+	}
+
+	byUUID := make(map[string]models.RepositoryConfiguration, len(repoConfigs))
+	for _, repoConfig := range repoConfigs {
+		byUUID[repoConfig.UUID] = repoConfig
+	}
+	latest, err := r.latestSnapshots(ctx, reposToExport.RepositoryUuids)
+	if err != nil {
+		return nil, err
+	}
+
+	exported := make([]api.RepositoryExportResponse, 0, len(reposToExport.RepositoryUuids))
+	for _, uuid := range reposToExport.RepositoryUuids {
+		repoConfig, ok := byUUID[uuid]
+		if !ok {
+			return nil, &ce.DaoError{NotFound: true, Message: "Could not find repository with UUID " + uuid}
+		}
+		export := api.RepositoryExportResponse{
+			UUID:                 repoConfig.UUID,
+			Name:                 repoConfig.Name,
+			URL:                  repoConfig.Repository.URL,
+			DistributionVersions: repoConfig.Versions,
+			DistributionArch:     repoConfig.Arch,
+			GpgKey:               repoConfig.GpgKey,
+			MetadataVerification: repoConfig.MetadataVerification,
+			Snapshot:             repoConfig.Snapshot,
+			ContentType:          repoConfig.ContentType,
+		}
+		if snap, ok := latest[uuid]; ok {
+			export.LatestSnapshotURL = snapshotURL(snap.RepositoryPath)
+		}
+		exported = append(exported, export)
+	}
+	return exported, nil
+}
+
+// latestSnapshots returns the latest snapshot of each of the repository configurations, by uuid
+func (r repositoryConfigDaoImpl) latestSnapshots(ctx context.Context, repoConfigUUIDs []string) (map[string]models.Snapshot, error) {
+	latest := make(map[string]models.Snapshot, len(repoConfigUUIDs))
+	for _, uuids := range chunkStrings(repoConfigUUIDs, bulkExportChunkSize) {
+		var snaps []models.Snapshot
+		result := r.db.WithContext(ctx).
+			Select("DISTINCT ON (repository_configuration_uuid) *").
+			Where("repository_configuration_uuid IN ?", uuids).
+			Order("repository_configuration_uuid, created_at DESC").
+			Find(&snaps)
+		if result.Error != nil {
+			return nil, DBErrorToApi(result.Error)
+		}
+		for _, snap := range snaps {
+			latest[snap.RepositoryConfigurationUUID] = snap
+		}
+	}
+	return latest, nil
+}
+
 // Update updates a RepositoryConfig with changed parameters.  Returns whether the url changed, and an error if updating failed
-func (r repositoryConfigDaoImpl) Update(orgID, uuid string, repoParams api.RepositoryRequest) (bool, error) {
+func (r repositoryConfigDaoImpl) Update(ctx context.Context, orgID, uuid string, repoParams api.RepositoryRequest) (bool, error) {
 	var repo models.Repository
 	var repoConfig models.RepositoryConfiguration
 	var err error
 	updatedUrl := false
 
 	// We are updating the repo config & snapshots, so bundle in a transaction
-	err = r.db.Transaction(func(tx *gorm.DB) error {
-		if repoConfig, err = r.fetchRepoConfig(orgID, uuid); err != nil {
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if repoConfig, err = r.fetchRepoConfig(ctx, orgID, uuid); err != nil {
 			return err
 		}
 		ApiFieldsToModel(repoParams, &repoConfig, &repo)
+		if err = encryptSecrets(repoParams, &repoConfig); err != nil {
+			return err
+		}
 
 		// If URL is included in params, search for existing
 		// Repository record, or create a new one.
 		// Then replace existing Repository/RepoConfig association.
 		if repoParams.URL != nil {
+			if message := models.DeniedURLMessage(*repoParams.URL); message != "" {
+				return &ce.DaoError{BadValidation: true, Message: message}
+			}
 			cleanedUrl := models.CleanupURL(*repoParams.URL)
 			err = tx.FirstOrCreate(&repo, "url = ?", cleanedUrl).Error
 			if err != nil {
@@ -344,103 +653,192 @@ func (r repositoryConfigDaoImpl) Update(orgID, uuid string, repoParams api.Repos
 		repositoryResponse := api.RepositoryResponse{}
 		ModelToApiFields(repoConfig, &repositoryResponse)
 
-		notifications.SendNotification(
+		return notifications.QueueNotification(
+			tx,
 			orgID,
 			notifications.RepositoryUpdated,
 			[]repositories.Repositories{notifications.MapRepositoryResponse(repositoryResponse)},
 		)
-		return nil
 	})
 	if err != nil {
 		return updatedUrl, err
 	}
 
-	repositoryResponse := api.RepositoryResponse{}
-	ModelToApiFields(repoConfig, &repositoryResponse)
-
-	notifications.SendNotification(
-		orgID,
-		notifications.RepositoryUpdated,
-		[]repositories.Repositories{notifications.MapRepositoryResponse(repositoryResponse)},
-	)
-
 	repoConfig.Repository = models.Repository{}
-	if err := r.db.Model(&repoConfig).Updates(repoConfig.MapForUpdate()).Error; err != nil {
+	if err := r.db.WithContext(ctx).Model(&repoConfig).Updates(repoConfig.MapForUpdate()).Error; err != nil {
 		return updatedUrl, DBErrorToApi(err)
 	}
 
 	return updatedUrl, nil
 }
 
+// UpdateLastSnapshotTask records the latest task snapshotting the repository of the org
+func (r repositoryConfigDaoImpl) UpdateLastSnapshotTask(ctx context.Context, taskUUID string, orgID string, repoUUID string) error {
+	result := r.db.WithContext(ctx).Model(&models.RepositoryConfiguration{}).
+		Where("org_id = ? AND repository_uuid = ?", orgID, repoUUID).
+		UpdateColumn("last_snapshot_task_uuid", taskUUID)
+	if result.Error != nil {
+		return DBErrorToApi(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return &ce.DaoError{NotFound: true, Message: "Could not find repository with UUID " + repoUUID}
+	}
+	return nil
+}
+
+// BulkUpdate applies the same changes to repositories of an organization, updating all of them or none
+func (r repositoryConfigDaoImpl) BulkUpdate(ctx context.Context, orgID string, uuids []string, repoParams api.RepositoryRequest) ([]api.RepositoryResponse, []error) {
+	var responses []api.RepositoryResponse
+	var errs []error
+
+	_ = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		responses, errs = r.bulkUpdate(ctx, tx, orgID, uuids, repoParams)
+		if len(errs) > 0 {
+			err = errors.New("rollback bulk update")
+			return err
+		}
+
+		mappedValues := make([]repositories.Repositories, len(responses))
+		for i := 0; i < len(responses); i++ {
+			mappedValues[i] = notifications.MapRepositoryResponse(responses[i])
+		}
+		if err = notifications.QueueNotification(tx, orgID, notifications.RepositoryUpdated, mappedValues); err != nil {
+			errs = []error{DBErrorToApi(err)}
+			responses = []api.RepositoryResponse{}
+		}
+		return err
+	})
+
+	return responses, errs
+}
+
+func (r repositoryConfigDaoImpl) bulkUpdate(ctx context.Context, tx *gorm.DB, orgID string, uuids []string, repoParams api.RepositoryRequest) ([]api.RepositoryResponse, []error) {
+	var dbErr error
+	size := len(uuids)
+	errors := make([]error, size)
+	responses := make([]api.RepositoryResponse, size)
+	const save = "beforeupdate"
+
+	tx.SavePoint(save)
+	for i := 0; i < size; i++ {
+		var err error
+		var repoConfig models.RepositoryConfiguration
+
+		if repoConfig, err = r.fetchRepoConfig(ctx, orgID, uuids[i]); err != nil {
+			dbErr = DBErrorToApi(err)
+			errors[i] = dbErr
+			tx.RollbackTo(save)
+			continue
+		}
+		// The URL is not updated in bulk, so the repository is left unchanged
+		ApiFieldsToModel(repoParams, &repoConfig, &models.Repository{})
+		if err = encryptSecrets(repoParams, &repoConfig); err != nil {
+			dbErr = err
+			errors[i] = dbErr
+			tx.RollbackTo(save)
+			continue
+		}
+		ModelToApiFields(repoConfig, &responses[i])
+
+		repoConfig.Repository = models.Repository{}
+		if err = tx.Model(&repoConfig).Updates(repoConfig.MapForUpdate()).Error; err != nil {
+			dbErr = DBErrorToApi(err)
+			errors[i] = dbErr
+			tx.RollbackTo(save)
+			continue
+		}
+	}
+
+	if dbErr == nil {
+		return responses, []error{}
+	} else {
+		return []api.RepositoryResponse{}, errors
+	}
+}
+
 // SavePublicRepos saves a list of urls and marks them as "Public"
 // This is meant for the list of repositories that are preloaded for all
 // users.
-func (r repositoryConfigDaoImpl) SavePublicRepos(urls []string) error {
+func (r repositoryConfigDaoImpl) SavePublicRepos(ctx context.Context, urls []string) error {
+	ctx = db.WithQueryClass(ctx, db.QueryBulk)
 	var repos []models.Repository
 
 	for i := 0; i < len(urls); i++ {
 		repos = append(repos, models.Repository{URL: urls[i], Public: true})
 	}
-	result := r.db.Clauses(clause.OnConflict{
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "url"}},
 		DoNothing: true}).Create(&repos)
 	return result.Error
 }
 
-func (r repositoryConfigDaoImpl) SoftDelete(orgID string, uuid string) error {
+func (r repositoryConfigDaoImpl) SoftDelete(ctx context.Context, orgID string, uuid string) error {
 	var repoConfig models.RepositoryConfiguration
 	var err error
 
-	if repoConfig, err = r.fetchRepoConfig(orgID, uuid); err != nil {
+	if repoConfig, err = r.fetchRepoConfig(ctx, orgID, uuid); err != nil {
 		return err
 	}
 
-	if err = r.db.Delete(&repoConfig).Error; err != nil {
-		return err
-	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&repoConfig).Error; err != nil {
+			return err
+		}
 
-	repositoryResponse := api.RepositoryResponse{}
-	ModelToApiFields(repoConfig, &repositoryResponse)
+		repositoryResponse := api.RepositoryResponse{}
+		ModelToApiFields(repoConfig, &repositoryResponse)
 
-	notifications.SendNotification(
-		orgID,
-		notifications.RepositoryDeleted,
-		[]repositories.Repositories{notifications.MapRepositoryResponse(repositoryResponse)},
-	)
+		if err := queueWebhookEvent(tx, orgID, api.WebhookEventRepositoryDeleted,
+			api.WebhookEventData{Repository: api.NewWebhookRepository(repositoryResponse)}); err != nil {
+			return err
+		}
 
-	return nil
+		return notifications.QueueNotification(
+			tx,
+			orgID,
+			notifications.RepositoryDeleted,
+			[]repositories.Repositories{notifications.MapRepositoryResponse(repositoryResponse)},
+		)
+	})
 }
 
-func (r repositoryConfigDaoImpl) Delete(orgID string, uuid string) error {
+func (r repositoryConfigDaoImpl) Delete(ctx context.Context, orgID string, uuid string) error {
 	repoConfig := models.RepositoryConfiguration{Base: models.Base{UUID: uuid}, OrgID: orgID}
-	return r.db.Unscoped().Delete(&repoConfig).Error
+	return r.db.WithContext(ctx).Unscoped().Scopes(WithOrg(orgID)).Delete(&repoConfig).Error
 }
 
-func (r repositoryConfigDaoImpl) BulkDelete(orgID string, uuids []string) []error {
+func (r repositoryConfigDaoImpl) BulkDelete(ctx context.Context, orgID string, uuids []string) []error {
 	var responses []api.RepositoryResponse
 	var errs []error
 
-	_ = r.db.Transaction(func(tx *gorm.DB) error {
+	_ = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var err error
-		responses, errs = r.bulkDelete(tx, orgID, uuids)
+		responses, errs = r.bulkDelete(ctx, tx, orgID, uuids)
 		if len(errs) > 0 {
 			err = errors.New("rollback bulk delete")
+			return err
 		}
-		return err
-	})
 
-	if len(responses) > 0 {
 		mappedValues := make([]repositories.Repositories, len(responses))
 		for i := 0; i < len(responses); i++ {
 			mappedValues[i] = notifications.MapRepositoryResponse(responses[i])
+			if err = queueWebhookEvent(tx, orgID, api.WebhookEventRepositoryDeleted,
+				api.WebhookEventData{Repository: api.NewWebhookRepository(responses[i])}); err != nil {
+				errs = []error{DBErrorToApi(err)}
+				return err
+			}
 		}
-		notifications.SendNotification(orgID, notifications.RepositoryDeleted, mappedValues)
-	}
+		if err = notifications.QueueNotification(tx, orgID, notifications.RepositoryDeleted, mappedValues); err != nil {
+			errs = []error{DBErrorToApi(err)}
+		}
+		return err
+	})
 
 	return errs
 }
 
-func (r repositoryConfigDaoImpl) bulkDelete(tx *gorm.DB, orgID string, uuids []string) ([]api.RepositoryResponse, []error) {
+func (r repositoryConfigDaoImpl) bulkDelete(ctx context.Context, tx *gorm.DB, orgID string, uuids []string) ([]api.RepositoryResponse, []error) {
 	var dbErr error
 	size := len(uuids)
 	errors := make([]error, size)
@@ -452,7 +850,7 @@ func (r repositoryConfigDaoImpl) bulkDelete(tx *gorm.DB, orgID string, uuids []s
 		var err error
 		var repoConfig models.RepositoryConfiguration
 
-		if repoConfig, err = r.fetchRepoConfig(orgID, uuids[i]); err != nil {
+		if repoConfig, err = r.fetchRepoConfig(ctx, orgID, uuids[i]); err != nil {
 			dbErr = DBErrorToApi(err)
 			errors[i] = dbErr
 			tx.RollbackTo(save)
@@ -500,6 +898,95 @@ func ApiFieldsToModel(apiRepo api.RepositoryRequest, repoConfig *models.Reposito
 	if apiRepo.Snapshot != nil {
 		repoConfig.Snapshot = *apiRepo.Snapshot
 	}
+	if apiRepo.ContentType != nil {
+		repoConfig.ContentType = *apiRepo.ContentType
+	}
+	if apiRepo.ProxyURL != nil {
+		repoConfig.ProxyURL = *apiRepo.ProxyURL
+	}
+	if apiRepo.ProxyUsername != nil {
+		repoConfig.ProxyUsername = *apiRepo.ProxyUsername
+	}
+	if apiRepo.Username != nil {
+		repoConfig.Username = *apiRepo.Username
+	}
+}
+
+// encryptSecrets sets the encrypted passwords of the request on the repository configuration
+func encryptSecrets(apiRepo api.RepositoryRequest, repoConfig *models.RepositoryConfiguration) error {
+	if apiRepo.Password != nil {
+		encrypted, err := crypto.Encrypt(*apiRepo.Password)
+		if err != nil {
+			return fmt.Errorf("could not encrypt the repository password: %w", err)
+		}
+		repoConfig.Password = encrypted
+	}
+	if apiRepo.ProxyPassword != nil {
+		encrypted, err := crypto.Encrypt(*apiRepo.ProxyPassword)
+		if err != nil {
+			return fmt.Errorf("could not encrypt the proxy password: %w", err)
+		}
+		repoConfig.ProxyPassword = encrypted
+	}
+	return nil
+}
+
+// secretColumns are the encrypted columns, the passwords of repository configurations set by
+// encryptSecrets and the secrets of webhooks
+var secretColumns = []struct {
+	model  interface{}
+	name   string
+	column string
+}{
+	{&models.RepositoryConfiguration{}, "repository configuration", "password"},
+	{&models.RepositoryConfiguration{}, "repository configuration", "proxy_password"},
+	{&models.Webhook{}, "webhook", "secret"},
+}
+
+// InternalOnly_RotateSecrets re-encrypts with the current key the secrets encrypted with a previous key,
+// returning the number of rotated values. A row is only updated if its secret did not change since
+// it was read, so that secrets can be rotated while the service runs.
+func (r repositoryConfigDaoImpl) InternalOnly_RotateSecrets(ctx context.Context) (int64, error) {
+	ctx = db.WithQueryClass(ctx, db.QueryBulk)
+	current, err := crypto.CurrentKeyID()
+	if err != nil {
+		return 0, err
+	}
+	var rotated int64
+	for _, secret := range secretColumns {
+		column := secret.column
+		for {
+			var rows []struct {
+				UUID   string
+				Secret string
+			}
+			err = r.db.WithContext(ctx).Unscoped().Model(secret.model).
+				Select("uuid, "+column+" AS secret").
+				Where(column+" != '' AND split_part("+column+", ':', 1) != ?", current).
+				Limit(rotateSecretsBatchSize).
+				Scan(&rows).Error
+			if err != nil {
+				return rotated, err
+			}
+			if len(rows) == 0 {
+				break
+			}
+			for _, row := range rows {
+				value, err := crypto.Rotate(row.Secret)
+				if err != nil {
+					return rotated, fmt.Errorf("could not rotate %s of %s %s: %w", column, secret.name, row.UUID, err)
+				}
+				result := r.db.WithContext(ctx).Unscoped().Model(secret.model).
+					Where("uuid = ? AND "+column+" = ?", row.UUID, row.Secret).
+					UpdateColumn(column, value)
+				if result.Error != nil {
+					return rotated, result.Error
+				}
+				rotated += result.RowsAffected
+			}
+		}
+	}
+	return rotated, nil
 }
 
 func ModelToApiFields(repoConfig models.RepositoryConfiguration, apiRepo *api.RepositoryResponse) {
@@ -511,12 +998,17 @@ func ModelToApiFields(repoConfig models.RepositoryConfiguration, apiRepo *api.Re
 	apiRepo.DistributionArch = repoConfig.Arch
 	apiRepo.AccountID = repoConfig.AccountID
 	apiRepo.OrgID = repoConfig.OrgID
-	apiRepo.Status = repoConfig.Repository.Status
+	apiRepo.Status = repoConfig.RollupStatus()
+	apiRepo.IntrospectionStatus = repoConfig.Repository.Status
 	apiRepo.GpgKey = repoConfig.GpgKey
 	apiRepo.MetadataVerification = repoConfig.MetadataVerification
 	apiRepo.FailedIntrospectionsCount = repoConfig.Repository.FailedIntrospectionsCount
 	apiRepo.RepositoryUUID = repoConfig.RepositoryUUID
 	apiRepo.Snapshot = repoConfig.Snapshot
+	apiRepo.ContentType = repoConfig.ContentType
+	apiRepo.ProxyURL = repoConfig.ProxyURL
+	apiRepo.ProxyUsername = repoConfig.ProxyUsername
+	apiRepo.Username = repoConfig.Username
 
 	if repoConfig.Repository.LastIntrospectionTime != nil {
 		apiRepo.LastIntrospectionTime = repoConfig.Repository.LastIntrospectionTime.Format(time.RFC3339)
@@ -530,6 +1022,93 @@ func ModelToApiFields(repoConfig models.RepositoryConfiguration, apiRepo *api.Re
 	if repoConfig.Repository.LastIntrospectionError != nil {
 		apiRepo.LastIntrospectionError = *repoConfig.Repository.LastIntrospectionError
 	}
+	if repoConfig.Repository.LastIntrospectionErrorCode != nil {
+		apiRepo.LastIntrospectionErrorCode = *repoConfig.Repository.LastIntrospectionErrorCode
+	}
+	if repoConfig.LastSnapshotUUID != nil {
+		apiRepo.LastSnapshotUUID = *repoConfig.LastSnapshotUUID
+	}
+	if repoConfig.LastSnapshotTaskUUID != nil {
+		apiRepo.LastSnapshotTaskUUID = *repoConfig.LastSnapshotTaskUUID
+	}
+	if repoConfig.LastSnapshotAt != nil {
+		apiRepo.LastSnapshotAt = repoConfig.LastSnapshotAt.Format(time.RFC3339)
+	}
+}
+
+// repositoryConfigFields maps the fields of a repository response to the columns of repository configurations
+var repositoryConfigFields = map[string]string{
+	"uuid":                    "uuid",
+	"name":                    "name",
+	"distribution_versions":   "versions",
+	"distribution_arch":       "arch",
+	"account_id":              "account_id",
+	"org_id":                  "org_id",
+	"gpg_key":                 "gpg_key",
+	"metadata_verification":   "metadata_verification",
+	"snapshot":                "snapshot",
+	"content_type":            "content_type",
+	"proxy_url":               "proxy_url",
+	"proxy_username":          "proxy_username",
+	"username":                "username",
+	"last_snapshot_uuid":      "last_snapshot_uuid",
+	"last_snapshot_task_uuid": "last_snapshot_task_uuid",
+	"last_snapshot_at":        "last_snapshot_at",
+}
+
+// repositoryFields maps the fields of a repository response to the columns of repositories
+var repositoryFields = map[string]string{
+	"url":                             "url",
+	"last_introspection_time":         "last_introspection_time",
+	"last_success_introspection_time": "last_introspection_success_time",
+	"last_update_introspection_time":  "last_introspection_update_time",
+	"last_introspection_error":        "last_introspection_error",
+	"last_introspection_error_code":   "last_introspection_error_code",
+	"failed_introspections_count":     "failed_introspections_count",
+	"package_count":                   "package_count",
+	"introspection_status":            "status",
+}
+
+// preloadStatus loads the repository and the last snapshot task of repository configurations,
+// which their status is rolled up from
+func preloadStatus(db *gorm.DB) *gorm.DB {
+	return db.Preload("Repository").Preload("LastSnapshotTask", func(tx *gorm.DB) *gorm.DB {
+		return tx.Select("id", "status")
+	})
+}
+
+// selectRepositoryFields reads only the columns of the requested fields, and
+// the repository only if one of its fields is requested
+func selectRepositoryFields(db *gorm.DB, fields []string) *gorm.DB {
+	if len(fields) == 0 {
+		return db.Scopes(preloadStatus)
+	}
+	configColumns := []string{"repository_configurations.uuid", "repository_configurations.repository_uuid"}
+	repoColumns := []string{"uuid"}
+	for _, field := range fields {
+		if field == "status" {
+			// The status is rolled up from the repository and the snapshots of the configuration
+			return db.Scopes(preloadStatus)
+		}
+		if column, ok := repositoryConfigFields[field]; ok {
+			if !slices.Contains(configColumns, "repository_configurations."+column) {
+				configColumns = append(configColumns, "repository_configurations."+column)
+			}
+		} else if column, ok := repositoryFields[field]; ok {
+			if !slices.Contains(repoColumns, column) {
+				repoColumns = append(repoColumns, column)
+			}
+		} else {
+			return db.Scopes(preloadStatus)
+		}
+	}
+	db = db.Select(configColumns)
+	if len(repoColumns) > 1 {
+		db = db.Preload("Repository", func(tx *gorm.DB) *gorm.DB {
+			return tx.Select(repoColumns)
+		})
+	}
+	return db
 }
 
 // Converts the database models to our response objects
@@ -551,7 +1130,7 @@ func isTimeout(err error) bool {
 	return false
 }
 
-func (r repositoryConfigDaoImpl) ValidateParameters(orgId string, params api.RepositoryValidationRequest, excludedUUIDS []string) (api.RepositoryValidationResponse, error) {
+func (r repositoryConfigDaoImpl) ValidateParameters(ctx context.Context, orgId string, params api.RepositoryValidationRequest, excludedUUIDS []string) (api.RepositoryValidationResponse, error) {
 	var (
 		err      error
 		response api.RepositoryValidationResponse
@@ -561,7 +1140,7 @@ func (r repositoryConfigDaoImpl) ValidateParameters(orgId string, params api.Rep
 	if params.Name == nil {
 		response.Name.Skipped = true
 	} else {
-		err = r.validateName(orgId, *params.Name, &response.Name, excludedUUIDS)
+		err = r.validateName(ctx, orgId, *params.Name, &response.Name, excludedUUIDS)
 		if err != nil {
 			return response, err
 		}
@@ -572,22 +1151,90 @@ func (r repositoryConfigDaoImpl) ValidateParameters(orgId string, params api.Rep
 		response.URL.Skipped = true
 	} else {
 		url := models.CleanupURL(*params.URL)
-		err = r.validateUrl(orgId, url, &response, excludedUUIDS)
+		err = r.validateUrl(ctx, orgId, url, &response, excludedUUIDS)
 		if err != nil {
 			return response, err
 		}
+		var client *http.Client
 		if response.URL.Valid {
-			r.yumRepo.Configure(yum.YummySettings{URL: &url, Client: http.DefaultClient})
-			r.validateMetadataPresence(&response)
+			if client, err = validationClient(url, params, &response); err != nil {
+				return response, err
+			}
+		}
+		urls := models.ExpandURL(url, params.DistributionVersions, params.DistributionArch)
+		if response.URL.Valid {
+			switch models.UnexpandedVariable(url, params.DistributionVersions, params.DistributionArch) {
+			case models.BaseArchVariable:
+				response.URL.Valid = false
+				response.URL.Error = fmt.Sprintf("A URL containing %s requires a distribution architecture to expand it with.", models.BaseArchVariable)
+				response.URL.ErrorCode = api.UrlErrorBaseArch
+			case models.ReleaseVerVariable:
+				response.URL.Valid = false
+				response.URL.Error = fmt.Sprintf("A URL containing %s requires distribution versions to expand it with.", models.ReleaseVerVariable)
+				response.URL.ErrorCode = api.UrlErrorReleaseVer
+			}
+		}
+		if response.URL.Valid {
+			// The metadata of each of the urls a url containing variables expands to is checked
+			for i := range urls {
+				r.yumRepo.Configure(yum.YummySettings{URL: &urls[i], Client: withContext(ctx, client)})
+				r.validateMetadataPresence(&response)
+				if !response.URL.MetadataPresent {
+					break
+				}
+			}
 			if response.URL.MetadataPresent {
 				r.checkSignaturePresent(&params, &response)
+				if params.DiscoverSiblings && !models.HasVariables(url) {
+					r.discoverSiblings(ctx, client, url, &response)
+				}
 			}
 		}
 	}
 	return response, err
 }
 
-func (r repositoryConfigDaoImpl) validateName(orgId string, name string, response *api.GenericAttributeValidationResponse, excludedUUIDS []string) error {
+// validationClient returns a client reaching the url through the proxy of the request,
+// authenticating with the credentials of the request, and connecting to the addresses
+// repositories are allowed to be fetched from only
+func validationClient(repoURL string, params api.RepositoryValidationRequest, response *api.RepositoryValidationResponse) (*http.Client, error) {
+	proxy := RepositoryProxy{}
+	if params.ProxyURL != nil {
+		proxy.URL = *params.ProxyURL
+	}
+	if params.ProxyUsername != nil {
+		proxy.Username = *params.ProxyUsername
+	}
+	if params.ProxyPassword != nil {
+		proxy.Password = *params.ProxyPassword
+	}
+	if _, err := proxy.ProxyFunc(); err != nil {
+		response.URL.Valid = false
+		response.URL.Error = fmt.Sprintf("Invalid proxy URL: %s", err.Error())
+		response.URL.ErrorCode = api.UrlErrorInvalidProxy
+		return nil, nil
+	}
+	var transport *http.Transport
+	var err error
+	if proxy.URL == "" {
+		transport, err = config.RepositoryTransport()
+	} else {
+		transport, err = proxy.Transport()
+	}
+	if err != nil {
+		return nil, err
+	}
+	credentials := RepositoryCredentials{}
+	if params.Username != nil {
+		credentials.Username = *params.Username
+	}
+	if params.Password != nil {
+		credentials.Password = *params.Password
+	}
+	return &http.Client{Transport: credentials.Transport(transport, repoURL)}, nil
+}
+
+func (r repositoryConfigDaoImpl) validateName(ctx context.Context, orgId string, name string, response *api.GenericAttributeValidationResponse, excludedUUIDS []string) error {
 	if name == "" {
 		response.Valid = false
 		response.Error = "Name cannot be blank"
@@ -595,7 +1242,7 @@ func (r repositoryConfigDaoImpl) validateName(orgId string, name string, respons
 	}
 
 	found := models.RepositoryConfiguration{}
-	query := r.db.Where("name = ? AND ORG_ID = ?", name, orgId)
+	query := r.db.WithContext(ctx).Scopes(WithOrg(orgId)).Where("name = ?", name)
 	if len(excludedUUIDS) != 0 {
 		query = query.Where("repository_configurations.uuid NOT IN ?", excludedUUIDS)
 	}
@@ -614,17 +1261,19 @@ func (r repositoryConfigDaoImpl) validateName(orgId string, name string, respons
 	return nil
 }
 
-func (r repositoryConfigDaoImpl) validateUrl(orgId string, url string, response *api.RepositoryValidationResponse, excludedUUIDS []string) error {
+func (r repositoryConfigDaoImpl) validateUrl(ctx context.Context, orgId string, url string, response *api.RepositoryValidationResponse, excludedUUIDS []string) error {
 	if url == "" {
 		response.URL.Valid = false
 		response.URL.Error = "URL cannot be blank"
+		response.URL.ErrorCode = api.UrlErrorBlank
 		return nil
 	}
 
 	found := models.RepositoryConfiguration{}
-	query := r.db.Preload("Repository").
+	query := r.db.WithContext(ctx).Preload("Repository").
 		Joins("inner join repositories on repository_configurations.repository_uuid = repositories.uuid").
-		Where("Repositories.URL = ? AND ORG_ID = ?", url, orgId)
+		Scopes(WithOrg(orgId)).
+		Where("Repositories.URL = ?", url)
 	if len(excludedUUIDS) != 0 {
 		query = query.Where("repository_configurations.uuid NOT IN ?", excludedUUIDS)
 	}
@@ -636,6 +1285,7 @@ func (r repositoryConfigDaoImpl) validateUrl(orgId string, url string, response
 	if found.UUID != "" {
 		response.URL.Valid = false
 		response.URL.Error = fmt.Sprintf("A repository with the URL '%s' already exists.", url)
+		response.URL.ErrorCode = api.UrlErrorDuplicate
 		return nil
 	}
 
@@ -643,6 +1293,21 @@ func (r repositoryConfigDaoImpl) validateUrl(orgId string, url string, response
 	if containsWhitespace {
 		response.URL.Valid = false
 		response.URL.Error = "URL cannot contain whitespace."
+		response.URL.ErrorCode = api.UrlErrorWhitespace
+		return nil
+	}
+
+	if message := models.UnsupportedVariablesMessage(url); message != "" {
+		response.URL.Valid = false
+		response.URL.Error = message
+		response.URL.ErrorCode = api.UrlErrorUnsupportedVar
+		return nil
+	}
+
+	if message := models.DeniedURLMessage(url); message != "" {
+		response.URL.Valid = false
+		response.URL.Error = message
+		response.URL.ErrorCode = api.UrlErrorDenied
 		return nil
 	}
 
@@ -659,10 +1324,49 @@ func (r repositoryConfigDaoImpl) validateMetadataPresence(response *api.Reposito
 		} else {
 			response.URL.Error = fmt.Sprintf("Error fetching YUM metadata: %s", err.Error())
 		}
+		response.URL.ErrorCode = metadataErrorCode(code, err)
 		response.URL.MetadataPresent = false
 	} else {
 		response.URL.HTTPCode = code
 		response.URL.MetadataPresent = code >= 200 && code < 300
+		if !response.URL.MetadataPresent {
+			response.URL.Error = fmt.Sprintf("Error fetching YUM metadata: %s", http.StatusText(code))
+			response.URL.ErrorCode = metadataErrorCode(code, nil)
+		}
+	}
+}
+
+// metadataErrorCode returns the code of the error met when fetching repomd.xml,
+// so that users can tell a typo in the host from a missing repository or an untrusted certificate
+func metadataErrorCode(code int, err error) string {
+	var (
+		dnsErr          *net.DNSError
+		opErr           *net.OpError
+		netErr          net.Error
+		unknownAuthErr  x509.UnknownAuthorityError
+		certInvalidErr  x509.CertificateInvalidError
+		hostnameErr     x509.HostnameError
+		recordHeaderErr tls.RecordHeaderError
+	)
+	switch {
+	case err != nil && errors.As(err, &dnsErr):
+		return api.UrlErrorDNSFailure
+	case err != nil && (errors.As(err, &unknownAuthErr) || errors.As(err, &certInvalidErr) ||
+		errors.As(err, &hostnameErr) || errors.As(err, &recordHeaderErr)):
+		return api.UrlErrorTLSFailure
+	case err != nil && (isTimeout(err) || errors.Is(err, context.DeadlineExceeded) ||
+		(errors.As(err, &netErr) && netErr.Timeout())):
+		return api.UrlErrorTimeout
+	case err != nil && errors.As(err, &opErr):
+		return api.UrlErrorConnectionFailure
+	case code == http.StatusNotFound:
+		return api.UrlErrorMetadataMissing
+	case code != 0 && (code < 200 || code > 299):
+		return api.UrlErrorHTTPStatus
+	case code != 0:
+		return api.UrlErrorMetadataMalformed
+	default:
+		return api.UrlErrorMetadataFetchError
 	}
 }
 
@@ -3,8 +3,12 @@
 package dao
 
 import (
+	context "context"
+
 	api "github.com/content-services/content-sources-backend/pkg/api"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // MockRepositoryConfigDao is an autogenerated mock type for the RepositoryConfigDao type
@@ -12,25 +16,25 @@ type MockRepositoryConfigDao struct {
 	mock.Mock
 }
 
-// BulkCreate provides a mock function with given fields: newRepositories
-func (_m *MockRepositoryConfigDao) BulkCreate(newRepositories []api.RepositoryRequest) ([]api.RepositoryResponse, []error) {
-	ret := _m.Called(newRepositories)
+// BulkCreate provides a mock function with given fields: ctx, newRepositories
+func (_m *MockRepositoryConfigDao) BulkCreate(ctx context.Context, newRepositories []api.RepositoryRequest) ([]api.RepositoryResponse, []error) {
+	ret := _m.Called(ctx, newRepositories)
 
 	var r0 []api.RepositoryResponse
 	var r1 []error
-	if rf, ok := ret.Get(0).(func([]api.RepositoryRequest) ([]api.RepositoryResponse, []error)); ok {
-		return rf(newRepositories)
+	if rf, ok := ret.Get(0).(func(context.Context, []api.RepositoryRequest) ([]api.RepositoryResponse, []error)); ok {
+		return rf(ctx, newRepositories)
 	}
-	if rf, ok := ret.Get(0).(func([]api.RepositoryRequest) []api.RepositoryResponse); ok {
-		r0 = rf(newRepositories)
+	if rf, ok := ret.Get(0).(func(context.Context, []api.RepositoryRequest) []api.RepositoryResponse); ok {
+		r0 = rf(ctx, newRepositories)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]api.RepositoryResponse)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func([]api.RepositoryRequest) []error); ok {
-		r1 = rf(newRepositories)
+	if rf, ok := ret.Get(1).(func(context.Context, []api.RepositoryRequest) []error); ok {
+		r1 = rf(ctx, newRepositories)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).([]error)
@@ -40,13 +44,13 @@ func (_m *MockRepositoryConfigDao) BulkCreate(newRepositories []api.RepositoryRe
 	return r0, r1
 }
 
-// BulkDelete provides a mock function with given fields: orgID, uuids
-func (_m *MockRepositoryConfigDao) BulkDelete(orgID string, uuids []string) []error {
-	ret := _m.Called(orgID, uuids)
+// BulkDelete provides a mock function with given fields: ctx, orgID, uuids
+func (_m *MockRepositoryConfigDao) BulkDelete(ctx context.Context, orgID string, uuids []string) []error {
+	ret := _m.Called(ctx, orgID, uuids)
 
 	var r0 []error
-	if rf, ok := ret.Get(0).(func(string, []string) []error); ok {
-		r0 = rf(orgID, uuids)
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) []error); ok {
+		r0 = rf(ctx, orgID, uuids)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]error)
@@ -56,23 +60,105 @@ func (_m *MockRepositoryConfigDao) BulkDelete(orgID string, uuids []string) []er
 	return r0
 }
 
-// Create provides a mock function with given fields: newRepo
-func (_m *MockRepositoryConfigDao) Create(newRepo api.RepositoryRequest) (api.RepositoryResponse, error) {
-	ret := _m.Called(newRepo)
+// BulkExport provides a mock function with given fields: ctx, orgID, reposToExport
+func (_m *MockRepositoryConfigDao) BulkExport(ctx context.Context, orgID string, reposToExport api.RepositoryExportRequest) ([]api.RepositoryExportResponse, error) {
+	ret := _m.Called(ctx, orgID, reposToExport)
+
+	var r0 []api.RepositoryExportResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.RepositoryExportRequest) ([]api.RepositoryExportResponse, error)); ok {
+		return rf(ctx, orgID, reposToExport)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.RepositoryExportRequest) []api.RepositoryExportResponse); ok {
+		r0 = rf(ctx, orgID, reposToExport)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]api.RepositoryExportResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, api.RepositoryExportRequest) error); ok {
+		r1 = rf(ctx, orgID, reposToExport)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BulkImport provides a mock function with given fields: ctx, reposToImport
+func (_m *MockRepositoryConfigDao) BulkImport(ctx context.Context, reposToImport []api.RepositoryRequest) ([]api.RepositoryImportResponse, []error) {
+	ret := _m.Called(ctx, reposToImport)
+
+	var r0 []api.RepositoryImportResponse
+	var r1 []error
+	if rf, ok := ret.Get(0).(func(context.Context, []api.RepositoryRequest) ([]api.RepositoryImportResponse, []error)); ok {
+		return rf(ctx, reposToImport)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []api.RepositoryRequest) []api.RepositoryImportResponse); ok {
+		r0 = rf(ctx, reposToImport)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]api.RepositoryImportResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []api.RepositoryRequest) []error); ok {
+		r1 = rf(ctx, reposToImport)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]error)
+		}
+	}
+
+	return r0, r1
+}
+
+// BulkUpdate provides a mock function with given fields: ctx, orgID, uuids, repoParams
+func (_m *MockRepositoryConfigDao) BulkUpdate(ctx context.Context, orgID string, uuids []string, repoParams api.RepositoryRequest) ([]api.RepositoryResponse, []error) {
+	ret := _m.Called(ctx, orgID, uuids, repoParams)
+
+	var r0 []api.RepositoryResponse
+	var r1 []error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, api.RepositoryRequest) ([]api.RepositoryResponse, []error)); ok {
+		return rf(ctx, orgID, uuids, repoParams)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, api.RepositoryRequest) []api.RepositoryResponse); ok {
+		r0 = rf(ctx, orgID, uuids, repoParams)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]api.RepositoryResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string, api.RepositoryRequest) []error); ok {
+		r1 = rf(ctx, orgID, uuids, repoParams)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]error)
+		}
+	}
+
+	return r0, r1
+}
+
+// Create provides a mock function with given fields: ctx, newRepo
+func (_m *MockRepositoryConfigDao) Create(ctx context.Context, newRepo api.RepositoryRequest) (api.RepositoryResponse, error) {
+	ret := _m.Called(ctx, newRepo)
 
 	var r0 api.RepositoryResponse
 	var r1 error
-	if rf, ok := ret.Get(0).(func(api.RepositoryRequest) (api.RepositoryResponse, error)); ok {
-		return rf(newRepo)
+	if rf, ok := ret.Get(0).(func(context.Context, api.RepositoryRequest) (api.RepositoryResponse, error)); ok {
+		return rf(ctx, newRepo)
 	}
-	if rf, ok := ret.Get(0).(func(api.RepositoryRequest) api.RepositoryResponse); ok {
-		r0 = rf(newRepo)
+	if rf, ok := ret.Get(0).(func(context.Context, api.RepositoryRequest) api.RepositoryResponse); ok {
+		r0 = rf(ctx, newRepo)
 	} else {
 		r0 = ret.Get(0).(api.RepositoryResponse)
 	}
 
-	if rf, ok := ret.Get(1).(func(api.RepositoryRequest) error); ok {
-		r1 = rf(newRepo)
+	if rf, ok := ret.Get(1).(func(context.Context, api.RepositoryRequest) error); ok {
+		r1 = rf(ctx, newRepo)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -80,13 +166,13 @@ func (_m *MockRepositoryConfigDao) Create(newRepo api.RepositoryRequest) (api.Re
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: orgID, uuid
-func (_m *MockRepositoryConfigDao) Delete(orgID string, uuid string) error {
-	ret := _m.Called(orgID, uuid)
+// Delete provides a mock function with given fields: ctx, orgID, uuid
+func (_m *MockRepositoryConfigDao) Delete(ctx context.Context, orgID string, uuid string) error {
+	ret := _m.Called(ctx, orgID, uuid)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string) error); ok {
-		r0 = rf(orgID, uuid)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, orgID, uuid)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -94,23 +180,23 @@ func (_m *MockRepositoryConfigDao) Delete(orgID string, uuid string) error {
 	return r0
 }
 
-// Fetch provides a mock function with given fields: orgID, uuid
-func (_m *MockRepositoryConfigDao) Fetch(orgID string, uuid string) (api.RepositoryResponse, error) {
-	ret := _m.Called(orgID, uuid)
+// Fetch provides a mock function with given fields: ctx, orgID, uuid
+func (_m *MockRepositoryConfigDao) Fetch(ctx context.Context, orgID string, uuid string) (api.RepositoryResponse, error) {
+	ret := _m.Called(ctx, orgID, uuid)
 
 	var r0 api.RepositoryResponse
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string) (api.RepositoryResponse, error)); ok {
-		return rf(orgID, uuid)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (api.RepositoryResponse, error)); ok {
+		return rf(ctx, orgID, uuid)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) api.RepositoryResponse); ok {
-		r0 = rf(orgID, uuid)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) api.RepositoryResponse); ok {
+		r0 = rf(ctx, orgID, uuid)
 	} else {
 		r0 = ret.Get(0).(api.RepositoryResponse)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(orgID, uuid)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, orgID, uuid)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -118,23 +204,123 @@ func (_m *MockRepositoryConfigDao) Fetch(orgID string, uuid string) (api.Reposit
 	return r0, r1
 }
 
-// FetchByRepoUuid provides a mock function with given fields: orgID, repoUuid
-func (_m *MockRepositoryConfigDao) FetchByRepoUuid(orgID string, repoUuid string) (api.RepositoryResponse, error) {
-	ret := _m.Called(orgID, repoUuid)
+// FetchByRepoUuid provides a mock function with given fields: ctx, orgID, repoUuid
+func (_m *MockRepositoryConfigDao) FetchByRepoUuid(ctx context.Context, orgID string, repoUuid string) (api.RepositoryResponse, error) {
+	ret := _m.Called(ctx, orgID, repoUuid)
 
 	var r0 api.RepositoryResponse
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string) (api.RepositoryResponse, error)); ok {
-		return rf(orgID, repoUuid)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (api.RepositoryResponse, error)); ok {
+		return rf(ctx, orgID, repoUuid)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) api.RepositoryResponse); ok {
-		r0 = rf(orgID, repoUuid)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) api.RepositoryResponse); ok {
+		r0 = rf(ctx, orgID, repoUuid)
 	} else {
 		r0 = ret.Get(0).(api.RepositoryResponse)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(orgID, repoUuid)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, orgID, repoUuid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FetchCredentials provides a mock function with given fields: ctx, orgID, uuid
+func (_m *MockRepositoryConfigDao) FetchCredentials(ctx context.Context, orgID string, uuid string) (RepositoryCredentials, error) {
+	ret := _m.Called(ctx, orgID, uuid)
+
+	var r0 RepositoryCredentials
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (RepositoryCredentials, error)); ok {
+		return rf(ctx, orgID, uuid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) RepositoryCredentials); ok {
+		r0 = rf(ctx, orgID, uuid)
+	} else {
+		r0 = ret.Get(0).(RepositoryCredentials)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, orgID, uuid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FetchProxy provides a mock function with given fields: ctx, orgID, uuid
+func (_m *MockRepositoryConfigDao) FetchProxy(ctx context.Context, orgID string, uuid string) (RepositoryProxy, error) {
+	ret := _m.Called(ctx, orgID, uuid)
+
+	var r0 RepositoryProxy
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (RepositoryProxy, error)); ok {
+		return rf(ctx, orgID, uuid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) RepositoryProxy); ok {
+		r0 = rf(ctx, orgID, uuid)
+	} else {
+		r0 = ret.Get(0).(RepositoryProxy)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, orgID, uuid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InternalOnly_FetchFailingByOrg provides a mock function with given fields: ctx
+func (_m *MockRepositoryConfigDao) InternalOnly_FetchFailingByOrg(ctx context.Context) (map[string][]api.RepositoryResponse, error) {
+	ret := _m.Called(ctx)
+
+	var r0 map[string][]api.RepositoryResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (map[string][]api.RepositoryResponse, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) map[string][]api.RepositoryResponse); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string][]api.RepositoryResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InternalOnly_FetchPendingDelete provides a mock function with given fields: ctx
+func (_m *MockRepositoryConfigDao) InternalOnly_FetchPendingDelete(ctx context.Context) ([]api.RepositoryResponse, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []api.RepositoryResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]api.RepositoryResponse, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []api.RepositoryResponse); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]api.RepositoryResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -142,13 +328,13 @@ func (_m *MockRepositoryConfigDao) FetchByRepoUuid(orgID string, repoUuid string
 	return r0, r1
 }
 
-// InternalOnly_FetchRepoConfigsForRepoUUID provides a mock function with given fields: uuid
-func (_m *MockRepositoryConfigDao) InternalOnly_FetchRepoConfigsForRepoUUID(uuid string) []api.RepositoryResponse {
-	ret := _m.Called(uuid)
+// InternalOnly_FetchRepoConfigsForRepoUUID provides a mock function with given fields: ctx, uuid
+func (_m *MockRepositoryConfigDao) InternalOnly_FetchRepoConfigsForRepoUUID(ctx context.Context, uuid string) []api.RepositoryResponse {
+	ret := _m.Called(ctx, uuid)
 
 	var r0 []api.RepositoryResponse
-	if rf, ok := ret.Get(0).(func(string) []api.RepositoryResponse); ok {
-		r0 = rf(uuid)
+	if rf, ok := ret.Get(0).(func(context.Context, string) []api.RepositoryResponse); ok {
+		r0 = rf(ctx, uuid)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]api.RepositoryResponse)
@@ -158,30 +344,78 @@ func (_m *MockRepositoryConfigDao) InternalOnly_FetchRepoConfigsForRepoUUID(uuid
 	return r0
 }
 
-// List provides a mock function with given fields: orgID, paginationData, filterData
-func (_m *MockRepositoryConfigDao) List(orgID string, paginationData api.PaginationData, filterData api.FilterData) (api.RepositoryCollectionResponse, int64, error) {
-	ret := _m.Called(orgID, paginationData, filterData)
+// InternalOnly_MigrateOrg provides a mock function with given fields: ctx, sourceOrgID, targetOrgID, accountID
+func (_m *MockRepositoryConfigDao) InternalOnly_MigrateOrg(ctx context.Context, sourceOrgID string, targetOrgID string, accountID string) (OrgMigration, error) {
+	ret := _m.Called(ctx, sourceOrgID, targetOrgID, accountID)
+
+	var r0 OrgMigration
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (OrgMigration, error)); ok {
+		return rf(ctx, sourceOrgID, targetOrgID, accountID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) OrgMigration); ok {
+		r0 = rf(ctx, sourceOrgID, targetOrgID, accountID)
+	} else {
+		r0 = ret.Get(0).(OrgMigration)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, sourceOrgID, targetOrgID, accountID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InternalOnly_RotateSecrets provides a mock function with given fields: ctx
+func (_m *MockRepositoryConfigDao) InternalOnly_RotateSecrets(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: ctx, orgID, paginationData, filterData
+func (_m *MockRepositoryConfigDao) List(ctx context.Context, orgID string, paginationData api.PaginationData, filterData api.FilterData) (api.RepositoryCollectionResponse, int64, error) {
+	ret := _m.Called(ctx, orgID, paginationData, filterData)
 
 	var r0 api.RepositoryCollectionResponse
 	var r1 int64
 	var r2 error
-	if rf, ok := ret.Get(0).(func(string, api.PaginationData, api.FilterData) (api.RepositoryCollectionResponse, int64, error)); ok {
-		return rf(orgID, paginationData, filterData)
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.PaginationData, api.FilterData) (api.RepositoryCollectionResponse, int64, error)); ok {
+		return rf(ctx, orgID, paginationData, filterData)
 	}
-	if rf, ok := ret.Get(0).(func(string, api.PaginationData, api.FilterData) api.RepositoryCollectionResponse); ok {
-		r0 = rf(orgID, paginationData, filterData)
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.PaginationData, api.FilterData) api.RepositoryCollectionResponse); ok {
+		r0 = rf(ctx, orgID, paginationData, filterData)
 	} else {
 		r0 = ret.Get(0).(api.RepositoryCollectionResponse)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, api.PaginationData, api.FilterData) int64); ok {
-		r1 = rf(orgID, paginationData, filterData)
+	if rf, ok := ret.Get(1).(func(context.Context, string, api.PaginationData, api.FilterData) int64); ok {
+		r1 = rf(ctx, orgID, paginationData, filterData)
 	} else {
 		r1 = ret.Get(1).(int64)
 	}
 
-	if rf, ok := ret.Get(2).(func(string, api.PaginationData, api.FilterData) error); ok {
-		r2 = rf(orgID, paginationData, filterData)
+	if rf, ok := ret.Get(2).(func(context.Context, string, api.PaginationData, api.FilterData) error); ok {
+		r2 = rf(ctx, orgID, paginationData, filterData)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -189,13 +423,96 @@ func (_m *MockRepositoryConfigDao) List(orgID string, paginationData api.Paginat
 	return r0, r1, r2
 }
 
-// SavePublicRepos provides a mock function with given fields: urls
-func (_m *MockRepositoryConfigDao) SavePublicRepos(urls []string) error {
-	ret := _m.Called(urls)
+// ListDuplicates provides a mock function with given fields: ctx, orgID
+func (_m *MockRepositoryConfigDao) ListDuplicates(ctx context.Context, orgID string) ([]api.DuplicateRepositoriesResponse, error) {
+	ret := _m.Called(ctx, orgID)
+
+	var r0 []api.DuplicateRepositoriesResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]api.DuplicateRepositoriesResponse, error)); ok {
+		return rf(ctx, orgID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []api.DuplicateRepositoriesResponse); ok {
+		r0 = rf(ctx, orgID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]api.DuplicateRepositoriesResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, orgID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListEvents provides a mock function with given fields: ctx, orgID, since
+func (_m *MockRepositoryConfigDao) ListEvents(ctx context.Context, orgID string, since time.Time) ([]api.RepositoryEventResponse, error) {
+	ret := _m.Called(ctx, orgID, since)
+
+	var r0 []api.RepositoryEventResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) ([]api.RepositoryEventResponse, error)); ok {
+		return rf(ctx, orgID, since)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) []api.RepositoryEventResponse); ok {
+		r0 = rf(ctx, orgID, since)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]api.RepositoryEventResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time) error); ok {
+		r1 = rf(ctx, orgID, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListIntrospections provides a mock function with given fields: ctx, orgID, uuid, pageData
+func (_m *MockRepositoryConfigDao) ListIntrospections(ctx context.Context, orgID string, uuid string, pageData api.PaginationData) (api.IntrospectionCollectionResponse, int64, error) {
+	ret := _m.Called(ctx, orgID, uuid, pageData)
+
+	var r0 api.IntrospectionCollectionResponse
+	var r1 int64
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, api.PaginationData) (api.IntrospectionCollectionResponse, int64, error)); ok {
+		return rf(ctx, orgID, uuid, pageData)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, api.PaginationData) api.IntrospectionCollectionResponse); ok {
+		r0 = rf(ctx, orgID, uuid, pageData)
+	} else {
+		r0 = ret.Get(0).(api.IntrospectionCollectionResponse)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, api.PaginationData) int64); ok {
+		r1 = rf(ctx, orgID, uuid, pageData)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, api.PaginationData) error); ok {
+		r2 = rf(ctx, orgID, uuid, pageData)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// SavePublicRepos provides a mock function with given fields: ctx, urls
+func (_m *MockRepositoryConfigDao) SavePublicRepos(ctx context.Context, urls []string) error {
+	ret := _m.Called(ctx, urls)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func([]string) error); ok {
-		r0 = rf(urls)
+	if rf, ok := ret.Get(0).(func(context.Context, []string) error); ok {
+		r0 = rf(ctx, urls)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -203,13 +520,13 @@ func (_m *MockRepositoryConfigDao) SavePublicRepos(urls []string) error {
 	return r0
 }
 
-// SoftDelete provides a mock function with given fields: orgID, uuid
-func (_m *MockRepositoryConfigDao) SoftDelete(orgID string, uuid string) error {
-	ret := _m.Called(orgID, uuid)
+// SoftDelete provides a mock function with given fields: ctx, orgID, uuid
+func (_m *MockRepositoryConfigDao) SoftDelete(ctx context.Context, orgID string, uuid string) error {
+	ret := _m.Called(ctx, orgID, uuid)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string) error); ok {
-		r0 = rf(orgID, uuid)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, orgID, uuid)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -217,23 +534,23 @@ func (_m *MockRepositoryConfigDao) SoftDelete(orgID string, uuid string) error {
 	return r0
 }
 
-// Update provides a mock function with given fields: orgID, uuid, repoParams
-func (_m *MockRepositoryConfigDao) Update(orgID string, uuid string, repoParams api.RepositoryRequest) (bool, error) {
-	ret := _m.Called(orgID, uuid, repoParams)
+// Update provides a mock function with given fields: ctx, orgID, uuid, repoParams
+func (_m *MockRepositoryConfigDao) Update(ctx context.Context, orgID string, uuid string, repoParams api.RepositoryRequest) (bool, error) {
+	ret := _m.Called(ctx, orgID, uuid, repoParams)
 
 	var r0 bool
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string, api.RepositoryRequest) (bool, error)); ok {
-		return rf(orgID, uuid, repoParams)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, api.RepositoryRequest) (bool, error)); ok {
+		return rf(ctx, orgID, uuid, repoParams)
 	}
-	if rf, ok := ret.Get(0).(func(string, string, api.RepositoryRequest) bool); ok {
-		r0 = rf(orgID, uuid, repoParams)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, api.RepositoryRequest) bool); ok {
+		r0 = rf(ctx, orgID, uuid, repoParams)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string, api.RepositoryRequest) error); ok {
-		r1 = rf(orgID, uuid, repoParams)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, api.RepositoryRequest) error); ok {
+		r1 = rf(ctx, orgID, uuid, repoParams)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -241,23 +558,37 @@ func (_m *MockRepositoryConfigDao) Update(orgID string, uuid string, repoParams
 	return r0, r1
 }
 
-// ValidateParameters provides a mock function with given fields: orgId, params, excludedUUIDS
-func (_m *MockRepositoryConfigDao) ValidateParameters(orgId string, params api.RepositoryValidationRequest, excludedUUIDS []string) (api.RepositoryValidationResponse, error) {
-	ret := _m.Called(orgId, params, excludedUUIDS)
+// UpdateLastSnapshotTask provides a mock function with given fields: ctx, taskUUID, orgID, repoUUID
+func (_m *MockRepositoryConfigDao) UpdateLastSnapshotTask(ctx context.Context, taskUUID string, orgID string, repoUUID string) error {
+	ret := _m.Called(ctx, taskUUID, orgID, repoUUID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, taskUUID, orgID, repoUUID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ValidateParameters provides a mock function with given fields: ctx, orgId, params, excludedUUIDS
+func (_m *MockRepositoryConfigDao) ValidateParameters(ctx context.Context, orgId string, params api.RepositoryValidationRequest, excludedUUIDS []string) (api.RepositoryValidationResponse, error) {
+	ret := _m.Called(ctx, orgId, params, excludedUUIDS)
 
 	var r0 api.RepositoryValidationResponse
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, api.RepositoryValidationRequest, []string) (api.RepositoryValidationResponse, error)); ok {
-		return rf(orgId, params, excludedUUIDS)
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.RepositoryValidationRequest, []string) (api.RepositoryValidationResponse, error)); ok {
+		return rf(ctx, orgId, params, excludedUUIDS)
 	}
-	if rf, ok := ret.Get(0).(func(string, api.RepositoryValidationRequest, []string) api.RepositoryValidationResponse); ok {
-		r0 = rf(orgId, params, excludedUUIDS)
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.RepositoryValidationRequest, []string) api.RepositoryValidationResponse); ok {
+		r0 = rf(ctx, orgId, params, excludedUUIDS)
 	} else {
 		r0 = ret.Get(0).(api.RepositoryValidationResponse)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, api.RepositoryValidationRequest, []string) error); ok {
-		r1 = rf(orgId, params, excludedUUIDS)
+	if rf, ok := ret.Get(1).(func(context.Context, string, api.RepositoryValidationRequest, []string) error); ok {
+		r1 = rf(ctx, orgId, params, excludedUUIDS)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1,16 +1,25 @@
 package dao
 
 import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/content-services/content-sources-backend/pkg/api"
 	"github.com/content-services/content-sources-backend/pkg/config"
-	"github.com/content-services/content-sources-backend/pkg/db"
+	"github.com/content-services/content-sources-backend/pkg/crypto"
 	ce "github.com/content-services/content-sources-backend/pkg/errors"
 	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/notifications"
 	"github.com/content-services/content-sources-backend/pkg/seeds"
 	"github.com/content-services/content-sources-backend/pkg/test"
 	mockExt "github.com/content-services/content-sources-backend/pkg/test/mocks/mock_external"
@@ -28,18 +37,6 @@ type RepositoryConfigSuite struct {
 	*DaoSuite
 }
 
-func (suite *RepositoryConfigSuite) SetupTest() {
-	if db.DB == nil {
-		if err := db.Connect(); err != nil {
-			suite.FailNow(err.Error())
-		}
-	}
-	suite.db = db.DB
-	suite.skipDefaultTransactionOld = suite.db.SkipDefaultTransaction
-	suite.db.SkipDefaultTransaction = false
-	suite.tx = suite.db.Begin()
-}
-
 func TestRepositoryConfigSuite(t *testing.T) {
 	m := DaoSuite{}
 	r := RepositoryConfigSuite{DaoSuite: &m}
@@ -80,14 +77,263 @@ func (suite *RepositoryConfigSuite) TestCreate() {
 	}
 
 	dao := GetRepositoryConfigDao(tx)
-	created, err := dao.Create(toCreate)
+	created, err := dao.Create(context.Background(), toCreate)
 	assert.Nil(t, err)
 
-	foundRepo, err := dao.Fetch(orgID, created.UUID)
+	foundRepo, err := dao.Fetch(context.Background(), orgID, created.UUID)
 	assert.Nil(t, err)
 	assert.Equal(t, url, foundRepo.URL)
 }
 
+func (suite *RepositoryConfigSuite) TestCreateWithProxy() {
+	t := suite.T()
+	tx := suite.tx
+	orgID := seeds.RandomOrgId()
+	defer setEncryptionKeys(testEncryptionKey("test", "k"))()
+
+	toCreate := api.RepositoryRequest{
+		Name:          pointy.String("proxied"),
+		URL:           pointy.String("http://proxied.example.com/"),
+		OrgID:         &orgID,
+		AccountID:     pointy.String(seeds.RandomAccountId()),
+		ProxyURL:      pointy.String("http://proxy.example.com:3128"),
+		ProxyUsername: pointy.String("user"),
+		ProxyPassword: pointy.String("secret"),
+	}
+	created, err := GetRepositoryConfigDao(tx).Create(context.Background(), toCreate)
+	require.NoError(t, err)
+
+	// The password is never returned to users
+	found, err := GetRepositoryConfigDao(tx).Fetch(context.Background(), orgID, created.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, "http://proxy.example.com:3128", found.ProxyURL)
+	assert.Equal(t, "user", found.ProxyUsername)
+
+	expected := RepositoryProxy{URL: "http://proxy.example.com:3128", Username: "user", Password: "secret"}
+	proxy, err := GetRepositoryConfigDao(tx).FetchProxy(context.Background(), orgID, created.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, expected, proxy)
+
+	// Introspection of the shared repository goes through the proxy as well
+	proxy, err = GetRepositoryDao(tx).FetchProxy(context.Background(), found.RepositoryUUID)
+	require.NoError(t, err)
+	assert.Equal(t, expected, proxy)
+
+	// The password is kept when updating without it
+	_, err = GetRepositoryConfigDao(tx).Update(context.Background(), orgID, created.UUID, api.RepositoryRequest{Name: pointy.String("renamed")})
+	require.NoError(t, err)
+	proxy, err = GetRepositoryConfigDao(tx).FetchProxy(context.Background(), orgID, created.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", proxy.Password)
+
+	_, err = GetRepositoryConfigDao(tx).FetchProxy(context.Background(), seeds.RandomOrgId(), created.UUID)
+	assert.Error(t, err)
+}
+
+// setEncryptionKeys configures the encryption keys, returning a function restoring the previous ones
+func setEncryptionKeys(keys ...string) func() {
+	previousKeys := config.Get().Encryption.Keys
+	config.Get().Encryption.Keys = keys
+	return func() { config.Get().Encryption.Keys = previousKeys }
+}
+
+func testEncryptionKey(id string, fill string) string {
+	return id + ":" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat(fill, crypto.KeySize)))
+}
+
+func (suite *RepositoryConfigSuite) TestCreateWithCredentials() {
+	t := suite.T()
+	tx := suite.tx
+	orgID := seeds.RandomOrgId()
+	defer setEncryptionKeys(testEncryptionKey("test", "k"))()
+
+	toCreate := api.RepositoryRequest{
+		Name:      pointy.String("authenticated"),
+		URL:       pointy.String("http://authenticated.example.com/"),
+		OrgID:     &orgID,
+		AccountID: pointy.String(seeds.RandomAccountId()),
+		Username:  pointy.String("user"),
+		Password:  pointy.String("secret"),
+	}
+	created, err := GetRepositoryConfigDao(tx).Create(context.Background(), toCreate)
+	require.NoError(t, err)
+	assert.Equal(t, "user", created.Username)
+
+	// The password is stored encrypted
+	stored := models.RepositoryConfiguration{}
+	require.NoError(t, tx.First(&stored, "uuid = ?", created.UUID).Error)
+	assert.NotEmpty(t, stored.Password)
+	assert.NotContains(t, stored.Password, "secret")
+
+	expected := RepositoryCredentials{Username: "user", Password: "secret"}
+	credentials, err := GetRepositoryConfigDao(tx).FetchCredentials(context.Background(), orgID, created.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, expected, credentials)
+
+	credentials, err = GetRepositoryDao(tx).FetchCredentials(context.Background(), created.RepositoryUUID)
+	require.NoError(t, err)
+	assert.Equal(t, expected, credentials)
+
+	// Without a key, passwords cannot be stored
+	config.Get().Encryption.Keys = nil
+	toCreate.URL = pointy.String("http://authenticated2.example.com/")
+	toCreate.Name = pointy.String("authenticated2")
+	_, err = GetRepositoryConfigDao(tx).Create(context.Background(), toCreate)
+	assert.ErrorIs(t, err, crypto.ErrNoKey)
+}
+
+func (suite *RepositoryConfigSuite) TestRotateSecrets() {
+	t := suite.T()
+	tx := suite.tx
+	orgID := seeds.RandomOrgId()
+	defer setEncryptionKeys(testEncryptionKey("old", "o"))()
+
+	created, err := GetRepositoryConfigDao(tx).Create(context.Background(), api.RepositoryRequest{
+		Name:          pointy.String("rotated"),
+		URL:           pointy.String("http://rotated.example.com/"),
+		OrgID:         &orgID,
+		AccountID:     pointy.String(seeds.RandomAccountId()),
+		Username:      pointy.String("user"),
+		Password:      pointy.String("secret"),
+		ProxyURL:      pointy.String("http://proxy.example.com:3128"),
+		ProxyUsername: pointy.String("proxy-user"),
+		ProxyPassword: pointy.String("proxy-secret"),
+	})
+	require.NoError(t, err)
+	webhookSecret, err := crypto.Encrypt("webhook-secret")
+	require.NoError(t, err)
+	webhook := models.Webhook{OrgID: orgID, URL: "https://example.com/hook", Secret: webhookSecret, EventTypes: []string{}}
+	require.NoError(t, tx.Create(&webhook).Error)
+
+	config.Get().Encryption.Keys = []string{testEncryptionKey("new", "n"), testEncryptionKey("old", "o")}
+	rotated, err := GetRepositoryConfigDao(tx).InternalOnly_RotateSecrets(context.Background())
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, rotated, int64(3))
+
+	stored := models.RepositoryConfiguration{}
+	require.NoError(t, tx.First(&stored, "uuid = ?", created.UUID).Error)
+	assert.True(t, strings.HasPrefix(stored.Password, "new:"))
+	assert.True(t, strings.HasPrefix(stored.ProxyPassword, "new:"))
+	storedWebhook := models.Webhook{}
+	require.NoError(t, tx.First(&storedWebhook, "uuid = ?", webhook.UUID).Error)
+	assert.True(t, strings.HasPrefix(storedWebhook.Secret, "new:"))
+
+	// The previous key is no longer needed once rotated
+	config.Get().Encryption.Keys = []string{testEncryptionKey("new", "n")}
+	credentials, err := GetRepositoryConfigDao(tx).FetchCredentials(context.Background(), orgID, created.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", credentials.Password)
+	proxy, err := GetRepositoryConfigDao(tx).FetchProxy(context.Background(), orgID, created.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, "proxy-secret", proxy.Password)
+	decrypted, err := crypto.Decrypt(storedWebhook.Secret)
+	require.NoError(t, err)
+	assert.Equal(t, "webhook-secret", decrypted)
+
+	rotated, err = GetRepositoryConfigDao(tx).InternalOnly_RotateSecrets(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), rotated)
+}
+
+func (suite *RepositoryConfigSuite) TestListDuplicates() {
+	t := suite.T()
+	tx := suite.tx
+	orgID := seeds.RandomOrgId()
+
+	dao := GetRepositoryConfigDao(tx)
+	created := map[string]api.RepositoryResponse{}
+	for _, name := range []string{"mirror-a", "mirror-b", "other"} {
+		repo, err := dao.Create(context.Background(), api.RepositoryRequest{
+			Name:      pointy.String(name),
+			URL:       pointy.String("http://" + name + ".duplicates.example.com/"),
+			OrgID:     &orgID,
+			AccountID: pointy.String(seeds.RandomAccountId()),
+		})
+		require.NoError(t, err)
+		created[name] = repo
+	}
+	checksums := map[string]string{"mirror-a": "same", "mirror-b": "same", "other": "different"}
+	for name, checksum := range checksums {
+		err := tx.Model(&models.Repository{}).
+			Where("uuid = ?", created[name].RepositoryUUID).
+			Update("repomd_checksum", checksum).Error
+		require.NoError(t, err)
+	}
+
+	duplicates, err := dao.ListDuplicates(context.Background(), orgID)
+	require.NoError(t, err)
+	require.Len(t, duplicates, 1)
+	assert.Equal(t, "same", duplicates[0].RepomdChecksum)
+	require.Len(t, duplicates[0].Repositories, 2)
+	assert.Equal(t, created["mirror-a"].UUID, duplicates[0].Repositories[0].UUID)
+	assert.Equal(t, created["mirror-b"].UUID, duplicates[0].Repositories[1].UUID)
+
+	// Deleted repositories and repositories of other organizations are not listed
+	require.NoError(t, dao.SoftDelete(context.Background(), orgID, created["mirror-b"].UUID))
+	duplicates, err = dao.ListDuplicates(context.Background(), orgID)
+	require.NoError(t, err)
+	assert.Empty(t, duplicates)
+	duplicates, err = dao.ListDuplicates(context.Background(), seeds.RandomOrgId())
+	require.NoError(t, err)
+	assert.Empty(t, duplicates)
+}
+
+func (suite *RepositoryConfigSuite) TestCreateQueuesNotification() {
+	t := suite.T()
+	tx := suite.tx
+	orgID := seeds.RandomOrgId()
+
+	toCreate := api.RepositoryRequest{
+		Name:      pointy.String("outbox"),
+		URL:       pointy.String("http://outbox.example.com/"),
+		OrgID:     &orgID,
+		AccountID: pointy.String(seeds.RandomAccountId()),
+	}
+
+	dao := GetRepositoryConfigDao(tx)
+	_, err := dao.Create(context.Background(), toCreate)
+	require.NoError(t, err)
+
+	events := []models.OutboxEvent{}
+	err = tx.Where("org_id = ?", orgID).Find(&events).Error
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, notifications.RepositoryCreated.String(), events[0].EventName)
+	assert.Nil(t, events[0].PublishedAt)
+}
+
+func (suite *RepositoryConfigSuite) TestListEvents() {
+	t := suite.T()
+	tx := suite.tx
+	orgID := seeds.RandomOrgId()
+	since := time.Now().Add(-time.Minute)
+
+	dao := GetRepositoryConfigDao(tx)
+	created, err := dao.Create(context.Background(), api.RepositoryRequest{
+		Name:      pointy.String("events"),
+		URL:       pointy.String("http://events.example.com/"),
+		OrgID:     &orgID,
+		AccountID: pointy.String(seeds.RandomAccountId()),
+	})
+	require.NoError(t, err)
+	require.NoError(t, dao.SoftDelete(context.Background(), orgID, created.UUID))
+
+	events, err := dao.ListEvents(context.Background(), orgID, since)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, notifications.RepositoryCreated.String(), events[0].Type)
+	assert.Equal(t, notifications.RepositoryDeleted.String(), events[1].Type)
+	assert.Contains(t, string(events[0].Data), created.UUID)
+
+	// Events of other organizations and older events are not listed
+	events, err = dao.ListEvents(context.Background(), seeds.RandomOrgId(), since)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+	events, err = dao.ListEvents(context.Background(), orgID, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
 func (suite *RepositoryConfigSuite) TestCreateTwiceWithNoSlash() {
 	toCreate := api.RepositoryRequest{
 		Name:             pointy.String(""),
@@ -100,14 +346,41 @@ func (suite *RepositoryConfigSuite) TestCreateTwiceWithNoSlash() {
 		},
 	}
 	dao := GetRepositoryConfigDao(suite.tx)
-	_, err := dao.Create(toCreate)
+	_, err := dao.Create(context.Background(), toCreate)
 	assert.ErrorContains(suite.T(), err, "Name cannot be blank")
 
 	dao = GetRepositoryConfigDao(suite.tx)
-	_, err = dao.Create(toCreate)
+	_, err = dao.Create(context.Background(), toCreate)
 	assert.ErrorContains(suite.T(), err, "Name cannot be blank")
 }
 
+func (suite *RepositoryConfigSuite) TestCreateDeniedURL() {
+	t := suite.T()
+	denyList := config.Get().Options.URLDenyList
+	config.Get().Options.URLDenyList = []string{"169.254.0.0/16", "*.internal"}
+	defer func() { config.Get().Options.URLDenyList = denyList }()
+	orgID := seeds.RandomOrgId()
+	dao := GetRepositoryConfigDao(suite.tx)
+
+	toCreate := api.RepositoryRequest{
+		Name:  pointy.String("metadata"),
+		URL:   pointy.String("http://169.254.169.254/latest/"),
+		OrgID: pointy.String(orgID),
+	}
+	_, err := dao.Create(context.Background(), toCreate)
+	var daoError *ce.DaoError
+	require.ErrorAs(t, err, &daoError)
+	assert.True(t, daoError.BadValidation)
+	assert.Equal(t, "URL is not allowed: host 169.254.169.254 is in the deny list.", daoError.Message)
+
+	toCreate.URL = pointy.String("https://example.com/denied/")
+	created, err := dao.Create(context.Background(), toCreate)
+	require.NoError(t, err)
+	_, err = dao.Update(context.Background(), orgID, created.UUID, api.RepositoryRequest{URL: pointy.String("https://mirror.corp.internal/repo/")})
+	require.ErrorAs(t, err, &daoError)
+	assert.True(t, daoError.BadValidation)
+}
+
 func (suite *RepositoryConfigSuite) TestRepositoryCreateAlreadyExists() {
 	t := suite.T()
 	tx := suite.tx
@@ -131,7 +404,7 @@ func (suite *RepositoryConfigSuite) TestRepositoryCreateAlreadyExists() {
 	require.NoError(t, err)
 
 	// Force failure on creating duplicate
-	_, err = GetRepositoryConfigDao(tx).Create(api.RepositoryRequest{
+	_, err = GetRepositoryConfigDao(tx).Create(context.Background(), api.RepositoryRequest{
 		Name:      &found.Name,
 		URL:       &found.Repository.URL,
 		OrgID:     &found.OrgID,
@@ -188,7 +461,7 @@ func (suite *RepositoryConfigSuite) TestRepositoryCreateBlank() {
 	}
 	tx.SavePoint("testrepositorycreateblanktest")
 	for i := 0; i < len(blankItems); i++ {
-		_, err := GetRepositoryConfigDao(tx).Create(blankItems[i].given)
+		_, err := GetRepositoryConfigDao(tx).Create(context.Background(), blankItems[i].given)
 		assert.NotNil(t, err)
 		if blankItems[i].expected == "" {
 			assert.NoError(t, err)
@@ -226,7 +499,7 @@ func (suite *RepositoryConfigSuite) TestBulkCreateCleanupURL() {
 		},
 	}
 
-	rr, errs := GetRepositoryConfigDao(tx).BulkCreate(request)
+	rr, errs := GetRepositoryConfigDao(tx).BulkCreate(context.Background(), request)
 	require.Empty(t, errs)
 	assert.Equal(t, repository.URL, rr[0].URL)
 }
@@ -250,7 +523,7 @@ func (suite *RepositoryConfigSuite) TestBulkCreate() {
 		}
 	}
 
-	rr, errs := GetRepositoryConfigDao(tx).BulkCreate(requests)
+	rr, errs := GetRepositoryConfigDao(tx).BulkCreate(context.Background(), requests)
 	assert.Empty(t, errs)
 	assert.Equal(t, amountToCreate, len(rr))
 
@@ -287,7 +560,7 @@ func (suite *RepositoryConfigSuite) TestBulkCreateOneFails() {
 		},
 	}
 
-	rr, errs := GetRepositoryConfigDao(tx).BulkCreate(requests)
+	rr, errs := GetRepositoryConfigDao(tx).BulkCreate(context.Background(), requests)
 
 	assert.NotEmpty(t, errs)
 	assert.Empty(t, rr)
@@ -330,14 +603,14 @@ func (suite *RepositoryConfigSuite) updateTest(url string) {
 	t := suite.T()
 	var err error
 
-	createResp, err := GetRepositoryConfigDao(suite.tx).Create(api.RepositoryRequest{
+	createResp, err := GetRepositoryConfigDao(suite.tx).Create(context.Background(), api.RepositoryRequest{
 		Name:  pointy.String("NotUpdated"),
 		URL:   &url,
 		OrgID: pointy.String("MyGreatOrg"),
 	})
 	assert.Nil(t, err)
 
-	_, err = GetRepositoryConfigDao(suite.tx).Update(createResp.OrgID, createResp.UUID,
+	_, err = GetRepositoryConfigDao(suite.tx).Update(context.Background(), createResp.OrgID, createResp.UUID,
 		api.RepositoryRequest{
 			Name: &name,
 			URL:  &url,
@@ -361,7 +634,7 @@ func (suite *RepositoryConfigSuite) TestUpdateDuplicateVersions() {
 	assert.Nil(t, err)
 	found := models.RepositoryConfiguration{}
 	suite.tx.First(&found)
-	_, err = GetRepositoryConfigDao(suite.tx).Update(found.OrgID, found.UUID,
+	_, err = GetRepositoryConfigDao(suite.tx).Update(context.Background(), found.OrgID, found.UUID,
 		api.RepositoryRequest{
 			DistributionVersions: &duplicateVersions,
 		})
@@ -410,7 +683,7 @@ func (suite *RepositoryConfigSuite) TestUpdateEmpty() {
 	assert.NotEmpty(t, found.Arch)
 
 	// Update the RepositoryConfiguration record using dao method
-	_, err = GetRepositoryConfigDao(tx).Update(found.OrgID, found.UUID,
+	_, err = GetRepositoryConfigDao(tx).Update(context.Background(), found.OrgID, found.UUID,
 		api.RepositoryRequest{
 			Name:                 &name,
 			DistributionArch:     &arch,
@@ -440,7 +713,7 @@ func (suite *RepositoryConfigSuite) TestDuplicateUpdate() {
 	var created2 api.RepositoryResponse
 
 	created1, err = GetRepositoryConfigDao(suite.tx).
-		Create(api.RepositoryRequest{
+		Create(context.Background(), api.RepositoryRequest{
 			OrgID:     &repoConfig.OrgID,
 			AccountID: &repoConfig.AccountID,
 			Name:      &repoConfig.Name,
@@ -449,14 +722,14 @@ func (suite *RepositoryConfigSuite) TestDuplicateUpdate() {
 	assert.NoError(t, err)
 
 	created2, err = GetRepositoryConfigDao(suite.tx).
-		Create(api.RepositoryRequest{
+		Create(context.Background(), api.RepositoryRequest{
 			OrgID:     &created1.OrgID,
 			AccountID: &created1.AccountID,
 			Name:      &name,
 			URL:       &url})
 	assert.NoError(t, err)
 
-	_, err = GetRepositoryConfigDao(tx).Update(
+	_, err = GetRepositoryConfigDao(tx).Update(context.Background(),
 		created2.OrgID,
 		created2.UUID,
 		api.RepositoryRequest{
@@ -484,7 +757,7 @@ func (suite *RepositoryConfigSuite) TestUpdateNotFound() {
 		Error
 	require.NoError(t, err)
 
-	_, err = GetRepositoryConfigDao(suite.tx).Update("Wrong OrgID!! zomg hacker", found.UUID,
+	_, err = GetRepositoryConfigDao(suite.tx).Update(context.Background(), "Wrong OrgID!! zomg hacker", found.UUID,
 		api.RepositoryRequest{
 			Name: &name,
 			URL:  &name,
@@ -544,7 +817,7 @@ func (suite *RepositoryConfigSuite) TestUpdateBlank() {
 	}
 	tx.SavePoint("updateblanktest")
 	for i := 0; i < len(blankItems); i++ {
-		_, err := GetRepositoryConfigDao(tx).Update(orgID, found.UUID, blankItems[i].given)
+		_, err := GetRepositoryConfigDao(tx).Update(context.Background(), orgID, found.UUID, blankItems[i].given)
 		assert.Error(t, err)
 		if blankItems[i].expected == "" {
 			assert.NoError(t, err)
@@ -574,13 +847,150 @@ func (suite *RepositoryConfigSuite) TestFetch() {
 		Error
 	assert.NoError(t, err)
 
-	fetched, err := GetRepositoryConfigDao(suite.tx).Fetch(found.OrgID, found.UUID)
+	fetched, err := GetRepositoryConfigDao(suite.tx).Fetch(context.Background(), found.OrgID, found.UUID)
 	assert.Nil(t, err)
 	assert.Equal(t, found.UUID, fetched.UUID)
 	assert.Equal(t, found.Name, fetched.Name)
 	assert.Equal(t, found.Repository.URL, fetched.URL)
 }
 
+func (suite *RepositoryConfigSuite) TestBulkExport() {
+	t := suite.T()
+	tx := suite.tx
+	orgID := seeds.RandomOrgId()
+	var err error
+
+	err = seeds.SeedRepositoryConfigurations(suite.tx, 2, seeds.SeedOptions{OrgID: orgID})
+	assert.Nil(t, err)
+	var found []models.RepositoryConfiguration
+	err = tx.
+		Preload("Repository").
+		Where("org_id = ?", orgID).
+		Order("name").
+		Find(&found).
+		Error
+	assert.NoError(t, err)
+	assert.Len(t, found, 2)
+
+	snap := models.Snapshot{
+		DistributionPath:            "/path/to/snapshot",
+		RepositoryPath:              "domain/path/to/snapshot",
+		RepositoryConfigurationUUID: found[0].UUID,
+		ContentCounts:               models.ContentCounts{},
+	}
+	err = tx.Create(&snap).Error
+	assert.NoError(t, err)
+
+	exported, err := GetRepositoryConfigDao(suite.tx).BulkExport(context.Background(), orgID, api.RepositoryExportRequest{
+		RepositoryUuids: []string{found[1].UUID, found[0].UUID},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, exported, 2)
+	assert.Equal(t, found[1].UUID, exported[0].UUID)
+	assert.Equal(t, found[1].Repository.URL, exported[0].URL)
+	assert.Equal(t, found[1].GpgKey, exported[0].GpgKey)
+	assert.Empty(t, exported[0].LatestSnapshotURL)
+	assert.Equal(t, found[0].Name, exported[1].Name)
+	assert.True(t, strings.HasSuffix(exported[1].LatestSnapshotURL, "/pulp/content/domain/path/to/snapshot"))
+
+	// Repositories of other orgs are not found
+	_, err = GetRepositoryConfigDao(suite.tx).BulkExport(context.Background(), "otherOrg", api.RepositoryExportRequest{
+		RepositoryUuids: []string{found[0].UUID},
+	})
+	assert.Error(t, err)
+	daoError, ok := err.(*ce.DaoError)
+	assert.True(t, ok)
+	assert.True(t, daoError.NotFound)
+}
+
+func (suite *RepositoryConfigSuite) TestBulkImport() {
+	t := suite.T()
+	tx := suite.tx
+	orgID := seeds.RandomOrgId()
+	var err error
+
+	err = seeds.SeedRepositoryConfigurations(suite.tx, 1, seeds.SeedOptions{OrgID: orgID})
+	assert.Nil(t, err)
+	existing := models.RepositoryConfiguration{}
+	err = tx.
+		Preload("Repository").
+		First(&existing, "org_id = ?", orgID).
+		Error
+	assert.NoError(t, err)
+
+	requests := []api.RepositoryRequest{
+		{
+			Name:  pointy.String("imported"),
+			URL:   pointy.String("https://imported.example.com/"),
+			OrgID: pointy.String(orgID),
+		},
+		{
+			Name:  pointy.String("conflicting"),
+			URL:   pointy.String(existing.Repository.URL),
+			OrgID: pointy.String(orgID),
+		},
+	}
+	responses, errs := GetRepositoryConfigDao(suite.tx).BulkImport(context.Background(), requests)
+	assert.Empty(t, errs)
+	assert.Len(t, responses, 2)
+	assert.Equal(t, "imported", responses[0].Name)
+	assert.Empty(t, responses[0].Warnings)
+	assert.Equal(t, existing.UUID, responses[1].UUID)
+	assert.Equal(t, existing.Name, responses[1].Name)
+	assert.NotEmpty(t, responses[1].Warnings)
+
+	var count int64
+	err = tx.Model(&models.RepositoryConfiguration{}).Where("org_id = ?", orgID).Count(&count).Error
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func (suite *RepositoryConfigSuite) TestFetchPendingDelete() {
+	t := suite.T()
+	tx := suite.tx
+	orgID := seeds.RandomOrgId()
+	var err error
+
+	err = seeds.SeedRepositoryConfigurations(suite.tx, 1, seeds.SeedOptions{OrgID: orgID})
+	assert.Nil(t, err)
+	found := models.RepositoryConfiguration{}
+	err = tx.First(&found, "org_id = ?", orgID).Error
+	assert.NoError(t, err)
+
+	rcDao := GetRepositoryConfigDao(suite.tx)
+	isPending := func() bool {
+		pending, err := rcDao.InternalOnly_FetchPendingDelete(context.Background())
+		assert.NoError(t, err)
+		for _, repoConfig := range pending {
+			if repoConfig.UUID == found.UUID {
+				return true
+			}
+		}
+		return false
+	}
+	assert.False(t, isPending())
+
+	err = tx.Delete(&found).Error
+	assert.NoError(t, err)
+	assert.True(t, isPending())
+
+	// Not pending anymore once the cleanup is queued
+	payload, err := json.Marshal(map[string]string{"RepoConfigUUID": found.UUID})
+	assert.NoError(t, err)
+	task := models.TaskInfo{
+		Id:             uuid.New(),
+		Token:          uuid.New(),
+		Typename:       config.DeleteRepositorySnapshotsTask,
+		Payload:        payload,
+		Status:         config.TaskStatusPending,
+		OrgId:          orgID,
+		RepositoryUUID: uuid.MustParse(found.RepositoryUUID),
+	}
+	err = tx.Create(&task).Error
+	assert.NoError(t, err)
+	assert.False(t, isPending())
+}
+
 func (suite *RepositoryConfigSuite) TestFetchByRepo() {
 	t := suite.T()
 	tx := suite.tx
@@ -596,7 +1006,7 @@ func (suite *RepositoryConfigSuite) TestFetchByRepo() {
 		Error
 	assert.NoError(t, err)
 
-	fetched, err := GetRepositoryConfigDao(suite.tx).FetchByRepoUuid(found.OrgID, found.RepositoryUUID)
+	fetched, err := GetRepositoryConfigDao(suite.tx).FetchByRepoUuid(context.Background(), found.OrgID, found.RepositoryUUID)
 	assert.Nil(t, err)
 	assert.Equal(t, found.UUID, fetched.UUID)
 	assert.Equal(t, found.Name, fetched.Name)
@@ -616,13 +1026,13 @@ func (suite *RepositoryConfigSuite) TestFetchNotFound() {
 		Error
 	assert.NoError(t, err)
 
-	_, err = GetRepositoryConfigDao(suite.tx).Fetch("bad org id", found.UUID)
+	_, err = GetRepositoryConfigDao(suite.tx).Fetch(context.Background(), "bad org id", found.UUID)
 	assert.NotNil(t, err)
 	daoError, ok := err.(*ce.DaoError)
 	assert.True(t, ok)
 	assert.True(t, daoError.NotFound)
 
-	_, err = GetRepositoryConfigDao(suite.tx).Fetch(orgID, "bad uuid")
+	_, err = GetRepositoryConfigDao(suite.tx).Fetch(context.Background(), orgID, "bad uuid")
 	assert.NotNil(t, err)
 	daoError, ok = err.(*ce.DaoError)
 	assert.True(t, ok)
@@ -649,7 +1059,7 @@ func (suite *RepositoryConfigSuite) TestInternalOnly_FetchRepoConfigsForRepoUUID
 		assert.Nil(t, err)
 	}
 
-	results := GetRepositoryConfigDao(suite.tx).InternalOnly_FetchRepoConfigsForRepoUUID(repoConfig.RepositoryUUID)
+	results := GetRepositoryConfigDao(suite.tx).InternalOnly_FetchRepoConfigsForRepoUUID(context.Background(), repoConfig.RepositoryUUID)
 
 	// Confirm all 10 repoConfigs are returned
 	assert.Equal(t, numberOfRepos, len(results))
@@ -685,7 +1095,7 @@ func (suite *RepositoryConfigSuite) TestList() {
 	assert.Nil(t, result.Error)
 	assert.Equal(t, int64(1), total)
 
-	response, total, err := GetRepositoryConfigDao(suite.tx).List(orgID, pageData, filterData)
+	response, total, err := GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, pageData, filterData)
 	assert.Nil(t, err)
 	assert.Equal(t, int64(1), total)
 	assert.Equal(t, 1, len(response.Data))
@@ -695,6 +1105,52 @@ func (suite *RepositoryConfigSuite) TestList() {
 	}
 }
 
+func (suite *RepositoryConfigSuite) TestListSkipCount() {
+	t := suite.T()
+	orgID := seeds.RandomOrgId()
+	err := seeds.SeedRepositoryConfigurations(suite.tx, 3, seeds.SeedOptions{OrgID: orgID})
+	assert.Nil(t, err)
+
+	pageData := api.PaginationData{Limit: 2, SkipCount: true}
+	response, total, err := GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, pageData, api.FilterData{})
+	assert.Nil(t, err)
+	assert.Equal(t, api.UncountedTotal, total)
+	assert.Len(t, response.Data, 2)
+}
+
+func (suite *RepositoryConfigSuite) TestListFields() {
+	t := suite.T()
+	orgID := seeds.RandomOrgId()
+	err := seeds.SeedRepositoryConfigurations(suite.tx, 2, seeds.SeedOptions{OrgID: orgID})
+	assert.Nil(t, err)
+
+	all, _, err := GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, api.PaginationData{Limit: 100, SortBy: "name"}, api.FilterData{})
+	assert.Nil(t, err)
+	require.Len(t, all.Data, 2)
+
+	pageData := api.PaginationData{Limit: 100, SortBy: "name", Fields: []string{"name", "url"}}
+	response, total, err := GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, pageData, api.FilterData{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, response.Data, 2)
+	for i, repo := range response.Data {
+		assert.Equal(t, all.Data[i].UUID, repo.UUID)
+		assert.Equal(t, all.Data[i].Name, repo.Name)
+		assert.Equal(t, all.Data[i].URL, repo.URL)
+		// Not requested, so not read
+		assert.Empty(t, repo.OrgID)
+		assert.Empty(t, repo.Status)
+	}
+
+	// Without fields of the repository, it is not read
+	pageData.Fields = []string{"name"}
+	response, _, err = GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, pageData, api.FilterData{})
+	assert.Nil(t, err)
+	require.Len(t, response.Data, 2)
+	assert.Equal(t, all.Data[0].Name, response.Data[0].Name)
+	assert.Empty(t, response.Data[0].URL)
+}
+
 func (suite *RepositoryConfigSuite) TestListNoRepositories() {
 	t := suite.T()
 	repoConfigs := make([]models.RepositoryConfiguration, 0)
@@ -714,7 +1170,7 @@ func (suite *RepositoryConfigSuite) TestListNoRepositories() {
 	assert.Nil(t, result.Error)
 	assert.Equal(t, int64(0), total)
 
-	response, total, err := GetRepositoryConfigDao(suite.tx).List(orgID, pageData, filterData)
+	response, total, err := GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, pageData, filterData)
 	assert.Nil(t, err)
 	assert.Empty(t, response.Data)
 	assert.Equal(t, int64(0), total)
@@ -745,7 +1201,7 @@ func (suite *RepositoryConfigSuite) TestListPageLimit() {
 	assert.Nil(t, result.Error)
 	assert.Equal(t, int64(20), total)
 
-	response, total, err := GetRepositoryConfigDao(suite.tx).List(orgID, pageData, filterData)
+	response, total, err := GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, pageData, filterData)
 
 	assert.Nil(t, err)
 	assert.Equal(t, len(response.Data), pageData.Limit)
@@ -763,11 +1219,11 @@ func (suite *RepositoryConfigSuite) TestListFilterName() {
 	filterData := api.FilterData{}
 
 	assert.Nil(t, seeds.SeedRepositoryConfigurations(suite.tx, 2, seeds.SeedOptions{OrgID: orgID, Versions: &[]string{config.El9}}))
-	allRepoResp, _, err := GetRepositoryConfigDao(suite.tx).List(orgID, api.PaginationData{}, api.FilterData{})
+	allRepoResp, _, err := GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, api.PaginationData{}, api.FilterData{})
 	assert.NoError(t, err)
 	filterData.Name = allRepoResp.Data[0].Name
 
-	response, total, err := GetRepositoryConfigDao(suite.tx).List(orgID, api.PaginationData{}, filterData)
+	response, total, err := GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, api.PaginationData{}, filterData)
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(response.Data))
 	assert.Equal(t, 1, int(total))
@@ -781,11 +1237,11 @@ func (suite *RepositoryConfigSuite) TestListFilterUrl() {
 	filterData := api.FilterData{}
 
 	assert.Nil(t, seeds.SeedRepositoryConfigurations(suite.tx, 2, seeds.SeedOptions{OrgID: orgID, Versions: &[]string{config.El9}}))
-	allRepoResp, _, err := GetRepositoryConfigDao(suite.tx).List(orgID, api.PaginationData{}, api.FilterData{})
+	allRepoResp, _, err := GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, api.PaginationData{}, api.FilterData{})
 	assert.NoError(t, err)
 	filterData.URL = allRepoResp.Data[0].URL
 
-	response, total, err := GetRepositoryConfigDao(suite.tx).List(orgID, api.PaginationData{}, filterData)
+	response, total, err := GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, api.PaginationData{}, filterData)
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(response.Data))
 	assert.Equal(t, 1, int(total))
@@ -794,12 +1250,104 @@ func (suite *RepositoryConfigSuite) TestListFilterUrl() {
 
 	// Test that it works with urls missing a trailing slash
 	filterData.URL = filterData.URL[:len(filterData.URL)-1]
-	response, total, err = GetRepositoryConfigDao(suite.tx).List(orgID, api.PaginationData{}, filterData)
+	response, total, err = GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, api.PaginationData{}, filterData)
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(response.Data))
 	assert.Equal(t, 1, int(total))
 }
 
+func (suite *RepositoryConfigSuite) TestCreateContentType() {
+	t := suite.T()
+	orgID := seeds.RandomOrgId()
+	dao := GetRepositoryConfigDao(suite.tx)
+
+	created, err := dao.Create(context.Background(), api.RepositoryRequest{
+		Name:      pointy.String("binary"),
+		URL:       pointy.String("http://binary.example.com/"),
+		OrgID:     &orgID,
+		AccountID: &orgID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, config.ContentTypeBinary, created.ContentType)
+
+	created, err = dao.Create(context.Background(), api.RepositoryRequest{
+		Name:        pointy.String("source"),
+		URL:         pointy.String("http://source.example.com/"),
+		OrgID:       &orgID,
+		AccountID:   &orgID,
+		ContentType: pointy.String(config.ContentTypeSource),
+	})
+	require.NoError(t, err)
+	found, err := dao.Fetch(context.Background(), orgID, created.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, config.ContentTypeSource, found.ContentType)
+
+	_, err = dao.Create(context.Background(), api.RepositoryRequest{
+		Name:        pointy.String("invalid"),
+		URL:         pointy.String("http://invalid.example.com/"),
+		OrgID:       &orgID,
+		AccountID:   &orgID,
+		ContentType: pointy.String("srpm"),
+	})
+	assert.ErrorContains(t, err, "Specified content type srpm is invalid.")
+}
+
+func (suite *RepositoryConfigSuite) TestListFilterContentType() {
+	t := suite.T()
+	orgID := seeds.RandomOrgId()
+
+	assert.Nil(t, seeds.SeedRepositoryConfigurations(suite.tx, 3, seeds.SeedOptions{OrgID: orgID}))
+	allRepoResp, _, err := GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, api.PaginationData{}, api.FilterData{})
+	require.NoError(t, err)
+	require.Len(t, allRepoResp.Data, 3)
+	err = suite.tx.Model(&models.RepositoryConfiguration{}).
+		Where("uuid = ?", allRepoResp.Data[0].UUID).
+		Update("content_type", config.ContentTypeDebug).Error
+	require.NoError(t, err)
+
+	response, total, err := GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, api.PaginationData{}, api.FilterData{ContentType: config.ContentTypeDebug})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, allRepoResp.Data[0].UUID, response.Data[0].UUID)
+
+	_, total, err = GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, api.PaginationData{}, api.FilterData{ContentType: config.ContentTypeBinary + "," + config.ContentTypeSource})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+}
+
+func (suite *RepositoryConfigSuite) TestListFilterSnapshot() {
+	t := suite.T()
+	orgID := seeds.RandomOrgId()
+
+	assert.Nil(t, seeds.SeedRepositoryConfigurations(suite.tx, 3, seeds.SeedOptions{OrgID: orgID}))
+	allRepoResp, _, err := GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, api.PaginationData{}, api.FilterData{})
+	require.NoError(t, err)
+	require.Len(t, allRepoResp.Data, 3)
+	err = suite.tx.Model(&models.RepositoryConfiguration{}).
+		Where("org_id = ?", orgID).
+		Update("snapshot", false).Error
+	require.NoError(t, err)
+	err = suite.tx.Model(&models.RepositoryConfiguration{}).
+		Where("uuid = ?", allRepoResp.Data[0].UUID).
+		Update("snapshot", true).Error
+	require.NoError(t, err)
+
+	response, total, err := GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, api.PaginationData{}, api.FilterData{Snapshot: "true"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, allRepoResp.Data[0].UUID, response.Data[0].UUID)
+	assert.True(t, response.Data[0].Snapshot)
+
+	_, total, err = GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, api.PaginationData{}, api.FilterData{Snapshot: "false"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+
+	_, _, err = GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, api.PaginationData{}, api.FilterData{Snapshot: "sometimes"})
+	assert.Error(t, err)
+}
+
 func (suite *RepositoryConfigSuite) TestListFilterVersion() {
 	t := suite.T()
 
@@ -819,7 +1367,7 @@ func (suite *RepositoryConfigSuite) TestListFilterVersion() {
 	quantity := 20
 
 	assert.Nil(t, seeds.SeedRepositoryConfigurations(suite.tx, quantity, seeds.SeedOptions{OrgID: orgID, Versions: &[]string{config.El9}}))
-	response, total, err := GetRepositoryConfigDao(suite.tx).List(orgID, pageData, filterData)
+	response, total, err := GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, pageData, filterData)
 
 	assert.Nil(t, err)
 	assert.Equal(t, quantity, len(response.Data))
@@ -862,7 +1410,7 @@ func (suite *RepositoryConfigSuite) TestListFilterArch() {
 	assert.Nil(t, result.Error)
 	assert.Equal(t, int64(quantity), total)
 
-	response, total, err := GetRepositoryConfigDao(tx).List(orgID, pageData, filterData)
+	response, total, err := GetRepositoryConfigDao(tx).List(context.Background(), orgID, pageData, filterData)
 
 	assert.Nil(t, err)
 	assert.Equal(t, quantity, len(response.Data))
@@ -899,7 +1447,7 @@ func (suite *RepositoryConfigSuite) TestListFilterStatus() {
 	assert.Nil(t, seeds.SeedRepositoryConfigurations(suite.tx, quantity/3,
 		seeds.SeedOptions{OrgID: orgID, Status: pointy.String(config.StatusPending)}))
 
-	response, count, err := GetRepositoryConfigDao(suite.tx).List(orgID, pageData, filterData)
+	response, count, err := GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, pageData, filterData)
 
 	assert.Nil(t, err)
 	assert.Equal(t, 20, len(response.Data))
@@ -911,6 +1459,42 @@ func (suite *RepositoryConfigSuite) TestListFilterStatus() {
 	assert.True(t, firstItem < lastItem)
 }
 
+func (suite *RepositoryConfigSuite) TestListFilterRollupStatus() {
+	t := suite.T()
+	orgID := seeds.RandomOrgId()
+	filterData := api.FilterData{Status: config.StatusInvalid}
+
+	require.NoError(t, seeds.SeedRepositoryConfigurations(suite.tx, 2,
+		seeds.SeedOptions{OrgID: orgID, Status: pointy.String(config.StatusValid)}))
+	repoConfigs := []models.RepositoryConfiguration{}
+	require.NoError(t, suite.tx.Where("org_id = ?", orgID).Order("name").Find(&repoConfigs).Error)
+
+	// The first snapshot of a valid repository failed
+	task := models.TaskInfo{
+		Id:             uuid.New(),
+		Token:          uuid.New(),
+		Typename:       config.RepositorySnapshotTask,
+		Status:         config.TaskStatusFailed,
+		OrgId:          orgID,
+		RepositoryUUID: uuid.MustParse(repoConfigs[0].RepositoryUUID),
+	}
+	require.NoError(t, suite.tx.Create(&task).Error)
+	require.NoError(t, suite.tx.Model(&repoConfigs[0]).
+		Updates(map[string]interface{}{"snapshot": true, "last_snapshot_task_uuid": task.Id.String()}).Error)
+
+	response, count, err := GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, api.PaginationData{Limit: 10}, filterData)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, repoConfigs[0].UUID, response.Data[0].UUID)
+	assert.Equal(t, config.StatusInvalid, response.Data[0].Status)
+	assert.Equal(t, config.StatusValid, response.Data[0].IntrospectionStatus)
+
+	fetched, err := GetRepositoryConfigDao(suite.tx).Fetch(context.Background(), orgID, repoConfigs[1].UUID)
+	require.NoError(t, err)
+	assert.Equal(t, config.StatusValid, fetched.Status)
+}
+
 func (suite *RepositoryConfigSuite) TestListFilterMultipleArch() {
 	t := suite.T()
 	orgID := seeds.RandomOrgId()
@@ -934,7 +1518,7 @@ func (suite *RepositoryConfigSuite) TestListFilterMultipleArch() {
 	assert.Nil(t, seeds.SeedRepositoryConfigurations(suite.tx, 10, seeds.SeedOptions{OrgID: orgID, Arch: &s390xref}))
 	assert.Nil(t, seeds.SeedRepositoryConfigurations(suite.tx, 30, seeds.SeedOptions{OrgID: orgID, Arch: &x86ref}))
 
-	response, count, err := GetRepositoryConfigDao(suite.tx).List(orgID, pageData, filterData)
+	response, count, err := GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, pageData, filterData)
 
 	assert.Nil(t, err)
 	assert.Equal(t, quantity, len(response.Data))
@@ -975,7 +1559,7 @@ func (suite *RepositoryConfigSuite) TestListFilterMultipleVersions() {
 	assert.Nil(t, seeds.SeedRepositoryConfigurations(suite.tx, quantity,
 		seeds.SeedOptions{OrgID: "kdksfkdf", Versions: &[]string{config.El7, config.El8, config.El9}}))
 
-	response, count, err := GetRepositoryConfigDao(suite.tx).List(orgID, pageData, filterData)
+	response, count, err := GetRepositoryConfigDao(suite.tx).List(context.Background(), orgID, pageData, filterData)
 
 	assert.Nil(t, err)
 	assert.Equal(t, quantity, len(response.Data))
@@ -1011,7 +1595,7 @@ func (suite *RepositoryConfigSuite) TestListFilterSearch() {
 		Version: "",
 	}
 
-	_, err := GetRepositoryConfigDao(tx).Create(api.RepositoryRequest{
+	_, err := GetRepositoryConfigDao(tx).Create(context.Background(), api.RepositoryRequest{
 		OrgID:     &orgID,
 		AccountID: &accountID,
 		Name:      &name,
@@ -1026,7 +1610,7 @@ func (suite *RepositoryConfigSuite) TestListFilterSearch() {
 	assert.Nil(t, result.Error)
 	assert.Equal(t, quantity, total)
 
-	response, total, err := GetRepositoryConfigDao(tx).List(orgID, pageData, filterData)
+	response, total, err := GetRepositoryConfigDao(tx).List(context.Background(), orgID, pageData, filterData)
 
 	assert.Nil(t, err)
 	assert.Equal(t, int(quantity), len(response.Data))
@@ -1043,7 +1627,7 @@ func (suite *RepositoryConfigSuite) TestSavePublicUrls() {
 	}
 
 	// Create the two Repository records
-	err := GetRepositoryConfigDao(tx).SavePublicRepos(repoUrls)
+	err := GetRepositoryConfigDao(tx).SavePublicRepos(context.Background(), repoUrls)
 	require.NoError(t, err)
 	repo := []models.Repository{}
 	err = tx.
@@ -1056,7 +1640,7 @@ func (suite *RepositoryConfigSuite) TestSavePublicUrls() {
 	assert.Equal(t, int64(len(repo)), count)
 
 	// Repeat to check clause on conflict
-	err = GetRepositoryConfigDao(suite.tx).SavePublicRepos(repoUrls)
+	err = GetRepositoryConfigDao(suite.tx).SavePublicRepos(context.Background(), repoUrls)
 	assert.NoError(t, err)
 	err = tx.
 		Model(&models.Repository{}).
@@ -1083,7 +1667,7 @@ func (suite *RepositoryConfigSuite) TestDelete() {
 		Error
 	require.NoError(t, err)
 
-	err = GetRepositoryConfigDao(tx).SoftDelete(repoConfig.OrgID, repoConfig.UUID)
+	err = GetRepositoryConfigDao(tx).SoftDelete(context.Background(), repoConfig.OrgID, repoConfig.UUID)
 	assert.NoError(t, err)
 
 	repoConfig2 := models.RepositoryConfiguration{}
@@ -1108,7 +1692,7 @@ func (suite *RepositoryConfigSuite) TestDeleteNotFound() {
 		Error
 	require.NoError(t, err)
 
-	err = GetRepositoryConfigDao(suite.tx).SoftDelete("bad org id", found.UUID)
+	err = GetRepositoryConfigDao(suite.tx).SoftDelete(context.Background(), "bad org id", found.UUID)
 	assert.Error(t, err)
 	daoError, ok := err.(*ce.DaoError)
 	assert.True(t, ok)
@@ -1134,7 +1718,7 @@ func (suite *RepositoryConfigSuite) TestBulkDelete() {
 	assert.NoError(t, err)
 	assert.Len(t, uuids, repoConfigCount)
 
-	errs := dao.BulkDelete(orgID, uuids)
+	errs := dao.BulkDelete(context.Background(), orgID, uuids)
 	assert.Len(t, errs, 0)
 
 	var found []models.RepositoryConfiguration
@@ -1158,7 +1742,7 @@ func (suite *RepositoryConfigSuite) TestBulkDeleteOneNotFound() {
 	assert.Len(t, uuids, repoConfigCount)
 	uuids[1] = uuid.NewString()
 
-	errs := dao.BulkDelete(orgID, uuids)
+	errs := dao.BulkDelete(context.Background(), orgID, uuids)
 	assert.Len(t, errs, repoConfigCount)
 	assert.Error(t, errs[1])
 
@@ -1184,7 +1768,7 @@ func (suite *RepositoryConfigSuite) TestBulkDeleteMultipleNotFound() {
 	uuids[1] = uuid.NewString()
 	uuids[3] = uuid.NewString()
 
-	errs := dao.BulkDelete(orgID, uuids)
+	errs := dao.BulkDelete(context.Background(), orgID, uuids)
 	assert.Len(t, errs, repoConfigCount)
 	assert.Error(t, errs[1])
 	assert.Error(t, errs[3])
@@ -1195,6 +1779,60 @@ func (suite *RepositoryConfigSuite) TestBulkDeleteMultipleNotFound() {
 	assert.Len(t, found, repoConfigCount)
 }
 
+func (suite *RepositoryConfigSuite) TestBulkUpdate() {
+	t := suite.T()
+	dao := GetRepositoryConfigDao(suite.tx)
+	orgID := seeds.RandomOrgId()
+	repoConfigCount := 5
+
+	err := seeds.SeedRepositoryConfigurations(suite.tx, repoConfigCount, seeds.SeedOptions{OrgID: orgID})
+	assert.Nil(t, err)
+
+	var uuids []string
+	err = suite.tx.Model(models.RepositoryConfiguration{}).Where("org_id = ?", orgID).Select("uuid").Find(&uuids).Error
+	assert.NoError(t, err)
+	assert.Len(t, uuids, repoConfigCount)
+
+	responses, errs := dao.BulkUpdate(context.Background(), orgID, uuids, api.RepositoryRequest{GpgKey: pointy.String("fixed key")})
+	assert.Len(t, errs, 0)
+	assert.Len(t, responses, repoConfigCount)
+
+	var found []models.RepositoryConfiguration
+	err = suite.tx.Where("org_id = ?", orgID).Find(&found).Error
+	assert.NoError(t, err)
+	assert.Len(t, found, repoConfigCount)
+	for i := range found {
+		assert.Equal(t, "fixed key", found[i].GpgKey)
+	}
+}
+
+func (suite *RepositoryConfigSuite) TestBulkUpdateOneNotFound() {
+	t := suite.T()
+	dao := GetRepositoryConfigDao(suite.tx)
+	orgID := seeds.RandomOrgId()
+	repoConfigCount := 5
+
+	err := seeds.SeedRepositoryConfigurations(suite.tx, repoConfigCount, seeds.SeedOptions{OrgID: orgID})
+	assert.Nil(t, err)
+
+	var uuids []string
+	err = suite.tx.Model(models.RepositoryConfiguration{}).Where("org_id = ?", orgID).Select("uuid").Find(&uuids).Error
+	assert.NoError(t, err)
+	assert.Len(t, uuids, repoConfigCount)
+	uuids[1] = uuid.NewString()
+
+	responses, errs := dao.BulkUpdate(context.Background(), orgID, uuids, api.RepositoryRequest{GpgKey: pointy.String("fixed key")})
+	assert.Len(t, responses, 0)
+	assert.Len(t, errs, repoConfigCount)
+	assert.Error(t, errs[1])
+
+	// None of the repositories is updated
+	var count int64
+	err = suite.tx.Model(models.RepositoryConfiguration{}).Where("org_id = ? AND gpg_key = ?", orgID, "fixed key").Count(&count).Error
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
 type MockTimeoutError struct {
 	Message string
 	Timeout bool
@@ -1217,7 +1855,7 @@ func (suite *RepositoryConfigSuite) TestValidateParameters() {
 
 	mockYumRepo.Mock.On("Repomd").Return(&yum.Repomd{}, 200, nil)
 	mockYumRepo.Mock.On("Signature").Return(test.RepomdSignature(), 200, nil)
-	response, err := dao.ValidateParameters(repoConfig.OrgID, parameters, []string{})
+	response, err := dao.ValidateParameters(context.Background(), repoConfig.OrgID, parameters, []string{})
 	assert.NoError(t, err)
 
 	assert.False(t, response.Name.Valid)
@@ -1230,7 +1868,7 @@ func (suite *RepositoryConfigSuite) TestValidateParameters() {
 	// Test again with an edit
 	mockYumRepo.Mock.On("Repomd").Return(&yum.Repomd{}, 200, nil)
 	mockYumRepo.Mock.On("Signature").Return(test.RepomdSignature(), 200, nil)
-	response, err = dao.ValidateParameters(repoConfig.OrgID, parameters, []string{*parameters.UUID})
+	response, err = dao.ValidateParameters(context.Background(), repoConfig.OrgID, parameters, []string{*parameters.UUID})
 	assert.NoError(t, err)
 
 	assert.True(t, response.Name.Valid)
@@ -1249,7 +1887,7 @@ func (suite *RepositoryConfigSuite) TestValidateParametersNoNameUrl() {
 		Name: nil,
 		URL:  nil,
 	}
-	response, err := dao.ValidateParameters(repoConfig.OrgID, parameters, []string{})
+	response, err := dao.ValidateParameters(context.Background(), repoConfig.OrgID, parameters, []string{})
 	assert.NoError(t, err)
 
 	assert.False(t, response.Name.Valid)
@@ -1267,7 +1905,7 @@ func (suite *RepositoryConfigSuite) TestValidateParametersBlankValues() {
 		Name: pointy.String(""),
 		URL:  pointy.String(""),
 	}
-	response, err := dao.ValidateParameters(repoConfig.OrgID, parameters, []string{})
+	response, err := dao.ValidateParameters(context.Background(), repoConfig.OrgID, parameters, []string{})
 	assert.NoError(t, err)
 
 	assert.False(t, response.Name.Valid)
@@ -1289,7 +1927,7 @@ func (suite *RepositoryConfigSuite) TestValidateParametersValidUrlName() {
 	mockYumRepo.Mock.On("Repomd").Return(&yum.Repomd{}, 200, nil)
 	mockYumRepo.Mock.On("Signature").Return(test.RepomdSignature(), 200, nil)
 
-	response, err := dao.ValidateParameters(repoConfig.OrgID, parameters, []string{})
+	response, err := dao.ValidateParameters(context.Background(), repoConfig.OrgID, parameters, []string{})
 	assert.NoError(t, err)
 
 	assert.True(t, response.Name.Valid)
@@ -1299,6 +1937,47 @@ func (suite *RepositoryConfigSuite) TestValidateParametersValidUrlName() {
 	assert.False(t, response.URL.Skipped)
 }
 
+func (suite *RepositoryConfigSuite) TestValidateParametersUrlVariables() {
+	t := suite.T()
+	_, dao, repoConfig := suite.setupValidationTest()
+
+	parameters := api.RepositoryValidationRequest{
+		URL: pointy.String("http://example.com/$releasever/$arch/"),
+	}
+	response, err := dao.ValidateParameters(context.Background(), repoConfig.OrgID, parameters, []string{})
+	assert.NoError(t, err)
+	assert.False(t, response.URL.Valid)
+	assert.Equal(t, api.UrlErrorUnsupportedVar, response.URL.ErrorCode)
+	assert.Contains(t, response.URL.Error, "$arch")
+
+	parameters = api.RepositoryValidationRequest{
+		URL:                  pointy.String("http://example.com/$releasever/$basearch/"),
+		DistributionVersions: []string{config.El8},
+	}
+	response, err = dao.ValidateParameters(context.Background(), repoConfig.OrgID, parameters, []string{})
+	assert.NoError(t, err)
+	assert.False(t, response.URL.Valid)
+	assert.Equal(t, api.UrlErrorBaseArch, response.URL.ErrorCode)
+}
+
+func (suite *RepositoryConfigSuite) TestValidateParametersDeniedUrl() {
+	t := suite.T()
+	_, dao, repoConfig := suite.setupValidationTest()
+	allowList := config.Get().Options.URLAllowList
+	config.Get().Options.URLAllowList = []string{"*.example.com"}
+	defer func() { config.Get().Options.URLAllowList = allowList }()
+
+	// The metadata of a denied url is not fetched
+	parameters := api.RepositoryValidationRequest{
+		URL: pointy.String("http://example.org/repo/"),
+	}
+	response, err := dao.ValidateParameters(context.Background(), repoConfig.OrgID, parameters, []string{})
+	assert.NoError(t, err)
+	assert.False(t, response.URL.Valid)
+	assert.Equal(t, api.UrlErrorDenied, response.URL.ErrorCode)
+	assert.Equal(t, "URL is not allowed: host example.org is not in the allow list.", response.URL.Error)
+}
+
 func (suite *RepositoryConfigSuite) TestValidateParametersBadUrl() {
 	t := suite.T()
 	mockYumRepo, dao, repoConfig := suite.setupValidationTest()
@@ -1309,13 +1988,14 @@ func (suite *RepositoryConfigSuite) TestValidateParametersBadUrl() {
 	}
 	mockYumRepo.Mock.On("Repomd").Return(nil, 404, nil)
 
-	response, err := dao.ValidateParameters(repoConfig.OrgID, parameters, []string{})
+	response, err := dao.ValidateParameters(context.Background(), repoConfig.OrgID, parameters, []string{})
 	assert.NoError(t, err)
 
 	assert.True(t, response.Name.Valid)
 	assert.False(t, response.Name.Skipped)
 	assert.True(t, response.URL.Valid) // Even if the metadata isn't present, the URL itself is valid
 	assert.Equal(t, response.URL.HTTPCode, 404)
+	assert.Equal(t, api.UrlErrorMetadataMissing, response.URL.ErrorCode)
 	assert.False(t, response.URL.MetadataPresent)
 	assert.False(t, response.URL.Skipped)
 }
@@ -1336,7 +2016,7 @@ func (suite *RepositoryConfigSuite) TestValidateParametersTimeOutUrl() {
 
 	mockYumRepo.Mock.On("Repomd").Return(nil, 0, timeoutErr)
 
-	response, err := dao.ValidateParameters(repoConfig.OrgID, parameters, []string{})
+	response, err := dao.ValidateParameters(context.Background(), repoConfig.OrgID, parameters, []string{})
 	assert.NoError(t, err)
 
 	assert.True(t, response.Name.Valid)
@@ -1361,7 +2041,7 @@ func (suite *RepositoryConfigSuite) TestValidateParametersGpgKey() {
 	mockYumRepo.Mock.On("Repomd").Return(test.Repomd, 200, nil)
 	mockYumRepo.Mock.On("Signature").Return(test.RepomdSignature(), 200, nil)
 
-	response, err := dao.ValidateParameters(repoConfig.OrgID, parameters, []string{})
+	response, err := dao.ValidateParameters(context.Background(), repoConfig.OrgID, parameters, []string{})
 	assert.NoError(t, err)
 	assert.True(t, response.GPGKey.Valid)
 	assert.Equal(t, "", response.GPGKey.Error)
@@ -1384,7 +2064,7 @@ func (suite *RepositoryConfigSuite) TestValidateParametersBadSig() {
 	mockYumRepo.Mock.On("Repomd").Return(&badRepomd, 200, nil)
 	mockYumRepo.Mock.On("Signature").Return(test.RepomdSignature(), 200, nil)
 
-	response, err := dao.ValidateParameters(repoConfig.OrgID, parameters, []string{})
+	response, err := dao.ValidateParameters(context.Background(), repoConfig.OrgID, parameters, []string{})
 	assert.NoError(t, err)
 	assert.False(t, response.GPGKey.Valid)
 	assert.True(t, response.URL.MetadataSignaturePresent)
@@ -1392,7 +2072,7 @@ func (suite *RepositoryConfigSuite) TestValidateParametersBadSig() {
 
 	// retest disabling metadata verification
 	parameters.MetadataVerification = false
-	response, err = dao.ValidateParameters(repoConfig.OrgID, parameters, []string{})
+	response, err = dao.ValidateParameters(context.Background(), repoConfig.OrgID, parameters, []string{})
 	assert.NoError(t, err)
 	assert.True(t, response.GPGKey.Valid)
 	assert.True(t, response.URL.MetadataSignaturePresent)
@@ -1413,13 +2093,72 @@ func (suite *RepositoryConfigSuite) TestValidateParametersBadGpgKey() {
 	mockYumRepo.Mock.On("Repomd").Return(test.Repomd, 200, nil)
 	mockYumRepo.Mock.On("Signature").Return(test.RepomdSignature(), 200, nil)
 
-	response, err := dao.ValidateParameters(repoConfig.OrgID, parameters, []string{})
+	response, err := dao.ValidateParameters(context.Background(), repoConfig.OrgID, parameters, []string{})
 	assert.NoError(t, err)
 	assert.False(t, response.GPGKey.Valid)
 	assert.True(t, response.URL.MetadataSignaturePresent)
 	assert.True(t, response.URL.Valid)
 }
 
+func TestMetadataErrorCode(t *testing.T) {
+	type TestCase struct {
+		Name     string
+		Code     int
+		Err      error
+		Expected string
+	}
+
+	testCases := []TestCase{
+		{
+			Name:     "DNS failure",
+			Err:      fmt.Errorf("GET error: %w", &url.Error{Op: "Get", Err: &net.OpError{Op: "dial", Err: &net.DNSError{Name: "bad.example.com"}}}),
+			Expected: api.UrlErrorDNSFailure,
+		},
+		{
+			Name:     "Untrusted certificate",
+			Err:      fmt.Errorf("GET error: %w", &url.Error{Op: "Get", Err: x509.UnknownAuthorityError{}}),
+			Expected: api.UrlErrorTLSFailure,
+		},
+		{
+			Name:     "Timeout",
+			Err:      fmt.Errorf("GET error: %w", &url.Error{Op: "Get", Err: context.DeadlineExceeded}),
+			Expected: api.UrlErrorTimeout,
+		},
+		{
+			Name:     "Connection refused",
+			Err:      fmt.Errorf("GET error: %w", &url.Error{Op: "Get", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}),
+			Expected: api.UrlErrorConnectionFailure,
+		},
+		{
+			Name:     "Missing repomd.xml",
+			Code:     404,
+			Err:      errors.New("Cannot fetch repomd.xml: 404"),
+			Expected: api.UrlErrorMetadataMissing,
+		},
+		{
+			Name:     "Server error",
+			Code:     503,
+			Err:      errors.New("Cannot fetch repomd.xml: 503"),
+			Expected: api.UrlErrorHTTPStatus,
+		},
+		{
+			Name:     "Malformed repomd.xml",
+			Code:     200,
+			Err:      errors.New("Error parsing repomd.xml: EOF"),
+			Expected: api.UrlErrorMetadataMalformed,
+		},
+		{
+			Name:     "Other error",
+			Err:      errors.New("Error parsing Repomd URL"),
+			Expected: api.UrlErrorMetadataFetchError,
+		},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.Expected, metadataErrorCode(testCase.Code, testCase.Err), testCase.Name)
+	}
+}
+
 func TestDBErrorToApi(t *testing.T) {
 	var result *ce.DaoError
 
@@ -1469,6 +2208,60 @@ func TestDBErrorToApi(t *testing.T) {
 	}
 }
 
+func (suite *RepositoryConfigSuite) TestValidateParametersDiscoverSiblings() {
+	t := suite.T()
+	mockYumRepo, dao, repoConfig := suite.setupValidationTest()
+
+	url := "https://mirror.example.com/fedora/releases/38/Everything/x86_64/os/"
+	parameters := api.RepositoryValidationRequest{
+		URL:              &url,
+		DiscoverSiblings: true,
+	}
+
+	// The repository, then its source repository, are found, the debug repository is not
+	mockYumRepo.Mock.On("Repomd").Return(&yum.Repomd{}, 200, nil).Twice()
+	mockYumRepo.Mock.On("Repomd").Return(nil, 404, nil).Once()
+	mockYumRepo.Mock.On("Signature").Return(test.RepomdSignature(), 200, nil)
+	response, err := dao.ValidateParameters(context.Background(), repoConfig.OrgID, parameters, []string{})
+	assert.NoError(t, err)
+	assert.True(t, response.URL.MetadataPresent)
+	assert.Equal(t, []api.SiblingRepository{
+		{URL: "https://mirror.example.com/fedora/releases/38/Everything/source/tree/", ContentType: config.ContentTypeSource},
+	}, response.URL.Siblings)
+	mockYumRepo.AssertExpectations(t)
+
+	// Not requested
+	parameters.DiscoverSiblings = false
+	mockYumRepo.Mock.On("Repomd").Return(&yum.Repomd{}, 200, nil).Once()
+	response, err = dao.ValidateParameters(context.Background(), repoConfig.OrgID, parameters, []string{})
+	assert.NoError(t, err)
+	assert.Nil(t, response.URL.Siblings)
+}
+
+func (suite *RepositoryConfigSuite) TestFetchFailingByOrg() {
+	t := suite.T()
+	tx := suite.tx
+	orgID := seeds.RandomOrgId()
+	optedOutOrgID := seeds.RandomOrgId()
+
+	err := seeds.SeedRepositoryConfigurations(tx, 2, seeds.SeedOptions{OrgID: orgID, Status: pointy.String(config.StatusInvalid)})
+	require.NoError(t, err)
+	err = seeds.SeedRepositoryConfigurations(tx, 1, seeds.SeedOptions{OrgID: orgID, Status: pointy.String(config.StatusValid)})
+	require.NoError(t, err)
+	err = seeds.SeedRepositoryConfigurations(tx, 1, seeds.SeedOptions{OrgID: optedOutOrgID, Status: pointy.String(config.StatusUnavailable)})
+	require.NoError(t, err)
+	err = tx.Create(&models.OrgSettings{OrgID: optedOutOrgID, IntrospectionDigestOptOut: true}).Error
+	require.NoError(t, err)
+
+	failing, err := GetRepositoryConfigDao(tx).InternalOnly_FetchFailingByOrg(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, failing[orgID], 2)
+	for _, repo := range failing[orgID] {
+		assert.Equal(t, config.StatusInvalid, repo.Status)
+	}
+	assert.NotContains(t, failing, optedOutOrgID)
+}
+
 func (suite *RepositoryConfigSuite) setupValidationTest() (*mockExt.YumRepositoryMock, repositoryConfigDaoImpl, models.RepositoryConfiguration) {
 	t := suite.T()
 	orgId := seeds.RandomOrgId()
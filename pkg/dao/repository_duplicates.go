@@ -0,0 +1,58 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"gorm.io/gorm"
+)
+
+// duplicateRepository is a repository config of an org, with the checksum of its repomd.xml
+type duplicateRepository struct {
+	RepomdChecksum string
+	UUID           string
+	Name           string
+	URL            string
+}
+
+// ListDuplicates returns the repositories of an org serving the same repomd.xml, as found by their last
+// introspection, grouped by its checksum.  They are mirrors of the same content under different urls.
+func (r repositoryConfigDaoImpl) ListDuplicates(ctx context.Context, orgID string) ([]api.DuplicateRepositoriesResponse, error) {
+	var rows []duplicateRepository
+	err := readOnly(ctx, r.db, func(conn *gorm.DB) error {
+		withChecksum := func(db *gorm.DB) *gorm.DB {
+			return db.Model(&models.RepositoryConfiguration{}).
+				Joins("inner join repositories on repositories.uuid = repository_configurations.repository_uuid").
+				Scopes(WithOrg(orgID)).
+				Where("repositories.repomd_checksum IS NOT NULL AND repositories.repomd_checksum <> ''")
+		}
+		duplicated := conn.Scopes(withChecksum).
+			Select("repositories.repomd_checksum").
+			Group("repositories.repomd_checksum").
+			Having("COUNT(*) > 1")
+		return conn.Scopes(withChecksum).
+			Select("repositories.repomd_checksum, repository_configurations.uuid, repository_configurations.name, repositories.url").
+			Where("repositories.repomd_checksum in (?)", duplicated).
+			Order("repositories.repomd_checksum, repository_configurations.name").
+			Scan(&rows).Error
+	})
+	if err != nil {
+		return nil, DBErrorToApi(err)
+	}
+
+	duplicates := []api.DuplicateRepositoriesResponse{}
+	for _, row := range rows {
+		last := len(duplicates) - 1
+		if last < 0 || duplicates[last].RepomdChecksum != row.RepomdChecksum {
+			duplicates = append(duplicates, api.DuplicateRepositoriesResponse{RepomdChecksum: row.RepomdChecksum})
+			last++
+		}
+		duplicates[last].Repositories = append(duplicates[last].Repositories, api.DuplicateRepository{
+			UUID: row.UUID,
+			Name: row.Name,
+			URL:  row.URL,
+		})
+	}
+	return duplicates, nil
+}
@@ -0,0 +1,47 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/notifications"
+)
+
+// repositoryEventsLimit is the number of events returned at most by ListEvents
+const repositoryEventsLimit = 500
+
+// repositoryEventNames are the outbox events listed by ListEvents
+var repositoryEventNames = []string{
+	notifications.RepositoryCreated.String(),
+	notifications.RepositoryUpdated.String(),
+	notifications.RepositoryDeleted.String(),
+	notifications.RepositoryIntrospected.String(),
+	notifications.RepositoryIntrospectionFailure.String(),
+}
+
+// ListEvents returns the changes of the repositories of an organization created since the given time,
+// oldest first.  They are read from the notifications outbox, so that they are only listed once committed.
+func (r repositoryConfigDaoImpl) ListEvents(ctx context.Context, orgID string, since time.Time) ([]api.RepositoryEventResponse, error) {
+	var events []models.OutboxEvent
+	err := r.db.WithContext(ctx).
+		Where("org_id = ? AND created_at >= ? AND event_name IN ?", orgID, since, repositoryEventNames).
+		Order("created_at ASC").
+		Limit(repositoryEventsLimit).
+		Find(&events).Error
+	if err != nil {
+		return nil, DBErrorToApi(err)
+	}
+
+	responses := make([]api.RepositoryEventResponse, len(events))
+	for i := range events {
+		responses[i] = api.RepositoryEventResponse{
+			UUID:      events[i].UUID,
+			Type:      events[i].EventName,
+			CreatedAt: events[i].CreatedAt,
+			Data:      events[i].Payload,
+		}
+	}
+	return responses, nil
+}
@@ -0,0 +1,431 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/config"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"gorm.io/gorm"
+)
+
+// dependencyProvider is a package providing a capability, with the version it provides
+// and the repository containing it
+type dependencyProvider struct {
+	Capability     string
+	RpmUUID        string
+	ProvideFlags   string
+	ProvideEpoch   int32
+	ProvideVersion string
+	ProvideRelease string
+	api.SearchPackageResponse
+}
+
+// requirement is a capability required by a package, optionally restricted to a range of
+// versions by its flags
+type requirement struct {
+	Name    string
+	Flags   string
+	Epoch   int32
+	Version string
+	Release string
+}
+
+// String returns the requirement as written in spec files, such as "bash >= 5.1"
+func (req requirement) String() string {
+	operator, versioned := flagOperators[req.Flags]
+	if !versioned {
+		return req.Name
+	}
+	return req.Name + " " + operator + " " + formatEVR(req.Epoch, req.Version, req.Release)
+}
+
+var flagOperators = map[string]string{"EQ": "=", "LT": "<", "LE": "<=", "GT": ">", "GE": ">="}
+
+func (r rpmDaoImpl) DependencyClosure(ctx context.Context, orgID string, request api.DependencyClosureRequest) (api.DependencyClosureResponse, error) {
+	var response api.DependencyClosureResponse
+	err := readOnly(ctx, r.db, func(conn *gorm.DB) error {
+		var err error
+		response, err = rpmDaoImpl{db: conn}.dependencyClosure(ctx, orgID, request)
+		return err
+	})
+	return response, err
+}
+
+// dependencyClosure walks the requires of the requested packages, and of the packages
+// providing them, until every requirement is provided or known to be unresolvable.
+// Versioned requirements are only satisfied by packages providing a matching version,
+// compared as rpm does.  rpmlib() requirements, provided by rpm itself, and rich
+// dependencies, such as (a or b), are not resolved.  Requirements are resolved against the
+// latest introspection of the repositories, the packages of snapshots are not stored.
+func (r rpmDaoImpl) dependencyClosure(ctx context.Context, orgID string, request api.DependencyClosureRequest) (api.DependencyClosureResponse, error) {
+	response := api.DependencyClosureResponse{
+		Packages:   []api.SearchPackageResponse{},
+		Unresolved: []api.UnresolvedDependency{},
+	}
+	if len(request.URLs) == 0 && len(request.UUIDs) == 0 {
+		return response, &ce.DaoError{BadValidation: true, Message: "must contain at least 1 URL or 1 UUID"}
+	}
+	if len(request.Packages) == 0 {
+		return response, &ce.DaoError{BadValidation: true, Message: "must contain at least 1 package"}
+	}
+
+	repoUuids, err := r.accessibleRepositories(ctx, orgID, request.URLs, request.UUIDs)
+	if err != nil {
+		return response, err
+	}
+
+	resolved := map[string]api.SearchPackageResponse{} // by rpm uuid
+	requiredBy := map[requirement][]string{}           // requirement to the names of the packages requiring it
+	seen := map[requirement]bool{}
+	pending := []requirement{}
+	for _, name := range request.Packages {
+		req := requirement{Name: name}
+		if !seen[req] {
+			seen[req] = true
+			pending = append(pending, req)
+		}
+	}
+
+	for len(pending) > 0 {
+		names := make([]string, 0, len(pending))
+		for _, req := range pending {
+			names = append(names, req.Name)
+		}
+		providers, err := r.dependencyProviders(ctx, repoUuids, names, request.Arch)
+		if err != nil {
+			return response, err
+		}
+
+		added := []string{}
+		for _, req := range pending {
+			candidates := satisfyingProviders(providers[req.Name], req)
+			if len(candidates) == 0 {
+				unresolved := api.UnresolvedDependency{Name: req.String(), RequiredBy: []string{}}
+				unresolved.RequiredBy = append(unresolved.RequiredBy, requiredBy[req]...)
+				response.Unresolved = append(response.Unresolved, unresolved)
+				continue
+			}
+			if alreadyProvided(candidates, resolved) {
+				continue
+			}
+			best := candidates[0]
+			resolved[best.RpmUUID] = best.SearchPackageResponse
+			added = append(added, best.RpmUUID)
+		}
+
+		requires, err := r.dependencyRequires(ctx, added)
+		if err != nil {
+			return response, err
+		}
+		pending = []requirement{}
+		for _, rpmUuid := range added {
+			for _, req := range requires[rpmUuid] {
+				if strings.HasPrefix(req.Name, "rpmlib(") || strings.HasPrefix(req.Name, "(") {
+					continue
+				}
+				requiredBy[req] = append(requiredBy[req], resolved[rpmUuid].Name)
+				if !seen[req] {
+					seen[req] = true
+					pending = append(pending, req)
+				}
+			}
+		}
+	}
+
+	for _, pkg := range resolved {
+		response.Packages = append(response.Packages, pkg)
+	}
+	sort.Slice(response.Packages, func(i, j int) bool {
+		if response.Packages[i].Name != response.Packages[j].Name {
+			return response.Packages[i].Name < response.Packages[j].Name
+		}
+		return response.Packages[i].Arch < response.Packages[j].Arch
+	})
+	sort.Slice(response.Unresolved, func(i, j int) bool {
+		return response.Unresolved[i].Name < response.Unresolved[j].Name
+	})
+	return response, nil
+}
+
+// accessibleRepositories returns the uuids of the repositories given by url or
+// repository configuration uuid that belong to the org or are public.  Source and
+// debug repositories are left out, their packages do not satisfy runtime requirements.
+func (r rpmDaoImpl) accessibleRepositories(ctx context.Context, orgID string, urls []string, uuids []string) ([]string, error) {
+	if orgID == "" {
+		return nil, fmt.Errorf("orgID can not be an empty string")
+	}
+	trailingUrls := make([]string, 0, len(urls)*2)
+	for _, url := range urls {
+		trailingUrls = append(trailingUrls, url, url+"/")
+	}
+
+	var repoUuids []string
+	err := r.db.WithContext(ctx).
+		Model(&models.Repository{}).
+		Joins("left join repository_configurations on repository_configurations.repository_uuid = repositories.uuid"+
+			" AND repository_configurations.org_id = ? AND repository_configurations.deleted_at IS NULL", orgID).
+		Where("repository_configurations.uuid IS NOT NULL OR repositories.public").
+		Where("repository_configurations.content_type IS NULL OR repository_configurations.content_type = ?", config.ContentTypeBinary).
+		Where(r.db.WithContext(ctx).Where("repositories.url in ?", trailingUrls).
+			Or("repository_configurations.uuid in ?", uuids)).
+		Distinct().
+		Pluck("repositories.uuid", &repoUuids).Error
+	return repoUuids, err
+}
+
+// dependencyProviders returns the packages of the repositories providing each capability,
+// with the version they provide
+func (r rpmDaoImpl) dependencyProviders(ctx context.Context, repoUuids []string, capabilities []string, arch string) (map[string][]dependencyProvider, error) {
+	providers := map[string][]dependencyProvider{}
+	if len(repoUuids) == 0 {
+		return providers, nil
+	}
+
+	for _, chunk := range chunkStrings(capabilities, rpmInsertBatchSize()) {
+		var found []dependencyProvider
+		byCapability := r.db.WithContext(ctx).
+			Select("rpm_capabilities.name as capability", "rpms.uuid as rpm_uuid",
+				"rpm_capabilities.flags as provide_flags", "rpm_capabilities.epoch as provide_epoch",
+				"rpm_capabilities.version as provide_version", "rpm_capabilities.release as provide_release").
+			Table(models.TableNameRpmCapability).
+			Joins("inner join rpms on rpms.uuid = rpm_capabilities.rpm_uuid").
+			Where("rpm_capabilities.kind = ? AND rpm_capabilities.name in ?", models.CapabilityProvides, chunk)
+		// Packages introspected before capabilities were stored only provide their name
+		byName := r.db.WithContext(ctx).
+			Select("rpms.name as capability", "rpms.uuid as rpm_uuid",
+				"'EQ' as provide_flags", "rpms.epoch as provide_epoch",
+				"rpms.version as provide_version", "rpms.release as provide_release").
+			Table(models.TableNameRpm).
+			Where("rpms.name in ?", chunk)
+
+		query := r.db.WithContext(ctx).
+			Select("candidates.*",
+				"rpms.name, rpms.arch, rpms.version, rpms.release, rpms.epoch, rpms.summary, rpms.checksum",
+				"repositories.url as repository_url").
+			Table("(? UNION ?) as candidates", byCapability, byName).
+			Joins("inner join rpms on rpms.uuid = candidates.rpm_uuid").
+			Joins("inner join repositories_rpms on repositories_rpms.rpm_uuid = rpms.uuid").
+			Joins("inner join repositories on repositories.uuid = repositories_rpms.repository_uuid").
+			Where("repositories.uuid in ?", repoUuids)
+		if arch != "" {
+			query = query.Where("rpms.arch in ?", []string{arch, "noarch"})
+		}
+		if err := query.
+			Order("candidates.capability, rpms.uuid").
+			Scan(&found).Error; err != nil {
+			return nil, err
+		}
+		for _, provider := range found {
+			providers[provider.Capability] = append(providers[provider.Capability], provider)
+		}
+	}
+	return providers, nil
+}
+
+// dependencyRequires returns the requirements of each rpm
+func (r rpmDaoImpl) dependencyRequires(ctx context.Context, rpmUuids []string) (map[string][]requirement, error) {
+	requires := map[string][]requirement{}
+	for _, chunk := range chunkStrings(rpmUuids, rpmInsertBatchSize()) {
+		var found []models.RpmCapability
+		if err := r.db.WithContext(ctx).
+			Where("kind = ? AND rpm_uuid in ?", models.CapabilityRequires, chunk).
+			Order("name").
+			Find(&found).Error; err != nil {
+			return nil, err
+		}
+		for _, capability := range found {
+			requires[capability.RpmUUID] = append(requires[capability.RpmUUID], requirement{
+				Name:    capability.Name,
+				Flags:   capability.Flags,
+				Epoch:   capability.Epoch,
+				Version: capability.Version,
+				Release: capability.Release,
+			})
+		}
+	}
+	return requires, nil
+}
+
+// satisfyingProviders returns the providers satisfying the requirement, best candidate first:
+// a package named after the capability, then the highest version of the package
+func satisfyingProviders(providers []dependencyProvider, req requirement) []dependencyProvider {
+	satisfying := []dependencyProvider{}
+	for _, provider := range providers {
+		provided := requirement{
+			Name:    provider.Capability,
+			Flags:   provider.ProvideFlags,
+			Epoch:   provider.ProvideEpoch,
+			Version: provider.ProvideVersion,
+			Release: provider.ProvideRelease,
+		}
+		if rangesOverlap(provided, req) {
+			satisfying = append(satisfying, provider)
+		}
+	}
+	sort.SliceStable(satisfying, func(i, j int) bool {
+		a, b := satisfying[i], satisfying[j]
+		if (a.Name == req.Name) != (b.Name == req.Name) {
+			return a.Name == req.Name
+		}
+		return compareEVR(a.Epoch, a.Version, a.Release, b.Epoch, b.Version, b.Release) > 0
+	})
+	return satisfying
+}
+
+// rangesOverlap returns whether a provided version range satisfies a required one, as rpm
+// does.  Unversioned provides and requires match any version.
+func rangesOverlap(provided requirement, required requirement) bool {
+	if _, versioned := flagOperators[provided.Flags]; !versioned {
+		return true
+	}
+	if _, versioned := flagOperators[required.Flags]; !versioned {
+		return true
+	}
+	providedRelease, requiredRelease := provided.Release, required.Release
+	if providedRelease == "" || requiredRelease == "" {
+		// A missing release matches any release
+		providedRelease, requiredRelease = "", ""
+	}
+	cmp := compareEVR(provided.Epoch, provided.Version, providedRelease, required.Epoch, required.Version, requiredRelease)
+
+	includesLess := func(flags string) bool { return flags == "LT" || flags == "LE" }
+	includesEqual := func(flags string) bool { return flags == "EQ" || flags == "LE" || flags == "GE" }
+	includesGreater := func(flags string) bool { return flags == "GT" || flags == "GE" }
+	switch {
+	case cmp < 0:
+		return includesGreater(provided.Flags) || includesLess(required.Flags)
+	case cmp > 0:
+		return includesLess(provided.Flags) || includesGreater(required.Flags)
+	}
+	return (includesEqual(provided.Flags) && includesEqual(required.Flags)) ||
+		(includesLess(provided.Flags) && includesLess(required.Flags)) ||
+		(includesGreater(provided.Flags) && includesGreater(required.Flags))
+}
+
+// formatEVR returns the version as epoch:version-release, omitting a zero epoch
+func formatEVR(epoch int32, version string, release string) string {
+	evr := version
+	if epoch != 0 {
+		evr = fmt.Sprintf("%d:%s", epoch, evr)
+	}
+	if release != "" {
+		evr += "-" + release
+	}
+	return evr
+}
+
+// compareEVR compares two epoch, version and release triplets, returning a negative number
+// when the first is older, 0 when they are equal and a positive number when it is newer
+func compareEVR(epoch1 int32, version1 string, release1 string, epoch2 int32, version2 string, release2 string) int {
+	if epoch1 != epoch2 {
+		if epoch1 < epoch2 {
+			return -1
+		}
+		return 1
+	}
+	if cmp := rpmVersionCompare(version1, version2); cmp != 0 {
+		return cmp
+	}
+	return rpmVersionCompare(release1, release2)
+}
+
+// rpmVersionCompare compares two versions or releases as rpmvercmp does: segments of digits
+// are compared as numbers and are newer than segments of letters, a tilde sorts before
+// anything and a caret sorts after the end of the version but before anything else.
+func rpmVersionCompare(a string, b string) int {
+	if a == b {
+		return 0
+	}
+	isAlnum := func(c byte) bool { return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+	isDigit := func(c byte) bool { return c >= '0' && c <= '9' }
+	trim := func(s string) string {
+		for len(s) > 0 && !isAlnum(s[0]) && s[0] != '~' && s[0] != '^' {
+			s = s[1:]
+		}
+		return s
+	}
+	segment := func(s string, digits bool) (string, string) {
+		i := 0
+		for i < len(s) && isAlnum(s[i]) && isDigit(s[i]) == digits {
+			i++
+		}
+		return s[:i], s[i:]
+	}
+
+	for {
+		a, b = trim(a), trim(b)
+		if strings.HasPrefix(a, "~") || strings.HasPrefix(b, "~") {
+			if !strings.HasPrefix(a, "~") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "~") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		if strings.HasPrefix(a, "^") || strings.HasPrefix(b, "^") {
+			switch {
+			case a == "":
+				return -1
+			case b == "":
+				return 1
+			case !strings.HasPrefix(a, "^"):
+				return 1
+			case !strings.HasPrefix(b, "^"):
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		if a == "" || b == "" {
+			break
+		}
+
+		digits := isDigit(a[0])
+		var segA, segB string
+		segA, a = segment(a, digits)
+		segB, b = segment(b, digits)
+		if segB == "" {
+			// Segments of different kinds, digits are newer
+			if digits {
+				return 1
+			}
+			return -1
+		}
+		if digits {
+			segA, segB = strings.TrimLeft(segA, "0"), strings.TrimLeft(segB, "0")
+			if len(segA) != len(segB) {
+				if len(segA) < len(segB) {
+					return -1
+				}
+				return 1
+			}
+		}
+		if cmp := strings.Compare(segA, segB); cmp != 0 {
+			return cmp
+		}
+	}
+	switch {
+	case a == "" && b == "":
+		return 0
+	case a == "":
+		return -1
+	}
+	return 1
+}
+
+// alreadyProvided returns true if one of the candidates has already been resolved
+func alreadyProvided(candidates []dependencyProvider, resolved map[string]api.SearchPackageResponse) bool {
+	for _, candidate := range candidates {
+		if _, found := resolved[candidate.RpmUUID]; found {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package dao
+
+import (
+	"context"
+	"regexp"
+	"sort"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/config"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"golang.org/x/exp/slices"
+	"gorm.io/gorm"
+)
+
+// officialRepositoryURL matches the urls of the Red Hat repositories, capturing their major version and architecture
+var officialRepositoryURL = regexp.MustCompile(`^https://cdn\.redhat\.com/content/dist/(?:layered/)?rhel(\d+)/(?:\d+(?:\.\d+)*/)?([^/]+)/`)
+
+// overlappingRpm is a package of a repository with the same name and architecture as a package
+// of an official repository
+type overlappingRpm struct {
+	Name            string
+	Arch            string
+	Epoch           int32
+	Version         string
+	Release         string
+	OfficialEpoch   int32
+	OfficialVersion string
+	OfficialRelease string
+	OfficialURL     string
+}
+
+func (r rpmDaoImpl) OfficialOverlaps(ctx context.Context, orgID string, repositoryConfigUUID string) (api.OfficialOverlapsResponse, error) {
+	var response api.OfficialOverlapsResponse
+	err := readOnly(ctx, r.db, func(conn *gorm.DB) error {
+		var err error
+		response, err = rpmDaoImpl{db: conn}.officialOverlaps(ctx, orgID, repositoryConfigUUID)
+		return err
+	})
+	return response, err
+}
+
+func (r rpmDaoImpl) officialOverlaps(ctx context.Context, orgID string, repositoryConfigUUID string) (api.OfficialOverlapsResponse, error) {
+	repoConfig := models.RepositoryConfiguration{}
+	err := r.db.WithContext(ctx).
+		Scopes(WithOrg(orgID)).
+		Where("uuid = ?", repositoryConfigUUID).
+		First(&repoConfig).Error
+	if err == gorm.ErrRecordNotFound {
+		return api.OfficialOverlapsResponse{}, &ce.DaoError{NotFound: true, Message: "Could not find repository with UUID " + repositoryConfigUUID}
+	} else if err != nil {
+		return api.OfficialOverlapsResponse{}, DBErrorToApi(err)
+	}
+
+	var official []models.Repository
+	err = r.db.WithContext(ctx).
+		Where("public AND url LIKE ? AND uuid <> ?", "https://cdn.redhat.com/content/dist/%", repoConfig.RepositoryUUID).
+		Order("url").
+		Find(&official).Error
+	if err != nil {
+		return api.OfficialOverlapsResponse{}, DBErrorToApi(err)
+	}
+	response := api.OfficialOverlapsResponse{OfficialRepositories: []string{}, Overlaps: []api.PackageOverlap{}}
+	officialUUIDs := []string{}
+	for _, repo := range official {
+		if officialRepositoryMatches(repo.URL, repoConfig.Versions, repoConfig.Arch) {
+			officialUUIDs = append(officialUUIDs, repo.UUID)
+			response.OfficialRepositories = append(response.OfficialRepositories, repo.URL)
+		}
+	}
+	if len(officialUUIDs) == 0 {
+		return response, nil
+	}
+
+	var rows []overlappingRpm
+	err = r.db.WithContext(ctx).
+		Select("custom.name, custom.arch, custom.epoch, custom.version, custom.release",
+			"official.epoch as official_epoch, official.version as official_version, official.release as official_release",
+			"repositories.url as official_url").
+		Table(models.TableNameRpm+" as custom").
+		Joins("inner join repositories_rpms custom_rpms on custom_rpms.rpm_uuid = custom.uuid").
+		Joins("inner join rpms official on official.name = custom.name AND official.arch = custom.arch").
+		Joins("inner join repositories_rpms official_rpms on official_rpms.rpm_uuid = official.uuid").
+		Joins("inner join repositories on repositories.uuid = official_rpms.repository_uuid").
+		Where("custom_rpms.repository_uuid = ? AND official_rpms.repository_uuid in ?", repoConfig.RepositoryUUID, officialUUIDs).
+		Scan(&rows).Error
+	if err != nil {
+		return api.OfficialOverlapsResponse{}, DBErrorToApi(err)
+	}
+	response.Overlaps = highestOverlaps(rows)
+	return response, nil
+}
+
+// officialRepositoryMatches returns whether the url is the one of an official repository of one of
+// the versions and of the architecture of a repository, any of them matching when unset
+func officialRepositoryMatches(url string, versions []string, arch string) bool {
+	match := officialRepositoryURL.FindStringSubmatch(url)
+	if match == nil {
+		return false
+	}
+	if arch != "" && arch != config.ANY_ARCH && arch != match[2] {
+		return false
+	}
+	return len(versions) == 0 || slices.Contains(versions, config.ANY_VERSION) || slices.Contains(versions, match[1])
+}
+
+// highestOverlaps keeps the highest version of each package of the repository and of the official
+// repositories, as the ones dnf picks, sorted by name and architecture
+func highestOverlaps(rows []overlappingRpm) []api.PackageOverlap {
+	highest := map[string]overlappingRpm{}
+	for _, row := range rows {
+		key := row.Name + "." + row.Arch
+		current, ok := highest[key]
+		if !ok {
+			highest[key] = row
+			continue
+		}
+		if compareEVR(row.Epoch, row.Version, row.Release, current.Epoch, current.Version, current.Release) > 0 {
+			current.Epoch, current.Version, current.Release = row.Epoch, row.Version, row.Release
+		}
+		if compareEVR(row.OfficialEpoch, row.OfficialVersion, row.OfficialRelease, current.OfficialEpoch, current.OfficialVersion, current.OfficialRelease) > 0 {
+			current.OfficialEpoch, current.OfficialVersion, current.OfficialRelease = row.OfficialEpoch, row.OfficialVersion, row.OfficialRelease
+			current.OfficialURL = row.OfficialURL
+		}
+		highest[key] = current
+	}
+
+	overlaps := make([]api.PackageOverlap, 0, len(highest))
+	for _, rpm := range highest {
+		overlap := api.PackageOverlap{
+			Name:            rpm.Name,
+			Arch:            rpm.Arch,
+			Version:         formatEVR(rpm.Epoch, rpm.Version, rpm.Release),
+			OfficialVersion: formatEVR(rpm.OfficialEpoch, rpm.OfficialVersion, rpm.OfficialRelease),
+			OfficialURL:     rpm.OfficialURL,
+		}
+		cmp := compareEVR(rpm.Epoch, rpm.Version, rpm.Release, rpm.OfficialEpoch, rpm.OfficialVersion, rpm.OfficialRelease)
+		switch {
+		case cmp < 0:
+			overlap.Comparison = api.PackageOverlapOlder
+		case cmp > 0:
+			overlap.Comparison = api.PackageOverlapNewer
+		default:
+			overlap.Comparison = api.PackageOverlapSame
+		}
+		overlaps = append(overlaps, overlap)
+	}
+	sort.Slice(overlaps, func(i, j int) bool {
+		if overlaps[i].Name != overlaps[j].Name {
+			return overlaps[i].Name < overlaps[j].Name
+		}
+		return overlaps[i].Arch < overlaps[j].Arch
+	})
+	return overlaps
+}
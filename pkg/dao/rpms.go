@@ -1,10 +1,14 @@
 package dao
 
 import (
+	"context"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/db"
 	ce "github.com/content-services/content-sources-backend/pkg/errors"
 	"github.com/content-services/content-sources-backend/pkg/models"
 	"github.com/content-services/yummy/pkg/yum"
@@ -24,11 +28,12 @@ func GetRpmDao(db *gorm.DB) RpmDao {
 	}
 }
 
-func (r rpmDaoImpl) isOwnedRepository(orgID string, repositoryConfigUUID string) (bool, error) {
+func (r rpmDaoImpl) isOwnedRepository(ctx context.Context, orgID string, repositoryConfigUUID string) (bool, error) {
 	var repoConfigs []models.RepositoryConfiguration
 	var count int64
-	if err := r.db.
-		Where("org_id = ? and text(uuid) = ?", orgID, repositoryConfigUUID).
+	if err := r.db.WithContext(ctx).
+		Scopes(WithOrg(orgID)).
+		Where("uuid = ?", repositoryConfigUUID).
 		Find(&repoConfigs).
 		Count(&count).
 		Error; err != nil {
@@ -40,7 +45,18 @@ func (r rpmDaoImpl) isOwnedRepository(orgID string, repositoryConfigUUID string)
 	return true, nil
 }
 
-func (r rpmDaoImpl) List(orgID string, repositoryConfigUUID string, limit int, offset int, search string, sortBy string) (api.RepositoryRpmCollectionResponse, int64, error) {
+func (r rpmDaoImpl) List(ctx context.Context, orgID string, repositoryConfigUUID string, limit int, offset int, search string, sortBy string) (api.RepositoryRpmCollectionResponse, int64, error) {
+	var response api.RepositoryRpmCollectionResponse
+	var total int64
+	err := readOnly(ctx, r.db, func(conn *gorm.DB) error {
+		var err error
+		response, total, err = rpmDaoImpl{db: conn}.list(ctx, orgID, repositoryConfigUUID, limit, offset, search, sortBy)
+		return err
+	})
+	return response, total, err
+}
+
+func (r rpmDaoImpl) list(ctx context.Context, orgID string, repositoryConfigUUID string, limit int, offset int, search string, sortBy string) (api.RepositoryRpmCollectionResponse, int64, error) {
 	// Check arguments
 	if orgID == "" {
 		return api.RepositoryRpmCollectionResponse{}, 0, fmt.Errorf("orgID can not be an empty string")
@@ -49,7 +65,7 @@ func (r rpmDaoImpl) List(orgID string, repositoryConfigUUID string, limit int, o
 	var totalRpms int64
 	repoRpms := []models.Rpm{}
 
-	if ok, err := r.isOwnedRepository(orgID, repositoryConfigUUID); !ok {
+	if ok, err := r.isOwnedRepository(ctx, orgID, repositoryConfigUUID); !ok {
 		if err != nil {
 			return api.RepositoryRpmCollectionResponse{},
 				totalRpms,
@@ -66,14 +82,14 @@ func (r rpmDaoImpl) List(orgID string, repositoryConfigUUID string, limit int, o
 	repositoryConfig := models.RepositoryConfiguration{}
 	// Select Repository from RepositoryConfig
 
-	if err := r.db.
+	if err := r.db.WithContext(ctx).
 		Preload("Repository").
 		Find(&repositoryConfig, "uuid = ?", repositoryConfigUUID).
 		Error; err != nil {
 		return api.RepositoryRpmCollectionResponse{}, totalRpms, err
 	}
 
-	filteredDB := r.db.Model(&repoRpms).Joins(strings.Join([]string{"inner join", models.TableNameRpmsRepositories, "on uuid = rpm_uuid"}, " ")).
+	filteredDB := r.db.WithContext(ctx).Model(&repoRpms).Joins(strings.Join([]string{"inner join", models.TableNameRpmsRepositories, "on uuid = rpm_uuid"}, " ")).
 		Where("repository_uuid = ?", repositoryConfig.Repository.UUID)
 
 	if search != "" {
@@ -144,7 +160,17 @@ func (r rpmDaoImpl) modelToApiFields(in *models.Rpm, out *api.RepositoryRpm) {
 	out.Checksum = in.Checksum
 }
 
-func (r rpmDaoImpl) Search(orgID string, request api.SearchRpmRequest) ([]api.SearchRpmResponse, error) {
+func (r rpmDaoImpl) Search(ctx context.Context, orgID string, request api.SearchRpmRequest) ([]api.SearchRpmResponse, error) {
+	var response []api.SearchRpmResponse
+	err := readOnly(ctx, r.db, func(conn *gorm.DB) error {
+		var err error
+		response, err = rpmDaoImpl{db: conn}.search(ctx, orgID, request)
+		return err
+	})
+	return response, err
+}
+
+func (r rpmDaoImpl) search(ctx context.Context, orgID string, request api.SearchRpmRequest) ([]api.SearchRpmResponse, error) {
 	// Retrieve the repository id list
 	if orgID == "" {
 		return nil, fmt.Errorf("orgID can not be an empty string")
@@ -170,34 +196,35 @@ func (r rpmDaoImpl) Search(orgID string, request api.SearchRpmRequest) ([]api.Se
 
 	// This implement the following SELECT statement:
 	//
-	// SELECT DISTINCT ON (rpms.name)
-	//        rpms.name, rpms.summary
-	// FROM rpms
-	//      inner join repositories_rpms on repositories_rpms.rpm_uuid = rpms.uuid
-	//      inner join repositories on repositories.uuid = repositories_rpms.repository_uuid
+	// SELECT DISTINCT ON (rpm_names.name)
+	//        rpm_names.name, rpm_names.summary
+	// FROM rpm_names
+	//      inner join repositories on repositories.uuid = rpm_names.repository_uuid
 	//      left join repository_configurations on repository_configurations.repository_uuid = repositories.uuid
 	// WHERE (repository_configurations.org_id = 'acme' OR repositories.public)
 	//       AND ( repositories.url in (...)
 	//             OR repository_configurations.uuid in (...)
 	//       )
-	//       AND rpms.name LIKE 'demo%'
-	// ORDER BY rpms.name, rpms.epoch DESC
+	//       AND rpm_names.name ILIKE '%demo%'
+	// ORDER BY rpm_names.name
 	// LIMIT 20;
+	//
+	// rpm_names holds one row per package name and repository, refreshed
+	// by InsertForRepository, so the rpms table is not scanned.
 
 	// https://github.com/go-gorm/gorm/issues/5318
 	dataResponse := []api.SearchRpmResponse{}
-	orGroupPublicOrPrivate := r.db.Where("repository_configurations.org_id = ?", orgID).Or("repositories.public")
-	db := r.db.
-		Select("DISTINCT ON(rpms.name) rpms.name as package_name", "rpms.summary").
-		Table(models.TableNameRpm).
-		Joins("inner join repositories_rpms on repositories_rpms.rpm_uuid = rpms.uuid").
-		Joins("inner join repositories on repositories.uuid = repositories_rpms.repository_uuid").
+	orGroupPublicOrPrivate := r.db.WithContext(ctx).Where("repository_configurations.org_id = ?", orgID).Or("repositories.public")
+	db := r.db.WithContext(ctx).
+		Select("DISTINCT ON(rpm_names.name) rpm_names.name as package_name", "rpm_names.summary").
+		Table(models.TableNameRpmName).
+		Joins("inner join repositories on repositories.uuid = rpm_names.repository_uuid").
 		Joins("left join repository_configurations on repository_configurations.repository_uuid = repositories.uuid").
 		Where(orGroupPublicOrPrivate).
-		Where("rpms.name ILIKE ?", fmt.Sprintf("%%%s%%", request.Search)).
-		Where(r.db.Where("repositories.url in ?", urls).
+		Where("rpm_names.name ILIKE ?", fmt.Sprintf("%%%s%%", request.Search)).
+		Where(r.db.WithContext(ctx).Where("repositories.url in ?", urls).
 			Or("repository_configurations.uuid in ?", uuids)).
-		Order("rpms.name ASC").
+		Order("rpm_names.name ASC").
 		Limit(*request.Limit).
 		Scan(&dataResponse)
 
@@ -208,9 +235,134 @@ func (r rpmDaoImpl) Search(orgID string, request api.SearchRpmRequest) ([]api.Se
 	return dataResponse, nil
 }
 
-func (r rpmDaoImpl) fetchRepo(uuid string) (models.Repository, error) {
+func (r rpmDaoImpl) SearchPackages(ctx context.Context, orgID string, request api.SearchPackageRequest) ([]api.SearchPackageResponse, error) {
+	var response []api.SearchPackageResponse
+	err := readOnly(ctx, r.db, func(conn *gorm.DB) error {
+		var err error
+		response, err = rpmDaoImpl{db: conn}.searchPackages(ctx, orgID, request)
+		return err
+	})
+	return response, err
+}
+
+// searchPackages looks up the rpms of the given repositories by exact NEVRA and by the
+// capabilities they provide or require.  Every criteria given must match.
+func (r rpmDaoImpl) searchPackages(ctx context.Context, orgID string, request api.SearchPackageRequest) ([]api.SearchPackageResponse, error) {
+	if orgID == "" {
+		return nil, fmt.Errorf("orgID can not be an empty string")
+	}
+	if len(request.URLs) == 0 && len(request.UUIDs) == 0 {
+		return nil, &ce.DaoError{BadValidation: true, Message: "must contain at least 1 URL or 1 UUID"}
+	}
+	if request.Nevra == "" && request.Provides == "" && request.Requires == "" {
+		return nil, &ce.DaoError{BadValidation: true, Message: "must contain a nevra, provides or requires search"}
+	}
+	if request.Limit == nil {
+		request.Limit = pointy.Int(api.SearchRpmRequestLimitDefault)
+	}
+	if *request.Limit > api.SearchRpmRequestLimitMaximum {
+		request.Limit = pointy.Int(api.SearchRpmRequestLimitMaximum)
+	}
+
+	urls := make([]string, len(request.URLs)*2)
+	for i, url := range request.URLs {
+		urls[i*2] = url
+		urls[i*2+1] = url + "/"
+	}
+
+	query := r.db.WithContext(ctx).
+		Select("rpms.name, rpms.arch, rpms.version, rpms.release, rpms.epoch, rpms.summary, rpms.checksum",
+			"repositories.url as repository_url", "repository_configurations.uuid as repository_uuid").
+		Table(models.TableNameRpm).
+		Joins("inner join repositories_rpms on repositories_rpms.rpm_uuid = rpms.uuid").
+		Joins("inner join repositories on repositories.uuid = repositories_rpms.repository_uuid").
+		Joins("left join repository_configurations on repository_configurations.repository_uuid = repositories.uuid"+
+			" AND repository_configurations.org_id = ? AND repository_configurations.deleted_at IS NULL", orgID).
+		Where("repository_configurations.uuid IS NOT NULL OR repositories.public").
+		Where(r.db.WithContext(ctx).Where("repositories.url in ?", urls).
+			Or("repository_configurations.uuid in ?", request.UUIDs))
+
+	if request.Nevra != "" {
+		nevra, err := parseNevra(request.Nevra)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("rpms.name = ? AND rpms.version = ? AND rpms.release = ? AND rpms.arch = ?",
+			nevra.name, nevra.version, nevra.release, nevra.arch)
+		if nevra.epoch != nil {
+			query = query.Where("rpms.epoch = ?", *nevra.epoch)
+		}
+	}
+	if request.Provides != "" {
+		query = query.Where("rpms.uuid in (?)", r.db.WithContext(ctx).Model(&models.RpmCapability{}).
+			Select("rpm_uuid").
+			Where("kind = ? AND name = ?", models.CapabilityProvides, request.Provides))
+	}
+	if request.Requires != "" {
+		query = query.Where("rpms.uuid in (?)", r.db.WithContext(ctx).Model(&models.RpmCapability{}).
+			Select("rpm_uuid").
+			Where("kind = ? AND name = ?", models.CapabilityRequires, request.Requires))
+	}
+
+	response := []api.SearchPackageResponse{}
+	if err := query.
+		Order("rpms.name ASC, rpms.epoch DESC, rpms.version DESC, rpms.release DESC, rpms.arch ASC, repositories.url ASC").
+		Limit(*request.Limit).
+		Scan(&response).Error; err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+type nevra struct {
+	name    string
+	epoch   *int32
+	version string
+	release string
+	arch    string
+}
+
+// parseNevra splits a name-[epoch:]version-release.arch string, such as
+// bash-0:5.1.8-6.el9.x86_64
+func parseNevra(in string) (nevra, error) {
+	var out nevra
+	invalid := &ce.DaoError{BadValidation: true, Message: "invalid nevra " + in + ", expected name-[epoch:]version-release.arch"}
+
+	dot := strings.LastIndex(in, ".")
+	if dot <= 0 || dot == len(in)-1 {
+		return out, invalid
+	}
+	out.arch = in[dot+1:]
+	rest := in[:dot]
+
+	dash := strings.LastIndex(rest, "-")
+	if dash <= 0 || dash == len(rest)-1 {
+		return out, invalid
+	}
+	out.release = rest[dash+1:]
+	rest = rest[:dash]
+
+	dash = strings.LastIndex(rest, "-")
+	if dash <= 0 || dash == len(rest)-1 {
+		return out, invalid
+	}
+	out.name = rest[:dash]
+	out.version = rest[dash+1:]
+
+	if colon := strings.Index(out.version, ":"); colon >= 0 {
+		epoch, err := strconv.ParseInt(out.version[:colon], 10, 32)
+		if err != nil || epoch < 0 || colon == len(out.version)-1 {
+			return out, invalid
+		}
+		out.epoch = pointy.Int32(int32(epoch))
+		out.version = out.version[colon+1:]
+	}
+	return out, nil
+}
+
+func (r rpmDaoImpl) fetchRepo(ctx context.Context, uuid string) (models.Repository, error) {
 	found := models.Repository{}
-	if err := r.db.
+	if err := r.db.WithContext(ctx).
 		Where("UUID = ?", uuid).
 		First(&found).
 		Error; err != nil {
@@ -219,11 +371,21 @@ func (r rpmDaoImpl) fetchRepo(uuid string) (models.Repository, error) {
 	return found, nil
 }
 
+// RpmInsertResult counts the rpms of a repository inserted by InsertForRepository
+type RpmInsertResult struct {
+	Inserted int64 // rpms inserted in the system, not present in any repository before
+	Skipped  int64 // rpms not inserted, already present in the system
+	Added    int64 // rpms associated to the repository, not associated to it before
+}
+
 // InsertForRepository inserts a set of yum packages for a given repository
 // and removes any that are not in the list.  This will involve inserting the RPMs
 // if not present, and adding or removing any associations to the Repository
-// Returns a count of new RPMs added to the system (not the repo), as well as any error
-func (r rpmDaoImpl) InsertForRepository(repoUuid string, pkgs []yum.Package) (int64, error) {
+// Inserts are done in batches of options.paged_rpm_inserts_limit rows.
+// Returns the counts of rpms inserted in the system, skipped as already present,
+// and newly associated to the repository, as well as any error
+func (r rpmDaoImpl) InsertForRepository(ctx context.Context, repoUuid string, pkgs []yum.Package) (RpmInsertResult, error) {
+	ctx = db.WithQueryClass(ctx, db.QueryBulk)
 	var (
 		err               error
 		repo              models.Repository
@@ -231,10 +393,14 @@ func (r rpmDaoImpl) InsertForRepository(repoUuid string, pkgs []yum.Package) (in
 	)
 
 	// Retrieve Repository record
-	if repo, err = r.fetchRepo(repoUuid); err != nil {
-		return 0, fmt.Errorf("failed to fetchRepo: %w", err)
+	if repo, err = r.fetchRepo(ctx, repoUuid); err != nil {
+		return RpmInsertResult{}, fmt.Errorf("failed to fetchRepo: %w", err)
 	}
 
+	// Duplicated entries in the metadata would otherwise be inserted twice in the same batch
+	pkgs = dedupePackages(pkgs)
+	batchSize := rpmInsertBatchSize()
+
 	// Build the list of checksums from the provided packages
 	checksums := make([]string, len(pkgs))
 	for i := 0; i < len(pkgs); i++ {
@@ -243,11 +409,15 @@ func (r rpmDaoImpl) InsertForRepository(repoUuid string, pkgs []yum.Package) (in
 
 	// Given the list of checksums, retrieve the list of the ones that exists
 	// in the 'rpm' table (whatever is the repository that it could belong)
-	if err = r.db.
-		Where("checksum in (?)", checksums).
-		Model(&models.Rpm{}).
-		Pluck("checksum", &existingChecksums).Error; err != nil {
-		return 0, fmt.Errorf("failed retrieving existing checksum in rpms: %w", err)
+	for _, chunk := range chunkStrings(checksums, batchSize) {
+		var found []string
+		if err = r.db.WithContext(ctx).
+			Where("checksum in (?)", chunk).
+			Model(&models.Rpm{}).
+			Pluck("checksum", &found).Error; err != nil {
+			return RpmInsertResult{}, fmt.Errorf("failed retrieving existing checksum in rpms: %w", err)
+		}
+		existingChecksums = append(existingChecksums, found...)
 	}
 
 	// Given a slice of yum.Package, it filters the ones which checksum exists
@@ -255,39 +425,227 @@ func (r rpmDaoImpl) InsertForRepository(repoUuid string, pkgs []yum.Package) (in
 	dbPkgs := FilteredConvert(pkgs, existingChecksums)
 
 	// Insert the filtered packages in rpms table
-	result := r.db.Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "checksum"}},
-		DoNothing: true,
-	}).Create(dbPkgs)
-	if result.Error != nil {
-		return 0, fmt.Errorf("failed to PagedRpmInsert: %w", err)
+	result := RpmInsertResult{}
+	if len(dbPkgs) > 0 {
+		inserted := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "checksum"}},
+			DoNothing: true,
+		}).CreateInBatches(dbPkgs, batchSize)
+		if inserted.Error != nil {
+			return RpmInsertResult{}, fmt.Errorf("failed to PagedRpmInsert: %w", inserted.Error)
+		}
+		result.Inserted = inserted.RowsAffected
 	}
+	result.Skipped = int64(len(pkgs)) - result.Inserted
 
 	// Now fetch the uuids of all the rpms we want associated to the repository
 	var rpmUuids []string
-	if err = r.db.
-		Where("checksum in (?)", checksums).
-		Model(&models.Rpm{}).
-		Pluck("uuid", &rpmUuids).Error; err != nil {
-		return 0, fmt.Errorf("failed retrieving rpms.uuid for the package checksums: %w", err)
+	for _, chunk := range chunkStrings(checksums, batchSize) {
+		var found []string
+		if err = r.db.WithContext(ctx).
+			Where("checksum in (?)", chunk).
+			Model(&models.Rpm{}).
+			Pluck("uuid", &found).Error; err != nil {
+			return RpmInsertResult{}, fmt.Errorf("failed retrieving rpms.uuid for the package checksums: %w", err)
+		}
+		rpmUuids = append(rpmUuids, found...)
 	}
 
 	// Delete Rpm and RepositoryRpm entries we don't need
-	if err = r.deleteUnneeded(repo, rpmUuids); err != nil {
-		return 0, fmt.Errorf("failed to deleteUnneeded: %w", err)
+	if err = r.deleteUnneeded(ctx, repo, rpmUuids); err != nil {
+		return RpmInsertResult{}, fmt.Errorf("failed to deleteUnneeded: %w", err)
 	}
 
 	// Add the RepositoryRpm entries we do need
 	associations := prepRepositoryRpms(repo, rpmUuids)
-	result = r.db.Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "repository_uuid"}, {Name: "rpm_uuid"}},
-		DoNothing: true}).
-		Create(&associations)
-	if result.Error != nil {
-		return result.RowsAffected, fmt.Errorf("failed to Create: %w", result.Error)
+	if len(associations) > 0 {
+		added := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "repository_uuid"}, {Name: "rpm_uuid"}},
+			DoNothing: true}).
+			CreateInBatches(&associations, batchSize)
+		result.Added = added.RowsAffected
+		if added.Error != nil {
+			return result, fmt.Errorf("failed to Create: %w", added.Error)
+		}
+	}
+
+	if err = r.refreshNames(ctx, repo.UUID); err != nil {
+		return result, fmt.Errorf("failed to refreshNames: %w", err)
+	}
+
+	return result, nil
+}
+
+// PackageChanges compares the packages of the repository with a set of yum packages about to replace them,
+// as done by InsertForRepository, and counts the packages added, removed and updated by name and architecture
+func (r rpmDaoImpl) PackageChanges(ctx context.Context, repoUuid string, pkgs []yum.Package) (models.PackageChanges, error) {
+	ctx = db.WithQueryClass(ctx, db.QueryBulk)
+	var existing []models.Rpm
+	if err := r.db.WithContext(ctx).
+		Select("rpms.name, rpms.arch, rpms.epoch, rpms.version, rpms.release").
+		Joins("INNER JOIN repositories_rpms ON repositories_rpms.rpm_uuid = rpms.uuid").
+		Where("repositories_rpms.repository_uuid = ?", repoUuid).
+		Find(&existing).Error; err != nil {
+		return models.PackageChanges{}, fmt.Errorf("failed retrieving the rpms of the repository: %w", err)
+	}
+	return packageChanges(existing, pkgs), nil
+}
+
+// packageChanges counts the packages, by name and architecture, found only in pkgs as added, those found only
+// in existing as removed, and those found in both with a different set of versions as updated
+func packageChanges(existing []models.Rpm, pkgs []yum.Package) models.PackageChanges {
+	type nameArch struct {
+		name, arch string
+	}
+	versions := func() map[nameArch]map[string]struct{} {
+		return make(map[nameArch]map[string]struct{})
+	}
+	addVersion := func(m map[nameArch]map[string]struct{}, key nameArch, version string) {
+		if m[key] == nil {
+			m[key] = make(map[string]struct{})
+		}
+		m[key][version] = struct{}{}
+	}
+
+	before := versions()
+	for _, rpm := range existing {
+		addVersion(before, nameArch{rpm.Name, rpm.Arch}, fmt.Sprintf("%d:%s-%s", rpm.Epoch, rpm.Version, rpm.Release))
+	}
+	after := versions()
+	for _, pkg := range pkgs {
+		addVersion(after, nameArch{pkg.Name, pkg.Arch}, fmt.Sprintf("%d:%s-%s", pkg.Version.Epoch, pkg.Version.Version, pkg.Version.Release))
 	}
 
-	return result.RowsAffected, err
+	changes := models.PackageChanges{}
+	for key, afterVersions := range after {
+		beforeVersions, found := before[key]
+		if !found {
+			changes.Added++
+			continue
+		}
+		if len(beforeVersions) != len(afterVersions) {
+			changes.Updated++
+			continue
+		}
+		for version := range afterVersions {
+			if _, found := beforeVersions[version]; !found {
+				changes.Updated++
+				break
+			}
+		}
+	}
+	for key := range before {
+		if _, found := after[key]; !found {
+			changes.Removed++
+		}
+	}
+	return changes
+}
+
+// refreshNames rebuilds the rpm_names rows of a repository from its rpms,
+// keeping the summary of the highest epoch for each package name
+func (r rpmDaoImpl) refreshNames(ctx context.Context, repoUuid string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Where("repository_uuid = ?", repoUuid).
+			Delete(&models.RpmName{}).
+			Error; err != nil {
+			return err
+		}
+		return tx.Exec(`
+			INSERT INTO rpm_names (repository_uuid, name, summary)
+			SELECT DISTINCT ON (rpms.name) repositories_rpms.repository_uuid, rpms.name, rpms.summary
+			FROM rpms
+			     INNER JOIN repositories_rpms ON repositories_rpms.rpm_uuid = rpms.uuid
+			WHERE repositories_rpms.repository_uuid = ?
+			ORDER BY rpms.name, rpms.epoch DESC`, repoUuid).
+			Error
+	})
+}
+
+// InsertCapabilities stores the capabilities of rpms, given by rpm checksum.  An rpm
+// never changes once inserted, so rpms already having capabilities are skipped.
+func (r rpmDaoImpl) InsertCapabilities(ctx context.Context, capabilities map[string][]models.RpmCapability) error {
+	ctx = db.WithQueryClass(ctx, db.QueryBulk)
+	checksums := make([]string, 0, len(capabilities))
+	for checksum, pkgCapabilities := range capabilities {
+		if len(pkgCapabilities) > 0 {
+			checksums = append(checksums, checksum)
+		}
+	}
+	batchSize := rpmInsertBatchSize()
+
+	for _, chunk := range chunkStrings(checksums, batchSize) {
+		var rpms []models.Rpm
+		if err := r.db.WithContext(ctx).
+			Select("uuid", "checksum").
+			Where("checksum in (?)", chunk).
+			Where("NOT EXISTS (SELECT 1 FROM rpm_capabilities WHERE rpm_capabilities.rpm_uuid = rpms.uuid)").
+			Find(&rpms).Error; err != nil {
+			return fmt.Errorf("failed retrieving rpms without capabilities: %w", err)
+		}
+
+		var rows []models.RpmCapability
+		for _, rpm := range rpms {
+			for _, capability := range capabilities[rpm.Checksum] {
+				capability.RpmUUID = rpm.UUID
+				rows = append(rows, capability)
+			}
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		if err := r.db.WithContext(ctx).CreateInBatches(&rows, batchSize).Error; err != nil {
+			return fmt.Errorf("failed to insert rpm capabilities: %w", err)
+		}
+	}
+	return nil
+}
+
+// rpmInsertBatchSize returns the configured number of rows per insert
+func rpmInsertBatchSize() int {
+	if size := config.Get().Options.PagedRpmInsertsLimit; size > 0 {
+		return size
+	}
+	return config.DefaultPagedRpmInsertsLimit
+}
+
+// dedupePackages removes packages with the same name, epoch, version, release, arch and checksum
+func dedupePackages(pkgs []yum.Package) []yum.Package {
+	type nevraChecksum struct {
+		name, version, release, arch, checksum string
+		epoch                                  int32
+	}
+	seen := make(map[nevraChecksum]struct{}, len(pkgs))
+	deduped := make([]yum.Package, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		key := nevraChecksum{
+			name:     pkg.Name,
+			epoch:    pkg.Version.Epoch,
+			version:  pkg.Version.Version,
+			release:  pkg.Version.Release,
+			arch:     pkg.Arch,
+			checksum: pkg.Checksum.Value,
+		}
+		if _, found := seen[key]; found {
+			continue
+		}
+		seen[key] = struct{}{}
+		deduped = append(deduped, pkg)
+	}
+	return deduped
+}
+
+// chunkStrings splits a slice into slices of at most size elements
+func chunkStrings(list []string, size int) [][]string {
+	var chunks [][]string
+	for size < len(list) {
+		list, chunks = list[size:], append(chunks, list[0:size:size])
+	}
+	if len(list) > 0 {
+		chunks = append(chunks, list)
+	}
+	return chunks
 }
 
 // prepRepositoryRpms  converts a list of rpm_uuids to a list of RepositoryRpm Objects
@@ -316,14 +674,14 @@ func difference(a, b []string) []string {
 }
 
 // deleteUnneeded Removes any RepositoryRpm entries that are not in the list of rpm_uuids
-func (r rpmDaoImpl) deleteUnneeded(repo models.Repository, rpm_uuids []string) error {
+func (r rpmDaoImpl) deleteUnneeded(ctx context.Context, repo models.Repository, rpm_uuids []string) error {
 	// First get uuids that are there:
 	var (
 		existing_rpm_uuids []string
 	)
 
 	// Read existing rpm_uuid associated to repository_uuid
-	if err := r.db.Model(&models.RepositoryRpm{}).
+	if err := r.db.WithContext(ctx).Model(&models.RepositoryRpm{}).
 		Where("repository_uuid = ?", repo.UUID).
 		Pluck("rpm_uuid", &existing_rpm_uuids).
 		Error; err != nil {
@@ -333,7 +691,7 @@ func (r rpmDaoImpl) deleteUnneeded(repo models.Repository, rpm_uuids []string) e
 	rpmsToDelete := difference(existing_rpm_uuids, rpm_uuids)
 
 	// Delete the many2many relationship for the unneeded rpms
-	if err := r.db.
+	if err := r.db.WithContext(ctx).
 		Unscoped().
 		Where("repositories_rpms.repository_uuid = ?", repo.UUID).
 		Where("repositories_rpms.rpm_uuid in (?)", rpmsToDelete).
@@ -345,11 +703,12 @@ func (r rpmDaoImpl) deleteUnneeded(repo models.Repository, rpm_uuids []string) e
 	return nil
 }
 
-func (r rpmDaoImpl) OrphanCleanup() error {
+func (r rpmDaoImpl) OrphanCleanup(ctx context.Context) error {
+	ctx = db.WithQueryClass(ctx, db.QueryBulk)
 	var danglingRpmUuids []string
 
 	// Retrieve dangling rpms.uuid
-	if err := r.db.
+	if err := r.db.WithContext(ctx).
 		Model(&models.Rpm{}).
 		Where("repositories_rpms.rpm_uuid is NULL").
 		Joins("left join repositories_rpms on rpms.uuid = repositories_rpms.rpm_uuid").
@@ -363,7 +722,7 @@ func (r rpmDaoImpl) OrphanCleanup() error {
 	}
 
 	// Remove dangling rpms
-	if err := r.db.
+	if err := r.db.WithContext(ctx).
 		Where("rpms.uuid in (?)", danglingRpmUuids).
 		Delete(&models.Rpm{}).
 		Error; err != nil {
@@ -385,8 +744,12 @@ func stringInSlice(a string, list []string) bool {
 // while filtering out any checksums that are in the excludedChecksums parameter
 func FilteredConvert(yumPkgs []yum.Package, excludeChecksums []string) []models.Rpm {
 	var dbPkgs []models.Rpm
+	excluded := make(map[string]struct{}, len(excludeChecksums))
+	for _, checksum := range excludeChecksums {
+		excluded[checksum] = struct{}{}
+	}
 	for _, yumPkg := range yumPkgs {
-		if !stringInSlice(yumPkg.Checksum.Value, excludeChecksums) {
+		if _, found := excluded[yumPkg.Checksum.Value]; !found {
 			epoch := yumPkg.Version.Epoch
 			dbPkgs = append(dbPkgs, models.Rpm{
 				Name:     yumPkg.Name,
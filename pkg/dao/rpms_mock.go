@@ -3,9 +3,13 @@
 package dao
 
 import (
+	context "context"
+
 	api "github.com/content-services/content-sources-backend/pkg/api"
 	mock "github.com/stretchr/testify/mock"
 
+	models "github.com/content-services/content-sources-backend/pkg/models"
+
 	yum "github.com/content-services/yummy/pkg/yum"
 )
 
@@ -14,23 +18,23 @@ type MockRpmDao struct {
 	mock.Mock
 }
 
-// InsertForRepository provides a mock function with given fields: repoUuid, pkgs
-func (_m *MockRpmDao) InsertForRepository(repoUuid string, pkgs []yum.Package) (int64, error) {
-	ret := _m.Called(repoUuid, pkgs)
+// DependencyClosure provides a mock function with given fields: ctx, orgID, request
+func (_m *MockRpmDao) DependencyClosure(ctx context.Context, orgID string, request api.DependencyClosureRequest) (api.DependencyClosureResponse, error) {
+	ret := _m.Called(ctx, orgID, request)
 
-	var r0 int64
+	var r0 api.DependencyClosureResponse
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, []yum.Package) (int64, error)); ok {
-		return rf(repoUuid, pkgs)
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.DependencyClosureRequest) (api.DependencyClosureResponse, error)); ok {
+		return rf(ctx, orgID, request)
 	}
-	if rf, ok := ret.Get(0).(func(string, []yum.Package) int64); ok {
-		r0 = rf(repoUuid, pkgs)
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.DependencyClosureRequest) api.DependencyClosureResponse); ok {
+		r0 = rf(ctx, orgID, request)
 	} else {
-		r0 = ret.Get(0).(int64)
+		r0 = ret.Get(0).(api.DependencyClosureResponse)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, []yum.Package) error); ok {
-		r1 = rf(repoUuid, pkgs)
+	if rf, ok := ret.Get(1).(func(context.Context, string, api.DependencyClosureRequest) error); ok {
+		r1 = rf(ctx, orgID, request)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -38,30 +42,68 @@ func (_m *MockRpmDao) InsertForRepository(repoUuid string, pkgs []yum.Package) (
 	return r0, r1
 }
 
-// List provides a mock function with given fields: orgID, uuidRepo, limit, offset, search, sortBy
-func (_m *MockRpmDao) List(orgID string, uuidRepo string, limit int, offset int, search string, sortBy string) (api.RepositoryRpmCollectionResponse, int64, error) {
-	ret := _m.Called(orgID, uuidRepo, limit, offset, search, sortBy)
+// InsertCapabilities provides a mock function with given fields: ctx, capabilities
+func (_m *MockRpmDao) InsertCapabilities(ctx context.Context, capabilities map[string][]models.RpmCapability) error {
+	ret := _m.Called(ctx, capabilities)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, map[string][]models.RpmCapability) error); ok {
+		r0 = rf(ctx, capabilities)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertForRepository provides a mock function with given fields: ctx, repoUuid, pkgs
+func (_m *MockRpmDao) InsertForRepository(ctx context.Context, repoUuid string, pkgs []yum.Package) (RpmInsertResult, error) {
+	ret := _m.Called(ctx, repoUuid, pkgs)
+
+	var r0 RpmInsertResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []yum.Package) (RpmInsertResult, error)); ok {
+		return rf(ctx, repoUuid, pkgs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []yum.Package) RpmInsertResult); ok {
+		r0 = rf(ctx, repoUuid, pkgs)
+	} else {
+		r0 = ret.Get(0).(RpmInsertResult)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []yum.Package) error); ok {
+		r1 = rf(ctx, repoUuid, pkgs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: ctx, orgID, uuidRepo, limit, offset, search, sortBy
+func (_m *MockRpmDao) List(ctx context.Context, orgID string, uuidRepo string, limit int, offset int, search string, sortBy string) (api.RepositoryRpmCollectionResponse, int64, error) {
+	ret := _m.Called(ctx, orgID, uuidRepo, limit, offset, search, sortBy)
 
 	var r0 api.RepositoryRpmCollectionResponse
 	var r1 int64
 	var r2 error
-	if rf, ok := ret.Get(0).(func(string, string, int, int, string, string) (api.RepositoryRpmCollectionResponse, int64, error)); ok {
-		return rf(orgID, uuidRepo, limit, offset, search, sortBy)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int, int, string, string) (api.RepositoryRpmCollectionResponse, int64, error)); ok {
+		return rf(ctx, orgID, uuidRepo, limit, offset, search, sortBy)
 	}
-	if rf, ok := ret.Get(0).(func(string, string, int, int, string, string) api.RepositoryRpmCollectionResponse); ok {
-		r0 = rf(orgID, uuidRepo, limit, offset, search, sortBy)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int, int, string, string) api.RepositoryRpmCollectionResponse); ok {
+		r0 = rf(ctx, orgID, uuidRepo, limit, offset, search, sortBy)
 	} else {
 		r0 = ret.Get(0).(api.RepositoryRpmCollectionResponse)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string, int, int, string, string) int64); ok {
-		r1 = rf(orgID, uuidRepo, limit, offset, search, sortBy)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int, int, string, string) int64); ok {
+		r1 = rf(ctx, orgID, uuidRepo, limit, offset, search, sortBy)
 	} else {
 		r1 = ret.Get(1).(int64)
 	}
 
-	if rf, ok := ret.Get(2).(func(string, string, int, int, string, string) error); ok {
-		r2 = rf(orgID, uuidRepo, limit, offset, search, sortBy)
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, int, int, string, string) error); ok {
+		r2 = rf(ctx, orgID, uuidRepo, limit, offset, search, sortBy)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -69,13 +111,37 @@ func (_m *MockRpmDao) List(orgID string, uuidRepo string, limit int, offset int,
 	return r0, r1, r2
 }
 
-// OrphanCleanup provides a mock function with given fields:
-func (_m *MockRpmDao) OrphanCleanup() error {
-	ret := _m.Called()
+// OfficialOverlaps provides a mock function with given fields: ctx, orgID, repositoryConfigUUID
+func (_m *MockRpmDao) OfficialOverlaps(ctx context.Context, orgID string, repositoryConfigUUID string) (api.OfficialOverlapsResponse, error) {
+	ret := _m.Called(ctx, orgID, repositoryConfigUUID)
+
+	var r0 api.OfficialOverlapsResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (api.OfficialOverlapsResponse, error)); ok {
+		return rf(ctx, orgID, repositoryConfigUUID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) api.OfficialOverlapsResponse); ok {
+		r0 = rf(ctx, orgID, repositoryConfigUUID)
+	} else {
+		r0 = ret.Get(0).(api.OfficialOverlapsResponse)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, orgID, repositoryConfigUUID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// OrphanCleanup provides a mock function with given fields: ctx
+func (_m *MockRpmDao) OrphanCleanup(ctx context.Context) error {
+	ret := _m.Called(ctx)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func() error); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -83,25 +149,75 @@ func (_m *MockRpmDao) OrphanCleanup() error {
 	return r0
 }
 
-// Search provides a mock function with given fields: orgID, request
-func (_m *MockRpmDao) Search(orgID string, request api.SearchRpmRequest) ([]api.SearchRpmResponse, error) {
-	ret := _m.Called(orgID, request)
+// PackageChanges provides a mock function with given fields: ctx, repoUuid, pkgs
+func (_m *MockRpmDao) PackageChanges(ctx context.Context, repoUuid string, pkgs []yum.Package) (models.PackageChanges, error) {
+	ret := _m.Called(ctx, repoUuid, pkgs)
+
+	var r0 models.PackageChanges
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []yum.Package) (models.PackageChanges, error)); ok {
+		return rf(ctx, repoUuid, pkgs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []yum.Package) models.PackageChanges); ok {
+		r0 = rf(ctx, repoUuid, pkgs)
+	} else {
+		r0 = ret.Get(0).(models.PackageChanges)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []yum.Package) error); ok {
+		r1 = rf(ctx, repoUuid, pkgs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Search provides a mock function with given fields: ctx, orgID, request
+func (_m *MockRpmDao) Search(ctx context.Context, orgID string, request api.SearchRpmRequest) ([]api.SearchRpmResponse, error) {
+	ret := _m.Called(ctx, orgID, request)
 
 	var r0 []api.SearchRpmResponse
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, api.SearchRpmRequest) ([]api.SearchRpmResponse, error)); ok {
-		return rf(orgID, request)
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.SearchRpmRequest) ([]api.SearchRpmResponse, error)); ok {
+		return rf(ctx, orgID, request)
 	}
-	if rf, ok := ret.Get(0).(func(string, api.SearchRpmRequest) []api.SearchRpmResponse); ok {
-		r0 = rf(orgID, request)
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.SearchRpmRequest) []api.SearchRpmResponse); ok {
+		r0 = rf(ctx, orgID, request)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]api.SearchRpmResponse)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, api.SearchRpmRequest) error); ok {
-		r1 = rf(orgID, request)
+	if rf, ok := ret.Get(1).(func(context.Context, string, api.SearchRpmRequest) error); ok {
+		r1 = rf(ctx, orgID, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SearchPackages provides a mock function with given fields: ctx, orgID, request
+func (_m *MockRpmDao) SearchPackages(ctx context.Context, orgID string, request api.SearchPackageRequest) ([]api.SearchPackageResponse, error) {
+	ret := _m.Called(ctx, orgID, request)
+
+	var r0 []api.SearchPackageResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.SearchPackageRequest) ([]api.SearchPackageResponse, error)); ok {
+		return rf(ctx, orgID, request)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.SearchPackageRequest) []api.SearchPackageResponse); ok {
+		r0 = rf(ctx, orgID, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]api.SearchPackageResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, api.SearchPackageRequest) error); ok {
+		r1 = rf(ctx, orgID, request)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -1,6 +1,8 @@
 package dao
 
 import (
+	"context"
+	"sort"
 	"strings"
 	"testing"
 
@@ -88,23 +90,23 @@ func (s *RpmSuite) TestRpmList() {
 
 	var repoRpmList api.RepositoryRpmCollectionResponse
 	var count int64
-	repoRpmList, count, err = dao.List(orgIDTest, s.repoConfig.Base.UUID, 10, 0, "", "")
+	repoRpmList, count, err = dao.List(context.Background(), orgIDTest, s.repoConfig.Base.UUID, 10, 0, "", "")
 	assert.NoError(t, err)
 	assert.Equal(t, count, int64(2))
 	assert.Equal(t, repoRpmList.Meta.Count, count)
 	assert.Equal(t, repoRpmList.Data[0].Name, repoRpmTest2.Name) // Asserts name:asc by default
 
-	repoRpmList, count, err = dao.List(orgIDTest, s.repoConfig.Base.UUID, 10, 0, "test-package", "")
+	repoRpmList, count, err = dao.List(context.Background(), orgIDTest, s.repoConfig.Base.UUID, 10, 0, "test-package", "")
 	assert.NoError(t, err)
 	assert.Equal(t, count, int64(1))
 	assert.Equal(t, repoRpmList.Meta.Count, count)
 
-	repoRpmList, count, err = dao.List(orgIDTest, s.repoConfig.Base.UUID, 10, 0, "", "name:desc")
+	repoRpmList, count, err = dao.List(context.Background(), orgIDTest, s.repoConfig.Base.UUID, 10, 0, "", "name:desc")
 	assert.NoError(t, err)
 	assert.Equal(t, count, int64(2))
 	assert.Equal(t, repoRpmList.Data[0].Name, repoRpmTest1.Name) // Asserts name:desc
 
-	repoRpmList, count, err = dao.List(orgIDTest, s.repoConfig.Base.UUID, 10, 0, "non-existing-repo", "")
+	repoRpmList, count, err = dao.List(context.Background(), orgIDTest, s.repoConfig.Base.UUID, 10, 0, "non-existing-repo", "")
 	assert.NoError(t, err)
 	assert.Equal(t, count, int64(0))
 }
@@ -113,7 +115,7 @@ func (s *RpmSuite) TestRpmListRepoNotFound() {
 	t := s.Suite.T()
 	dao := GetRpmDao(s.tx)
 
-	_, count, err := dao.List(orgIDTest, uuid.NewString(), 10, 0, "", "")
+	_, count, err := dao.List(context.Background(), orgIDTest, uuid.NewString(), 10, 0, "", "")
 	assert.Equal(t, count, int64(0))
 	assert.Error(t, err)
 	daoError, ok := err.(*ce.DaoError)
@@ -129,7 +131,7 @@ func (s *RpmSuite) TestRpmListRepoNotFound() {
 	}).Error
 	assert.NoError(t, err)
 
-	_, count, err = dao.List(seeds.RandomOrgId(), s.repoConfig.Base.UUID, 10, 0, "", "")
+	_, count, err = dao.List(context.Background(), seeds.RandomOrgId(), s.repoConfig.Base.UUID, 10, 0, "", "")
 	assert.Equal(t, count, int64(0))
 	assert.Error(t, err)
 	daoError, ok = err.(*ce.DaoError)
@@ -215,6 +217,10 @@ func (s *RpmSuite) TestRpmSearch() {
 	repositoriesRpms[7].RpmUUID = rpms[3].Base.UUID
 	err = tx.Create(&repositoriesRpms).Error
 	require.NoError(t, err)
+	for _, repository := range repositories {
+		err = rpmDaoImpl{db: tx}.refreshNames(context.Background(), repository.Base.UUID)
+		require.NoError(t, err)
+	}
 
 	uuids := []string{
 		repositoryConfigurations[0].Base.UUID,
@@ -456,7 +462,7 @@ func (s *RpmSuite) TestRpmSearch() {
 	for ict, caseTest := range testCases {
 		t.Log(caseTest.name)
 		var searchRpmResponse []api.SearchRpmResponse
-		searchRpmResponse, err = dao.Search(caseTest.given.orgId, caseTest.given.input)
+		searchRpmResponse, err = dao.Search(context.Background(), caseTest.given.orgId, caseTest.given.input)
 		require.NoError(t, err)
 		assert.Equal(t, len(caseTest.expected), len(searchRpmResponse))
 		for i, expected := range caseTest.expected {
@@ -564,13 +570,13 @@ func (s *RpmSuite) TestRpmSearchError() {
 	// the state previous to the error to let the test do more actions
 	tx.SavePoint(txSP)
 
-	searchRpmResponse, err = dao.Search("", api.SearchRpmRequest{Search: "", URLs: []string{"https:/noreturn.org"}, Limit: pointy.Int(100)})
+	searchRpmResponse, err = dao.Search(context.Background(), "", api.SearchRpmRequest{Search: "", URLs: []string{"https:/noreturn.org"}, Limit: pointy.Int(100)})
 	require.Error(t, err)
 	assert.Equal(t, int(0), len(searchRpmResponse))
 	assert.Equal(t, err.Error(), "orgID can not be an empty string")
 	tx.RollbackTo(txSP)
 
-	searchRpmResponse, err = dao.Search(orgIDTest, api.SearchRpmRequest{Search: "", Limit: pointy.Int(100)})
+	searchRpmResponse, err = dao.Search(context.Background(), orgIDTest, api.SearchRpmRequest{Search: "", Limit: pointy.Int(100)})
 	require.Error(t, err)
 	assert.Equal(t, int(0), len(searchRpmResponse))
 	assert.Equal(t, err.Error(), "must contain at least 1 URL or 1 UUID")
@@ -612,7 +618,7 @@ func (s *RpmSuite) genericInsertForRepository(testCase TestInsertForRepositoryCa
 	dao := GetRpmDao(tx)
 
 	p := s.prepareScenarioRpms(testCase.given, 10)
-	records, err := dao.InsertForRepository(s.repo.Base.UUID, p)
+	result, err := dao.InsertForRepository(context.Background(), s.repo.Base.UUID, p)
 
 	var rpmCount int = 0
 	tx.Select("count(*) as rpm_count").
@@ -627,8 +633,9 @@ func (s *RpmSuite) genericInsertForRepository(testCase TestInsertForRepositoryCa
 		assert.Contains(t, err.Error(), testCase.expected)
 	} else {
 		assert.NoError(t, err)
-		assert.Equal(t, int64(len(p)), records)
-		assert.Equal(t, int64(rpmCount), records)
+		assert.Equal(t, int64(len(p)), result.Added)
+		assert.Equal(t, int64(rpmCount), result.Added)
+		assert.Equal(t, RpmInsertResult{Inserted: int64(len(p)), Skipped: 0, Added: int64(len(p))}, result)
 	}
 }
 
@@ -670,37 +677,379 @@ func (s *RpmSuite) TestInsertForRepositoryWithExistingChecksums() {
 
 	dao := GetRpmDao(tx)
 	p := s.prepareScenarioRpms(scenarioThreshold, pagedRpmInsertsLimit)
-	records, err := dao.InsertForRepository(s.repo.Base.UUID, p[0:groupCount])
+	result, err := dao.InsertForRepository(context.Background(), s.repo.Base.UUID, p[0:groupCount])
 	assert.NoError(t, err)
-	assert.Equal(t, int64(len(p[0:groupCount])), records)
+	assert.Equal(t, RpmInsertResult{Inserted: int64(groupCount), Skipped: 0, Added: int64(groupCount)}, result)
 	rpm_count, err = repoRpmCount(tx, s.repo.UUID)
 	assert.NoError(t, err)
 	assert.Equal(t, int64(len(p[0:groupCount])), rpm_count)
 
-	records, err = dao.InsertForRepository(s.repo.Base.UUID, p[groupCount:])
+	result, err = dao.InsertForRepository(context.Background(), s.repo.Base.UUID, p[groupCount:])
 	assert.NoError(t, err)
-	assert.Equal(t, int64(len(p[groupCount:])), records)
+	assert.Equal(t, int64(len(p[groupCount:])), result.Added)
+	assert.Equal(t, int64(len(p[groupCount:])), result.Inserted)
 	rpm_count, err = repoRpmCount(tx, s.repo.UUID)
 	assert.NoError(t, err)
 	assert.Equal(t, int64(len(p[groupCount:])), rpm_count)
 
-	records, err = dao.InsertForRepository(s.repoPrivate.Base.UUID, p[1:groupCount+1])
+	// The rpms are already in the system, they are only associated to the other repository
+	result, err = dao.InsertForRepository(context.Background(), s.repoPrivate.Base.UUID, p[1:groupCount+1])
 	assert.NoError(t, err)
-
-	assert.Equal(t, int64(groupCount), records)
+	assert.Equal(t, RpmInsertResult{Inserted: 0, Skipped: int64(groupCount), Added: int64(groupCount)}, result)
 	rpm_count, err = repoRpmCount(tx, s.repoPrivate.UUID)
 	assert.NoError(t, err)
 	assert.Equal(t, int64(len(p[1:groupCount+1])), rpm_count)
 
-	records, err = dao.InsertForRepository(s.repoPrivate.Base.UUID, p[1:groupCount+1])
+	result, err = dao.InsertForRepository(context.Background(), s.repoPrivate.Base.UUID, p[1:groupCount+1])
 	assert.NoError(t, err)
-	assert.Equal(t, int64(0), records) // Rpms have already been inserted
+	assert.Equal(t, RpmInsertResult{Inserted: 0, Skipped: int64(groupCount), Added: 0}, result) // Rpms have already been inserted
 
 	rpm_count, err = repoRpmCount(tx, s.repoPrivate.Base.UUID)
 	assert.NoError(t, err)
 	assert.Equal(t, int64(len(p[1:groupCount+1])), rpm_count)
 }
 
+func (s *RpmSuite) TestInsertForRepositoryRefreshesNames() {
+	t := s.Suite.T()
+	tx := s.tx
+
+	dao := GetRpmDao(tx)
+	p := s.prepareScenarioRpms(scenario3, 10)
+	_, err := dao.InsertForRepository(context.Background(), s.repo.Base.UUID, p)
+	require.NoError(t, err)
+
+	var names []string
+	err = tx.Model(&models.RpmName{}).
+		Where("repository_uuid = ?", s.repo.Base.UUID).
+		Order("name").
+		Pluck("name", &names).Error
+	require.NoError(t, err)
+	expected := []string{p[0].Name, p[1].Name, p[2].Name}
+	sort.Strings(expected)
+	assert.Equal(t, expected, names)
+
+	// Names of packages removed from the repository are removed as well
+	_, err = dao.InsertForRepository(context.Background(), s.repo.Base.UUID, p[0:1])
+	require.NoError(t, err)
+	err = tx.Model(&models.RpmName{}).
+		Where("repository_uuid = ?", s.repo.Base.UUID).
+		Pluck("name", &names).Error
+	require.NoError(t, err)
+	assert.Equal(t, []string{p[0].Name}, names)
+}
+
+func (s *RpmSuite) TestPackageChanges() {
+	t := s.Suite.T()
+	dao := GetRpmDao(s.tx)
+	p := s.prepareScenarioRpms(scenario3, 10)
+	_, err := dao.InsertForRepository(context.Background(), s.repo.Base.UUID, p)
+	require.NoError(t, err)
+
+	// The first package is removed, the second updated, and a new one added
+	updated := p[1]
+	updated.Version.Release = updated.Version.Release + ".1"
+	added := p[2]
+	added.Name = added.Name + "-new"
+	changes, err := dao.PackageChanges(context.Background(), s.repo.Base.UUID, []yum.Package{updated, p[2], added})
+	require.NoError(t, err)
+	assert.Equal(t, models.PackageChanges{Added: 1, Removed: 1, Updated: 1}, changes)
+}
+
+func (s *RpmSuite) TestSearchPackages() {
+	t := s.Suite.T()
+	tx := s.tx
+
+	dao := GetRpmDao(tx)
+	p := s.prepareScenarioRpms(scenario3, 10)
+	p[0].Version.Epoch = 2
+	_, err := dao.InsertForRepository(context.Background(), s.repo.Base.UUID, p)
+	require.NoError(t, err)
+
+	capabilities := map[string][]models.RpmCapability{
+		p[0].Checksum.Value: {
+			{Kind: models.CapabilityProvides, Name: "webserver"},
+			{Kind: models.CapabilityRequires, Name: "libc.so.6()(64bit)"},
+		},
+		p[1].Checksum.Value: {
+			{Kind: models.CapabilityRequires, Name: "webserver"},
+		},
+	}
+	err = dao.InsertCapabilities(context.Background(), capabilities)
+	require.NoError(t, err)
+	// Capabilities are only inserted once per rpm
+	err = dao.InsertCapabilities(context.Background(), capabilities)
+	require.NoError(t, err)
+	var count int64
+	tx.Model(&models.RpmCapability{}).Where("name = ?", "webserver").Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	found, err := dao.SearchPackages(context.Background(), orgIDTest, api.SearchPackageRequest{
+		UUIDs:    []string{s.repoConfig.UUID},
+		Provides: "webserver",
+	})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, p[0].Name, found[0].Name)
+	assert.Equal(t, s.repoConfig.UUID, found[0].RepositoryUUID)
+	assert.Equal(t, s.repo.URL, found[0].RepositoryURL)
+
+	found, err = dao.SearchPackages(context.Background(), orgIDTest, api.SearchPackageRequest{
+		URLs:     []string{s.repo.URL},
+		Requires: "webserver",
+	})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, p[1].Name, found[0].Name)
+
+	nevra := p[0].Name + "-" + p[0].Version.Version + "-" + p[0].Version.Release + "." + p[0].Arch
+	found, err = dao.SearchPackages(context.Background(), orgIDTest, api.SearchPackageRequest{
+		URLs:  []string{s.repo.URL},
+		Nevra: nevra,
+	})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, p[0].Checksum.Value, found[0].Checksum)
+
+	nevra = p[0].Name + "-1:" + p[0].Version.Version + "-" + p[0].Version.Release + "." + p[0].Arch
+	found, err = dao.SearchPackages(context.Background(), orgIDTest, api.SearchPackageRequest{
+		URLs:  []string{s.repo.URL},
+		Nevra: nevra,
+	})
+	require.NoError(t, err)
+	assert.Len(t, found, 0)
+
+	_, err = dao.SearchPackages(context.Background(), orgIDTest, api.SearchPackageRequest{URLs: []string{s.repo.URL}})
+	require.Error(t, err)
+	daoError, ok := err.(*ce.DaoError)
+	require.True(t, ok)
+	assert.True(t, daoError.BadValidation)
+}
+
+func (s *RpmSuite) TestDependencyClosure() {
+	t := s.Suite.T()
+	tx := s.tx
+
+	dao := GetRpmDao(tx)
+	p := s.prepareScenarioRpms(scenario3, 10)
+	_, err := dao.InsertForRepository(context.Background(), s.repo.Base.UUID, p)
+	require.NoError(t, err)
+	err = dao.InsertCapabilities(context.Background(), map[string][]models.RpmCapability{
+		p[0].Checksum.Value: {
+			{Kind: models.CapabilityRequires, Name: "libdemo.so.1()(64bit)"},
+			{Kind: models.CapabilityRequires, Name: "rpmlib(PayloadIsZstd)"},
+		},
+		p[1].Checksum.Value: {
+			{Kind: models.CapabilityProvides, Name: "libdemo.so.1()(64bit)"},
+			{Kind: models.CapabilityProvides, Name: "demo-api", Flags: "EQ", Version: "1.2"},
+			{Kind: models.CapabilityRequires, Name: "/usr/bin/missing"},
+		},
+		p[2].Checksum.Value: {
+			{Kind: models.CapabilityRequires, Name: "demo-api", Flags: "GE", Version: "2.0"},
+		},
+	})
+	require.NoError(t, err)
+
+	closure, err := dao.DependencyClosure(context.Background(), orgIDTest, api.DependencyClosureRequest{
+		UUIDs:    []string{s.repoConfig.UUID},
+		Packages: []string{p[0].Name, "not-a-package"},
+	})
+	require.NoError(t, err)
+
+	names := []string{}
+	for _, pkg := range closure.Packages {
+		names = append(names, pkg.Name)
+	}
+	expected := []string{p[0].Name, p[1].Name}
+	sort.Strings(expected)
+	assert.Equal(t, expected, names)
+	assert.Equal(t, []api.UnresolvedDependency{
+		{Name: "/usr/bin/missing", RequiredBy: []string{p[1].Name}},
+		{Name: "not-a-package", RequiredBy: []string{}},
+	}, closure.Unresolved)
+
+	// Versioned requirements are only satisfied by a matching version
+	closure, err = dao.DependencyClosure(context.Background(), orgIDTest, api.DependencyClosureRequest{
+		UUIDs:    []string{s.repoConfig.UUID},
+		Packages: []string{p[2].Name},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, closure.Unresolved, api.UnresolvedDependency{Name: "demo-api >= 2.0", RequiredBy: []string{p[2].Name}})
+
+	// Repositories of other orgs are not used
+	closure, err = dao.DependencyClosure(context.Background(), "other-org", api.DependencyClosureRequest{
+		UUIDs:    []string{s.repoConfig.UUID},
+		Packages: []string{p[0].Name},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, closure.Packages)
+	assert.Len(t, closure.Unresolved, 1)
+
+	// Packages of source repositories do not satisfy requirements
+	err = tx.Model(&models.RepositoryConfiguration{}).
+		Where("uuid = ?", s.repoConfig.UUID).
+		Update("content_type", config.ContentTypeSource).Error
+	require.NoError(t, err)
+	closure, err = dao.DependencyClosure(context.Background(), orgIDTest, api.DependencyClosureRequest{
+		UUIDs:    []string{s.repoConfig.UUID},
+		Packages: []string{p[0].Name},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, closure.Packages)
+}
+
+func (s *RpmSuite) TestOfficialOverlaps() {
+	t := s.Suite.T()
+	tx := s.tx
+
+	official := models.Repository{URL: "https://cdn.redhat.com/content/dist/rhel8/8.8/x86_64/baseos/os/", Public: true}
+	require.NoError(t, tx.Create(&official).Error)
+	otherVersion := models.Repository{URL: "https://cdn.redhat.com/content/dist/rhel9/9.2/x86_64/baseos/os/", Public: true}
+	require.NoError(t, tx.Create(&otherVersion).Error)
+
+	dao := GetRpmDao(tx)
+	p := s.prepareScenarioRpms(scenario3, 10)
+	_, err := dao.InsertForRepository(context.Background(), s.repo.Base.UUID, p)
+	require.NoError(t, err)
+	newer := p[0]
+	newer.Version.Release = "dev.1"
+	newer.Checksum.Value = randomHexadecimal(64)
+	_, err = dao.InsertForRepository(context.Background(), official.UUID, []yum.Package{newer, p[1]})
+	require.NoError(t, err)
+	_, err = dao.InsertForRepository(context.Background(), otherVersion.UUID, []yum.Package{p[2]})
+	require.NoError(t, err)
+
+	overlaps, err := dao.OfficialOverlaps(context.Background(), orgIDTest, s.repoConfig.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{official.URL}, overlaps.OfficialRepositories)
+	expected := []api.PackageOverlap{
+		{Name: p[0].Name, Arch: "x86_64", Version: "1.0.0-dev", OfficialVersion: "1.0.0-dev.1", OfficialURL: official.URL, Comparison: api.PackageOverlapOlder},
+		{Name: p[1].Name, Arch: "x86_64", Version: "1.0.0-dev", OfficialVersion: "1.0.0-dev", OfficialURL: official.URL, Comparison: api.PackageOverlapSame},
+	}
+	sort.Slice(expected, func(i, j int) bool { return expected[i].Name < expected[j].Name })
+	assert.Equal(t, expected, overlaps.Overlaps)
+
+	_, err = dao.OfficialOverlaps(context.Background(), "other-org", s.repoConfig.UUID)
+	assert.True(t, err.(*ce.DaoError).NotFound)
+}
+
+func TestOfficialRepositoryMatches(t *testing.T) {
+	url := "https://cdn.redhat.com/content/dist/rhel9/9.2/x86_64/appstream/os"
+	assert.True(t, officialRepositoryMatches(url, []string{config.El9}, "x86_64"))
+	assert.True(t, officialRepositoryMatches(url, []string{config.ANY_VERSION}, config.ANY_ARCH))
+	assert.True(t, officialRepositoryMatches(url, nil, ""))
+	assert.False(t, officialRepositoryMatches(url, []string{config.El8}, "x86_64"))
+	assert.False(t, officialRepositoryMatches(url, []string{config.El9}, "aarch64"))
+	assert.True(t, officialRepositoryMatches("https://cdn.redhat.com/content/dist/layered/rhel8/x86_64/ansible/2/os", []string{config.El8}, "x86_64"))
+	assert.False(t, officialRepositoryMatches("https://cdn.redhat.com/content/beta/rhel9/9/x86_64/baseos/os", nil, ""))
+}
+
+func TestHighestOverlaps(t *testing.T) {
+	overlaps := highestOverlaps([]overlappingRpm{
+		{Name: "bash", Arch: "x86_64", Version: "5.1.8", Release: "9", OfficialVersion: "5.1.8", OfficialRelease: "6", OfficialURL: "https://cdn.redhat.com/a/"},
+		{Name: "bash", Arch: "x86_64", Version: "5.1.8", Release: "9", OfficialVersion: "5.1.8", OfficialRelease: "10", OfficialURL: "https://cdn.redhat.com/b/"},
+		{Name: "bash", Arch: "x86_64", Version: "5.1.8", Release: "11", OfficialVersion: "5.1.8", OfficialRelease: "6", OfficialURL: "https://cdn.redhat.com/a/"},
+		{Name: "acl", Arch: "x86_64", Epoch: 1, Version: "2.3", OfficialVersion: "2.3", OfficialURL: "https://cdn.redhat.com/a/"},
+	})
+	assert.Equal(t, []api.PackageOverlap{
+		{Name: "acl", Arch: "x86_64", Version: "1:2.3", OfficialVersion: "2.3", OfficialURL: "https://cdn.redhat.com/a/", Comparison: api.PackageOverlapNewer},
+		{Name: "bash", Arch: "x86_64", Version: "5.1.8-11", OfficialVersion: "5.1.8-10", OfficialURL: "https://cdn.redhat.com/b/", Comparison: api.PackageOverlapNewer},
+	}, overlaps)
+}
+
+func TestRpmVersionCompare(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0", "2.0", -1},
+		{"2.0.1", "2.0", 1},
+		{"1.10", "1.9", 1},
+		{"1.010", "1.10", 0},
+		{"1.0a", "1.0", 1},
+		{"1.a", "1.1", -1},
+		{"1.0~rc1", "1.0", -1},
+		{"1.0~rc1", "1.0~rc2", -1},
+		{"1.0^git1", "1.0", 1},
+		{"1.0^git1", "1.0.1", -1},
+		{"5.1.8", "5.1_8", 0},
+		{"6.el9", "6.el9_2", -1},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.expected, rpmVersionCompare(tc.a, tc.b), "%s <=> %s", tc.a, tc.b)
+		assert.Equal(t, -tc.expected, rpmVersionCompare(tc.b, tc.a), "%s <=> %s", tc.b, tc.a)
+	}
+}
+
+func TestRangesOverlap(t *testing.T) {
+	provided := requirement{Name: "bash", Flags: "EQ", Version: "5.1.8", Release: "6.el9"}
+	cases := []struct {
+		required requirement
+		expected bool
+	}{
+		{requirement{Name: "bash"}, true},
+		{requirement{Name: "bash", Flags: "GE", Version: "5.1"}, true},
+		{requirement{Name: "bash", Flags: "GE", Version: "5.2"}, false},
+		{requirement{Name: "bash", Flags: "LT", Version: "5.2"}, true},
+		{requirement{Name: "bash", Flags: "EQ", Version: "5.1.8"}, true},
+		{requirement{Name: "bash", Flags: "EQ", Version: "5.1.8", Release: "7.el9"}, false},
+		{requirement{Name: "bash", Flags: "GT", Version: "5.1.8"}, false},
+		{requirement{Name: "bash", Flags: "GT", Version: "5.1.8", Release: "5.el9"}, true},
+		{requirement{Name: "bash", Flags: "GE", Epoch: 1, Version: "1.0"}, false},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.expected, rangesOverlap(provided, tc.required), tc.required.String())
+	}
+	// Unversioned provides satisfy any version
+	assert.True(t, rangesOverlap(requirement{Name: "bash"}, requirement{Name: "bash", Flags: "GE", Version: "9"}))
+	assert.Equal(t, "bash >= 1:5.1-2", requirement{Name: "bash", Flags: "GE", Epoch: 1, Version: "5.1", Release: "2"}.String())
+}
+
+func TestSatisfyingProviders(t *testing.T) {
+	provider := func(name string, version string) dependencyProvider {
+		return dependencyProvider{
+			Capability:            "libdemo",
+			ProvideFlags:          "EQ",
+			ProvideVersion:        version,
+			SearchPackageResponse: api.SearchPackageResponse{Name: name, Version: version},
+		}
+	}
+	providers := []dependencyProvider{provider("demo-compat", "1.10"), provider("libdemo", "1.2"), provider("libdemo", "1.9"), provider("libdemo", "2.0")}
+
+	satisfying := satisfyingProviders(providers, requirement{Name: "libdemo", Flags: "LT", Version: "2.0"})
+	versions := []string{}
+	for _, p := range satisfying {
+		versions = append(versions, p.Name+"-"+p.Version)
+	}
+	// Packages named after the capability come first, highest version first
+	assert.Equal(t, []string{"libdemo-1.9", "libdemo-1.2", "demo-compat-1.10"}, versions)
+	assert.Empty(t, satisfyingProviders(providers, requirement{Name: "libdemo", Flags: "GT", Version: "2.0"}))
+}
+
+func TestParseNevra(t *testing.T) {
+	parsed, err := parseNevra("bash-0:5.1.8-6.el9.x86_64")
+	require.NoError(t, err)
+	assert.Equal(t, "bash", parsed.name)
+	require.NotNil(t, parsed.epoch)
+	assert.Equal(t, int32(0), *parsed.epoch)
+	assert.Equal(t, "5.1.8", parsed.version)
+	assert.Equal(t, "6.el9", parsed.release)
+	assert.Equal(t, "x86_64", parsed.arch)
+
+	parsed, err = parseNevra("python3-dnf-plugin-versionlock-4.3.0-5.el9.noarch")
+	require.NoError(t, err)
+	assert.Equal(t, "python3-dnf-plugin-versionlock", parsed.name)
+	assert.Nil(t, parsed.epoch)
+	assert.Equal(t, "4.3.0", parsed.version)
+	assert.Equal(t, "5.el9", parsed.release)
+	assert.Equal(t, "noarch", parsed.arch)
+
+	for _, invalid := range []string{"", "bash", "bash.x86_64", "bash-6.el9.x86_64", "bash-a:5.1.8-6.el9.x86_64", "bash-5.1.8-6.el9."} {
+		_, err = parseNevra(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
 func (s *RpmSuite) TestInsertForRepositoryWithWrongRepoUUID() {
 	t := s.Suite.T()
 	tx := s.tx
@@ -709,10 +1058,10 @@ func (s *RpmSuite) TestInsertForRepositoryWithWrongRepoUUID() {
 
 	dao := GetRpmDao(tx)
 	p := s.prepareScenarioRpms(scenario3, pagedRpmInsertsLimit)
-	records, err := dao.InsertForRepository(uuid.NewString(), p)
+	result, err := dao.InsertForRepository(context.Background(), uuid.NewString(), p)
 
 	assert.Error(t, err)
-	assert.Equal(t, records, int64(0))
+	assert.Equal(t, RpmInsertResult{}, result)
 }
 
 func (s *RpmSuite) TestOrphanCleanup() {
@@ -731,7 +1080,7 @@ func (s *RpmSuite) TestOrphanCleanup() {
 	s.tx.Model(&rpm1).Where("uuid = ?", rpm1.UUID).Count(&count)
 	assert.Equal(t, int64(1), count)
 
-	err = dao.OrphanCleanup()
+	err = dao.OrphanCleanup(context.Background())
 	assert.NoError(t, err)
 
 	s.tx.Model(&rpm1).Where("uuid = ?", rpm1.UUID).Count(&count)
@@ -739,7 +1088,7 @@ func (s *RpmSuite) TestOrphanCleanup() {
 	assert.Equal(t, int64(0), count)
 
 	// Repeat the call for 'len(danglingRpmUuids) == 0'
-	err = dao.OrphanCleanup()
+	err = dao.OrphanCleanup(context.Background())
 	assert.NoError(t, err)
 }
 
@@ -747,11 +1096,11 @@ func (s *RpmSuite) TestEmptyOrphanCleanup() {
 	var count int64
 	var countAfter int64
 	dao := GetRpmDao(s.tx)
-	err := dao.OrphanCleanup() // Clear out any existing orphaned rpms in the db
+	err := dao.OrphanCleanup(context.Background()) // Clear out any existing orphaned rpms in the db
 	assert.NoError(s.T(), err)
 
 	s.tx.Model(&repoRpmTest1).Count(&count)
-	err = dao.OrphanCleanup()
+	err = dao.OrphanCleanup(context.Background())
 	assert.NoError(s.T(), err)
 
 	s.tx.Model(&repoRpmTest1).Count(&countAfter)
@@ -782,6 +1131,51 @@ func TestStringInSlice(t *testing.T) {
 	assert.False(t, result)
 }
 
+func TestChunkStrings(t *testing.T) {
+	assert.Nil(t, chunkStrings([]string{}, 2))
+	assert.Equal(t, [][]string{{"a", "b"}, {"c"}}, chunkStrings([]string{"a", "b", "c"}, 2))
+	assert.Equal(t, [][]string{{"a", "b"}}, chunkStrings([]string{"a", "b"}, 2))
+}
+
+func TestDedupePackages(t *testing.T) {
+	pkg := yum.Package{
+		Name:     "package1",
+		Arch:     config.X8664,
+		Version:  yum.Version{Version: "1.0", Release: "1", Epoch: 0},
+		Checksum: yum.Checksum{Value: "checksum1", Type: "sha256"},
+	}
+	otherArch := pkg
+	otherArch.Arch = config.AARCH64
+
+	deduped := dedupePackages([]yum.Package{pkg, otherArch, pkg})
+	assert.Equal(t, []yum.Package{pkg, otherArch}, deduped)
+}
+
+func TestPackageChangesCounts(t *testing.T) {
+	existing := []models.Rpm{
+		{Name: "kept", Arch: config.X8664, Version: "1.0", Release: "1"},
+		{Name: "updated", Arch: config.X8664, Version: "1.0", Release: "1"},
+		{Name: "updated", Arch: config.X8664, Version: "1.1", Release: "1"},
+		{Name: "removed", Arch: config.X8664, Version: "1.0", Release: "1"},
+	}
+	pkgs := []yum.Package{
+		{Name: "kept", Arch: config.X8664, Version: yum.Version{Version: "1.0", Release: "1"}},
+		{Name: "updated", Arch: config.X8664, Version: yum.Version{Version: "1.1", Release: "1"}},
+		{Name: "kept", Arch: config.AARCH64, Version: yum.Version{Version: "1.0", Release: "1"}},
+	}
+	assert.Equal(t, models.PackageChanges{Added: 1, Removed: 1, Updated: 1}, packageChanges(existing, pkgs))
+	assert.Equal(t, models.PackageChanges{}, packageChanges(existing, pkgsFromRpms(existing)))
+}
+
+// pkgsFromRpms converts rpms to the yum packages they were inserted from
+func pkgsFromRpms(rpms []models.Rpm) []yum.Package {
+	pkgs := make([]yum.Package, len(rpms))
+	for i, rpm := range rpms {
+		pkgs[i] = yum.Package{Name: rpm.Name, Arch: rpm.Arch, Version: yum.Version{Epoch: rpm.Epoch, Version: rpm.Version, Release: rpm.Release}}
+	}
+	return pkgs
+}
+
 func TestFilteredConvert(t *testing.T) {
 	givenYumPackages := []yum.Package{
 		{
@@ -0,0 +1,75 @@
+package dao
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/yummy/pkg/yum"
+)
+
+// siblingPattern rewrites the url of a binary repository into the urls of the source
+// and debug repositories published next to it
+type siblingPattern struct {
+	binary *regexp.Regexp
+	source string
+	debug  string
+}
+
+const archPattern = `(x86_64|aarch64|ppc64le|s390x|i686)`
+
+// siblingPatterns are the layouts of the common distributions, the first matching wins
+var siblingPatterns = []siblingPattern{
+	// Fedora, CentOS Stream: .../BaseOS/x86_64/os/
+	{
+		binary: regexp.MustCompile(`^(.*)/` + archPattern + `/os/$`),
+		source: "$1/source/tree/",
+		debug:  "$1/$2/debug/tree/",
+	},
+	// Red Hat CDN: .../rhel9/9/x86_64/baseos/os/
+	{
+		binary: regexp.MustCompile(`^(.*)/os/$`),
+		source: "$1/source/SRPMS/",
+		debug:  "$1/debug/",
+	},
+	// EPEL: .../epel/9/Everything/x86_64/
+	{
+		binary: regexp.MustCompile(`^(.*)/` + archPattern + `/$`),
+		source: "$1/source/tree/",
+		debug:  "$1/$2/debug/",
+	},
+}
+
+// siblingRepositoryURLs returns the urls the source and debug repositories of the
+// binary repository at url would have, following the layout of the common distributions
+func siblingRepositoryURLs(url string) []api.SiblingRepository {
+	withSlash := url
+	if len(withSlash) == 0 || withSlash[len(withSlash)-1] != '/' {
+		withSlash += "/"
+	}
+	for _, pattern := range siblingPatterns {
+		if !pattern.binary.MatchString(withSlash) {
+			continue
+		}
+		return []api.SiblingRepository{
+			{URL: pattern.binary.ReplaceAllString(withSlash, pattern.source), ContentType: config.ContentTypeSource},
+			{URL: pattern.binary.ReplaceAllString(withSlash, pattern.debug), ContentType: config.ContentTypeDebug},
+		}
+	}
+	return nil
+}
+
+// discoverSiblings suggests the source and debug repositories of the repository at
+// url that exist, which is when their repomd.xml can be fetched with the client
+func (r repositoryConfigDaoImpl) discoverSiblings(ctx context.Context, client *http.Client, url string, response *api.RepositoryValidationResponse) {
+	response.URL.Siblings = []api.SiblingRepository{}
+	for _, sibling := range siblingRepositoryURLs(url) {
+		siblingURL := sibling.URL
+		r.yumRepo.Configure(yum.YummySettings{URL: &siblingURL, Client: withContext(ctx, client)})
+		if _, code, err := r.yumRepo.Repomd(); err == nil && code >= 200 && code < 300 {
+			response.URL.Siblings = append(response.URL.Siblings, sibling)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package dao
+
+import (
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSiblingRepositoryURLs(t *testing.T) {
+	siblings := func(source string, debug string) []api.SiblingRepository {
+		return []api.SiblingRepository{
+			{URL: source, ContentType: config.ContentTypeSource},
+			{URL: debug, ContentType: config.ContentTypeDebug},
+		}
+	}
+
+	assert.Equal(t, siblings(
+		"https://mirror.stream.centos.org/9-stream/BaseOS/source/tree/",
+		"https://mirror.stream.centos.org/9-stream/BaseOS/x86_64/debug/tree/",
+	), siblingRepositoryURLs("https://mirror.stream.centos.org/9-stream/BaseOS/x86_64/os/"))
+
+	assert.Equal(t, siblings(
+		"https://cdn.redhat.com/content/dist/rhel9/9/x86_64/baseos/source/SRPMS/",
+		"https://cdn.redhat.com/content/dist/rhel9/9/x86_64/baseos/debug/",
+	), siblingRepositoryURLs("https://cdn.redhat.com/content/dist/rhel9/9/x86_64/baseos/os"))
+
+	assert.Equal(t, siblings(
+		"https://dl.fedoraproject.org/pub/epel/9/Everything/source/tree/",
+		"https://dl.fedoraproject.org/pub/epel/9/Everything/x86_64/debug/",
+	), siblingRepositoryURLs("https://dl.fedoraproject.org/pub/epel/9/Everything/x86_64/"))
+
+	assert.Empty(t, siblingRepositoryURLs("https://example.com/my/repo/"))
+	assert.Empty(t, siblingRepositoryURLs(""))
+}
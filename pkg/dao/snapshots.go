@@ -1,8 +1,15 @@
 package dao
 
 import (
+	"context"
+	"time"
+
 	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/db"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
 	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/signing"
 	"gorm.io/gorm"
 )
 
@@ -10,26 +17,54 @@ type snapshotDaoImpl struct {
 	db *gorm.DB
 }
 
-// Create records a snapshot of a repository
-func (sDao snapshotDaoImpl) Create(s *models.Snapshot) error {
-	trans := sDao.db.Create(s)
-	if trans.Error != nil {
-		return trans.Error
-	}
-	return nil
+// Create records a snapshot of a repository as its latest one, queuing its webhook event
+func (sDao snapshotDaoImpl) Create(ctx context.Context, s *models.Snapshot) error {
+	return sDao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(s).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&models.RepositoryConfiguration{}).
+			Where("uuid = ?", s.RepositoryConfigurationUUID).
+			UpdateColumns(map[string]interface{}{
+				"last_snapshot_uuid": s.UUID,
+				"last_snapshot_at":   s.CreatedAt,
+			}).Error
+		if err != nil {
+			return err
+		}
+		repoConfig := models.RepositoryConfiguration{}
+		err = tx.Scopes(preloadStatus).
+			Where("uuid = ?", s.RepositoryConfigurationUUID).
+			First(&repoConfig).Error
+		if err != nil {
+			return err
+		}
+		repositoryResponse := api.RepositoryResponse{}
+		ModelToApiFields(repoConfig, &repositoryResponse)
+		snapshotResponse := api.SnapshotResponse{}
+		snapshotModelToApi(*s, &snapshotResponse)
+		return queueWebhookEvent(tx, repoConfig.OrgID, api.WebhookEventSnapshotCompleted, api.WebhookEventData{
+			Repository: api.NewWebhookRepository(repositoryResponse),
+			Snapshot:   &snapshotResponse,
+		})
+	})
 }
 
 // List the snapshots for a given repository config
-func (sDao snapshotDaoImpl) List(repoConfigUuid string, paginationData api.PaginationData, _ api.FilterData) (api.SnapshotCollectionResponse, int64, error) {
+func (sDao snapshotDaoImpl) List(ctx context.Context, repoConfigUuid string, paginationData api.PaginationData, _ api.FilterData) (api.SnapshotCollectionResponse, int64, error) {
 	var snaps []models.Snapshot
 	var totalSnaps int64
 
-	filteredDB := sDao.db
-	result := filteredDB.
+	filteredDB := sDao.db.WithContext(ctx).Model(&models.Snapshot{}).
 		Where("snapshots.repository_configuration_uuid = ?", repoConfigUuid).
+		Session(&gorm.Session{})
+	if err := countTotal(filteredDB, paginationData, &totalSnaps); err != nil {
+		return api.SnapshotCollectionResponse{}, 0, err
+	}
+	result := filteredDB.
 		Limit(paginationData.Limit).
 		Offset(paginationData.Offset).
-		Find(&snaps).Count(&totalSnaps)
+		Find(&snaps)
 	resp := snapshotConvertToResponses(snaps)
 	if result.Error != nil {
 		return api.SnapshotCollectionResponse{}, 0, result.Error
@@ -47,14 +82,45 @@ func snapshotConvertToResponses(snapshots []models.Snapshot) []api.SnapshotRespo
 }
 
 func snapshotModelToApi(model models.Snapshot, resp *api.SnapshotResponse) {
+	resp.UUID = model.UUID
 	resp.CreatedAt = model.CreatedAt
 	resp.RepositoryPath = model.RepositoryPath
+	resp.URL = snapshotURL(model.RepositoryPath)
 	resp.ContentCounts = model.ContentCounts
+	resp.SizeBytes = model.SizeBytes
+	resp.VerificationError = model.VerificationError
+	resp.VerifiedAt = model.VerifiedAt
+}
+
+// snapshotURL returns the url a snapshot is served from, given its repository path.
+// The url is signed when content signing is enabled.
+func snapshotURL(repositoryPath string) string {
+	if signer := signing.FromConfig(); signer != nil {
+		return signer.ContentURL(config.Get().Clients.Pulp.ContentSigning.BaseURL, repositoryPath, time.Now())
+	}
+	return config.PulpContentURL(repositoryPath)
+}
+
+// Fetch returns a snapshot of the given repository config
+func (sDao snapshotDaoImpl) Fetch(ctx context.Context, repoConfigUUID string, snapUUID string) (api.SnapshotResponse, error) {
+	var snap models.Snapshot
+	var resp api.SnapshotResponse
+	result := sDao.db.WithContext(ctx).
+		Where("repository_configuration_uuid = ? AND uuid = ?", repoConfigUUID, snapUUID).
+		First(&snap)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return resp, &ce.DaoError{NotFound: true, Message: "Could not find snapshot with UUID " + snapUUID}
+		}
+		return resp, DBErrorToApi(result.Error)
+	}
+	snapshotModelToApi(snap, &resp)
+	return resp, nil
 }
 
-func (sDao snapshotDaoImpl) FetchForRepoConfigUUID(repoConfigUUID string) ([]models.Snapshot, error) {
+func (sDao snapshotDaoImpl) FetchForRepoConfigUUID(ctx context.Context, repoConfigUUID string) ([]models.Snapshot, error) {
 	var snaps []models.Snapshot
-	result := sDao.db.Model(&models.Snapshot{}).
+	result := sDao.db.WithContext(ctx).Model(&models.Snapshot{}).
 		Where("repository_configuration_uuid = ?", repoConfigUUID).
 		Find(&snaps)
 	if result.Error != nil {
@@ -63,15 +129,172 @@ func (sDao snapshotDaoImpl) FetchForRepoConfigUUID(repoConfigUUID string) ([]mod
 	return snaps, nil
 }
 
-func (sDao snapshotDaoImpl) Delete(snapUUID string) error {
-	var snap models.Snapshot
-	result := sDao.db.Where("uuid = ?", snapUUID).First(&snap)
+// FetchForOrg returns the snapshots of the repository configurations of an org
+func (sDao snapshotDaoImpl) FetchForOrg(ctx context.Context, orgID string) ([]models.Snapshot, error) {
+	var snaps []models.Snapshot
+	result := sDao.db.WithContext(ctx).Model(&models.Snapshot{}).
+		Joins("INNER JOIN repository_configurations ON repository_configurations.uuid = snapshots.repository_configuration_uuid").
+		Where("repository_configurations.org_id = ?", orgID).
+		Where("repository_configurations.deleted_at IS NULL").
+		Order("snapshots.created_at").
+		Find(&snaps)
 	if result.Error != nil {
-		return result.Error
+		return snaps, result.Error
 	}
-	result = sDao.db.Delete(snap)
+	return snaps, nil
+}
+
+// UpdateVerification records the result of verifying a snapshot, along with the repository version,
+// publication and distribution it was repaired with
+func (sDao snapshotDaoImpl) UpdateVerification(ctx context.Context, snap models.Snapshot) error {
+	result := sDao.db.WithContext(ctx).Model(&models.Snapshot{}).
+		Where("uuid = ?", snap.UUID).
+		Updates(map[string]interface{}{
+			"version_href":       snap.VersionHref,
+			"publication_href":   snap.PublicationHref,
+			"distribution_href":  snap.DistributionHref,
+			"verification_error": snap.VerificationError,
+			"verified_at":        snap.VerifiedAt,
+		})
 	if result.Error != nil {
 		return result.Error
 	}
+	if result.RowsAffected == 0 {
+		return &ce.DaoError{NotFound: true, Message: "Could not find snapshot with UUID " + snap.UUID}
+	}
 	return nil
 }
+
+// Delete removes a snapshot, the latest snapshot of its repository becoming the one taken before it
+func (sDao snapshotDaoImpl) Delete(ctx context.Context, snapUUID string) error {
+	var snap models.Snapshot
+	result := sDao.db.WithContext(ctx).Where("uuid = ?", snapUUID).First(&snap)
+	if result.Error != nil {
+		return result.Error
+	}
+	return sDao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(snap).Error; err != nil {
+			return err
+		}
+		return updateLastSnapshot(tx, snap.RepositoryConfigurationUUID)
+	})
+}
+
+// FetchSnapshotsByDateAndRepository returns, for each requested repository of the org, the latest
+// snapshot taken on or before the requested date.  A snapshot taken at any time during the
+// requested day is an exact match.
+func (sDao snapshotDaoImpl) FetchSnapshotsByDateAndRepository(ctx context.Context, orgID string, request api.ListSnapshotByDateRequest) (api.ListSnapshotByDateResponse, error) {
+	var snaps []models.Snapshot
+	day := request.Date.UTC().Truncate(24 * time.Hour)
+
+	result := sDao.db.WithContext(ctx).
+		Select("DISTINCT ON (snapshots.repository_configuration_uuid) snapshots.*").
+		Joins("INNER JOIN repository_configurations ON repository_configurations.uuid = snapshots.repository_configuration_uuid").
+		Where("repository_configurations.org_id = ?", orgID).
+		Where("repository_configurations.deleted_at IS NULL").
+		Where("snapshots.repository_configuration_uuid IN ?", request.RepositoryUUIDS).
+		Where("snapshots.created_at <= ?", request.Date).
+		Order("snapshots.repository_configuration_uuid, snapshots.created_at DESC").
+		Find(&snaps)
+	if result.Error != nil {
+		return api.ListSnapshotByDateResponse{}, result.Error
+	}
+
+	byRepo := make(map[string]models.Snapshot, len(snaps))
+	for _, snap := range snaps {
+		byRepo[snap.RepositoryConfigurationUUID] = snap
+	}
+
+	resp := api.ListSnapshotByDateResponse{Data: make([]api.SnapshotForDate, len(request.RepositoryUUIDS))}
+	for i, repoUUID := range request.RepositoryUUIDS {
+		resp.Data[i].RepositoryUUID = repoUUID
+		snap, found := byRepo[repoUUID]
+		if !found {
+			continue
+		}
+		match := api.SnapshotResponse{}
+		snapshotModelToApi(snap, &match)
+		resp.Data[i].Match = &match
+		// The snapshot was taken on the same day, before the requested time
+		resp.Data[i].IsExactMatch = !snap.CreatedAt.Before(day)
+	}
+	return resp, nil
+}
+
+// FetchLatestForRepoConfigs returns the latest snapshot of each of the repository configurations
+// having one, by repository configuration uuid
+func (sDao snapshotDaoImpl) FetchLatestForRepoConfigs(ctx context.Context, orgID string, repoConfigUUIDs []string) (map[string]api.SnapshotResponse, error) {
+	latest := make(map[string]api.SnapshotResponse, len(repoConfigUUIDs))
+	if len(repoConfigUUIDs) == 0 {
+		return latest, nil
+	}
+	var snaps []models.Snapshot
+	err := readOnly(ctx, sDao.db, func(conn *gorm.DB) error {
+		return conn.
+			Select("DISTINCT ON (snapshots.repository_configuration_uuid) snapshots.*").
+			Joins("INNER JOIN repository_configurations ON repository_configurations.uuid = snapshots.repository_configuration_uuid").
+			Where("repository_configurations.org_id = ?", orgID).
+			Where("snapshots.repository_configuration_uuid IN ?", repoConfigUUIDs).
+			Order("snapshots.repository_configuration_uuid, snapshots.created_at DESC").
+			Find(&snaps).Error
+	})
+	if err != nil {
+		return nil, DBErrorToApi(err)
+	}
+	for _, snap := range snaps {
+		resp := api.SnapshotResponse{}
+		snapshotModelToApi(snap, &resp)
+		latest[snap.RepositoryConfigurationUUID] = resp
+	}
+	return latest, nil
+}
+
+// StorageUsage returns the storage used by the snapshots of an org, by repository.
+// Snapshots of a repository share most of their artifacts, so a repository is estimated
+// to use the size of its largest snapshot rather than the sum of the sizes of its snapshots.
+func (sDao snapshotDaoImpl) StorageUsage(ctx context.Context, orgID string) (api.SnapshotStorageResponse, error) {
+	ctx = db.WithQueryClass(ctx, db.QueryReport)
+	resp := api.SnapshotStorageResponse{Repositories: []api.RepositoryStorage{}}
+	err := readOnly(ctx, sDao.db, func(conn *gorm.DB) error {
+		return conn.Model(&models.Snapshot{}).
+			Select("repository_configurations.uuid AS uuid, repository_configurations.name AS name, "+
+				"MAX(snapshots.size_bytes) AS size_bytes, COUNT(snapshots.uuid) AS snapshots").
+			Joins("INNER JOIN repository_configurations ON repository_configurations.uuid = snapshots.repository_configuration_uuid").
+			Where("repository_configurations.org_id = ?", orgID).
+			Where("repository_configurations.deleted_at IS NULL").
+			Group("repository_configurations.uuid, repository_configurations.name").
+			Order("size_bytes DESC, repository_configurations.name").
+			Scan(&resp.Repositories).Error
+	})
+	if err != nil {
+		return api.SnapshotStorageResponse{}, err
+	}
+	for _, repo := range resp.Repositories {
+		resp.SizeBytes += repo.SizeBytes
+		resp.Snapshots += repo.Snapshots
+	}
+	return resp, nil
+}
+
+// updateLastSnapshot records the latest snapshot of the repository config, clearing it if there is none
+func updateLastSnapshot(tx *gorm.DB, repoConfigUUID string) error {
+	var latest []models.Snapshot
+	err := tx.Where("repository_configuration_uuid = ?", repoConfigUUID).
+		Order("created_at DESC").
+		Limit(1).
+		Find(&latest).Error
+	if err != nil {
+		return err
+	}
+	updates := map[string]interface{}{
+		"last_snapshot_uuid": nil,
+		"last_snapshot_at":   nil,
+	}
+	if len(latest) > 0 {
+		updates["last_snapshot_uuid"] = latest[0].UUID
+		updates["last_snapshot_at"] = latest[0].CreatedAt
+	}
+	return tx.Model(&models.RepositoryConfiguration{}).
+		Where("uuid = ?", repoConfigUUID).
+		UpdateColumns(updates).Error
+}
@@ -3,6 +3,8 @@
 package dao
 
 import (
+	context "context"
+
 	api "github.com/content-services/content-sources-backend/pkg/api"
 	mock "github.com/stretchr/testify/mock"
 
@@ -14,13 +16,13 @@ type MockSnapshotDao struct {
 	mock.Mock
 }
 
-// Create provides a mock function with given fields: snap
-func (_m *MockSnapshotDao) Create(snap *models.Snapshot) error {
-	ret := _m.Called(snap)
+// Create provides a mock function with given fields: ctx, snap
+func (_m *MockSnapshotDao) Create(ctx context.Context, snap *models.Snapshot) error {
+	ret := _m.Called(ctx, snap)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*models.Snapshot) error); ok {
-		r0 = rf(snap)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Snapshot) error); ok {
+		r0 = rf(ctx, snap)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -28,13 +30,13 @@ func (_m *MockSnapshotDao) Create(snap *models.Snapshot) error {
 	return r0
 }
 
-// Delete provides a mock function with given fields: snapUUID
-func (_m *MockSnapshotDao) Delete(snapUUID string) error {
-	ret := _m.Called(snapUUID)
+// Delete provides a mock function with given fields: ctx, snapUUID
+func (_m *MockSnapshotDao) Delete(ctx context.Context, snapUUID string) error {
+	ret := _m.Called(ctx, snapUUID)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(snapUUID)
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, snapUUID)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -42,25 +44,49 @@ func (_m *MockSnapshotDao) Delete(snapUUID string) error {
 	return r0
 }
 
-// FetchForRepoConfigUUID provides a mock function with given fields: repoConfigUUID
-func (_m *MockSnapshotDao) FetchForRepoConfigUUID(repoConfigUUID string) ([]models.Snapshot, error) {
-	ret := _m.Called(repoConfigUUID)
+// Fetch provides a mock function with given fields: ctx, repoConfigUUID, snapUUID
+func (_m *MockSnapshotDao) Fetch(ctx context.Context, repoConfigUUID string, snapUUID string) (api.SnapshotResponse, error) {
+	ret := _m.Called(ctx, repoConfigUUID, snapUUID)
+
+	var r0 api.SnapshotResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (api.SnapshotResponse, error)); ok {
+		return rf(ctx, repoConfigUUID, snapUUID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) api.SnapshotResponse); ok {
+		r0 = rf(ctx, repoConfigUUID, snapUUID)
+	} else {
+		r0 = ret.Get(0).(api.SnapshotResponse)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, repoConfigUUID, snapUUID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FetchForOrg provides a mock function with given fields: ctx, orgID
+func (_m *MockSnapshotDao) FetchForOrg(ctx context.Context, orgID string) ([]models.Snapshot, error) {
+	ret := _m.Called(ctx, orgID)
 
 	var r0 []models.Snapshot
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]models.Snapshot, error)); ok {
-		return rf(repoConfigUUID)
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]models.Snapshot, error)); ok {
+		return rf(ctx, orgID)
 	}
-	if rf, ok := ret.Get(0).(func(string) []models.Snapshot); ok {
-		r0 = rf(repoConfigUUID)
+	if rf, ok := ret.Get(0).(func(context.Context, string) []models.Snapshot); ok {
+		r0 = rf(ctx, orgID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]models.Snapshot)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(repoConfigUUID)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, orgID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -68,30 +94,106 @@ func (_m *MockSnapshotDao) FetchForRepoConfigUUID(repoConfigUUID string) ([]mode
 	return r0, r1
 }
 
-// List provides a mock function with given fields: repoConfigUuid, paginationData, filterData
-func (_m *MockSnapshotDao) List(repoConfigUuid string, paginationData api.PaginationData, filterData api.FilterData) (api.SnapshotCollectionResponse, int64, error) {
-	ret := _m.Called(repoConfigUuid, paginationData, filterData)
+// FetchForRepoConfigUUID provides a mock function with given fields: ctx, repoConfigUUID
+func (_m *MockSnapshotDao) FetchForRepoConfigUUID(ctx context.Context, repoConfigUUID string) ([]models.Snapshot, error) {
+	ret := _m.Called(ctx, repoConfigUUID)
+
+	var r0 []models.Snapshot
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]models.Snapshot, error)); ok {
+		return rf(ctx, repoConfigUUID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []models.Snapshot); ok {
+		r0 = rf(ctx, repoConfigUUID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Snapshot)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, repoConfigUUID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FetchLatestForRepoConfigs provides a mock function with given fields: ctx, orgID, repoConfigUUIDs
+func (_m *MockSnapshotDao) FetchLatestForRepoConfigs(ctx context.Context, orgID string, repoConfigUUIDs []string) (map[string]api.SnapshotResponse, error) {
+	ret := _m.Called(ctx, orgID, repoConfigUUIDs)
+
+	var r0 map[string]api.SnapshotResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) (map[string]api.SnapshotResponse, error)); ok {
+		return rf(ctx, orgID, repoConfigUUIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) map[string]api.SnapshotResponse); ok {
+		r0 = rf(ctx, orgID, repoConfigUUIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]api.SnapshotResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = rf(ctx, orgID, repoConfigUUIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FetchSnapshotsByDateAndRepository provides a mock function with given fields: ctx, orgID, request
+func (_m *MockSnapshotDao) FetchSnapshotsByDateAndRepository(ctx context.Context, orgID string, request api.ListSnapshotByDateRequest) (api.ListSnapshotByDateResponse, error) {
+	ret := _m.Called(ctx, orgID, request)
+
+	var r0 api.ListSnapshotByDateResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.ListSnapshotByDateRequest) (api.ListSnapshotByDateResponse, error)); ok {
+		return rf(ctx, orgID, request)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.ListSnapshotByDateRequest) api.ListSnapshotByDateResponse); ok {
+		r0 = rf(ctx, orgID, request)
+	} else {
+		r0 = ret.Get(0).(api.ListSnapshotByDateResponse)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, api.ListSnapshotByDateRequest) error); ok {
+		r1 = rf(ctx, orgID, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: ctx, repoConfigUuid, paginationData, filterData
+func (_m *MockSnapshotDao) List(ctx context.Context, repoConfigUuid string, paginationData api.PaginationData, filterData api.FilterData) (api.SnapshotCollectionResponse, int64, error) {
+	ret := _m.Called(ctx, repoConfigUuid, paginationData, filterData)
 
 	var r0 api.SnapshotCollectionResponse
 	var r1 int64
 	var r2 error
-	if rf, ok := ret.Get(0).(func(string, api.PaginationData, api.FilterData) (api.SnapshotCollectionResponse, int64, error)); ok {
-		return rf(repoConfigUuid, paginationData, filterData)
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.PaginationData, api.FilterData) (api.SnapshotCollectionResponse, int64, error)); ok {
+		return rf(ctx, repoConfigUuid, paginationData, filterData)
 	}
-	if rf, ok := ret.Get(0).(func(string, api.PaginationData, api.FilterData) api.SnapshotCollectionResponse); ok {
-		r0 = rf(repoConfigUuid, paginationData, filterData)
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.PaginationData, api.FilterData) api.SnapshotCollectionResponse); ok {
+		r0 = rf(ctx, repoConfigUuid, paginationData, filterData)
 	} else {
 		r0 = ret.Get(0).(api.SnapshotCollectionResponse)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, api.PaginationData, api.FilterData) int64); ok {
-		r1 = rf(repoConfigUuid, paginationData, filterData)
+	if rf, ok := ret.Get(1).(func(context.Context, string, api.PaginationData, api.FilterData) int64); ok {
+		r1 = rf(ctx, repoConfigUuid, paginationData, filterData)
 	} else {
 		r1 = ret.Get(1).(int64)
 	}
 
-	if rf, ok := ret.Get(2).(func(string, api.PaginationData, api.FilterData) error); ok {
-		r2 = rf(repoConfigUuid, paginationData, filterData)
+	if rf, ok := ret.Get(2).(func(context.Context, string, api.PaginationData, api.FilterData) error); ok {
+		r2 = rf(ctx, repoConfigUuid, paginationData, filterData)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -99,6 +201,44 @@ func (_m *MockSnapshotDao) List(repoConfigUuid string, paginationData api.Pagina
 	return r0, r1, r2
 }
 
+// StorageUsage provides a mock function with given fields: ctx, orgID
+func (_m *MockSnapshotDao) StorageUsage(ctx context.Context, orgID string) (api.SnapshotStorageResponse, error) {
+	ret := _m.Called(ctx, orgID)
+
+	var r0 api.SnapshotStorageResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (api.SnapshotStorageResponse, error)); ok {
+		return rf(ctx, orgID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) api.SnapshotStorageResponse); ok {
+		r0 = rf(ctx, orgID)
+	} else {
+		r0 = ret.Get(0).(api.SnapshotStorageResponse)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, orgID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateVerification provides a mock function with given fields: ctx, snap
+func (_m *MockSnapshotDao) UpdateVerification(ctx context.Context, snap models.Snapshot) error {
+	ret := _m.Called(ctx, snap)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Snapshot) error); ok {
+		r0 = rf(ctx, snap)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewMockSnapshotDao interface {
 	mock.TestingT
 	Cleanup(func())
@@ -1,10 +1,13 @@
 package dao
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/content-services/content-sources-backend/pkg/api"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
 	"github.com/content-services/content-sources-backend/pkg/models"
 	uuid2 "github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -38,7 +41,7 @@ func (s *SnapshotsSuite) TestCreateAndList() {
 
 	snap := s.createSnapshot(rConfig)
 
-	collection, total, err := sDao.List(rConfig.UUID, pageData, filterData)
+	collection, total, err := sDao.List(context.Background(), rConfig.UUID, pageData, filterData)
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), total)
 	assert.Equal(t, 1, len(collection.Data))
@@ -49,6 +52,26 @@ func (s *SnapshotsSuite) TestCreateAndList() {
 	}
 }
 
+func (s *SnapshotsSuite) TestFetch() {
+	t := s.T()
+	tx := s.tx
+	sDao := snapshotDaoImpl{db: tx}
+	rConfig := s.createRepository()
+	snap := s.createSnapshot(rConfig)
+
+	found, err := sDao.Fetch(context.Background(), rConfig.UUID, snap.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, snap.UUID, found.UUID)
+	assert.Equal(t, snapshotURL(snap.RepositoryPath), found.URL)
+
+	otherConfig := s.createRepository()
+	_, err = sDao.Fetch(context.Background(), otherConfig.UUID, snap.UUID)
+	assert.Error(t, err)
+	daoError, ok := err.(*ce.DaoError)
+	assert.True(t, ok)
+	assert.True(t, daoError.NotFound)
+}
+
 func (s *SnapshotsSuite) TestListNoSnapshots() {
 	t := s.T()
 	tx := s.tx
@@ -63,24 +86,9 @@ func (s *SnapshotsSuite) TestListNoSnapshots() {
 		Version: "",
 	}
 
-	testRepository := models.Repository{
-		URL:                    "https://example.com",
-		LastIntrospectionTime:  nil,
-		LastIntrospectionError: nil,
-	}
-	err := tx.Create(&testRepository).Error
-	assert.NoError(t, err)
-
-	rConfig := models.RepositoryConfiguration{
-		Name:           "toSnapshot",
-		OrgID:          "someOrg",
-		RepositoryUUID: testRepository.UUID,
-	}
+	rConfig := s.Factory.RepositoryConfig(t)
 
-	err = tx.Create(&rConfig).Error
-	assert.NoError(t, err)
-
-	collection, total, err := sDao.List(rConfig.UUID, pageData, filterData)
+	collection, total, err := sDao.List(context.Background(), rConfig.UUID, pageData, filterData)
 	assert.NoError(t, err)
 	assert.Equal(t, int64(0), total)
 	assert.Equal(t, 0, len(collection.Data))
@@ -105,33 +113,14 @@ func (s *SnapshotsSuite) TestListPageLimit() {
 		s.createSnapshot(rConfig)
 	}
 
-	collection, total, err := sDao.List(rConfig.UUID, pageData, filterData)
+	collection, total, err := sDao.List(context.Background(), rConfig.UUID, pageData, filterData)
 	assert.NoError(t, err)
 	assert.Equal(t, int64(11), total)
 	assert.Equal(t, 10, len(collection.Data))
 }
 
 func (s *SnapshotsSuite) createRepository() models.RepositoryConfiguration {
-	t := s.T()
-	tx := s.tx
-
-	testRepository := models.Repository{
-		URL:                    "https://example.com",
-		LastIntrospectionTime:  nil,
-		LastIntrospectionError: nil,
-	}
-	err := tx.Create(&testRepository).Error
-	assert.NoError(t, err)
-
-	rConfig := models.RepositoryConfiguration{
-		Name:           "toSnapshot",
-		OrgID:          "someOrg",
-		RepositoryUUID: testRepository.UUID,
-	}
-
-	err = tx.Create(&rConfig).Error
-	assert.NoError(t, err)
-	return rConfig
+	return s.Factory.RepositoryConfig(s.T())
 }
 
 func (s *SnapshotsSuite) createSnapshot(rConfig models.RepositoryConfiguration) models.Snapshot {
@@ -148,7 +137,7 @@ func (s *SnapshotsSuite) createSnapshot(rConfig models.RepositoryConfiguration)
 	}
 
 	sDao := snapshotDaoImpl{db: tx}
-	err := sDao.Create(&snap)
+	err := sDao.Create(context.Background(), &snap)
 	assert.NoError(t, err)
 	return snap
 }
@@ -161,8 +150,229 @@ func (s *SnapshotsSuite) TestFetchForRepoUUID() {
 	s.createSnapshot(repoConfig)
 
 	sDao := snapshotDaoImpl{db: tx}
-	snaps, err := sDao.FetchForRepoConfigUUID(repoConfig.UUID)
+	snaps, err := sDao.FetchForRepoConfigUUID(context.Background(), repoConfig.UUID)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(snaps))
 	assert.Equal(t, snaps[0].RepositoryConfigurationUUID, repoConfig.UUID)
 }
+
+func (s *SnapshotsSuite) TestFetchForOrg() {
+	t := s.T()
+	tx := s.tx
+	sDao := snapshotDaoImpl{db: tx}
+	orgID := uuid2.NewString()
+
+	repoConfig := s.createRepository()
+	deleted := s.createRepository()
+	snap := s.createSnapshot(repoConfig)
+	s.createSnapshot(deleted)
+	for _, rConfig := range []models.RepositoryConfiguration{repoConfig, deleted} {
+		assert.NoError(t, tx.Model(&rConfig).Update("org_id", orgID).Error)
+	}
+	assert.NoError(t, tx.Delete(&deleted).Error)
+
+	snaps, err := sDao.FetchForOrg(context.Background(), orgID)
+	assert.NoError(t, err)
+	if assert.Len(t, snaps, 1) {
+		assert.Equal(t, snap.UUID, snaps[0].UUID)
+	}
+
+	snaps, err = sDao.FetchForOrg(context.Background(), "otherOrg")
+	assert.NoError(t, err)
+	assert.Empty(t, snaps)
+}
+
+func (s *SnapshotsSuite) TestUpdateVerification() {
+	t := s.T()
+	tx := s.tx
+	sDao := snapshotDaoImpl{db: tx}
+
+	snap := s.createSnapshot(s.createRepository())
+	verifiedAt := time.Now()
+	snap.VersionHref = "/pulp/version/repaired"
+	snap.PublicationHref = "/pulp/publication/repaired"
+	snap.DistributionHref = "/pulp/distribution/repaired"
+	snap.VerificationError = "Repository version /pulp/version could not be found"
+	snap.VerifiedAt = &verifiedAt
+	err := sDao.UpdateVerification(context.Background(), snap)
+	assert.NoError(t, err)
+
+	found := models.Snapshot{}
+	assert.NoError(t, tx.Where("uuid = ?", snap.UUID).First(&found).Error)
+	assert.Equal(t, snap.VersionHref, found.VersionHref)
+	assert.Equal(t, snap.PublicationHref, found.PublicationHref)
+	assert.Equal(t, snap.DistributionHref, found.DistributionHref)
+	assert.Equal(t, snap.VerificationError, found.VerificationError)
+	if assert.NotNil(t, found.VerifiedAt) {
+		assert.WithinDuration(t, verifiedAt, *found.VerifiedAt, time.Second)
+	}
+
+	snap.UUID = uuid2.NewString()
+	err = sDao.UpdateVerification(context.Background(), snap)
+	assert.Error(t, err)
+	daoError, ok := err.(*ce.DaoError)
+	assert.True(t, ok)
+	assert.True(t, daoError.NotFound)
+}
+
+func (s *SnapshotsSuite) TestFetchSnapshotsByDateAndRepository() {
+	t := s.T()
+	tx := s.tx
+	sDao := snapshotDaoImpl{db: tx}
+
+	repoConfig := s.createRepository()
+	older := s.createSnapshot(repoConfig)
+	newer := s.createSnapshot(repoConfig)
+	day := time.Date(2023, 8, 10, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, tx.Model(&older).Update("created_at", day.Add(-48*time.Hour)).Error)
+	assert.NoError(t, tx.Model(&newer).Update("created_at", day.Add(2*time.Hour)).Error)
+	noSnapshots := s.createRepository()
+
+	// Snapshot taken earlier on the requested day
+	resp, err := sDao.FetchSnapshotsByDateAndRepository(context.Background(), repoConfig.OrgID, api.ListSnapshotByDateRequest{
+		RepositoryUUIDS: []string{repoConfig.UUID, noSnapshots.UUID},
+		Date:            day.Add(3 * time.Hour),
+	})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 2)
+	assert.Equal(t, repoConfig.UUID, resp.Data[0].RepositoryUUID)
+	assert.True(t, resp.Data[0].IsExactMatch)
+	if assert.NotNil(t, resp.Data[0].Match) {
+		assert.Equal(t, newer.UUID, resp.Data[0].Match.UUID)
+	}
+	assert.Equal(t, noSnapshots.UUID, resp.Data[1].RepositoryUUID)
+	assert.Nil(t, resp.Data[1].Match)
+
+	// Snapshots taken after the requested time are not matched, even on the same day
+	resp, err = sDao.FetchSnapshotsByDateAndRepository(context.Background(), repoConfig.OrgID, api.ListSnapshotByDateRequest{
+		RepositoryUUIDS: []string{repoConfig.UUID},
+		Date:            day.Add(time.Hour),
+	})
+	assert.NoError(t, err)
+	assert.False(t, resp.Data[0].IsExactMatch)
+	if assert.NotNil(t, resp.Data[0].Match) {
+		assert.Equal(t, older.UUID, resp.Data[0].Match.UUID)
+	}
+
+	// Only an older snapshot exists
+	resp, err = sDao.FetchSnapshotsByDateAndRepository(context.Background(), repoConfig.OrgID, api.ListSnapshotByDateRequest{
+		RepositoryUUIDS: []string{repoConfig.UUID},
+		Date:            day.Add(-24 * time.Hour),
+	})
+	assert.NoError(t, err)
+	assert.False(t, resp.Data[0].IsExactMatch)
+	if assert.NotNil(t, resp.Data[0].Match) {
+		assert.Equal(t, older.UUID, resp.Data[0].Match.UUID)
+	}
+
+	// Repositories of other orgs are not matched
+	resp, err = sDao.FetchSnapshotsByDateAndRepository(context.Background(), "otherOrg", api.ListSnapshotByDateRequest{
+		RepositoryUUIDS: []string{repoConfig.UUID},
+		Date:            day,
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, resp.Data[0].Match)
+}
+
+func (s *SnapshotsSuite) TestStorageUsage() {
+	t := s.T()
+	tx := s.tx
+	sDao := snapshotDaoImpl{db: tx}
+	orgID := uuid2.NewString()
+
+	small := s.createRepository()
+	large := s.createRepository()
+	deleted := s.createRepository()
+	for i, size := range []int64{100, 300} {
+		snap := s.createSnapshot(small)
+		assert.NoError(t, tx.Model(&snap).Update("size_bytes", size*int64(i+1)).Error)
+	}
+	snap := s.createSnapshot(large)
+	assert.NoError(t, tx.Model(&snap).Update("size_bytes", 5000).Error)
+	snap = s.createSnapshot(deleted)
+	assert.NoError(t, tx.Model(&snap).Update("size_bytes", 7000).Error)
+	for _, rConfig := range []models.RepositoryConfiguration{small, large, deleted} {
+		assert.NoError(t, tx.Model(&rConfig).Update("org_id", orgID).Error)
+	}
+	assert.NoError(t, tx.Delete(&deleted).Error)
+
+	resp, err := sDao.StorageUsage(context.Background(), orgID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5600), resp.SizeBytes)
+	assert.Equal(t, int64(3), resp.Snapshots)
+	if assert.Len(t, resp.Repositories, 2) {
+		assert.Equal(t, large.UUID, resp.Repositories[0].UUID)
+		assert.Equal(t, large.Name, resp.Repositories[0].Name)
+		assert.Equal(t, int64(5000), resp.Repositories[0].SizeBytes)
+		assert.Equal(t, small.UUID, resp.Repositories[1].UUID)
+		assert.Equal(t, int64(600), resp.Repositories[1].SizeBytes)
+		assert.Equal(t, int64(2), resp.Repositories[1].Snapshots)
+	}
+
+	resp, err = sDao.StorageUsage(context.Background(), "otherOrg")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), resp.SizeBytes)
+	assert.Empty(t, resp.Repositories)
+}
+
+func (s *SnapshotsSuite) TestFetchLatestForRepoConfigs() {
+	t := s.T()
+	tx := s.tx
+	sDao := snapshotDaoImpl{db: tx}
+
+	repoConfig := s.createRepository()
+	noSnapshots := s.createRepository()
+	older := s.createSnapshot(repoConfig)
+	assert.NoError(t, tx.Model(&older).Update("created_at", time.Now().Add(-time.Hour)).Error)
+	latest := s.createSnapshot(repoConfig)
+
+	snapshots, err := sDao.FetchLatestForRepoConfigs(context.Background(), repoConfig.OrgID, []string{repoConfig.UUID, noSnapshots.UUID})
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 1)
+	assert.Equal(t, latest.UUID, snapshots[repoConfig.UUID].UUID)
+
+	snapshots, err = sDao.FetchLatestForRepoConfigs(context.Background(), "otherOrg", []string{repoConfig.UUID})
+	assert.NoError(t, err)
+	assert.Empty(t, snapshots)
+}
+
+func (s *SnapshotsSuite) TestLastSnapshot() {
+	t := s.T()
+	tx := s.tx
+	sDao := snapshotDaoImpl{db: tx}
+	rDao := repositoryConfigDaoImpl{db: tx}
+
+	repoConfig := s.createRepository()
+	older := s.createSnapshot(repoConfig)
+	assert.NoError(t, tx.Model(&older).Update("created_at", time.Now().Add(-time.Hour)).Error)
+	latest := s.createSnapshot(repoConfig)
+
+	taskUUID := uuid2.NewString()
+	err := rDao.UpdateLastSnapshotTask(context.Background(), taskUUID, repoConfig.OrgID, repoConfig.RepositoryUUID)
+	assert.NoError(t, err)
+
+	response, err := rDao.Fetch(context.Background(), repoConfig.OrgID, repoConfig.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, latest.UUID, response.LastSnapshotUUID)
+	assert.Equal(t, taskUUID, response.LastSnapshotTaskUUID)
+	assert.NotEmpty(t, response.LastSnapshotAt)
+
+	// Deleting the latest snapshot makes the one before it the latest
+	assert.NoError(t, sDao.Delete(context.Background(), latest.UUID))
+	response, err = rDao.Fetch(context.Background(), repoConfig.OrgID, repoConfig.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, older.UUID, response.LastSnapshotUUID)
+
+	assert.NoError(t, sDao.Delete(context.Background(), older.UUID))
+	response, err = rDao.Fetch(context.Background(), repoConfig.OrgID, repoConfig.UUID)
+	assert.NoError(t, err)
+	assert.Empty(t, response.LastSnapshotUUID)
+	assert.Empty(t, response.LastSnapshotAt)
+	assert.Equal(t, taskUUID, response.LastSnapshotTaskUUID)
+
+	err = rDao.UpdateLastSnapshotTask(context.Background(), taskUUID, "otherOrg", repoConfig.RepositoryUUID)
+	assert.Error(t, err)
+	daoError, ok := err.(*ce.DaoError)
+	assert.True(t, ok)
+	assert.True(t, daoError.NotFound)
+}
@@ -0,0 +1,98 @@
+package dao
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/db"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type StatementTimeoutSuite struct {
+	*DaoSuite
+	dbConfig config.Database
+}
+
+func TestStatementTimeoutSuite(t *testing.T) {
+	m := DaoSuite{}
+	r := StatementTimeoutSuite{DaoSuite: &m}
+	suite.Run(t, &r)
+}
+
+func (s *StatementTimeoutSuite) SetupTest() {
+	s.DaoSuite.SetupTest()
+	s.dbConfig = config.Get().Database
+}
+
+func (s *StatementTimeoutSuite) TearDownTest() {
+	config.Get().Database = s.dbConfig
+	s.DaoSuite.TearDownTest()
+}
+
+// sleep runs a query lasting a second, returning how long it ran and its error
+func (s *StatementTimeoutSuite) sleep(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	err := s.tx.WithContext(ctx).Exec("SELECT pg_sleep(1)").Error
+	return time.Since(start), err
+}
+
+func (s *StatementTimeoutSuite) TestCanceledContext() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err := usageDaoImpl{db: s.tx}.List(ctx, api.PaginationData{Limit: 10}, api.UsageFilterData{})
+	assert.Error(s.T(), err)
+}
+
+func (s *StatementTimeoutSuite) TestQueryAbortsWhenCanceled() {
+	config.Get().Database.StatementTimeout = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	elapsed, err := s.sleep(ctx)
+	assert.Error(s.T(), err)
+	assert.Less(s.T(), elapsed, time.Second)
+}
+
+func (s *StatementTimeoutSuite) TestStatementTimeout() {
+	config.Get().Database.StatementTimeout = 50 * time.Millisecond
+
+	elapsed, err := s.sleep(context.Background())
+	assert.Error(s.T(), err)
+	assert.Less(s.T(), elapsed, time.Second)
+
+	daoError := DBErrorToApi(err)
+	assert.True(s.T(), daoError.Timeout)
+	assert.Equal(s.T(), 504, ce.HttpCodeForDaoError(daoError))
+}
+
+func (s *StatementTimeoutSuite) TestStatementTimeoutOfQueryClass() {
+	config.Get().Database.StatementTimeout = 50 * time.Millisecond
+	config.Get().Database.BulkStatementTimeout = 0
+	config.Get().Database.ReportStatementTimeout = 50 * time.Millisecond
+
+	// Bulk queries are not limited, while report queries are
+	start := time.Now()
+	err := s.tx.WithContext(db.WithQueryClass(context.Background(), db.QueryBulk)).Exec("SELECT pg_sleep(0.2)").Error
+	assert.NoError(s.T(), err)
+	assert.GreaterOrEqual(s.T(), time.Since(start), 200*time.Millisecond)
+
+	elapsed, err := s.sleep(db.WithQueryClass(context.Background(), db.QueryReport))
+	assert.Error(s.T(), err)
+	assert.Less(s.T(), elapsed, time.Second)
+}
+
+func (s *StatementTimeoutSuite) TestChainedQueriesKeepContext() {
+	config.Get().Database.StatementTimeout = time.Second
+
+	// A statement reused by several queries is given a new timeout for each of them
+	var count int64
+	query := s.tx.WithContext(context.Background()).Table("repositories")
+	assert.NoError(s.T(), query.Count(&count).Error)
+	assert.NoError(s.T(), query.Count(&count).Error)
+}
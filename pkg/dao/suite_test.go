@@ -1,26 +1,22 @@
 package dao
 
 import (
-	"log"
-	"os"
 	"time"
 
 	"github.com/content-services/content-sources-backend/pkg/config"
-	"github.com/content-services/content-sources-backend/pkg/db"
 	"github.com/content-services/content-sources-backend/pkg/models"
 	"github.com/content-services/content-sources-backend/pkg/seeds"
+	"github.com/content-services/content-sources-backend/pkg/test/database"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
-	"github.com/stretchr/testify/suite"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
+// DaoSuite runs against its own schema, db, each test in a transaction, tx, rolled back once it completes
 type DaoSuite struct {
-	suite.Suite
-	db                        *gorm.DB
-	tx                        *gorm.DB
-	skipDefaultTransactionOld bool
+	database.Suite
+	db *gorm.DB
+	tx *gorm.DB
 }
 
 var orgIDTest = seeds.RandomOrgId()
@@ -103,26 +99,9 @@ var repoRpmTest2 = models.Rpm{
 	Checksum: "SHA1:6799a487f8eaf5c6ad6aba43e1dc4503e69e75bd",
 }
 
-func (s *DaoSuite) TearDownTest() {
-	//Rollback and reset db.DB
-	s.tx.Rollback()
-	s.db.SkipDefaultTransaction = s.skipDefaultTransactionOld
-}
-
+// SetupTest begins the transaction of the test in the schema of the suite
 func (s *DaoSuite) SetupTest() {
-	if db.DB == nil {
-		if err := db.Connect(); err != nil {
-			s.FailNow(err.Error())
-		}
-	}
-	s.skipDefaultTransactionOld = db.DB.SkipDefaultTransaction
-	s.db = db.DB.Session(&gorm.Session{
-		SkipDefaultTransaction: false,
-		Logger: logger.New(
-			log.New(os.Stderr, "\r\n", log.LstdFlags),
-			logger.Config{
-				LogLevel: logger.Info,
-			}),
-	})
-	s.tx = s.db.Begin()
+	s.Suite.SetupTest()
+	s.db = s.Schema.DB
+	s.tx = s.Tx
 }
@@ -1,17 +1,22 @@
 package dao
 
 import (
+	"context"
 	"time"
 
 	"github.com/content-services/content-sources-backend/pkg/api"
 	"github.com/content-services/content-sources-backend/pkg/config"
 	ce "github.com/content-services/content-sources-backend/pkg/errors"
 	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/pulp_client"
+	"github.com/content-services/content-sources-backend/pkg/tasks/payloads"
+	zest "github.com/content-services/zest/release/v2023"
 	"gorm.io/gorm"
 )
 
 type taskInfoDaoImpl struct {
-	db *gorm.DB
+	db         *gorm.DB
+	pulpClient pulp_client.PulpGlobalClient
 }
 
 func GetTaskInfoDao(db *gorm.DB) TaskInfoDao {
@@ -20,23 +25,91 @@ func GetTaskInfoDao(db *gorm.DB) TaskInfoDao {
 	}
 }
 
-func (t taskInfoDaoImpl) Fetch(orgId string, id string) (api.TaskInfoResponse, error) {
-	taskInfo := models.TaskInfo{}
-	result := t.db.Where("id = ? AND org_id = ?", id, orgId).First(&taskInfo)
+func (t taskInfoDaoImpl) Fetch(ctx context.Context, orgId string, id string) (api.TaskInfoResponse, error) {
 	taskInfoResponse := api.TaskInfoResponse{}
+	taskInfo, err := t.fetchTaskInfo(ctx, orgId, id)
+	if err != nil {
+		return taskInfoResponse, err
+	}
+	taskInfoModelToApiFields(&taskInfo, &taskInfoResponse)
+	return taskInfoResponse, nil
+}
 
+func (t taskInfoDaoImpl) fetchTaskInfo(ctx context.Context, orgId string, id string) (models.TaskInfo, error) {
+	taskInfo := models.TaskInfo{}
+	result := t.db.WithContext(ctx).Scopes(WithOrg(orgId)).Where("id = ?", id).First(&taskInfo)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
-			return taskInfoResponse, &ce.DaoError{NotFound: true, Message: "Could not find task with UUID " + id}
+			return taskInfo, &ce.DaoError{NotFound: true, Message: "Could not find task with UUID " + id}
 		} else {
-			return taskInfoResponse, result.Error
+			return taskInfo, result.Error
 		}
 	}
-	taskInfoModelToApiFields(&taskInfo, &taskInfoResponse)
-	return taskInfoResponse, nil
+	return taskInfo, nil
+}
+
+// FetchEvent returns the status of a task, along with the progress of running snapshots read from pulp
+func (t taskInfoDaoImpl) FetchEvent(ctx context.Context, orgId string, id string) (api.TaskEventResponse, error) {
+	taskInfo, err := t.fetchTaskInfo(ctx, orgId, id)
+	if err != nil {
+		return api.TaskEventResponse{}, err
+	}
+	event := api.TaskEventResponse{UUID: taskInfo.Id.String(), Status: taskInfo.Status}
+	if taskInfo.Error != nil {
+		event.Error = *taskInfo.Error
+	}
+	if taskInfo.Typename != payloads.Snapshot || taskInfo.Status != config.TaskStatusRunning {
+		return event, nil
+	}
+
+	var payload payloads.SnapshotPayload
+	if err := payloads.Decode(taskInfo.Typename, taskInfo.Payload, &payload); err != nil {
+		return event, nil
+	}
+	switch {
+	case payload.DistributionTaskHref != nil:
+		event.Step = "distribution"
+	case payload.PublicationTaskHref != nil:
+		event.Step = "publication"
+	case payload.SyncTaskHref != nil:
+		event.Step = "sync"
+		if t.pulpClient != nil {
+			sync, err := t.pulpClient.GetTask(*payload.SyncTaskHref)
+			if err != nil {
+				return event, err
+			}
+			event.Progress = syncProgress(sync.ProgressReports)
+		}
+	}
+	if event.Step != "sync" {
+		done := 100
+		event.Progress = &done
+	}
+	return event, nil
+}
+
+// syncProgress returns the percentage of the items processed by the reports of a sync task
+// that have a total, or nil if none does yet
+func syncProgress(reports []zest.ProgressReportResponse) *int {
+	var done, total int64
+	for _, report := range reports {
+		if report.Total == nil || *report.Total == 0 {
+			continue
+		}
+		total += *report.Total
+		if report.Done != nil {
+			done += *report.Done
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	progress := int(done * 100 / total)
+	return &progress
 }
 
 func (t taskInfoDaoImpl) List(
+	ctx context.Context,
 	orgId string,
 	pageData api.PaginationData,
 	statusFilter string,
@@ -44,13 +117,16 @@ func (t taskInfoDaoImpl) List(
 	var totalTasks int64
 	tasks := make([]models.TaskInfo, 0)
 
-	filteredDB := t.db.Where("org_id = ?", orgId)
+	filteredDB := t.db.WithContext(ctx).Scopes(WithOrg(orgId))
 
 	if statusFilter != "" {
 		filteredDB = filteredDB.Where("status = ?", statusFilter)
 	}
 
-	filteredDB.Find(&tasks).Count(&totalTasks)
+	filteredDB = filteredDB.Model(&models.TaskInfo{})
+	if err := countTotal(filteredDB, pageData, &totalTasks); err != nil {
+		return api.TaskInfoCollectionResponse{}, 0, err
+	}
 	// Most recently queued (created) first
 	filteredDB.Order("queued_at DESC").Offset(pageData.Offset).Limit(pageData.Limit).Find(&tasks)
 
@@ -61,10 +137,10 @@ func (t taskInfoDaoImpl) List(
 	return api.TaskInfoCollectionResponse{Data: taskResponses}, totalTasks, nil
 }
 
-func (t taskInfoDaoImpl) IsSnapshotInProgress(orgID, repoUUID string) (bool, error) {
+func (t taskInfoDaoImpl) IsSnapshotInProgress(ctx context.Context, orgID, repoUUID string) (bool, error) {
 	taskInfo := models.TaskInfo{}
-	result := t.db.Where("org_id = ? and repository_uuid = ? and status = ? and type = ?",
-		orgID, repoUUID, config.TaskStatusRunning, config.RepositorySnapshotTask).First(&taskInfo)
+	result := t.db.WithContext(ctx).Scopes(WithOrg(orgID)).Where("repository_uuid = ? and status = ? and type = ?",
+		repoUUID, config.TaskStatusRunning, config.RepositorySnapshotTask).First(&taskInfo)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
 			return false, nil
@@ -78,10 +154,43 @@ func (t taskInfoDaoImpl) IsSnapshotInProgress(orgID, repoUUID string) (bool, err
 	return false, nil
 }
 
+// CountsByRepository returns the number of tasks of each of the repositories by status, by repository uuid
+func (t taskInfoDaoImpl) CountsByRepository(ctx context.Context, orgID string, repoUUIDs []string) (map[string]api.TaskCounts, error) {
+	counts := make(map[string]api.TaskCounts, len(repoUUIDs))
+	if len(repoUUIDs) == 0 {
+		return counts, nil
+	}
+	rows := []struct {
+		RepositoryUUID string
+		Status         string
+		Count          int64
+	}{}
+	err := readOnly(ctx, t.db, func(conn *gorm.DB) error {
+		return conn.Model(&models.TaskInfo{}).
+			Select("repository_uuid, status, COUNT(*) AS count").
+			Scopes(WithOrg(orgID)).
+			Where("repository_uuid IN ?", repoUUIDs).
+			Group("repository_uuid, status").
+			Scan(&rows).Error
+	})
+	if err != nil {
+		return nil, DBErrorToApi(err)
+	}
+	for _, row := range rows {
+		if counts[row.RepositoryUUID] == nil {
+			counts[row.RepositoryUUID] = api.TaskCounts{}
+		}
+		counts[row.RepositoryUUID][row.Status] = row.Count
+	}
+	return counts, nil
+}
+
 func taskInfoModelToApiFields(taskInfo *models.TaskInfo, apiTaskInfo *api.TaskInfoResponse) {
 	apiTaskInfo.UUID = taskInfo.Id.String()
 	apiTaskInfo.OrgId = taskInfo.OrgId
 	apiTaskInfo.Status = taskInfo.Status
+	apiTaskInfo.RequestId = taskInfo.RequestID
+	apiTaskInfo.Username = taskInfo.Username
 
 	if taskInfo.Error != nil {
 		apiTaskInfo.Error = *taskInfo.Error
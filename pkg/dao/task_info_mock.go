@@ -3,6 +3,8 @@
 package dao
 
 import (
+	context "context"
+
 	api "github.com/content-services/content-sources-backend/pkg/api"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -12,23 +14,73 @@ type MockTaskInfoDao struct {
 	mock.Mock
 }
 
-// Fetch provides a mock function with given fields: OrgID, id
-func (_m *MockTaskInfoDao) Fetch(OrgID string, id string) (api.TaskInfoResponse, error) {
-	ret := _m.Called(OrgID, id)
+// CountsByRepository provides a mock function with given fields: ctx, orgID, repoUUIDs
+func (_m *MockTaskInfoDao) CountsByRepository(ctx context.Context, orgID string, repoUUIDs []string) (map[string]api.TaskCounts, error) {
+	ret := _m.Called(ctx, orgID, repoUUIDs)
+
+	var r0 map[string]api.TaskCounts
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) (map[string]api.TaskCounts, error)); ok {
+		return rf(ctx, orgID, repoUUIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) map[string]api.TaskCounts); ok {
+		r0 = rf(ctx, orgID, repoUUIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]api.TaskCounts)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = rf(ctx, orgID, repoUUIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Fetch provides a mock function with given fields: ctx, OrgID, id
+func (_m *MockTaskInfoDao) Fetch(ctx context.Context, OrgID string, id string) (api.TaskInfoResponse, error) {
+	ret := _m.Called(ctx, OrgID, id)
 
 	var r0 api.TaskInfoResponse
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string) (api.TaskInfoResponse, error)); ok {
-		return rf(OrgID, id)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (api.TaskInfoResponse, error)); ok {
+		return rf(ctx, OrgID, id)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) api.TaskInfoResponse); ok {
-		r0 = rf(OrgID, id)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) api.TaskInfoResponse); ok {
+		r0 = rf(ctx, OrgID, id)
 	} else {
 		r0 = ret.Get(0).(api.TaskInfoResponse)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(OrgID, id)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, OrgID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FetchEvent provides a mock function with given fields: ctx, OrgID, id
+func (_m *MockTaskInfoDao) FetchEvent(ctx context.Context, OrgID string, id string) (api.TaskEventResponse, error) {
+	ret := _m.Called(ctx, OrgID, id)
+
+	var r0 api.TaskEventResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (api.TaskEventResponse, error)); ok {
+		return rf(ctx, OrgID, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) api.TaskEventResponse); ok {
+		r0 = rf(ctx, OrgID, id)
+	} else {
+		r0 = ret.Get(0).(api.TaskEventResponse)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, OrgID, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -36,23 +88,23 @@ func (_m *MockTaskInfoDao) Fetch(OrgID string, id string) (api.TaskInfoResponse,
 	return r0, r1
 }
 
-// IsSnapshotInProgress provides a mock function with given fields: orgID, repoUUID
-func (_m *MockTaskInfoDao) IsSnapshotInProgress(orgID string, repoUUID string) (bool, error) {
-	ret := _m.Called(orgID, repoUUID)
+// IsSnapshotInProgress provides a mock function with given fields: ctx, orgID, repoUUID
+func (_m *MockTaskInfoDao) IsSnapshotInProgress(ctx context.Context, orgID string, repoUUID string) (bool, error) {
+	ret := _m.Called(ctx, orgID, repoUUID)
 
 	var r0 bool
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string) (bool, error)); ok {
-		return rf(orgID, repoUUID)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (bool, error)); ok {
+		return rf(ctx, orgID, repoUUID)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) bool); ok {
-		r0 = rf(orgID, repoUUID)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) bool); ok {
+		r0 = rf(ctx, orgID, repoUUID)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(orgID, repoUUID)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, orgID, repoUUID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -60,30 +112,30 @@ func (_m *MockTaskInfoDao) IsSnapshotInProgress(orgID string, repoUUID string) (
 	return r0, r1
 }
 
-// List provides a mock function with given fields: OrgID, pageData, statusFilter
-func (_m *MockTaskInfoDao) List(OrgID string, pageData api.PaginationData, statusFilter string) (api.TaskInfoCollectionResponse, int64, error) {
-	ret := _m.Called(OrgID, pageData, statusFilter)
+// List provides a mock function with given fields: ctx, OrgID, pageData, statusFilter
+func (_m *MockTaskInfoDao) List(ctx context.Context, OrgID string, pageData api.PaginationData, statusFilter string) (api.TaskInfoCollectionResponse, int64, error) {
+	ret := _m.Called(ctx, OrgID, pageData, statusFilter)
 
 	var r0 api.TaskInfoCollectionResponse
 	var r1 int64
 	var r2 error
-	if rf, ok := ret.Get(0).(func(string, api.PaginationData, string) (api.TaskInfoCollectionResponse, int64, error)); ok {
-		return rf(OrgID, pageData, statusFilter)
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.PaginationData, string) (api.TaskInfoCollectionResponse, int64, error)); ok {
+		return rf(ctx, OrgID, pageData, statusFilter)
 	}
-	if rf, ok := ret.Get(0).(func(string, api.PaginationData, string) api.TaskInfoCollectionResponse); ok {
-		r0 = rf(OrgID, pageData, statusFilter)
+	if rf, ok := ret.Get(0).(func(context.Context, string, api.PaginationData, string) api.TaskInfoCollectionResponse); ok {
+		r0 = rf(ctx, OrgID, pageData, statusFilter)
 	} else {
 		r0 = ret.Get(0).(api.TaskInfoCollectionResponse)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, api.PaginationData, string) int64); ok {
-		r1 = rf(OrgID, pageData, statusFilter)
+	if rf, ok := ret.Get(1).(func(context.Context, string, api.PaginationData, string) int64); ok {
+		r1 = rf(ctx, OrgID, pageData, statusFilter)
 	} else {
 		r1 = ret.Get(1).(int64)
 	}
 
-	if rf, ok := ret.Get(2).(func(string, api.PaginationData, string) error); ok {
-		r2 = rf(OrgID, pageData, statusFilter)
+	if rf, ok := ret.Get(2).(func(context.Context, string, api.PaginationData, string) error); ok {
+		r2 = rf(ctx, OrgID, pageData, statusFilter)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -1,14 +1,18 @@
 package dao
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 	"time"
 
 	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/config"
 	ce "github.com/content-services/content-sources-backend/pkg/errors"
 	"github.com/content-services/content-sources-backend/pkg/models"
 	"github.com/content-services/content-sources-backend/pkg/seeds"
+	"github.com/content-services/content-sources-backend/pkg/tasks/payloads"
+	zest "github.com/content-services/zest/release/v2023"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -30,7 +34,7 @@ func (suite *TaskInfoSuite) TestFetch() {
 	t := suite.T()
 
 	dao := GetTaskInfoDao(suite.tx)
-	fetchedTask, err := dao.Fetch(task.OrgId, task.Id.String())
+	fetchedTask, err := dao.Fetch(context.Background(), task.OrgId, task.Id.String())
 	assert.NoError(t, err)
 
 	fetchedUUID, uuidErr := uuid.Parse(fetchedTask.UUID)
@@ -43,6 +47,49 @@ func (suite *TaskInfoSuite) TestFetch() {
 	assert.Equal(t, *task.Error, fetchedTask.Error)
 }
 
+func (suite *TaskInfoSuite) TestFetchEvent() {
+	task := suite.createTask()
+	t := suite.T()
+
+	dao := GetTaskInfoDao(suite.tx)
+	event, err := dao.FetchEvent(context.Background(), task.OrgId, task.Id.String())
+	require.NoError(t, err)
+	assert.Equal(t, api.TaskEventResponse{UUID: task.Id.String(), Status: task.Status, Error: *task.Error}, event)
+
+	// Running snapshots report the step they are at
+	publicationHref := "/pulp/api/v3/tasks/publication/"
+	payload, err := json.Marshal(payloads.SnapshotPayload{SyncTaskHref: &publicationHref, PublicationTaskHref: &publicationHref})
+	require.NoError(t, err)
+	require.NoError(t, suite.tx.Model(&task).Updates(map[string]interface{}{
+		"type":    payloads.Snapshot,
+		"status":  config.TaskStatusRunning,
+		"payload": payload,
+	}).Error)
+
+	event, err = dao.FetchEvent(context.Background(), task.OrgId, task.Id.String())
+	require.NoError(t, err)
+	assert.Equal(t, "publication", event.Step)
+	require.NotNil(t, event.Progress)
+	assert.Equal(t, 100, *event.Progress)
+
+	_, err = dao.FetchEvent(context.Background(), "bad org id", task.Id.String())
+	assert.Error(t, err)
+}
+
+func TestSyncProgress(t *testing.T) {
+	total, done, other := int64(200), int64(50), int64(7)
+	reports := []zest.ProgressReportResponse{
+		{Total: &total, Done: &done},
+		// Reports without a total are ignored
+		{Done: &other},
+	}
+	progress := syncProgress(reports)
+	require.NotNil(t, progress)
+	assert.Equal(t, 25, *progress)
+
+	assert.Nil(t, syncProgress([]zest.ProgressReportResponse{{Done: &other}}))
+}
+
 func (suite *TaskInfoSuite) TestFetchNotFound() {
 	task := suite.createTask()
 	t := suite.T()
@@ -50,7 +97,7 @@ func (suite *TaskInfoSuite) TestFetchNotFound() {
 
 	var err error
 
-	_, err = dao.Fetch("bad org id", task.Id.String())
+	_, err = dao.Fetch(context.Background(), "bad org id", task.Id.String())
 	assert.NotNil(t, err)
 	daoError, ok := err.(*ce.DaoError)
 	assert.True(t, ok)
@@ -58,13 +105,22 @@ func (suite *TaskInfoSuite) TestFetchNotFound() {
 
 	otherUUID := uuid.NewString()
 
-	_, err = dao.Fetch(task.OrgId, otherUUID)
+	_, err = dao.Fetch(context.Background(), task.OrgId, otherUUID)
 	assert.NotNil(t, err)
 	daoError, ok = err.(*ce.DaoError)
 	assert.True(t, ok)
 	assert.True(t, daoError.NotFound)
 }
 
+func (suite *TaskInfoSuite) TestFetchEmptyOrg() {
+	task := suite.createTask()
+	t := suite.T()
+	dao := GetTaskInfoDao(suite.tx)
+
+	_, err := dao.Fetch(context.Background(), "", task.Id.String())
+	assert.ErrorIs(t, err, ErrMissingOrgID)
+}
+
 func (suite *TaskInfoSuite) TestList() {
 	task := suite.createTask()
 	t := suite.T()
@@ -78,7 +134,7 @@ func (suite *TaskInfoSuite) TestList() {
 	statusFilter := ""
 	var err error
 
-	response, total, err := dao.List(task.OrgId, pageData, statusFilter)
+	response, total, err := dao.List(context.Background(), task.OrgId, pageData, statusFilter)
 	assert.Nil(t, err)
 	assert.Equal(t, int64(1), total)
 	assert.Equal(t, 1, len(response.Data))
@@ -108,7 +164,7 @@ func (suite *TaskInfoSuite) TestListNoRepositories() {
 	statusFilter := ""
 	var err error
 
-	response, total, err := dao.List(otherOrgId, pageData, statusFilter)
+	response, total, err := dao.List(context.Background(), otherOrgId, pageData, statusFilter)
 	assert.Nil(t, err)
 	assert.Equal(t, int64(0), total)
 	assert.Equal(t, 0, len(response.Data))
@@ -137,7 +193,7 @@ func (suite *TaskInfoSuite) TestListPageLimit() {
 	assert.Nil(t, result.Error)
 	assert.Equal(t, int64(20), total)
 
-	response, total, err := dao.List(orgID, pageData, statusFilter)
+	response, total, err := dao.List(context.Background(), orgID, pageData, statusFilter)
 	assert.Nil(t, err)
 	assert.Equal(t, pageData.Limit, len(response.Data))
 	assert.Equal(t, int64(20), total)
@@ -173,7 +229,7 @@ func (suite *TaskInfoSuite) TestListOffsetPage() {
 	assert.Nil(t, result.Error)
 	assert.Equal(t, int64(11), total)
 
-	response, total, err := dao.List(orgID, pageData, statusFilter)
+	response, total, err := dao.List(context.Background(), orgID, pageData, statusFilter)
 	assert.Nil(t, err)
 	assert.Equal(t, pageData.Limit, len(response.Data))
 	assert.Equal(t, int64(11), total)
@@ -183,7 +239,7 @@ func (suite *TaskInfoSuite) TestListOffsetPage() {
 		Offset: 10,
 	}
 
-	nextResponse, nextTotal, err := dao.List(orgID, nextPageData, statusFilter)
+	nextResponse, nextTotal, err := dao.List(context.Background(), orgID, nextPageData, statusFilter)
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(nextResponse.Data))
 	assert.Equal(t, int64(11), nextTotal)
@@ -226,7 +282,7 @@ func (suite *TaskInfoSuite) TestListFilterStatus() {
 	assert.Nil(t, result.Error)
 	assert.Equal(t, int64(30), total)
 
-	response, total, err := dao.List(orgID, pageData, statusFilter)
+	response, total, err := dao.List(context.Background(), orgID, pageData, statusFilter)
 	assert.Nil(t, err)
 	assert.Equal(t, 10, len(response.Data))
 	assert.Equal(t, int64(10), total)
@@ -260,7 +316,7 @@ func (suite *TaskInfoSuite) TestIsSnapshotInProgress() {
 	createErr = suite.tx.Create(notRunningSnap).Error
 	require.NoError(t, createErr)
 
-	val, err := dao.IsSnapshotInProgress(orgID, repoUUID.String())
+	val, err := dao.IsSnapshotInProgress(context.Background(), orgID, repoUUID.String())
 	assert.NoError(t, err)
 	assert.False(t, val)
 
@@ -275,15 +331,50 @@ func (suite *TaskInfoSuite) TestIsSnapshotInProgress() {
 	createErr = suite.tx.Create(runningSnap).Error
 	require.NoError(t, createErr)
 
-	val, err = dao.IsSnapshotInProgress(orgID, repoUUID.String())
+	val, err = dao.IsSnapshotInProgress(context.Background(), orgID, repoUUID.String())
 	assert.NoError(t, err)
 	assert.True(t, val)
 
-	val, err = dao.IsSnapshotInProgress("bad org ID", repoUUID.String())
+	val, err = dao.IsSnapshotInProgress(context.Background(), "bad org ID", repoUUID.String())
 	assert.NoError(t, err)
 	assert.False(t, val)
 }
 
+func (suite *TaskInfoSuite) TestCountsByRepository() {
+	t := suite.T()
+	dao := GetTaskInfoDao(suite.tx)
+	repoUUID := uuid.New()
+	otherRepoUUID := uuid.New()
+	orgID := seeds.RandomOrgId()
+
+	for _, status := range []string{"completed", "completed", "failed"} {
+		task := models.TaskInfo{
+			Typename:       "introspect",
+			Status:         status,
+			RepositoryUUID: repoUUID,
+			Token:          uuid.New(),
+			Id:             uuid.New(),
+			OrgId:          orgID,
+		}
+		require.NoError(t, suite.tx.Create(task).Error)
+	}
+	task := models.TaskInfo{
+		Typename:       "snapshot",
+		Status:         "running",
+		RepositoryUUID: repoUUID,
+		Token:          uuid.New(),
+		Id:             uuid.New(),
+		OrgId:          "other org",
+	}
+	require.NoError(t, suite.tx.Create(task).Error)
+
+	counts, err := dao.CountsByRepository(context.Background(), orgID, []string{repoUUID.String(), otherRepoUUID.String()})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]api.TaskCounts{
+		repoUUID.String(): {"completed": 2, "failed": 1},
+	}, counts)
+}
+
 func (suite *TaskInfoSuite) createTask() models.TaskInfo {
 	t := suite.T()
 	var queued = time.Now()
@@ -0,0 +1,131 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/db"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// repositoriesPerOrgRanges buckets the orgs by number of repositories, so that no org can be recognized
+// by its exact count
+var repositoriesPerOrgRanges = []struct {
+	key string
+	min int64
+	max int64 // 0 for no maximum
+}{
+	{"1", 1, 1},
+	{"2-5", 2, 5},
+	{"6-10", 6, 10},
+	{"11-50", 11, 50},
+	{"51-100", 51, 100},
+	{"101+", 101, 0},
+}
+
+// telemetryFeatures are the features whose adoption is counted, as the condition an org using it meets
+var telemetryFeatures = []struct {
+	key       string
+	condition string
+}{
+	{"snapshot", "EXISTS (SELECT 1 FROM repository_configurations rc WHERE rc.org_id = orgs.org_id AND rc.deleted_at IS NULL AND rc.snapshot)"},
+	{"metadata_verification", "EXISTS (SELECT 1 FROM repository_configurations rc WHERE rc.org_id = orgs.org_id AND rc.deleted_at IS NULL AND rc.metadata_verification)"},
+	{"gpg_key", "EXISTS (SELECT 1 FROM repository_configurations rc WHERE rc.org_id = orgs.org_id AND rc.deleted_at IS NULL AND rc.gpg_key != '')"},
+	{"proxy", "EXISTS (SELECT 1 FROM repository_configurations rc WHERE rc.org_id = orgs.org_id AND rc.deleted_at IS NULL AND rc.proxy_url != '')"},
+	{"authentication", "EXISTS (SELECT 1 FROM repository_configurations rc WHERE rc.org_id = orgs.org_id AND rc.deleted_at IS NULL AND rc.username != '')"},
+	{"webhooks", "EXISTS (SELECT 1 FROM webhooks w WHERE w.org_id = orgs.org_id AND w.enabled)"},
+	{"settings", "EXISTS (SELECT 1 FROM org_settings s WHERE s.org_id = orgs.org_id)"},
+}
+
+// telemetryOrgs are the orgs with repositories, leaving out the one holding shared snapshots
+const telemetryOrgs = "SELECT org_id, COUNT(*) AS repositories FROM repository_configurations WHERE deleted_at IS NULL AND org_id != ? GROUP BY org_id"
+
+type telemetryDaoImpl struct {
+	db *gorm.DB
+}
+
+func GetTelemetryDao(db *gorm.DB) TelemetryDao {
+	return telemetryDaoImpl{db: db}
+}
+
+// Compute returns the statistics of the day: the orgs by number of repositories, the topHosts hosts of
+// repositories used by the most orgs, and the orgs using each feature.  Hosts used by less than minOrgs
+// orgs are left out, as they could tell an org apart.
+func (t telemetryDaoImpl) Compute(ctx context.Context, day time.Time, topHosts int, minOrgs int) ([]models.TelemetryStat, error) {
+	ctx = db.WithQueryClass(ctx, db.QueryReport)
+	day = day.UTC().Truncate(24 * time.Hour)
+	stats := []models.TelemetryStat{}
+
+	var orgs int64
+	if err := t.db.WithContext(ctx).Raw("SELECT COUNT(*) FROM ("+telemetryOrgs+") orgs", config.SharedSnapshotsOrg).Scan(&orgs).Error; err != nil {
+		return nil, DBErrorToApi(err)
+	}
+	stats = append(stats, models.TelemetryStat{Day: day, Metric: models.TelemetryFeatureAdoption, Key: "orgs", Value: orgs})
+
+	for _, r := range repositoriesPerOrgRanges {
+		query := t.db.WithContext(ctx).Table("("+telemetryOrgs+") orgs", config.SharedSnapshotsOrg).
+			Where("repositories >= ?", r.min)
+		if r.max > 0 {
+			query = query.Where("repositories <= ?", r.max)
+		}
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			return nil, DBErrorToApi(err)
+		}
+		stats = append(stats, models.TelemetryStat{Day: day, Metric: models.TelemetryRepositoriesPerOrg, Key: r.key, Value: count})
+	}
+
+	hosts := []struct {
+		Host string
+		Orgs int64
+	}{}
+	err := t.db.WithContext(ctx).
+		Table("repository_configurations rc").
+		Select("substring(r.url from '^[a-zA-Z]+://([^/:@]+)') AS host, COUNT(DISTINCT rc.org_id) AS orgs").
+		Joins("INNER JOIN repositories r ON r.uuid = rc.repository_uuid").
+		Where("rc.deleted_at IS NULL AND rc.org_id != ? AND NOT r.public", config.SharedSnapshotsOrg).
+		Group("host").
+		Having("COUNT(DISTINCT rc.org_id) >= ?", minOrgs).
+		Order("orgs DESC, host").
+		Limit(topHosts).
+		Scan(&hosts).Error
+	if err != nil {
+		return nil, DBErrorToApi(err)
+	}
+	for _, host := range hosts {
+		if host.Host == "" {
+			continue
+		}
+		stats = append(stats, models.TelemetryStat{Day: day, Metric: models.TelemetryExternalHosts, Key: host.Host, Value: host.Orgs})
+	}
+
+	for _, feature := range telemetryFeatures {
+		var count int64
+		err := t.db.WithContext(ctx).Table("("+telemetryOrgs+") orgs", config.SharedSnapshotsOrg).
+			Where(feature.condition).
+			Count(&count).Error
+		if err != nil {
+			return nil, DBErrorToApi(err)
+		}
+		stats = append(stats, models.TelemetryStat{Day: day, Metric: models.TelemetryFeatureAdoption, Key: feature.key, Value: count})
+	}
+	return stats, nil
+}
+
+// Save stores the statistics, replacing the ones computed earlier the same day
+func (t telemetryDaoImpl) Save(ctx context.Context, stats []models.TelemetryStat) error {
+	if len(stats) == 0 {
+		return nil
+	}
+	err := t.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "day"}, {Name: "metric"}, {Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value"}),
+	}).Create(&stats).Error
+	if err != nil {
+		return DBErrorToApi(err)
+	}
+	return nil
+}
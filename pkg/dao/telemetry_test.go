@@ -0,0 +1,76 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/seeds"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type TelemetrySuite struct {
+	*DaoSuite
+}
+
+func TestTelemetrySuite(t *testing.T) {
+	m := DaoSuite{}
+	r := TelemetrySuite{DaoSuite: &m}
+	suite.Run(t, &r)
+}
+
+func findStat(stats []models.TelemetryStat, metric string, key string) *models.TelemetryStat {
+	for i := range stats {
+		if stats[i].Metric == metric && stats[i].Key == key {
+			return &stats[i]
+		}
+	}
+	return nil
+}
+
+func (s *TelemetrySuite) TestComputeAndSave() {
+	t := s.T()
+	telemetryDao := telemetryDaoImpl{db: s.tx}
+	day := time.Date(2023, 9, 11, 2, 0, 0, 0, time.UTC)
+
+	before, err := telemetryDao.Compute(context.Background(), day, 20, 1)
+	require.NoError(t, err)
+	orgs := findStat(before, models.TelemetryFeatureAdoption, "orgs")
+	require.NotNil(t, orgs)
+
+	// A new org with three snapshotted repositories
+	orgID := seeds.RandomOrgId()
+	require.NoError(t, seeds.SeedRepositoryConfigurations(s.tx, 3, seeds.SeedOptions{OrgID: orgID}))
+	require.NoError(t, s.tx.Model(&models.RepositoryConfiguration{}).Where("org_id = ?", orgID).Update("snapshot", true).Error)
+
+	stats, err := telemetryDao.Compute(context.Background(), day, 20, 1)
+	require.NoError(t, err)
+	assert.Equal(t, orgs.Value+1, findStat(stats, models.TelemetryFeatureAdoption, "orgs").Value)
+	assert.Equal(t, findStat(before, models.TelemetryRepositoriesPerOrg, "2-5").Value+1,
+		findStat(stats, models.TelemetryRepositoriesPerOrg, "2-5").Value)
+	assert.Equal(t, findStat(before, models.TelemetryFeatureAdoption, "snapshot").Value+1,
+		findStat(stats, models.TelemetryFeatureAdoption, "snapshot").Value)
+	for _, stat := range stats {
+		assert.Equal(t, "2023-09-11", stat.Day.Format("2006-01-02"))
+		assert.NotContains(t, stat.Key, orgID, fmt.Sprintf("%v leaks the org", stat.Metric))
+	}
+
+	// Hosts used by too few orgs are left out
+	stats, err = telemetryDao.Compute(context.Background(), day, 20, 1000000)
+	require.NoError(t, err)
+	for _, stat := range stats {
+		assert.NotEqual(t, models.TelemetryExternalHosts, stat.Metric)
+	}
+
+	// Saving again the same day replaces the statistics
+	require.NoError(t, telemetryDao.Save(context.Background(), stats))
+	stats[0].Value = 42
+	require.NoError(t, telemetryDao.Save(context.Background(), stats))
+	saved := models.TelemetryStat{}
+	require.NoError(t, s.tx.Where("day = ? AND metric = ? AND key = ?", "2023-09-11", stats[0].Metric, stats[0].Key).First(&saved).Error)
+	assert.Equal(t, int64(42), saved.Value)
+}
@@ -0,0 +1,105 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/db"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const usageDayLayout = "2006-01-02"
+
+// UsageCount is a number of requests to add to the usage of an org
+type UsageCount struct {
+	OrgID  string
+	Method string
+	Route  string
+	Day    time.Time
+	Count  int64
+}
+
+type usageDaoImpl struct {
+	db *gorm.DB
+}
+
+func GetUsageDao(db *gorm.DB) UsageDao {
+	return usageDaoImpl{db: db}
+}
+
+// Record adds the counts to the daily usage rollup
+func (u usageDaoImpl) Record(ctx context.Context, counts []UsageCount) error {
+	if len(counts) == 0 {
+		return nil
+	}
+	rows := make([]models.ApiUsage, len(counts))
+	for i, count := range counts {
+		rows[i] = models.ApiUsage{
+			OrgID:        count.OrgID,
+			Method:       count.Method,
+			Route:        count.Route,
+			Day:          count.Day.UTC().Truncate(24 * time.Hour),
+			RequestCount: count.Count,
+		}
+	}
+	return u.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "org_id"}, {Name: "method"}, {Name: "route"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"request_count": gorm.Expr("api_usage.request_count + excluded.request_count"),
+		}),
+	}).Create(&rows).Error
+}
+
+func (u usageDaoImpl) List(ctx context.Context, pageData api.PaginationData, filterData api.UsageFilterData) (api.UsageCollectionResponse, int64, error) {
+	ctx = db.WithQueryClass(ctx, db.QueryReport)
+	var total int64
+	usage := make([]models.ApiUsage, 0)
+
+	filteredDB := u.db.WithContext(ctx).Model(&models.ApiUsage{})
+	if filterData.OrgID != "" {
+		filteredDB = filteredDB.Where("org_id = ?", filterData.OrgID)
+	}
+	if filterData.Route != "" {
+		filteredDB = filteredDB.Where("route = ?", filterData.Route)
+	}
+	if filterData.Since != "" {
+		since, err := time.Parse(usageDayLayout, filterData.Since)
+		if err != nil {
+			return api.UsageCollectionResponse{}, 0, &ce.DaoError{BadValidation: true, Message: "since must be a date formatted as YYYY-MM-DD"}
+		}
+		filteredDB = filteredDB.Where("day >= ?", since)
+	}
+
+	sortMap := map[string]string{
+		"org_id":        "org_id",
+		"route":         "route",
+		"day":           "day",
+		"request_count": "request_count",
+	}
+	order := "day desc, request_count desc"
+	if pageData.SortBy != "" {
+		order = convertSortByToSQL(pageData.SortBy, sortMap)
+	}
+
+	filteredDB.Count(&total)
+	filteredDB.Order(order).Offset(pageData.Offset).Limit(pageData.Limit).Find(&usage)
+	if filteredDB.Error != nil {
+		return api.UsageCollectionResponse{}, 0, filteredDB.Error
+	}
+
+	resp := make([]api.UsageResponse, len(usage))
+	for i := range usage {
+		resp[i] = api.UsageResponse{
+			OrgID:        usage[i].OrgID,
+			Method:       usage[i].Method,
+			Route:        usage[i].Route,
+			Day:          usage[i].Day.Format(usageDayLayout),
+			RequestCount: usage[i].RequestCount,
+		}
+	}
+	return api.UsageCollectionResponse{Data: resp}, total, nil
+}
@@ -0,0 +1,59 @@
+package dao
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/seeds"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UsageSuite struct {
+	*DaoSuite
+}
+
+func TestUsageSuite(t *testing.T) {
+	m := DaoSuite{}
+	r := UsageSuite{DaoSuite: &m}
+	suite.Run(t, &r)
+}
+
+func (us *UsageSuite) TestRecordAndList() {
+	t := us.T()
+	orgID := seeds.RandomOrgId()
+	usageDao := usageDaoImpl{db: us.tx}
+	day := time.Date(2023, 8, 14, 10, 0, 0, 0, time.UTC)
+	route := "/api/content-sources/v1/repositories/"
+
+	err := usageDao.Record(context.Background(), []UsageCount{
+		{OrgID: orgID, Method: http.MethodGet, Route: route, Day: day, Count: 3},
+		{OrgID: orgID, Method: http.MethodPost, Route: route, Day: day, Count: 1},
+	})
+	assert.NoError(t, err)
+	// Counts of the same day are added up
+	err = usageDao.Record(context.Background(), []UsageCount{
+		{OrgID: orgID, Method: http.MethodGet, Route: route, Day: day.Add(time.Hour), Count: 2},
+	})
+	assert.NoError(t, err)
+
+	pageData := api.PaginationData{Limit: 10, SortBy: "request_count:desc"}
+	usage, total, err := usageDao.List(context.Background(), pageData, api.UsageFilterData{OrgID: orgID, Since: "2023-08-14"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, usage.Data, 2)
+	assert.Equal(t, http.MethodGet, usage.Data[0].Method)
+	assert.Equal(t, int64(5), usage.Data[0].RequestCount)
+	assert.Equal(t, "2023-08-14", usage.Data[0].Day)
+
+	usage, total, err = usageDao.List(context.Background(), pageData, api.UsageFilterData{OrgID: orgID, Since: "2023-08-15"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	assert.Empty(t, usage.Data)
+
+	_, _, err = usageDao.List(context.Background(), pageData, api.UsageFilterData{Since: "yesterday"})
+	assert.Error(t, err)
+}
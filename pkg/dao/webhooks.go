@@ -0,0 +1,244 @@
+package dao
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/crypto"
+	"github.com/content-services/content-sources-backend/pkg/db"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/ssrf"
+	"golang.org/x/exp/slices"
+	"gorm.io/gorm"
+)
+
+const webhookSecretBytes = 32
+
+type webhookDaoImpl struct {
+	db *gorm.DB
+}
+
+func GetWebhookDao(db *gorm.DB) WebhookDao {
+	return webhookDaoImpl{db: db}
+}
+
+// Create registers a webhook, generating its secret if the request has none.  The secret is
+// stored encrypted, and only returned in plain text by Create.
+func (w webhookDaoImpl) Create(ctx context.Context, newWebhook api.WebhookRequest) (api.WebhookResponse, error) {
+	if newWebhook.OrgID == nil || *newWebhook.OrgID == "" {
+		return api.WebhookResponse{}, &ce.DaoError{BadValidation: true, Message: "Org ID cannot be blank"}
+	}
+	webhook := models.Webhook{OrgID: *newWebhook.OrgID, Enabled: true, EventTypes: []string{}}
+	if newWebhook.URL != nil {
+		webhook.URL = *newWebhook.URL
+	}
+	if newWebhook.EventTypes != nil {
+		for _, eventType := range *newWebhook.EventTypes {
+			if !slices.Contains(api.WebhookEventTypes, eventType) {
+				return api.WebhookResponse{}, &ce.DaoError{BadValidation: true, Message: "Unknown event type " + eventType}
+			}
+		}
+		webhook.EventTypes = *newWebhook.EventTypes
+	}
+	var secret string
+	if newWebhook.Secret != nil && *newWebhook.Secret != "" {
+		secret = *newWebhook.Secret
+	} else {
+		generated := make([]byte, webhookSecretBytes)
+		if _, err := rand.Read(generated); err != nil {
+			return api.WebhookResponse{}, err
+		}
+		secret = hex.EncodeToString(generated)
+	}
+
+	if err := checkWebhookURL(ctx, webhook.URL); err != nil {
+		return api.WebhookResponse{}, err
+	}
+
+	encrypted, err := crypto.Encrypt(secret)
+	if err != nil {
+		return api.WebhookResponse{}, fmt.Errorf("could not encrypt the webhook secret: %w", err)
+	}
+	webhook.Secret = encrypted
+	if err = w.db.WithContext(ctx).Create(&webhook).Error; err != nil {
+		return api.WebhookResponse{}, DBErrorToApi(err)
+	}
+	response := webhookModelToApi(webhook)
+	response.Secret = secret
+	return response, nil
+}
+
+// checkWebhookHost resolves the host of a webhook url and checks its addresses, replaced in tests
+var checkWebhookHost = ssrf.CheckHost
+
+// checkWebhookURL refuses the urls whose host resolves to a private, loopback or link-local address.
+// The host is resolved before inserting the webhook, not to hold the transaction meanwhile.  Urls
+// that are not absolute http urls are refused by the validation of the model.
+func checkWebhookURL(ctx context.Context, webhookURL string) error {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return nil
+	}
+	if err = checkWebhookHost(ctx, parsed.Hostname()); errors.Is(err, ssrf.ErrForbiddenAddress) {
+		return &ce.DaoError{BadValidation: true, Message: "URL cannot resolve to a private, loopback or link-local address."}
+	} else if err != nil {
+		return &ce.DaoError{BadValidation: true, Message: "URL host could not be resolved."}
+	}
+	return nil
+}
+
+func (w webhookDaoImpl) List(ctx context.Context, orgID string, pageData api.PaginationData) (api.WebhookCollectionResponse, int64, error) {
+	var total int64
+	webhooks := make([]models.Webhook, 0)
+	err := readOnly(ctx, w.db, func(conn *gorm.DB) error {
+		filteredDB := conn.Where("org_id = ?", orgID).Session(&gorm.Session{})
+		if err := countTotal(filteredDB.Model(&models.Webhook{}), pageData, &total); err != nil {
+			return err
+		}
+		return filteredDB.Order("created_at").Limit(pageData.Limit).Offset(pageData.Offset).Find(&webhooks).Error
+	})
+	if err != nil {
+		return api.WebhookCollectionResponse{}, 0, DBErrorToApi(err)
+	}
+	response := api.WebhookCollectionResponse{Data: make([]api.WebhookResponse, len(webhooks))}
+	for i := range webhooks {
+		response.Data[i] = webhookModelToApi(webhooks[i])
+	}
+	return response, total, nil
+}
+
+func (w webhookDaoImpl) Fetch(ctx context.Context, orgID string, uuid string) (api.WebhookResponse, error) {
+	webhook, err := w.fetchWebhook(ctx, orgID, uuid)
+	if err != nil {
+		return api.WebhookResponse{}, err
+	}
+	return webhookModelToApi(webhook), nil
+}
+
+func (w webhookDaoImpl) fetchWebhook(ctx context.Context, orgID string, uuid string) (models.Webhook, error) {
+	webhook := models.Webhook{}
+	result := w.db.WithContext(ctx).Where("org_id = ? AND uuid = ?", orgID, uuid).First(&webhook)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return webhook, &ce.DaoError{NotFound: true, Message: "Could not find webhook with UUID " + uuid}
+		}
+		return webhook, DBErrorToApi(result.Error)
+	}
+	return webhook, nil
+}
+
+// Delete removes a webhook and its deliveries
+func (w webhookDaoImpl) Delete(ctx context.Context, orgID string, uuid string) error {
+	webhook, err := w.fetchWebhook(ctx, orgID, uuid)
+	if err != nil {
+		return err
+	}
+	if err = w.db.WithContext(ctx).Delete(&webhook).Error; err != nil {
+		return DBErrorToApi(err)
+	}
+	return nil
+}
+
+// ListDeliveries lists the deliveries of a webhook, the latest first
+func (w webhookDaoImpl) ListDeliveries(ctx context.Context, orgID string, uuid string, pageData api.PaginationData) (api.WebhookDeliveryCollectionResponse, int64, error) {
+	if _, err := w.fetchWebhook(ctx, orgID, uuid); err != nil {
+		return api.WebhookDeliveryCollectionResponse{}, 0, err
+	}
+	var total int64
+	deliveries := make([]models.WebhookDelivery, 0)
+	err := readOnly(ctx, w.db, func(conn *gorm.DB) error {
+		filteredDB := conn.Where("org_id = ? AND webhook_uuid = ?", orgID, uuid).Session(&gorm.Session{})
+		if err := countTotal(filteredDB.Model(&models.WebhookDelivery{}), pageData, &total); err != nil {
+			return err
+		}
+		return filteredDB.Order("created_at DESC").Limit(pageData.Limit).Offset(pageData.Offset).Find(&deliveries).Error
+	})
+	if err != nil {
+		return api.WebhookDeliveryCollectionResponse{}, 0, DBErrorToApi(err)
+	}
+	response := api.WebhookDeliveryCollectionResponse{Data: make([]api.WebhookDeliveryResponse, len(deliveries))}
+	for i, delivery := range deliveries {
+		response.Data[i] = api.WebhookDeliveryResponse{
+			UUID:           delivery.UUID,
+			EventType:      delivery.EventType,
+			Status:         delivery.Status,
+			Attempts:       delivery.Attempts,
+			CreatedAt:      delivery.CreatedAt,
+			DeliveredAt:    delivery.DeliveredAt,
+			ResponseStatus: delivery.ResponseStatus,
+		}
+		if delivery.Status == models.WebhookDeliveryPending {
+			nextAttemptAt := delivery.NextAttemptAt
+			response.Data[i].NextAttemptAt = &nextAttemptAt
+		}
+		if delivery.LastError != nil {
+			response.Data[i].LastError = *delivery.LastError
+		}
+	}
+	return response, total, nil
+}
+
+// QueueEvent queues the event for delivery to the webhooks of the org subscribed to it
+func (w webhookDaoImpl) QueueEvent(ctx context.Context, orgID string, eventType string, data api.WebhookEventData) error {
+	return queueWebhookEvent(w.db.WithContext(ctx), orgID, eventType, data)
+}
+
+// DeleteDeliveriesBefore deletes the deliveries of every org created before the given time,
+// returning the number deleted
+func (w webhookDaoImpl) DeleteDeliveriesBefore(ctx context.Context, before time.Time) (int64, error) {
+	ctx = db.WithQueryClass(ctx, db.QueryBulk)
+	result := w.db.WithContext(ctx).Where("created_at < ?", before).Delete(&models.WebhookDelivery{})
+	return result.RowsAffected, result.Error
+}
+
+// queueWebhookEvent queues the event using the given transaction, so that it is only
+// delivered if the change that produced it is committed
+func queueWebhookEvent(tx *gorm.DB, orgID string, eventType string, data api.WebhookEventData) error {
+	var webhookUUIDs []string
+	err := tx.Model(&models.Webhook{}).
+		Where("org_id = ? AND enabled", orgID).
+		Where("(cardinality(event_types) = 0 OR ? = ANY(event_types))", eventType).
+		Pluck("uuid", &webhookUUIDs).Error
+	if err != nil || len(webhookUUIDs) == 0 {
+		return err
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	deliveries := make([]models.WebhookDelivery, len(webhookUUIDs))
+	for i, webhookUUID := range webhookUUIDs {
+		deliveries[i] = models.WebhookDelivery{
+			WebhookUUID:   webhookUUID,
+			OrgID:         orgID,
+			EventType:     eventType,
+			Payload:       payload,
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: now,
+		}
+	}
+	return tx.Create(&deliveries).Error
+}
+
+func webhookModelToApi(webhook models.Webhook) api.WebhookResponse {
+	eventTypes := []string(webhook.EventTypes)
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	return api.WebhookResponse{
+		UUID:       webhook.UUID,
+		URL:        webhook.URL,
+		EventTypes: eventTypes,
+		Enabled:    webhook.Enabled,
+		CreatedAt:  webhook.CreatedAt,
+	}
+}
@@ -0,0 +1,220 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/crypto"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/seeds"
+	"github.com/content-services/content-sources-backend/pkg/ssrf"
+	"github.com/openlyinc/pointy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type WebhookSuite struct {
+	*DaoSuite
+	restoreKeys func()
+}
+
+func TestWebhookSuite(t *testing.T) {
+	m := DaoSuite{}
+	r := WebhookSuite{DaoSuite: &m}
+	suite.Run(t, &r)
+}
+
+// SetupTest resolves the hosts of webhooks without a dns lookup, internal.example.com to a private address,
+// and configures the key encrypting their secrets
+func (ws *WebhookSuite) SetupTest() {
+	ws.DaoSuite.SetupTest()
+	ws.restoreKeys = setEncryptionKeys(testEncryptionKey("test", "k"))
+	checkWebhookHost = func(ctx context.Context, host string) error {
+		switch host {
+		case "example.com":
+			return nil
+		case "internal.example.com":
+			return fmt.Errorf("%w: 10.0.0.5", ssrf.ErrForbiddenAddress)
+		}
+		return errors.New("no such host")
+	}
+}
+
+func (ws *WebhookSuite) TearDownTest() {
+	checkWebhookHost = ssrf.CheckHost
+	ws.restoreKeys()
+	ws.DaoSuite.TearDownTest()
+}
+
+func (ws *WebhookSuite) createWebhook(orgID string, eventTypes ...string) api.WebhookResponse {
+	webhook, err := webhookDaoImpl{db: ws.tx}.Create(context.Background(), api.WebhookRequest{
+		URL:        pointy.String("https://example.com/hook"),
+		EventTypes: &eventTypes,
+		OrgID:      &orgID,
+	})
+	require.NoError(ws.T(), err)
+	return webhook
+}
+
+func (ws *WebhookSuite) TestCreateFetchDelete() {
+	t := ws.T()
+	orgID := seeds.RandomOrgId()
+	webhookDao := webhookDaoImpl{db: ws.tx}
+
+	created := ws.createWebhook(orgID, api.WebhookEventSnapshotCompleted)
+	assert.Len(t, created.Secret, 2*webhookSecretBytes)
+	assert.True(t, created.Enabled)
+
+	// The secret is stored encrypted
+	stored := models.Webhook{}
+	require.NoError(t, ws.tx.First(&stored, "uuid = ?", created.UUID).Error)
+	assert.True(t, strings.HasPrefix(stored.Secret, "test:"))
+	decrypted, err := crypto.Decrypt(stored.Secret)
+	require.NoError(t, err)
+	assert.Equal(t, created.Secret, decrypted)
+
+	// The secret is only returned on creation
+	fetched, err := webhookDao.Fetch(context.Background(), orgID, created.UUID)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/hook", fetched.URL)
+	assert.Equal(t, []string{api.WebhookEventSnapshotCompleted}, fetched.EventTypes)
+	assert.Empty(t, fetched.Secret)
+
+	// Not found from another org
+	_, err = webhookDao.Fetch(context.Background(), seeds.RandomOrgId(), created.UUID)
+	daoError, ok := err.(*ce.DaoError)
+	require.True(t, ok)
+	assert.True(t, daoError.NotFound)
+
+	require.NoError(t, webhookDao.Delete(context.Background(), orgID, created.UUID))
+	_, err = webhookDao.Fetch(context.Background(), orgID, created.UUID)
+	assert.Error(t, err)
+}
+
+func (ws *WebhookSuite) TestCreateInvalid() {
+	t := ws.T()
+	orgID := seeds.RandomOrgId()
+	webhookDao := webhookDaoImpl{db: ws.tx}
+
+	_, err := webhookDao.Create(context.Background(), api.WebhookRequest{URL: pointy.String("ftp://example.com"), OrgID: &orgID})
+	daoError, ok := err.(*ce.DaoError)
+	require.True(t, ok)
+	assert.True(t, daoError.BadValidation)
+
+	_, err = webhookDao.Create(context.Background(), api.WebhookRequest{
+		URL:        pointy.String("https://example.com/hook"),
+		EventTypes: &[]string{"unknown"},
+		OrgID:      &orgID,
+	})
+	daoError, ok = err.(*ce.DaoError)
+	require.True(t, ok)
+	assert.True(t, daoError.BadValidation)
+
+	_, err = webhookDao.Create(context.Background(), api.WebhookRequest{URL: pointy.String("https://internal.example.com/hook"), OrgID: &orgID})
+	daoError, ok = err.(*ce.DaoError)
+	require.True(t, ok)
+	assert.True(t, daoError.BadValidation)
+	assert.Contains(t, daoError.Message, "private")
+
+	_, err = webhookDao.Create(context.Background(), api.WebhookRequest{URL: pointy.String("https://unknown.example.com/hook"), OrgID: &orgID})
+	daoError, ok = err.(*ce.DaoError)
+	require.True(t, ok)
+	assert.True(t, daoError.BadValidation)
+	assert.Contains(t, daoError.Message, "could not be resolved")
+}
+
+func (ws *WebhookSuite) TestList() {
+	t := ws.T()
+	orgID := seeds.RandomOrgId()
+	ws.createWebhook(orgID)
+	ws.createWebhook(orgID)
+	ws.createWebhook(seeds.RandomOrgId())
+
+	collection, total, err := webhookDaoImpl{db: ws.tx}.List(context.Background(), orgID, api.PaginationData{Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, collection.Data, 1)
+}
+
+func (ws *WebhookSuite) TestQueueEventFiltersEventTypes() {
+	t := ws.T()
+	orgID := seeds.RandomOrgId()
+	webhookDao := webhookDaoImpl{db: ws.tx}
+	all := ws.createWebhook(orgID)
+	snapshots := ws.createWebhook(orgID, api.WebhookEventSnapshotCompleted)
+	deleted := ws.createWebhook(orgID, api.WebhookEventRepositoryDeleted)
+
+	data := api.WebhookEventData{Repository: api.WebhookRepository{UUID: "abc", Name: "repo", URL: "https://example.com/repo/"}}
+	require.NoError(t, webhookDao.QueueEvent(context.Background(), orgID, api.WebhookEventRepositoryDeleted, data))
+	// Not sent to the webhooks of other orgs
+	require.NoError(t, webhookDao.QueueEvent(context.Background(), seeds.RandomOrgId(), api.WebhookEventRepositoryDeleted, data))
+
+	pageData := api.PaginationData{Limit: 10}
+	for _, webhook := range []api.WebhookResponse{all, deleted} {
+		deliveries, total, err := webhookDao.ListDeliveries(context.Background(), orgID, webhook.UUID, pageData)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, api.WebhookEventRepositoryDeleted, deliveries.Data[0].EventType)
+		assert.Equal(t, models.WebhookDeliveryPending, deliveries.Data[0].Status)
+		assert.NotNil(t, deliveries.Data[0].NextAttemptAt)
+	}
+	_, total, err := webhookDao.ListDeliveries(context.Background(), orgID, snapshots.UUID, pageData)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+}
+
+func (ws *WebhookSuite) TestDeleteDeliveriesBefore() {
+	t := ws.T()
+	orgID := seeds.RandomOrgId()
+	webhookDao := webhookDaoImpl{db: ws.tx}
+	webhook := ws.createWebhook(orgID)
+	require.NoError(t, webhookDao.QueueEvent(context.Background(), orgID, api.WebhookEventRepositoryDeleted, api.WebhookEventData{}))
+
+	deleted, err := webhookDao.DeleteDeliveriesBefore(context.Background(), time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), deleted)
+
+	deleted, err = webhookDao.DeleteDeliveriesBefore(context.Background(), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, deleted, int64(1))
+	_, total, err := webhookDao.ListDeliveries(context.Background(), orgID, webhook.UUID, api.PaginationData{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+}
+
+func (ws *WebhookSuite) TestRepositoryEventsQueueDeliveries() {
+	t := ws.T()
+	tx := ws.tx
+	orgID := seeds.RandomOrgId()
+	webhook := ws.createWebhook(orgID)
+
+	err := seeds.SeedRepositoryConfigurations(tx, 1, seeds.SeedOptions{OrgID: orgID})
+	require.NoError(t, err)
+	repoConfig := models.RepositoryConfiguration{}
+	require.NoError(t, tx.Where("org_id = ?", orgID).First(&repoConfig).Error)
+
+	snap := models.Snapshot{
+		VersionHref:                 "/pulp/version",
+		PublicationHref:             "/pulp/publication",
+		DistributionPath:            "/path/to/snapshot",
+		RepositoryConfigurationUUID: repoConfig.UUID,
+		ContentCounts:               models.ContentCounts{},
+	}
+	require.NoError(t, snapshotDaoImpl{db: tx}.Create(context.Background(), &snap))
+	require.NoError(t, GetRepositoryConfigDao(tx).SoftDelete(context.Background(), orgID, repoConfig.UUID))
+
+	deliveries, _, err := webhookDaoImpl{db: tx}.ListDeliveries(context.Background(), orgID, webhook.UUID, api.PaginationData{Limit: 10})
+	require.NoError(t, err)
+	eventTypes := []string{}
+	for _, delivery := range deliveries.Data {
+		eventTypes = append(eventTypes, delivery.EventType)
+	}
+	assert.ElementsMatch(t, []string{api.WebhookEventSnapshotCompleted, api.WebhookEventRepositoryDeleted}, eventTypes)
+}
@@ -12,7 +12,6 @@ import (
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
-	gorm_zerolog "github.com/mpalmer/gorm-zerolog"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/exp/slices"
 	pg "gorm.io/driver/postgres"
@@ -21,16 +20,38 @@ import (
 
 var DB *gorm.DB
 
+// ReadReplica is the connection used for read only queries, it is the
+// same as DB when no replica is configured or the replica is unreachable.
+var ReadReplica *gorm.DB
+
 // GetUrl Get database config and return url
 func GetUrl() string {
+	dbConfig := config.Get().Database
+	return connectionUrl(dbConfig.Host, dbConfig.Port)
+}
+
+// GetReplicaUrl Get database config and return the read replica url, empty if no replica is configured
+func GetReplicaUrl() string {
+	dbConfig := config.Get().Database
+	if dbConfig.ReplicaHost == "" {
+		return ""
+	}
+	port := dbConfig.ReplicaPort
+	if port == 0 {
+		port = dbConfig.Port
+	}
+	return connectionUrl(dbConfig.ReplicaHost, port)
+}
+
+func connectionUrl(host string, port int) string {
 	dbConfig := config.Get().Database
 	connectStr := fmt.Sprintf(
 		"user=%s password=%s dbname=%s host=%s port=%d",
 		dbConfig.User,
 		dbConfig.Password,
 		dbConfig.Name,
-		dbConfig.Host,
-		dbConfig.Port,
+		host,
+		port,
 	)
 
 	var sslStr string
@@ -46,27 +67,95 @@ func GetUrl() string {
 func Connect() error {
 	var err error
 
-	dbURL := GetUrl()
-	DB, err = gorm.Open(pg.Open(dbURL), &gorm.Config{Logger: gorm_zerolog.Logger{}})
+	DB, err = Open(GetUrl())
 	if err != nil {
 		return err
 	}
-	DB.CreateBatchSize = config.DefaultPagedRpmInsertsLimit
+	ReadReplica = DB
+
+	if replicaURL := GetReplicaUrl(); replicaURL != "" {
+		replica, err := Open(replicaURL)
+		if err == nil {
+			err = ping(replica)
+		}
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to connect to the read replica, falling back to the primary database")
+		} else {
+			ReadReplica = replica
+		}
+	}
+	return nil
+}
+
+// Open connects to the database at dbURL, configured as the connections of the application
+func Open(dbURL string) (*gorm.DB, error) {
+	dbConfig := config.Get().Database
+	dialector := pg.New(pg.Config{
+		DSN:        dbURL,
+		DriverName: "pgx",
+		// The driver prepares the statements it runs, unless using the simple protocol
+		PreferSimpleProtocol: dbConfig.TransactionPooling,
+	})
+	conn, err := gorm.Open(dialector, &gorm.Config{
+		Logger:      queryLogger{slowThreshold: dbConfig.SlowQueryThreshold},
+		PrepareStmt: dbConfig.PrepareStatements && !dbConfig.TransactionPooling,
+	})
+	if err != nil {
+		return nil, err
+	}
+	conn.CreateBatchSize = config.DefaultPagedRpmInsertsLimit
+	if err = conn.Use(statementTimeouts{}); err != nil {
+		return nil, err
+	}
+
+	sqlDb, err := conn.DB()
+	if err != nil {
+		return nil, err
+	}
+	configurePool(sqlDb, dbConfig)
+	return conn, nil
+}
+
+// configurePool applies the connection pool settings, falling back to the
+// deprecated pool_limit setting for the maximum of open connections.
+func configurePool(sqlDb *sql.DB, dbConfig config.Database) {
+	maxOpen := dbConfig.MaxOpenConns
+	if maxOpen == 0 {
+		maxOpen = dbConfig.PoolLimit
+	}
+	maxIdle := dbConfig.MaxIdleConns
+	if maxOpen > 0 && maxIdle > maxOpen {
+		maxIdle = maxOpen
+	}
+	sqlDb.SetMaxOpenConns(maxOpen)
+	sqlDb.SetMaxIdleConns(maxIdle)
+	sqlDb.SetConnMaxLifetime(dbConfig.ConnMaxLifetime)
+	sqlDb.SetConnMaxIdleTime(dbConfig.ConnMaxIdleTime)
+}
 
-	sqlDb, err := DB.DB()
+func ping(conn *gorm.DB) error {
+	sqlDb, err := conn.DB()
 	if err != nil {
 		return err
 	}
-	sqlDb.SetMaxOpenConns(config.Get().Database.PoolLimit)
-	return nil
+	return sqlDb.Ping()
 }
 
-// Close closes global database connection, DB
+// Close closes global database connections, DB and ReadReplica
 func Close() error {
+	if ReadReplica != nil && ReadReplica != DB {
+		if err := closeConn(ReadReplica); err != nil {
+			return err
+		}
+	}
+	return closeConn(DB)
+}
+
+func closeConn(conn *gorm.DB) error {
 	var sqlDB *sql.DB
 	var err error
 
-	sqlDB, err = DB.DB()
+	sqlDB, err = conn.DB()
 	if err != nil {
 		return err
 	}
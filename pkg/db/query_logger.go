@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/instrumentation"
+	gorm_zerolog "github.com/mpalmer/gorm-zerolog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm/logger"
+)
+
+// slowQueriesTotal counts the statements that ran longer than the slow query threshold, by kind of
+// statement, see SlowQueriesCollector
+var slowQueriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: instrumentation.NameSpace,
+	Name:      "slow_queries_total",
+	Help:      "Database statements slower than the slow query threshold, by kind of statement",
+}, []string{"statement"})
+
+// SlowQueriesCollector returns the counter of slow queries, to register with the metrics of the process
+func SlowQueriesCollector() prometheus.Collector {
+	return slowQueriesTotal
+}
+
+// queryLogger is the gorm logger.  It logs the statements as gorm_zerolog does, and warns about
+// the ones running longer than the slow query threshold with their values left out, so that
+// regressions such as a missing index are noticed without logging the data of orgs.
+type queryLogger struct {
+	gorm_zerolog.Logger
+	slowThreshold time.Duration // 0 to disable
+}
+
+func (l queryLogger) LogMode(logger.LogLevel) logger.Interface {
+	return l
+}
+
+func (l queryLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	if l.slowThreshold <= 0 || elapsed < l.slowThreshold {
+		l.Logger.Trace(ctx, begin, fc, err)
+		return
+	}
+	sql, rows := fc()
+	slowQueriesTotal.WithLabelValues(statementKind(sql)).Inc()
+	event := zerolog.Ctx(ctx).Warn().
+		Str("sql", sanitizeSQL(sql)).
+		Dur("duration", elapsed).
+		Int64("rows", rows)
+	if err != nil {
+		event = event.Err(err)
+	}
+	event.Msg("Slow query")
+}
+
+var (
+	sqlStrings     = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlNumbers     = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	sqlValueLists  = regexp.MustCompile(`\((?:\s*\?\s*,)+\s*\?\s*\)`)
+	sqlWhitespaces = regexp.MustCompile(`\s+`)
+)
+
+// sanitizeSQL replaces the values of a statement by ?, and the lists of values by a single one so
+// that statements differing by the number of values look the same
+func sanitizeSQL(sql string) string {
+	sql = sqlStrings.ReplaceAllString(sql, "?")
+	sql = sqlNumbers.ReplaceAllString(sql, "?")
+	sql = sqlValueLists.ReplaceAllString(sql, "(?)")
+	return strings.TrimSpace(sqlWhitespaces.ReplaceAllString(sql, " "))
+}
+
+// statementKind returns the kind of a statement (select, insert, update, delete), or other
+func statementKind(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "other"
+	}
+	kind := strings.ToLower(fields[0])
+	switch kind {
+	case "select", "insert", "update", "delete":
+		return kind
+	case "with":
+		// The kind of a statement with common table expressions is the one of its main statement
+		for _, field := range fields[1:] {
+			switch word := strings.ToLower(field); word {
+			case "insert", "update", "delete":
+				return word
+			}
+		}
+		return "select"
+	default:
+		return "other"
+	}
+}
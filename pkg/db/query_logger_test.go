@@ -0,0 +1,23 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeSQL(t *testing.T) {
+	sql := `SELECT * FROM "repository_configurations" WHERE org_id = 'acme''s org' AND
+		"repository_configurations"."uuid" IN ('a1','b2', 'c3') AND version > 1.5 LIMIT 100`
+	assert.Equal(t, `SELECT * FROM "repository_configurations" WHERE org_id = ? AND "repository_configurations"."uuid" IN (?) AND version > ? LIMIT ?`,
+		sanitizeSQL(sql))
+}
+
+func TestStatementKind(t *testing.T) {
+	assert.Equal(t, "select", statementKind("SELECT 1"))
+	assert.Equal(t, "insert", statementKind(`INSERT INTO "rpms" ("name") VALUES (?)`))
+	assert.Equal(t, "delete", statementKind("WITH old AS (SELECT uuid FROM tasks) DELETE FROM tasks USING old"))
+	assert.Equal(t, "select", statementKind("WITH counts AS (SELECT 1) SELECT * FROM counts"))
+	assert.Equal(t, "other", statementKind("SET statement_timeout = 0"))
+	assert.Equal(t, "other", statementKind(""))
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"gorm.io/gorm"
+)
+
+// QueryClass groups the queries by the time they are expected to take, the statements
+// of each class are canceled after the statement timeout of the class
+type QueryClass int
+
+const (
+	// QueryDefault are the queries answering requests, and any query not given a class
+	QueryDefault QueryClass = iota
+	// QueryReport are the aggregations over whole tables, such as metrics and usage
+	QueryReport
+	// QueryBulk are the inserts and cleanups of many rows run by tasks and jobs
+	QueryBulk
+)
+
+type queryClassKey struct{}
+
+// statementDeadlineKey marks the contexts given a statement timeout, so that the
+// statements run by the hooks of a statement are not given another one
+type statementDeadlineKey struct{}
+
+// WithQueryClass returns a copy of ctx whose queries are given the statement timeout of class
+func WithQueryClass(ctx context.Context, class QueryClass) context.Context {
+	return context.WithValue(ctx, queryClassKey{}, class)
+}
+
+// StatementTimeout returns the statement timeout of the class, 0 if its statements are not limited
+func (class QueryClass) StatementTimeout() time.Duration {
+	dbConfig := config.Get().Database
+	switch class {
+	case QueryReport:
+		return dbConfig.ReportStatementTimeout
+	case QueryBulk:
+		return dbConfig.BulkStatementTimeout
+	default:
+		return dbConfig.StatementTimeout
+	}
+}
+
+func queryClass(ctx context.Context) QueryClass {
+	if class, ok := ctx.Value(queryClassKey{}).(QueryClass); ok {
+		return class
+	}
+	return QueryDefault
+}
+
+const (
+	statementContextKey = "statement_timeout:context"
+	statementCancelKey  = "statement_timeout:cancel"
+)
+
+// statementTimeouts is a gorm plugin canceling each statement once it runs longer than the
+// statement timeout of its query class.  Canceling the context of a statement makes the driver
+// cancel the query in postgres, as it does when the context of the request is canceled.
+type statementTimeouts struct{}
+
+func (statementTimeouts) Name() string {
+	return "statement_timeouts"
+}
+
+func (statementTimeouts) Initialize(conn *gorm.DB) error {
+	callbacks := conn.Callback()
+	for name, register := range map[string]func(string, func(*gorm.DB)) error{
+		"before_create": callbacks.Create().Before("*").Register,
+		"before_query":  callbacks.Query().Before("*").Register,
+		"before_update": callbacks.Update().Before("*").Register,
+		"before_delete": callbacks.Delete().Before("*").Register,
+		"before_raw":    callbacks.Raw().Before("*").Register,
+		"before_row":    callbacks.Row().Before("*").Register,
+	} {
+		if err := register("statement_timeout:"+name, startStatement); err != nil {
+			return err
+		}
+	}
+	for name, register := range map[string]func(string, func(*gorm.DB)) error{
+		"after_create": callbacks.Create().After("*").Register,
+		"after_query":  callbacks.Query().After("*").Register,
+		"after_update": callbacks.Update().After("*").Register,
+		"after_delete": callbacks.Delete().After("*").Register,
+		"after_raw":    callbacks.Raw().After("*").Register,
+	} {
+		if err := register("statement_timeout:"+name, endStatement); err != nil {
+			return err
+		}
+	}
+	// The rows returned are read once the callbacks ran, so their context is left to expire
+	return callbacks.Row().After("*").Register("statement_timeout:after_row", restoreStatement)
+}
+
+func startStatement(tx *gorm.DB) {
+	ctx := tx.Statement.Context
+	if ctx == nil || ctx.Value(statementDeadlineKey{}) != nil {
+		return
+	}
+	timeout := queryClass(ctx).StatementTimeout()
+	if timeout <= 0 {
+		return
+	}
+	withTimeout, cancel := context.WithTimeout(context.WithValue(ctx, statementDeadlineKey{}, true), timeout)
+	tx.InstanceSet(statementContextKey, ctx)
+	tx.InstanceSet(statementCancelKey, cancel)
+	tx.Statement.Context = withTimeout
+}
+
+// restoreStatement gives the statement back its context, as a statement is reused by the
+// queries chained on it
+func restoreStatement(tx *gorm.DB) {
+	if ctx, ok := tx.InstanceGet(statementContextKey); ok && ctx != nil {
+		tx.Statement.Context = ctx.(context.Context)
+		tx.InstanceSet(statementContextKey, nil)
+	}
+}
+
+func endStatement(tx *gorm.DB) {
+	restoreStatement(tx)
+	if cancel, ok := tx.InstanceGet(statementCancelKey); ok && cancel != nil {
+		cancel.(context.CancelFunc)()
+		tx.InstanceSet(statementCancelKey, nil)
+	}
+}
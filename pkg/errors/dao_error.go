@@ -8,6 +8,7 @@ type DaoError struct {
 	Message       string
 	NotFound      bool
 	BadValidation bool
+	Timeout       bool // the query was canceled, having run out of time
 }
 
 func (e DaoError) Error() string {
@@ -7,8 +7,29 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
+// MIMEApplicationProblemJSON is the media type of RFC 7807 problem details
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// Stable machine-readable codes of the types of errors, clients should rely on them rather than on titles and details
+const (
+	CodeBadRequest           = "bad_request"            // The request is malformed
+	CodeValidationFailed     = "validation_failed"      // The request is well-formed, but its values are not valid
+	CodeUnauthorized         = "unauthorized"           // The request is not authenticated
+	CodeForbidden            = "forbidden"              // The user is not allowed to perform the request
+	CodeNotFound             = "not_found"              // The requested resource does not exist
+	CodeMethodNotAllowed     = "method_not_allowed"     // The resource does not support the method of the request
+	CodeConflict             = "conflict"               // The request conflicts with the state of the resource
+	CodeRequestTooLarge      = "request_too_large"      // The request is larger, or asks for more items, than allowed
+	CodeUnsupportedMediaType = "unsupported_media_type" // The content type of the request is not supported
+	CodeTooManyRequests      = "too_many_requests"      // The request has been rate limited
+	CodeInternal             = "internal_error"         // The server failed to process the request
+	CodeUnavailable          = "service_unavailable"    // The server, or a service it depends on, is unavailable
+	CodeTimeout              = "timeout"                // The server did not process the request in time
+)
+
 type HandlerError struct {
 	Status int    `json:"status,omitempty"` // HTTP status code applicable to the error
+	Code   string `json:"code,omitempty"`   // Machine-readable code of the type of the error
 	Title  string `json:"title,omitempty"`  // A summary of the problem
 	Detail string `json:"detail,omitempty"` // An explanation specific to the problem
 }
@@ -17,6 +38,15 @@ type ErrorResponse struct {
 	Errors []HandlerError `json:"errors"`
 }
 
+// ProblemDetails is the RFC 7807 representation of an ErrorResponse, returned to clients accepting application/problem+json
+type ProblemDetails struct {
+	Title  string         `json:"title"`            // A summary of the problem
+	Status int            `json:"status"`           // HTTP status code of the response
+	Detail string         `json:"detail,omitempty"` // An explanation specific to the problem
+	Code   string         `json:"code"`             // Machine-readable code of the type of the problem
+	Errors []HandlerError `json:"errors,omitempty"` // Each of the errors, when several occurred
+}
+
 // Error makes it compatible with `error` interface.
 func (er HandlerError) Error() string {
 	return fmt.Sprintf("code=%d, title=%v, detail=%v", er.Status, er.Title, er.Detail)
@@ -35,6 +65,7 @@ func NewErrorResponse(code int, title string, detail string) ErrorResponse {
 	return ErrorResponse{Errors: []HandlerError{
 		{
 			Status: code,
+			Code:   CodeForStatus(code),
 			Title:  title,
 			Detail: detail,
 		}},
@@ -51,6 +82,7 @@ func NewErrorResponseFromError(title string, errs ...error) ErrorResponse {
 	if len(errs) == 1 {
 		errors[0] = HandlerError{
 			Status: HttpCodeForDaoError(errs[0]),
+			Code:   CodeForDaoError(errs[0]),
 			Title:  title,
 			Detail: errs[0].Error(),
 		}
@@ -59,6 +91,7 @@ func NewErrorResponseFromError(title string, errs ...error) ErrorResponse {
 			if errs[i] != nil {
 				errors[i] = HandlerError{
 					Status: HttpCodeForDaoError(errs[i]),
+					Code:   CodeForDaoError(errs[i]),
 					Title:  title,
 					Detail: errs[i].Error(),
 				}
@@ -81,6 +114,7 @@ func NewErrorResponseFromEchoError(echoErr *echo.HTTPError) ErrorResponse {
 	return ErrorResponse{Errors: []HandlerError{
 		{
 			Status: echoErr.Code,
+			Code:   CodeForStatus(echoErr.Code),
 			Title:  "",
 			Detail: detail,
 		}},
@@ -95,6 +129,8 @@ func HttpCodeForDaoError(err error) int {
 			return http.StatusNotFound
 		} else if daoError.BadValidation {
 			return http.StatusBadRequest
+		} else if daoError.Timeout {
+			return http.StatusGatewayTimeout
 		} else {
 			return http.StatusInternalServerError
 		}
@@ -103,6 +139,87 @@ func HttpCodeForDaoError(err error) int {
 	}
 }
 
+// CodeForDaoError returns the code of the type of a dao error
+func CodeForDaoError(err error) string {
+	if daoError, ok := err.(*DaoError); ok && daoError.BadValidation {
+		return CodeValidationFailed
+	}
+	return CodeForStatus(HttpCodeForDaoError(err))
+}
+
+// CodeForStatus returns the code of the type of error an http status stands for
+func CodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodeRequestTooLarge
+	case http.StatusUnsupportedMediaType:
+		return CodeUnsupportedMediaType
+	case http.StatusTooManyRequests:
+		return CodeTooManyRequests
+	case http.StatusServiceUnavailable, http.StatusBadGateway:
+		return CodeUnavailable
+	case http.StatusGatewayTimeout, http.StatusRequestTimeout:
+		return CodeTimeout
+	}
+	if status >= 400 && status < 500 {
+		return CodeBadRequest
+	}
+	return CodeInternal
+}
+
+// WithCodes returns a copy of the response, where the errors lacking a code have the one of their status
+func (er ErrorResponse) WithCodes() ErrorResponse {
+	if len(er.Errors) == 0 {
+		return er
+	}
+	errors := make([]HandlerError, len(er.Errors))
+	copy(errors, er.Errors)
+	for i := range errors {
+		if errors[i].Code == "" && errors[i].Status != 0 {
+			errors[i].Code = CodeForStatus(errors[i].Status)
+		}
+	}
+	return ErrorResponse{Errors: errors}
+}
+
+// ProblemDetails returns the RFC 7807 representation of the response, given its http status.
+// A single error is the problem itself, while several errors are listed under a problem summarizing them.
+func (er ErrorResponse) ProblemDetails(status int) ProblemDetails {
+	if len(er.Errors) == 1 {
+		problem := ProblemDetails{
+			Title:  er.Errors[0].Title,
+			Status: status,
+			Detail: er.Errors[0].Detail,
+			Code:   er.Errors[0].Code,
+		}
+		if problem.Title == "" {
+			problem.Title = http.StatusText(status)
+		}
+		if problem.Code == "" {
+			problem.Code = CodeForStatus(status)
+		}
+		return problem
+	}
+	return ProblemDetails{
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   CodeForStatus(status),
+		Errors: er.Errors,
+	}
+}
+
 // GetGeneralResponseCode returns the most common error code class in response
 func GetGeneralResponseCode(response ErrorResponse) int {
 	if len(response.Errors) == 0 {
@@ -13,6 +13,7 @@ func TestNewErrorResponse(t *testing.T) {
 	expected := ErrorResponse{Errors: []HandlerError{
 		{
 			Status: http.StatusBadRequest,
+			Code:   CodeBadRequest,
 			Title:  "title",
 			Detail: "detail",
 		},
@@ -31,6 +32,7 @@ func TestNewErrorResponseFromError(t *testing.T) {
 	expected = ErrorResponse{Errors: []HandlerError{
 		{
 			Status: http.StatusInternalServerError,
+			Code:   CodeInternal,
 			Title:  "an error's title",
 			Detail: "an unexpected error",
 		},
@@ -45,16 +47,19 @@ func TestNewErrorResponseFromError(t *testing.T) {
 	expected = ErrorResponse{Errors: []HandlerError{
 		{
 			Status: http.StatusNotFound,
+			Code:   CodeNotFound,
 			Title:  "an error's title",
 			Detail: "not found",
 		},
 		{
 			Status: http.StatusBadRequest,
+			Code:   CodeValidationFailed,
 			Title:  "an error's title",
 			Detail: "bad validation",
 		},
 		{
 			Status: http.StatusInternalServerError,
+			Code:   CodeInternal,
 			Title:  "an error's title",
 			Detail: "unknown error",
 		},
@@ -94,6 +99,7 @@ func TestNewErrorResponseFromEchoError(t *testing.T) {
 	expected := ErrorResponse{Errors: []HandlerError{
 		{
 			Status: http.StatusBadRequest,
+			Code:   CodeBadRequest,
 			Title:  "",
 			Detail: http.StatusText(http.StatusBadRequest),
 		}},
@@ -112,6 +118,7 @@ func TestNewErrorResponseFromEchoErrorOtherType(t *testing.T) {
 		Errors: []HandlerError{
 			{
 				Status: http.StatusBadRequest,
+				Code:   CodeBadRequest,
 				Title:  "",
 				Detail: "code=400, message=Bad Request",
 			},
@@ -173,3 +180,52 @@ func TestGetGeneralResponseCode(t *testing.T) {
 	result = GetGeneralResponseCode(er)
 	assert.Equal(t, 500, result)
 }
+
+func TestCodeForStatus(t *testing.T) {
+	assert.Equal(t, CodeBadRequest, CodeForStatus(http.StatusBadRequest))
+	assert.Equal(t, CodeNotFound, CodeForStatus(http.StatusNotFound))
+	assert.Equal(t, CodeRequestTooLarge, CodeForStatus(http.StatusRequestEntityTooLarge))
+	assert.Equal(t, CodeBadRequest, CodeForStatus(http.StatusTeapot))
+	assert.Equal(t, CodeUnavailable, CodeForStatus(http.StatusBadGateway))
+	assert.Equal(t, CodeInternal, CodeForStatus(http.StatusInternalServerError))
+}
+
+func TestWithCodes(t *testing.T) {
+	response := ErrorResponse{Errors: []HandlerError{
+		{Status: http.StatusForbidden, Detail: "forbidden"},
+		{Status: http.StatusBadRequest, Code: CodeValidationFailed},
+		{},
+	}}
+	result := response.WithCodes()
+	assert.Equal(t, CodeForbidden, result.Errors[0].Code)
+	assert.Equal(t, CodeValidationFailed, result.Errors[1].Code)
+	assert.Equal(t, "", result.Errors[2].Code)
+	assert.Equal(t, "", response.Errors[0].Code)
+}
+
+func TestProblemDetails(t *testing.T) {
+	single := NewErrorResponseFromError("Error creating repository", &DaoError{Message: "name is required", BadValidation: true})
+	assert.Equal(t, ProblemDetails{
+		Title:  "Error creating repository",
+		Status: http.StatusBadRequest,
+		Detail: "name is required",
+		Code:   CodeValidationFailed,
+	}, single.ProblemDetails(http.StatusBadRequest))
+
+	untitled := NewErrorResponse(http.StatusNotFound, "", "")
+	assert.Equal(t, ProblemDetails{
+		Title:  "Not Found",
+		Status: http.StatusNotFound,
+		Code:   CodeNotFound,
+	}, untitled.ProblemDetails(http.StatusNotFound))
+
+	several := NewErrorResponseFromError("Error deleting repository",
+		&DaoError{Message: "not found", NotFound: true},
+		&DaoError{Message: "bad validation", BadValidation: true})
+	assert.Equal(t, ProblemDetails{
+		Title:  "Bad Request",
+		Status: http.StatusBadRequest,
+		Code:   CodeBadRequest,
+		Errors: several.Errors,
+	}, several.ProblemDetails(http.StatusBadRequest))
+}
@@ -13,6 +13,7 @@ import (
 // which is used to compose a kafka message.
 type KafkaHeaders interface {
 	FromEchoContext(ctx echo.Context, event string) (headers []kafka.Header, err error)
+	FromEvent(event string) (headers []kafka.Header, err error)
 }
 
 // KafkaAdapter represent a specific implementation from the KafkaHeaders adapter interface.
@@ -63,7 +64,7 @@ func (a KafkaAdapter) FromEchoContext(ctx echo.Context, event string) (headers [
 	headers = []kafka.Header{
 		{
 			Key:   string(message.HdrType),
-			Value: []byte(message.HdrTypeIntrospect),
+			Value: []byte(event),
 		},
 		{
 			Key:   string(message.HdrXRhIdentity),
@@ -77,3 +78,26 @@ func (a KafkaAdapter) FromEchoContext(ctx echo.Context, event string) (headers [
 
 	return headers, nil
 }
+
+// FromEvent builds the []kafka.Header of a message produced by the service itself,
+// rather than on behalf of an http request, so without any identity.
+// event is an additional type to identify exactly the schema which match
+// with the kafka message.
+// Return headers a slice of kafka.Header and nil error when success, else
+// an error reference filled and an empty slice of kafka.Header.
+func (a KafkaAdapter) FromEvent(event string) (headers []kafka.Header, err error) {
+	if event == "" {
+		return []kafka.Header{}, fmt.Errorf("event cannot be an empty string")
+	}
+	headers = []kafka.Header{
+		{
+			Key:   string(message.HdrType),
+			Value: []byte(event),
+		},
+		{
+			Key:   string(message.HdrXRhInsightsRequestId),
+			Value: []byte(random.String(32)),
+		},
+	}
+	return headers, nil
+}
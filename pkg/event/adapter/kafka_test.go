@@ -201,3 +201,17 @@ func TestFromEchoContext(t *testing.T) {
 		}
 	}
 }
+
+func TestFromEvent(t *testing.T) {
+	_, err := NewKafkaHeaders().FromEvent("")
+	require.Error(t, err)
+	assert.Equal(t, "event cannot be an empty string", err.Error())
+
+	headers, err := NewKafkaHeaders().FromEvent(message.HdrTypeRepositoryChanges)
+	require.NoError(t, err)
+	require.Len(t, headers, 2)
+	assert.Equal(t, string(message.HdrType), headers[0].Key)
+	assert.Equal(t, []byte(message.HdrTypeRepositoryChanges), headers[0].Value)
+	assert.Equal(t, string(message.HdrXRhInsightsRequestId), headers[1].Key)
+	assert.Len(t, headers[1].Value, 32)
+}
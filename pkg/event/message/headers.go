@@ -7,8 +7,9 @@ const (
 	// HdrType is the type of event that match finally with the schema.
 	// In a future refactor this will be removed and simplified
 	// making a matching of 1 topic - 1 schema.
-	HdrType           EventHeaderKey = "Type"
-	HdrTypeIntrospect string         = "Introspect"
+	HdrType                  EventHeaderKey = "Type"
+	HdrTypeIntrospect        string         = "Introspect"
+	HdrTypeRepositoryChanges string         = "RepositoryChanges"
 
 	// HdrXRhIdentity is the identity header; this will allow to
 	// communicate with other services, and add validations based
@@ -0,0 +1,54 @@
+package producer
+
+import (
+	"fmt"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/content-services/content-sources-backend/pkg/event/adapter"
+	"github.com/content-services/content-sources-backend/pkg/event/message"
+	"github.com/content-services/content-sources-backend/pkg/event/schema"
+)
+
+// Interface which define the producer for the RepositoryChangesMessage message
+type RepositoryChanges interface {
+	Produce(msg *message.RepositoryChangesMessage) error
+}
+
+// Implementation for the specific producer
+type RepositoryChangesProducer struct {
+	producer *kafka.Producer
+}
+
+// NewRepositoryChanges Create the specific producer for RepositoryChanges message.
+// producer is the reference to the kafka.Producer; see NewProducer(...) function.
+// Return a RepositoryChanges interface and nil error if success, else nil interface
+// and a filled error with the information about the situation.
+func NewRepositoryChanges(producer *kafka.Producer) (RepositoryChanges, error) {
+	if producer == nil {
+		return nil, fmt.Errorf("producer cannot be nil")
+	}
+	output := &RepositoryChangesProducer{
+		producer: producer,
+	}
+	return output, nil
+}
+
+// Produce Implement the specific method to produce a RepositoryChangesMessage.
+// msg Reference to the RepositoryChangesMessage; it cannot be nil.
+// Return nil if success, else an error filled with the information about the
+// situation.
+func (p *RepositoryChangesProducer) Produce(msg *message.RepositoryChangesMessage) error {
+	if msg == nil {
+		return fmt.Errorf("msg cannot be nil")
+	}
+	topic := schema.TopicRepositoryChanges
+	key := msg.Uuid
+	headers, err := adapter.NewKafkaHeaders().FromEvent(message.HdrTypeRepositoryChanges)
+	if err != nil {
+		return fmt.Errorf("Error adapting to kafka interface: %w", err)
+	}
+	if err = Produce(p.producer, topic, key, msg, headers...); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package producer
+
+import (
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/event"
+	"github.com/content-services/content-sources-backend/pkg/event/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRepositoryChanges(t *testing.T) {
+	event.TopicTranslationConfig = event.NewTopicTranslationWithDefaults()
+
+	producer, err := NewProducer(helperGetKafkaConfig())
+	require.NoError(t, err)
+	require.NotNil(t, producer)
+	defer producer.Close()
+
+	// When producer is nil
+	repositoryChanges, err := NewRepositoryChanges(nil)
+	require.Error(t, err)
+	assert.Nil(t, repositoryChanges)
+	assert.Equal(t, "producer cannot be nil", err.Error())
+
+	// Success result
+	repositoryChanges, err = NewRepositoryChanges(producer)
+	require.NoError(t, err)
+	assert.NotNil(t, repositoryChanges)
+}
+
+func TestRepositoryChangesProduce(t *testing.T) {
+	event.TopicTranslationConfig = event.NewTopicTranslationWithDefaults()
+
+	producer, err := NewProducer(helperGetKafkaConfig())
+	require.NoError(t, err)
+	require.NotNil(t, producer)
+	defer producer.Close()
+
+	repositoryChangesProducer, err := NewRepositoryChanges(producer)
+	require.NoError(t, err)
+
+	// Error when the message is nil
+	err = repositoryChangesProducer.Produce(nil)
+	require.Error(t, err)
+	assert.Equal(t, "msg cannot be nil", err.Error())
+
+	// Error when producing the message
+	err = repositoryChangesProducer.Produce(&message.RepositoryChangesMessage{})
+	require.Error(t, err)
+	assert.Equal(t, "key cannot be an empty string", err.Error())
+
+	// Success scenario
+	err = repositoryChangesProducer.Produce(&message.RepositoryChangesMessage{
+		Uuid:            "5e23cc84-5052-11ed-a551-482ae3863d30",
+		Url:             "https://example.test",
+		PackagesAdded:   2,
+		PackagesUpdated: 1,
+	})
+	require.NoError(t, err)
+}
@@ -43,14 +43,18 @@ import (
 )
 
 const (
-	SchemaIntrospectKey = "Introspect"
+	SchemaIntrospectKey        = "Introspect"
+	SchemaRepositoryChangesKey = "RepositoryChanges"
 
 	// Topic constants
 	TopicIntrospect = "platform.content-sources.introspect"
+	// TopicRepositoryChanges is produced for downstream services, and not consumed by this service
+	TopicRepositoryChanges = "platform.content-sources.repository-changes"
 )
 
 var AllowedTopics = []string{
 	TopicIntrospect,
+	TopicRepositoryChanges,
 }
 
 // https://pkg.go.dev/embed
@@ -60,11 +64,17 @@ var AllowedTopics = []string{
 //go:embed "introspectRequest.message.json"
 var schemaMessageIntrospect string
 
+//go:embed "repositoryChanges.message.json"
+var schemaMessageRepositoryChanges string
+
 var (
 	schemaKey2JsonSpec = map[string]map[string]string{
 		TopicIntrospect: {
 			SchemaIntrospectKey: schemaMessageIntrospect,
 		},
+		TopicRepositoryChanges: {
+			SchemaRepositoryChangesKey: schemaMessageRepositoryChanges,
+		},
 	}
 )
 
@@ -102,7 +112,7 @@ func getHeader(msg *kafka.Message, key string) (*kafka.Header, error) {
 
 func isValidEvent(event string) bool {
 	switch event {
-	case string(message.HdrTypeIntrospect):
+	case string(message.HdrTypeIntrospect), string(message.HdrTypeRepositoryChanges):
 		return true
 	default:
 		return false
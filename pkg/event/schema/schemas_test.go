@@ -193,6 +193,7 @@ func TestPrepareParseErrorList(t *testing.T) {
 
 func TestIsValidEvent(t *testing.T) {
 	assert.True(t, isValidEvent(message.HdrTypeIntrospect))
+	assert.True(t, isValidEvent(message.HdrTypeRepositoryChanges))
 	assert.False(t, isValidEvent("AnyOtherKey"))
 }
 
@@ -0,0 +1,26 @@
+package external_repos
+
+import (
+	"context"
+	"github.com/RedHatInsights/event-schemas-go/apps/repositories/v1"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	"github.com/content-services/content-sources-backend/pkg/notifications"
+)
+
+// SendIntrospectionDigests sends each org a single notification listing its repositories
+// failing to introspect, returning the number of orgs notified.  Orgs without failing
+// repositories, or that opted out in their settings, are not notified.
+func SendIntrospectionDigests(ctx context.Context, daoReg *dao.DaoRegistry) (int, error) {
+	failing, err := daoReg.RepositoryConfig.InternalOnly_FetchFailingByOrg(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for orgID, repos := range failing {
+		mapped := make([]repositories.Repositories, len(repos))
+		for i := range repos {
+			mapped[i] = notifications.MapRepositoryResponse(repos[i])
+		}
+		notifications.SendNotification(ctx, orgID, notifications.RepositoryIntrospectionDigest, mapped)
+	}
+	return len(failing), nil
+}
@@ -0,0 +1,23 @@
+package external_repos
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendIntrospectionDigests(t *testing.T) {
+	mockDao := dao.GetMockDaoRegistry(t)
+	mockDao.RepositoryConfig.On("InternalOnly_FetchFailingByOrg", mock.Anything).Return(map[string][]api.RepositoryResponse{
+		"org1": {{UUID: "a", Name: "first", Status: "Invalid"}, {UUID: "b", Name: "second", Status: "Unavailable"}},
+		"org2": {{UUID: "c", Name: "third", Status: "Invalid"}},
+	}, nil)
+
+	count, err := SendIntrospectionDigests(context.Background(), mockDao.ToDaoRegistry())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
@@ -0,0 +1,206 @@
+package external_repos
+
+//nolint:gci
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "embed"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+	"github.com/ulikunitz/xz"
+)
+
+//go:embed "test_files/updateinfo.xml"
+var updateinfoXml []byte
+
+//go:embed "test_files/primary.xml.bz2"
+var primaryXmlBzip2 []byte
+
+// lz4Magic starts lz4 frames, a compression createrepo does not produce
+var lz4Magic = []byte{0x04, 0x22, 0x4d, 0x18}
+
+// fixtureEtag is the ETag of the repomd.xml of every fixture repository
+const fixtureEtag = `"fixture"`
+
+// fixtureRepomdTemplate references the primary metadata, with the extension of its
+// compression, and the updateinfo of a fixture repository
+const fixtureRepomdTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<repomd xmlns="http://linux.duke.edu/metadata/repo" xmlns:rpm="http://linux.duke.edu/metadata/rpm">
+  <revision>%[1]s</revision>
+  <data type="primary">
+    <location href="repodata/primary.xml%[2]s"></location>
+  </data>
+  <data type="updateinfo">
+    <location href="repodata/updateinfo.xml"></location>
+  </data>
+</repomd>
+`
+
+// fixtureVariant is a compression of the primary metadata served by the fixture server
+type fixtureVariant struct {
+	Extension string // extension of primary.xml, empty when not compressed
+	Primary   []byte
+}
+
+// fixtureServer serves canned repositories, one per path prefix:
+//
+//	/<variant>/         primary.xml compressed as the variant (plain, gzip, zstd, xz, bzip2, lz4)
+//	/missing/           every file answers 404
+//	/missing-primary/   repomd.xml is served, primary.xml answers 404
+//	/slow/              answers after the server delay
+//
+// Requests are recorded, and the next requests to a path can be made to fail.
+type fixtureServer struct {
+	*httptest.Server
+	variants map[string]fixtureVariant
+	delay    time.Duration
+
+	mutex    sync.Mutex
+	requests []string
+	failures map[string]int // remaining requests answering 503, by path
+}
+
+func newFixtureServer(t *testing.T) *fixtureServer {
+	reader, err := gzip.NewReader(bytes.NewReader(primaryXml))
+	require.NoError(t, err)
+	plain, err := io.ReadAll(reader)
+	require.NoError(t, err)
+
+	encoder, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	zstdPrimary := encoder.EncodeAll(plain, nil)
+	require.NoError(t, encoder.Close())
+
+	var xzPrimary bytes.Buffer
+	writer, err := xz.NewWriter(&xzPrimary)
+	require.NoError(t, err)
+	_, err = writer.Write(plain)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	server := &fixtureServer{
+		variants: map[string]fixtureVariant{
+			"plain": {Extension: "", Primary: plain},
+			"gzip":  {Extension: ".gz", Primary: primaryXml},
+			"zstd":  {Extension: ".zst", Primary: zstdPrimary},
+			"xz":    {Extension: ".xz", Primary: xzPrimary.Bytes()},
+			"bzip2": {Extension: ".bz2", Primary: primaryXmlBzip2},
+			"lz4":   {Extension: ".lz4", Primary: append(append([]byte{}, lz4Magic...), plain...)},
+		},
+		delay:    2 * time.Second,
+		failures: map[string]int{},
+	}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serve))
+	t.Cleanup(server.Close)
+	allowLoopback(t)
+	return server
+}
+
+// allowLoopback clears the url deny list and allows the loopback network for the test, so that
+// repositories served by test servers on the loopback interface can be introspected
+func allowLoopback(t *testing.T) {
+	setURLDenyList(t, []string{})
+	allowedNetworks := config.Get().Options.RepositoryAllowedNetworks
+	config.Get().Options.RepositoryAllowedNetworks = []string{"127.0.0.0/8", "::1"}
+	t.Cleanup(func() { config.Get().Options.RepositoryAllowedNetworks = allowedNetworks })
+}
+
+// setURLDenyList sets the url deny list for the test
+func setURLDenyList(t *testing.T, denyList []string) {
+	previous := config.Get().Options.URLDenyList
+	config.Get().Options.URLDenyList = denyList
+	t.Cleanup(func() { config.Get().Options.URLDenyList = previous })
+}
+
+// RepoURL returns the url of the fixture repository
+func (s *fixtureServer) RepoURL(repository string) string {
+	return s.Server.URL + "/" + repository + "/"
+}
+
+// FailNext makes the next count requests to path answer 503
+func (s *fixtureServer) FailNext(path string, count int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.failures[path] = count
+}
+
+// Requests returns the paths requested so far
+func (s *fixtureServer) Requests() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string{}, s.requests...)
+}
+
+func (s *fixtureServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	s.requests = append(s.requests, r.URL.Path)
+	failing := s.failures[r.URL.Path] > 0
+	if failing {
+		s.failures[r.URL.Path]--
+	}
+	s.mutex.Unlock()
+	if failing {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	if len(parts) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+	repository, file := parts[0], parts[1]
+
+	variant := s.variants["gzip"]
+	switch repository {
+	case "missing":
+		http.NotFound(w, r)
+		return
+	case "missing-primary":
+		if strings.HasPrefix(file, "repodata/primary.xml") {
+			http.NotFound(w, r)
+			return
+		}
+	case "slow":
+		select {
+		case <-time.After(s.delay):
+		case <-r.Context().Done():
+			return
+		}
+	default:
+		var ok bool
+		if variant, ok = s.variants[repository]; !ok {
+			http.NotFound(w, r)
+			return
+		}
+	}
+
+	switch file {
+	case "repodata/repomd.xml":
+		if r.Header.Get("If-None-Match") == fixtureEtag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", fixtureEtag)
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = fmt.Fprintf(w, fixtureRepomdTemplate, templateRepoMdXmlRevision, variant.Extension)
+	case "repodata/primary.xml" + variant.Extension:
+		_, _ = w.Write(variant.Primary)
+	case "repodata/updateinfo.xml":
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = w.Write(updateinfoXml)
+	default:
+		http.NotFound(w, r)
+	}
+}
@@ -0,0 +1,107 @@
+package external_repos
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+)
+
+// introspectHosts limits the requests of every introspection of the process, so that repositories
+// sharing an upstream host, such as the mirrors of a distribution, do not trip its rate limits
+var introspectHosts = newHostLimiter()
+
+// hostLimiter limits the requests sent at once to each host, and spaces them out
+type hostLimiter struct {
+	mutex sync.Mutex
+	hosts map[string]*hostSlots
+}
+
+type hostSlots struct {
+	slots chan struct{} // holds a value for each request in progress
+	next  time.Time     // earliest time of the next request
+}
+
+func newHostLimiter() *hostLimiter {
+	return &hostLimiter{hosts: make(map[string]*hostSlots)}
+}
+
+// acquire waits for the turn of a request to the host, returning the function releasing it
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	options := config.Get().Options
+	l.mutex.Lock()
+	slots, ok := l.hosts[host]
+	if !ok {
+		concurrency := options.IntrospectHostConcurrency
+		if concurrency <= 0 {
+			concurrency = 1
+		}
+		slots = &hostSlots{slots: make(chan struct{}, concurrency)}
+		l.hosts[host] = slots
+	}
+	now := time.Now()
+	wait := slots.next.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	slots.next = now.Add(wait + options.IntrospectHostInterval)
+	l.mutex.Unlock()
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	select {
+	case slots.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	var once sync.Once
+	return func() { once.Do(func() { <-slots.slots }) }, nil
+}
+
+// hostLimitedTransport returns a RoundTripper sending the requests of base through the limiter,
+// a request keeping its turn until its response body is closed
+func hostLimitedTransport(base http.RoundTripper, limiter *hostLimiter) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return limitedTransport{base: base, limiter: limiter}
+}
+
+type limitedTransport struct {
+	base    http.RoundTripper
+	limiter *hostLimiter
+}
+
+func (t limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := t.limiter.acquire(req.Context(), req.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.Body == nil {
+		release()
+		return resp, err
+	}
+	resp.Body = releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b releasingBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
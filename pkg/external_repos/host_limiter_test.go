@@ -0,0 +1,92 @@
+package external_repos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setHostLimits(t *testing.T, concurrency int, interval time.Duration) {
+	options := config.Get().Options
+	t.Cleanup(func() { config.Get().Options = options })
+	config.Get().Options.IntrospectHostConcurrency = concurrency
+	config.Get().Options.IntrospectHostInterval = interval
+}
+
+func TestHostLimiterConcurrency(t *testing.T) {
+	setHostLimits(t, 1, 0)
+	limiter := newHostLimiter()
+
+	release, err := limiter.acquire(context.Background(), "example.com")
+	require.NoError(t, err)
+
+	// Other hosts are not limited by the requests to example.com
+	otherRelease, err := limiter.acquire(context.Background(), "example.org")
+	require.NoError(t, err)
+	otherRelease()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = limiter.acquire(ctx, "example.com")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release()
+	release() // releasing twice frees a single slot
+	release, err = limiter.acquire(context.Background(), "example.com")
+	require.NoError(t, err)
+	release()
+}
+
+func TestHostLimiterInterval(t *testing.T) {
+	setHostLimits(t, 3, 50*time.Millisecond)
+	limiter := newHostLimiter()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		release, err := limiter.acquire(context.Background(), "example.com")
+		require.NoError(t, err)
+		release()
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestHostLimitedTransport(t *testing.T) {
+	setHostLimits(t, 2, 0)
+	var running, maxRunning int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			seen := atomic.LoadInt32(&maxRunning)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxRunning, seen, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := http.Client{Transport: hostLimitedTransport(nil, newHostLimiter())}
+	wg := sync.WaitGroup{}
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(server.URL)
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxRunning), int32(2))
+}
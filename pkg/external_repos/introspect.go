@@ -14,9 +14,12 @@ import (
 	"time"
 
 	"github.com/RedHatInsights/event-schemas-go/apps/repositories/v1"
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/candlepin_client"
 	"github.com/content-services/content-sources-backend/pkg/config"
 	"github.com/content-services/content-sources-backend/pkg/dao"
 	"github.com/content-services/content-sources-backend/pkg/db"
+	"github.com/content-services/content-sources-backend/pkg/models"
 	"github.com/content-services/content-sources-backend/pkg/notifications"
 	"github.com/content-services/yummy/pkg/yum"
 	"github.com/openlyinc/pointy"
@@ -27,6 +30,8 @@ import (
 const (
 	RhCdnHost              = "cdn.redhat.com"
 	IntrospectTimeInterval = time.Hour * 23
+	// MaxIntrospectTimeInterval is the longest delay between introspections of a failing repository
+	MaxIntrospectTimeInterval = time.Hour * 24 * 7
 )
 
 // IntrospectUrl Fetch the metadata of a url and insert RPM data
@@ -44,77 +49,180 @@ func IsRedHat(url string) bool {
 
 // Introspect introspects a dao.Repository with the given Rpm
 // inserting any needed RPMs and adding and removing associations to the repository
-// Returns the number of new RPMs inserted system-wide and any error encountered
-func Introspect(ctx context.Context, repo *dao.Repository, dao *dao.DaoRegistry) (int64, error, bool) {
+// Returns the number of RPMs newly associated to the repository, the packages changed in the repository
+// and any error encountered
+func Introspect(ctx context.Context, repo *dao.Repository, dao *dao.DaoRegistry) (int64, models.PackageChanges, error, bool) {
 	var (
-		client   http.Client
-		err      error
-		total    int64
-		repomd   *yum.Repomd
-		packages []yum.Package
+		client       http.Client
+		err          error
+		total        int64
+		changes      models.PackageChanges
+		repomd       repomdResponse
+		packages     []yum.Package
+		capabilities packageCapabilities
 	)
+	ctx = config.WithLogModule(ctx, "external_repos")
 	logger := zerolog.Ctx(ctx)
 
 	logger.Debug().Msg("Introspecting " + repo.URL)
 
 	if repo.FailedIntrospectionsCount >= config.FailedIntrospectionsLimit && !repo.Public {
-		return 0, fmt.Errorf("introspection skipped because this repository has failed more than %v times in a row", config.FailedIntrospectionsLimit), false
+		return 0, changes, fmt.Errorf("introspection skipped because this repository has failed more than %v times in a row", config.FailedIntrospectionsLimit), false
 	}
 
-	if client, err = httpClient(IsRedHat(repo.URL)); err != nil {
-		return 0, err, false
+	if err = config.CheckRepositoryURL(repo.URL); err != nil {
+		return 0, changes, err, false
 	}
-	settings := yum.YummySettings{
-		Client: &client,
-		URL:    &repo.URL,
+
+	proxy, err := dao.Repository.FetchProxy(ctx, repo.UUID)
+	if err != nil {
+		return 0, changes, err, false
+	}
+	cert, err := cdnCertificate(ctx, repo, dao)
+	if err != nil {
+		return 0, changes, err, false
+	}
+	if client, err = httpClient(cert, proxy); err != nil {
+		return 0, changes, err, false
 	}
-	yumRepo, _ := yum.NewRepository(settings)
+	credentials, err := dao.Repository.FetchCredentials(ctx, repo.UUID)
+	if err != nil {
+		return 0, changes, err, false
+	}
+	if credentials.Username != "" {
+		client.Transport = credentials.Transport(client.Transport, repo.URL)
+	}
+	client.Transport = hostLimitedTransport(client.Transport, introspectHosts)
+	client.CheckRedirect = checkRedirect
 
-	if repomd, _, err = yumRepo.Repomd(); err != nil {
-		return 0, err, false
+	urls, err := introspectionURLs(ctx, repo, dao)
+	if err != nil {
+		return 0, changes, err, false
 	}
+	repomds := make([]repomdResponse, len(urls))
+	for i, url := range urls {
+		target := *repo
+		target.URL = url
+		if len(urls) > 1 {
+			// Conditional requests are only made for a single url, whose headers were recorded
+			target.Etag = ""
+			target.LastModified = ""
+		}
+		if repomds[i], err = fetchRepomd(ctx, &client, target); err != nil {
+			return 0, changes, err, false
+		}
+		if repomds[i].NotModified {
+			// Upstream reports repomd.xml has not changed since the last introspection
+			return 0, changes, nil, false
+		}
+	}
+	repomd = repomds[len(repomds)-1]
 
 	checksumStr := ""
-	if repomd.RepomdString != nil && *repomd.RepomdString != "" {
-		sum := sha256.Sum256([]byte(*repomd.RepomdString))
+	if bodies := repomdBodies(repomds); bodies != "" {
+		sum := sha256.Sum256([]byte(bodies))
 		checksumStr = hex.EncodeToString(sum[:])
 	}
 
 	if repo.RepomdChecksum != "" && checksumStr != "" && checksumStr == repo.RepomdChecksum {
 		// If repository hasn't changed, no need to update
-		return 0, nil, false
+		return 0, changes, nil, false
 	}
 
-	if packages, _, err = yumRepo.Packages(); err != nil {
-		return 0, err, false
+	capabilities = packageCapabilities{}
+	for i, url := range urls {
+		urlPackages, urlCapabilities, err := fetchPackages(ctx, &client, url, repomds[i].Body)
+		if err != nil {
+			return 0, changes, err, false
+		}
+		packages = append(packages, urlPackages...)
+		for checksum, packageCapabilities := range urlCapabilities {
+			capabilities[checksum] = packageCapabilities
+		}
 	}
 
-	if total, err = dao.Rpm.InsertForRepository(repo.UUID, packages); err != nil {
-		return 0, err, false
+	if changes, err = dao.Rpm.PackageChanges(ctx, repo.UUID, packages); err != nil {
+		return 0, changes, err, false
+	}
+	inserted, err := dao.Rpm.InsertForRepository(ctx, repo.UUID, packages)
+	if err != nil {
+		return 0, changes, err, false
+	}
+	logger.Info().
+		Str("repository_uuid", repo.UUID).
+		Int64("inserted", inserted.Inserted).
+		Int64("skipped", inserted.Skipped).
+		Int64("added", inserted.Added).
+		Msg("Inserted the rpms of the repository")
+	total = inserted.Added
+	if err = dao.Rpm.InsertCapabilities(ctx, capabilities); err != nil {
+		return 0, changes, err, false
 	}
 
 	var foundCount int
-	if foundCount, err = dao.Repository.FetchRepositoryRPMCount(repo.UUID); err != nil {
-		return 0, err, false
+	if foundCount, err = dao.Repository.FetchRepositoryRPMCount(ctx, repo.UUID); err != nil {
+		return 0, changes, err, false
 	}
 
 	repo.RepomdChecksum = checksumStr
+	repo.RepomdRevision = repomd.Revision
+	repo.Etag = ""
+	repo.LastModified = ""
+	if len(urls) == 1 {
+		repo.Etag = repomd.Etag
+		repo.LastModified = repomd.LastModified
+	}
 	repo.PackageCount = foundCount
-	if err = dao.Repository.Update(RepoToRepoUpdate(*repo)); err != nil {
-		return 0, err, false
+	if err = dao.Repository.Update(ctx, RepoToRepoUpdate(*repo)); err != nil {
+		return 0, changes, err, false
+	}
+
+	return total, changes, nil, true
+}
+
+// introspectionURLs returns the urls the repository is introspected from, its url expanded with the
+// distribution versions and architecture of each of its repository configurations when it contains variables
+func introspectionURLs(ctx context.Context, repo *dao.Repository, dao *dao.DaoRegistry) ([]string, error) {
+	if !models.HasVariables(repo.URL) {
+		return []string{repo.URL}, nil
+	}
+	distributions, err := dao.Repository.FetchDistributions(ctx, repo.UUID)
+	if err != nil {
+		return nil, err
+	}
+	urls := []string{}
+	found := make(map[string]bool)
+	for _, distribution := range distributions {
+		for _, url := range models.ExpandURL(repo.URL, distribution.Versions, distribution.Arch) {
+			if !found[url] {
+				found[url] = true
+				urls = append(urls, url)
+			}
+		}
+	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no distribution version or architecture to expand the variables of %s with", repo.URL)
 	}
+	return urls, nil
+}
 
-	return total, nil, true
+// repomdBodies joins the repomd.xml of each url, so that their checksum changes when any of them changes
+func repomdBodies(repomds []repomdResponse) string {
+	bodies := make([]string, len(repomds))
+	for i := range repomds {
+		bodies[i] = repomds[i].Body
+	}
+	return strings.Join(bodies, "")
 }
 
-func reposForIntrospection(urls *[]string, force bool) ([]dao.Repository, []error) {
+func reposForIntrospection(ctx context.Context, urls *[]string, force bool) ([]dao.Repository, []error) {
 	repoDao := dao.GetRepositoryDao(db.DB)
 	ignoredFailed := !force // when forcing introspection, include repositories over FailedIntrospectionsLimit
 	if urls != nil {
 		var repos []dao.Repository
 		var errors []error
 		for i := 0; i < len(*urls); i++ {
-			repo, err := repoDao.FetchForUrl((*urls)[i])
+			repo, err := repoDao.FetchForUrl(ctx, (*urls)[i])
 			if err != nil {
 				errors = append(errors, err)
 			} else if ignoredFailed && repo.FailedIntrospectionsCount > config.FailedIntrospectionsLimit {
@@ -125,7 +233,7 @@ func reposForIntrospection(urls *[]string, force bool) ([]dao.Repository, []erro
 		}
 		return repos, errors
 	} else {
-		repos, err := repoDao.List(ignoredFailed)
+		repos, err := repoDao.List(ctx, ignoredFailed)
 		return repos, []error{err}
 	}
 }
@@ -137,6 +245,7 @@ func IntrospectAll(ctx context.Context, urls *[]string, force bool) (int64, []er
 	var (
 		total                  int64
 		count                  int64
+		changes                models.PackageChanges
 		err                    error
 		dao                    = dao.GetDaoRegistry(db.DB)
 		introspectionErrors    []error
@@ -144,7 +253,7 @@ func IntrospectAll(ctx context.Context, urls *[]string, force bool) (int64, []er
 		introspectSuccessUuids []string
 		updated                bool
 	)
-	repos, errors := reposForIntrospection(urls, force)
+	repos, errors := reposForIntrospection(ctx, urls, force)
 	for i := 0; i < len(repos); i++ {
 		if !force {
 			hasToIntrospect, reason := needsIntrospect(&repos[i])
@@ -154,50 +263,91 @@ func IntrospectAll(ctx context.Context, urls *[]string, force bool) (int64, []er
 			}
 		} else {
 			log.Info().Msgf("Forcing introspection for '%s'", repos[i].URL)
+			forceIntrospection(&repos[i])
 		}
-		count, err, updated = Introspect(ctx, &repos[i], dao)
+		startedAt := time.Now()
+		count, changes, err, updated = Introspect(ctx, &repos[i], dao)
 		total += count
+		if recordErr := recordIntrospection(ctx, repos[i], dao, startedAt, changes, err, updated); recordErr != nil {
+			errors = append(errors, recordErr)
+		}
 
 		if err != nil {
 			introspectionErrors = append(introspectionErrors, fmt.Errorf("Error introspecting %s: %s", repos[i].URL, err.Error()))
 			introspectFailedUuids = append(introspectFailedUuids, repos[i].UUID)
 		} else if updated {
 			introspectSuccessUuids = append(introspectSuccessUuids, repos[i].UUID)
+			produceRepositoryChanges(repos[i], changes)
 		}
 
-		err = UpdateIntrospectionStatusMetadata(repos[i], dao, count, err)
+		err = UpdateIntrospectionStatusMetadata(ctx, repos[i], dao, count, err)
 		if err != nil {
 			errors = append(errors, err)
 		}
 	}
-	err = dao.Repository.OrphanCleanup()
+	err = dao.Repository.OrphanCleanup(ctx)
 	if err != nil {
 		errors = append(errors, err)
 	}
-	err = dao.Repository.OrphanCleanup()
+	err = dao.Repository.OrphanCleanup(ctx)
 	if err != nil {
 		errors = append(errors, err)
 	}
 
 	// Logic to handle notifications
-	sendIntrospectionNotifications(introspectSuccessUuids, introspectFailedUuids, dao)
+	sendIntrospectionNotifications(ctx, introspectSuccessUuids, introspectFailedUuids, dao)
+	flushRepositoryChanges()
 
 	return total, introspectionErrors, errors
 }
 
-func sendIntrospectionNotifications(successUuids []string, failedUuids []string, dao *dao.DaoRegistry) {
+// forceIntrospection forgets the ETag, Last-Modified and checksum of the last repomd.xml of the repository,
+// so that its packages are fetched and inserted again even though upstream has not changed, such as after
+// an introspection that failed to insert them
+func forceIntrospection(repo *dao.Repository) {
+	repo.Etag = ""
+	repo.LastModified = ""
+	repo.RepomdChecksum = ""
+}
+
+// recordIntrospection adds the introspection of the repository, and the packages it changed,
+// to the history of its introspections
+func recordIntrospection(ctx context.Context, repo dao.Repository, dao *dao.DaoRegistry, startedAt time.Time, changes models.PackageChanges, introspectErr error, updated bool) error {
+	introspection := models.Introspection{
+		RepositoryUUID: repo.UUID,
+		StartedAt:      startedAt,
+		FinishedAt:     time.Now(),
+		Status:         models.IntrospectionUnchanged,
+	}
+	if introspectErr != nil {
+		message := introspectErr.Error()
+		introspection.Status = models.IntrospectionFailed
+		introspection.Error = &message
+	} else if updated {
+		introspection.Status = models.IntrospectionSucceeded
+		introspection.PackagesAdded = changes.Added
+		introspection.PackagesRemoved = changes.Removed
+		introspection.PackagesUpdated = changes.Updated
+	}
+	if err := dao.Repository.CreateIntrospection(ctx, introspection); err != nil {
+		return fmt.Errorf("failed to record introspection: %w", err)
+	}
+	return nil
+}
+
+func sendIntrospectionNotifications(ctx context.Context, successUuids []string, failedUuids []string, dao *dao.DaoRegistry) {
 	count := 0
 	wg := sync.WaitGroup{}
 
 	if len(successUuids) != 0 {
 		for i := 0; i < len(successUuids); i++ {
 			uuid := successUuids[i]
-			repos := dao.RepositoryConfig.InternalOnly_FetchRepoConfigsForRepoUUID(uuid)
+			repos := dao.RepositoryConfig.InternalOnly_FetchRepoConfigsForRepoUUID(ctx, uuid)
 			for j := 0; j < len(repos); j++ {
 				wg.Add(1)
 				count = count + 1
 				go func(index int) {
-					notifications.SendNotification(
+					notifications.SendNotification(ctx,
 						repos[index].OrgID,
 						notifications.RepositoryIntrospected,
 						[]repositories.Repositories{notifications.MapRepositoryResponse(repos[index])},
@@ -217,16 +367,24 @@ func sendIntrospectionNotifications(successUuids []string, failedUuids []string,
 
 	for i := 0; i < len(failedUuids); i++ {
 		uuid := failedUuids[i]
-		repos := dao.RepositoryConfig.InternalOnly_FetchRepoConfigsForRepoUUID(uuid)
+		repos := dao.RepositoryConfig.InternalOnly_FetchRepoConfigsForRepoUUID(ctx, uuid)
 		for j := 0; j < len(repos); j++ {
 			wg.Add(1)
 			count = count + 1
 			go func(index int) {
-				notifications.SendNotification(
+				notifications.SendNotification(ctx,
 					repos[index].OrgID,
 					notifications.RepositoryIntrospectionFailure,
 					[]repositories.Repositories{notifications.MapRepositoryResponse(repos[index])},
 				)
+				err := dao.Webhook.QueueEvent(ctx,
+					repos[index].OrgID,
+					api.WebhookEventIntrospectionFailure,
+					api.WebhookEventData{Repository: api.NewWebhookRepository(repos[index])},
+				)
+				if err != nil {
+					log.Error().Err(err).Msgf("Could not queue webhook event for repository %v", repos[index].UUID)
+				}
 				wg.Done()
 			}(j)
 			if count > 100 { // This limits the thread count
@@ -244,6 +402,13 @@ func needsIntrospect(repo *dao.Repository) (bool, string) {
 		return false, "Cannot introspect nil Repository"
 	}
 
+	if repo.FailedIntrospectionsCount > 0 && repo.LastIntrospectionTime != nil {
+		interval := introspectBackoffInterval(repo.FailedIntrospectionsCount)
+		if repo.LastIntrospectionTime.Add(interval).After(time.Now()) {
+			return false, fmt.Sprintf("Introspection skipped: %d failed introspections in a row, retrying every %v for Repository.UUID = %s", repo.FailedIntrospectionsCount, interval, repo.UUID)
+		}
+	}
+
 	if repo.Status != config.StatusValid {
 		return true, fmt.Sprintf("Introspection started: the Status field content differs from '%s' for Repository.UUID = %s", config.StatusValid, repo.UUID)
 	}
@@ -260,43 +425,98 @@ func needsIntrospect(repo *dao.Repository) (bool, string) {
 	return true, fmt.Sprintf("Introspection started: last introspection happened after the threshold for Repository.UUID = %s", repo.UUID)
 }
 
-func httpClient(useCert bool) (http.Client, error) {
+// introspectBackoffInterval returns the delay between introspections of a repository that failed
+// failedCount times in a row, doubling from IntrospectTimeInterval up to MaxIntrospectTimeInterval
+func introspectBackoffInterval(failedCount int) time.Duration {
+	interval := IntrospectTimeInterval
+	for i := 1; i < failedCount && interval < MaxIntrospectTimeInterval; i++ {
+		interval *= 2
+	}
+	if interval > MaxIntrospectTimeInterval {
+		return MaxIntrospectTimeInterval
+	}
+	return interval
+}
+
+// checkRedirect follows the redirects of the metadata of a repository to urls allowed to repositories only,
+// at most 10 times as the default policy
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	return config.CheckRepositoryURL(req.URL.String())
+}
+
+// cdnCertificate returns the certificate a repository of the Red Hat CDN is introspected with, nil
+// for other repositories.  Repositories added by orgs use the entitlement certificate of the org
+// when candlepin is configured, public ones the cdn certificate of the service.
+func cdnCertificate(ctx context.Context, repo *dao.Repository, daoReg *dao.DaoRegistry) (*tls.Certificate, error) {
+	if !IsRedHat(repo.URL) {
+		return nil, nil
+	}
+	if !repo.Public && config.CandlepinConfigured() {
+		orgID, err := daoReg.Repository.FetchEntitledOrg(ctx, repo.UUID)
+		if err != nil {
+			return nil, err
+		}
+		if orgID != "" {
+			entitlement, err := candlepin_client.GetCandlepinClient(ctx).FetchEntitlementCertificate(orgID)
+			if err != nil {
+				return nil, fmt.Errorf("could not fetch the entitlement certificate of org %v: %w", orgID, err)
+			}
+			return entitlement.TLSCertificate()
+		}
+	}
+	cert := config.Get().Certs.CdnCertPair
+	if cert == nil {
+		return nil, errors.New("no certificate loaded")
+	}
+	return cert, nil
+}
+
+// httpClient returns the client fetching the metadata of a repository, authenticating with
+// the CDN certificate to Red Hat repositories, and going through the proxy of the repository if any.
+// It does not connect to loopback, private or link-local addresses outside of the allowed networks.
+func httpClient(cert *tls.Certificate, proxy dao.RepositoryProxy) (http.Client, error) {
 	timeout := 90 * time.Second
-	if useCert {
-		var (
-			cert   *tls.Certificate
-			caCert []byte
-			err    error
-		)
-
-		cert = config.Get().Certs.CdnCertPair
-		if cert == nil {
-			return http.Client{}, errors.New("no certificate loaded")
+	if _, err := proxy.ProxyFunc(); err != nil {
+		return http.Client{}, fmt.Errorf("invalid proxy url: %w", err)
+	}
+	if cert == nil && proxy.URL == "" {
+		transport, err := config.RepositoryTransport()
+		if err != nil {
+			return http.Client{}, err
 		}
+		return http.Client{Transport: transport}, nil
+	}
+	transport, err := proxy.Transport()
+	if err != nil {
+		return http.Client{}, err
+	}
+	if cert != nil {
+		var caCert []byte
 		if caCert, err = LoadCA(); err != nil {
 			return http.Client{}, err
 		}
 		caCertPool := x509.NewCertPool()
 		caCertPool.AppendCertsFromPEM(caCert)
 
-		tlsConfig := &tls.Config{
+		transport.TLSClientConfig = &tls.Config{
 			Certificates: []tls.Certificate{*cert},
 			RootCAs:      caCertPool,
 		}
-
-		transport := &http.Transport{TLSClientConfig: tlsConfig, ResponseHeaderTimeout: timeout}
+		transport.ResponseHeaderTimeout = timeout
 		return http.Client{Transport: transport, Timeout: timeout}, nil
-	} else {
-		return http.Client{}, nil
 	}
+	return http.Client{Transport: transport}, nil
 }
 
 // UpdateIntrospectionStatusMetadata updates introspection timestamps, error, and status on repo. Use after calling Introspect().
-func UpdateIntrospectionStatusMetadata(repo dao.Repository, dao *dao.DaoRegistry, count int64, err error) error {
+func UpdateIntrospectionStatusMetadata(ctx context.Context, repo dao.Repository, dao *dao.DaoRegistry, count int64, err error) error {
 	introspectTimeEnd := time.Now()
 	repoUpdate := updateIntrospectionStatusMetadata(repo, count, err, &introspectTimeEnd)
 
-	if err := dao.Repository.Update(repoUpdate); err != nil {
+	if err := dao.Repository.Update(ctx, repoUpdate); err != nil {
 		return fmt.Errorf("failed to update introspection timestamps: %w", err)
 	}
 
@@ -314,6 +534,7 @@ func updateIntrospectionStatusMetadata(input dao.Repository, count int64, err er
 		}
 		output.LastIntrospectionSuccessTime = introspectTimeEnd
 		output.LastIntrospectionError = pointy.String("")
+		output.LastIntrospectionErrorCode = pointy.String("")
 		output.Status = config.StatusValid
 		output.FailedIntrospectionsCount = 0
 		return RepoToRepoUpdate(output)
@@ -321,6 +542,7 @@ func updateIntrospectionStatusMetadata(input dao.Repository, count int64, err er
 
 	// If introspection fails
 	output.LastIntrospectionError = pointy.String(err.Error())
+	output.LastIntrospectionErrorCode = pointy.String(IntrospectionErrorCode(err))
 	output.FailedIntrospectionsCount += 1
 	switch input.Status {
 	case config.StatusValid:
@@ -346,10 +568,14 @@ func RepoToRepoUpdate(repo dao.Repository) dao.RepositoryUpdate {
 		UUID:                         repo.UUID,
 		URL:                          &repo.URL,
 		RepomdChecksum:               &repo.RepomdChecksum,
+		RepomdRevision:               &repo.RepomdRevision,
+		Etag:                         &repo.Etag,
+		LastModified:                 &repo.LastModified,
 		LastIntrospectionTime:        repo.LastIntrospectionTime,
 		LastIntrospectionSuccessTime: repo.LastIntrospectionSuccessTime,
 		LastIntrospectionUpdateTime:  repo.LastIntrospectionUpdateTime,
 		LastIntrospectionError:       repo.LastIntrospectionError,
+		LastIntrospectionErrorCode:   repo.LastIntrospectionErrorCode,
 		Status:                       &repo.Status,
 		PackageCount:                 &repo.PackageCount,
 		FailedIntrospectionsCount:    &repo.FailedIntrospectionsCount,
@@ -0,0 +1,155 @@
+package external_repos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/yummy/pkg/yum"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// introspectFixture introspects a repository of the fixture server, returning the
+// packages inserted
+func introspectFixture(t *testing.T, ctx context.Context, repo *dao.Repository) ([]yum.Package, error, bool) {
+	var inserted []yum.Package
+	mockDao := dao.GetMockDaoRegistry(t)
+	mockDao.Rpm.On("InsertForRepository", mock.Anything, repo.UUID, mock.MatchedBy(func(pkgs []yum.Package) bool {
+		inserted = pkgs
+		return true
+	})).Return(dao.RpmInsertResult{Inserted: 14, Added: 14}, nil).Maybe()
+	mockDao.Rpm.On("PackageChanges", mock.Anything, repo.UUID, mock.Anything).Return(models.PackageChanges{}, nil).Maybe()
+	mockDao.Rpm.On("InsertCapabilities", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mockDao.Repository.On("FetchProxy", mock.Anything, repo.UUID).Return(dao.RepositoryProxy{}, nil).Maybe()
+	mockDao.Repository.On("FetchCredentials", mock.Anything, repo.UUID).Return(dao.RepositoryCredentials{}, nil).Maybe()
+	mockDao.Repository.On("FetchRepositoryRPMCount", mock.Anything, repo.UUID).Return(14, nil).Maybe()
+	mockDao.Repository.On("Update", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	_, _, err, updated := Introspect(ctx, repo, mockDao.ToDaoRegistry())
+	return inserted, err, updated
+}
+
+func TestIntrospectFixtureCompressions(t *testing.T) {
+	server := newFixtureServer(t)
+
+	for variant := range server.variants {
+		if variant == "lz4" {
+			continue
+		}
+		t.Run(variant, func(t *testing.T) {
+			repo := &dao.Repository{UUID: uuid.NewString(), URL: server.RepoURL(variant)}
+			packages, err, updated := introspectFixture(t, context.Background(), repo)
+			require.NoError(t, err)
+			assert.True(t, updated)
+			require.Len(t, packages, 14)
+			assert.Equal(t, "dnf-plugin-artifact-registry", packages[0].Name)
+			assert.Equal(t, templateRepoMdXmlRevision, repo.RepomdRevision)
+			assert.Equal(t, fixtureEtag, repo.Etag)
+		})
+	}
+
+	// Only repomd.xml and primary.xml are fetched
+	for _, path := range server.Requests() {
+		assert.NotContains(t, path, "updateinfo.xml")
+	}
+}
+
+func TestIntrospectFixtureUnsupportedCompression(t *testing.T) {
+	server := newFixtureServer(t)
+	repo := &dao.Repository{UUID: uuid.NewString(), URL: server.RepoURL("lz4")}
+
+	_, err, updated := introspectFixture(t, context.Background(), repo)
+	assert.ErrorIs(t, err, ErrUnsupportedCompression)
+	assert.ErrorContains(t, err, "primary.xml.lz4")
+	assert.False(t, updated)
+}
+
+func TestIntrospectFixtureNotModified(t *testing.T) {
+	server := newFixtureServer(t)
+	repo := &dao.Repository{UUID: uuid.NewString(), URL: server.RepoURL("gzip")}
+
+	_, err, updated := introspectFixture(t, context.Background(), repo)
+	require.NoError(t, err)
+	assert.True(t, updated)
+	requested := len(server.Requests())
+
+	// The saved ETag is sent back, and the unchanged primary metadata is not fetched again
+	_, err, updated = introspectFixture(t, context.Background(), repo)
+	require.NoError(t, err)
+	assert.False(t, updated)
+	assert.Equal(t, []string{"/gzip/repodata/repomd.xml"}, server.Requests()[requested:])
+}
+
+func TestIntrospectFixtureForced(t *testing.T) {
+	server := newFixtureServer(t)
+	repo := &dao.Repository{UUID: uuid.NewString(), URL: server.RepoURL("gzip")}
+
+	_, err, updated := introspectFixture(t, context.Background(), repo)
+	require.NoError(t, err)
+	assert.True(t, updated)
+	requested := len(server.Requests())
+
+	// A forced introspection fetches and inserts the packages again, though upstream has not changed
+	forceIntrospection(repo)
+	packages, err, updated := introspectFixture(t, context.Background(), repo)
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.Len(t, packages, 14)
+	assert.Contains(t, server.Requests()[requested:], "/gzip/repodata/primary.xml.gz")
+	assert.Equal(t, fixtureEtag, repo.Etag)
+}
+
+func TestIntrospectFixtureNotFound(t *testing.T) {
+	server := newFixtureServer(t)
+
+	repo := &dao.Repository{UUID: uuid.NewString(), URL: server.RepoURL("missing")}
+	_, err, updated := introspectFixture(t, context.Background(), repo)
+	assert.ErrorContains(t, err, "error fetching repomd.xml, received http status 404")
+	assert.Equal(t, api.IntrospectionErrorMetadataMissing, IntrospectionErrorCode(err))
+	assert.False(t, updated)
+
+	repo = &dao.Repository{UUID: uuid.NewString(), URL: server.RepoURL("missing-primary")}
+	_, err, updated = introspectFixture(t, context.Background(), repo)
+	assert.ErrorContains(t, err, "error fetching primary metadata, received http status 404")
+	assert.Equal(t, api.IntrospectionErrorMetadataMissing, IntrospectionErrorCode(err))
+	assert.False(t, updated)
+	assert.Empty(t, repo.RepomdChecksum, "a failed introspection must not save the checksum of repomd.xml")
+}
+
+func TestIntrospectFixtureSlow(t *testing.T) {
+	server := newFixtureServer(t)
+	repo := &dao.Repository{UUID: uuid.NewString(), URL: server.RepoURL("slow")}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err, updated := introspectFixture(t, ctx, repo)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, api.IntrospectionErrorTimeout, IntrospectionErrorCode(err))
+	assert.False(t, updated)
+	assert.Less(t, time.Since(start), server.delay, "introspection did not stop at the deadline")
+}
+
+func TestIntrospectFixtureRecovers(t *testing.T) {
+	server := newFixtureServer(t)
+	repo := &dao.Repository{UUID: uuid.NewString(), URL: server.RepoURL("gzip")}
+
+	// A failing upstream fails the introspection, the next one starts over
+	server.FailNext("/gzip/repodata/primary.xml.gz", 1)
+	_, err, updated := introspectFixture(t, context.Background(), repo)
+	assert.ErrorContains(t, err, "received http status 503")
+	assert.Equal(t, api.IntrospectionErrorHTTP5XX, IntrospectionErrorCode(err))
+	assert.False(t, updated)
+	assert.Empty(t, repo.Etag)
+
+	packages, err, updated := introspectFixture(t, context.Background(), repo)
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.Len(t, packages, 14)
+}
@@ -3,16 +3,26 @@ package external_repos
 //nolint:gci
 import (
 	"context"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	_ "embed"
 
+	pgperrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+	"github.com/content-services/content-sources-backend/pkg/api"
 	"github.com/content-services/content-sources-backend/pkg/config"
 	"github.com/content-services/content-sources-backend/pkg/dao"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/ssrf"
 	"github.com/google/uuid"
 	"github.com/openlyinc/pointy"
 	"github.com/stretchr/testify/assert"
@@ -34,8 +44,10 @@ var templateRepomdXml []byte
 var primaryXml []byte
 
 const templateRepoMdXmlSum = "a4e86114143b27e8977b735a354a35cc55100a9e856bcac765cd454dfa4449e2"
+const templateRepoMdXmlRevision = "1676314282699806"
 
 func TestIntrospect(t *testing.T) {
+	allowLoopback(t)
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case "/content/repodata/primary.xml.gz":
@@ -96,14 +108,19 @@ func TestIntrospect(t *testing.T) {
 		UUID:           repoUUID,
 		URL:            server.URL + "/content",
 		RepomdChecksum: templateRepoMdXmlSum,
+		RepomdRevision: templateRepoMdXmlRevision,
 		PackageCount:   14,
 	}
 	repoUpdate := RepoToRepoUpdate(expected)
-	mockDao.Repository.On("FetchRepositoryRPMCount", repoUUID).Return(14, nil)
-	mockDao.Repository.On("Update", repoUpdate).Return(nil).Times(1)
-	mockDao.Rpm.On("InsertForRepository", repoUpdate.UUID, mock.Anything).Return(int64(14), nil)
+	mockDao.Repository.On("FetchProxy", mock.Anything, repoUUID).Return(dao.RepositoryProxy{}, nil)
+	mockDao.Repository.On("FetchCredentials", mock.Anything, repoUUID).Return(dao.RepositoryCredentials{}, nil)
+	mockDao.Repository.On("FetchRepositoryRPMCount", mock.Anything, repoUUID).Return(14, nil)
+	mockDao.Repository.On("Update", mock.Anything, repoUpdate).Return(nil).Times(1)
+	mockDao.Rpm.On("PackageChanges", mock.Anything, repoUpdate.UUID, mock.Anything).Return(models.PackageChanges{Added: 14}, nil)
+	mockDao.Rpm.On("InsertForRepository", mock.Anything, repoUpdate.UUID, mock.Anything).Return(dao.RpmInsertResult{Inserted: 14, Added: 14}, nil)
+	mockDao.Rpm.On("InsertCapabilities", mock.Anything, mock.Anything).Return(nil)
 
-	count, err, updated := Introspect(
+	count, changes, err, updated := Introspect(
 		context.Background(),
 		&dao.Repository{
 			UUID:         repoUUID,
@@ -113,11 +130,12 @@ func TestIntrospect(t *testing.T) {
 		mockDao.ToDaoRegistry())
 	assert.NoError(t, err)
 	assert.Equal(t, int64(14), count)
+	assert.Equal(t, models.PackageChanges{Added: 14}, changes)
 	assert.Equal(t, true, updated)
 	assert.Equal(t, 14, expected.PackageCount)
 
 	// Without any changes to the repo, there should be no package updates
-	count, err, updated = Introspect(
+	count, _, err, updated = Introspect(
 		context.Background(),
 		&dao.Repository{
 			UUID:           repoUUID,
@@ -132,7 +150,7 @@ func TestIntrospect(t *testing.T) {
 	assert.Equal(t, 14, expected.PackageCount)
 
 	// If the repository has failed more than FailedIntrospectionsLimit number of times in a row, it should not introspect
-	_, err, updated = Introspect(
+	_, _, err, updated = Introspect(
 		context.Background(),
 		&dao.Repository{
 			UUID:                      repoUUID,
@@ -146,13 +164,187 @@ func TestIntrospect(t *testing.T) {
 	assert.Equal(t, false, updated)
 }
 
+func TestIntrospectReleaseVer(t *testing.T) {
+	allowLoopback(t)
+	var mutex sync.Mutex
+	requested := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requested[r.URL.Path] = true
+		mutex.Unlock()
+		switch r.URL.Path {
+		case "/8/repodata/repomd.xml", "/9/repodata/repomd.xml":
+			w.Header().Add("Content-Type", "text/xml")
+			_, err := w.Write(templateRepomdXml)
+			assert.NoError(t, err)
+		case "/8/repodata/primary.xml.gz", "/9/repodata/primary.xml.gz":
+			w.Header().Add("Content-Type", "application/gzip")
+			_, err := w.Write(primaryXml)
+			assert.NoError(t, err)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	mockDao := dao.GetMockDaoRegistry(t)
+	repoUUID := uuid.NewString()
+	mockDao.Repository.On("FetchProxy", mock.Anything, repoUUID).Return(dao.RepositoryProxy{}, nil)
+	mockDao.Repository.On("FetchCredentials", mock.Anything, repoUUID).Return(dao.RepositoryCredentials{}, nil)
+	mockDao.Repository.On("FetchDistributions", mock.Anything, repoUUID).Return([]dao.RepositoryDistribution{
+		{Versions: []string{config.El8, config.El9}, Arch: config.X8664},
+		{Versions: []string{config.El9}, Arch: config.X8664},
+	}, nil)
+	mockDao.Repository.On("FetchRepositoryRPMCount", mock.Anything, repoUUID).Return(14, nil)
+	mockDao.Repository.On("Update", mock.Anything, mock.Anything).Return(nil).Once()
+	mockDao.Rpm.On("PackageChanges", mock.Anything, repoUUID, mock.Anything).Return(models.PackageChanges{Added: 14}, nil)
+	mockDao.Rpm.On("InsertForRepository", mock.Anything, repoUUID, mock.Anything).Return(dao.RpmInsertResult{Inserted: 14, Added: 14}, nil)
+	mockDao.Rpm.On("InsertCapabilities", mock.Anything, mock.Anything).Return(nil)
+
+	repo := dao.Repository{UUID: repoUUID, URL: server.URL + "/$releasever/"}
+	count, _, err, updated := Introspect(context.Background(), &repo, mockDao.ToDaoRegistry())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(14), count)
+	assert.True(t, updated)
+	mutex.Lock()
+	assert.True(t, requested["/8/repodata/primary.xml.gz"])
+	assert.True(t, requested["/9/repodata/primary.xml.gz"])
+	mutex.Unlock()
+	assert.NotEqual(t, templateRepoMdXmlSum, repo.RepomdChecksum)
+
+	// Without any distribution version, there is no url to introspect
+	mockDao = dao.GetMockDaoRegistry(t)
+	mockDao.Repository.On("FetchProxy", mock.Anything, repoUUID).Return(dao.RepositoryProxy{}, nil)
+	mockDao.Repository.On("FetchCredentials", mock.Anything, repoUUID).Return(dao.RepositoryCredentials{}, nil)
+	mockDao.Repository.On("FetchDistributions", mock.Anything, repoUUID).Return([]dao.RepositoryDistribution{{Versions: []string{config.ANY_VERSION}, Arch: config.ANY_ARCH}}, nil)
+	_, _, err, updated = Introspect(context.Background(), &dao.Repository{UUID: repoUUID, URL: server.URL + "/$releasever/"}, mockDao.ToDaoRegistry())
+	assert.Error(t, err)
+	assert.False(t, updated)
+}
+
+func TestIntrospectDeniedURL(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer server.Close()
+
+	// The test server is on the loopback interface, denied by default
+	setURLDenyList(t, config.DefaultURLDenyList)
+	mockDao := dao.GetMockDaoRegistry(t)
+	_, _, err, updated := Introspect(context.Background(), &dao.Repository{UUID: uuid.NewString(), URL: server.URL + "/content/"}, mockDao.ToDaoRegistry())
+	assert.ErrorIs(t, err, ssrf.ErrDeniedURL)
+	assert.Equal(t, api.IntrospectionErrorURLDenied, IntrospectionErrorCode(err))
+	assert.False(t, updated)
+	assert.False(t, requested)
+}
+
+func TestIntrospectDeniedRedirect(t *testing.T) {
+	allowLoopback(t)
+	setURLDenyList(t, []string{"*.internal"})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://metadata.internal/computeMetadata/v1/", http.StatusFound)
+	}))
+	defer server.Close()
+
+	mockDao := dao.GetMockDaoRegistry(t)
+	repoUUID := uuid.NewString()
+	mockDao.Repository.On("FetchProxy", mock.Anything, repoUUID).Return(dao.RepositoryProxy{}, nil)
+	mockDao.Repository.On("FetchCredentials", mock.Anything, repoUUID).Return(dao.RepositoryCredentials{}, nil)
+
+	_, _, err, updated := Introspect(context.Background(), &dao.Repository{UUID: repoUUID, URL: server.URL + "/content/"}, mockDao.ToDaoRegistry())
+	assert.ErrorIs(t, err, ssrf.ErrDeniedURL)
+	assert.Equal(t, api.IntrospectionErrorURLDenied, IntrospectionErrorCode(err))
+	assert.False(t, updated)
+}
+
 func TestHttpClient(t *testing.T) {
 	initialConfig := *config.Get()
 	config.LoadedConfig = initialConfig
 
-	client, err := httpClient(false)
+	client, err := httpClient(nil, dao.RepositoryProxy{})
 	assert.NoError(t, err)
-	assert.Equal(t, http.Client{}, client)
+	assert.Equal(t, time.Duration(0), client.Timeout)
+	transport, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.NotNil(t, transport.DialContext)
+}
+
+func TestHttpClientRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	allowedNetworks := config.Get().Options.RepositoryAllowedNetworks
+	defer func() { config.Get().Options.RepositoryAllowedNetworks = allowedNetworks }()
+
+	// Host names are checked once resolved, when connecting
+	serverURL := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	config.Get().Options.RepositoryAllowedNetworks = []string{}
+	client, err := httpClient(nil, dao.RepositoryProxy{})
+	require.NoError(t, err)
+	_, err = client.Get(serverURL)
+	assert.ErrorIs(t, err, ssrf.ErrForbiddenAddress)
+	assert.Equal(t, api.IntrospectionErrorURLDenied, IntrospectionErrorCode(err))
+
+	// On-premise deployments allow the networks of their mirrors
+	config.Get().Options.RepositoryAllowedNetworks = []string{"127.0.0.0/8", "::1"}
+	client, err = httpClient(nil, dao.RepositoryProxy{})
+	require.NoError(t, err)
+	resp, err := client.Get(serverURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
+
+func TestHttpClientProxy(t *testing.T) {
+	proxy := dao.RepositoryProxy{URL: "http://proxy.example.com:3128", Username: "user", Password: "pass"}
+	client, err := httpClient(nil, proxy)
+	require.NoError(t, err)
+
+	transport, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+	req, err := http.NewRequest(http.MethodGet, "https://93.184.216.34/repodata/repomd.xml", nil)
+	require.NoError(t, err)
+	proxyURL, err := transport.Proxy(req)
+	require.NoError(t, err)
+	assert.Equal(t, "proxy.example.com:3128", proxyURL.Host)
+	password, _ := proxyURL.User.Password()
+	assert.Equal(t, "user", proxyURL.User.Username())
+	assert.Equal(t, "pass", password)
+}
+
+func TestHttpClientProxyRefusesPrivateAddresses(t *testing.T) {
+	// The proxy answers the requests itself, recording their hosts
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.Host)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+	allowedNetworks := config.Get().Options.RepositoryAllowedNetworks
+	defer func() { config.Get().Options.RepositoryAllowedNetworks = allowedNetworks }()
+
+	// The proxy of a repository is checked like the repository
+	config.Get().Options.RepositoryAllowedNetworks = []string{}
+	client, err := httpClient(nil, dao.RepositoryProxy{URL: proxy.URL})
+	require.NoError(t, err)
+	_, err = client.Get("http://93.184.216.34/repodata/repomd.xml")
+	assert.ErrorIs(t, err, ssrf.ErrForbiddenAddress)
+
+	// The hosts of the requests are checked rather than the address of the proxy
+	config.Get().Options.RepositoryAllowedNetworks = []string{"127.0.0.0/8", "::1"}
+	client, err = httpClient(nil, dao.RepositoryProxy{URL: proxy.URL})
+	require.NoError(t, err)
+	resp, err := client.Get("http://93.184.216.34/repodata/repomd.xml")
+	require.NoError(t, err)
+	resp.Body.Close()
+	for _, target := range []string{"http://169.254.169.254/latest/meta-data/", "http://10.0.0.5/repodata/repomd.xml"} {
+		_, err = client.Get(target)
+		assert.ErrorIs(t, err, ssrf.ErrForbiddenAddress, target)
+		assert.Equal(t, api.IntrospectionErrorURLDenied, IntrospectionErrorCode(err))
+	}
+	assert.Equal(t, []string{"93.184.216.34"}, proxied)
 }
 
 func TestUpdateIntrospectionStatusMetadata(t *testing.T) {
@@ -339,6 +531,12 @@ func TestUpdateIntrospectionStatusMetadata(t *testing.T) {
 			&timestamp)
 
 		assert.Equal(t, testCase.expected.LastIntrospectionError, result.LastIntrospectionError)
+		require.NotNil(t, result.LastIntrospectionErrorCode)
+		if testCase.given.err == nil {
+			assert.Equal(t, "", *result.LastIntrospectionErrorCode)
+		} else {
+			assert.Equal(t, api.IntrospectionErrorUnknown, *result.LastIntrospectionErrorCode)
+		}
 		require.NotNil(t, result.Status)
 		assert.Equal(t, testCase.expected.Status, *result.Status)
 		assert.Equal(t, testCase.expected.LastIntrospectionTime, result.LastIntrospectionTime)
@@ -347,6 +545,59 @@ func TestUpdateIntrospectionStatusMetadata(t *testing.T) {
 	}
 }
 
+func TestIntrospectionErrorCode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"No error", nil, ""},
+		{"DNS failure", fmt.Errorf("error fetching repomd.xml: %w", &url.Error{Op: "Get", Err: &net.OpError{Op: "dial", Err: &net.DNSError{Name: "bad.example.com"}}}), api.IntrospectionErrorDNSFailure},
+		{"Untrusted certificate", fmt.Errorf("error fetching repomd.xml: %w", &url.Error{Op: "Get", Err: x509.UnknownAuthorityError{}}), api.IntrospectionErrorTLS},
+		{"Timeout", fmt.Errorf("error fetching repomd.xml: %w", &url.Error{Op: "Get", Err: context.DeadlineExceeded}), api.IntrospectionErrorTimeout},
+		{"Forbidden", httpStatusError{file: "repomd.xml", status: http.StatusForbidden}, api.IntrospectionErrorHTTP4XX},
+		{"Missing repomd.xml", httpStatusError{file: "repomd.xml", status: http.StatusNotFound}, api.IntrospectionErrorMetadataMissing},
+		{"Server error", httpStatusError{file: "primary metadata", status: http.StatusBadGateway}, api.IntrospectionErrorHTTP5XX},
+		{"Primary not referenced", ErrPrimaryNotReferenced, api.IntrospectionErrorMetadataMissing},
+		{"Bad signature", fmt.Errorf("error verifying repomd.xml: %w", pgperrors.SignatureError("hash mismatch")), api.IntrospectionErrorSignatureInvalid},
+		{"Denied url", fmt.Errorf("%w: host localhost is in the deny list", ssrf.ErrDeniedURL), api.IntrospectionErrorURLDenied},
+		{"Other", errors.New("introspection skipped"), api.IntrospectionErrorUnknown},
+	}
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, IntrospectionErrorCode(testCase.err), testCase.name)
+	}
+}
+
+func TestIntrospectBackoffInterval(t *testing.T) {
+	assert.Equal(t, IntrospectTimeInterval, introspectBackoffInterval(0))
+	assert.Equal(t, IntrospectTimeInterval, introspectBackoffInterval(1))
+	assert.Equal(t, 2*IntrospectTimeInterval, introspectBackoffInterval(2))
+	assert.Equal(t, 4*IntrospectTimeInterval, introspectBackoffInterval(3))
+	assert.Equal(t, MaxIntrospectTimeInterval, introspectBackoffInterval(4))
+	assert.Equal(t, MaxIntrospectTimeInterval, introspectBackoffInterval(config.FailedIntrospectionsLimit))
+}
+
+func TestNeedIntrospectBackoff(t *testing.T) {
+	threeDaysAgo := time.Now().Add(-72 * time.Hour)
+
+	// Failed twice, retried every 46 hours
+	result, _ := needsIntrospect(&dao.Repository{
+		Status:                    config.StatusUnavailable,
+		LastIntrospectionTime:     &threeDaysAgo,
+		FailedIntrospectionsCount: 2,
+	})
+	assert.True(t, result)
+
+	// Failed four times, retried weekly
+	result, reason := needsIntrospect(&dao.Repository{
+		Status:                    config.StatusUnavailable,
+		LastIntrospectionTime:     &threeDaysAgo,
+		FailedIntrospectionsCount: 4,
+	})
+	assert.False(t, result)
+	assert.Equal(t, fmt.Sprintf("Introspection skipped: 4 failed introspections in a row, retrying every %v for Repository.UUID = ", MaxIntrospectTimeInterval), reason)
+}
+
 func TestNeedIntrospect(t *testing.T) {
 	type TestCaseExpected struct {
 		result bool
@@ -455,3 +706,32 @@ func TestNeedIntrospect(t *testing.T) {
 		assert.Equal(t, testCase.expected.reason, reason)
 	}
 }
+
+func TestRecordIntrospection(t *testing.T) {
+	mockDao := dao.GetMockDaoRegistry(t)
+	repo := dao.Repository{UUID: uuid.NewString(), PackageCount: 12}
+	startedAt := time.Now()
+
+	mockDao.Repository.On("CreateIntrospection", mock.Anything, mock.MatchedBy(func(introspection models.Introspection) bool {
+		return introspection.RepositoryUUID == repo.UUID &&
+			introspection.Status == models.IntrospectionSucceeded &&
+			introspection.PackagesAdded == 5 &&
+			introspection.PackagesRemoved == 3 &&
+			introspection.PackagesUpdated == 2 &&
+			introspection.StartedAt == startedAt &&
+			introspection.Error == nil
+	})).Return(nil).Once()
+	changes := models.PackageChanges{Added: 5, Removed: 3, Updated: 2}
+	assert.NoError(t, recordIntrospection(context.Background(), repo, mockDao.ToDaoRegistry(), startedAt, changes, nil, true))
+
+	mockDao.Repository.On("CreateIntrospection", mock.Anything, mock.MatchedBy(func(introspection models.Introspection) bool {
+		return introspection.Status == models.IntrospectionUnchanged && introspection.PackagesAdded == 0
+	})).Return(nil).Once()
+	assert.NoError(t, recordIntrospection(context.Background(), repo, mockDao.ToDaoRegistry(), startedAt, models.PackageChanges{}, nil, false))
+
+	mockDao.Repository.On("CreateIntrospection", mock.Anything, mock.MatchedBy(func(introspection models.Introspection) bool {
+		return introspection.Status == models.IntrospectionFailed &&
+			introspection.Error != nil && *introspection.Error == "repomd.xml not found"
+	})).Return(nil).Once()
+	assert.NoError(t, recordIntrospection(context.Background(), repo, mockDao.ToDaoRegistry(), startedAt, models.PackageChanges{}, errors.New("repomd.xml not found"), false))
+}
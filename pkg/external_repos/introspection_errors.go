@@ -0,0 +1,70 @@
+package external_repos
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	pgperrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/ssrf"
+)
+
+// httpStatusError is returned when a metadata file is answered with an unexpected http status
+type httpStatusError struct {
+	file   string
+	status int
+}
+
+func (e httpStatusError) Error() string {
+	return fmt.Sprintf("error fetching %s, received http status %d", e.file, e.status)
+}
+
+// IntrospectionErrorCode returns the code of an error returned by Introspect, so that users can
+// tell a typo in the host from a missing repository or an untrusted certificate
+func IntrospectionErrorCode(err error) string {
+	var (
+		dnsErr          *net.DNSError
+		netErr          net.Error
+		statusErr       httpStatusError
+		unknownAuthErr  x509.UnknownAuthorityError
+		certInvalidErr  x509.CertificateInvalidError
+		hostnameErr     x509.HostnameError
+		recordHeaderErr tls.RecordHeaderError
+		signatureErr    pgperrors.SignatureError
+	)
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ssrf.ErrDeniedURL) || errors.Is(err, ssrf.ErrForbiddenAddress):
+		return api.IntrospectionErrorURLDenied
+	case errors.As(err, &dnsErr):
+		return api.IntrospectionErrorDNSFailure
+	case errors.As(err, &unknownAuthErr) || errors.As(err, &certInvalidErr) ||
+		errors.As(err, &hostnameErr) || errors.As(err, &recordHeaderErr):
+		return api.IntrospectionErrorTLS
+	case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		return api.IntrospectionErrorTimeout
+	case errors.As(err, &statusErr):
+		switch {
+		case statusErr.status == http.StatusNotFound:
+			return api.IntrospectionErrorMetadataMissing
+		case statusErr.status >= 400 && statusErr.status < 500:
+			return api.IntrospectionErrorHTTP4XX
+		case statusErr.status >= 500:
+			return api.IntrospectionErrorHTTP5XX
+		default:
+			return api.IntrospectionErrorUnknown
+		}
+	case errors.Is(err, ErrPrimaryNotReferenced) || errors.Is(err, ErrPrimaryEmpty):
+		return api.IntrospectionErrorMetadataMissing
+	case errors.As(err, &signatureErr) || errors.Is(err, pgperrors.ErrUnknownIssuer):
+		return api.IntrospectionErrorSignatureInvalid
+	default:
+		return api.IntrospectionErrorUnknown
+	}
+}
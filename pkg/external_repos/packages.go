@@ -0,0 +1,285 @@
+package external_repos
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/yummy/pkg/yum"
+	"github.com/klauspost/compress/zstd"
+	"github.com/rs/zerolog"
+	"github.com/ulikunitz/xz"
+)
+
+// progressInterval is the number of packages parsed between progress log messages
+const progressInterval = 50000
+
+// ErrMemoryBudgetExceeded is returned when the parsed packages would use more than the configured memory budget
+var ErrMemoryBudgetExceeded = errors.New("repository metadata exceeds the introspection memory budget")
+
+// ErrUnsupportedCompression is returned when the primary metadata is neither xml nor compressed with gzip, zstd, xz or bzip2
+var ErrUnsupportedCompression = errors.New("unsupported compression of the primary metadata")
+
+// ErrPrimaryNotReferenced is returned when repomd.xml does not reference the primary metadata
+var ErrPrimaryNotReferenced = errors.New("repomd.xml does not reference primary metadata")
+
+// ErrPrimaryEmpty is returned when the primary metadata is empty
+var ErrPrimaryEmpty = errors.New("primary metadata is empty")
+
+// Magic numbers starting the supported compressed formats
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	xzMagic    = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+	bzip2Magic = []byte("BZh")
+)
+
+// sniffLength is the number of bytes read to detect the compression of the metadata
+const sniffLength = 64
+
+type repomdLocations struct {
+	Data []struct {
+		Type     string `xml:"type,attr"`
+		Location struct {
+			Href string `xml:"href,attr"`
+		} `xml:"location"`
+	} `xml:"data"`
+}
+
+// primaryPackage holds the fields of a primary.xml package entry that are stored
+type primaryPackage struct {
+	Type    string `xml:"type,attr"`
+	Name    string `xml:"name"`
+	Arch    string `xml:"arch"`
+	Version struct {
+		Epoch   int32  `xml:"epoch,attr"`
+		Version string `xml:"ver,attr"`
+		Release string `xml:"rel,attr"`
+	} `xml:"version"`
+	Checksum struct {
+		Type  string `xml:"type,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"checksum"`
+	Summary string `xml:"summary"`
+	Format  struct {
+		Provides  capabilityEntries `xml:"provides"`
+		Requires  capabilityEntries `xml:"requires"`
+		Obsoletes capabilityEntries `xml:"obsoletes"`
+		Files     []string          `xml:"file"`
+	} `xml:"format"`
+}
+
+type capabilityEntries struct {
+	Entries []struct {
+		Name    string `xml:"name,attr"`
+		Flags   string `xml:"flags,attr"`
+		Epoch   int32  `xml:"epoch,attr"`
+		Version string `xml:"ver,attr"`
+		Release string `xml:"rel,attr"`
+	} `xml:"entry"`
+}
+
+// size approximates the memory used by the package once converted
+func (p primaryPackage) size() int64 {
+	size := len(p.Type) + len(p.Name) + len(p.Arch) + len(p.Version.Version) + len(p.Version.Release) +
+		len(p.Checksum.Type) + len(p.Checksum.Value) + len(p.Summary)
+	for _, entries := range []capabilityEntries{p.Format.Provides, p.Format.Requires, p.Format.Obsoletes} {
+		for _, entry := range entries.Entries {
+			size += len(entry.Name) + len(entry.Flags) + len(entry.Version) + len(entry.Release)
+		}
+	}
+	for _, file := range p.Format.Files {
+		size += len(file)
+	}
+	return int64(size)
+}
+
+// capabilities converts the provides, requires and obsoletes entries of the package.
+// The files listed in the primary metadata are provided by the package as well, as
+// packages may require them.
+func (p primaryPackage) capabilities() []models.RpmCapability {
+	var capabilities []models.RpmCapability
+	for _, kind := range []struct {
+		name    string
+		entries capabilityEntries
+	}{
+		{models.CapabilityProvides, p.Format.Provides},
+		{models.CapabilityRequires, p.Format.Requires},
+		{models.CapabilityObsoletes, p.Format.Obsoletes},
+	} {
+		for _, entry := range kind.entries.Entries {
+			capabilities = append(capabilities, models.RpmCapability{
+				Kind:    kind.name,
+				Name:    entry.Name,
+				Flags:   entry.Flags,
+				Epoch:   entry.Epoch,
+				Version: entry.Version,
+				Release: entry.Release,
+			})
+		}
+	}
+	for _, file := range p.Format.Files {
+		capabilities = append(capabilities, models.RpmCapability{
+			Kind: models.CapabilityProvides,
+			Name: file,
+		})
+	}
+	return capabilities
+}
+
+// primaryHref returns the location of the primary metadata within the repository
+func primaryHref(repomdString string) (string, error) {
+	var locations repomdLocations
+	if err := xml.Unmarshal([]byte(repomdString), &locations); err != nil {
+		return "", fmt.Errorf("error parsing repomd.xml: %w", err)
+	}
+	for _, data := range locations.Data {
+		if data.Type == "primary" {
+			return data.Location.Href, nil
+		}
+	}
+	return "", ErrPrimaryNotReferenced
+}
+
+// packageCapabilities are the capabilities of the parsed packages, by package checksum
+type packageCapabilities map[string][]models.RpmCapability
+
+// fetchPackages downloads the primary metadata of the repository and parses its packages
+func fetchPackages(ctx context.Context, client *http.Client, repoURL string, repomdString string) ([]yum.Package, packageCapabilities, error) {
+	href, err := primaryHref(repomdString)
+	if err != nil {
+		return nil, nil, err
+	}
+	primaryURL := strings.TrimSuffix(repoURL, "/") + "/" + strings.TrimPrefix(href, "/")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, primaryURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching primary metadata: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, nil, httpStatusError{file: "primary metadata", status: resp.StatusCode}
+	}
+
+	reader, err := decompress(resp.Body, href)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer reader.Close()
+	return parsePackages(ctx, reader, memoryBudget())
+}
+
+// decompress returns a reader of the decompressed metadata.  The compression is
+// detected from the first bytes of the metadata rather than from the extension of
+// href, as some repositories do not name their metadata after its compression.
+func decompress(body io.Reader, href string) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(body)
+	start, err := buffered.Peek(sniffLength)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("error reading primary metadata: %w", err)
+	}
+
+	switch {
+	case len(start) == 0:
+		return nil, ErrPrimaryEmpty
+	case bytes.HasPrefix(start, gzipMagic):
+		reader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing primary metadata: %w", err)
+		}
+		return reader, nil
+	case bytes.HasPrefix(start, zstdMagic):
+		decoder, err := zstd.NewReader(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing primary metadata: %w", err)
+		}
+		return decoder.IOReadCloser(), nil
+	case bytes.HasPrefix(start, xzMagic):
+		reader, err := xz.NewReader(buffered)
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing primary metadata: %w", err)
+		}
+		return io.NopCloser(reader), nil
+	case bytes.HasPrefix(start, bzip2Magic):
+		return io.NopCloser(bzip2.NewReader(buffered)), nil
+	case bytes.HasPrefix(bytes.TrimSpace(bytes.TrimPrefix(start, []byte("\xef\xbb\xbf"))), []byte("<")):
+		return io.NopCloser(buffered), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCompression, path.Base(href))
+	}
+}
+
+// parsePackages parses primary.xml one package at a time, so that only the fields
+// stored are kept in memory instead of the whole document.  Fails with
+// ErrMemoryBudgetExceeded once the parsed packages exceed budget bytes, if budget is positive.
+func parsePackages(ctx context.Context, reader io.Reader, budget int64) ([]yum.Package, packageCapabilities, error) {
+	logger := zerolog.Ctx(ctx)
+	decoder := xml.NewDecoder(reader)
+	packages := []yum.Package{}
+	capabilities := packageCapabilities{}
+	var used int64
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing primary metadata: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "package" {
+			continue
+		}
+
+		var pkg primaryPackage
+		if err = decoder.DecodeElement(&pkg, &start); err != nil {
+			return nil, nil, fmt.Errorf("error parsing primary metadata: %w", err)
+		}
+		used += pkg.size()
+		if budget > 0 && used > budget {
+			return nil, nil, ErrMemoryBudgetExceeded
+		}
+		packages = append(packages, yum.Package{
+			Type: pkg.Type,
+			Name: pkg.Name,
+			Arch: pkg.Arch,
+			Version: yum.Version{
+				Version: pkg.Version.Version,
+				Release: pkg.Version.Release,
+				Epoch:   pkg.Version.Epoch,
+			},
+			Checksum: yum.Checksum{
+				Value: pkg.Checksum.Value,
+				Type:  pkg.Checksum.Type,
+			},
+			Summary: pkg.Summary,
+		})
+		if pkgCapabilities := pkg.capabilities(); len(pkgCapabilities) > 0 {
+			capabilities[pkg.Checksum.Value] = pkgCapabilities
+		}
+		if len(packages)%progressInterval == 0 {
+			logger.Debug().Int("packages", len(packages)).Int64("bytes", used).Msg("Parsing primary metadata")
+		}
+	}
+	return packages, capabilities, nil
+}
+
+func memoryBudget() int64 {
+	return int64(config.Get().Options.IntrospectMemoryBudgetMB) * 1024 * 1024
+}
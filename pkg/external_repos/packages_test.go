@@ -0,0 +1,100 @@
+package external_repos
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrimaryHref(t *testing.T) {
+	href, err := primaryHref(string(templateRepomdXml))
+	assert.NoError(t, err)
+	assert.Equal(t, "repodata/primary.xml.gz", href)
+
+	_, err = primaryHref("<repomd></repomd>")
+	assert.Error(t, err)
+}
+
+func TestParsePackages(t *testing.T) {
+	reader, err := gzip.NewReader(bytes.NewReader(primaryXml))
+	require.NoError(t, err)
+
+	packages, capabilities, err := parsePackages(context.Background(), reader, 0)
+	assert.NoError(t, err)
+	require.Len(t, packages, 14)
+	assert.Equal(t, "dnf-plugin-artifact-registry", packages[0].Name)
+	assert.Equal(t, "aarch64", packages[0].Arch)
+	assert.Equal(t, int32(1), packages[0].Version.Epoch)
+	assert.Equal(t, "20230213.00", packages[0].Version.Version)
+	assert.Equal(t, "g1.el8", packages[0].Version.Release)
+	assert.Equal(t, "eba5b1f3bbd67cf1f738b6fd2539479e956e6dcc820a0ed92be289b8f3448637", packages[0].Checksum.Value)
+	assert.Equal(t, "dnf plugin for Artifact Registry", packages[0].Summary)
+
+	pkgCapabilities := capabilities[packages[0].Checksum.Value]
+	require.Len(t, pkgCapabilities, 6)
+	assert.Equal(t, models.RpmCapability{
+		Kind:    models.CapabilityProvides,
+		Name:    "config(dnf-plugin-artifact-registry)",
+		Flags:   "EQ",
+		Epoch:   1,
+		Version: "20230213.00",
+		Release: "g1.el8",
+	}, pkgCapabilities[0])
+	assert.Equal(t, models.RpmCapability{
+		Kind:    models.CapabilityRequires,
+		Name:    "dnf",
+		Flags:   "GE",
+		Version: "1.0.0",
+	}, pkgCapabilities[3])
+	assert.Equal(t, models.RpmCapability{
+		Kind: models.CapabilityProvides,
+		Name: "/etc/dnf/plugins/artifact-registry.conf",
+	}, pkgCapabilities[5])
+}
+
+func TestParsePackagesMemoryBudget(t *testing.T) {
+	reader, err := gzip.NewReader(bytes.NewReader(primaryXml))
+	require.NoError(t, err)
+
+	_, _, err = parsePackages(context.Background(), reader, 100)
+	assert.ErrorIs(t, err, ErrMemoryBudgetExceeded)
+}
+
+func TestDecompress(t *testing.T) {
+	server := newFixtureServer(t)
+	plain := server.variants["plain"].Primary
+
+	for name, variant := range server.variants {
+		if name == "lz4" {
+			continue
+		}
+		// The compression is detected from the content, whatever the name of the file
+		for _, href := range []string{"repodata/primary.xml" + variant.Extension, "repodata/primary.xml"} {
+			reader, err := decompress(bytes.NewReader(variant.Primary), href)
+			require.NoError(t, err, name)
+			decompressed, err := io.ReadAll(reader)
+			require.NoError(t, err, name)
+			assert.NoError(t, reader.Close())
+			assert.Equal(t, plain, decompressed, name)
+		}
+	}
+
+	_, err := decompress(bytes.NewReader(server.variants["lz4"].Primary), "repodata/primary.xml.lz4")
+	assert.ErrorIs(t, err, ErrUnsupportedCompression)
+
+	_, err = decompress(bytes.NewReader([]byte{}), "repodata/primary.xml")
+	assert.Error(t, err)
+
+	reader, err := decompress(strings.NewReader("\n  <?xml version=\"1.0\"?><metadata></metadata>"), "repodata/primary.xml")
+	require.NoError(t, err)
+	packages, _, err := parsePackages(context.Background(), reader, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, packages)
+}
@@ -0,0 +1,68 @@
+package external_repos
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/content-services/content-sources-backend/pkg/dao"
+)
+
+// repomdResponse is the result of a conditional fetch of repomd.xml
+type repomdResponse struct {
+	NotModified  bool // upstream answered 304, the other fields are empty
+	Body         string
+	Revision     string
+	Etag         string
+	LastModified string
+}
+
+// fetchRepomd fetches repomd.xml, sending the ETag and Last-Modified headers saved
+// from the last introspection so that unchanged repositories are not downloaded again
+func fetchRepomd(ctx context.Context, client *http.Client, repo dao.Repository) (repomdResponse, error) {
+	repomdURL := strings.TrimSuffix(repo.URL, "/") + "/repodata/repomd.xml"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, repomdURL, nil)
+	if err != nil {
+		return repomdResponse{}, err
+	}
+	if repo.Etag != "" {
+		req.Header.Set("If-None-Match", repo.Etag)
+	}
+	if repo.LastModified != "" {
+		req.Header.Set("If-Modified-Since", repo.LastModified)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return repomdResponse{}, fmt.Errorf("error fetching repomd.xml: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return repomdResponse{NotModified: true}, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return repomdResponse{}, httpStatusError{file: "repomd.xml", status: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return repomdResponse{}, fmt.Errorf("error reading repomd.xml: %w", err)
+	}
+	var parsed struct {
+		Revision string `xml:"revision"`
+	}
+	if err = xml.Unmarshal(body, &parsed); err != nil {
+		return repomdResponse{}, fmt.Errorf("error parsing repomd.xml: %w", err)
+	}
+
+	return repomdResponse{
+		Body:         string(body),
+		Revision:     strings.TrimSpace(parsed.Revision),
+		Etag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
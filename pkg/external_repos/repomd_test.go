@@ -0,0 +1,44 @@
+package external_repos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchRepomdConditional(t *testing.T) {
+	const etag = `"abc123"`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/content/repodata/repomd.xml", r.URL.Path)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", "Mon, 13 Feb 2023 18:51:22 GMT")
+		_, err := w.Write(templateRepomdXml)
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client := http.Client{}
+	repo := dao.Repository{URL: server.URL + "/content/"}
+
+	repomd, err := fetchRepomd(context.Background(), &client, repo)
+	require.NoError(t, err)
+	assert.False(t, repomd.NotModified)
+	assert.Equal(t, string(templateRepomdXml), repomd.Body)
+	assert.Equal(t, templateRepoMdXmlRevision, repomd.Revision)
+	assert.Equal(t, etag, repomd.Etag)
+	assert.Equal(t, "Mon, 13 Feb 2023 18:51:22 GMT", repomd.LastModified)
+
+	repo.Etag = repomd.Etag
+	repomd, err = fetchRepomd(context.Background(), &client, repo)
+	require.NoError(t, err)
+	assert.True(t, repomd.NotModified)
+}
@@ -0,0 +1,74 @@
+package external_repos
+
+import (
+	"sync"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	"github.com/content-services/content-sources-backend/pkg/event/message"
+	"github.com/content-services/content-sources-backend/pkg/event/producer"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/rs/zerolog/log"
+)
+
+// flushTimeoutMs is how long to wait for the repository changes to be delivered at the end of an introspection
+const flushTimeoutMs = 10000
+
+var (
+	repositoryChangesOnce     sync.Once
+	repositoryChangesKafka    *kafka.Producer
+	repositoryChangesProducer producer.RepositoryChanges
+)
+
+// getRepositoryChangesProducer returns the producer of the repository changes events, created on first use,
+// or nil when kafka is not configured or the producer cannot be created
+func getRepositoryChangesProducer() producer.RepositoryChanges {
+	repositoryChangesOnce.Do(func() {
+		kafkaConfig := &config.Get().Kafka
+		if kafkaConfig.Bootstrap.Servers == "" {
+			log.Warn().Msg("Repository changes are not produced, as no kafka broker is configured")
+			return
+		}
+		var err error
+		if repositoryChangesKafka, err = producer.NewProducer(kafkaConfig); err != nil {
+			log.Error().Err(err).Msg("Could not create the kafka producer for repository changes")
+			return
+		}
+		if repositoryChangesProducer, err = producer.NewRepositoryChanges(repositoryChangesKafka); err != nil {
+			log.Error().Err(err).Msg("Could not create the repository changes producer")
+		}
+	})
+	return repositoryChangesProducer
+}
+
+// produceRepositoryChanges announces to downstream services the packages changed by an introspection of the repository
+func produceRepositoryChanges(repo dao.Repository, changes models.PackageChanges) {
+	if changes == (models.PackageChanges{}) {
+		return
+	}
+	p := getRepositoryChangesProducer()
+	if p == nil {
+		return
+	}
+	err := p.Produce(&message.RepositoryChangesMessage{
+		Uuid:            repo.UUID,
+		Url:             repo.URL,
+		PackagesAdded:   int(changes.Added),
+		PackagesRemoved: int(changes.Removed),
+		PackagesUpdated: int(changes.Updated),
+	})
+	if err != nil {
+		log.Error().Err(err).Msgf("Could not produce the changes of repository %v", repo.UUID)
+	}
+}
+
+// flushRepositoryChanges waits for the repository changes produced to be delivered
+func flushRepositoryChanges() {
+	if repositoryChangesKafka == nil {
+		return
+	}
+	if remaining := repositoryChangesKafka.Flush(flushTimeoutMs); remaining > 0 {
+		log.Warn().Msgf("%d repository changes events were not delivered", remaining)
+	}
+}
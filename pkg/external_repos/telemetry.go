@@ -0,0 +1,83 @@
+package external_repos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	"github.com/content-services/content-sources-backend/pkg/event"
+	"github.com/content-services/content-sources-backend/pkg/event/producer"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/openlyinc/pointy"
+)
+
+// telemetryReport is the message sent to the telemetry topic, all the statistics of a day
+type telemetryReport struct {
+	Day   string                 `json:"day"`
+	Stats []models.TelemetryStat `json:"stats"`
+}
+
+// ReportTelemetry computes the anonymous usage statistics of the day into the telemetry_stats table,
+// and sends them to the telemetry topic when one is configured, returning the number of statistics.
+// Nothing is computed unless telemetry is enabled.
+func ReportTelemetry(ctx context.Context, daoReg *dao.DaoRegistry, day time.Time) (int, error) {
+	telemetry := config.Get().Telemetry
+	if !telemetry.Enabled {
+		return 0, nil
+	}
+	stats, err := daoReg.Telemetry.Compute(ctx, day, telemetry.TopHosts, telemetry.MinOrgs)
+	if err != nil {
+		return 0, err
+	}
+	if err = daoReg.Telemetry.Save(ctx, stats); err != nil {
+		return 0, err
+	}
+	if telemetry.Topic != "" {
+		report := telemetryReport{Day: day.UTC().Format("2006-01-02"), Stats: stats}
+		if err = produceTelemetry(telemetry.Topic, report); err != nil {
+			return len(stats), fmt.Errorf("statistics were saved but not sent: %w", err)
+		}
+	}
+	return len(stats), nil
+}
+
+// produceTelemetry sends the report to the topic, waiting for it to be delivered
+func produceTelemetry(topic string, report telemetryReport) error {
+	kafkaConfig := &config.Get().Kafka
+	if kafkaConfig.Bootstrap.Servers == "" {
+		return fmt.Errorf("no kafka broker is configured")
+	}
+	p, err := producer.NewProducer(kafkaConfig)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	// The topic is renamed by clowder when requested in the app config, and used as is otherwise
+	realTopic := topic
+	if event.TopicTranslationConfig != nil {
+		if translated := event.TopicTranslationConfig.GetReal(topic); translated != "" {
+			realTopic = translated
+		}
+	}
+	value, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	err = p.Produce(&kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: pointy.String(realTopic), Partition: kafka.PartitionAny},
+		Key:            []byte(report.Day),
+		Value:          value,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	if remaining := p.Flush(flushTimeoutMs); remaining > 0 {
+		return fmt.Errorf("telemetry report was not delivered")
+	}
+	return nil
+}
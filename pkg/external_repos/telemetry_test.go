@@ -0,0 +1,37 @@
+package external_repos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestReportTelemetry(t *testing.T) {
+	previous := config.Get().Telemetry
+	defer func() { config.Get().Telemetry = previous }()
+	day := time.Date(2023, 9, 11, 2, 0, 0, 0, time.UTC)
+	stats := []models.TelemetryStat{
+		{Day: day, Metric: models.TelemetryRepositoriesPerOrg, Key: "2-5", Value: 7},
+		{Day: day, Metric: models.TelemetryFeatureAdoption, Key: "snapshot", Value: 3},
+	}
+
+	// Nothing is computed while telemetry is disabled
+	config.Get().Telemetry = config.Telemetry{Enabled: false}
+	mockDao := dao.GetMockDaoRegistry(t)
+	count, err := ReportTelemetry(context.Background(), mockDao.ToDaoRegistry(), day)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	config.Get().Telemetry = config.Telemetry{Enabled: true, TopHosts: 20, MinOrgs: 5}
+	mockDao.Telemetry.On("Compute", mock.Anything, day, 20, 5).Return(stats, nil).Once()
+	mockDao.Telemetry.On("Save", mock.Anything, stats).Return(nil).Once()
+	count, err = ReportTelemetry(context.Background(), mockDao.ToDaoRegistry(), day)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/rbac"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/exp/slices"
+)
+
+type AdminLoggingHandler struct {
+	DaoRegistry dao.DaoRegistry
+}
+
+func RegisterAdminLoggingRoutes(engine *echo.Group, daoReg *dao.DaoRegistry) {
+	if engine == nil {
+		panic("engine is nil")
+	}
+	if daoReg == nil {
+		panic("daoReg is nil")
+	}
+
+	adminLoggingHandler := AdminLoggingHandler{
+		DaoRegistry: *daoReg,
+	}
+	addRoute(engine, http.MethodGet, "/admin/logging/", adminLoggingHandler.fetch, rbac.RbacVerbRead, checkAccessible)
+	addRoute(engine, http.MethodPut, "/admin/logging/", adminLoggingHandler.update, rbac.RbacVerbWrite, checkAccessible)
+}
+
+// fetch returns the log levels the processes apply
+func (adminLoggingHandler *AdminLoggingHandler) fetch(c echo.Context) error {
+	levels, err := adminLoggingHandler.DaoRegistry.LogLevels.Fetch(c.Request().Context())
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error fetching log levels", err)
+	}
+	return c.JSON(http.StatusOK, logLevelsToApi(levels, time.Now()))
+}
+
+// update changes the log levels of every process until the ttl elapses.  The serving process applies
+// them at once, the others within the log level sync interval.
+func (adminLoggingHandler *AdminLoggingHandler) update(c echo.Context) error {
+	var request api.AdminLoggingRequest
+	if err := c.Bind(&request); err != nil {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error binding parameters", err.Error())
+	}
+	levels, err := validateLoggingRequest(request)
+	if err != nil {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error changing log levels", err.Error())
+	}
+	levels.UpdatedBy = getUsername(c)
+
+	if err = adminLoggingHandler.DaoRegistry.LogLevels.Save(c.Request().Context(), levels); err != nil {
+		return ce.NewErrorResponseFromError("Error saving log levels", err)
+	}
+	log.Info().Msgf("Log level changed to %v with debugged modules %v until %v by %v",
+		levels.Level, levels.DebugModules, levels.ExpiresAt.Format(time.RFC3339), levels.UpdatedBy)
+	ApplyLogLevels(&levels, time.Now())
+	return c.JSON(http.StatusOK, logLevelsToApi(&levels, time.Now()))
+}
+
+func validateLoggingRequest(request api.AdminLoggingRequest) (models.LogLevels, error) {
+	levels := models.LogLevels{Level: config.ConfiguredLogLevel().String()}
+	if request.Level != "" {
+		level, err := zerolog.ParseLevel(request.Level)
+		if err != nil || level < zerolog.TraceLevel || level > zerolog.PanicLevel {
+			return levels, fmt.Errorf("unknown log level %v", request.Level)
+		}
+		levels.Level = level.String()
+	}
+	for _, module := range request.DebugModules {
+		if !slices.Contains(config.LogModules, module) {
+			return levels, fmt.Errorf("unknown module %v, expected one of %v", module, config.LogModules)
+		}
+	}
+	levels.DebugModules = request.DebugModules
+
+	if request.TTL == "" {
+		return levels, fmt.Errorf("ttl is required")
+	}
+	ttl, err := time.ParseDuration(request.TTL)
+	if err != nil {
+		return levels, fmt.Errorf("invalid ttl: %w", err)
+	}
+	maxTTL := config.Get().Options.LogLevelMaxTTL
+	if ttl <= 0 || ttl > maxTTL {
+		return levels, fmt.Errorf("ttl must be positive and at most %v", maxTTL)
+	}
+	levels.ExpiresAt = time.Now().Add(ttl)
+	return levels, nil
+}
+
+func logLevelsToApi(levels *models.LogLevels, now time.Time) api.AdminLoggingResponse {
+	configured := config.ConfiguredLogLevel().String()
+	if levels == nil || levels.Expired(now) {
+		return api.AdminLoggingResponse{Level: configured, DebugModules: []string{}, ConfiguredLevel: configured}
+	}
+	expiresAt := levels.ExpiresAt
+	return api.AdminLoggingResponse{
+		Level:           levels.Level,
+		DebugModules:    levels.DebugModules,
+		ExpiresAt:       &expiresAt,
+		UpdatedBy:       levels.UpdatedBy,
+		ConfiguredLevel: configured,
+	}
+}
+
+// ApplyLogLevels sets the log levels of the process, or restores the configured ones when the
+// levels are missing or expired
+func ApplyLogLevels(levels *models.LogLevels, now time.Time) {
+	if levels == nil || levels.Expired(now) {
+		config.ResetRuntimeLogLevels()
+		return
+	}
+	level, err := zerolog.ParseLevel(levels.Level)
+	if err != nil {
+		log.Error().Err(err).Msg("Ignoring invalid log level")
+		config.ResetRuntimeLogLevels()
+		return
+	}
+	config.SetRuntimeLogLevels(level, levels.DebugModules)
+}
+
+// SyncLogLevels applies the log levels set through the logging admin endpoint every interval,
+// until ctx is done
+func SyncLogLevels(ctx context.Context, logLevelsDao dao.LogLevelsDao, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		levels, err := logLevelsDao.Fetch(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Error fetching log levels")
+		} else {
+			ApplyLogLevels(levels, time.Now())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	"github.com/content-services/content-sources-backend/pkg/middleware"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	test_handler "github.com/content-services/content-sources-backend/pkg/test/handler"
+	"github.com/labstack/echo/v4"
+	"github.com/redhatinsights/platform-go-middlewares/identity"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type AdminLoggingSuite struct {
+	suite.Suite
+	reg *dao.MockDaoRegistry
+}
+
+func TestAdminLoggingSuite(t *testing.T) {
+	suite.Run(t, new(AdminLoggingSuite))
+}
+
+func (suite *AdminLoggingSuite) SetupTest() {
+	suite.reg = dao.GetMockDaoRegistry(suite.T())
+}
+
+func (suite *AdminLoggingSuite) TearDownTest() {
+	config.ResetRuntimeLogLevels()
+}
+
+func (suite *AdminLoggingSuite) serveAdminLoggingRouter(req *http.Request) (int, []byte, error) {
+	router := echo.New()
+	router.Use(middleware.WrapMiddlewareWithSkipper(identity.EnforceIdentity, middleware.SkipAuth))
+	router.HTTPErrorHandler = config.CustomHTTPErrorHandler
+	pathPrefix := router.Group(fullRootPath())
+
+	config.Get().Features.AdminTasks.Enabled = true
+	config.Get().Features.AdminTasks.Accounts = &[]string{test_handler.MockAccountNumber}
+
+	RegisterAdminLoggingRoutes(pathPrefix, suite.reg.ToDaoRegistry())
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	response := rr.Result()
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	return response.StatusCode, body, err
+}
+
+func (suite *AdminLoggingSuite) putLogging(request api.AdminLoggingRequest) (int, []byte) {
+	t := suite.T()
+	body, err := json.Marshal(request)
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPut, fullRootPath()+"/admin/logging/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveAdminLoggingRouter(req)
+	require.NoError(t, err)
+	return code, body
+}
+
+func (suite *AdminLoggingSuite) TestUpdate() {
+	t := suite.T()
+
+	suite.reg.LogLevels.On("Save", mock.Anything, mock.MatchedBy(func(levels models.LogLevels) bool {
+		return levels.Level == "warn" && len(levels.DebugModules) == 1 && levels.DebugModules[0] == "pulp_client" &&
+			levels.ExpiresAt.After(time.Now().Add(29*time.Minute))
+	})).Return(nil).Once()
+
+	code, body := suite.putLogging(api.AdminLoggingRequest{Level: "warn", DebugModules: []string{"pulp_client"}, TTL: "30m"})
+	assert.Equal(t, http.StatusOK, code)
+
+	response := api.AdminLoggingResponse{}
+	require.NoError(t, json.Unmarshal(body, &response))
+	assert.Equal(t, "warn", response.Level)
+	assert.Equal(t, []string{"pulp_client"}, response.DebugModules)
+	assert.NotNil(t, response.ExpiresAt)
+	assert.Equal(t, config.ConfiguredLogLevel().String(), response.ConfiguredLevel)
+	// The serving process applies the levels at once
+	assert.Equal(t, zerolog.DebugLevel, zerolog.GlobalLevel())
+}
+
+func (suite *AdminLoggingSuite) TestUpdateInvalid() {
+	t := suite.T()
+
+	for _, request := range []api.AdminLoggingRequest{
+		{Level: "verbose", TTL: "30m"},
+		{Level: "debug", DebugModules: []string{"unknown"}, TTL: "30m"},
+		{Level: "debug"},
+		{Level: "debug", TTL: "-5m"},
+		{Level: "debug", TTL: "1000h"},
+	} {
+		code, _ := suite.putLogging(request)
+		assert.Equal(t, http.StatusBadRequest, code, request)
+	}
+}
+
+func (suite *AdminLoggingSuite) TestFetchExpired() {
+	t := suite.T()
+
+	suite.reg.LogLevels.On("Fetch", mock.Anything).Return(&models.LogLevels{
+		Level:     "debug",
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, fullRootPath()+"/admin/logging/", nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+	code, body, err := suite.serveAdminLoggingRouter(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	response := api.AdminLoggingResponse{}
+	require.NoError(t, json.Unmarshal(body, &response))
+	assert.Equal(t, config.ConfiguredLogLevel().String(), response.Level)
+	assert.Nil(t, response.ExpiresAt)
+}
+
+func (suite *AdminLoggingSuite) TestApplyLogLevels() {
+	t := suite.T()
+	now := time.Now()
+
+	ApplyLogLevels(&models.LogLevels{Level: "error", ExpiresAt: now.Add(time.Hour)}, now)
+	assert.Equal(t, zerolog.ErrorLevel, zerolog.GlobalLevel())
+
+	// Expired levels revert to the configured one
+	ApplyLogLevels(&models.LogLevels{Level: "error", ExpiresAt: now.Add(-time.Hour)}, now)
+	assert.Equal(t, config.ConfiguredLogLevel(), zerolog.GlobalLevel())
+}
@@ -1,18 +1,25 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/config"
 	"github.com/content-services/content-sources-backend/pkg/dao"
 	ce "github.com/content-services/content-sources-backend/pkg/errors"
 	"github.com/content-services/content-sources-backend/pkg/rbac"
+	"github.com/content-services/content-sources-backend/pkg/tasks"
+	"github.com/content-services/content-sources-backend/pkg/tasks/client"
+	"github.com/content-services/content-sources-backend/pkg/tasks/queue"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/rs/zerolog/log"
 )
 
 type AdminTaskHandler struct {
 	DaoRegistry dao.DaoRegistry
+	TaskClient  client.TaskClient
 }
 
 func checkAccessible(next echo.HandlerFunc) echo.HandlerFunc {
@@ -24,28 +31,37 @@ func checkAccessible(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
-func RegisterAdminTaskRoutes(engine *echo.Group, daoReg *dao.DaoRegistry) {
+func RegisterAdminTaskRoutes(engine *echo.Group, daoReg *dao.DaoRegistry, taskClient *client.TaskClient) {
 	if engine == nil {
 		panic("engine is nil")
 	}
 	if daoReg == nil {
 		panic("taskInfoReg is nil")
 	}
+	if taskClient == nil {
+		panic("taskClient is nil")
+	}
 
 	adminTaskHandler := AdminTaskHandler{
 		DaoRegistry: *daoReg,
+		TaskClient:  *taskClient,
 	}
 	addRoute(engine, http.MethodGet, "/admin/tasks/", adminTaskHandler.listTasks, rbac.RbacVerbRead, checkAccessible)
 	addRoute(engine, http.MethodGet, "/admin/tasks/:uuid", adminTaskHandler.fetch, rbac.RbacVerbRead, checkAccessible)
+	addRoute(engine, http.MethodPost, "/admin/tasks/:uuid/requeue", adminTaskHandler.requeue, rbac.RbacVerbWrite, checkAccessible)
+	addRoute(engine, http.MethodPost, "/admin/snapshots/verify/", adminTaskHandler.verifySnapshots, rbac.RbacVerbWrite, checkAccessible)
+	addRoute(engine, http.MethodPost, "/admin/orgs/migrate/", adminTaskHandler.migrateOrg, rbac.RbacVerbWrite, checkAccessible)
+	addRoute(engine, http.MethodPost, "/admin/references/repair/", adminTaskHandler.repairReferences, rbac.RbacVerbWrite, checkAccessible)
+	addRoute(engine, http.MethodPost, "/admin/orgs/:org_id/bootstrap", adminTaskHandler.bootstrapOrg, rbac.RbacVerbWrite, checkAccessible)
 }
 
 func (adminTaskHandler *AdminTaskHandler) listTasks(c echo.Context) error {
 	pageData := ParsePagination(c)
 	filterData := ParseAdminTaskFilters(c)
 
-	tasks, totalTasks, err := adminTaskHandler.DaoRegistry.AdminTask.List(pageData, filterData)
+	tasks, totalTasks, err := adminTaskHandler.DaoRegistry.AdminTask.List(c.Request().Context(), pageData, filterData)
 	if err != nil {
-		return ce.NewErrorResponse(ce.HttpCodeForDaoError(err), "Error listing tasks", err.Error())
+		return ce.NewErrorResponseFromError("Error listing tasks", err)
 	}
 
 	return c.JSON(http.StatusOK, setCollectionResponseMetadata(&tasks, c, totalTasks))
@@ -54,9 +70,190 @@ func (adminTaskHandler *AdminTaskHandler) listTasks(c echo.Context) error {
 func (adminTaskHandler *AdminTaskHandler) fetch(c echo.Context) error {
 	id := c.Param("uuid")
 
-	response, err := adminTaskHandler.DaoRegistry.AdminTask.Fetch(id)
+	response, err := adminTaskHandler.DaoRegistry.AdminTask.Fetch(c.Request().Context(), id)
 	if err != nil {
-		return ce.NewErrorResponse(ce.HttpCodeForDaoError(err), "Error fetching task", err.Error())
+		return ce.NewErrorResponseFromError("Error fetching task", err)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// requeue queues again a failed or dead task, once the cause of its failure is fixed
+func (adminTaskHandler *AdminTaskHandler) requeue(c echo.Context) error {
+	id := c.Param("uuid")
+	if err := validateUUIDs("uuid", id); err != nil {
+		return err
+	}
+
+	err := adminTaskHandler.TaskClient.RequeueFailed(uuid.MustParse(id))
+	if errors.Is(err, queue.ErrNotExist) {
+		return ce.NewErrorResponse(http.StatusNotFound, "Error requeuing task", "Could not find task with UUID "+id)
+	}
+	if errors.Is(err, queue.ErrNotFailed) {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error requeuing task", "Only failed or dead tasks can be requeued")
+	}
+	if err != nil {
+		return ce.NewErrorResponse(http.StatusInternalServerError, "Error requeuing task", err.Error())
+	}
+
+	response, err := adminTaskHandler.DaoRegistry.AdminTask.Fetch(c.Request().Context(), id)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error fetching task", err)
+	}
+	return c.JSON(http.StatusAccepted, response)
+}
+
+// verifySnapshots queues a task verifying, and repairing when possible, the snapshots of an org or of one of its repositories
+func (adminTaskHandler *AdminTaskHandler) verifySnapshots(c echo.Context) error {
+	var request api.AdminVerifySnapshotsRequest
+	if err := c.Bind(&request); err != nil {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error binding parameters", err.Error())
+	}
+	if request.OrgID == "" {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error verifying snapshots", "org_id is required")
+	}
+	if request.RepositoryUUID != "" {
+		if err := validateUUIDs("repository_uuid", request.RepositoryUUID); err != nil {
+			return err
+		}
+	}
+	if !config.Get().NewTaskingSystem || !config.PulpConfigured() {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error verifying snapshots", "Snapshotting is not enabled")
+	}
+
+	task := queue.Task{
+		Typename:  config.VerifySnapshotsTask,
+		Payload:   tasks.VerifySnapshotsPayload{},
+		OrgId:     request.OrgID,
+		RequestID: c.Response().Header().Get(config.HeaderRequestId),
+		Username:  getUsername(c),
+	}
+	if request.RepositoryUUID != "" {
+		repo, err := adminTaskHandler.DaoRegistry.RepositoryConfig.Fetch(c.Request().Context(), request.OrgID, request.RepositoryUUID)
+		if err != nil {
+			return ce.NewErrorResponseFromError("Error fetching repository", err)
+		}
+		task.Payload = tasks.VerifySnapshotsPayload{RepoConfigUUID: repo.UUID}
+		task.RepositoryUUID = repo.RepositoryUUID
+	}
+
+	taskID, err := adminTaskHandler.TaskClient.Enqueue(task)
+	if err != nil {
+		return ce.NewErrorResponse(http.StatusInternalServerError, "Error enqueuing task", err.Error())
+	}
+	response, err := adminTaskHandler.DaoRegistry.AdminTask.Fetch(c.Request().Context(), taskID.String())
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error fetching task", err)
+	}
+	return c.JSON(http.StatusAccepted, response)
+}
+
+// repairReferences queues a task pointing the domain and snapshots of an org at the objects pulp has
+// for them, after pulp was restored from a backup or rebuilt
+func (adminTaskHandler *AdminTaskHandler) repairReferences(c echo.Context) error {
+	var request api.AdminRepairReferencesRequest
+	if err := c.Bind(&request); err != nil {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error binding parameters", err.Error())
+	}
+	if request.OrgID == "" {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error repairing references", "org_id is required")
+	}
+	if !config.Get().NewTaskingSystem || !config.PulpConfigured() {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error repairing references", "Snapshotting is not enabled")
+	}
+
+	taskID, err := adminTaskHandler.TaskClient.Enqueue(queue.Task{
+		Typename:  config.RepairReferencesTask,
+		Payload:   tasks.RepairReferencesPayload{},
+		OrgId:     request.OrgID,
+		RequestID: c.Response().Header().Get(config.HeaderRequestId),
+		Username:  getUsername(c),
+	})
+	if err != nil {
+		return ce.NewErrorResponse(http.StatusInternalServerError, "Error enqueuing task", err.Error())
+	}
+	response, err := adminTaskHandler.DaoRegistry.AdminTask.Fetch(c.Request().Context(), taskID.String())
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error fetching task", err)
+	}
+	return c.JSON(http.StatusAccepted, response)
+}
+
+// migrateOrg queues a task moving the repositories and snapshots of an org to another org
+func (adminTaskHandler *AdminTaskHandler) migrateOrg(c echo.Context) error {
+	var request api.AdminMigrateOrgRequest
+	if err := c.Bind(&request); err != nil {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error binding parameters", err.Error())
+	}
+	if request.SourceOrgID == "" || request.TargetOrgID == "" {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error migrating org", "source_org_id and target_org_id are required")
+	}
+	if request.SourceOrgID == request.TargetOrgID {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error migrating org", "source_org_id and target_org_id must differ")
+	}
+	if !config.Get().NewTaskingSystem {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error migrating org", "The tasking system is not enabled")
+	}
+
+	taskID, err := adminTaskHandler.TaskClient.Enqueue(queue.Task{
+		Typename: config.MigrateOrgTask,
+		Payload: tasks.MigrateOrgPayload{
+			SourceOrgID: request.SourceOrgID,
+			TargetOrgID: request.TargetOrgID,
+			AccountID:   request.AccountID,
+		},
+		OrgId:     request.SourceOrgID,
+		RequestID: c.Response().Header().Get(config.HeaderRequestId),
+		Username:  getUsername(c),
+	})
+	if err != nil {
+		return ce.NewErrorResponse(http.StatusInternalServerError, "Error enqueuing task", err.Error())
+	}
+	response, err := adminTaskHandler.DaoRegistry.AdminTask.Fetch(c.Request().Context(), taskID.String())
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error fetching task", err)
+	}
+	return c.JSON(http.StatusAccepted, response)
+}
+
+// bootstrapOrg prepares a new org before its first request: it saves the domain and default settings
+// of the org, and queues a task creating its pulp domain and candlepin owner.  Called by provisioning
+// when an account is activated, calling it again for an org leaves what exists unchanged.
+func (adminTaskHandler *AdminTaskHandler) bootstrapOrg(c echo.Context) error {
+	orgID := c.Param("org_id")
+	if orgID == "" {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error bootstrapping org", "org_id is required")
+	}
+
+	domainName, err := adminTaskHandler.DaoRegistry.Domain.FetchOrCreateDomain(c.Request().Context(), orgID)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error creating domain", err)
+	}
+	settings, err := adminTaskHandler.DaoRegistry.OrgSettings.CreateDefaults(c.Request().Context(), orgID)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error creating settings", err)
+	}
+	response := api.AdminOrgBootstrapResponse{
+		OrgID:      orgID,
+		DomainName: domainName,
+		Settings:   settings,
+	}
+
+	if config.Get().NewTaskingSystem && (config.PulpConfigured() || config.CandlepinConfigured()) {
+		taskID, err := adminTaskHandler.TaskClient.Enqueue(queue.Task{
+			Typename:  config.BootstrapOrgTask,
+			Payload:   tasks.BootstrapOrgPayload{DomainName: domainName},
+			OrgId:     orgID,
+			RequestID: c.Response().Header().Get(config.HeaderRequestId),
+			Username:  getUsername(c),
+		})
+		if err != nil {
+			return ce.NewErrorResponse(http.StatusInternalServerError, "Error enqueuing task", err.Error())
+		}
+		task, err := adminTaskHandler.DaoRegistry.AdminTask.Fetch(c.Request().Context(), taskID.String())
+		if err != nil {
+			return ce.NewErrorResponseFromError("Error fetching task", err)
+		}
+		response.Task = &task
 	}
 	return c.JSON(http.StatusOK, response)
 }
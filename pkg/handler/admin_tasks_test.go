@@ -15,12 +15,17 @@ import (
 	ce "github.com/content-services/content-sources-backend/pkg/errors"
 	"github.com/content-services/content-sources-backend/pkg/middleware"
 	"github.com/content-services/content-sources-backend/pkg/seeds"
+	"github.com/content-services/content-sources-backend/pkg/tasks"
+	"github.com/content-services/content-sources-backend/pkg/tasks/client"
+	"github.com/content-services/content-sources-backend/pkg/tasks/queue"
 	test_handler "github.com/content-services/content-sources-backend/pkg/test/handler"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	echo_middleware "github.com/labstack/echo/v4/middleware"
 	"github.com/redhatinsights/platform-go-middlewares/identity"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -86,7 +91,8 @@ func (suite *AdminTasksSuite) serveAdminTasksRouter(req *http.Request, enabled b
 		config.Get().Features.AdminTasks.Accounts = &[]string{seeds.RandomAccountId()}
 	}
 
-	RegisterAdminTaskRoutes(pathPrefix, suite.reg.ToDaoRegistry())
+	var taskClient client.TaskClient = suite.tcMock
+	RegisterAdminTaskRoutes(pathPrefix, suite.reg.ToDaoRegistry(), &taskClient)
 
 	rr := httptest.NewRecorder()
 	router.ServeHTTP(rr, req)
@@ -100,7 +106,8 @@ func (suite *AdminTasksSuite) serveAdminTasksRouter(req *http.Request, enabled b
 
 type AdminTasksSuite struct {
 	suite.Suite
-	reg *dao.MockDaoRegistry
+	reg    *dao.MockDaoRegistry
+	tcMock *client.MockTaskClient
 }
 
 func TestAdminTasksSuite(t *testing.T) {
@@ -108,6 +115,7 @@ func TestAdminTasksSuite(t *testing.T) {
 }
 func (suite *AdminTasksSuite) SetupTest() {
 	suite.reg = dao.GetMockDaoRegistry(suite.T())
+	suite.tcMock = client.NewMockTaskClient(suite.T())
 }
 
 func (suite *AdminTasksSuite) TestSimple() {
@@ -116,7 +124,7 @@ func (suite *AdminTasksSuite) TestSimple() {
 	collection := createAdminTaskCollection(1, 10, 0)
 	paginationData := api.PaginationData{Limit: 10, Offset: DefaultOffset}
 	filterData := api.AdminTaskFilterData{}
-	suite.reg.AdminTask.On("List", paginationData, filterData).Return(collection, int64(1), nil)
+	suite.reg.AdminTask.On("List", mock.Anything, paginationData, filterData).Return(collection, int64(1), nil)
 
 	path := fmt.Sprintf("%s/admin/tasks/?limit=%d", fullRootPath(), 10)
 	req := httptest.NewRequest(http.MethodGet, path, nil)
@@ -152,7 +160,7 @@ func (suite *AdminTasksSuite) TestListNoTasks() {
 	collection := api.AdminTaskInfoCollectionResponse{}
 	paginationData := api.PaginationData{Limit: DefaultLimit, Offset: DefaultOffset}
 	filterData := api.AdminTaskFilterData{}
-	suite.reg.AdminTask.On("List", paginationData, filterData).Return(collection, int64(0), nil)
+	suite.reg.AdminTask.On("List", mock.Anything, paginationData, filterData).Return(collection, int64(0), nil)
 
 	req := httptest.NewRequest(http.MethodGet, fullRootPath()+"/admin/tasks/", nil)
 	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
@@ -185,7 +193,7 @@ func (suite *AdminTasksSuite) TestListDisabled() {
 
 	paginationData := api.PaginationData{Limit: DefaultLimit, Offset: DefaultOffset}
 	filterData := api.AdminTaskFilterData{}
-	suite.reg.AdminTask.AssertNotCalled(t, "List", paginationData, filterData)
+	suite.reg.AdminTask.AssertNotCalled(t, "List", mock.Anything, paginationData, filterData)
 }
 
 func (suite *AdminTasksSuite) TestListNotAccessible() {
@@ -201,7 +209,7 @@ func (suite *AdminTasksSuite) TestListNotAccessible() {
 
 	paginationData := api.PaginationData{Limit: DefaultLimit, Offset: DefaultOffset}
 	filterData := api.AdminTaskFilterData{}
-	suite.reg.AdminTask.AssertNotCalled(t, "List", paginationData, filterData)
+	suite.reg.AdminTask.AssertNotCalled(t, "List", mock.Anything, paginationData, filterData)
 }
 
 func (suite *AdminTasksSuite) TestListPagedExtraRemaining() {
@@ -211,8 +219,8 @@ func (suite *AdminTasksSuite) TestListPagedExtraRemaining() {
 	paginationData1 := api.PaginationData{Limit: 10, Offset: 0}
 	paginationData2 := api.PaginationData{Limit: 10, Offset: 100}
 
-	suite.reg.AdminTask.On("List", paginationData1, api.AdminTaskFilterData{}).Return(collection, int64(102), nil).Once()
-	suite.reg.AdminTask.On("List", paginationData2, api.AdminTaskFilterData{}).Return(collection, int64(102), nil).Once()
+	suite.reg.AdminTask.On("List", mock.Anything, paginationData1, api.AdminTaskFilterData{}).Return(collection, int64(102), nil).Once()
+	suite.reg.AdminTask.On("List", mock.Anything, paginationData2, api.AdminTaskFilterData{}).Return(collection, int64(102), nil).Once()
 
 	path := fmt.Sprintf("%s/admin/tasks/?limit=%d", fullRootPath(), 10)
 	req := httptest.NewRequest(http.MethodGet, path, nil)
@@ -249,8 +257,8 @@ func (suite *AdminTasksSuite) TestListPagedNoRemaining() {
 	paginationData2 := api.PaginationData{Limit: 10, Offset: 90}
 
 	collection := api.AdminTaskInfoCollectionResponse{}
-	suite.reg.AdminTask.On("List", paginationData1, api.AdminTaskFilterData{}).Return(collection, int64(100), nil)
-	suite.reg.AdminTask.On("List", paginationData2, api.AdminTaskFilterData{}).Return(collection, int64(100), nil)
+	suite.reg.AdminTask.On("List", mock.Anything, paginationData1, api.AdminTaskFilterData{}).Return(collection, int64(100), nil)
+	suite.reg.AdminTask.On("List", mock.Anything, paginationData2, api.AdminTaskFilterData{}).Return(collection, int64(100), nil)
 
 	path := fmt.Sprintf("%s/admin/tasks/?limit=%d", fullRootPath(), 10)
 	req := httptest.NewRequest(http.MethodGet, path, nil)
@@ -285,7 +293,7 @@ func (suite *AdminTasksSuite) TestFetch() {
 
 	task := createAdminTask()
 
-	suite.reg.AdminTask.On("Fetch", task.UUID).Return(task, nil)
+	suite.reg.AdminTask.On("Fetch", mock.Anything, task.UUID).Return(task, nil)
 
 	var body []byte
 
@@ -314,7 +322,7 @@ func (suite *AdminTasksSuite) TestFetchNotFound() {
 		NotFound: true,
 		Message:  "Not found",
 	}
-	suite.reg.AdminTask.On("Fetch", task.UUID).Return(api.AdminTaskInfoResponse{}, &daoError)
+	suite.reg.AdminTask.On("Fetch", mock.Anything, task.UUID).Return(api.AdminTaskInfoResponse{}, &daoError)
 
 	var body []byte
 
@@ -338,7 +346,7 @@ func (suite *AdminTasksSuite) TestFetchDisabled() {
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusBadRequest, code)
 	assert.Contains(t, string(body), "Admin tasks feature is disabled.")
-	suite.reg.AdminTask.AssertNotCalled(t, "Fetch", task.UUID)
+	suite.reg.AdminTask.AssertNotCalled(t, "Fetch", mock.Anything, task.UUID)
 }
 
 func (suite *AdminTasksSuite) TestFetchNotAccessible() {
@@ -353,5 +361,233 @@ func (suite *AdminTasksSuite) TestFetchNotAccessible() {
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusBadRequest, code)
 	assert.Contains(t, string(body), "Neither the user nor account is allowed.")
-	suite.reg.AdminTask.AssertNotCalled(t, "Fetch", task.UUID)
+	suite.reg.AdminTask.AssertNotCalled(t, "Fetch", mock.Anything, task.UUID)
+}
+
+func (suite *AdminTasksSuite) TestVerifySnapshots() {
+	t := suite.T()
+	tasking := config.Get().NewTaskingSystem
+	pulpServer := config.Get().Clients.Pulp.Server
+	config.Get().NewTaskingSystem = true
+	config.Get().Clients.Pulp.Server = "http://pulp.example.com"
+	defer func() {
+		config.Get().NewTaskingSystem = tasking
+		config.Get().Clients.Pulp.Server = pulpServer
+	}()
+
+	repo := api.RepositoryResponse{UUID: uuid.NewString(), RepositoryUUID: uuid.NewString()}
+	task := createAdminTask()
+	task.Typename = config.VerifySnapshotsTask
+	suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, repo.UUID).Return(repo, nil)
+	suite.tcMock.On("Enqueue", mock.MatchedBy(func(queued queue.Task) bool {
+		return queued.Typename == config.VerifySnapshotsTask &&
+			queued.Payload == tasks.VerifySnapshotsPayload{RepoConfigUUID: repo.UUID} &&
+			queued.OrgId == test_handler.MockOrgId &&
+			queued.RepositoryUUID == repo.RepositoryUUID &&
+			queued.Username == test_handler.MockUsername
+	})).Return(uuid.MustParse(task.UUID), nil)
+	suite.reg.AdminTask.On("Fetch", mock.Anything, task.UUID).Return(task, nil)
+
+	body, err := json.Marshal(api.AdminVerifySnapshotsRequest{OrgID: test_handler.MockOrgId, RepositoryUUID: repo.UUID})
+	assert.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, fullRootPath()+"/admin/snapshots/verify/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveAdminTasksRouter(req, true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, code)
+
+	var response api.AdminTaskInfoResponse
+	err = json.Unmarshal(body, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, task, response)
+}
+
+func (suite *AdminTasksSuite) TestVerifySnapshotsNoOrg() {
+	t := suite.T()
+
+	body, err := json.Marshal(api.AdminVerifySnapshotsRequest{RepositoryUUID: uuid.NewString()})
+	assert.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, fullRootPath()+"/admin/snapshots/verify/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveAdminTasksRouter(req, true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, string(body), "org_id is required")
+	suite.tcMock.AssertNotCalled(t, "Enqueue", mock.Anything)
+}
+
+func (suite *AdminTasksSuite) TestRepairReferences() {
+	t := suite.T()
+	tasking := config.Get().NewTaskingSystem
+	pulpServer := config.Get().Clients.Pulp.Server
+	config.Get().NewTaskingSystem = true
+	config.Get().Clients.Pulp.Server = "http://pulp.example.com"
+	defer func() {
+		config.Get().NewTaskingSystem = tasking
+		config.Get().Clients.Pulp.Server = pulpServer
+	}()
+
+	request := api.AdminRepairReferencesRequest{OrgID: test_handler.MockOrgId}
+	task := createAdminTask()
+	task.Typename = config.RepairReferencesTask
+	suite.tcMock.On("Enqueue", mock.MatchedBy(func(queued queue.Task) bool {
+		return queued.Typename == config.RepairReferencesTask &&
+			queued.Payload == tasks.RepairReferencesPayload{} &&
+			queued.OrgId == request.OrgID
+	})).Return(uuid.MustParse(task.UUID), nil)
+	suite.reg.AdminTask.On("Fetch", mock.Anything, task.UUID).Return(task, nil)
+
+	body, err := json.Marshal(request)
+	assert.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, fullRootPath()+"/admin/references/repair/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveAdminTasksRouter(req, true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, code)
+
+	var response api.AdminTaskInfoResponse
+	err = json.Unmarshal(body, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, task, response)
+}
+
+func (suite *AdminTasksSuite) TestBootstrapOrg() {
+	t := suite.T()
+	tasking := config.Get().NewTaskingSystem
+	pulpServer := config.Get().Clients.Pulp.Server
+	config.Get().NewTaskingSystem = true
+	config.Get().Clients.Pulp.Server = "http://pulp.example.com"
+	defer func() {
+		config.Get().NewTaskingSystem = tasking
+		config.Get().Clients.Pulp.Server = pulpServer
+	}()
+
+	orgID := "newOrg"
+	settings := api.OrgSettingsResponse{DefaultDistributionArch: config.ANY_ARCH, DefaultDistributionVersions: []string{config.ANY_VERSION}}
+	task := createAdminTask()
+	task.Typename = config.BootstrapOrgTask
+	suite.reg.Domain.On("FetchOrCreateDomain", mock.Anything, orgID).Return("cs-abcd", nil)
+	suite.reg.OrgSettings.On("CreateDefaults", mock.Anything, orgID).Return(settings, nil)
+	suite.tcMock.On("Enqueue", mock.MatchedBy(func(queued queue.Task) bool {
+		return queued.Typename == config.BootstrapOrgTask &&
+			queued.Payload == tasks.BootstrapOrgPayload{DomainName: "cs-abcd"} &&
+			queued.OrgId == orgID
+	})).Return(uuid.MustParse(task.UUID), nil)
+	suite.reg.AdminTask.On("Fetch", mock.Anything, task.UUID).Return(task, nil)
+
+	req := httptest.NewRequest(http.MethodPost, fullRootPath()+"/admin/orgs/"+orgID+"/bootstrap", nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveAdminTasksRouter(req, true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	var response api.AdminOrgBootstrapResponse
+	err = json.Unmarshal(body, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, orgID, response.OrgID)
+	assert.Equal(t, "cs-abcd", response.DomainName)
+	assert.Equal(t, settings, response.Settings)
+	require.NotNil(t, response.Task)
+	assert.Equal(t, task, *response.Task)
+}
+
+func (suite *AdminTasksSuite) TestMigrateOrg() {
+	t := suite.T()
+	tasking := config.Get().NewTaskingSystem
+	config.Get().NewTaskingSystem = true
+	defer func() {
+		config.Get().NewTaskingSystem = tasking
+	}()
+
+	request := api.AdminMigrateOrgRequest{SourceOrgID: "sourceOrg", TargetOrgID: test_handler.MockOrgId, AccountID: test_handler.MockAccountNumber}
+	task := createAdminTask()
+	task.Typename = config.MigrateOrgTask
+	suite.tcMock.On("Enqueue", mock.MatchedBy(func(queued queue.Task) bool {
+		return queued.Typename == config.MigrateOrgTask &&
+			queued.Payload == tasks.MigrateOrgPayload{SourceOrgID: request.SourceOrgID, TargetOrgID: request.TargetOrgID, AccountID: request.AccountID} &&
+			queued.OrgId == request.SourceOrgID
+	})).Return(uuid.MustParse(task.UUID), nil)
+	suite.reg.AdminTask.On("Fetch", mock.Anything, task.UUID).Return(task, nil)
+
+	body, err := json.Marshal(request)
+	assert.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, fullRootPath()+"/admin/orgs/migrate/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveAdminTasksRouter(req, true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, code)
+
+	var response api.AdminTaskInfoResponse
+	err = json.Unmarshal(body, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, task, response)
+}
+
+func (suite *AdminTasksSuite) TestRequeue() {
+	t := suite.T()
+
+	task := createAdminTask()
+	task.Status = config.TaskStatusPending
+	suite.tcMock.On("RequeueFailed", uuid.MustParse(task.UUID)).Return(nil)
+	suite.reg.AdminTask.On("Fetch", mock.Anything, task.UUID).Return(task, nil)
+
+	req := httptest.NewRequest(http.MethodPost, fullRootPath()+"/admin/tasks/"+task.UUID+"/requeue", nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveAdminTasksRouter(req, true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, code)
+
+	var response api.AdminTaskInfoResponse
+	err = json.Unmarshal(body, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, task, response)
+}
+
+func (suite *AdminTasksSuite) TestRequeueErrors() {
+	t := suite.T()
+
+	notFailed := uuid.New()
+	notFound := uuid.New()
+	suite.tcMock.On("RequeueFailed", notFailed).Return(queue.ErrNotFailed)
+	suite.tcMock.On("RequeueFailed", notFound).Return(queue.ErrNotExist)
+
+	for id, expected := range map[string]int{
+		notFailed.String(): http.StatusBadRequest,
+		notFound.String():  http.StatusNotFound,
+		"not-a-uuid":       http.StatusBadRequest,
+	} {
+		req := httptest.NewRequest(http.MethodPost, fullRootPath()+"/admin/tasks/"+id+"/requeue", nil)
+		req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+		code, _, err := suite.serveAdminTasksRouter(req, true, true)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, code, id)
+	}
+	suite.reg.AdminTask.AssertNotCalled(t, "Fetch", mock.Anything, mock.Anything)
+}
+
+func (suite *AdminTasksSuite) TestMigrateOrgSameOrg() {
+	t := suite.T()
+
+	body, err := json.Marshal(api.AdminMigrateOrgRequest{SourceOrgID: test_handler.MockOrgId, TargetOrgID: test_handler.MockOrgId})
+	assert.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, fullRootPath()+"/admin/orgs/migrate/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveAdminTasksRouter(req, true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, string(body), "must differ")
+	suite.tcMock.AssertNotCalled(t, "Enqueue", mock.Anything)
 }
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/content-services/content-sources-backend/pkg/rbac"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultTopOrgs = 10  // orgs returned by listOrgRowCounts without a limit
+	maxTopOrgs     = 100 // orgs returned at most by listOrgRowCounts
+)
+
+type AdminUsageHandler struct {
+	DaoRegistry dao.DaoRegistry
+}
+
+func RegisterAdminUsageRoutes(engine *echo.Group, daoReg *dao.DaoRegistry) {
+	if engine == nil {
+		panic("engine is nil")
+	}
+	if daoReg == nil {
+		panic("daoReg is nil")
+	}
+
+	adminUsageHandler := AdminUsageHandler{
+		DaoRegistry: *daoReg,
+	}
+	addRoute(engine, http.MethodGet, "/admin/usage/", adminUsageHandler.listUsage, rbac.RbacVerbRead, checkAccessible)
+	addRoute(engine, http.MethodGet, "/admin/orgs/row_counts/", adminUsageHandler.listOrgRowCounts, rbac.RbacVerbRead, checkAccessible)
+}
+
+// listUsage returns the number of requests per org, route and day, most recent and
+// busiest first unless sort_by is given.  Available to the same users as admin tasks.
+func (adminUsageHandler *AdminUsageHandler) listUsage(c echo.Context) error {
+	pageData := ParsePagination(c)
+	filterData := ParseUsageFilters(c)
+
+	usage, total, err := adminUsageHandler.DaoRegistry.Usage.List(c.Request().Context(), pageData, filterData)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error listing usage", err)
+	}
+
+	return c.JSON(http.StatusOK, setCollectionResponseMetadata(&usage, c, total))
+}
+
+// listOrgRowCounts returns the orgs owning the most rows of the table given by sort_by
+// (repository_configurations, snapshots or tasks), to track the capacity used by the largest orgs.
+// Available to the same users as admin tasks.
+func (adminUsageHandler *AdminUsageHandler) listOrgRowCounts(c echo.Context) error {
+	limit := defaultTopOrgs
+	sortBy := ""
+	err := echo.QueryParamsBinder(c).
+		Int("limit", &limit).
+		String("sort_by", &sortBy).
+		BindError()
+	if err != nil {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error parsing parameters", err.Error())
+	}
+	if limit <= 0 || limit > maxTopOrgs {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error parsing parameters", fmt.Sprintf("limit must be between 1 and %d", maxTopOrgs))
+	}
+
+	counts, err := adminUsageHandler.DaoRegistry.Metrics.TopOrgRowCounts(c.Request().Context(), sortBy, limit)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error counting rows", err)
+	}
+	return c.JSON(http.StatusOK, api.OrgRowCountsCollectionResponse{Data: counts})
+}
+
+func ParseUsageFilters(c echo.Context) api.UsageFilterData {
+	filterData := api.UsageFilterData{}
+	err := echo.QueryParamsBinder(c).
+		String("org_id", &filterData.OrgID).
+		String("route", &filterData.Route).
+		String("since", &filterData.Since).
+		BindError()
+
+	if err != nil {
+		log.Error().Err(err).Msg("Error parsing filters")
+	}
+
+	return filterData
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/content-services/content-sources-backend/pkg/middleware"
+	test_handler "github.com/content-services/content-sources-backend/pkg/test/handler"
+	"github.com/labstack/echo/v4"
+	"github.com/redhatinsights/platform-go-middlewares/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type AdminUsageSuite struct {
+	suite.Suite
+	reg *dao.MockDaoRegistry
+}
+
+func TestAdminUsageSuite(t *testing.T) {
+	suite.Run(t, new(AdminUsageSuite))
+}
+
+func (suite *AdminUsageSuite) SetupTest() {
+	suite.reg = dao.GetMockDaoRegistry(suite.T())
+}
+
+func (suite *AdminUsageSuite) serveAdminUsageRouter(req *http.Request, enabled bool) (int, []byte, error) {
+	router := echo.New()
+	router.Use(middleware.WrapMiddlewareWithSkipper(identity.EnforceIdentity, middleware.SkipAuth))
+	router.HTTPErrorHandler = config.CustomHTTPErrorHandler
+	pathPrefix := router.Group(fullRootPath())
+
+	config.Get().Features.AdminTasks.Enabled = enabled
+	config.Get().Features.AdminTasks.Accounts = &[]string{test_handler.MockAccountNumber}
+
+	RegisterAdminUsageRoutes(pathPrefix, suite.reg.ToDaoRegistry())
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	response := rr.Result()
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	return response.StatusCode, body, err
+}
+
+func (suite *AdminUsageSuite) TestList() {
+	t := suite.T()
+
+	collection := api.UsageCollectionResponse{Data: []api.UsageResponse{{
+		OrgID:        test_handler.MockOrgId,
+		Method:       http.MethodGet,
+		Route:        "/api/content-sources/v1/repositories/",
+		Day:          "2023-08-14",
+		RequestCount: 42,
+	}}}
+	paginationData := api.PaginationData{Limit: 10, Offset: DefaultOffset}
+	filterData := api.UsageFilterData{OrgID: test_handler.MockOrgId, Since: "2023-08-01"}
+	suite.reg.Usage.On("List", mock.Anything, paginationData, filterData).Return(collection, int64(1), nil)
+
+	path := fmt.Sprintf("%s/admin/usage/?limit=%d&org_id=%s&since=2023-08-01", fullRootPath(), 10, test_handler.MockOrgId)
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveAdminUsageRouter(req, true)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	response := api.UsageCollectionResponse{}
+	err = json.Unmarshal(body, &response)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), response.Meta.Count)
+	assert.Equal(t, collection.Data, response.Data)
+}
+
+func (suite *AdminUsageSuite) TestListInvalidSince() {
+	t := suite.T()
+
+	paginationData := api.PaginationData{Limit: DefaultLimit, Offset: DefaultOffset}
+	filterData := api.UsageFilterData{Since: "yesterday"}
+	suite.reg.Usage.On("List", mock.Anything, paginationData, filterData).
+		Return(api.UsageCollectionResponse{}, int64(0), &ce.DaoError{BadValidation: true, Message: "since must be a date formatted as YYYY-MM-DD"})
+
+	req := httptest.NewRequest(http.MethodGet, fullRootPath()+"/admin/usage/?since=yesterday", nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, _, err := suite.serveAdminUsageRouter(req, true)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func (suite *AdminUsageSuite) TestListDisabled() {
+	t := suite.T()
+
+	req := httptest.NewRequest(http.MethodGet, fullRootPath()+"/admin/usage/", nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, _, err := suite.serveAdminUsageRouter(req, false)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func (suite *AdminUsageSuite) TestListOrgRowCounts() {
+	t := suite.T()
+
+	counts := []api.OrgRowCountsResponse{{
+		OrgID:                    test_handler.MockOrgId,
+		RepositoryConfigurations: 12,
+		Snapshots:                30,
+		Tasks:                    48,
+	}}
+	suite.reg.Metrics.On("TopOrgRowCounts", mock.Anything, "snapshots", 5).Return(counts, nil)
+
+	req := httptest.NewRequest(http.MethodGet, fullRootPath()+"/admin/orgs/row_counts/?limit=5&sort_by=snapshots", nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveAdminUsageRouter(req, true)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	response := api.OrgRowCountsCollectionResponse{}
+	err = json.Unmarshal(body, &response)
+	assert.Nil(t, err)
+	assert.Equal(t, counts, response.Data)
+}
+
+func (suite *AdminUsageSuite) TestListOrgRowCountsInvalidLimit() {
+	t := suite.T()
+
+	req := httptest.NewRequest(http.MethodGet, fullRootPath()+"/admin/orgs/row_counts/?limit=1000", nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, _, err := suite.serveAdminUsageRouter(req, true)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -62,7 +61,6 @@ func RegisterRoutes(engine *echo.Echo) {
 		introspectRequest producer.IntrospectRequest
 		pgqueue           queue.PgQueue
 	)
-	paths := []string{fullRootPath(), majorRootPath()}
 	if kafkaProducer, err = producer.NewProducer(&config.Get().Kafka); err != nil {
 		panic(err)
 	}
@@ -75,21 +73,13 @@ func RegisterRoutes(engine *echo.Echo) {
 	}
 	taskClient := client.NewTaskClient(&pgqueue)
 
-	for i := 0; i < len(paths); i++ {
-		group := engine.Group(paths[i])
-		group.GET("/openapi.json", openapi)
-
-		daoReg := dao.GetDaoRegistry(db.DB)
-		RegisterRepositoryRoutes(group, daoReg, &introspectRequest, &taskClient)
-		RegisterRepositoryParameterRoutes(group, daoReg)
-		RegisterRepositoryRpmRoutes(group, daoReg)
-		RegisterPopularRepositoriesRoutes(group, daoReg)
-		RegisterTaskInfoRoutes(group, daoReg)
-		RegisterSnapshotRoutes(group, daoReg)
-		RegisterAdminTaskRoutes(group, daoReg)
-		RegisterFeaturesRoutes(group)
-		RegisterPublicRepositoriesRoutes(group, daoReg)
-	}
+	versions := apiVersions()
+	engine.Pre(negotiateVersion(versions))
+	registerVersionedRoutes(engine, versions, resources, routeDeps{
+		daoReg:            dao.GetDaoRegistry(db.DB),
+		introspectRequest: &introspectRequest,
+		taskClient:        &taskClient,
+	})
 
 	data, err := json.MarshalIndent(engine.Routes(), "", "  ")
 	if err == nil {
@@ -97,6 +87,17 @@ func RegisterRoutes(engine *echo.Echo) {
 	}
 }
 
+// RegisterInternalRoutes registers the routes of internal callers, authenticated by
+// the given middleware instead of an identity header.
+func RegisterInternalRoutes(engine *echo.Echo, auth echo.MiddlewareFunc) {
+	paths := []string{internalFullRootPath(), internalMajorRootPath()}
+	daoReg := dao.GetDaoRegistry(db.DB)
+	for i := 0; i < len(paths); i++ {
+		group := engine.Group(paths[i], auth)
+		RegisterImageBuilderRoutes(group, daoReg)
+	}
+}
+
 func RegisterPing(engine *echo.Echo) {
 	engine.GET("/ping", ping)
 	engine.GET("/ping/", ping)
@@ -130,16 +131,7 @@ func openapi(c echo.Context) error {
 }
 
 func rootPrefix() string {
-	pathPrefix, present := os.LookupEnv("PATH_PREFIX")
-	if !present {
-		pathPrefix = "api"
-	}
-
-	appName, present := os.LookupEnv("APP_NAME")
-	if !present {
-		appName = config.DefaultAppName
-	}
-	return filepath.Join("/", pathPrefix, appName)
+	return filepath.Join("/", config.Get().PathPrefix, config.Get().AppName)
 }
 
 func fullRootPath() string {
@@ -149,6 +141,13 @@ func majorRootPath() string {
 	return filepath.Join(rootPrefix(), "v"+ApiVersionMajor)
 }
 
+func internalFullRootPath() string {
+	return filepath.Join(rootPrefix(), "internal", "v"+ApiVersion)
+}
+func internalMajorRootPath() string {
+	return filepath.Join(rootPrefix(), "internal", "v"+ApiVersionMajor)
+}
+
 func createLink(c echo.Context, offset int) string {
 	req := c.Request()
 	q := req.URL.Query()
@@ -183,6 +182,9 @@ func createLink(c echo.Context, offset int) string {
 // Returns collection response with updated metadata.
 func setCollectionResponseMetadata(collection api.CollectionMetadataSettable, c echo.Context, totalCount int64) api.CollectionMetadataSettable {
 	page := ParsePagination(c)
+	if page.SkipCount {
+		return setUncountedCollectionResponseMetadata(collection, c, page)
+	}
 	var lastPage int
 	if int(totalCount) > 0 && (int(totalCount)%page.Limit) == 0 {
 		lastPage = int(totalCount) - page.Limit
@@ -210,13 +212,35 @@ func setCollectionResponseMetadata(collection api.CollectionMetadataSettable, c
 	return collection
 }
 
+// setUncountedCollectionResponseMetadata sets the metadata of a collection whose total was not
+// counted.  Without a total there is no last page, and the next page is always linked: clients
+// stop paging once a page has fewer results than the limit.
+func setUncountedCollectionResponseMetadata(collection api.CollectionMetadataSettable, c echo.Context, page api.PaginationData) api.CollectionMetadataSettable {
+	links := api.Links{
+		First: createLink(c, 0),
+		Next:  createLink(c, page.Offset+page.Limit),
+	}
+	if page.Offset-page.Limit >= 0 {
+		links.Prev = createLink(c, page.Offset-page.Limit)
+	}
+	collection.SetMetadata(api.ResponseMetadata{
+		Count:  api.UncountedTotal,
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	}, links)
+	return collection
+}
+
 func ParsePagination(c echo.Context) api.PaginationData {
 	pageData := api.PaginationData{Limit: DefaultLimit, Offset: DefaultOffset, SortBy: DefaultSortBy}
+	count := true
 	err := echo.QueryParamsBinder(c).
 		Int("limit", &pageData.Limit).
 		Int("offset", &pageData.Offset).
 		String("sort_by", &pageData.SortBy).
+		Bool("count", &count).
 		BindError()
+	pageData.SkipCount = !count
 
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to bind pagination.")
@@ -255,6 +279,8 @@ func ParseFilters(c echo.Context) api.FilterData {
 		String("name", &filterData.Name).
 		String("url", &filterData.URL).
 		String("status", &filterData.Status).
+		String("content_type", &filterData.ContentType).
+		String("snapshot", &filterData.Snapshot).
 		BindError()
 
 	if err != nil {
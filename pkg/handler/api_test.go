@@ -57,7 +57,7 @@ func TestPingV1IsNotAvailable(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, http.StatusNotFound, code)
 
-		expected := "{\"errors\":[{\"status\":404,\"detail\":\"Not Found\"}]}\n"
+		expected := "{\"errors\":[{\"status\":404,\"code\":\"not_found\",\"detail\":\"Not Found\"}]}\n"
 		assert.Equal(t, expected, string(body))
 	}
 }
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/content-services/content-sources-backend/pkg/signing"
+	"github.com/labstack/echo/v4"
+)
+
+// contentTimeout bounds the time to fetch a file of a snapshot from pulp
+const contentTimeout = 5 * time.Minute
+
+var contentClient = &http.Client{Timeout: contentTimeout}
+
+// RegisterContentRoutes registers the route serving snapshot contents through signed urls.
+// It is authenticated by the signature of the url instead of an identity header, so that
+// package managers can use it.
+func RegisterContentRoutes(engine *echo.Echo) {
+	engine.GET(signing.ContentPath()+":token/*", serveSignedContent)
+	// The transfer of a file is bounded by contentTimeout rather than the request timeout
+	streamRoutes.add(http.MethodGet, signing.ContentPath()+":token/*")
+}
+
+func serveSignedContent(c echo.Context) error {
+	signer := signing.FromConfig()
+	if signer == nil {
+		return ce.NewErrorResponse(http.StatusNotFound, "Content signing is disabled", "")
+	}
+
+	contentPath := c.Param("*")
+	if contentPath == "" || path.Clean("/"+contentPath) != "/"+strings.TrimSuffix(contentPath, "/") {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Invalid path", "path must not contain relative elements")
+	}
+	if err := signer.Verify(c.Param("token"), contentPath, time.Now()); err != nil {
+		if errors.Is(err, signing.ErrExpired) {
+			return ce.NewErrorResponse(http.StatusForbidden, "Signed url expired", err.Error())
+		}
+		return ce.NewErrorResponse(http.StatusForbidden, "Invalid signed url", err.Error())
+	}
+	return proxyContent(c, config.PulpContentURL(contentPath))
+}
+
+// proxyContent streams the file at contentURL to the client
+func proxyContent(c echo.Context, contentURL string) error {
+	req, err := http.NewRequestWithContext(c.Request().Context(), http.MethodGet, contentURL, nil)
+	if err != nil {
+		return ce.NewErrorResponse(http.StatusInternalServerError, "Error fetching content", err.Error())
+	}
+	resp, err := contentClient.Do(req)
+	if err != nil {
+		return ce.NewErrorResponse(http.StatusInternalServerError, "Error fetching content", err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ce.NewErrorResponse(http.StatusNotFound, "Error fetching content", "Could not find "+path.Base(req.URL.Path))
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return ce.NewErrorResponse(http.StatusInternalServerError, "Error fetching content", fmt.Sprintf("received http status %d", resp.StatusCode))
+	}
+
+	contentType := resp.Header.Get(echo.HeaderContentType)
+	if contentType == "" {
+		contentType = echo.MIMEOctetStream
+	}
+	if resp.ContentLength >= 0 {
+		c.Response().Header().Set(echo.HeaderContentLength, fmt.Sprint(resp.ContentLength))
+	}
+	return c.Stream(http.StatusOK, contentType, resp.Body)
+}
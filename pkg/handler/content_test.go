@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/signing"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveContentRouter(t *testing.T, path string) (int, string) {
+	router := echo.New()
+	router.HTTPErrorHandler = config.CustomHTTPErrorHandler
+	RegisterContentRoutes(router)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+	response := rr.Result()
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	return response.StatusCode, string(body)
+}
+
+func TestServeSignedContent(t *testing.T) {
+	content := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pulp/content/domain/snapshot/repodata/repomd.xml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("<repomd></repomd>"))
+	}))
+	defer content.Close()
+
+	pulpConfig := config.Get().Clients.Pulp
+	defer func() { config.Get().Clients.Pulp = pulpConfig }()
+	config.Get().Clients.Pulp.ContentOrigin = content.URL
+	config.Get().Clients.Pulp.ContentSigning = config.ContentSigning{
+		Enabled:    true,
+		Keys:       []string{"secret"},
+		Expiration: time.Hour,
+	}
+
+	signer := signing.FromConfig()
+	require.NotNil(t, signer)
+	signedURL := signer.ContentURL("", "domain/snapshot", time.Now())
+
+	code, body := serveContentRouter(t, signedURL+"repodata/repomd.xml")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "<repomd></repomd>", body)
+
+	// Paths outside of the signed prefix are refused
+	otherURL := strings.Replace(signedURL, "domain/snapshot", "domain/other", 1)
+	code, _ = serveContentRouter(t, otherURL+"repodata/repomd.xml")
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, _ = serveContentRouter(t, signedURL+"../other/repodata/repomd.xml")
+	assert.NotEqual(t, http.StatusOK, code)
+
+	expiredSigner, err := signing.NewSigner([]string{"secret"}, -time.Hour)
+	require.NoError(t, err)
+	code, _ = serveContentRouter(t, expiredSigner.ContentURL("", "domain/snapshot", time.Now())+"repodata/repomd.xml")
+	assert.Equal(t, http.StatusForbidden, code)
+
+	config.Get().Clients.Pulp.ContentSigning.Enabled = false
+	code, _ = serveContentRouter(t, signedURL+"repodata/repomd.xml")
+	assert.Equal(t, http.StatusNotFound, code)
+}
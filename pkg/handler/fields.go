@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/exp/slices"
+)
+
+// ParseFields returns the fields requested with ?fields=uuid,name, which must be json fields of item
+func ParseFields(c echo.Context, item interface{}) ([]string, error) {
+	param := c.QueryParam("fields")
+	if param == "" {
+		return nil, nil
+	}
+	allowed := jsonFields(reflect.TypeOf(item))
+	fields := []string{}
+	for _, field := range strings.Split(param, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" || slices.Contains(fields, field) {
+			continue
+		}
+		if !slices.Contains(allowed, field) {
+			return nil, ce.NewErrorResponse(http.StatusBadRequest, "Invalid fields",
+				fmt.Sprintf("%s is not a field, fields must be some of %s", field, strings.Join(allowed, ", ")))
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// ParseIncludes returns the related resources requested with ?include=, which must be some of allowed
+func ParseIncludes(c echo.Context, allowed ...string) ([]string, error) {
+	param := c.QueryParam("include")
+	if param == "" {
+		return nil, nil
+	}
+	includes := []string{}
+	for _, include := range strings.Split(param, ",") {
+		include = strings.TrimSpace(include)
+		if include == "" || slices.Contains(includes, include) {
+			continue
+		}
+		if !slices.Contains(allowed, include) {
+			return nil, ce.NewErrorResponse(http.StatusBadRequest, "Invalid include",
+				fmt.Sprintf("%s can not be included, include must be some of %s", include, strings.Join(allowed, ", ")))
+		}
+		includes = append(includes, include)
+	}
+	return includes, nil
+}
+
+// jsonFields lists the fields of a struct in its json representation
+func jsonFields(t reflect.Type) []string {
+	fields := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+// sparseCollection keeps only the requested fields in each item of the data of a collection response
+func sparseCollection(collection interface{}, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return collection, nil
+	}
+	marshalled, err := json.Marshal(collection)
+	if err != nil {
+		return nil, err
+	}
+	var response map[string]json.RawMessage
+	if err = json.Unmarshal(marshalled, &response); err != nil {
+		return nil, err
+	}
+	var items []map[string]json.RawMessage
+	if err = json.Unmarshal(response["data"], &items); err != nil {
+		return nil, err
+	}
+	sparse := make([]map[string]json.RawMessage, len(items))
+	for i, item := range items {
+		sparse[i] = make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			sparse[i][field] = item[field]
+		}
+	}
+	if response["data"], err = json.Marshal(sparse); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/labstack/echo/v4"
+)
+
+// ImageBuilderHandler serves the internal endpoints used by image builder to pin
+// custom content in image builds.  Requests are authenticated with a pre shared key
+// and are not subject to the bulk limits of the public api.
+type ImageBuilderHandler struct {
+	DaoRegistry dao.DaoRegistry
+}
+
+func RegisterImageBuilderRoutes(group *echo.Group, daoReg *dao.DaoRegistry) {
+	if group == nil {
+		panic("group is nil")
+	}
+	if daoReg == nil {
+		panic("daoReg is nil")
+	}
+
+	ih := ImageBuilderHandler{DaoRegistry: *daoReg}
+	sh := SnapshotHandler{DaoRegistry: *daoReg}
+	group.POST("/repositories/bulk_export/", ih.bulkExportRepositories)
+	group.POST("/snapshots/for_date/", sh.listSnapshotsByDate)
+}
+
+// bulkExportRepositories returns the requested repositories, including their gpg key
+// and the url of their latest snapshot.
+func (ih *ImageBuilderHandler) bulkExportRepositories(c echo.Context) error {
+	var reposToExport api.RepositoryExportRequest
+	if err := c.Bind(&reposToExport); err != nil {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error binding parameters", err.Error())
+	}
+	if len(reposToExport.RepositoryUuids) == 0 {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Invalid parameters", "repository_uuids must not be empty")
+	}
+	if err := validateUUIDs("repository_uuids", reposToExport.RepositoryUuids...); err != nil {
+		return err
+	}
+
+	_, orgID := getAccountIdOrgId(c)
+	response, err := ih.DaoRegistry.RepositoryConfig.BulkExport(c.Request().Context(), orgID, reposToExport)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error exporting repositories", err)
+	}
+	return c.JSON(http.StatusOK, response)
+}
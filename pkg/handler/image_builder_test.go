@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/content-services/content-sources-backend/pkg/middleware"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+const testPSK = "image-builder-psk"
+const testPSKOrgID = "psk-org"
+
+type ImageBuilderSuite struct {
+	suite.Suite
+	reg *dao.MockDaoRegistry
+}
+
+func TestImageBuilderSuite(t *testing.T) {
+	suite.Run(t, new(ImageBuilderSuite))
+}
+
+func (suite *ImageBuilderSuite) SetupTest() {
+	suite.reg = dao.GetMockDaoRegistry(suite.T())
+}
+
+func (suite *ImageBuilderSuite) serveImageBuilderRouter(path string, body interface{}, psk string) (int, []byte, error) {
+	router := echo.New()
+	router.HTTPErrorHandler = config.CustomHTTPErrorHandler
+	pathPrefix := router.Group(internalFullRootPath(), middleware.EnforcePSK([]string{testPSK}))
+	RegisterImageBuilderRoutes(pathPrefix, suite.reg.ToDaoRegistry())
+
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return 0, nil, err
+	}
+	req := httptest.NewRequest(http.MethodPost, internalFullRootPath()+path, bytes.NewReader(encoded))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middleware.HeaderPSK, psk)
+	req.Header.Set(middleware.HeaderOrgID, testPSKOrgID)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	response := rr.Result()
+	defer response.Body.Close()
+
+	respBody, err := io.ReadAll(response.Body)
+	return response.StatusCode, respBody, err
+}
+
+func (suite *ImageBuilderSuite) TestBulkExport() {
+	t := suite.T()
+
+	request := api.RepositoryExportRequest{RepositoryUuids: []string{"3f1b6c9e-2d4a-4e8b-9c71-5a0d2e6f8abc", "3f1b6c9e-2d4a-4e8b-9c71-5a0d2e6f8def"}}
+	expected := []api.RepositoryExportResponse{
+		{UUID: "3f1b6c9e-2d4a-4e8b-9c71-5a0d2e6f8abc", Name: "first", GpgKey: "key", LatestSnapshotURL: "http://pulp/pulp/content/abc/"},
+		{UUID: "3f1b6c9e-2d4a-4e8b-9c71-5a0d2e6f8def", Name: "second"},
+	}
+	suite.reg.RepositoryConfig.On("BulkExport", mock.Anything, testPSKOrgID, request).Return(expected, nil)
+
+	code, body, err := suite.serveImageBuilderRouter("/repositories/bulk_export/", request, testPSK)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	var response []api.RepositoryExportResponse
+	err = json.Unmarshal(body, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, response)
+}
+
+func (suite *ImageBuilderSuite) TestBulkExportNotFound() {
+	t := suite.T()
+
+	request := api.RepositoryExportRequest{RepositoryUuids: []string{"3f1b6c9e-2d4a-4e8b-9c71-5a0d2e6f8abc"}}
+	suite.reg.RepositoryConfig.On("BulkExport", mock.Anything, testPSKOrgID, request).
+		Return(nil, &ce.DaoError{NotFound: true, Message: "Could not find repository with UUID abc"})
+
+	code, _, err := suite.serveImageBuilderRouter("/repositories/bulk_export/", request, testPSK)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func (suite *ImageBuilderSuite) TestSnapshotsForDate() {
+	t := suite.T()
+
+	request := api.ListSnapshotByDateRequest{RepositoryUUIDS: []string{"3f1b6c9e-2d4a-4e8b-9c71-5a0d2e6f8abc"}, Date: time.Date(2023, 8, 10, 0, 0, 0, 0, time.UTC)}
+	expected := api.ListSnapshotByDateResponse{Data: []api.SnapshotForDate{{RepositoryUUID: "3f1b6c9e-2d4a-4e8b-9c71-5a0d2e6f8abc"}}}
+	suite.reg.Snapshot.On("FetchSnapshotsByDateAndRepository", mock.Anything, testPSKOrgID, request).Return(expected, nil)
+
+	code, body, err := suite.serveImageBuilderRouter("/snapshots/for_date/", request, testPSK)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	var response api.ListSnapshotByDateResponse
+	err = json.Unmarshal(body, &response)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, response)
+}
+
+func (suite *ImageBuilderSuite) TestInvalidPSK() {
+	t := suite.T()
+
+	request := api.RepositoryExportRequest{RepositoryUuids: []string{"3f1b6c9e-2d4a-4e8b-9c71-5a0d2e6f8abc"}}
+	code, _, err := suite.serveImageBuilderRouter("/repositories/bulk_export/", request, "wrong")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
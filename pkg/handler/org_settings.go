@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/content-services/content-sources-backend/pkg/rbac"
+	"github.com/labstack/echo/v4"
+)
+
+type OrgSettingsHandler struct {
+	DaoRegistry dao.DaoRegistry
+}
+
+func RegisterOrgSettingsRoutes(group *echo.Group, daoReg *dao.DaoRegistry) {
+	if group == nil {
+		panic("engine is nil")
+	}
+	if daoReg == nil {
+		panic("daoReg is nil")
+	}
+
+	sh := OrgSettingsHandler{DaoRegistry: *daoReg}
+	addRoute(group, http.MethodGet, "/settings/", sh.fetchSettings, rbac.RbacVerbRead)
+	addRoute(group, http.MethodPut, "/settings/", sh.updateSettings, rbac.RbacVerbWrite)
+}
+
+// FetchSettings godoc
+// @Summary      Get settings
+// @ID           fetchSettings
+// @Description  Get the settings of the organization, the defaults are returned until it saves its own.
+// @Tags         settings
+// @Produce      json
+// @Success      200 {object} api.OrgSettingsResponse
+// @Failure      401 {object} ce.ErrorResponse
+// @Failure      500 {object} ce.ErrorResponse
+// @Router       /settings/ [get]
+func (sh *OrgSettingsHandler) fetchSettings(c echo.Context) error {
+	_, orgID := getAccountIdOrgId(c)
+
+	response, err := sh.DaoRegistry.OrgSettings.Fetch(c.Request().Context(), orgID)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error fetching settings", err)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// UpdateSettings godoc
+// @Summary      Update settings
+// @ID           updateSettings
+// @Description  Save the settings of the organization, the settings not in the request are reset to their default. The default distribution architecture, versions and metadata verification apply to the repositories created afterwards.
+// @Tags         settings
+// @Accept       json
+// @Produce      json
+// @Param        body  body     api.OrgSettingsRequest  true  "request body"
+// @Success      200 {object} api.OrgSettingsResponse
+// @Failure      400 {object} ce.ErrorResponse
+// @Failure      401 {object} ce.ErrorResponse
+// @Failure      415 {object} ce.ErrorResponse
+// @Failure      500 {object} ce.ErrorResponse
+// @Router       /settings/ [put]
+func (sh *OrgSettingsHandler) updateSettings(c echo.Context) error {
+	var request api.OrgSettingsRequest
+	if err := c.Bind(&request); err != nil {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error binding parameters", err.Error())
+	}
+	_, orgID := getAccountIdOrgId(c)
+
+	response, err := sh.DaoRegistry.OrgSettings.Update(c.Request().Context(), orgID, request)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error updating settings", err)
+	}
+	return c.JSON(http.StatusOK, response)
+}
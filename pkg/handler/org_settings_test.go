@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/content-services/content-sources-backend/pkg/middleware"
+	test_handler "github.com/content-services/content-sources-backend/pkg/test/handler"
+	"github.com/labstack/echo/v4"
+	echo_middleware "github.com/labstack/echo/v4/middleware"
+	"github.com/openlyinc/pointy"
+	"github.com/redhatinsights/platform-go-middlewares/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type OrgSettingsSuite struct {
+	suite.Suite
+	reg *dao.MockDaoRegistry
+}
+
+func TestOrgSettingsSuite(t *testing.T) {
+	suite.Run(t, new(OrgSettingsSuite))
+}
+
+func (suite *OrgSettingsSuite) SetupTest() {
+	suite.reg = dao.GetMockDaoRegistry(suite.T())
+}
+
+func (suite *OrgSettingsSuite) serveSettingsRouter(req *http.Request) (int, []byte, error) {
+	router := echo.New()
+	router.Use(echo_middleware.RequestIDWithConfig(echo_middleware.RequestIDConfig{
+		TargetHeader: "x-rh-insights-request-id",
+	}))
+	router.Use(middleware.WrapMiddlewareWithSkipper(identity.EnforceIdentity, middleware.SkipAuth))
+	router.HTTPErrorHandler = config.CustomHTTPErrorHandler
+	pathPrefix := router.Group(fullRootPath())
+
+	RegisterOrgSettingsRoutes(pathPrefix, suite.reg.ToDaoRegistry())
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	response := rr.Result()
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	return response.StatusCode, body, err
+}
+
+func (suite *OrgSettingsSuite) TestFetch() {
+	t := suite.T()
+
+	suite.reg.OrgSettings.On("Fetch", mock.Anything, test_handler.MockOrgId).Return(defaultOrgSettings(), nil)
+
+	req := httptest.NewRequest(http.MethodGet, fullRootPath()+"/settings/", nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveSettingsRouter(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	response := api.OrgSettingsResponse{}
+	assert.NoError(t, json.Unmarshal(body, &response))
+	assert.Equal(t, defaultOrgSettings(), response)
+}
+
+func (suite *OrgSettingsSuite) TestUpdate() {
+	t := suite.T()
+
+	request := api.OrgSettingsRequest{
+		DefaultDistributionArch: pointy.String(config.X8664),
+		NotificationOptOuts:     &[]string{"repository-created"},
+	}
+	expected := defaultOrgSettings()
+	expected.DefaultDistributionArch = config.X8664
+	expected.NotificationOptOuts = []string{"repository-created"}
+	suite.reg.OrgSettings.On("Update", mock.Anything, test_handler.MockOrgId, request).Return(expected, nil)
+
+	body, err := json.Marshal(request)
+	assert.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPut, fullRootPath()+"/settings/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveSettingsRouter(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	response := api.OrgSettingsResponse{}
+	assert.NoError(t, json.Unmarshal(body, &response))
+	assert.Equal(t, expected, response)
+}
+
+func (suite *OrgSettingsSuite) TestUpdateInvalid() {
+	t := suite.T()
+
+	request := api.OrgSettingsRequest{DefaultDistributionArch: pointy.String("sparc")}
+	suite.reg.OrgSettings.On("Update", mock.Anything, test_handler.MockOrgId, request).
+		Return(api.OrgSettingsResponse{}, &ce.DaoError{BadValidation: true, Message: "Specified distribution architecture sparc is invalid."})
+
+	body, err := json.Marshal(request)
+	assert.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPut, fullRootPath()+"/settings/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, _, err := suite.serveSettingsRouter(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
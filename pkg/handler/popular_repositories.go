@@ -92,7 +92,7 @@ func filterPopularRepositories(configData []api.PopularRepositoryResponse, filte
 func (rh *PopularRepositoriesHandler) updateIfExists(c echo.Context, repo *api.PopularRepositoryResponse) error {
 	_, orgID := getAccountIdOrgId(c)
 	// Go get the records for this URL
-	repos, _, err := rh.Dao.RepositoryConfig.List(orgID, api.PaginationData{}, api.FilterData{Search: repo.URL})
+	repos, _, err := rh.Dao.RepositoryConfig.List(c.Request().Context(), orgID, api.PaginationData{}, api.FilterData{Search: repo.URL})
 	if err != nil {
 		return ce.NewErrorResponseFromError("Could not get repository list", err)
 	}
@@ -3,6 +3,7 @@ package handler
 import (
 	"net/http"
 
+	"github.com/content-services/content-sources-backend/pkg/api"
 	"github.com/content-services/content-sources-backend/pkg/dao"
 	ce "github.com/content-services/content-sources-backend/pkg/errors"
 	"github.com/content-services/content-sources-backend/pkg/rbac"
@@ -27,6 +28,7 @@ func RegisterPublicRepositoriesRoutes(engine *echo.Group, dao *dao.DaoRegistry)
 // @Tags         public_repositories
 // @Param		 offset query int false "Offset into the list of results to return in the response"
 // @Param		 limit query int false "Limit the number of items returned"
+// @Param		 fields query string false "Comma separated list of fields to return in each repository, all fields if not set (e.g. 'uuid,url')"
 // @Accept       json
 // @Produce      json
 // @Success      200 {object} api.PublicRepositoryCollectionResponse
@@ -38,11 +40,20 @@ func RegisterPublicRepositoriesRoutes(engine *echo.Group, dao *dao.DaoRegistry)
 func (rh *PublicRepositoriesHandler) listPublicRepositories(c echo.Context) error {
 	pageData := ParsePagination(c)
 	filterData := ParseFilters(c)
+	fields, err := ParseFields(c, api.PublicRepositoryResponse{})
+	if err != nil {
+		return err
+	}
+	pageData.Fields = fields
 
-	repos, totalRepos, err := rh.DaoRegistry.Repository.ListPublic(pageData, filterData)
+	repos, totalRepos, err := rh.DaoRegistry.Repository.ListPublic(c.Request().Context(), pageData, filterData)
 	if err != nil {
-		return ce.NewErrorResponse(ce.HttpCodeForDaoError(err), "Error listing repositories", err.Error())
+		return ce.NewErrorResponseFromError("Error listing repositories", err)
 	}
 
-	return c.JSON(200, setCollectionResponseMetadata(&repos, c, totalRepos))
+	response, err := sparseCollection(setCollectionResponseMetadata(&repos, c, totalRepos), fields)
+	if err != nil {
+		return ce.NewErrorResponse(http.StatusInternalServerError, "Error listing repositories", err.Error())
+	}
+	return c.JSON(200, response)
 }
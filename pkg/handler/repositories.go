@@ -1,7 +1,11 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"mime"
 	"net/http"
 	"time"
 
@@ -17,13 +21,25 @@ import (
 	"github.com/content-services/content-sources-backend/pkg/tasks/client"
 	"github.com/content-services/content-sources-backend/pkg/tasks/payloads"
 	"github.com/content-services/content-sources-backend/pkg/tasks/queue"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/redhatinsights/platform-go-middlewares/identity"
 	"github.com/rs/zerolog/log"
+	"golang.org/x/exp/slices"
 )
 
 const BulkCreateLimit = 20
 const BulkDeleteLimit = 100
+const BulkExportLimit = 100
+const BulkImportLimit = 100
+const BulkUpdateLimit = 100
+
+// repositoryEventsInterval is the time between two reads of the events streamed by streamEvents
+var repositoryEventsInterval = 5 * time.Second
+
+// repositoryEventsOverlap is how far back the events are read again, as the events of a
+// transaction are only visible once it commits, after more recent events may have been streamed
+const repositoryEventsOverlap = time.Minute
 
 type RepositoryHandler struct {
 	DaoRegistry               dao.DaoRegistry
@@ -53,14 +69,22 @@ func RegisterRepositoryRoutes(engine *echo.Group, daoReg *dao.DaoRegistry, prod
 	}
 
 	addRoute(engine, http.MethodGet, "/repositories/", rh.listRepositories, rbac.RbacVerbRead)
+	addStreamRoute(engine, http.MethodGet, "/repositories/events", rh.streamEvents, rbac.RbacVerbRead)
+	addRoute(engine, http.MethodGet, "/repositories/duplicates/", rh.listDuplicates, rbac.RbacVerbRead)
 	addRoute(engine, http.MethodGet, "/repositories/:uuid", rh.fetch, rbac.RbacVerbRead)
 	addRoute(engine, http.MethodPut, "/repositories/:uuid", rh.fullUpdate, rbac.RbacVerbWrite)
 	addRoute(engine, http.MethodPatch, "/repositories/:uuid", rh.partialUpdate, rbac.RbacVerbWrite)
 	addRoute(engine, http.MethodDelete, "/repositories/:uuid", rh.deleteRepository, rbac.RbacVerbWrite)
 	addRoute(engine, http.MethodPost, "/repositories/bulk_delete/", rh.bulkDeleteRepositories, rbac.RbacVerbWrite)
+	addRoute(engine, http.MethodPatch, "/repositories/bulk/", rh.bulkUpdateRepositories, rbac.RbacVerbWrite)
 	addRoute(engine, http.MethodPost, "/repositories/", rh.createRepository, rbac.RbacVerbWrite)
 	addRoute(engine, http.MethodPost, "/repositories/bulk_create/", rh.bulkCreateRepositories, rbac.RbacVerbWrite)
+	addRoute(engine, http.MethodPost, "/repositories/bulk_export/", rh.bulkExportRepositories, rbac.RbacVerbRead)
+	addUploadRoute(engine, http.MethodPost, "/repositories/bulk_import/", rh.bulkImportRepositories, rbac.RbacVerbWrite)
+	addRoute(engine, http.MethodPost, "/repositories/introspect/", rh.bulkIntrospect, rbac.RbacVerbWrite)
 	addRoute(engine, http.MethodPost, "/repositories/:uuid/introspect/", rh.introspect, rbac.RbacVerbWrite)
+	addRoute(engine, http.MethodGet, "/repositories/:uuid/introspections/", rh.listIntrospections, rbac.RbacVerbRead)
+	addRoute(engine, http.MethodPost, "/repositories/:uuid/clone/", rh.cloneRepository, rbac.RbacVerbWrite)
 }
 
 func GetIdentity(c echo.Context) (identity.XRHID, error) {
@@ -85,6 +109,15 @@ func getAccountIdOrgId(c echo.Context) (string, string) {
 	return data.Identity.AccountNumber, data.Identity.Internal.OrgID
 }
 
+// getUsername returns the username of the user making the request, or an empty string
+func getUsername(c echo.Context) string {
+	data, err := GetIdentity(c)
+	if err != nil {
+		return ""
+	}
+	return data.Identity.User.Username
+}
+
 // ListRepositories godoc
 // @Summary      List Repositories
 // @ID           listRepositories
@@ -101,6 +134,10 @@ func getAccountIdOrgId(c echo.Context) (string, string) {
 // @Param		 url query string false "Filter repositories by name using an exact match"
 // @Param		 sort_by query string false "Sets the sort order of the results"
 // @Param        status query string false "Comma separated list of statuses to optionally filter on"
+// @Param        content_type query string false "Comma separated list of content types (binary, source, debug) to optionally filter on"
+// @Param        snapshot query string false "Filter repositories by whether they are snapshotted (true or false)"
+// @Param        fields query string false "Comma separated list of fields to return in each repository, all fields if not set (e.g. 'uuid,name')"
+// @Param        include query string false "Comma separated list of related resources to embed in each repository (last_snapshot, task_counts)"
 // @Accept       json
 // @Produce      json
 // @Success      200 {object} api.RepositoryCollectionResponse
@@ -114,12 +151,32 @@ func (rh *RepositoryHandler) listRepositories(c echo.Context) error {
 	c.Logger().Infof("org_id: %s", orgID)
 	pageData := ParsePagination(c)
 	filterData := ParseFilters(c)
-	repos, totalRepos, err := rh.DaoRegistry.RepositoryConfig.List(orgID, pageData, filterData)
+	fields, err := ParseFields(c, api.RepositoryResponse{})
+	if err != nil {
+		return err
+	}
+	pageData.Fields = fields
+	includes, err := ParseIncludes(c, repositoryIncludes...)
 	if err != nil {
-		return ce.NewErrorResponse(ce.HttpCodeForDaoError(err), "Error listing repositories", err.Error())
+		return err
+	}
+	repos, totalRepos, err := rh.DaoRegistry.RepositoryConfig.List(c.Request().Context(), orgID, pageData, filterData)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error listing repositories", err)
+	}
+	if err = rh.embedIncludes(c.Request().Context(), orgID, repos.Data, includes); err != nil {
+		return ce.NewErrorResponseFromError("Error listing repositories", err)
 	}
 
-	return c.JSON(200, setCollectionResponseMetadata(&repos, c, totalRepos))
+	if len(fields) > 0 {
+		// The included resources are returned even if not listed in the fields
+		fields = append(fields, includes...)
+	}
+	response, err := sparseCollection(setCollectionResponseMetadata(&repos, c, totalRepos), fields)
+	if err != nil {
+		return ce.NewErrorResponse(http.StatusInternalServerError, "Error listing repositories", err.Error())
+	}
+	return c.JSON(200, response)
 }
 
 // CreateRepository godoc
@@ -130,6 +187,7 @@ func (rh *RepositoryHandler) listRepositories(c echo.Context) error {
 // @Accept       json
 // @Produce      json
 // @Param        body  body     api.RepositoryRequest  true  "request body"
+// @Param        Idempotency-Key  header  string  false  "Key to replay the response of the first request to its retries"
 // @Success      201  {object}  api.RepositoryResponse
 // @Header       201  {string}  Location "resource URL"
 // @Failure      400 {object} ce.ErrorResponse
@@ -148,17 +206,21 @@ func (rh *RepositoryHandler) createRepository(c echo.Context) error {
 	}
 
 	accountID, orgID := getAccountIdOrgId(c)
+	settings, err := rh.DaoRegistry.OrgSettings.Fetch(c.Request().Context(), orgID)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error fetching settings", err)
+	}
 	newRepository.AccountID = &accountID
 	newRepository.OrgID = &orgID
-	newRepository.FillDefaults()
+	newRepository.FillOrgDefaults(settings)
 
 	if err = rh.CheckSnapshotForRepos(c, orgID, []api.RepositoryRequest{newRepository}); err != nil {
 		return err
 	}
 
 	var response api.RepositoryResponse
-	if response, err = rh.DaoRegistry.RepositoryConfig.Create(newRepository); err != nil {
-		return ce.NewErrorResponse(ce.HttpCodeForDaoError(err), "Error creating repository", err.Error())
+	if response, err = rh.DaoRegistry.RepositoryConfig.Create(c.Request().Context(), newRepository); err != nil {
+		return ce.NewErrorResponseFromError("Error creating repository", err)
 	}
 	if response.Snapshot {
 		rh.enqueueSnapshotEvent(c, response.RepositoryUUID, orgID)
@@ -177,6 +239,7 @@ func (rh *RepositoryHandler) createRepository(c echo.Context) error {
 // @Accept       json
 // @Produce      json
 // @Param        body  body     []api.RepositoryRequest  true  "request body"
+// @Param        Idempotency-Key  header  string  false  "Key to replay the response of the first request to its retries"
 // @Success      201  {object}  []api.RepositoryResponse
 // @Header       201  {string}  Location "resource URL"
 // @Failure      400 {object} ce.ErrorResponse
@@ -197,17 +260,21 @@ func (rh *RepositoryHandler) bulkCreateRepositories(c echo.Context) error {
 	}
 
 	accountID, orgID := getAccountIdOrgId(c)
+	settings, err := rh.DaoRegistry.OrgSettings.Fetch(c.Request().Context(), orgID)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error fetching settings", err)
+	}
 	for i := 0; i < len(newRepositories); i++ {
 		newRepositories[i].AccountID = &accountID
 		newRepositories[i].OrgID = &orgID
-		newRepositories[i].FillDefaults()
+		newRepositories[i].FillOrgDefaults(settings)
 	}
 
 	if err := rh.CheckSnapshotForRepos(c, orgID, newRepositories); err != nil {
 		return err
 	}
 
-	responses, errs := rh.DaoRegistry.RepositoryConfig.BulkCreate(newRepositories)
+	responses, errs := rh.DaoRegistry.RepositoryConfig.BulkCreate(c.Request().Context(), newRepositories)
 	if len(errs) > 0 {
 		return ce.NewErrorResponseFromError("Error creating repository", errs...)
 	}
@@ -225,6 +292,133 @@ func (rh *RepositoryHandler) bulkCreateRepositories(c echo.Context) error {
 	return c.JSON(http.StatusCreated, responses)
 }
 
+// BulkExportRepositories godoc
+// @Summary      Bulk export repositories
+// @ID           bulkExportRepositories
+// @Description  Export repositories as a portable document, that can be imported in another organization.
+// @Tags         repositories
+// @Accept       json
+// @Produce      json
+// @Param        body  body     api.RepositoryExportRequest  true  "request body"
+// @Success      200  {object}  []api.RepositoryExportResponse
+// @Failure      400 {object} ce.ErrorResponse
+// @Failure      401 {object} ce.ErrorResponse
+// @Failure      404 {object} ce.ErrorResponse
+// @Failure      415 {object} ce.ErrorResponse
+// @Failure      500 {object} ce.ErrorResponse
+// @Router       /repositories/bulk_export/ [post]
+func (rh *RepositoryHandler) bulkExportRepositories(c echo.Context) error {
+	var reposToExport api.RepositoryExportRequest
+	if err := c.Bind(&reposToExport); err != nil {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error binding parameters", err.Error())
+	}
+
+	if len(reposToExport.RepositoryUuids) == 0 {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error exporting repositories", "Request body must contain at least 1 repository UUID to export.")
+	}
+	if BulkExportLimit < len(reposToExport.RepositoryUuids) {
+		limitErrMsg := fmt.Sprintf("Cannot export more than %d repositories at once.", BulkExportLimit)
+		return ce.NewErrorResponse(http.StatusRequestEntityTooLarge, "Error exporting repositories", limitErrMsg)
+	}
+	if err := validateUUIDs("repository_uuids", reposToExport.RepositoryUuids...); err != nil {
+		return err
+	}
+
+	_, orgID := getAccountIdOrgId(c)
+	response, err := rh.DaoRegistry.RepositoryConfig.BulkExport(c.Request().Context(), orgID, reposToExport)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error exporting repositories", err)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// BulkImportRepositories godoc
+// @Summary      Bulk import repositories
+// @ID           bulkImportRepositories
+// @Description  Import repositories exported by bulk_export.  Repositories with a URL already in the organization are not imported, and are returned with a warning.
+// @Description  The exported document is sent either as the request body, or as the file field of a multipart form.
+// @Tags         repositories
+// @Accept       json,mpfd
+// @Produce      json
+// @Param        body  body     []api.RepositoryRequest  true  "request body"
+// @Success      201  {object}  []api.RepositoryImportResponse
+// @Failure      400 {object} ce.ErrorResponse
+// @Failure      401 {object} ce.ErrorResponse
+// @Failure      404 {object} ce.ErrorResponse
+// @Failure      413 {object} ce.ErrorResponse
+// @Failure      415 {object} ce.ErrorResponse
+// @Failure      500 {object} ce.ErrorResponse
+// @Router       /repositories/bulk_import/ [post]
+func (rh *RepositoryHandler) bulkImportRepositories(c echo.Context) error {
+	reposToImport, err := bindImportedRepositories(c)
+	if errors.Is(err, echo.ErrStatusRequestEntityTooLarge) {
+		return ce.NewErrorResponse(http.StatusRequestEntityTooLarge, "Error binding parameters", "Request body is too large")
+	} else if err != nil {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error binding parameters", err.Error())
+	}
+
+	if len(reposToImport) == 0 {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error importing repositories", "Request body must contain at least 1 repository to import.")
+	}
+	if BulkImportLimit < len(reposToImport) {
+		limitErrMsg := fmt.Sprintf("Cannot import more than %d repositories at once.", BulkImportLimit)
+		return ce.NewErrorResponse(http.StatusRequestEntityTooLarge, "Error importing repositories", limitErrMsg)
+	}
+
+	accountID, orgID := getAccountIdOrgId(c)
+	settings, err := rh.DaoRegistry.OrgSettings.Fetch(c.Request().Context(), orgID)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error fetching settings", err)
+	}
+	for i := 0; i < len(reposToImport); i++ {
+		// exported documents carry the uuid of the source repository
+		reposToImport[i].UUID = nil
+		reposToImport[i].AccountID = &accountID
+		reposToImport[i].OrgID = &orgID
+		reposToImport[i].FillOrgDefaults(settings)
+	}
+
+	if err := rh.CheckSnapshotForRepos(c, orgID, reposToImport); err != nil {
+		return err
+	}
+
+	responses, errs := rh.DaoRegistry.RepositoryConfig.BulkImport(c.Request().Context(), reposToImport)
+	if len(errs) > 0 {
+		return ce.NewErrorResponseFromError("Error importing repository", errs...)
+	}
+
+	for _, repo := range responses {
+		if len(repo.Warnings) > 0 {
+			continue
+		}
+		if repo.Snapshot {
+			rh.enqueueSnapshotEvent(c, repo.RepositoryUUID, orgID)
+		}
+		rh.enqueueIntrospectEvent(c, repo.RepositoryResponse, orgID)
+	}
+
+	return c.JSON(http.StatusCreated, responses)
+}
+
+// bindImportedRepositories reads the exported document sent as the request body, or streamed
+// from the file field of a multipart form
+func bindImportedRepositories(c echo.Context) ([]api.RepositoryRequest, error) {
+	var reposToImport []api.RepositoryRequest
+	mediatype, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if mediatype != echo.MIMEMultipartForm {
+		err := c.Bind(&reposToImport)
+		return reposToImport, err
+	}
+
+	file, remove, err := streamUpload(c, "file")
+	if err != nil {
+		return nil, err
+	}
+	defer remove()
+	err = json.NewDecoder(file).Decode(&reposToImport)
+	return reposToImport, err
+}
+
 // Get RepositoryResponse godoc
 // @Summary      Get Repository
 // @ID           getRepository
@@ -233,6 +427,7 @@ func (rh *RepositoryHandler) bulkCreateRepositories(c echo.Context) error {
 // @Accept       json
 // @Produce      json
 // @Param  uuid  path  string    true  "Identifier of the Repository"
+// @Param  include query string false "Comma separated list of related resources to embed in the repository (last_snapshot, task_counts)"
 // @Success      200   {object}  api.RepositoryResponse
 // @Failure      400 {object} ce.ErrorResponse
 // @Failure      401 {object} ce.ErrorResponse
@@ -242,12 +437,68 @@ func (rh *RepositoryHandler) bulkCreateRepositories(c echo.Context) error {
 func (rh *RepositoryHandler) fetch(c echo.Context) error {
 	_, orgID := getAccountIdOrgId(c)
 	uuid := c.Param("uuid")
+	includes, err := ParseIncludes(c, repositoryIncludes...)
+	if err != nil {
+		return err
+	}
 
-	response, err := rh.DaoRegistry.RepositoryConfig.Fetch(orgID, uuid)
+	response, err := rh.DaoRegistry.RepositoryConfig.Fetch(c.Request().Context(), orgID, uuid)
 	if err != nil {
-		return ce.NewErrorResponse(ce.HttpCodeForDaoError(err), "Error fetching repository", err.Error())
+		return ce.NewErrorResponseFromError("Error fetching repository", err)
 	}
-	return c.JSON(http.StatusOK, response)
+	repos := []api.RepositoryResponse{response}
+	if err = rh.embedIncludes(c.Request().Context(), orgID, repos, includes); err != nil {
+		return ce.NewErrorResponseFromError("Error fetching repository", err)
+	}
+	return c.JSON(http.StatusOK, repos[0])
+}
+
+const (
+	includeLastSnapshot = "last_snapshot"
+	includeTaskCounts   = "task_counts"
+)
+
+// repositoryIncludes are the related resources that can be embedded in repositories with ?include=
+var repositoryIncludes = []string{includeLastSnapshot, includeTaskCounts}
+
+// embedIncludes embeds the requested related resources in the repositories, loading each
+// resource for all the repositories at once
+func (rh *RepositoryHandler) embedIncludes(ctx context.Context, orgID string, repos []api.RepositoryResponse, includes []string) error {
+	if len(includes) == 0 || len(repos) == 0 {
+		return nil
+	}
+	if slices.Contains(includes, includeLastSnapshot) {
+		uuids := make([]string, len(repos))
+		for i := range repos {
+			uuids[i] = repos[i].UUID
+		}
+		snapshots, err := rh.DaoRegistry.Snapshot.FetchLatestForRepoConfigs(ctx, orgID, uuids)
+		if err != nil {
+			return err
+		}
+		for i := range repos {
+			if snap, ok := snapshots[repos[i].UUID]; ok {
+				repos[i].LastSnapshot = &snap
+			}
+		}
+	}
+	if slices.Contains(includes, includeTaskCounts) {
+		uuids := make([]string, len(repos))
+		for i := range repos {
+			uuids[i] = repos[i].RepositoryUUID
+		}
+		counts, err := rh.DaoRegistry.TaskInfo.CountsByRepository(ctx, orgID, uuids)
+		if err != nil {
+			return err
+		}
+		for i := range repos {
+			repos[i].TaskCounts = counts[repos[i].RepositoryUUID]
+			if repos[i].TaskCounts == nil {
+				repos[i].TaskCounts = api.TaskCounts{}
+			}
+		}
+	}
+	return nil
 }
 
 // FullUpdateRepository godoc
@@ -305,32 +556,33 @@ func (rh *RepositoryHandler) update(c echo.Context, fillDefaults bool) error {
 		repoParams.FillDefaults()
 	}
 
-	repoConfig, err := rh.DaoRegistry.RepositoryConfig.Fetch(orgID, uuid)
+	repoConfig, err := rh.DaoRegistry.RepositoryConfig.Fetch(c.Request().Context(), orgID, uuid)
 	if err != nil {
-		return ce.NewErrorResponse(ce.HttpCodeForDaoError(err), "Error fetching repository", err.Error())
+		return ce.NewErrorResponseFromError("Error fetching repository", err)
 	}
 
 	if repoParams.URL != nil && repoConfig.URL != *repoParams.URL {
-		snapInProgress, err := rh.DaoRegistry.TaskInfo.IsSnapshotInProgress(orgID, repoConfig.RepositoryUUID)
+		snapInProgress, err := rh.DaoRegistry.TaskInfo.IsSnapshotInProgress(c.Request().Context(), orgID, repoConfig.RepositoryUUID)
 		if err != nil {
-			return ce.NewErrorResponse(ce.HttpCodeForDaoError(err), "Error checking if snapshot is in progress", err.Error())
+			return ce.NewErrorResponseFromError("Error checking if snapshot is in progress", err)
 		}
 		if snapInProgress {
 			return ce.NewErrorResponse(http.StatusBadRequest, "Cannot update repository URL while snapshotting is in progress", "")
 		}
 	}
 
-	urlUpdated, err := rh.DaoRegistry.RepositoryConfig.Update(orgID, uuid, repoParams)
+	urlUpdated, err := rh.DaoRegistry.RepositoryConfig.Update(c.Request().Context(), orgID, uuid, repoParams)
 	if err != nil {
-		return ce.NewErrorResponse(ce.HttpCodeForDaoError(err), "Error updating repository", err.Error())
+		return ce.NewErrorResponseFromError("Error updating repository", err)
 	}
 
-	response, err := rh.DaoRegistry.RepositoryConfig.Fetch(orgID, uuid)
-	if urlUpdated && response.Snapshot {
-		rh.enqueueSnapshotEvent(c, response.RepositoryUUID, orgID)
-	}
+	response, err := rh.DaoRegistry.RepositoryConfig.Fetch(c.Request().Context(), orgID, uuid)
 	if err != nil {
-		return ce.NewErrorResponse(ce.HttpCodeForDaoError(err), "Error fetching repository", err.Error())
+		return ce.NewErrorResponseFromError("Error fetching repository", err)
+	}
+	// A repository is snapshotted right away once snapshots are turned on, instead of waiting for its next update
+	if response.Snapshot && (urlUpdated || !repoConfig.Snapshot) {
+		rh.enqueueSnapshotEvent(c, response.RepositoryUUID, orgID)
 	}
 	rh.enqueueIntrospectEvent(c, response, orgID)
 
@@ -352,20 +604,20 @@ func (rh *RepositoryHandler) deleteRepository(c echo.Context) error {
 	_, orgID := getAccountIdOrgId(c)
 	uuid := c.Param("uuid")
 
-	repoConfig, err := rh.DaoRegistry.RepositoryConfig.Fetch(orgID, uuid)
+	repoConfig, err := rh.DaoRegistry.RepositoryConfig.Fetch(c.Request().Context(), orgID, uuid)
 	if err != nil {
-		return ce.NewErrorResponse(ce.HttpCodeForDaoError(err), "Error fetching repository", err.Error())
+		return ce.NewErrorResponseFromError("Error fetching repository", err)
 	}
 
-	snapInProgress, err := rh.DaoRegistry.TaskInfo.IsSnapshotInProgress(orgID, repoConfig.RepositoryUUID)
+	snapInProgress, err := rh.DaoRegistry.TaskInfo.IsSnapshotInProgress(c.Request().Context(), orgID, repoConfig.RepositoryUUID)
 	if err != nil {
-		return ce.NewErrorResponse(ce.HttpCodeForDaoError(err), "Error checking if snapshot is in progress", err.Error())
+		return ce.NewErrorResponseFromError("Error checking if snapshot is in progress", err)
 	}
 	if snapInProgress {
 		return ce.NewErrorResponse(http.StatusBadRequest, "Cannot delete repository while snapshot is in progress", "")
 	}
-	if err := rh.DaoRegistry.RepositoryConfig.SoftDelete(orgID, uuid); err != nil {
-		return ce.NewErrorResponse(ce.HttpCodeForDaoError(err), "Error deleting repository", err.Error())
+	if err := rh.DaoRegistry.RepositoryConfig.SoftDelete(c.Request().Context(), orgID, uuid); err != nil {
+		return ce.NewErrorResponseFromError("Error deleting repository", err)
 	}
 	rh.enqueueSnapshotDeleteEvent(c, orgID, repoConfig)
 
@@ -403,6 +655,9 @@ func (rh *RepositoryHandler) bulkDeleteRepositories(c echo.Context) error {
 		limitErrMsg := fmt.Sprintf("Cannot delete more than %d repositories at once.", BulkDeleteLimit)
 		return ce.NewErrorResponse(http.StatusRequestEntityTooLarge, "Error deleting repositories", limitErrMsg)
 	}
+	if err := validateUUIDs("uuids", uuids...); err != nil {
+		return err
+	}
 
 	_, orgID := getAccountIdOrgId(c)
 
@@ -410,7 +665,7 @@ func (rh *RepositoryHandler) bulkDeleteRepositories(c echo.Context) error {
 	hasErr := false
 	errs := make([]error, len(uuids))
 	for i := range uuids {
-		repoConfig, err := rh.DaoRegistry.RepositoryConfig.Fetch(orgID, uuids[i])
+		repoConfig, err := rh.DaoRegistry.RepositoryConfig.Fetch(c.Request().Context(), orgID, uuids[i])
 		responses[i] = repoConfig
 		if err != nil {
 			hasErr = true
@@ -418,7 +673,7 @@ func (rh *RepositoryHandler) bulkDeleteRepositories(c echo.Context) error {
 			continue
 		}
 
-		snapInProgress, err := rh.DaoRegistry.TaskInfo.IsSnapshotInProgress(orgID, repoConfig.RepositoryUUID)
+		snapInProgress, err := rh.DaoRegistry.TaskInfo.IsSnapshotInProgress(c.Request().Context(), orgID, repoConfig.RepositoryUUID)
 		if err != nil {
 			hasErr = true
 			errs[i] = err
@@ -438,7 +693,7 @@ func (rh *RepositoryHandler) bulkDeleteRepositories(c echo.Context) error {
 		return ce.NewErrorResponseFromError("Error deleting repositories", errs...)
 	}
 
-	errs = rh.DaoRegistry.RepositoryConfig.BulkDelete(orgID, uuids)
+	errs = rh.DaoRegistry.RepositoryConfig.BulkDelete(c.Request().Context(), orgID, uuids)
 	if len(errs) > 0 {
 		return ce.NewErrorResponseFromError("Error deleting repositories", errs...)
 	}
@@ -450,6 +705,120 @@ func (rh *RepositoryHandler) bulkDeleteRepositories(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+// BulkUpdateRepositories godoc
+// @Summary      Bulk update repositories
+// @ID           bulkUpdateRepositories
+// @Description  apply the same changes to several repositories, updating all of them or none
+// @Tags         repositories
+// @Accept       json
+// @Produce      json
+// @Param        body  body     api.RepositoryBulkUpdateRequest  true  "Identifiers of the repositories and changes to apply"
+// @Success      200  {object}  []api.RepositoryResponse
+// @Failure      400 {object} ce.ErrorResponse
+// @Failure      401 {object} ce.ErrorResponse
+// @Failure      404 {object} ce.ErrorResponse
+// @Failure      413 {object} ce.ErrorResponse
+// @Failure      415 {object} ce.ErrorResponse
+// @Failure      500 {object} ce.ErrorResponse
+// @Router       /repositories/bulk/ [patch]
+func (rh *RepositoryHandler) bulkUpdateRepositories(c echo.Context) error {
+	var body api.RepositoryBulkUpdateRequest
+	if err := c.Bind(&body); err != nil {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error binding parameters", err.Error())
+	}
+
+	if len(body.UUIDs) == 0 {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error updating repositories", "Request body must contain at least 1 repository UUID to update.")
+	}
+	if BulkUpdateLimit < len(body.UUIDs) {
+		limitErrMsg := fmt.Sprintf("Cannot update more than %d repositories at once.", BulkUpdateLimit)
+		return ce.NewErrorResponse(http.StatusRequestEntityTooLarge, "Error updating repositories", limitErrMsg)
+	}
+	if err := validateUUIDs("uuids", body.UUIDs...); err != nil {
+		return err
+	}
+	if body.Repository.Name != nil || body.Repository.URL != nil {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error updating repositories", "The name and URL of repositories cannot be updated in bulk.")
+	}
+
+	_, orgID := getAccountIdOrgId(c)
+	if err := rh.CheckSnapshotForRepos(c, orgID, []api.RepositoryRequest{body.Repository}); err != nil {
+		return err
+	}
+
+	// Repositories having snapshots turned on by the update are snapshotted right away
+	enablesSnapshot := map[string]bool{}
+	if body.Repository.Snapshot != nil && *body.Repository.Snapshot {
+		for _, uuid := range body.UUIDs {
+			repoConfig, err := rh.DaoRegistry.RepositoryConfig.Fetch(c.Request().Context(), orgID, uuid)
+			if err != nil {
+				return ce.NewErrorResponseFromError("Error fetching repository", err)
+			}
+			enablesSnapshot[uuid] = !repoConfig.Snapshot
+		}
+	}
+
+	responses, errs := rh.DaoRegistry.RepositoryConfig.BulkUpdate(c.Request().Context(), orgID, body.UUIDs, body.Repository)
+	if len(errs) > 0 {
+		return ce.NewErrorResponseFromError("Error updating repositories", errs...)
+	}
+
+	for i := range responses {
+		if enablesSnapshot[responses[i].UUID] {
+			rh.enqueueSnapshotEvent(c, responses[i].RepositoryUUID, orgID)
+		}
+		rh.enqueueIntrospectEvent(c, responses[i], orgID)
+	}
+
+	return c.JSON(http.StatusOK, responses)
+}
+
+// ListIntrospections godoc
+// @Summary      List introspections of a repository
+// @ID           listIntrospections
+// @Description  List the introspections of a repository in the last days, latest first, with the packages they added and removed and their error.
+// @Tags         repositories
+// @Produce      json
+// @Param        uuid    path   string  true   "Identifier of the Repository"
+// @Param        offset  query  int     false  "Starting point for retrieving a subset of results."
+// @Param        limit   query  int     false  "Number of items to include in response."
+// @Success      200 {object} api.IntrospectionCollectionResponse
+// @Failure      400 {object} ce.ErrorResponse
+// @Failure      401 {object} ce.ErrorResponse
+// @Failure      404 {object} ce.ErrorResponse
+// @Failure      500 {object} ce.ErrorResponse
+// @Router       /repositories/{uuid}/introspections/ [get]
+func (rh *RepositoryHandler) listIntrospections(c echo.Context) error {
+	_, orgID := getAccountIdOrgId(c)
+	pageData := ParsePagination(c)
+
+	introspections, total, err := rh.DaoRegistry.RepositoryConfig.ListIntrospections(c.Request().Context(), orgID, c.Param("uuid"), pageData)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error listing introspections", err)
+	}
+	return c.JSON(http.StatusOK, setCollectionResponseMetadata(&introspections, c, total))
+}
+
+// ListDuplicates godoc
+// @Summary      List duplicated repositories
+// @ID           listDuplicateRepositories
+// @Description  List the groups of repositories of the organization serving the same repomd.xml at their last introspection. They are mirrors of the same content under different URLs, and could be consolidated into one repository.
+// @Tags         repositories
+// @Produce      json
+// @Success      200 {object} []api.DuplicateRepositoriesResponse
+// @Failure      401 {object} ce.ErrorResponse
+// @Failure      500 {object} ce.ErrorResponse
+// @Router       /repositories/duplicates/ [get]
+func (rh *RepositoryHandler) listDuplicates(c echo.Context) error {
+	_, orgID := getAccountIdOrgId(c)
+
+	duplicates, err := rh.DaoRegistry.RepositoryConfig.ListDuplicates(c.Request().Context(), orgID)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error listing duplicated repositories", err)
+	}
+	return c.JSON(http.StatusOK, duplicates)
+}
+
 // IntrospectRepository godoc
 // @summary 		introspect a repository
 // @ID				introspect
@@ -471,14 +840,14 @@ func (rh *RepositoryHandler) introspect(c echo.Context) error {
 		return ce.NewErrorResponse(http.StatusBadRequest, "Error binding parameters", err.Error())
 	}
 
-	response, err := rh.DaoRegistry.RepositoryConfig.Fetch(orgID, uuid)
+	response, err := rh.DaoRegistry.RepositoryConfig.Fetch(c.Request().Context(), orgID, uuid)
 	if err != nil {
-		return ce.NewErrorResponse(ce.HttpCodeForDaoError(err), "Error fetching repository", err.Error())
+		return ce.NewErrorResponseFromError("Error fetching repository", err)
 	}
 
-	repo, err := rh.DaoRegistry.Repository.FetchForUrl(response.URL)
+	repo, err := rh.DaoRegistry.Repository.FetchForUrl(c.Request().Context(), response.URL)
 	if err != nil {
-		return ce.NewErrorResponse(ce.HttpCodeForDaoError(err), "Error fetching repository uuid", err.Error())
+		return ce.NewErrorResponseFromError("Error fetching repository uuid", err)
 	}
 
 	if repo.LastIntrospectionTime != nil {
@@ -506,8 +875,8 @@ func (rh *RepositoryHandler) introspect(c echo.Context) error {
 		}
 	}
 
-	if err := rh.DaoRegistry.Repository.Update(repoUpdate); err != nil {
-		return ce.NewErrorResponse(ce.HttpCodeForDaoError(err), "Error resetting failed introspections count", err.Error())
+	if err := rh.DaoRegistry.Repository.Update(c.Request().Context(), repoUpdate); err != nil {
+		return ce.NewErrorResponseFromError("Error resetting failed introspections count", err)
 	}
 
 	rh.enqueueIntrospectEvent(c, response, orgID)
@@ -515,6 +884,226 @@ func (rh *RepositoryHandler) introspect(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+// BulkIntrospectRepositories godoc
+// @Summary      Bulk introspect repositories
+// @ID           bulkIntrospectRepositories
+// @Description  Queue the introspection of all the repositories of the organization matching the filters, for instance of the failing ones with status=Invalid,Unavailable once the cause of the failures is fixed. Repositories introspected too recently are skipped.
+// @Tags         repositories
+// @Accept       json
+// @Produce      json
+// @Param        search query string false "Search term for name and url."
+// @Param        name query string false "Filter repositories by name using an exact match"
+// @Param        url query string false "Filter repositories by url using an exact match"
+// @Param        status query string false "Comma separated list of statuses to optionally filter on"
+// @Param        content_type query string false "Comma separated list of content types (binary, source, debug) to optionally filter on"
+// @Param        snapshot query string false "Filter repositories by whether they are snapshotted (true or false)"
+// @Param        body body api.RepositoryIntrospectRequest false "request body"
+// @Success      200 {object} api.RepositoryBulkIntrospectResponse
+// @Failure      400 {object} ce.ErrorResponse
+// @Failure      401 {object} ce.ErrorResponse
+// @Failure      500 {object} ce.ErrorResponse
+// @Router       /repositories/introspect/ [post]
+func (rh *RepositoryHandler) bulkIntrospect(c echo.Context) error {
+	var req api.RepositoryIntrospectRequest
+	if err := c.Bind(&req); err != nil {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error binding parameters", err.Error())
+	}
+	_, orgID := getAccountIdOrgId(c)
+	filterData := ParseFilters(c)
+	limit := time.Second * time.Duration(config.Get().Options.IntrospectApiTimeLimitSec)
+
+	// All the matching repositories are read before queuing any introspection, as queuing changes their status
+	repos := []api.RepositoryResponse{}
+	pageData := api.PaginationData{Limit: MaxLimit, SortBy: "name", SkipCount: true}
+	for {
+		page, _, err := rh.DaoRegistry.RepositoryConfig.List(c.Request().Context(), orgID, pageData, filterData)
+		if err != nil {
+			return ce.NewErrorResponseFromError("Error listing repositories", err)
+		}
+		repos = append(repos, page.Data...)
+		if len(page.Data) < pageData.Limit {
+			break
+		}
+		pageData.Offset += pageData.Limit
+	}
+
+	response := api.RepositoryBulkIntrospectResponse{UUIDs: []string{}, TaskUUIDs: []string{}}
+	status := config.StatusPending
+	count := 0
+	for _, repoConfig := range repos {
+		repo, err := rh.DaoRegistry.Repository.FetchForUrl(c.Request().Context(), repoConfig.URL)
+		if err != nil {
+			return ce.NewErrorResponseFromError("Error fetching repository uuid", err)
+		}
+		if repo.LastIntrospectionTime != nil && time.Since(*repo.LastIntrospectionTime) < limit {
+			continue
+		}
+
+		repoUpdate := dao.RepositoryUpdate{UUID: repo.UUID, Status: &status}
+		if req.ResetCount {
+			repoUpdate.FailedIntrospectionsCount = &count
+		}
+		if err := rh.DaoRegistry.Repository.Update(c.Request().Context(), repoUpdate); err != nil {
+			return ce.NewErrorResponseFromError("Error resetting failed introspections count", err)
+		}
+
+		response.UUIDs = append(response.UUIDs, repoConfig.UUID)
+		if taskID := rh.enqueueIntrospectEvent(c, repoConfig, orgID); taskID != uuid.Nil {
+			response.TaskUUIDs = append(response.TaskUUIDs, taskID.String())
+		}
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// StreamRepositoryEvents godoc
+// @Summary      Stream Repository Events
+// @ID           streamRepositoryEvents
+// @Description  Server-Sent Events stream of the changes of the repositories of the organization, sending an event
+// @Description  named after the type of each change (e.g. repository-created) from the time the stream is opened.
+// @Tags         repositories
+// @Produce      text/event-stream
+// @Success      200 {object} api.RepositoryEventResponse
+// @Failure      401 {object} ce.ErrorResponse
+// @Failure      500 {object} ce.ErrorResponse
+// @Router       /repositories/events [get]
+func (rh *RepositoryHandler) streamEvents(c echo.Context) error {
+	_, orgID := getAccountIdOrgId(c)
+	start := time.Now()
+	since := start
+	sent := map[string]time.Time{}
+
+	response := startServerSentEvents(c)
+	ticker := time.NewTicker(repositoryEventsInterval)
+	defer ticker.Stop()
+	lastWrite := time.Now()
+	for {
+		events, err := rh.DaoRegistry.RepositoryConfig.ListEvents(c.Request().Context(), orgID, since.Add(-repositoryEventsOverlap))
+		if err != nil {
+			// The status code is already sent, so the error is sent as an event
+			_ = writeServerSentEvent(response, "error", ce.NewErrorResponseFromError("Error listing repository events", err))
+			return nil
+		}
+		for _, event := range events {
+			if _, found := sent[event.UUID]; found || event.CreatedAt.Before(start) {
+				continue
+			}
+			if err = writeServerSentEvent(response, event.Type, event); err != nil {
+				return nil
+			}
+			sent[event.UUID] = event.CreatedAt
+			if event.CreatedAt.After(since) {
+				since = event.CreatedAt
+			}
+			lastWrite = time.Now()
+		}
+		for uuid, createdAt := range sent {
+			if createdAt.Before(since.Add(-repositoryEventsOverlap)) {
+				delete(sent, uuid)
+			}
+		}
+		if time.Since(lastWrite) >= serverSentEventsKeepAlive {
+			if err = writeServerSentComment(response, "keep-alive"); err != nil {
+				return nil
+			}
+			lastWrite = time.Now()
+		}
+
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// CloneRepository godoc
+// @Summary      Clone Repository
+// @ID           cloneRepository
+// @Description  create a copy of a repository, keeping its URL, GPG key and credentials unless changed
+// @Tags         repositories
+// @Accept       json
+// @Produce      json
+// @Param        uuid  path     string                      true   "Identifier of the Repository"
+// @Param        body  body     api.RepositoryCloneRequest  false  "request body"
+// @Success      201  {object}  api.RepositoryResponse
+// @Header       201  {string}  Location "resource URL"
+// @Failure      400 {object} ce.ErrorResponse
+// @Failure      401 {object} ce.ErrorResponse
+// @Failure      404 {object} ce.ErrorResponse
+// @Failure      415 {object} ce.ErrorResponse
+// @Failure      500 {object} ce.ErrorResponse
+// @Router       /repositories/{uuid}/clone/ [post]
+func (rh *RepositoryHandler) cloneRepository(c echo.Context) error {
+	var req api.RepositoryCloneRequest
+
+	accountID, orgID := getAccountIdOrgId(c)
+	uuid := c.Param("uuid")
+
+	if err := c.Bind(&req); err != nil {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error binding parameters", err.Error())
+	}
+
+	source, err := rh.DaoRegistry.RepositoryConfig.Fetch(c.Request().Context(), orgID, uuid)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error fetching repository", err)
+	}
+	credentials, err := rh.DaoRegistry.RepositoryConfig.FetchCredentials(c.Request().Context(), orgID, uuid)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error fetching repository credentials", err)
+	}
+	proxy, err := rh.DaoRegistry.RepositoryConfig.FetchProxy(c.Request().Context(), orgID, uuid)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error fetching repository proxy", err)
+	}
+
+	name := source.Name + " (copy)"
+	newRepository := api.RepositoryRequest{
+		Name:                 &name,
+		URL:                  &source.URL,
+		DistributionVersions: &source.DistributionVersions,
+		DistributionArch:     &source.DistributionArch,
+		GpgKey:               &source.GpgKey,
+		MetadataVerification: &source.MetadataVerification,
+		Snapshot:             &source.Snapshot,
+		ContentType:          &source.ContentType,
+		AccountID:            &accountID,
+		OrgID:                &orgID,
+		ProxyURL:             &proxy.URL,
+		ProxyUsername:        &proxy.Username,
+		ProxyPassword:        &proxy.Password,
+		Username:             &credentials.Username,
+		Password:             &credentials.Password,
+	}
+	if req.Name != nil {
+		newRepository.Name = req.Name
+	}
+	if req.URL != nil {
+		newRepository.URL = req.URL
+	}
+	if req.DistributionVersions != nil {
+		newRepository.DistributionVersions = req.DistributionVersions
+	}
+	if req.DistributionArch != nil {
+		newRepository.DistributionArch = req.DistributionArch
+	}
+
+	if err = rh.CheckSnapshotForRepos(c, orgID, []api.RepositoryRequest{newRepository}); err != nil {
+		return err
+	}
+
+	response, err := rh.DaoRegistry.RepositoryConfig.Create(c.Request().Context(), newRepository)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error cloning repository", err)
+	}
+	if response.Snapshot {
+		rh.enqueueSnapshotEvent(c, response.RepositoryUUID, orgID)
+	}
+	rh.enqueueIntrospectEvent(c, response, orgID)
+
+	c.Response().Header().Set("Location", "/api/"+config.DefaultAppName+"/v1.0/repositories/"+response.UUID)
+	return c.JSON(http.StatusCreated, response)
+}
+
 // enqueueSnapshotEvent queues up a snapshot for a given repository uuid (not repository config) and org.
 func (rh *RepositoryHandler) enqueueSnapshotEvent(c echo.Context, repositoryUUID string, orgID string) {
 	if config.Get().NewTaskingSystem && config.PulpConfigured() {
@@ -524,10 +1113,12 @@ func (rh *RepositoryHandler) enqueueSnapshotEvent(c echo.Context, repositoryUUID
 			OrgId:          orgID,
 			RepositoryUUID: repositoryUUID,
 			RequestID:      c.Response().Header().Get(config.HeaderRequestId),
+			Username:       getUsername(c),
+			Priority:       queue.PriorityUser,
 		}
 		taskID, err := rh.TaskClient.Enqueue(task)
 		if err != nil {
-			logger := tasks.LogForTask(taskID.String(), task.Typename, task.RequestID)
+			logger := tasks.LogForTask(taskID.String(), task.Typename, task.RequestID, task.Username)
 			logger.Error().Msg("error enqueuing task")
 		}
 	}
@@ -542,10 +1133,11 @@ func (rh *RepositoryHandler) enqueueSnapshotDeleteEvent(c echo.Context, orgID st
 			OrgId:          orgID,
 			RepositoryUUID: repo.RepositoryUUID,
 			RequestID:      c.Response().Header().Get(config.HeaderRequestId),
+			Username:       getUsername(c),
 		}
 		taskID, err := rh.TaskClient.Enqueue(task)
 		if err != nil {
-			logger := tasks.LogForTask(taskID.String(), task.Typename, task.RequestID)
+			logger := tasks.LogForTask(taskID.String(), task.Typename, task.RequestID, task.Username)
 			logger.Error().Msg("error enqueuing task")
 		}
 	} else {
@@ -554,7 +1146,9 @@ func (rh *RepositoryHandler) enqueueSnapshotDeleteEvent(c echo.Context, orgID st
 	}
 }
 
-func (rh *RepositoryHandler) enqueueIntrospectEvent(c echo.Context, response api.RepositoryResponse, orgID string) {
+// enqueueIntrospectEvent queues the introspection of a repository, returning the task introspecting it,
+// or uuid.Nil when it could not be queued or tasks are not used
+func (rh *RepositoryHandler) enqueueIntrospectEvent(c echo.Context, response api.RepositoryResponse, orgID string) uuid.UUID {
 	var msg *message.IntrospectRequestMessage
 	var err error
 	if config.Get().NewTaskingSystem {
@@ -564,20 +1158,24 @@ func (rh *RepositoryHandler) enqueueIntrospectEvent(c echo.Context, response api
 			OrgId:          orgID,
 			RepositoryUUID: response.RepositoryUUID,
 			RequestID:      c.Response().Header().Get(config.HeaderRequestId),
+			Username:       getUsername(c),
+			Priority:       queue.PriorityUser,
 		}
 		taskID, err := rh.TaskClient.Enqueue(task)
 		if err != nil {
-			logger := tasks.LogForTask(taskID.String(), task.Typename, task.RequestID)
+			logger := tasks.LogForTask(taskID.String(), task.Typename, task.RequestID, task.Username)
 			logger.Error().Msg("error enqueuing task")
+			return uuid.Nil
 		}
-	} else {
-		if msg, err = adapter.NewIntrospect().FromRepositoryResponse(&response); err != nil {
-			log.Error().Msgf("error mapping to event message: %s", err.Error())
-		}
-		if err = rh.IntrospectRequestProducer.Produce(c, msg); err != nil {
-			log.Warn().Msgf("error producing event message: %s", err.Error())
-		}
+		return taskID
+	}
+	if msg, err = adapter.NewIntrospect().FromRepositoryResponse(&response); err != nil {
+		log.Error().Msgf("error mapping to event message: %s", err.Error())
+	}
+	if err = rh.IntrospectRequestProducer.Produce(c, msg); err != nil {
+		log.Warn().Msgf("error producing event message: %s", err.Error())
 	}
+	return uuid.Nil
 }
 
 // CheckSnapshotForRepos checks if for a given RepositoryRequest, snapshotting can be done
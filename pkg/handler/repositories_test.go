@@ -2,9 +2,11 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -24,10 +26,13 @@ import (
 	"github.com/content-services/content-sources-backend/pkg/tasks/payloads"
 	"github.com/content-services/content-sources-backend/pkg/tasks/queue"
 	test_handler "github.com/content-services/content-sources-backend/pkg/test/handler"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/openlyinc/pointy"
 	"github.com/redhatinsights/platform-go-middlewares/identity"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -116,6 +121,8 @@ func mockTaskClientEnqueueIntrospect(tcMock *client.MockTaskClient, expectedUrl
 			Dependencies:   nil,
 			OrgId:          test_handler.MockOrgId,
 			RepositoryUUID: repositoryUuid,
+			Username:       test_handler.MockUsername,
+			Priority:       queue.PriorityUser,
 		}).Return(nil, nil)
 	}
 }
@@ -127,6 +134,8 @@ func mockTaskClientEnqueueSnapshot(tcMock *client.MockTaskClient, repositoryUuid
 			Payload:        payloads.SnapshotPayload{},
 			OrgId:          test_handler.MockOrgId,
 			RepositoryUUID: repositoryUuid,
+			Username:       test_handler.MockUsername,
+			Priority:       queue.PriorityUser,
 		}).Return(nil, nil)
 	}
 }
@@ -139,6 +148,7 @@ func mockSnapshotDeleteEvent(tcMock *client.MockTaskClient, repoConfigUUID strin
 			Dependencies:   nil,
 			OrgId:          test_handler.MockOrgId,
 			RepositoryUUID: repoConfigUUID,
+			Username:       test_handler.MockUsername,
 		}).Return(nil, nil)
 	}
 }
@@ -155,7 +165,7 @@ func (suite *ReposSuite) TestSimple() {
 
 	collection := createRepoCollection(1, 10, 0)
 	paginationData := api.PaginationData{Limit: 10, Offset: DefaultOffset}
-	suite.reg.RepositoryConfig.On("List", test_handler.MockOrgId, paginationData, api.FilterData{}).Return(collection, int64(1), nil)
+	suite.reg.RepositoryConfig.On("List", mock.Anything, test_handler.MockOrgId, paginationData, api.FilterData{}).Return(collection, int64(1), nil)
 
 	path := fmt.Sprintf("%s/repositories/?limit=%d", fullRootPath(), 10)
 	req := httptest.NewRequest(http.MethodGet, path, nil)
@@ -185,12 +195,72 @@ func (suite *ReposSuite) TestSimple() {
 	assert.Equal(t, collection.Data[0].MetadataVerification, response.Data[0].MetadataVerification)
 }
 
+func (suite *ReposSuite) TestListWithoutCount() {
+	t := suite.T()
+
+	collection := createRepoCollection(10, 10, 10)
+	paginationData := api.PaginationData{Limit: 10, Offset: 10, SkipCount: true}
+	suite.reg.RepositoryConfig.On("List", mock.Anything, test_handler.MockOrgId, paginationData, api.FilterData{}).Return(collection, api.UncountedTotal, nil)
+
+	path := fmt.Sprintf("%s/repositories/?limit=10&offset=10&count=false", fullRootPath())
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveRepositoriesRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	response := api.RepositoryCollectionResponse{}
+	require.NoError(t, json.Unmarshal(body, &response))
+	assert.Equal(t, api.UncountedTotal, response.Meta.Count)
+	assert.Empty(t, response.Links.Last)
+	assert.Contains(t, response.Links.Next, "offset=20")
+	assert.Contains(t, response.Links.Prev, "offset=0")
+}
+
+func (suite *ReposSuite) TestListFields() {
+	t := suite.T()
+
+	collection := api.RepositoryCollectionResponse{Data: []api.RepositoryResponse{{UUID: "abc", Name: "repo", URL: "https://example.com/repo/"}}}
+	paginationData := api.PaginationData{Limit: DefaultLimit, Offset: DefaultOffset, Fields: []string{"uuid", "name"}}
+	suite.reg.RepositoryConfig.On("List", mock.Anything, test_handler.MockOrgId, paginationData, api.FilterData{}).Return(collection, int64(1), nil)
+
+	req := httptest.NewRequest(http.MethodGet, fullRootPath()+"/repositories/?fields=uuid,name", nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveRepositoriesRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	response := struct {
+		Data  []map[string]interface{} `json:"data"`
+		Meta  api.ResponseMetadata     `json:"meta"`
+		Links api.Links                `json:"links"`
+	}{}
+	err = json.Unmarshal(body, &response)
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]interface{}{{"uuid": "abc", "name": "repo"}}, response.Data)
+	assert.Equal(t, int64(1), response.Meta.Count)
+	assert.Contains(t, response.Links.First, "fields=uuid,name")
+}
+
+func (suite *ReposSuite) TestListInvalidFields() {
+	t := suite.T()
+
+	req := httptest.NewRequest(http.MethodGet, fullRootPath()+"/repositories/?fields=uuid,password", nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, _, err := suite.serveRepositoriesRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
 func (suite *ReposSuite) TestListNoRepositories() {
 	t := suite.T()
 
 	collection := api.RepositoryCollectionResponse{}
 	paginationData := api.PaginationData{Limit: DefaultLimit, Offset: DefaultOffset}
-	suite.reg.RepositoryConfig.On("List", test_handler.MockOrgId, paginationData, api.FilterData{}).Return(collection, int64(0), nil)
+	suite.reg.RepositoryConfig.On("List", mock.Anything, test_handler.MockOrgId, paginationData, api.FilterData{}).Return(collection, int64(0), nil)
 
 	req := httptest.NewRequest(http.MethodGet, fullRootPath()+"/repositories/", nil)
 	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
@@ -217,8 +287,8 @@ func (suite *ReposSuite) TestListPagedExtraRemaining() {
 	paginationData1 := api.PaginationData{Limit: 10, Offset: 0}
 	paginationData2 := api.PaginationData{Limit: 10, Offset: 100}
 
-	suite.reg.RepositoryConfig.On("List", test_handler.MockOrgId, paginationData1, api.FilterData{}).Return(collection, int64(102), nil).Once()
-	suite.reg.RepositoryConfig.On("List", test_handler.MockOrgId, paginationData2, api.FilterData{}).Return(collection, int64(102), nil).Once()
+	suite.reg.RepositoryConfig.On("List", mock.Anything, test_handler.MockOrgId, paginationData1, api.FilterData{}).Return(collection, int64(102), nil).Once()
+	suite.reg.RepositoryConfig.On("List", mock.Anything, test_handler.MockOrgId, paginationData2, api.FilterData{}).Return(collection, int64(102), nil).Once()
 
 	path := fmt.Sprintf("%s/repositories/?limit=%d", fullRootPath(), 10)
 	req := httptest.NewRequest(http.MethodGet, path, nil)
@@ -255,8 +325,8 @@ func (suite *ReposSuite) TestListPagedNoRemaining() {
 	paginationData2 := api.PaginationData{Limit: 10, Offset: 90}
 
 	collection := api.RepositoryCollectionResponse{}
-	suite.reg.RepositoryConfig.On("List", test_handler.MockOrgId, paginationData1, api.FilterData{}).Return(collection, int64(100), nil)
-	suite.reg.RepositoryConfig.On("List", test_handler.MockOrgId, paginationData2, api.FilterData{}).Return(collection, int64(100), nil)
+	suite.reg.RepositoryConfig.On("List", mock.Anything, test_handler.MockOrgId, paginationData1, api.FilterData{}).Return(collection, int64(100), nil)
+	suite.reg.RepositoryConfig.On("List", mock.Anything, test_handler.MockOrgId, paginationData2, api.FilterData{}).Return(collection, int64(100), nil)
 
 	path := fmt.Sprintf("%s/repositories/?limit=%d", fullRootPath(), 10)
 	req := httptest.NewRequest(http.MethodGet, path, nil)
@@ -294,7 +364,7 @@ func (suite *ReposSuite) TestListDaoError() {
 	}
 	paginationData := api.PaginationData{Limit: DefaultLimit}
 
-	suite.reg.RepositoryConfig.On("List", test_handler.MockOrgId, paginationData, api.FilterData{}).
+	suite.reg.RepositoryConfig.On("List", mock.Anything, test_handler.MockOrgId, paginationData, api.FilterData{}).
 		Return(api.RepositoryCollectionResponse{}, int64(0), &daoError)
 
 	path := fmt.Sprintf("%s/repositories/", fullRootPath())
@@ -316,7 +386,7 @@ func (suite *ReposSuite) TestFetch() {
 		UUID: uuid,
 	}
 
-	suite.reg.RepositoryConfig.On("Fetch", test_handler.MockOrgId, uuid).Return(repo, nil)
+	suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, uuid).Return(repo, nil)
 
 	body, err := json.Marshal(repo)
 	if err != nil {
@@ -338,6 +408,115 @@ func (suite *ReposSuite) TestFetch() {
 	assert.Equal(t, http.StatusOK, code)
 }
 
+func (suite *ReposSuite) TestListIntrospections() {
+	t := suite.T()
+
+	uuid := "abcadaba"
+	introspections := api.IntrospectionCollectionResponse{Data: []api.IntrospectionResponse{
+		{UUID: "introspection-uuid", Status: "failed", Error: "repomd.xml not found"},
+	}}
+	suite.reg.RepositoryConfig.On("ListIntrospections", mock.Anything, test_handler.MockOrgId, uuid, mock.Anything).
+		Return(introspections, int64(1), nil)
+
+	req := httptest.NewRequest(http.MethodGet, fullRootPath()+"/repositories/"+uuid+"/introspections/?limit=10", nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveRepositoriesRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	var response api.IntrospectionCollectionResponse
+	err = json.Unmarshal(body, &response)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), response.Meta.Count)
+	assert.Equal(t, "repomd.xml not found", response.Data[0].Error)
+}
+
+func (suite *ReposSuite) TestListDuplicates() {
+	t := suite.T()
+
+	duplicates := []api.DuplicateRepositoriesResponse{{
+		RepomdChecksum: "abc123",
+		Repositories: []api.DuplicateRepository{
+			{UUID: "uuid-1", Name: "epel", URL: "https://mirror.example.com/epel/"},
+			{UUID: "uuid-2", Name: "epel mirror", URL: "https://other.example.com/epel/"},
+		},
+	}}
+	suite.reg.RepositoryConfig.On("ListDuplicates", mock.Anything, test_handler.MockOrgId).Return(duplicates, nil)
+
+	req := httptest.NewRequest(http.MethodGet, fullRootPath()+"/repositories/duplicates/", nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveRepositoriesRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	var response []api.DuplicateRepositoriesResponse
+	err = json.Unmarshal(body, &response)
+	assert.Nil(t, err)
+	assert.Equal(t, duplicates, response)
+}
+
+func (suite *ReposSuite) TestFetchInclude() {
+	t := suite.T()
+
+	uuid := "abcadaba"
+	repo := api.RepositoryResponse{Name: "my repo", UUID: uuid, RepositoryUUID: "repo-uuid"}
+	snapshot := api.SnapshotResponse{UUID: "snap-uuid"}
+
+	suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, uuid).Return(repo, nil)
+	suite.reg.Snapshot.On("FetchLatestForRepoConfigs", mock.Anything, test_handler.MockOrgId, []string{uuid}).
+		Return(map[string]api.SnapshotResponse{uuid: snapshot}, nil)
+	suite.reg.TaskInfo.On("CountsByRepository", mock.Anything, test_handler.MockOrgId, []string{"repo-uuid"}).
+		Return(map[string]api.TaskCounts{"repo-uuid": {"completed": 2}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, fullRootPath()+"/repositories/"+uuid+"?include=last_snapshot,task_counts", nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveRepositoriesRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	var response api.RepositoryResponse
+	err = json.Unmarshal(body, &response)
+	assert.Nil(t, err)
+	require.NotNil(t, response.LastSnapshot)
+	assert.Equal(t, "snap-uuid", response.LastSnapshot.UUID)
+	assert.Equal(t, api.TaskCounts{"completed": 2}, response.TaskCounts)
+}
+
+func (suite *ReposSuite) TestListInclude() {
+	t := suite.T()
+
+	collection := api.RepositoryCollectionResponse{Data: []api.RepositoryResponse{{UUID: "abc"}, {UUID: "def"}}}
+	paginationData := api.PaginationData{Limit: DefaultLimit, Offset: DefaultOffset}
+	suite.reg.RepositoryConfig.On("List", mock.Anything, test_handler.MockOrgId, paginationData, api.FilterData{}).Return(collection, int64(2), nil)
+	suite.reg.Snapshot.On("FetchLatestForRepoConfigs", mock.Anything, test_handler.MockOrgId, []string{"abc", "def"}).
+		Return(map[string]api.SnapshotResponse{"def": {UUID: "snap-uuid"}}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, fullRootPath()+"/repositories/?include=last_snapshot", nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveRepositoriesRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	response := api.RepositoryCollectionResponse{}
+	err = json.Unmarshal(body, &response)
+	assert.Nil(t, err)
+	require.Len(t, response.Data, 2)
+	assert.Nil(t, response.Data[0].LastSnapshot)
+	require.NotNil(t, response.Data[1].LastSnapshot)
+	assert.Equal(t, "snap-uuid", response.Data[1].LastSnapshot.UUID)
+
+	// Unknown resources can not be included
+	req = httptest.NewRequest(http.MethodGet, fullRootPath()+"/repositories/?include=rpms", nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+	code, _, err = suite.serveRepositoriesRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
 func (suite *ReposSuite) TestFetchNotFound() {
 	t := suite.T()
 
@@ -352,7 +531,7 @@ func (suite *ReposSuite) TestFetchNotFound() {
 		NotFound: true,
 		Message:  "Not found",
 	}
-	suite.reg.RepositoryConfig.On("Fetch", test_handler.MockOrgId, uuid).Return(api.RepositoryResponse{}, &daoError)
+	suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, uuid).Return(api.RepositoryResponse{}, &daoError)
 
 	body, err := json.Marshal(repo)
 	if err != nil {
@@ -382,8 +561,9 @@ func (suite *ReposSuite) TestCreate() {
 	repo.Snapshot = pointy.Pointer(true)
 	repo.FillDefaults()
 
-	suite.reg.Domain.On("FetchOrCreateDomain", test_handler.MockOrgId).Return("MyDomain", nil)
-	suite.reg.RepositoryConfig.On("Create", repo).Return(expected, nil)
+	suite.reg.Domain.On("FetchOrCreateDomain", mock.Anything, test_handler.MockOrgId).Return("MyDomain", nil)
+	suite.reg.OrgSettings.On("Fetch", mock.Anything, test_handler.MockOrgId).Return(defaultOrgSettings(), nil)
+	suite.reg.RepositoryConfig.On("Create", mock.Anything, repo).Return(expected, nil)
 
 	mockTaskClientEnqueueSnapshot(suite.tcMock, repoUuid)
 	mockTaskClientEnqueueIntrospect(suite.tcMock, expected.URL, repoUuid)
@@ -408,6 +588,46 @@ func (suite *ReposSuite) TestCreate() {
 	assert.Equal(t, http.StatusCreated, code)
 }
 
+func (suite *ReposSuite) TestCreateWithOrgDefaults() {
+	t := suite.T()
+	expected := api.RepositoryResponse{Name: "my repo", URL: "https://example.com", RepositoryUUID: "repoUuid"}
+
+	settings := defaultOrgSettings()
+	settings.DefaultDistributionArch = config.X8664
+	settings.DefaultDistributionVersions = []string{config.El9}
+	settings.DefaultMetadataVerification = true
+	suite.reg.OrgSettings.On("Fetch", mock.Anything, test_handler.MockOrgId).Return(settings, nil)
+
+	// Values set in the request are kept
+	request := createRepoRequest("my repo", "https://example.com")
+	request.DistributionVersions = &[]string{config.El8}
+	repo := request
+	repo.DistributionArch = pointy.String(config.X8664)
+	repo.MetadataVerification = pointy.Bool(true)
+	repo.FillDefaults()
+	suite.reg.RepositoryConfig.On("Create", mock.Anything, repo).Return(expected, nil)
+	mockTaskClientEnqueueIntrospect(suite.tcMock, expected.URL, expected.RepositoryUUID)
+
+	body, err := json.Marshal(request)
+	assert.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, fullRootPath()+"/repositories/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, _, err := suite.serveRepositoriesRouter(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, code)
+}
+
+// defaultOrgSettings are the settings of an org that has not saved any
+func defaultOrgSettings() api.OrgSettingsResponse {
+	return api.OrgSettingsResponse{
+		DefaultDistributionArch:     config.ANY_ARCH,
+		DefaultDistributionVersions: []string{config.ANY_VERSION},
+		NotificationOptOuts:         []string{},
+	}
+}
+
 func resetFeatures() {
 	config.Get().Features.Snapshots.Enabled = true
 	config.Get().Features.Snapshots.Accounts = nil
@@ -428,7 +648,8 @@ func (suite *ReposSuite) TestCreateSnapshotNotAllowed() {
 	repo.FillDefaults()
 	repo.Snapshot = pointy.Bool(true)
 
-	suite.reg.RepositoryConfig.On("Create", repo).Return(expected, nil)
+	suite.reg.OrgSettings.On("Fetch", mock.Anything, test_handler.MockOrgId).Return(defaultOrgSettings(), nil)
+	suite.reg.RepositoryConfig.On("Create", mock.Anything, repo).Return(expected, nil)
 
 	body, err := json.Marshal(repo)
 	if err != nil {
@@ -460,7 +681,8 @@ func (suite *ReposSuite) TestCreateAlreadyExists() {
 		BadValidation: true,
 		Message:       "Already exists",
 	}
-	suite.reg.RepositoryConfig.On("Create", repo).Return(api.RepositoryResponse{}, &daoError)
+	suite.reg.OrgSettings.On("Fetch", mock.Anything, test_handler.MockOrgId).Return(defaultOrgSettings(), nil)
+	suite.reg.RepositoryConfig.On("Create", mock.Anything, repo).Return(api.RepositoryResponse{}, &daoError)
 
 	body, err := json.Marshal(repo)
 	if err != nil {
@@ -513,8 +735,9 @@ func (suite *ReposSuite) TestBulkCreate() {
 		},
 	}
 
-	suite.reg.RepositoryConfig.On("BulkCreate", repos).Return(expected, []error{})
-	suite.reg.Domain.On("FetchOrCreateDomain", test_handler.MockOrgId).Return("MyDomain", nil)
+	suite.reg.OrgSettings.On("Fetch", mock.Anything, test_handler.MockOrgId).Return(defaultOrgSettings(), nil)
+	suite.reg.RepositoryConfig.On("BulkCreate", mock.Anything, repos).Return(expected, []error{})
+	suite.reg.Domain.On("FetchOrCreateDomain", mock.Anything, test_handler.MockOrgId).Return("MyDomain", nil)
 	mockTaskClientEnqueueSnapshot(suite.tcMock, repoUuid1)
 	mockTaskClientEnqueueIntrospect(suite.tcMock, expected[0].URL, repoUuid1)
 	mockTaskClientEnqueueIntrospect(suite.tcMock, expected[1].URL, repoUuid2)
@@ -561,7 +784,8 @@ func (suite *ReposSuite) TestBulkCreateOneFails() {
 		},
 	}
 
-	suite.reg.RepositoryConfig.On("BulkCreate", repos).Return([]api.RepositoryResponse{}, expected)
+	suite.reg.OrgSettings.On("Fetch", mock.Anything, test_handler.MockOrgId).Return(defaultOrgSettings(), nil)
+	suite.reg.RepositoryConfig.On("BulkCreate", mock.Anything, repos).Return([]api.RepositoryResponse{}, expected)
 
 	body, err := json.Marshal(repos)
 	if err != nil {
@@ -609,18 +833,183 @@ func (suite *ReposSuite) TestBulkCreateTooMany() {
 	assert.Equal(t, http.StatusRequestEntityTooLarge, code)
 }
 
+func (suite *ReposSuite) TestBulkExport() {
+	t := suite.T()
+
+	request := api.RepositoryExportRequest{RepositoryUuids: []string{"6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01", "6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a02"}}
+	expected := []api.RepositoryExportResponse{
+		{UUID: "6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01", Name: "repo_1", URL: "https://example1.com", GpgKey: "key"},
+		{UUID: "6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a02", Name: "repo_2", URL: "https://example2.com"},
+	}
+	suite.reg.RepositoryConfig.On("BulkExport", mock.Anything, test_handler.MockOrgId, request).Return(expected, nil)
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		t.Error("Could not marshal JSON")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, fullRootPath()+"/repositories/bulk_export/",
+		bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveRepositoriesRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	var response []api.RepositoryExportResponse
+	err = json.Unmarshal(body, &response)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, response)
+}
+
+func (suite *ReposSuite) TestBulkExportTooMany() {
+	t := suite.T()
+
+	request := api.RepositoryExportRequest{RepositoryUuids: make([]string, BulkExportLimit+1)}
+	body, err := json.Marshal(request)
+	if err != nil {
+		t.Error("Could not marshal JSON")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, fullRootPath()+"/repositories/bulk_export/",
+		bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, _, err := suite.serveRepositoriesRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+}
+
+func (suite *ReposSuite) TestBulkImport() {
+	t := suite.T()
+
+	repo1 := createRepoRequest("repo_1", "https://example1.com")
+	repo1.FillDefaults()
+	repo2 := createRepoRequest("repo_2", "https://example2.com")
+	repo2.FillDefaults()
+	repos := []api.RepositoryRequest{repo1, repo2}
+
+	expectedRequests := []api.RepositoryRequest{repo1, repo2}
+	for i := range expectedRequests {
+		expectedRequests[i].UUID = nil
+	}
+	expected := []api.RepositoryImportResponse{
+		{
+			RepositoryResponse: api.RepositoryResponse{Name: "repo_1", URL: "https://example1.com", RepositoryUUID: "repoUuid1"},
+			Warnings:           []string{},
+		},
+		{
+			RepositoryResponse: api.RepositoryResponse{Name: "existing", URL: "https://example2.com", RepositoryUUID: "repoUuid2"},
+			Warnings:           []string{"Repository with this URL already belongs to organization"},
+		},
+	}
+
+	suite.reg.OrgSettings.On("Fetch", mock.Anything, test_handler.MockOrgId).Return(defaultOrgSettings(), nil)
+	suite.reg.RepositoryConfig.On("BulkImport", mock.Anything, expectedRequests).Return(expected, []error{})
+	// Only the imported repository is introspected
+	mockTaskClientEnqueueIntrospect(suite.tcMock, expected[0].URL, "repoUuid1")
+
+	body, err := json.Marshal(repos)
+	if err != nil {
+		t.Error("Could not marshal JSON")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, fullRootPath()+"/repositories/bulk_import/",
+		bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveRepositoriesRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, code)
+
+	var response []api.RepositoryImportResponse
+	err = json.Unmarshal(body, &response)
+	assert.Nil(t, err)
+	// The uuid of the repository is not serialized
+	for i := range expected {
+		expected[i].RepositoryUUID = ""
+	}
+	assert.Equal(t, expected, response)
+}
+
+func (suite *ReposSuite) TestBulkImportMultipart() {
+	t := suite.T()
+
+	repo := createRepoRequest("repo_1", "https://example1.com")
+	repo.FillDefaults()
+	expectedRequest := repo
+	expectedRequest.UUID = nil
+	expected := []api.RepositoryImportResponse{
+		{
+			RepositoryResponse: api.RepositoryResponse{Name: "repo_1", URL: "https://example1.com", RepositoryUUID: "repoUuid1"},
+			Warnings:           []string{},
+		},
+	}
+
+	suite.reg.OrgSettings.On("Fetch", mock.Anything, test_handler.MockOrgId).Return(defaultOrgSettings(), nil)
+	suite.reg.RepositoryConfig.On("BulkImport", mock.Anything, []api.RepositoryRequest{expectedRequest}).Return(expected, []error{})
+	mockTaskClientEnqueueIntrospect(suite.tcMock, expected[0].URL, "repoUuid1")
+
+	document, err := json.Marshal([]api.RepositoryRequest{repo})
+	assert.NoError(t, err)
+	form := bytes.Buffer{}
+	writer := multipart.NewWriter(&form)
+	assert.NoError(t, writer.WriteField("description", "exported repositories"))
+	part, err := writer.CreateFormFile("file", "repositories.json")
+	assert.NoError(t, err)
+	_, err = part.Write(document)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, fullRootPath()+"/repositories/bulk_import/", &form)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveRepositoriesRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, code)
+
+	var response []api.RepositoryImportResponse
+	err = json.Unmarshal(body, &response)
+	assert.Nil(t, err)
+	// The uuid of the repository is not serialized
+	expected[0].RepositoryUUID = ""
+	assert.Equal(t, expected, response)
+}
+
+func (suite *ReposSuite) TestBulkImportMultipartNoFile() {
+	t := suite.T()
+
+	form := bytes.Buffer{}
+	writer := multipart.NewWriter(&form)
+	assert.NoError(t, writer.WriteField("description", "exported repositories"))
+	assert.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, fullRootPath()+"/repositories/bulk_import/", &form)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveRepositoriesRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, string(body), "form has no file field")
+}
+
 func (suite *ReposSuite) TestDelete() {
 	t := suite.T()
 	uuid := "valid-uuid"
 
-	suite.reg.RepositoryConfig.On("Fetch", test_handler.MockOrgId, uuid).Return(api.RepositoryResponse{
+	suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, uuid).Return(api.RepositoryResponse{
 		Name:           "my repo",
 		URL:            "https://example.com",
 		UUID:           uuid,
 		RepositoryUUID: uuid,
 	}, nil)
-	suite.reg.TaskInfo.On("IsSnapshotInProgress", test_handler.MockOrgId, uuid).Return(false, nil)
-	suite.reg.RepositoryConfig.On("SoftDelete", test_handler.MockOrgId, uuid).Return(nil)
+	suite.reg.TaskInfo.On("IsSnapshotInProgress", mock.Anything, test_handler.MockOrgId, uuid).Return(false, nil)
+	suite.reg.RepositoryConfig.On("SoftDelete", mock.Anything, test_handler.MockOrgId, uuid).Return(nil)
 	mockSnapshotDeleteEvent(suite.tcMock, uuid)
 
 	req := httptest.NewRequest(http.MethodDelete, fullRootPath()+"/repositories/"+uuid, nil)
@@ -639,14 +1028,14 @@ func (suite *ReposSuite) TestDeleteNotFound() {
 		NotFound: true,
 	}
 
-	suite.reg.RepositoryConfig.On("Fetch", test_handler.MockOrgId, uuid).Return(api.RepositoryResponse{
+	suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, uuid).Return(api.RepositoryResponse{
 		Name:           "my repo",
 		URL:            "https://example.com",
 		UUID:           uuid,
 		RepositoryUUID: uuid,
 	}, nil)
-	suite.reg.TaskInfo.On("IsSnapshotInProgress", test_handler.MockOrgId, uuid).Return(false, nil)
-	suite.reg.RepositoryConfig.On("SoftDelete", test_handler.MockOrgId, uuid).Return(&daoError)
+	suite.reg.TaskInfo.On("IsSnapshotInProgress", mock.Anything, test_handler.MockOrgId, uuid).Return(false, nil)
+	suite.reg.RepositoryConfig.On("SoftDelete", mock.Anything, test_handler.MockOrgId, uuid).Return(&daoError)
 
 	req := httptest.NewRequest(http.MethodDelete, fullRootPath()+"/repositories/"+uuid, nil)
 	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
@@ -658,15 +1047,15 @@ func (suite *ReposSuite) TestDeleteNotFound() {
 
 func (suite *ReposSuite) TestSnapshotInProgress() {
 	t := suite.T()
-	uuid := "inprogress-uuid"
+	uuid := "6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a03"
 
-	suite.reg.RepositoryConfig.On("Fetch", test_handler.MockOrgId, uuid).Return(api.RepositoryResponse{
+	suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, uuid).Return(api.RepositoryResponse{
 		Name:           "my repo",
 		URL:            "https://example.com",
 		UUID:           uuid,
 		RepositoryUUID: uuid,
 	}, nil)
-	suite.reg.TaskInfo.On("IsSnapshotInProgress", test_handler.MockOrgId, uuid).Return(true, nil)
+	suite.reg.TaskInfo.On("IsSnapshotInProgress", mock.Anything, test_handler.MockOrgId, uuid).Return(true, nil)
 
 	req := httptest.NewRequest(http.MethodDelete, fullRootPath()+"/repositories/"+uuid, nil)
 	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
@@ -678,20 +1067,20 @@ func (suite *ReposSuite) TestSnapshotInProgress() {
 
 func (suite *ReposSuite) TestBulkDelete() {
 	t := suite.T()
-	uuids := []string{"uuid-1", "uuid-2"}
+	uuids := []string{"6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01", "6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a02"}
 
 	for i := range uuids {
-		suite.reg.RepositoryConfig.On("Fetch", test_handler.MockOrgId, uuids[i]).Return(api.RepositoryResponse{
+		suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, uuids[i]).Return(api.RepositoryResponse{
 			Name:           fmt.Sprintf("my repo %d", i),
 			URL:            fmt.Sprintf("https://example.com/%d", i),
 			UUID:           uuids[i],
 			RepositoryUUID: uuids[i],
 		}, nil)
-		suite.reg.TaskInfo.On("IsSnapshotInProgress", test_handler.MockOrgId, uuids[i]).Return(false, nil)
+		suite.reg.TaskInfo.On("IsSnapshotInProgress", mock.Anything, test_handler.MockOrgId, uuids[i]).Return(false, nil)
 		mockSnapshotDeleteEvent(suite.tcMock, uuids[i])
 	}
 
-	suite.reg.RepositoryConfig.On("BulkDelete", test_handler.MockOrgId, uuids).Return([]error{})
+	suite.reg.RepositoryConfig.On("BulkDelete", mock.Anything, test_handler.MockOrgId, uuids).Return([]error{})
 
 	body, err := json.Marshal(api.UUIDListRequest{UUIDs: uuids})
 	assert.NoError(t, err)
@@ -705,6 +1094,59 @@ func (suite *ReposSuite) TestBulkDelete() {
 	assert.Equal(t, http.StatusNoContent, code)
 }
 
+func (suite *ReposSuite) TestBulkUpdate() {
+	t := suite.T()
+	uuids := []string{"6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01", "6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a02"}
+	changes := api.RepositoryRequest{GpgKey: pointy.String("fixed key")}
+
+	responses := make([]api.RepositoryResponse, len(uuids))
+	for i := range uuids {
+		responses[i] = api.RepositoryResponse{
+			Name:           fmt.Sprintf("my repo %d", i),
+			URL:            fmt.Sprintf("https://example.com/%d", i),
+			UUID:           uuids[i],
+			RepositoryUUID: uuids[i],
+			GpgKey:         "fixed key",
+		}
+		mockTaskClientEnqueueIntrospect(suite.tcMock, responses[i].URL, uuids[i])
+	}
+	suite.reg.RepositoryConfig.On("BulkUpdate", mock.Anything, test_handler.MockOrgId, uuids, changes).Return(responses, []error{})
+
+	body, err := json.Marshal(api.RepositoryBulkUpdateRequest{UUIDs: uuids, Repository: changes})
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPatch, fullRootPath()+"/repositories/bulk/", bytes.NewReader(body))
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+	req.Header.Set("Content-Type", "application/json")
+
+	code, body, err := suite.serveRepositoriesRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	var updated []api.RepositoryResponse
+	require.NoError(t, json.Unmarshal(body, &updated))
+	require.Len(t, updated, len(uuids))
+	assert.Equal(t, "fixed key", updated[1].GpgKey)
+}
+
+func (suite *ReposSuite) TestBulkUpdateName() {
+	t := suite.T()
+
+	body, err := json.Marshal(api.RepositoryBulkUpdateRequest{
+		UUIDs:      []string{"6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01", "6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a02"},
+		Repository: api.RepositoryRequest{Name: pointy.String("same name")},
+	})
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPatch, fullRootPath()+"/repositories/bulk/", bytes.NewReader(body))
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+	req.Header.Set("Content-Type", "application/json")
+
+	code, _, err := suite.serveRepositoriesRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
 func (suite *ReposSuite) TestBulkDeleteNoUUIDs() {
 	t := suite.T()
 
@@ -730,22 +1172,39 @@ func (suite *ReposSuite) TestBulkDeleteNoUUIDs() {
 	assert.Contains(t, string(body), "Request body must contain at least 1 repository UUID to delete.")
 }
 
+func (suite *ReposSuite) TestBulkDeleteInvalidUUID() {
+	t := suite.T()
+
+	body, err := json.Marshal(api.UUIDListRequest{UUIDs: []string{"6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01", "not-a-uuid"}})
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, fullRootPath()+"/repositories/bulk_delete/", bytes.NewReader(body))
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+	req.Header.Set("Content-Type", "application/json")
+
+	code, body, err := suite.serveRepositoriesRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, string(body), "not-a-uuid")
+	suite.reg.RepositoryConfig.AssertNotCalled(t, "Fetch", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func (suite *ReposSuite) TestBulkDeleteNotFound() {
 	t := suite.T()
-	uuids := []string{"uuid-1", "uuid-2"}
+	uuids := []string{"6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01", "6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a02"}
 	daoError := ce.DaoError{
 		NotFound: true,
 	}
 
-	suite.reg.RepositoryConfig.On("Fetch", test_handler.MockOrgId, uuids[0]).Return(api.RepositoryResponse{
+	suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, uuids[0]).Return(api.RepositoryResponse{
 		Name:           "my repo",
 		URL:            "https://example.com/%d",
 		UUID:           uuids[0],
 		RepositoryUUID: uuids[0],
 	}, nil)
-	suite.reg.TaskInfo.On("IsSnapshotInProgress", test_handler.MockOrgId, uuids[0]).Return(false, nil)
-	suite.reg.RepositoryConfig.On("Fetch", test_handler.MockOrgId, uuids[0]).Return(api.RepositoryResponse{}, nil)
-	suite.reg.RepositoryConfig.On("Fetch", test_handler.MockOrgId, uuids[1]).Return(api.RepositoryResponse{}, &daoError)
+	suite.reg.TaskInfo.On("IsSnapshotInProgress", mock.Anything, test_handler.MockOrgId, uuids[0]).Return(false, nil)
+	suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, uuids[0]).Return(api.RepositoryResponse{}, nil)
+	suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, uuids[1]).Return(api.RepositoryResponse{}, &daoError)
 
 	body, err := json.Marshal(api.UUIDListRequest{UUIDs: uuids})
 	assert.NoError(t, err)
@@ -768,20 +1227,20 @@ func (suite *ReposSuite) TestBulkDeleteNotFound() {
 
 func (suite *ReposSuite) TestBulkDeleteSnapshotInProgress() {
 	t := suite.T()
-	uuids := []string{"inprogress-uuid", "uuid-1"}
+	uuids := []string{"6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a03", "6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01"}
 
 	for i := range uuids {
-		suite.reg.RepositoryConfig.On("Fetch", test_handler.MockOrgId, uuids[i]).Return(api.RepositoryResponse{
+		suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, uuids[i]).Return(api.RepositoryResponse{
 			Name:           fmt.Sprintf("my repo %d", i),
 			URL:            fmt.Sprintf("https://example.com/%d", i),
 			UUID:           uuids[i],
 			RepositoryUUID: uuids[i],
 		}, nil)
 	}
-	suite.reg.TaskInfo.On("IsSnapshotInProgress", test_handler.MockOrgId, uuids[0]).Return(true, nil)
-	suite.reg.TaskInfo.On("IsSnapshotInProgress", test_handler.MockOrgId, uuids[1]).Return(false, nil)
+	suite.reg.TaskInfo.On("IsSnapshotInProgress", mock.Anything, test_handler.MockOrgId, uuids[0]).Return(true, nil)
+	suite.reg.TaskInfo.On("IsSnapshotInProgress", mock.Anything, test_handler.MockOrgId, uuids[1]).Return(false, nil)
 
-	suite.reg.RepositoryConfig.On("BulkDelete", test_handler.MockOrgId, uuids).Return([]error{})
+	suite.reg.RepositoryConfig.On("BulkDelete", mock.Anything, test_handler.MockOrgId, uuids).Return([]error{})
 
 	body, err := json.Marshal(api.UUIDListRequest{UUIDs: uuids})
 	assert.NoError(t, err)
@@ -833,8 +1292,8 @@ func (suite *ReposSuite) TestFullUpdate() {
 	expected := createRepoRequest(*request.Name, *request.URL)
 	expected.FillDefaults()
 
-	suite.reg.RepositoryConfig.On("Update", test_handler.MockOrgId, uuid, expected).Return(false, nil)
-	suite.reg.RepositoryConfig.On("Fetch", test_handler.MockOrgId, uuid).Return(api.RepositoryResponse{
+	suite.reg.RepositoryConfig.On("Update", mock.Anything, test_handler.MockOrgId, uuid, expected).Return(false, nil)
+	suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, uuid).Return(api.RepositoryResponse{
 		Name:           "my repo",
 		URL:            "https://example.com",
 		UUID:           uuid,
@@ -866,15 +1325,15 @@ func (suite *ReposSuite) TestPartialUpdateUrlChange() {
 	request := createRepoRequest("Some Name", "http://someurl.com")
 	expected := createRepoRequest(*request.Name, *request.URL)
 
-	suite.reg.RepositoryConfig.On("Update", test_handler.MockOrgId, repoConfigUuid, expected).Return(true, nil)
-	suite.reg.RepositoryConfig.On("Fetch", test_handler.MockOrgId, repoConfigUuid).Return(api.RepositoryResponse{
+	suite.reg.RepositoryConfig.On("Update", mock.Anything, test_handler.MockOrgId, repoConfigUuid, expected).Return(true, nil)
+	suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, repoConfigUuid).Return(api.RepositoryResponse{
 		Name:           "my repo",
 		URL:            "https://example.com",
 		UUID:           repoConfigUuid,
 		RepositoryUUID: repoUuid,
 		Snapshot:       true,
 	}, nil)
-	suite.reg.TaskInfo.On("IsSnapshotInProgress", *expected.OrgID, repoUuid).Return(false, nil)
+	suite.reg.TaskInfo.On("IsSnapshotInProgress", mock.Anything, *expected.OrgID, repoUuid).Return(false, nil)
 
 	mockTaskClientEnqueueSnapshot(suite.tcMock, repoUuid)
 	mockTaskClientEnqueueIntrospect(suite.tcMock, "https://example.com", repoUuid)
@@ -893,6 +1352,44 @@ func (suite *ReposSuite) TestPartialUpdateUrlChange() {
 	assert.Equal(t, http.StatusOK, code)
 }
 
+func (suite *ReposSuite) TestPartialUpdateSnapshotTurnedOn() {
+	t := suite.T()
+
+	repoConfigUuid := "RepoConfigUuid"
+	repoUuid := "RepoUuid"
+	request := api.RepositoryRequest{Snapshot: pointy.Bool(true)}
+	existing := api.RepositoryResponse{
+		Name:           "my repo",
+		URL:            "https://example.com",
+		UUID:           repoConfigUuid,
+		RepositoryUUID: repoUuid,
+		Snapshot:       false,
+	}
+	updated := existing
+	updated.Snapshot = true
+
+	suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, repoConfigUuid).Return(existing, nil).Once()
+	suite.reg.RepositoryConfig.On("Update", mock.Anything, test_handler.MockOrgId, repoConfigUuid, request).Return(false, nil)
+	suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, repoConfigUuid).Return(updated, nil).Once()
+
+	// The repository is snapshotted right away although its url did not change
+	mockTaskClientEnqueueSnapshot(suite.tcMock, repoUuid)
+	mockTaskClientEnqueueIntrospect(suite.tcMock, "https://example.com", repoUuid)
+	body, err := json.Marshal(request)
+	if err != nil {
+		t.Error("Could not marshal JSON")
+	}
+
+	req := httptest.NewRequest(http.MethodPatch, fullRootPath()+"/repositories/"+repoConfigUuid,
+		bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, _, err := suite.serveRepositoriesRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, code)
+}
+
 func (suite *ReposSuite) TestPartialUpdate() {
 	t := suite.T()
 
@@ -901,8 +1398,8 @@ func (suite *ReposSuite) TestPartialUpdate() {
 	request := createRepoRequest("Some Name", "https://example.com")
 	expected := createRepoRequest(*request.Name, *request.URL)
 
-	suite.reg.RepositoryConfig.On("Update", test_handler.MockOrgId, uuid, expected).Return(true, nil)
-	suite.reg.RepositoryConfig.On("Fetch", test_handler.MockOrgId, uuid).Return(api.RepositoryResponse{
+	suite.reg.RepositoryConfig.On("Update", mock.Anything, test_handler.MockOrgId, uuid, expected).Return(true, nil)
+	suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, uuid).Return(api.RepositoryResponse{
 		Name:           "my repo",
 		URL:            "https://example.com",
 		UUID:           uuid,
@@ -949,9 +1446,9 @@ func (suite *ReposSuite) TestIntrospectRepository() {
 	mockTaskClientEnqueueIntrospect(suite.tcMock, "https://example.com", repoUuid)
 
 	// Fetch will filter the request by Org ID before updating
-	suite.reg.Repository.On("Update", repoUpdate).Return(nil).NotBefore(
-		suite.reg.Repository.On("FetchForUrl", repoResp.URL).Return(repo, nil).NotBefore(
-			suite.reg.RepositoryConfig.On("Fetch", test_handler.MockOrgId, uuid).Return(repoResp, nil),
+	suite.reg.Repository.On("Update", mock.Anything, repoUpdate).Return(nil).NotBefore(
+		suite.reg.Repository.On("FetchForUrl", mock.Anything, repoResp.URL).Return(repo, nil).NotBefore(
+			suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, uuid).Return(repoResp, nil),
 		),
 	)
 	body, err := json.Marshal(intReq)
@@ -987,8 +1484,8 @@ func (suite *ReposSuite) TestIntrospectRepositoryBeforeTimeLimit() {
 	repo := dao.Repository{UUID: "12345", LastIntrospectionTime: &now}
 
 	// Fetch will filter the request by Org ID before updating
-	suite.reg.Repository.On("FetchForUrl", repoResp.URL).Return(repo, nil).NotBefore(
-		suite.reg.RepositoryConfig.On("Fetch", test_handler.MockOrgId, uuid).Return(repoResp, nil),
+	suite.reg.Repository.On("FetchForUrl", mock.Anything, repoResp.URL).Return(repo, nil).NotBefore(
+		suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, uuid).Return(repoResp, nil),
 	)
 	body, err := json.Marshal(intReq)
 	if err != nil {
@@ -1005,6 +1502,154 @@ func (suite *ReposSuite) TestIntrospectRepositoryBeforeTimeLimit() {
 	assert.Equal(t, http.StatusBadRequest, code)
 }
 
+func (suite *ReposSuite) TestBulkIntrospectRepositories() {
+	t := suite.T()
+
+	t.Setenv("OPTIONS_INTROSPECT_API_TIME_LIMIT_SEC", "300")
+	config.Load()
+	tasking := config.Get().NewTaskingSystem
+	config.Get().NewTaskingSystem = true
+	defer func() { config.Get().NewTaskingSystem = tasking }()
+
+	failing := api.RepositoryResponse{UUID: "failingUuid", URL: "https://example.com/failing/", RepositoryUUID: "failingRepoUuid"}
+	recent := api.RepositoryResponse{UUID: "recentUuid", URL: "https://example.com/recent/", RepositoryUUID: "recentRepoUuid"}
+	pageData := api.PaginationData{Limit: MaxLimit, SortBy: "name", SkipCount: true}
+	filterData := api.FilterData{Status: config.StatusInvalid + "," + config.StatusUnavailable}
+	suite.reg.RepositoryConfig.On("List", mock.Anything, test_handler.MockOrgId, pageData, filterData).
+		Return(api.RepositoryCollectionResponse{Data: []api.RepositoryResponse{failing, recent}}, api.UncountedTotal, nil)
+
+	longAgo := time.Now().Add(-time.Hour)
+	now := time.Now()
+	suite.reg.Repository.On("FetchForUrl", mock.Anything, failing.URL).Return(dao.Repository{UUID: "failingRepoUuid", LastIntrospectionTime: &longAgo}, nil)
+	suite.reg.Repository.On("FetchForUrl", mock.Anything, recent.URL).Return(dao.Repository{UUID: "recentRepoUuid", LastIntrospectionTime: &now}, nil)
+
+	// Only the repository not introspected recently is queued
+	suite.reg.Repository.On("Update", mock.Anything, dao.RepositoryUpdate{
+		UUID: "failingRepoUuid", FailedIntrospectionsCount: pointy.Int(0), Status: pointy.String(config.StatusPending),
+	}).Return(nil).Once()
+	taskID := uuid.New()
+	suite.tcMock.On("Enqueue", mock.MatchedBy(func(task queue.Task) bool {
+		return task.Typename == payloads.Introspect && task.RepositoryUUID == failing.RepositoryUUID
+	})).Return(taskID, nil).Once()
+
+	body, err := json.Marshal(api.RepositoryIntrospectRequest{ResetCount: true})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, fullRootPath()+"/repositories/introspect/?status=Invalid,Unavailable", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, respBody, err := suite.serveRepositoriesRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	response := api.RepositoryBulkIntrospectResponse{}
+	require.NoError(t, json.Unmarshal(respBody, &response))
+	assert.Equal(t, []string{failing.UUID}, response.UUIDs)
+	assert.Equal(t, []string{taskID.String()}, response.TaskUUIDs)
+}
+
+func (suite *ReposSuite) TestStreamEvents() {
+	t := suite.T()
+	repositoryEventsInterval = time.Millisecond
+	defer func() { repositoryEventsInterval = 5 * time.Second }()
+
+	event := api.RepositoryEventResponse{
+		UUID:      "eventUuid",
+		Type:      "repository-created",
+		CreatedAt: time.Now().Add(time.Hour),
+		Data:      []byte(`{"repositories":[]}`),
+	}
+	old := api.RepositoryEventResponse{UUID: "oldUuid", Type: "repository-deleted", CreatedAt: time.Now().Add(-time.Hour)}
+	// Events read again are only sent once, and events older than the stream are not sent
+	suite.reg.RepositoryConfig.On("ListEvents", mock.Anything, test_handler.MockOrgId, mock.Anything).Return([]api.RepositoryEventResponse{old, event}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, fullRootPath()+"/repositories/events", nil).WithContext(ctx)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveRepositoriesRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	assert.Equal(t, "event: repository-created\ndata: "+string(data)+"\n\n", string(body))
+}
+
+func (suite *ReposSuite) TestCloneRepository() {
+	t := suite.T()
+
+	uuid := "abcadaba"
+	source := api.RepositoryResponse{
+		Name:                 "my repo",
+		URL:                  "https://example.com/8/",
+		UUID:                 uuid,
+		DistributionVersions: []string{config.El8},
+		DistributionArch:     config.X8664,
+		GpgKey:               "gpg key",
+		MetadataVerification: true,
+		ContentType:          config.ContentTypeBinary,
+		Username:             "user",
+	}
+	credentials := dao.RepositoryCredentials{Username: "user", Password: "secret"}
+	suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, uuid).Return(source, nil)
+	suite.reg.RepositoryConfig.On("FetchCredentials", mock.Anything, test_handler.MockOrgId, uuid).Return(credentials, nil)
+	suite.reg.RepositoryConfig.On("FetchProxy", mock.Anything, test_handler.MockOrgId, uuid).Return(dao.RepositoryProxy{}, nil)
+
+	// The URL, GPG key and credentials are copied when not changed
+	clone := api.RepositoryCloneRequest{URL: pointy.String("https://example.com/9/"), DistributionVersions: &[]string{config.El9}}
+	expectedRequest := api.RepositoryRequest{
+		Name:                 pointy.String("my repo (copy)"),
+		URL:                  pointy.String("https://example.com/9/"),
+		DistributionVersions: &[]string{config.El9},
+		DistributionArch:     pointy.String(config.X8664),
+		GpgKey:               pointy.String("gpg key"),
+		MetadataVerification: pointy.Bool(true),
+		Snapshot:             pointy.Bool(false),
+		ContentType:          pointy.String(config.ContentTypeBinary),
+		AccountID:            pointy.String(test_handler.MockAccountNumber),
+		OrgID:                pointy.String(test_handler.MockOrgId),
+		ProxyURL:             pointy.String(""),
+		ProxyUsername:        pointy.String(""),
+		ProxyPassword:        pointy.String(""),
+		Username:             pointy.String("user"),
+		Password:             pointy.String("secret"),
+	}
+	expected := api.RepositoryResponse{Name: "my repo (copy)", URL: "https://example.com/9/", UUID: "clonedUuid", RepositoryUUID: "repoUuid"}
+	suite.reg.RepositoryConfig.On("Create", mock.Anything, expectedRequest).Return(expected, nil)
+	mockTaskClientEnqueueIntrospect(suite.tcMock, expected.URL, expected.RepositoryUUID)
+
+	body, err := json.Marshal(clone)
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, fullRootPath()+"/repositories/"+uuid+"/clone/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveRepositoriesRouter(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, code)
+
+	var response api.RepositoryResponse
+	require.NoError(t, json.Unmarshal(body, &response))
+	assert.Equal(t, expected.UUID, response.UUID)
+}
+
+func (suite *ReposSuite) TestCloneRepositoryNotFound() {
+	t := suite.T()
+
+	uuid := "abcadaba"
+	daoError := ce.DaoError{NotFound: true, Message: "Not found"}
+	suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, uuid).Return(api.RepositoryResponse{}, &daoError)
+
+	req := httptest.NewRequest(http.MethodPost, fullRootPath()+"/repositories/"+uuid+"/clone/", nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, _, err := suite.serveRepositoriesRouter(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
 func TestReposSuite(t *testing.T) {
 	suite.Run(t, new(ReposSuite))
 }
@@ -25,6 +25,7 @@ func RegisterRepositoryParameterRoutes(engine *echo.Group, dao *dao.DaoRegistry)
 	rph := RepositoryParameterHandler{dao: *dao}
 
 	addRoute(engine, http.MethodGet, "/repository_parameters/", rph.listParameters, rbac.RbacVerbRead)
+	addRoute(engine, http.MethodGet, "/repository_parameters/defaults/", rph.listDefaults, rbac.RbacVerbRead)
 	addRoute(engine, http.MethodPost, "/repository_parameters/external_gpg_key/", rph.fetchGpgKey, rbac.RbacVerbWrite)
 	addRoute(engine, http.MethodPost, "/repository_parameters/validate/", rph.validate, rbac.RbacVerbWrite)
 }
@@ -78,6 +79,47 @@ func (rh *RepositoryParameterHandler) listParameters(c echo.Context) error {
 	return c.JSON(200, api.RepositoryParameterResponse{
 		DistributionVersions: config.DistributionVersions[:],
 		DistributionArches:   config.DistributionArches[:],
+		ContentTypes:         config.ContentTypes[:],
+	})
+}
+
+// ListRepositoryDefaults godoc
+// @Summary      List Repository Defaults
+// @ID           listRepositoryDefaults
+// @Description  Get the values used for the attributes of a repository created without them, including the defaults set in the organization settings, and the limits of the repositories API.
+// @Tags         repositories
+// @Produce      json
+// @Success      200 {object} api.RepositoryDefaultsResponse
+// @Failure      401 {object} ce.ErrorResponse
+// @Failure      500 {object} ce.ErrorResponse
+// @Router       /repository_parameters/defaults/ [get]
+func (rph *RepositoryParameterHandler) listDefaults(c echo.Context) error {
+	_, orgID := getAccountIdOrgId(c)
+	settings, err := rph.dao.OrgSettings.Fetch(c.Request().Context(), orgID)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error fetching settings", err)
+	}
+
+	// The defaults are those filled in a request without any value, as done when creating repositories
+	defaults := api.RepositoryRequest{}
+	defaults.FillOrgDefaults(settings)
+
+	return c.JSON(http.StatusOK, api.RepositoryDefaultsResponse{
+		DistributionVersions: *defaults.DistributionVersions,
+		DistributionArch:     *defaults.DistributionArch,
+		MetadataVerification: *defaults.MetadataVerification,
+		ContentType:          config.ContentTypeBinary,
+		Snapshot:             false,
+		SnapshotsAllowed:     CheckSnapshotAccessible(c.Request().Context()) == nil,
+		Limits: api.RepositoryLimits{
+			BulkCreate:      BulkCreateLimit,
+			BulkDelete:      BulkDeleteLimit,
+			BulkExport:      BulkExportLimit,
+			BulkImport:      BulkImportLimit,
+			BulkUpdate:      BulkUpdateLimit,
+			DefaultPageSize: DefaultLimit,
+			MaxPageSize:     MaxLimit,
+		},
 	})
 }
 
@@ -121,6 +163,9 @@ func (rph *RepositoryParameterHandler) validate(c echo.Context) error {
 			excludedUUIDs = append(excludedUUIDs, *validationParams[i].UUID)
 		}
 	}
+	if err := validateUUIDs("uuid", excludedUUIDs...); err != nil {
+		return err
+	}
 
 	// Use go routine here to reduce the api call time length.
 	// Each url validation can take seconds to fail in case of a timeout.
@@ -130,7 +175,7 @@ func (rph *RepositoryParameterHandler) validate(c echo.Context) error {
 	for i := 0; i < len(validationParams); i++ {
 		go func(slot int, validationParam api.RepositoryValidationRequest) {
 			defer wg.Done()
-			response, err := rph.dao.RepositoryConfig.ValidateParameters(orgID, validationParam, excludedUUIDs)
+			response, err := rph.dao.RepositoryConfig.ValidateParameters(c.Request().Context(), orgID, validationParam, excludedUUIDs)
 			if err == nil {
 				validationResponse[slot] = response
 			} else {
@@ -143,7 +188,7 @@ func (rph *RepositoryParameterHandler) validate(c echo.Context) error {
 	// Check for any errors and return the first one.  Errors are fatal, not errors retrieving metadata.
 	for i := 0; i < len(errors); i++ {
 		if errors[i] != nil {
-			return c.JSON(ce.HttpCodeForDaoError(errors[i]), errors[i].Error())
+			return ce.NewErrorResponseFromError("Error validating repository", errors[i])
 		}
 	}
 
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"github.com/stretchr/testify/mock"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -67,9 +68,42 @@ func (s *RepositoryParameterSuite) TestListParams() {
 
 	assert.Equal(t, http.StatusOK, code)
 	assert.NotEmpty(t, response.DistributionArches)
+	assert.NotEmpty(t, response.ContentTypes)
 	assert.NotEmpty(t, response.DistributionVersions)
 }
 
+func (s *RepositoryParameterSuite) TestListDefaults() {
+	t := s.T()
+	config.Get().Features.Snapshots.Enabled = false
+	defer resetFeatures()
+
+	settings := defaultOrgSettings()
+	settings.DefaultDistributionArch = config.X8664
+	s.mockDao.OrgSettings.On("Fetch", mock.Anything, test_handler.MockOrgId).Return(settings, nil)
+
+	path := fmt.Sprintf("%s/repository_parameters/defaults/", fullRootPath())
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	setHeaders(t, req)
+	code, body, err := s.serveRepositoryParametersRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	response := api.RepositoryDefaultsResponse{}
+	err = json.Unmarshal(body, &response)
+	assert.Nil(t, err)
+
+	// Matches the values filled when creating a repository
+	expected := api.RepositoryRequest{}
+	expected.FillDefaults()
+	assert.Equal(t, config.X8664, response.DistributionArch)
+	assert.Equal(t, *expected.DistributionVersions, response.DistributionVersions)
+	assert.Equal(t, *expected.MetadataVerification, response.MetadataVerification)
+	assert.Equal(t, config.ContentTypeBinary, response.ContentType)
+	assert.False(t, response.SnapshotsAllowed)
+	assert.Equal(t, BulkCreateLimit, response.Limits.BulkCreate)
+	assert.Equal(t, MaxLimit, response.Limits.MaxPageSize)
+}
+
 func (s *RepositoryParameterSuite) TestValidate() {
 	t := s.T()
 
@@ -78,11 +112,11 @@ func (s *RepositoryParameterSuite) TestValidate() {
 	requestBody := []api.RepositoryValidationRequest{
 		{
 			Name: pointy.String("myValidateRepo"),
-			UUID: pointy.String("steve-the-id"),
+			UUID: pointy.String("6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01"),
 		},
 		{
 			URL:  pointy.String("http://myrepo.com"),
-			UUID: pointy.String("paul-the-id"),
+			UUID: pointy.String("6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a02"),
 		},
 		{},
 	}
@@ -117,9 +151,9 @@ func (s *RepositoryParameterSuite) TestValidate() {
 	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(requestJson))
 	setHeaders(t, req)
 
-	s.mockDao.RepositoryConfig.Mock.On("ValidateParameters", test_handler.MockOrgId, requestBody[0], []string{"steve-the-id", "paul-the-id"}).Return(expectedResponse[0], nil)
-	s.mockDao.RepositoryConfig.Mock.On("ValidateParameters", test_handler.MockOrgId, requestBody[1], []string{"steve-the-id", "paul-the-id"}).Return(expectedResponse[1], nil)
-	s.mockDao.RepositoryConfig.Mock.On("ValidateParameters", test_handler.MockOrgId, requestBody[2], []string{"steve-the-id", "paul-the-id"}).Return(expectedResponse[2], nil)
+	s.mockDao.RepositoryConfig.Mock.On("ValidateParameters", test_handler.MockOrgId, requestBody[0], []string{"6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01", "6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a02"}).Return(expectedResponse[0], nil)
+	s.mockDao.RepositoryConfig.Mock.On("ValidateParameters", test_handler.MockOrgId, requestBody[1], []string{"6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01", "6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a02"}).Return(expectedResponse[1], nil)
+	s.mockDao.RepositoryConfig.Mock.On("ValidateParameters", test_handler.MockOrgId, requestBody[2], []string{"6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01", "6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a02"}).Return(expectedResponse[2], nil)
 
 	code, body, err := s.serveRepositoryParametersRouter(req)
 
@@ -21,7 +21,10 @@ func RegisterRepositoryRpmRoutes(engine *echo.Group, rDao *dao.DaoRegistry) {
 	}
 
 	addRoute(engine, http.MethodGet, "/repositories/:uuid/rpms", rh.listRepositoriesRpm, rbac.RbacVerbRead)
+	addRoute(engine, http.MethodGet, "/repositories/:uuid/rpms/official_overlaps/", rh.officialOverlaps, rbac.RbacVerbRead)
 	addRoute(engine, http.MethodPost, "/rpms/names", rh.searchRpmByName, rbac.RbacVerbRead)
+	addRoute(engine, http.MethodPost, "/rpms/search/", rh.searchPackages, rbac.RbacVerbRead)
+	addRoute(engine, http.MethodPost, "/rpms/dependencies/", rh.dependencyClosure, rbac.RbacVerbRead)
 }
 
 // searchRpmByName godoc
@@ -45,9 +48,12 @@ func (rh *RepositoryRpmHandler) searchRpmByName(c echo.Context) error {
 	if err := c.Bind(&dataInput); err != nil {
 		return ce.NewErrorResponse(http.StatusBadRequest, "Error binding parameters", err.Error())
 	}
+	if err := validateUUIDs("uuids", dataInput.UUIDs...); err != nil {
+		return err
+	}
 	rh.searchRpmPreprocessInput(&dataInput)
 
-	apiResponse, err := rh.Dao.Rpm.Search(orgId, dataInput)
+	apiResponse, err := rh.Dao.Rpm.Search(c.Request().Context(), orgId, dataInput)
 	if err != nil {
 		return ce.NewErrorResponse(http.StatusInternalServerError, "Error searching RPMs", err.Error())
 	}
@@ -70,6 +76,104 @@ func (rh *RepositoryRpmHandler) searchRpmPreprocessInput(input *api.SearchRpmReq
 	}
 }
 
+// searchPackages godoc
+// @Summary      Search RPMs by NEVRA or capability
+// @ID           searchPackages
+// @Description  Search RPMs of a given list of repositories as URLs or UUIDs by exact NEVRA, provided capability or required capability
+// @Tags         repositories,rpms
+// @Accept       json
+// @Produce      json
+// @Param        body  body   api.SearchPackageRequest  true  "request body"
+// @Success      200 {object} []api.SearchPackageResponse
+// @Failure      400 {object} ce.ErrorResponse
+// @Failure      401 {object} ce.ErrorResponse
+// @Failure      415 {object} ce.ErrorResponse
+// @Failure      500 {object} ce.ErrorResponse
+// @Router       /rpms/search/ [post]
+func (rh *RepositoryRpmHandler) searchPackages(c echo.Context) error {
+	_, orgId := getAccountIdOrgId(c)
+	dataInput := api.SearchPackageRequest{}
+	if err := c.Bind(&dataInput); err != nil {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error binding parameters", err.Error())
+	}
+	if err := validateUUIDs("uuids", dataInput.UUIDs...); err != nil {
+		return err
+	}
+	for i, url := range dataInput.URLs {
+		dataInput.URLs[i] = removeEndSuffix(url, "/")
+	}
+
+	apiResponse, err := rh.Dao.Rpm.SearchPackages(c.Request().Context(), orgId, dataInput)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error searching RPMs", err)
+	}
+
+	return c.JSON(200, apiResponse)
+}
+
+// dependencyClosure godoc
+// @Summary      Resolve RPM dependencies
+// @ID           dependencyClosure
+// @Description  Compute the packages needed to install a list of packages from a given list of repositories as URLs or UUIDs, and the dependencies none of them provide
+// @Tags         repositories,rpms
+// @Accept       json
+// @Produce      json
+// @Param        body  body   api.DependencyClosureRequest  true  "request body"
+// @Success      200 {object} api.DependencyClosureResponse
+// @Failure      400 {object} ce.ErrorResponse
+// @Failure      401 {object} ce.ErrorResponse
+// @Failure      415 {object} ce.ErrorResponse
+// @Failure      500 {object} ce.ErrorResponse
+// @Router       /rpms/dependencies/ [post]
+func (rh *RepositoryRpmHandler) dependencyClosure(c echo.Context) error {
+	_, orgId := getAccountIdOrgId(c)
+	dataInput := api.DependencyClosureRequest{}
+	if err := c.Bind(&dataInput); err != nil {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error binding parameters", err.Error())
+	}
+	if err := validateUUIDs("uuids", dataInput.UUIDs...); err != nil {
+		return err
+	}
+	for i, url := range dataInput.URLs {
+		dataInput.URLs[i] = removeEndSuffix(url, "/")
+	}
+
+	apiResponse, err := rh.Dao.Rpm.DependencyClosure(c.Request().Context(), orgId, dataInput)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error resolving RPM dependencies", err)
+	}
+
+	return c.JSON(200, apiResponse)
+}
+
+// officialOverlaps godoc
+// @Summary      List packages overlapping official repositories
+// @ID           listOfficialOverlaps
+// @Description  Compare the packages of a repository with the ones of the Red Hat repositories of its versions and architecture, listing the packages in both with the version of each. A repository package older than the official one is not installed by dnf, a newer one overrides it.
+// @Tags         repositories,rpms
+// @Produce      json
+// @Param		 uuid	path string true "Identifier of the Repository"
+// @Success      200 {object} api.OfficialOverlapsResponse
+// @Failure      400 {object} ce.ErrorResponse
+// @Failure      401 {object} ce.ErrorResponse
+// @Failure      404 {object} ce.ErrorResponse
+// @Failure      500 {object} ce.ErrorResponse
+// @Router       /repositories/{uuid}/rpms/official_overlaps/ [get]
+func (rh *RepositoryRpmHandler) officialOverlaps(c echo.Context) error {
+	_, orgId := getAccountIdOrgId(c)
+	uuid := c.Param("uuid")
+	if err := validateUUIDs("uuid", uuid); err != nil {
+		return err
+	}
+
+	apiResponse, err := rh.Dao.Rpm.OfficialOverlaps(c.Request().Context(), orgId, uuid)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error comparing with official repositories", err)
+	}
+
+	return c.JSON(200, apiResponse)
+}
+
 // listRepositoriesRpm godoc
 // @Summary      List Repositories RPMs
 // @ID           listRepositoriesRpms
@@ -99,9 +203,9 @@ func (rh *RepositoryRpmHandler) listRepositoriesRpm(c echo.Context) error {
 	page := ParsePagination(c)
 
 	// Request record from database
-	apiResponse, total, err := rh.Dao.Rpm.List(orgId, rpmInput.UUID, page.Limit, page.Offset, rpmInput.Search, rpmInput.SortBy)
+	apiResponse, total, err := rh.Dao.Rpm.List(c.Request().Context(), orgId, rpmInput.UUID, page.Limit, page.Offset, rpmInput.Search, rpmInput.SortBy)
 	if err != nil {
-		return ce.NewErrorResponse(ce.HttpCodeForDaoError(err), "Error listing RPMs", err.Error())
+		return ce.NewErrorResponseFromError("Error listing RPMs", err)
 	}
 
 	return c.JSON(200, setCollectionResponseMetadata(&apiResponse, c, total))
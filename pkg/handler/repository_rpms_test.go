@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/stretchr/testify/mock"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -150,7 +151,7 @@ func (suite *RpmSuite) TestListRepositoryRpms() {
 		switch {
 		case testCase.Expected.Code >= 200 && testCase.Expected.Code < 300:
 			{
-				suite.dao.Rpm.On("List", test_handler.MockOrgId, testCase.Given.UUID, testCase.Given.Page.Limit,
+				suite.dao.Rpm.On("List", mock.Anything, test_handler.MockOrgId, testCase.Given.UUID, testCase.Given.Page.Limit,
 					testCase.Given.Page.Offset, testCase.Given.Search, testCase.Given.Page.SortBy).
 					Return(api.RepositoryRpmCollectionResponse{
 						Data: []api.RepositoryRpm{
@@ -166,13 +167,13 @@ func (suite *RpmSuite) TestListRepositoryRpms() {
 			}
 		case testCase.Expected.Code == http.StatusInternalServerError:
 			{
-				suite.dao.Rpm.On("List", test_handler.MockOrgId, testCase.Given.UUID, testCase.Given.Page.Limit,
+				suite.dao.Rpm.On("List", mock.Anything, test_handler.MockOrgId, testCase.Given.UUID, testCase.Given.Page.Limit,
 					testCase.Given.Page.Offset, testCase.Given.Search, testCase.Given.Page.SortBy).
 					Return(api.RepositoryRpmCollectionResponse{}, int64(0), echo.NewHTTPError(http.StatusInternalServerError, "ISE"))
 			}
 		case testCase.Expected.Code == http.StatusNotFound:
 			{
-				suite.dao.Rpm.On("List", test_handler.MockOrgId, testCase.Given.UUID, testCase.Given.Page.Limit,
+				suite.dao.Rpm.On("List", mock.Anything, test_handler.MockOrgId, testCase.Given.UUID, testCase.Given.Page.Limit,
 					testCase.Given.Page.Offset, testCase.Given.Search, testCase.Given.Page.SortBy).
 					Return(api.RepositoryRpmCollectionResponse{}, int64(0), &ce.DaoError{NotFound: true})
 			}
@@ -354,7 +355,7 @@ func (suite *RpmSuite) TestSearchRpmByName() {
 			},
 			Expected: TestCaseExpected{
 				Code: http.StatusBadRequest,
-				Body: "{\"errors\":[{\"status\":400,\"title\":\"Error binding parameters\",\"detail\":\"code=400, message=unexpected EOF, internal=unexpected EOF\"}]}\n",
+				Body: "{\"errors\":[{\"status\":400,\"code\":\"bad_request\",\"title\":\"Error binding parameters\",\"detail\":\"code=400, message=unexpected EOF, internal=unexpected EOF\"}]}\n",
 			},
 		},
 		{
@@ -365,7 +366,7 @@ func (suite *RpmSuite) TestSearchRpmByName() {
 			},
 			Expected: TestCaseExpected{
 				Code: http.StatusInternalServerError,
-				Body: "{\"errors\":[{\"status\":500,\"title\":\"Error searching RPMs\",\"detail\":\"code=500, message=must contain at least 1 URL or 1 UUID\"}]}\n",
+				Body: "{\"errors\":[{\"status\":500,\"code\":\"internal_error\",\"title\":\"Error searching RPMs\",\"detail\":\"code=500, message=must contain at least 1 URL or 1 UUID\"}]}\n",
 			},
 		},
 	}
@@ -380,7 +381,7 @@ func (suite *RpmSuite) TestSearchRpmByName() {
 				var bodyRequest api.SearchRpmRequest
 				err := json.Unmarshal([]byte(testCase.Given.Body), &bodyRequest)
 				require.NoError(t, err)
-				suite.dao.Rpm.On("Search", test_handler.MockOrgId, bodyRequest).
+				suite.dao.Rpm.On("Search", mock.Anything, test_handler.MockOrgId, bodyRequest).
 					Return([]api.SearchRpmResponse{
 						{
 							PackageName: "demo-1",
@@ -405,7 +406,7 @@ func (suite *RpmSuite) TestSearchRpmByName() {
 				err := json.Unmarshal([]byte(testCase.Given.Body), &bodyRequest)
 				bodyRequest.Limit = pointy.Int(api.SearchRpmRequestLimitDefault)
 				require.NoError(t, err)
-				suite.dao.Rpm.On("Search", test_handler.MockOrgId, bodyRequest).
+				suite.dao.Rpm.On("Search", mock.Anything, test_handler.MockOrgId, bodyRequest).
 					Return(nil, echo.NewHTTPError(http.StatusInternalServerError, "must contain at least 1 URL or 1 UUID"))
 			}
 		}
@@ -432,6 +433,120 @@ func (suite *RpmSuite) TestSearchRpmByName() {
 	}
 }
 
+func (suite *RpmSuite) TestSearchPackages() {
+	t := suite.T()
+	path := fmt.Sprintf("%s/rpms/search/", fullRootPath())
+
+	expected := []api.SearchPackageResponse{
+		{
+			Name:          "httpd",
+			Arch:          "x86_64",
+			Version:       "2.4.57",
+			Release:       "5.el9",
+			Epoch:         0,
+			Summary:       "Apache HTTP Server",
+			Checksum:      "SHA256:abc",
+			RepositoryURL: "https://www.example.test",
+		},
+	}
+	suite.dao.Rpm.On("SearchPackages", mock.Anything, test_handler.MockOrgId, api.SearchPackageRequest{
+		URLs:     []string{"https://www.example.test"},
+		Provides: "webserver",
+	}).Return(expected, nil)
+
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"urls":["https://www.example.test/"],"provides":"webserver"}`))
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+	req.Header.Set("Content-Type", "application/json")
+
+	code, body, err := suite.serveRpmsRouter(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	var response []api.SearchPackageResponse
+	require.NoError(t, json.Unmarshal(body, &response))
+	assert.Equal(t, expected, response)
+}
+
+func (suite *RpmSuite) TestSearchPackagesInvalid() {
+	t := suite.T()
+	path := fmt.Sprintf("%s/rpms/search/", fullRootPath())
+
+	suite.dao.Rpm.On("SearchPackages", mock.Anything, test_handler.MockOrgId, api.SearchPackageRequest{
+		UUIDs: []string{"6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01"},
+		Nevra: "httpd",
+	}).Return(nil, &ce.DaoError{BadValidation: true, Message: "invalid nevra httpd"})
+
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"uuids":["6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01"],"nevra":"httpd"}`))
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+	req.Header.Set("Content-Type", "application/json")
+
+	code, _, err := suite.serveRpmsRouter(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func (suite *RpmSuite) TestDependencyClosure() {
+	t := suite.T()
+	path := fmt.Sprintf("%s/rpms/dependencies/", fullRootPath())
+
+	expected := api.DependencyClosureResponse{
+		Packages: []api.SearchPackageResponse{
+			{Name: "httpd", Arch: "x86_64", Version: "2.4.57", Release: "5.el9", RepositoryURL: "https://www.example.test"},
+		},
+		Unresolved: []api.UnresolvedDependency{
+			{Name: "httpd-filesystem", RequiredBy: []string{"httpd"}},
+		},
+	}
+	suite.dao.Rpm.On("DependencyClosure", mock.Anything, test_handler.MockOrgId, api.DependencyClosureRequest{
+		URLs:     []string{"https://www.example.test"},
+		Packages: []string{"httpd"},
+	}).Return(expected, nil)
+
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"urls":["https://www.example.test/"],"packages":["httpd"]}`))
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+	req.Header.Set("Content-Type", "application/json")
+
+	code, body, err := suite.serveRpmsRouter(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	var response api.DependencyClosureResponse
+	require.NoError(t, json.Unmarshal(body, &response))
+	assert.Equal(t, expected, response)
+}
+
+func (suite *RpmSuite) TestOfficialOverlaps() {
+	t := suite.T()
+	repoUUID := "4b2b2e3a-9d5d-4e2b-8f5e-0c5a6b0e2c11"
+	path := fmt.Sprintf("%s/repositories/%s/rpms/official_overlaps/", fullRootPath(), repoUUID)
+
+	expected := api.OfficialOverlapsResponse{
+		OfficialRepositories: []string{"https://cdn.redhat.com/content/dist/rhel9/9.2/x86_64/baseos/os"},
+		Overlaps: []api.PackageOverlap{
+			{Name: "bash", Arch: "x86_64", Version: "5.1.8-5.el9", OfficialVersion: "5.1.8-6.el9_1",
+				OfficialURL: "https://cdn.redhat.com/content/dist/rhel9/9.2/x86_64/baseos/os", Comparison: api.PackageOverlapOlder},
+		},
+	}
+	suite.dao.Rpm.On("OfficialOverlaps", mock.Anything, test_handler.MockOrgId, repoUUID).Return(expected, nil)
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveRpmsRouter(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	var response api.OfficialOverlapsResponse
+	require.NoError(t, json.Unmarshal(body, &response))
+	assert.Equal(t, expected, response)
+
+	req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("%s/repositories/not-a-uuid/rpms/official_overlaps/", fullRootPath()), nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+	code, _, err = suite.serveRpmsRouter(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
 func TestRpmSuite(t *testing.T) {
 	suite.Run(t, new(RpmSuite))
 }
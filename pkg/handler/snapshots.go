@@ -2,7 +2,11 @@ package handler
 
 import (
 	"net/http"
+	"path"
+	"strings"
 
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/config"
 	"github.com/content-services/content-sources-backend/pkg/dao"
 	ce "github.com/content-services/content-sources-backend/pkg/errors"
 	"github.com/content-services/content-sources-backend/pkg/rbac"
@@ -23,6 +27,9 @@ func RegisterSnapshotRoutes(group *echo.Group, daoReg *dao.DaoRegistry) {
 
 	sh := SnapshotHandler{DaoRegistry: *daoReg}
 	addRoute(group, http.MethodGet, "/repositories/:uuid/snapshots/", sh.listSnapshots, rbac.RbacVerbRead)
+	addRoute(group, http.MethodPost, "/snapshots/for_date/", sh.listSnapshotsByDate, rbac.RbacVerbRead)
+	addRoute(group, http.MethodGet, "/snapshots/storage/", sh.getStorageUsage, rbac.RbacVerbRead)
+	addStreamRoute(group, http.MethodGet, "/repositories/:uuid/snapshots/:snapshot_uuid/repodata/:file", sh.getRepodata, rbac.RbacVerbRead)
 }
 
 // Get Snapshots godoc
@@ -42,9 +49,109 @@ func (sh *SnapshotHandler) listSnapshots(c echo.Context) error {
 	uuid := c.Param("uuid")
 	pageData := ParsePagination(c)
 	filterData := ParseFilters(c)
-	snapshots, totalSnaps, err := sh.DaoRegistry.Snapshot.List(uuid, pageData, filterData)
+	_, orgID := getAccountIdOrgId(c)
+
+	// Repositories pending deletion are not found, even though their snapshots still exist
+	if _, err := sh.DaoRegistry.RepositoryConfig.Fetch(c.Request().Context(), orgID, uuid); err != nil {
+		return ce.NewErrorResponseFromError("Error fetching repository", err)
+	}
+	snapshots, totalSnaps, err := sh.DaoRegistry.Snapshot.List(c.Request().Context(), uuid, pageData, filterData)
 	if err != nil {
-		return ce.NewErrorResponse(ce.HttpCodeForDaoError(err), "Error listing repositories", err.Error())
+		return ce.NewErrorResponseFromError("Error listing repositories", err)
 	}
 	return c.JSON(200, setCollectionResponseMetadata(&snapshots, c, totalSnaps))
 }
+
+// Post Snapshots By Date godoc
+// @Summary      Get nearest snapshot by date for a list of repositories.
+// @ID           listSnapshotsByDate
+// @Description  Get the latest snapshot taken on or before the date for each repository, and whether it was taken on that date.
+// @Tags         snapshots
+// @Accept       json
+// @Produce      json
+// @Param        body  body     api.ListSnapshotByDateRequest  true  "request body"
+// @Success      200 {object} api.ListSnapshotByDateResponse
+// @Failure      400 {object} ce.ErrorResponse
+// @Failure      401 {object} ce.ErrorResponse
+// @Failure      404 {object} ce.ErrorResponse
+// @Failure      500 {object} ce.ErrorResponse
+// @Router       /snapshots/for_date/ [post]
+func (sh *SnapshotHandler) listSnapshotsByDate(c echo.Context) error {
+	var listSnapshotByDateParams api.ListSnapshotByDateRequest
+	_, orgID := getAccountIdOrgId(c)
+
+	if err := c.Bind(&listSnapshotByDateParams); err != nil {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error binding parameters", err.Error())
+	}
+	if len(listSnapshotByDateParams.RepositoryUUIDS) == 0 {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Invalid parameters", "repository_uuids must not be empty")
+	}
+	if err := validateUUIDs("repository_uuids", listSnapshotByDateParams.RepositoryUUIDS...); err != nil {
+		return err
+	}
+	if listSnapshotByDateParams.Date.IsZero() {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Invalid parameters", "date is required")
+	}
+
+	response, err := sh.DaoRegistry.Snapshot.FetchSnapshotsByDateAndRepository(c.Request().Context(), orgID, listSnapshotByDateParams)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error fetching snapshots", err)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// Get Snapshot Storage godoc
+// @Summary      Get the storage used by snapshots
+// @ID           getSnapshotStorage
+// @Description  Get the estimated bytes stored for the snapshots of the organization, in total and by repository.
+// @Tags         snapshots
+// @Produce      json
+// @Success      200 {object} api.SnapshotStorageResponse
+// @Failure      401 {object} ce.ErrorResponse
+// @Failure      500 {object} ce.ErrorResponse
+// @Router       /snapshots/storage/ [get]
+func (sh *SnapshotHandler) getStorageUsage(c echo.Context) error {
+	_, orgID := getAccountIdOrgId(c)
+
+	response, err := sh.DaoRegistry.Snapshot.StorageUsage(c.Request().Context(), orgID)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error fetching snapshot storage", err)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// Get Snapshot Repodata godoc
+// @Summary      Get a repodata file of a snapshot
+// @ID           getSnapshotRepodata
+// @Description  Serve a file of the repodata directory of a snapshot, so that clients only need to reach the API host.
+// @Tags         snapshots
+// @Produce      octet-stream
+// @Param  uuid           path  string  true  "Identifier of the Repository"
+// @Param  snapshot_uuid  path  string  true  "Identifier of the Snapshot"
+// @Param  file           path  string  true  "Name of the repodata file, such as repomd.xml"
+// @Success      200
+// @Failure      400 {object} ce.ErrorResponse
+// @Failure      401 {object} ce.ErrorResponse
+// @Failure      404 {object} ce.ErrorResponse
+// @Failure      500 {object} ce.ErrorResponse
+// @Router       /repositories/{uuid}/snapshots/{snapshot_uuid}/repodata/{file} [get]
+func (sh *SnapshotHandler) getRepodata(c echo.Context) error {
+	uuid := c.Param("uuid")
+	snapshotUUID := c.Param("snapshot_uuid")
+	file := c.Param("file")
+	_, orgID := getAccountIdOrgId(c)
+
+	if file == "" || file != path.Base(file) || strings.HasPrefix(file, ".") {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Invalid parameters", "file must be the name of a repodata file")
+	}
+	if _, err := sh.DaoRegistry.RepositoryConfig.Fetch(c.Request().Context(), orgID, uuid); err != nil {
+		return ce.NewErrorResponseFromError("Error fetching repository", err)
+	}
+	snapshot, err := sh.DaoRegistry.Snapshot.Fetch(c.Request().Context(), uuid, snapshotUUID)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error fetching snapshot", err)
+	}
+
+	fileURL := strings.TrimSuffix(config.PulpContentURL(snapshot.RepositoryPath), "/") + "/repodata/" + file
+	return proxyContent(c, fileURL)
+}
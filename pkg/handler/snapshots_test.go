@@ -1,16 +1,20 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"github.com/stretchr/testify/mock"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/content-services/content-sources-backend/pkg/api"
 	"github.com/content-services/content-sources-backend/pkg/config"
 	"github.com/content-services/content-sources-backend/pkg/dao"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
 	"github.com/content-services/content-sources-backend/pkg/middleware"
 	test_handler "github.com/content-services/content-sources-backend/pkg/test/handler"
 	"github.com/labstack/echo/v4"
@@ -58,8 +62,9 @@ func (suite *SnapshotSuite) TestSnapshotList() {
 
 	paginationData := api.PaginationData{Limit: 10, Offset: DefaultOffset}
 	collection := createSnapshotCollection(1, 10, 0)
-	uuid := "abcadaba"
-	suite.reg.Snapshot.On("List", uuid, paginationData, api.FilterData{}).Return(collection, int64(1), nil)
+	uuid := "6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01"
+	suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, uuid).Return(api.RepositoryResponse{UUID: uuid}, nil)
+	suite.reg.Snapshot.On("List", mock.Anything, uuid, paginationData, api.FilterData{}).Return(collection, int64(1), nil)
 
 	path := fmt.Sprintf("%s/repositories/%s/snapshots/?limit=%d", fullRootPath(), uuid, 10)
 	req := httptest.NewRequest(http.MethodGet, path, nil)
@@ -79,6 +84,128 @@ func (suite *SnapshotSuite) TestSnapshotList() {
 	assert.Equal(t, collection.Data[0].RepositoryPath, response.Data[0].RepositoryPath)
 }
 
+func (suite *SnapshotSuite) TestGetRepodata() {
+	t := suite.T()
+
+	content := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pulp/content/domain/snap/repodata/repomd.xml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = w.Write([]byte("<repomd></repomd>"))
+	}))
+	defer content.Close()
+
+	origin := config.Get().Clients.Pulp.ContentOrigin
+	config.Get().Clients.Pulp.ContentOrigin = content.URL
+	defer func() { config.Get().Clients.Pulp.ContentOrigin = origin }()
+
+	uuid := "6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01"
+	snapshotUUID := "snap-uuid"
+	suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, uuid).Return(api.RepositoryResponse{UUID: uuid}, nil)
+	suite.reg.Snapshot.On("Fetch", mock.Anything, uuid, snapshotUUID).
+		Return(api.SnapshotResponse{UUID: snapshotUUID, RepositoryPath: "domain/snap"}, nil)
+
+	path := fmt.Sprintf("%s/repositories/%s/snapshots/%s/repodata/repomd.xml", fullRootPath(), uuid, snapshotUUID)
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveSnapshotsRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "<repomd></repomd>", string(body))
+
+	path = fmt.Sprintf("%s/repositories/%s/snapshots/%s/repodata/primary.xml.gz", fullRootPath(), uuid, snapshotUUID)
+	req = httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, _, err = suite.serveSnapshotsRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func (suite *SnapshotSuite) TestGetRepodataUnknownSnapshot() {
+	t := suite.T()
+
+	uuid := "6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01"
+	suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, uuid).Return(api.RepositoryResponse{UUID: uuid}, nil)
+	suite.reg.Snapshot.On("Fetch", mock.Anything, uuid, "other").
+		Return(api.SnapshotResponse{}, &ce.DaoError{NotFound: true, Message: "Could not find snapshot with UUID other"})
+
+	path := fmt.Sprintf("%s/repositories/%s/snapshots/other/repodata/repomd.xml", fullRootPath(), uuid)
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, _, err := suite.serveSnapshotsRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func (suite *SnapshotSuite) TestSnapshotListDeletedRepository() {
+	t := suite.T()
+
+	uuid := "6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01"
+	suite.reg.RepositoryConfig.On("Fetch", mock.Anything, test_handler.MockOrgId, uuid).
+		Return(api.RepositoryResponse{}, &ce.DaoError{NotFound: true, Message: "Could not find repository with UUID " + uuid})
+
+	path := fmt.Sprintf("%s/repositories/%s/snapshots/", fullRootPath(), uuid)
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, _, err := suite.serveSnapshotsRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func (suite *SnapshotSuite) TestListSnapshotsByDate() {
+	t := suite.T()
+
+	date := time.Date(2023, 8, 10, 0, 0, 0, 0, time.UTC)
+	request := api.ListSnapshotByDateRequest{RepositoryUUIDS: []string{"6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01", "6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a02"}, Date: date}
+	expected := api.ListSnapshotByDateResponse{Data: []api.SnapshotForDate{
+		{RepositoryUUID: "6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01", IsExactMatch: true, Match: &api.SnapshotResponse{UUID: "snap", RepositoryPath: "distribution/path/"}},
+		{RepositoryUUID: "6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a02"},
+	}}
+	suite.reg.Snapshot.On("FetchSnapshotsByDateAndRepository", mock.Anything, test_handler.MockOrgId, request).Return(expected, nil)
+
+	body, err := json.Marshal(request)
+	assert.NoError(t, err)
+	path := fmt.Sprintf("%s/snapshots/for_date/", fullRootPath())
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+	req.Header.Set("Content-Type", "application/json")
+
+	code, body, err := suite.serveSnapshotsRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	response := api.ListSnapshotByDateResponse{}
+	err = json.Unmarshal(body, &response)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, response)
+}
+
+func (suite *SnapshotSuite) TestListSnapshotsByDateInvalid() {
+	t := suite.T()
+
+	for _, request := range []api.ListSnapshotByDateRequest{
+		{Date: time.Now()},
+		{RepositoryUUIDS: []string{"6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01"}},
+	} {
+		body, err := json.Marshal(request)
+		assert.NoError(t, err)
+		path := fmt.Sprintf("%s/snapshots/for_date/", fullRootPath())
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+		req.Header.Set("Content-Type", "application/json")
+
+		code, _, err := suite.serveSnapshotsRouter(req)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, code)
+	}
+}
+
 func createSnapshotCollection(size, limit, offset int) api.SnapshotCollectionResponse {
 	snaps := make([]api.SnapshotResponse, size)
 	for i := 0; i < size; i++ {
@@ -94,3 +221,30 @@ func createSnapshotCollection(size, limit, offset int) api.SnapshotCollectionRes
 	setCollectionResponseMetadata(&collection, getTestContext(params), int64(size))
 	return collection
 }
+
+func (suite *SnapshotSuite) TestGetStorageUsage() {
+	t := suite.T()
+
+	expected := api.SnapshotStorageResponse{
+		SizeBytes: 5600,
+		Snapshots: 3,
+		Repositories: []api.RepositoryStorage{
+			{UUID: "6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01", Name: "large", SizeBytes: 5000, Snapshots: 1},
+			{UUID: "6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a02", Name: "small", SizeBytes: 600, Snapshots: 2},
+		},
+	}
+	suite.reg.Snapshot.On("StorageUsage", mock.Anything, test_handler.MockOrgId).Return(expected, nil)
+
+	path := fmt.Sprintf("%s/snapshots/storage/", fullRootPath())
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveSnapshotsRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	response := api.SnapshotStorageResponse{}
+	err = json.Unmarshal(body, &response)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, response)
+}
@@ -2,7 +2,10 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/config"
 	"github.com/content-services/content-sources-backend/pkg/dao"
 	ce "github.com/content-services/content-sources-backend/pkg/errors"
 	"github.com/content-services/content-sources-backend/pkg/rbac"
@@ -27,8 +30,12 @@ func RegisterTaskInfoRoutes(engine *echo.Group, daoReg *dao.DaoRegistry) {
 	}
 	addRoute(engine, http.MethodGet, "/tasks/", taskInfoHandler.listTasks, rbac.RbacVerbRead)
 	addRoute(engine, http.MethodGet, "/tasks/:uuid", taskInfoHandler.fetch, rbac.RbacVerbRead)
+	addStreamRoute(engine, http.MethodGet, "/tasks/:uuid/events", taskInfoHandler.streamEvents, rbac.RbacVerbRead)
 }
 
+// taskEventsInterval is the time between two checks of the task streamed by streamEvents
+var taskEventsInterval = 2 * time.Second
+
 // ListTasks godoc
 // @Summary      List Tasks
 // @ID           listTasks
@@ -54,9 +61,9 @@ func (taskInfoHandler *TaskInfoHandler) listTasks(c echo.Context) error {
 		log.Error().Err(err).Msg("Error parsing filters")
 	}
 
-	tasks, totalTasks, err := taskInfoHandler.DaoRegistry.TaskInfo.List(orgID, pageData, statusFilter)
+	tasks, totalTasks, err := taskInfoHandler.DaoRegistry.TaskInfo.List(c.Request().Context(), orgID, pageData, statusFilter)
 	if err != nil {
-		return ce.NewErrorResponse(ce.HttpCodeForDaoError(err), "Error listing tasks", err.Error())
+		return ce.NewErrorResponseFromError("Error listing tasks", err)
 	}
 
 	return c.JSON(http.StatusOK, setCollectionResponseMetadata(&tasks, c, totalTasks))
@@ -80,9 +87,78 @@ func (taskInfoHandler *TaskInfoHandler) fetch(c echo.Context) error {
 	_, orgID := getAccountIdOrgId(c)
 	id := c.Param("uuid")
 
-	response, err := taskInfoHandler.DaoRegistry.TaskInfo.Fetch(orgID, id)
+	response, err := taskInfoHandler.DaoRegistry.TaskInfo.Fetch(c.Request().Context(), orgID, id)
 	if err != nil {
-		return ce.NewErrorResponse(ce.HttpCodeForDaoError(err), "Error fetching task", err.Error())
+		return ce.NewErrorResponseFromError("Error fetching task", err)
 	}
 	return c.JSON(http.StatusOK, response)
 }
+
+// StreamTaskEvents godoc
+// @Summary      Stream Task Events
+// @ID           streamTaskEvents
+// @Description  Server-Sent Events stream of the status and progress of a Task, sending a "task" event each time
+// @Description  they change until the task ends.
+// @Tags         tasks
+// @Produce      text/event-stream
+// @Param  uuid  path  string    true  "Identifier of the Task"
+// @Success      200   {object}  api.TaskEventResponse
+// @Failure      400 {object} ce.ErrorResponse
+// @Failure      401 {object} ce.ErrorResponse
+// @Failure      404 {object} ce.ErrorResponse
+// @Failure      500 {object} ce.ErrorResponse
+// @Router       /tasks/{uuid}/events [get]
+func (taskInfoHandler *TaskInfoHandler) streamEvents(c echo.Context) error {
+	_, orgID := getAccountIdOrgId(c)
+	id := c.Param("uuid")
+
+	event, err := taskInfoHandler.DaoRegistry.TaskInfo.FetchEvent(c.Request().Context(), orgID, id)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error fetching task", err)
+	}
+
+	response := startServerSentEvents(c)
+
+	ticker := time.NewTicker(taskEventsInterval)
+	defer ticker.Stop()
+	var sent *api.TaskEventResponse
+	lastWrite := time.Now()
+	for {
+		if sent == nil || !sameTaskEvent(*sent, event) {
+			if err = writeServerSentEvent(response, "task", event); err != nil {
+				return nil
+			}
+			// event is overwritten by the next fetch, so a copy is kept to compare with
+			last := event
+			sent = &last
+			lastWrite = time.Now()
+		} else if time.Since(lastWrite) >= serverSentEventsKeepAlive {
+			if err = writeServerSentComment(response, "keep-alive"); err != nil {
+				return nil
+			}
+			lastWrite = time.Now()
+		}
+		if event.Status != config.TaskStatusRunning && event.Status != config.TaskStatusPending {
+			return nil
+		}
+
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		if event, err = taskInfoHandler.DaoRegistry.TaskInfo.FetchEvent(c.Request().Context(), orgID, id); err != nil {
+			// The status code is already sent, so the error is sent as an event
+			_ = writeServerSentEvent(response, "error", ce.NewErrorResponseFromError("Error fetching task", err))
+			return nil
+		}
+	}
+}
+
+func sameTaskEvent(a api.TaskEventResponse, b api.TaskEventResponse) bool {
+	if (a.Progress == nil) != (b.Progress == nil) || (a.Progress != nil && *a.Progress != *b.Progress) {
+		return false
+	}
+	return a.Status == b.Status && a.Error == b.Error && a.Step == b.Step
+}
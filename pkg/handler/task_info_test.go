@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"github.com/stretchr/testify/mock"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -81,7 +82,7 @@ func (suite *TaskInfoSuite) TestSimple() {
 
 	collection := createTaskCollection(1, 10, 0)
 	paginationData := api.PaginationData{Limit: 10, Offset: DefaultOffset}
-	suite.reg.TaskInfo.On("List", test_handler.MockOrgId, paginationData, "").Return(collection, int64(1), nil)
+	suite.reg.TaskInfo.On("List", mock.Anything, test_handler.MockOrgId, paginationData, "").Return(collection, int64(1), nil)
 
 	path := fmt.Sprintf("%s/tasks/?limit=%d", fullRootPath(), 10)
 	req := httptest.NewRequest(http.MethodGet, path, nil)
@@ -111,7 +112,7 @@ func (suite *TaskInfoSuite) TestListNoTasks() {
 
 	collection := api.TaskInfoCollectionResponse{}
 	paginationData := api.PaginationData{Limit: DefaultLimit, Offset: DefaultOffset}
-	suite.reg.TaskInfo.On("List", test_handler.MockOrgId, paginationData, "").Return(collection, int64(0), nil)
+	suite.reg.TaskInfo.On("List", mock.Anything, test_handler.MockOrgId, paginationData, "").Return(collection, int64(0), nil)
 
 	req := httptest.NewRequest(http.MethodGet, fullRootPath()+"/tasks/", nil)
 	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
@@ -138,8 +139,8 @@ func (suite *TaskInfoSuite) TestListPagedExtraRemaining() {
 	paginationData1 := api.PaginationData{Limit: 10, Offset: 0}
 	paginationData2 := api.PaginationData{Limit: 10, Offset: 100}
 
-	suite.reg.TaskInfo.On("List", test_handler.MockOrgId, paginationData1, "").Return(collection, int64(102), nil).Once()
-	suite.reg.TaskInfo.On("List", test_handler.MockOrgId, paginationData2, "").Return(collection, int64(102), nil).Once()
+	suite.reg.TaskInfo.On("List", mock.Anything, test_handler.MockOrgId, paginationData1, "").Return(collection, int64(102), nil).Once()
+	suite.reg.TaskInfo.On("List", mock.Anything, test_handler.MockOrgId, paginationData2, "").Return(collection, int64(102), nil).Once()
 
 	path := fmt.Sprintf("%s/tasks/?limit=%d", fullRootPath(), 10)
 	req := httptest.NewRequest(http.MethodGet, path, nil)
@@ -176,8 +177,8 @@ func (suite *TaskInfoSuite) TestListPagedNoRemaining() {
 	paginationData2 := api.PaginationData{Limit: 10, Offset: 90}
 
 	collection := api.TaskInfoCollectionResponse{}
-	suite.reg.TaskInfo.On("List", test_handler.MockOrgId, paginationData1, "").Return(collection, int64(100), nil)
-	suite.reg.TaskInfo.On("List", test_handler.MockOrgId, paginationData2, "").Return(collection, int64(100), nil)
+	suite.reg.TaskInfo.On("List", mock.Anything, test_handler.MockOrgId, paginationData1, "").Return(collection, int64(100), nil)
+	suite.reg.TaskInfo.On("List", mock.Anything, test_handler.MockOrgId, paginationData2, "").Return(collection, int64(100), nil)
 
 	path := fmt.Sprintf("%s/tasks/?limit=%d", fullRootPath(), 10)
 	req := httptest.NewRequest(http.MethodGet, path, nil)
@@ -214,8 +215,8 @@ func (suite *TaskInfoSuite) TestListStatusFilter() {
 	statusFilter1 := "completed"
 
 	collection := api.TaskInfoCollectionResponse{}
-	suite.reg.TaskInfo.On("List", test_handler.MockOrgId, paginationData, statusFilter1).Return(collection, int64(100), nil)
-	suite.reg.TaskInfo.On("List", test_handler.MockOrgId, paginationData, "").Return(collection, int64(110), nil)
+	suite.reg.TaskInfo.On("List", mock.Anything, test_handler.MockOrgId, paginationData, statusFilter1).Return(collection, int64(100), nil)
+	suite.reg.TaskInfo.On("List", mock.Anything, test_handler.MockOrgId, paginationData, "").Return(collection, int64(110), nil)
 
 	// Listing with filter
 	path := fmt.Sprintf("%s/tasks/?limit=%d&status=%s", fullRootPath(), 10, statusFilter1)
@@ -265,7 +266,7 @@ func (suite *TaskInfoSuite) TestFetch() {
 		OrgId:     "org id",
 	}
 
-	suite.reg.TaskInfo.On("Fetch", test_handler.MockOrgId, uuid).Return(task, nil)
+	suite.reg.TaskInfo.On("Fetch", mock.Anything, test_handler.MockOrgId, uuid).Return(task, nil)
 
 	body, err := json.Marshal(task)
 	if err != nil {
@@ -288,6 +289,56 @@ func (suite *TaskInfoSuite) TestFetch() {
 	assert.Equal(t, task, response)
 }
 
+func (suite *TaskInfoSuite) TestStreamEvents() {
+	t := suite.T()
+	taskEventsInterval = time.Millisecond
+	defer func() { taskEventsInterval = 2 * time.Second }()
+
+	uuid := "abcadaba"
+	progress := 40
+	running := api.TaskEventResponse{UUID: uuid, Status: config.TaskStatusRunning, Step: "sync", Progress: &progress}
+	moreProgress := 60
+	progressed := api.TaskEventResponse{UUID: uuid, Status: config.TaskStatusRunning, Step: "sync", Progress: &moreProgress}
+	completed := api.TaskEventResponse{UUID: uuid, Status: config.TaskStatusCompleted}
+	// Unchanged events are only sent once, every change is sent
+	suite.reg.TaskInfo.On("FetchEvent", mock.Anything, test_handler.MockOrgId, uuid).Return(running, nil).Twice()
+	suite.reg.TaskInfo.On("FetchEvent", mock.Anything, test_handler.MockOrgId, uuid).Return(progressed, nil).Twice()
+	suite.reg.TaskInfo.On("FetchEvent", mock.Anything, test_handler.MockOrgId, uuid).Return(completed, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, fullRootPath()+"/tasks/"+uuid+"/events", nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveTasksRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	runningData, err := json.Marshal(running)
+	assert.NoError(t, err)
+	progressedData, err := json.Marshal(progressed)
+	assert.NoError(t, err)
+	completedData, err := json.Marshal(completed)
+	assert.NoError(t, err)
+	expected := "event: task\ndata: " + string(runningData) + "\n\n" +
+		"event: task\ndata: " + string(progressedData) + "\n\n" +
+		"event: task\ndata: " + string(completedData) + "\n\n"
+	assert.Equal(t, expected, string(body))
+}
+
+func (suite *TaskInfoSuite) TestStreamEventsNotFound() {
+	t := suite.T()
+
+	uuid := "abcadaba"
+	daoError := ce.DaoError{NotFound: true, Message: "Not found"}
+	suite.reg.TaskInfo.On("FetchEvent", mock.Anything, test_handler.MockOrgId, uuid).Return(api.TaskEventResponse{}, &daoError)
+
+	req := httptest.NewRequest(http.MethodGet, fullRootPath()+"/tasks/"+uuid+"/events", nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, _, err := suite.serveTasksRouter(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
 func (suite *TaskInfoSuite) TestFetchNotFound() {
 	t := suite.T()
 
@@ -305,7 +356,7 @@ func (suite *TaskInfoSuite) TestFetchNotFound() {
 		NotFound: true,
 		Message:  "Not found",
 	}
-	suite.reg.TaskInfo.On("Fetch", test_handler.MockOrgId, uuid).Return(api.TaskInfoResponse{}, &daoError)
+	suite.reg.TaskInfo.On("Fetch", mock.Anything, test_handler.MockOrgId, uuid).Return(api.TaskInfoResponse{}, &daoError)
 
 	body, err := json.Marshal(task)
 	if err != nil {
@@ -1,12 +1,25 @@
 package handler
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
 	"github.com/content-services/content-sources-backend/pkg/rbac"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
+// serverSentEventsKeepAlive is the time after which a comment is sent on an idle events stream,
+// so that proxies do not close it
+const serverSentEventsKeepAlive = 30 * time.Second
+
 func GetHeader(c echo.Context, key string, defvalues []string) []string {
 	val, ok := c.Request().Header[key]
 	if !ok {
@@ -26,7 +39,174 @@ func removeEndSuffix(source string, suffix string) string {
 	return output
 }
 
+// validateUUIDs returns a bad request error naming the field if any of the identifiers is not a
+// UUID, so that a malformed identifier is rejected before it reaches a query
+func validateUUIDs(field string, uuids ...string) error {
+	for _, id := range uuids {
+		if _, err := uuid.Parse(id); err != nil || len(id) != 36 {
+			return ce.NewErrorResponse(http.StatusBadRequest, "Invalid UUID", fmt.Sprintf("%s contains %q, which is not a valid UUID.", field, id))
+		}
+	}
+	return nil
+}
+
+// integerQueryParams are the query parameters bound as integers by the handlers
+var integerQueryParams = []string{"limit", "offset"}
+
+// validateParams rejects a request whose uuid path parameters are not UUIDs, or whose integer
+// query parameters are not integers, with a bad request error instead of letting the handler
+// fail on them.  The path parameters are known once the request is routed, so it must be
+// registered on the routes and not with Pre.
+func validateParams(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		for i, name := range c.ParamNames() {
+			if name != "uuid" && !strings.HasSuffix(name, "_uuid") {
+				continue
+			}
+			if err := validateUUIDs(name, c.ParamValues()[i]); err != nil {
+				return err
+			}
+		}
+		for _, name := range integerQueryParams {
+			value := c.QueryParam(name)
+			if value == "" {
+				continue
+			}
+			if i, err := strconv.Atoi(value); err != nil || i < 0 {
+				return ce.NewErrorResponse(http.StatusBadRequest, "Invalid parameter", fmt.Sprintf("%s must be a non-negative integer, got %q.", name, value))
+			}
+		}
+		return next(c)
+	}
+}
+
 func addRoute(e *echo.Group, method string, path string, h echo.HandlerFunc, verb rbac.Verb, m ...echo.MiddlewareFunc) {
 	e.Add(method, path, h, m...)
 	rbac.ServicePermissions.Add(method, path, rbac.ResourceRepositories, verb)
 }
+
+// routeSet holds the paths of routes, by method.  The paths are relative to the groups of
+// the api versions, so that a route is matched under every version.
+type routeSet map[string][]string
+
+func (rs routeSet) add(method string, path string) {
+	for _, existing := range rs[method] {
+		if existing == path {
+			return
+		}
+	}
+	rs[method] = append(rs[method], path)
+}
+
+func (rs routeSet) matches(c echo.Context) bool {
+	for _, path := range rs[c.Request().Method] {
+		if strings.HasSuffix(c.Path(), path) {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadRoutes are the routes accepting uploads
+var uploadRoutes = routeSet{}
+
+// streamRoutes are the routes streaming events or content, whose requests last as long as the
+// client listens or the content takes to transfer
+var streamRoutes = routeSet{}
+
+// addUploadRoute adds a route accepting uploads, given the upload body limit and accepting multipart forms
+func addUploadRoute(e *echo.Group, method string, path string, h echo.HandlerFunc, verb rbac.Verb, m ...echo.MiddlewareFunc) {
+	addRoute(e, method, path, h, verb, m...)
+	uploadRoutes.add(method, path)
+}
+
+// addStreamRoute adds a route streaming events or content, which is not given the request timeout
+func addStreamRoute(e *echo.Group, method string, path string, h echo.HandlerFunc, verb rbac.Verb, m ...echo.MiddlewareFunc) {
+	addRoute(e, method, path, h, verb, m...)
+	streamRoutes.add(method, path)
+}
+
+// IsUploadRoute returns whether the request is served by a route accepting uploads
+func IsUploadRoute(c echo.Context) bool {
+	return uploadRoutes.matches(c)
+}
+
+// IsStreamRoute returns whether the request is served by a route streaming events or content
+func IsStreamRoute(c echo.Context) bool {
+	return streamRoutes.matches(c)
+}
+
+// IsLongRunningRoute returns whether the request is served by a route streaming events or content,
+// or accepting uploads, whose duration depends on the client and the size of the transfer
+func IsLongRunningRoute(c echo.Context) bool {
+	return IsStreamRoute(c) || IsUploadRoute(c)
+}
+
+// streamUpload copies the file of a multipart form field to temporary storage, rather than buffering
+// it in memory, and returns it ready to be read.  The returned function closes and removes the file.
+func streamUpload(c echo.Context, field string) (*os.File, func(), error) {
+	reader, err := c.Request().MultipartReader()
+	if err != nil {
+		return nil, nil, err
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, nil, fmt.Errorf("form has no %s field", field)
+		} else if err != nil {
+			return nil, nil, err
+		}
+		if part.FormName() != field {
+			continue
+		}
+
+		file, err := os.CreateTemp("", "content-sources-upload-*")
+		if err != nil {
+			return nil, nil, err
+		}
+		remove := func() {
+			file.Close()
+			os.Remove(file.Name())
+		}
+		if _, err = io.Copy(file, part); err != nil {
+			remove()
+			return nil, nil, err
+		}
+		if _, err = file.Seek(0, io.SeekStart); err != nil {
+			remove()
+			return nil, nil, err
+		}
+		return file, remove, nil
+	}
+}
+
+// startServerSentEvents sends the headers of an events stream
+func startServerSentEvents(c echo.Context) *echo.Response {
+	response := c.Response()
+	response.Header().Set(echo.HeaderContentType, "text/event-stream")
+	response.Header().Set(echo.HeaderCacheControl, "no-cache")
+	response.WriteHeader(http.StatusOK)
+	return response
+}
+
+// writeServerSentEvent writes an event with data encoded in JSON and flushes it to the client
+func writeServerSentEvent(response *echo.Response, name string, data interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err = fmt.Fprintf(response, "event: %s\ndata: %s\n\n", name, encoded); err != nil {
+		return err
+	}
+	response.Flush()
+	return nil
+}
+
+// writeServerSentComment writes a comment, ignored by clients, and flushes it
+func writeServerSentComment(response *echo.Response, comment string) error {
+	if _, err := fmt.Fprintf(response, ": %s\n\n", comment); err != nil {
+		return err
+	}
+	response.Flush()
+	return nil
+}
@@ -1,8 +1,14 @@
 package handler
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/rbac"
+	"github.com/content-services/content-sources-backend/pkg/signing"
+	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -61,3 +67,55 @@ func TestRemoveEndChar(t *testing.T) {
 		assert.Equal(t, testCase.Expected, result)
 	}
 }
+
+func TestValidateParams(t *testing.T) {
+	router := echo.New()
+	router.HTTPErrorHandler = config.CustomHTTPErrorHandler
+	router.GET("/things/:uuid/parts/:part_uuid/:file", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, validateParams)
+
+	valid := "6f0d7c1e-8b3a-4c52-a1d9-0e4b7f2c9a01"
+	tests := []struct {
+		path string
+		code int
+	}{
+		{"/things/" + valid + "/parts/" + valid + "/repomd.xml", http.StatusOK},
+		{"/things/" + valid + "/parts/" + valid + "/repomd.xml?limit=10&offset=0", http.StatusOK},
+		{"/things/not-a-uuid/parts/" + valid + "/repomd.xml", http.StatusBadRequest},
+		{"/things/" + valid + "/parts/abc/repomd.xml", http.StatusBadRequest},
+		{"/things/" + valid + "/parts/" + valid + "/repomd.xml?limit=ten", http.StatusBadRequest},
+		{"/things/" + valid + "/parts/" + valid + "/repomd.xml?offset=-1", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		assert.Equal(t, tt.code, rr.Code, tt.path)
+	}
+}
+
+func TestIsLongRunningRoute(t *testing.T) {
+	e := echo.New()
+	RegisterContentRoutes(e)
+	group := e.Group("/api/" + config.DefaultAppName + "/v1")
+	addUploadRoute(group, http.MethodPost, "/test_upload/", func(c echo.Context) error { return nil }, rbac.RbacVerbWrite)
+	addStreamRoute(group, http.MethodGet, "/test_stream/", func(c echo.Context) error { return nil }, rbac.RbacVerbRead)
+
+	prefix := "/api/" + config.DefaultAppName + "/v1"
+	cases := []struct {
+		method   string
+		path     string
+		expected bool
+	}{
+		{http.MethodGet, prefix + "/test_stream/", true},
+		{http.MethodPost, prefix + "/test_upload/", true},
+		{http.MethodGet, prefix + "/test_upload/", false},
+		{http.MethodGet, signing.ContentPath() + ":token/*", true},
+		{http.MethodGet, prefix + "/repositories/", false},
+	}
+	for _, tc := range cases {
+		c := e.NewContext(httptest.NewRequest(tc.method, "/", nil), httptest.NewRecorder())
+		c.SetPath(tc.path)
+		assert.Equal(t, tc.expected, IsLongRunningRoute(c), tc.method+" "+tc.path)
+	}
+}
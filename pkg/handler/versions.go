@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/content-services/content-sources-backend/pkg/event/producer"
+	"github.com/content-services/content-sources-backend/pkg/tasks/client"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	HeaderApiVersion    = "API-Version"
+	HeaderAcceptVersion = "Accept-Version"
+)
+
+// routeDeps holds what the resources need to register their handlers
+type routeDeps struct {
+	daoReg            *dao.DaoRegistry
+	introspectRequest *producer.IntrospectRequest
+	taskClient        *client.TaskClient
+}
+
+// registerFunc registers the handlers of one resource in the group of an api version
+type registerFunc func(group *echo.Group, deps routeDeps)
+
+// resourceRoutes names the handlers of one resource, so that an api version can replace them
+type resourceRoutes struct {
+	name     string
+	register registerFunc
+}
+
+// apiVersion is a version of the api, served under all of its paths.  By default, a version
+// serves the handlers of every resource, overrides replaces the handlers of a resource
+// with the ones changed in this version, or removes the resource when set to nil.
+type apiVersion struct {
+	Name      string
+	Paths     []string
+	overrides map[string]registerFunc
+}
+
+// resources lists the handlers served by every api version, unless overridden
+var resources = []resourceRoutes{
+	{"openapi", func(group *echo.Group, deps routeDeps) {
+		group.GET("/openapi.json", openapi)
+	}},
+	{"repositories", func(group *echo.Group, deps routeDeps) {
+		RegisterRepositoryRoutes(group, deps.daoReg, deps.introspectRequest, deps.taskClient)
+	}},
+	{"repository_parameters", func(group *echo.Group, deps routeDeps) {
+		RegisterRepositoryParameterRoutes(group, deps.daoReg)
+	}},
+	{"rpms", func(group *echo.Group, deps routeDeps) {
+		RegisterRepositoryRpmRoutes(group, deps.daoReg)
+	}},
+	{"popular_repositories", func(group *echo.Group, deps routeDeps) {
+		RegisterPopularRepositoriesRoutes(group, deps.daoReg)
+	}},
+	{"tasks", func(group *echo.Group, deps routeDeps) {
+		RegisterTaskInfoRoutes(group, deps.daoReg)
+	}},
+	{"snapshots", func(group *echo.Group, deps routeDeps) {
+		RegisterSnapshotRoutes(group, deps.daoReg)
+	}},
+	{"admin_tasks", func(group *echo.Group, deps routeDeps) {
+		RegisterAdminTaskRoutes(group, deps.daoReg, deps.taskClient)
+	}},
+	{"admin_usage", func(group *echo.Group, deps routeDeps) {
+		RegisterAdminUsageRoutes(group, deps.daoReg)
+	}},
+	{"admin_logging", func(group *echo.Group, deps routeDeps) {
+		RegisterAdminLoggingRoutes(group, deps.daoReg)
+	}},
+	{"features", func(group *echo.Group, deps routeDeps) {
+		RegisterFeaturesRoutes(group)
+	}},
+	{"public_repositories", func(group *echo.Group, deps routeDeps) {
+		RegisterPublicRepositoriesRoutes(group, deps.daoReg)
+	}},
+	{"webhooks", func(group *echo.Group, deps routeDeps) {
+		RegisterWebhookRoutes(group, deps.daoReg)
+	}},
+	{"settings", func(group *echo.Group, deps routeDeps) {
+		RegisterOrgSettingsRoutes(group, deps.daoReg)
+	}},
+}
+
+// apiVersions lists the served api versions, the first one is used when a request does not ask for a version.
+// A new version lists only the resources it changes in its overrides, e.g.:
+//
+//	{Name: "2.0", Paths: []string{"v2.0", "v2"}, overrides: map[string]registerFunc{"repositories": registerRepositoryRoutesV2}}
+func apiVersions() []apiVersion {
+	return []apiVersion{
+		{Name: ApiVersion, Paths: []string{"v" + ApiVersion, "v" + ApiVersionMajor}},
+	}
+}
+
+// registerVersionedRoutes registers the resources of every version under each of its paths
+func registerVersionedRoutes(engine *echo.Echo, versions []apiVersion, resources []resourceRoutes, deps routeDeps) {
+	for _, version := range versions {
+		for _, path := range version.Paths {
+			group := engine.Group(filepath.Join(rootPrefix(), path), versionHeader(version.Name), validateParams)
+			for _, resource := range resources {
+				register := resource.register
+				if override, ok := version.overrides[resource.name]; ok {
+					register = override
+				}
+				if register != nil {
+					register(group, deps)
+				}
+			}
+		}
+	}
+}
+
+// versionHeader sets the version serving the request in the response
+func versionHeader(name string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Response().Header().Set(HeaderApiVersion, name)
+			return next(c)
+		}
+	}
+}
+
+// negotiateVersion routes requests without a version in their path to the version asked for
+// in the Accept-Version header, or to the default version when the header is not set.
+// It must be registered with engine.Pre, so that the request is routed with the new path.
+func negotiateVersion(versions []apiVersion) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			prefix := rootPrefix() + "/"
+			p := c.Request().URL.Path
+			if !strings.HasPrefix(p, prefix) {
+				return next(c)
+			}
+			segment := strings.SplitN(strings.TrimPrefix(p, prefix), "/", 2)[0]
+			if segment == "" || segment == "internal" || segment == "content" || isVersionSegment(segment) {
+				return next(c)
+			}
+
+			path, ok := versionPath(versions, c.Request().Header.Get(HeaderAcceptVersion))
+			if !ok {
+				return ce.NewErrorResponse(http.StatusNotAcceptable, "Unsupported api version",
+					"Accept-Version must be one of "+strings.Join(versionNames(versions), ", "))
+			}
+			c.Request().URL.Path = prefix + path + "/" + strings.TrimPrefix(p, prefix)
+			c.Request().URL.RawPath = ""
+			return next(c)
+		}
+	}
+}
+
+// versionPath returns the path of the version matching the Accept-Version value
+func versionPath(versions []apiVersion, requested string) (string, bool) {
+	if len(versions) == 0 {
+		return "", false
+	}
+	requested = strings.TrimSpace(requested)
+	if requested == "" {
+		return versions[0].Paths[0], true
+	}
+	requested = "v" + strings.TrimPrefix(requested, "v")
+	for _, version := range versions {
+		for _, path := range version.Paths {
+			if path == requested {
+				return version.Paths[0], true
+			}
+		}
+	}
+	return "", false
+}
+
+// isVersionSegment tells if the path segment is a version, whether it is served or not
+func isVersionSegment(segment string) bool {
+	return len(segment) > 1 && segment[0] == 'v' && segment[1] >= '0' && segment[1] <= '9'
+}
+
+func versionNames(versions []apiVersion) []string {
+	names := []string{}
+	for _, version := range versions {
+		names = append(names, version.Name)
+	}
+	return names
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fakeResource(name string, body string) resourceRoutes {
+	return resourceRoutes{name, fakeRegister(name, body)}
+}
+
+func fakeRegister(name string, body string) registerFunc {
+	return func(group *echo.Group, deps routeDeps) {
+		group.GET("/"+name+"/", func(c echo.Context) error {
+			return c.String(http.StatusOK, body)
+		})
+	}
+}
+
+func serveVersionedRouter(t *testing.T, req *http.Request) (int, string, http.Header) {
+	versions := []apiVersion{
+		{Name: "1.0", Paths: []string{"v1.0", "v1"}},
+		{Name: "2.0", Paths: []string{"v2.0", "v2"}, overrides: map[string]registerFunc{
+			"widgets": fakeRegister("widgets", "widgets v2"),
+			"gadgets": nil,
+		}},
+	}
+	resources := []resourceRoutes{
+		fakeResource("widgets", "widgets v1"),
+		fakeResource("gadgets", "gadgets v1"),
+		fakeResource("things", "things v1"),
+	}
+	router := echo.New()
+	router.HTTPErrorHandler = config.CustomHTTPErrorHandler
+	router.Pre(negotiateVersion(versions))
+	registerVersionedRoutes(router, versions, resources, routeDeps{})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	response := rr.Result()
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	return response.StatusCode, string(body), response.Header
+}
+
+func TestVersionedRoutes(t *testing.T) {
+	tests := []struct {
+		path    string
+		code    int
+		body    string
+		version string
+	}{
+		{"/v1.0/widgets/", http.StatusOK, "widgets v1", "1.0"},
+		{"/v1/widgets/", http.StatusOK, "widgets v1", "1.0"},
+		{"/v1/gadgets/", http.StatusOK, "gadgets v1", "1.0"},
+		{"/v2/widgets/", http.StatusOK, "widgets v2", "2.0"},
+		{"/v2.0/things/", http.StatusOK, "things v1", "2.0"},
+		{"/v2/gadgets/", http.StatusNotFound, "", ""},
+		{"/v3/things/", http.StatusNotFound, "", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, rootPrefix()+tt.path, nil)
+		code, body, header := serveVersionedRouter(t, req)
+		assert.Equal(t, tt.code, code, tt.path)
+		if tt.code == http.StatusOK {
+			assert.Equal(t, tt.body, body, tt.path)
+		}
+		assert.Equal(t, tt.version, header.Get(HeaderApiVersion), tt.path)
+	}
+}
+
+func TestNegotiateVersion(t *testing.T) {
+	tests := []struct {
+		accept  string
+		code    int
+		body    string
+		version string
+	}{
+		{"", http.StatusOK, "widgets v1", "1.0"},
+		{"1", http.StatusOK, "widgets v1", "1.0"},
+		{"v2", http.StatusOK, "widgets v2", "2.0"},
+		{"2.0", http.StatusOK, "widgets v2", "2.0"},
+		{"3", http.StatusNotAcceptable, "", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, rootPrefix()+"/widgets/", nil)
+		if tt.accept != "" {
+			req.Header.Set(HeaderAcceptVersion, tt.accept)
+		}
+		code, body, header := serveVersionedRouter(t, req)
+		assert.Equal(t, tt.code, code, tt.accept)
+		if tt.code == http.StatusOK {
+			assert.Equal(t, tt.body, body, tt.accept)
+		}
+		assert.Equal(t, tt.version, header.Get(HeaderApiVersion), tt.accept)
+	}
+}
+
+func TestNegotiateVersionSkipsOtherPaths(t *testing.T) {
+	paths := []string{
+		"/ping",
+		rootPrefix() + "/internal/widgets/",
+		rootPrefix() + "/content/widgets/",
+	}
+	for _, path := range paths {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(HeaderAcceptVersion, "3")
+		code, _, _ := serveVersionedRouter(t, req)
+		assert.Equal(t, http.StatusNotFound, code, path)
+	}
+}
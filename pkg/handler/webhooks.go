@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/content-services/content-sources-backend/pkg/rbac"
+	"github.com/labstack/echo/v4"
+)
+
+type WebhookHandler struct {
+	DaoRegistry dao.DaoRegistry
+}
+
+func RegisterWebhookRoutes(group *echo.Group, daoReg *dao.DaoRegistry) {
+	if group == nil {
+		panic("engine is nil")
+	}
+	if daoReg == nil {
+		panic("daoReg is nil")
+	}
+
+	wh := WebhookHandler{DaoRegistry: *daoReg}
+	addRoute(group, http.MethodGet, "/webhooks/", wh.listWebhooks, rbac.RbacVerbRead)
+	addRoute(group, http.MethodPost, "/webhooks/", wh.createWebhook, rbac.RbacVerbWrite)
+	addRoute(group, http.MethodGet, "/webhooks/:uuid", wh.fetchWebhook, rbac.RbacVerbRead)
+	addRoute(group, http.MethodDelete, "/webhooks/:uuid", wh.deleteWebhook, rbac.RbacVerbWrite)
+	addRoute(group, http.MethodGet, "/webhooks/:uuid/deliveries/", wh.listDeliveries, rbac.RbacVerbRead)
+}
+
+// ListWebhooks godoc
+// @Summary      List webhooks
+// @ID           listWebhooks
+// @Description  List the webhooks receiving the repository events of the organization.
+// @Tags         webhooks
+// @Produce      json
+// @Param		 offset query int false "Offset into the list of results to return in the response"
+// @Param		 limit query int false "Limit the number of items returned"
+// @Success      200 {object} api.WebhookCollectionResponse
+// @Failure      400 {object} ce.ErrorResponse
+// @Failure      401 {object} ce.ErrorResponse
+// @Failure      500 {object} ce.ErrorResponse
+// @Router       /webhooks/ [get]
+func (wh *WebhookHandler) listWebhooks(c echo.Context) error {
+	_, orgID := getAccountIdOrgId(c)
+	pageData := ParsePagination(c)
+
+	webhooks, total, err := wh.DaoRegistry.Webhook.List(c.Request().Context(), orgID, pageData)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error listing webhooks", err)
+	}
+	return c.JSON(http.StatusOK, setCollectionResponseMetadata(&webhooks, c, total))
+}
+
+// CreateWebhook godoc
+// @Summary      Create webhook
+// @ID           createWebhook
+// @Description  Register a url receiving repository events as JSON posts, signed with an HMAC-SHA256 of the body in the X-Content-Sources-Signature header. The secret is only returned on creation.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        body  body     api.WebhookRequest  true  "request body"
+// @Success      201  {object}  api.WebhookResponse
+// @Header       201  {string}  Location "resource URL"
+// @Failure      400 {object} ce.ErrorResponse
+// @Failure      401 {object} ce.ErrorResponse
+// @Failure      415 {object} ce.ErrorResponse
+// @Failure      500 {object} ce.ErrorResponse
+// @Router       /webhooks/ [post]
+func (wh *WebhookHandler) createWebhook(c echo.Context) error {
+	var newWebhook api.WebhookRequest
+	if err := c.Bind(&newWebhook); err != nil {
+		return ce.NewErrorResponse(http.StatusBadRequest, "Error binding parameters", err.Error())
+	}
+	_, orgID := getAccountIdOrgId(c)
+	newWebhook.OrgID = &orgID
+
+	response, err := wh.DaoRegistry.Webhook.Create(c.Request().Context(), newWebhook)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error creating webhook", err)
+	}
+	c.Response().Header().Set("Location", "/api/"+config.DefaultAppName+"/v1.0/webhooks/"+response.UUID)
+	return c.JSON(http.StatusCreated, response)
+}
+
+// FetchWebhook godoc
+// @Summary      Get webhook
+// @ID           fetchWebhook
+// @Tags         webhooks
+// @Produce      json
+// @Param        uuid  path  string  true  "Identifier of the Webhook"
+// @Success      200 {object} api.WebhookResponse
+// @Failure      401 {object} ce.ErrorResponse
+// @Failure      404 {object} ce.ErrorResponse
+// @Failure      500 {object} ce.ErrorResponse
+// @Router       /webhooks/{uuid} [get]
+func (wh *WebhookHandler) fetchWebhook(c echo.Context) error {
+	_, orgID := getAccountIdOrgId(c)
+
+	response, err := wh.DaoRegistry.Webhook.Fetch(c.Request().Context(), orgID, c.Param("uuid"))
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error fetching webhook", err)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// DeleteWebhook godoc
+// @Summary      Delete webhook
+// @ID           deleteWebhook
+// @Description  Delete a webhook and its delivery log, pending deliveries are not sent.
+// @Tags         webhooks
+// @Param        uuid  path  string  true  "Identifier of the Webhook"
+// @Success      204 "Webhook was successfully deleted"
+// @Failure      401 {object} ce.ErrorResponse
+// @Failure      404 {object} ce.ErrorResponse
+// @Failure      500 {object} ce.ErrorResponse
+// @Router       /webhooks/{uuid} [delete]
+func (wh *WebhookHandler) deleteWebhook(c echo.Context) error {
+	_, orgID := getAccountIdOrgId(c)
+
+	if err := wh.DaoRegistry.Webhook.Delete(c.Request().Context(), orgID, c.Param("uuid")); err != nil {
+		return ce.NewErrorResponseFromError("Error deleting webhook", err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries godoc
+// @Summary      List deliveries of a webhook
+// @ID           listWebhookDeliveries
+// @Description  List the events sent to a webhook, the latest first, with the result of their last attempt. Failed attempts are retried with an exponential backoff.
+// @Tags         webhooks
+// @Produce      json
+// @Param        uuid  path  string  true  "Identifier of the Webhook"
+// @Param		 offset query int false "Offset into the list of results to return in the response"
+// @Param		 limit query int false "Limit the number of items returned"
+// @Success      200 {object} api.WebhookDeliveryCollectionResponse
+// @Failure      400 {object} ce.ErrorResponse
+// @Failure      401 {object} ce.ErrorResponse
+// @Failure      404 {object} ce.ErrorResponse
+// @Failure      500 {object} ce.ErrorResponse
+// @Router       /webhooks/{uuid}/deliveries/ [get]
+func (wh *WebhookHandler) listDeliveries(c echo.Context) error {
+	_, orgID := getAccountIdOrgId(c)
+	pageData := ParsePagination(c)
+
+	deliveries, total, err := wh.DaoRegistry.Webhook.ListDeliveries(c.Request().Context(), orgID, c.Param("uuid"), pageData)
+	if err != nil {
+		return ce.NewErrorResponseFromError("Error listing webhook deliveries", err)
+	}
+	return c.JSON(http.StatusOK, setCollectionResponseMetadata(&deliveries, c, total))
+}
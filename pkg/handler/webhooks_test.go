@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/content-services/content-sources-backend/pkg/middleware"
+	test_handler "github.com/content-services/content-sources-backend/pkg/test/handler"
+	"github.com/labstack/echo/v4"
+	echo_middleware "github.com/labstack/echo/v4/middleware"
+	"github.com/openlyinc/pointy"
+	"github.com/redhatinsights/platform-go-middlewares/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type WebhookSuite struct {
+	suite.Suite
+	reg *dao.MockDaoRegistry
+}
+
+func TestWebhookSuite(t *testing.T) {
+	suite.Run(t, new(WebhookSuite))
+}
+
+func (suite *WebhookSuite) SetupTest() {
+	suite.reg = dao.GetMockDaoRegistry(suite.T())
+}
+
+func (suite *WebhookSuite) serveWebhooksRouter(req *http.Request) (int, []byte, error) {
+	router := echo.New()
+	router.Use(echo_middleware.RequestIDWithConfig(echo_middleware.RequestIDConfig{
+		TargetHeader: "x-rh-insights-request-id",
+	}))
+	router.Use(middleware.WrapMiddlewareWithSkipper(identity.EnforceIdentity, middleware.SkipAuth))
+	router.HTTPErrorHandler = config.CustomHTTPErrorHandler
+	pathPrefix := router.Group(fullRootPath())
+
+	RegisterWebhookRoutes(pathPrefix, suite.reg.ToDaoRegistry())
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	response := rr.Result()
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	return response.StatusCode, body, err
+}
+
+func (suite *WebhookSuite) TestCreate() {
+	t := suite.T()
+
+	request := api.WebhookRequest{
+		URL:        pointy.String("https://example.com/hook"),
+		EventTypes: &[]string{api.WebhookEventSnapshotCompleted},
+	}
+	expected := request
+	expected.OrgID = pointy.String(test_handler.MockOrgId)
+	suite.reg.Webhook.On("Create", mock.Anything, expected).
+		Return(api.WebhookResponse{UUID: "abc", URL: *request.URL, Secret: "secret"}, nil)
+
+	body, err := json.Marshal(request)
+	assert.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, fullRootPath()+"/webhooks/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveWebhooksRouter(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, code)
+
+	response := api.WebhookResponse{}
+	assert.NoError(t, json.Unmarshal(body, &response))
+	assert.Equal(t, "abc", response.UUID)
+	assert.Equal(t, "secret", response.Secret)
+}
+
+func (suite *WebhookSuite) TestCreateInvalid() {
+	t := suite.T()
+
+	request := api.WebhookRequest{URL: pointy.String("ftp://example.com")}
+	expected := request
+	expected.OrgID = pointy.String(test_handler.MockOrgId)
+	suite.reg.Webhook.On("Create", mock.Anything, expected).
+		Return(api.WebhookResponse{}, &ce.DaoError{BadValidation: true, Message: "URL must be an absolute http or https url."})
+
+	body, err := json.Marshal(request)
+	assert.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, fullRootPath()+"/webhooks/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, _, err := suite.serveWebhooksRouter(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func (suite *WebhookSuite) TestList() {
+	t := suite.T()
+
+	collection := api.WebhookCollectionResponse{Data: []api.WebhookResponse{{UUID: "abc"}}}
+	suite.reg.Webhook.On("List", mock.Anything, test_handler.MockOrgId, api.PaginationData{Limit: 10, Offset: DefaultOffset}).
+		Return(collection, int64(1), nil)
+
+	req := httptest.NewRequest(http.MethodGet, fullRootPath()+"/webhooks/?limit=10", nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveWebhooksRouter(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	response := api.WebhookCollectionResponse{}
+	assert.NoError(t, json.Unmarshal(body, &response))
+	assert.Equal(t, int64(1), response.Meta.Count)
+	assert.Equal(t, "abc", response.Data[0].UUID)
+}
+
+func (suite *WebhookSuite) TestDelete() {
+	t := suite.T()
+
+	suite.reg.Webhook.On("Delete", mock.Anything, test_handler.MockOrgId, "abc").Return(nil)
+
+	req := httptest.NewRequest(http.MethodDelete, fullRootPath()+"/webhooks/abc", nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, _, err := suite.serveWebhooksRouter(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, code)
+}
+
+func (suite *WebhookSuite) TestListDeliveries() {
+	t := suite.T()
+
+	collection := api.WebhookDeliveryCollectionResponse{Data: []api.WebhookDeliveryResponse{
+		{UUID: "delivery", EventType: api.WebhookEventRepositoryDeleted, Status: "delivered", Attempts: 1},
+	}}
+	suite.reg.Webhook.On("ListDeliveries", mock.Anything, test_handler.MockOrgId, "abc", api.PaginationData{Limit: DefaultLimit, Offset: DefaultOffset}).
+		Return(collection, int64(1), nil)
+
+	req := httptest.NewRequest(http.MethodGet, fullRootPath()+"/webhooks/abc/deliveries/", nil)
+	req.Header.Set(api.IdentityHeader, test_handler.EncodedIdentity(t))
+
+	code, body, err := suite.serveWebhooksRouter(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	response := api.WebhookDeliveryCollectionResponse{}
+	assert.NoError(t, json.Unmarshal(body, &response))
+	assert.Equal(t, collection.Data, response.Data)
+}
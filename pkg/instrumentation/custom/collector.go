@@ -14,10 +14,15 @@ import (
 
 const tickerDelay = 5 // in seconds // could be good to match this with the scrapper frequency
 
+// rowCountsInterval is the time between two counts of the rows of the largest tables, which take
+// longer than the other metrics to compute
+const rowCountsInterval = 5 * time.Minute
+
 type Collector struct {
-	context context.Context
-	metrics *instrumentation.Metrics
-	dao     dao.MetricsDao
+	context       context.Context
+	metrics       *instrumentation.Metrics
+	dao           dao.MetricsDao
+	lastRowCounts time.Time
 }
 
 func NewCollector(context context.Context, metrics *instrumentation.Metrics, db *gorm.DB) *Collector {
@@ -38,20 +43,36 @@ func NewCollector(context context.Context, metrics *instrumentation.Metrics, db
 }
 
 func (c *Collector) iterate() {
-	c.metrics.RepositoriesTotal.Set(float64(c.dao.RepositoriesCount()))
-	c.metrics.RepositoryConfigsTotal.Set(float64(c.dao.RepositoryConfigsCount()))
-	c.metrics.RepositoryConfigsTotal.Set(float64(c.dao.RepositoryConfigsCount()))
-	c.metrics.OrgTotal.Set(float64(c.dao.OrganizationTotal()))
+	c.metrics.RepositoriesTotal.Set(float64(c.dao.RepositoriesCount(c.context)))
+	c.metrics.RepositoryConfigsTotal.Set(float64(c.dao.RepositoryConfigsCount(c.context)))
+	c.metrics.RepositoryConfigsTotal.Set(float64(c.dao.RepositoryConfigsCount(c.context)))
+	c.metrics.OrgTotal.Set(float64(c.dao.OrganizationTotal(c.context)))
 	c.metrics.RHCertExpiryDays.Set(float64(config.Get().Certs.DaysTillExpiration))
 
-	public := c.dao.RepositoriesIntrospectionCount(36, true)
+	public := c.dao.RepositoriesIntrospectionCount(c.context, 36, true)
 	c.metrics.PublicRepositories36HourIntrospectionTotal.With(prometheus.Labels{"status": "introspected"}).Set(float64(public.Introspected))
 	c.metrics.PublicRepositories36HourIntrospectionTotal.With(prometheus.Labels{"status": "missed"}).Set(float64(public.Missed))
 
-	custom := c.dao.RepositoriesIntrospectionCount(36, false)
+	custom := c.dao.RepositoriesIntrospectionCount(c.context, 36, false)
 	c.metrics.CustomRepositories36HourIntrospectionTotal.With(prometheus.Labels{"status": "introspected"}).Set(float64(custom.Introspected))
 	c.metrics.CustomRepositories36HourIntrospectionTotal.With(prometheus.Labels{"status": "missed"}).Set(float64(custom.Missed))
-	c.metrics.PublicRepositoriesWithFailedIntrospectionTotal.Set(float64(c.dao.PublicRepositoriesFailedIntrospectionCount()))
+	c.metrics.PublicRepositoriesWithFailedIntrospectionTotal.Set(float64(c.dao.PublicRepositoriesFailedIntrospectionCount(c.context)))
+	c.metrics.OldestQueuedTaskAge.Set(c.dao.OldestQueuedTaskAge(c.context))
+
+	if time.Since(c.lastRowCounts) >= rowCountsInterval {
+		c.iterateRowCounts()
+	}
+}
+
+func (c *Collector) iterateRowCounts() {
+	counts := c.dao.RowCounts(c.context)
+	for table, rows := range counts.Tables {
+		c.metrics.TableRows.With(prometheus.Labels{"table": table}).Set(float64(rows))
+	}
+	for status, rows := range counts.TasksByStatus {
+		c.metrics.TasksByStatus.With(prometheus.Labels{"status": status}).Set(float64(rows))
+	}
+	c.lastRowCounts = time.Now()
 }
 
 func (c *Collector) Run() {
@@ -19,8 +19,17 @@ const (
 	CustomRepositories36HourIntrospectionTotal     = "custom_repositories_36_hour_introspection_total"
 	MessageLatency                                 = "message_latency"
 	MessageResultTotal                             = "message_result_total"
+	TaskQueueWait                                  = "task_queue_wait"
 	OrgTotal                                       = "org_total"
 	RHCertExpiryDays                               = "rh_cert_expiry_days"
+	DeprecatedRequestsTotal                        = "deprecated_requests_total"
+	TaskDuration                                   = "task_duration"
+	TaskFailuresTotal                              = "task_failures_total"
+	TaskRetriesTotal                               = "task_retries_total"
+	TaskDeadLettersTotal                           = "task_dead_letters_total"
+	OldestQueuedTaskAge                            = "oldest_queued_task_age"
+	TableRows                                      = "table_rows"
+	TasksByStatus                                  = "tasks_by_status"
 )
 
 type Metrics struct {
@@ -34,8 +43,17 @@ type Metrics struct {
 	CustomRepositories36HourIntrospectionTotal     prometheus.GaugeVec
 	MessageResultTotal                             prometheus.CounterVec
 	MessageLatency                                 prometheus.Histogram
+	TaskQueueWait                                  prometheus.HistogramVec
 	OrgTotal                                       prometheus.Gauge
 	RHCertExpiryDays                               prometheus.Gauge
+	DeprecatedRequestsTotal                        prometheus.CounterVec
+	TaskDuration                                   prometheus.HistogramVec
+	TaskFailuresTotal                              prometheus.CounterVec
+	TaskRetriesTotal                               prometheus.CounterVec
+	TaskDeadLettersTotal                           prometheus.CounterVec
+	OldestQueuedTaskAge                            prometheus.Gauge
+	TableRows                                      prometheus.GaugeVec
+	TasksByStatus                                  prometheus.GaugeVec
 	reg                                            *prometheus.Registry
 }
 
@@ -61,6 +79,13 @@ func NewMetrics(reg *prometheus.Registry) *Metrics {
 			//                        1m  5m   30m   1h    2h    3h     5h     10h
 			Buckets: []float64{.5, 1, 60, 300, 1800, 3600, 7200, 10800, 18000, 36000},
 		}),
+		TaskQueueWait: *promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: NameSpace,
+			Name:      TaskQueueWait,
+			Help:      "Seconds tasks waited in the queue, by priority class",
+			//                        1m  5m   30m   1h    2h    3h     5h     10h
+			Buckets: []float64{.5, 1, 60, 300, 1800, 3600, 7200, 10800, 18000, 36000},
+		}, []string{"priority"}),
 		MessageResultTotal: *promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 			Namespace:   NameSpace,
 			Name:        MessageResultTotal,
@@ -102,6 +127,48 @@ func NewMetrics(reg *prometheus.Registry) *Metrics {
 			Name:      RHCertExpiryDays,
 			Help:      "Number of days until the Red Hat client certificate expires",
 		}),
+		DeprecatedRequestsTotal: *promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: NameSpace,
+			Name:      DeprecatedRequestsTotal,
+			Help:      "Requests to deprecated routes, by org",
+		}, []string{"method", "route", "org_id"}),
+		TaskDuration: *promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: NameSpace,
+			Name:      TaskDuration,
+			Help:      "Seconds tasks ran for, by task type and result",
+			//                      1s 10s 1m  5m   10m  30m   1h    2h    5h
+			Buckets: []float64{.1, 1, 10, 60, 300, 600, 1800, 3600, 7200, 18000},
+		}, []string{"type", "result"}),
+		TaskFailuresTotal: *promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: NameSpace,
+			Name:      TaskFailuresTotal,
+			Help:      "Tasks that failed, by task type",
+		}, []string{"type"}),
+		TaskRetriesTotal: *promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: NameSpace,
+			Name:      TaskRetriesTotal,
+			Help:      "Tasks requeued to run again, by task type",
+		}, []string{"type"}),
+		TaskDeadLettersTotal: *promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: NameSpace,
+			Name:      TaskDeadLettersTotal,
+			Help:      "Tasks given up on after running out of retries, by task type",
+		}, []string{"type"}),
+		OldestQueuedTaskAge: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Namespace: NameSpace,
+			Name:      OldestQueuedTaskAge,
+			Help:      "Seconds the oldest task ready to run has been waiting in the queue, 0 when there is none",
+		}),
+		TableRows: *promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: NameSpace,
+			Name:      TableRows,
+			Help:      "Number of rows of the largest tables, by table",
+		}, []string{"table"}),
+		TasksByStatus: *promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: NameSpace,
+			Name:      TasksByStatus,
+			Help:      "Number of rows of the tasks table, by status",
+		}, []string{"status"}),
 	}
 
 	reg.MustRegister(collectors.NewBuildInfoCollector())
@@ -123,6 +190,44 @@ func (m *Metrics) RecordMessageLatency(msgTime time.Time) {
 	m.MessageLatency.Observe(diff.Seconds())
 }
 
+func (m *Metrics) RecordTaskQueueWait(queued time.Time, priority string) {
+	if m != nil {
+		m.TaskQueueWait.With(prometheus.Labels{"priority": priority}).Observe(time.Since(queued).Seconds())
+	}
+}
+
+func (m *Metrics) RecordDeprecatedRequest(method, route, orgID string) {
+	if m != nil {
+		m.DeprecatedRequestsTotal.With(prometheus.Labels{"method": method, "route": route, "org_id": orgID}).Inc()
+	}
+}
+
+func (m *Metrics) RecordTaskDuration(typename string, started time.Time, success bool) {
+	if m == nil {
+		return
+	}
+	result := "failed"
+	if success {
+		result = "success"
+	}
+	m.TaskDuration.With(prometheus.Labels{"type": typename, "result": result}).Observe(time.Since(started).Seconds())
+	if !success {
+		m.TaskFailuresTotal.With(prometheus.Labels{"type": typename}).Inc()
+	}
+}
+
+func (m *Metrics) RecordTaskRetry(typename string) {
+	if m != nil {
+		m.TaskRetriesTotal.With(prometheus.Labels{"type": typename}).Inc()
+	}
+}
+
+func (m *Metrics) RecordTaskDeadLetter(typename string) {
+	if m != nil {
+		m.TaskDeadLettersTotal.With(prometheus.Labels{"type": typename}).Inc()
+	}
+}
+
 func (m Metrics) Registry() *prometheus.Registry {
 	return m.reg
 }
@@ -2,8 +2,10 @@ package instrumentation
 
 import (
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -28,3 +30,36 @@ func TestRegistry(t *testing.T) {
 	assert.NotNil(t, metrics)
 	assert.Equal(t, reg, metrics.Registry())
 }
+
+func TestRecordTaskQueueWait(t *testing.T) {
+	metrics := NewMetrics(prometheus.NewRegistry())
+	metrics.RecordTaskQueueWait(time.Now().Add(-time.Minute), "user")
+	metrics.RecordTaskQueueWait(time.Now(), "background")
+
+	assert.Equal(t, 2, testutil.CollectAndCount(&metrics.TaskQueueWait))
+}
+
+func TestRecordTaskDuration(t *testing.T) {
+	metrics := NewMetrics(prometheus.NewRegistry())
+	metrics.RecordTaskDuration("snapshot", time.Now().Add(-time.Minute), true)
+	metrics.RecordTaskDuration("snapshot", time.Now(), false)
+	metrics.RecordTaskDuration("introspect", time.Now(), false)
+
+	assert.Equal(t, 3, testutil.CollectAndCount(&metrics.TaskDuration))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.TaskFailuresTotal.With(prometheus.Labels{"type": "snapshot"})))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.TaskFailuresTotal.With(prometheus.Labels{"type": "introspect"})))
+
+	var nilMetrics *Metrics
+	nilMetrics.RecordTaskDuration("snapshot", time.Now(), true)
+	nilMetrics.RecordTaskRetry("snapshot")
+}
+
+func TestRecordTaskRetry(t *testing.T) {
+	metrics := NewMetrics(prometheus.NewRegistry())
+	metrics.RecordTaskRetry("snapshot")
+	metrics.RecordTaskRetry("snapshot")
+	metrics.RecordTaskDeadLetter("snapshot")
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.TaskRetriesTotal.With(prometheus.Labels{"type": "snapshot"})))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.TaskDeadLettersTotal.With(prometheus.Labels{"type": "snapshot"})))
+}
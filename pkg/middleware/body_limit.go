@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	echo_middleware "github.com/labstack/echo/v4/middleware"
+)
+
+// BodyLimit rejects with 413 the requests whose body is larger than the limit of their route class,
+// routes accepting uploads are given uploadLimit and all the others limit.  Requests announcing a
+// larger Content-Length are rejected before reading their body.  An empty limit does not limit the body.
+func BodyLimit(limit string, uploadLimit string, isUpload func(c echo.Context) bool) echo.MiddlewareFunc {
+	withLimit := func(limit string) echo.MiddlewareFunc {
+		if limit == "" {
+			return func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+		}
+		return echo_middleware.BodyLimit(limit)
+	}
+	limited := withLimit(limit)
+	uploadLimited := withLimit(uploadLimit)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		limitedNext := limited(next)
+		uploadLimitedNext := uploadLimited(next)
+		return func(c echo.Context) error {
+			if c.Request().Body == http.NoBody {
+				return next(c)
+			}
+			if isUpload(c) {
+				return uploadLimitedNext(c)
+			}
+			return limitedNext(c)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveBodyLimitRouter(path string, body string) int {
+	router := echo.New()
+	router.Use(BodyLimit("10B", "20B", func(c echo.Context) bool {
+		return c.Path() == "/upload"
+	}))
+	router.HTTPErrorHandler = config.CustomHTTPErrorHandler
+	for _, route := range []string{"/json", "/upload"} {
+		router.POST(route, func(c echo.Context) error {
+			var data map[string]interface{}
+			if err := c.Bind(&data); err != nil {
+				return err
+			}
+			return c.NoContent(http.StatusNoContent)
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr.Code
+}
+
+func TestBodyLimit(t *testing.T) {
+	small := `{"a":"b"}`
+	large := `{"a":"bcdefghij"}`
+	assert.Equal(t, http.StatusNoContent, serveBodyLimitRouter("/json", small))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, serveBodyLimitRouter("/json", large))
+	assert.Equal(t, http.StatusNoContent, serveBodyLimitRouter("/upload", large))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, serveBodyLimitRouter("/upload", large+large))
+}
+
+func TestBodyLimitNoLimit(t *testing.T) {
+	router := echo.New()
+	router.Use(BodyLimit("", "", func(c echo.Context) bool { return false }))
+	router.POST("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("a", 10000)))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	handler_utils "github.com/content-services/content-sources-backend/pkg/handler/utils"
+	"github.com/content-services/content-sources-backend/pkg/instrumentation"
+	"github.com/labstack/echo/v4"
+	"github.com/redhatinsights/platform-go-middlewares/identity"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	HeaderDeprecation = "Deprecation"
+	HeaderSunset      = "Sunset"
+	HeaderLink        = "Link"
+	deprecationLayout = "2006-01-02"
+)
+
+type deprecation struct {
+	version string
+	since   *time.Time
+	sunset  *time.Time
+	link    string
+}
+
+// Deprecation marks the configured routes deprecated, see https://www.rfc-editor.org/rfc/rfc9745
+// and https://www.rfc-editor.org/rfc/rfc8594.  Requests to deprecated routes are counted per org,
+// so that the orgs still calling them can be found before they are removed.
+func Deprecation(routes []config.DeprecatedRoute, metrics *instrumentation.Metrics) echo.MiddlewareFunc {
+	deprecations := parseDeprecations(routes)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if len(deprecations) == 0 {
+				return next(c)
+			}
+			route := c.Path()
+			version, relative := splitRoute(route)
+			if relative == "" {
+				return next(c)
+			}
+			method := c.Request().Method
+			d, ok := findDeprecation(deprecations[method+" "+relative], version)
+			if !ok {
+				return next(c)
+			}
+
+			header := c.Response().Header()
+			if d.since != nil {
+				header.Set(HeaderDeprecation, fmt.Sprintf("@%d", d.since.Unix()))
+			} else {
+				header.Set(HeaderDeprecation, "true")
+			}
+			if d.sunset != nil {
+				header.Set(HeaderSunset, d.sunset.Format(http.TimeFormat))
+			}
+			if d.link != "" {
+				header.Add(HeaderLink, fmt.Sprintf("<%s>; rel=\"deprecation\"", d.link))
+			}
+			if id, ok := c.Request().Context().Value(identity.Key).(identity.XRHID); ok {
+				metrics.RecordDeprecatedRequest(method, route, id.Identity.Internal.OrgID)
+			}
+			return next(c)
+		}
+	}
+}
+
+// parseDeprecations indexes the deprecated routes by method and route relative to the api version
+func parseDeprecations(routes []config.DeprecatedRoute) map[string][]deprecation {
+	deprecations := make(map[string][]deprecation)
+	for _, route := range routes {
+		d := deprecation{
+			version: strings.TrimPrefix(route.Version, "v"),
+			since:   parseDeprecationDay(route.Since, route),
+			sunset:  parseDeprecationDay(route.Sunset, route),
+			link:    route.Link,
+		}
+		key := strings.ToUpper(route.Method) + " " + strings.Trim(route.Route, "/")
+		deprecations[key] = append(deprecations[key], d)
+	}
+	return deprecations
+}
+
+func parseDeprecationDay(day string, route config.DeprecatedRoute) *time.Time {
+	if day == "" {
+		return nil
+	}
+	parsed, err := time.Parse(deprecationLayout, day)
+	if err != nil {
+		log.Error().Err(err).Msgf("Invalid day in the deprecation of %s %s", route.Method, route.Route)
+		return nil
+	}
+	return &parsed
+}
+
+// splitRoute returns the major version and the rest of a route, e.g. "1" and "repositories/:uuid"
+// for /api/content-sources/v1.0/repositories/:uuid
+func splitRoute(route string) (string, string) {
+	path := handler_utils.NewPathWithString(route)
+	relative := path.RemovePrefixes()
+	if len(relative) == 0 {
+		return "", ""
+	}
+	version := strings.TrimPrefix(path[len(path)-len(relative)-1], "v")
+	version = strings.SplitN(version, ".", 2)[0]
+	return version, strings.Join(relative, "/")
+}
+
+func findDeprecation(deprecations []deprecation, version string) (deprecation, bool) {
+	for _, d := range deprecations {
+		if d.version == "" || strings.SplitN(d.version, ".", 2)[0] == version {
+			return d, true
+		}
+	}
+	return deprecation{}, false
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/instrumentation"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redhatinsights/platform-go-middlewares/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveDeprecationRouter(metrics *instrumentation.Metrics, method string, path string, orgID string) http.Header {
+	e := echo.New()
+	e.Use(Deprecation([]config.DeprecatedRoute{
+		{Method: "post", Route: "/repositories/bulk_create/", Version: "1", Since: "2023-09-01", Sunset: "2024-03-01", Link: "https://example.com/bulk_create"},
+		{Method: http.MethodGet, Route: "/rpms/names/"},
+	}, metrics))
+	handler := func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}
+	for _, version := range []string{"v1", "v1.0", "v2"} {
+		e.POST("/api/content-sources/"+version+"/repositories/bulk_create/", handler)
+		e.GET("/api/content-sources/"+version+"/repositories/", handler)
+		e.GET("/api/content-sources/"+version+"/rpms/names/", handler)
+	}
+
+	req := httptest.NewRequest(method, path, nil)
+	if orgID != "" {
+		id := identity.XRHID{Identity: identity.Identity{Internal: identity.Internal{OrgID: orgID}}}
+		req = req.WithContext(context.WithValue(req.Context(), identity.Key, id))
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec.Header()
+}
+
+func TestDeprecationHeaders(t *testing.T) {
+	metrics := instrumentation.NewMetrics(prometheus.NewRegistry())
+
+	header := serveDeprecationRouter(metrics, http.MethodPost, "/api/content-sources/v1.0/repositories/bulk_create/", "org1")
+	assert.Equal(t, "@1693526400", header.Get(HeaderDeprecation))
+	assert.Equal(t, "Fri, 01 Mar 2024 00:00:00 GMT", header.Get(HeaderSunset))
+	assert.Equal(t, "<https://example.com/bulk_create>; rel=\"deprecation\"", header.Get(HeaderLink))
+
+	// Deprecated in all versions, without a date
+	header = serveDeprecationRouter(metrics, http.MethodGet, "/api/content-sources/v2/rpms/names/", "org1")
+	assert.Equal(t, "true", header.Get(HeaderDeprecation))
+	assert.Empty(t, header.Get(HeaderSunset))
+
+	// Not deprecated in v2, nor other routes
+	header = serveDeprecationRouter(metrics, http.MethodPost, "/api/content-sources/v2/repositories/bulk_create/", "org1")
+	assert.Empty(t, header.Get(HeaderDeprecation))
+	header = serveDeprecationRouter(metrics, http.MethodGet, "/api/content-sources/v1/repositories/", "org1")
+	assert.Empty(t, header.Get(HeaderDeprecation))
+}
+
+func TestDeprecationCountsPerOrg(t *testing.T) {
+	metrics := instrumentation.NewMetrics(prometheus.NewRegistry())
+	route := "/api/content-sources/v1/repositories/bulk_create/"
+
+	serveDeprecationRouter(metrics, http.MethodPost, route, "org1")
+	serveDeprecationRouter(metrics, http.MethodPost, route, "org1")
+	serveDeprecationRouter(metrics, http.MethodPost, route, "org2")
+	// Not counted without an identity
+	serveDeprecationRouter(metrics, http.MethodPost, route, "")
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(metrics.DeprecatedRequestsTotal.WithLabelValues(http.MethodPost, route, "org1")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.DeprecatedRequestsTotal.WithLabelValues(http.MethodPost, route, "org2")))
+}
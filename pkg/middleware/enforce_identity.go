@@ -2,9 +2,11 @@ package middleware
 
 import (
 	"net/http"
+	"path"
 	"strings"
 
 	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/signing"
 	"github.com/labstack/echo/v4"
 	echo_middleware "github.com/labstack/echo/v4/middleware"
 )
@@ -30,8 +32,17 @@ func WrapMiddlewareWithSkipper(m func(http.Handler) http.Handler, skip echo_midd
 	}
 }
 
+// SkipAuth skips identity and rbac checks of liveness, openapi and internal
+// routes.  Internal routes are authenticated with EnforcePSK instead.
 func SkipAuth(c echo.Context) bool {
 	p := c.Request().URL.Path
+	if strings.HasPrefix(p, path.Join("/", config.Get().PathPrefix, config.Get().AppName, "internal")+"/") {
+		return true
+	}
+	// Signed content urls are authenticated by their signature
+	if strings.HasPrefix(p, signing.ContentPath()) {
+		return true
+	}
 	skipped := []string{"ping", "openapi.json"}
 	for i := 0; i < len(skipped); i++ {
 		path := skipped[i]
@@ -20,6 +20,8 @@ func TestSkipLivenessTrue(t *testing.T) {
 		"/ping",
 		urlPrefix + "/v1.0/ping",
 		urlPrefix + "/v1/ping",
+		urlPrefix + "/internal/v1/snapshots/for_date/",
+		urlPrefix + "/content/token/domain/snapshot/repodata/repomd.xml",
 	}
 	e := echo.New()
 	handler.RegisterPing(e)
@@ -54,6 +56,24 @@ func TestSkipLivenessFalse(t *testing.T) {
 	}
 }
 
+func TestSkipAuthFollowsPathPrefix(t *testing.T) {
+	prefix, appName := config.Get().PathPrefix, config.Get().AppName
+	t.Cleanup(func() { config.Get().PathPrefix, config.Get().AppName = prefix, appName })
+	config.Get().PathPrefix, config.Get().AppName = "beta/api", "sources"
+
+	e := echo.New()
+	for route, skipped := range map[string]bool{
+		"/beta/api/sources/internal/v1/snapshots/for_date/": true,
+		"/beta/api/sources/content/token/domain/snapshot/":  true,
+		urlPrefix + "/internal/v1/snapshots/for_date/":      false,
+		urlPrefix + "/content/token/domain/snapshot/":       false,
+		"/beta/api/sources/v1/repositories/":                false,
+	} {
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, route, nil), httptest.NewRecorder())
+		assert.Equal(t, skipped, SkipAuth(c), route)
+	}
+}
+
 func TestWrapMiddlewareWithSkipper(t *testing.T) {
 	var (
 		req              *http.Request
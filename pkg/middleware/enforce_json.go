@@ -9,6 +9,7 @@ import (
 )
 
 const JSONMimeType = "application/json"
+const MultipartFormMimeType = "multipart/form-data"
 
 func enforceJSONContentTypeSkipper(c echo.Context) bool {
 	return c.Request().Body == http.NoBody
@@ -29,3 +30,18 @@ func EnforceJSONContentType(next echo.HandlerFunc) echo.HandlerFunc {
 		return next(c)
 	}
 }
+
+// EnforceJSONContentTypeOrUpload is EnforceJSONContentType, except that routes accepting uploads
+// also accept files sent as multipart forms
+func EnforceJSONContentTypeOrUpload(isUpload func(c echo.Context) bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		enforced := EnforceJSONContentType(next)
+		return func(c echo.Context) error {
+			mediatype, _, err := mime.ParseMediaType(c.Request().Header.Get("Content-Type"))
+			if err == nil && mediatype == MultipartFormMimeType && isUpload(c) {
+				return next(c)
+			}
+			return enforced(c)
+		}
+	}
+}
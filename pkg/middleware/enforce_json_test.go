@@ -87,3 +87,24 @@ func TestSkippedRoutes(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusOK, status)
 }
+
+func TestMultipartUpload(t *testing.T) {
+	router := echo.New()
+	router.Use(EnforceJSONContentTypeOrUpload(func(c echo.Context) bool {
+		return c.Path() == "/upload"
+	}))
+	router.HTTPErrorHandler = config.CustomHTTPErrorHandler
+	for _, path := range []string{"/json", "/upload"} {
+		router.POST(path, func(c echo.Context) error {
+			return c.NoContent(http.StatusNoContent)
+		})
+	}
+
+	for path, expected := range map[string]int{"/json": http.StatusUnsupportedMediaType, "/upload": http.StatusNoContent} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("body"))
+		req.Header.Set("Content-Type", "multipart/form-data; boundary=xyz")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, expected, rr.Code, path)
+	}
+}
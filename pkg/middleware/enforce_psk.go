@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/labstack/echo/v4"
+	"github.com/redhatinsights/platform-go-middlewares/identity"
+)
+
+const (
+	HeaderPSK   = "X-Rh-Content-Sources-Psk"    // pre shared key of internal callers
+	HeaderOrgID = "X-Rh-Content-Sources-Org-Id" // org the internal caller acts on behalf of
+)
+
+// EnforcePSK authenticates internal callers with one of the given pre shared keys
+// instead of an identity header.  The org id the caller acts on behalf of is read
+// from the HeaderOrgID header and added to the request context as an identity, so
+// handlers read it the same way as for identity authenticated requests.
+func EnforcePSK(psks []string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !validPSK(psks, c.Request().Header.Get(HeaderPSK)) {
+				return ce.NewErrorResponse(http.StatusUnauthorized, "Unauthorized", "missing or invalid pre shared key")
+			}
+			orgID := c.Request().Header.Get(HeaderOrgID)
+			if orgID == "" {
+				return ce.NewErrorResponse(http.StatusBadRequest, "Missing org id", HeaderOrgID+" header is required")
+			}
+			id := identity.XRHID{Identity: identity.Identity{
+				OrgID:    orgID,
+				Internal: identity.Internal{OrgID: orgID},
+				Type:     "Internal",
+				AuthType: "psk",
+			}}
+			c.SetRequest(c.Request().WithContext(context.WithValue(c.Request().Context(), identity.Key, id)))
+			return next(c)
+		}
+	}
+}
+
+func validPSK(psks []string, key string) bool {
+	if key == "" {
+		return false
+	}
+	valid := false
+	for _, psk := range psks {
+		if psk != "" && subtle.ConstantTimeCompare([]byte(psk), []byte(key)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/labstack/echo/v4"
+	"github.com/redhatinsights/platform-go-middlewares/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+func servePSKRouter(psk string, orgID string) (int, string) {
+	router := echo.New()
+	router.HTTPErrorHandler = config.CustomHTTPErrorHandler
+	router.Use(EnforcePSK([]string{"first", "second"}))
+
+	var seenOrgID string
+	router.GET("/", func(c echo.Context) error {
+		seenOrgID = identity.Get(c.Request().Context()).Identity.Internal.OrgID
+		return c.JSON(http.StatusOK, map[string]string{"Status": "OK"})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if psk != "" {
+		req.Header.Set(HeaderPSK, psk)
+	}
+	if orgID != "" {
+		req.Header.Set(HeaderOrgID, orgID)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr.Result().StatusCode, seenOrgID
+}
+
+func TestEnforcePSK(t *testing.T) {
+	status, orgID := servePSKRouter("second", "org1")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "org1", orgID)
+
+	status, _ = servePSKRouter("", "org1")
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	status, _ = servePSKRouter("wrong", "org1")
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	status, _ = servePSKRouter("first", "")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestValidPSK(t *testing.T) {
+	assert.False(t, validPSK([]string{}, ""))
+	assert.False(t, validPSK([]string{""}, ""))
+	assert.False(t, validPSK([]string{"key"}, "other"))
+	assert.True(t, validPSK([]string{"other", "key"}, "key"))
+}
@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Gzip compresses the responses of clients accepting gzip with the given level,
+// once the body reaches minLength bytes.  Smaller bodies are sent uncompressed, as
+// compressing them costs more than it saves.  A level of 0 disables compression.
+// The responses of the routes skipped, such as the ones streaming content that is
+// already compressed, are never compressed.
+func Gzip(level int, minLength int, skip func(c echo.Context) bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if level == 0 {
+			return next
+		}
+		return func(c echo.Context) error {
+			if skip(c) {
+				return next(c)
+			}
+			c.Response().Header().Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+			if c.Request().Method == http.MethodHead ||
+				!acceptsGzip(c.Request().Header.Get(echo.HeaderAcceptEncoding)) {
+				return next(c)
+			}
+
+			w := &gzipResponseWriter{ResponseWriter: c.Response().Writer, level: level, minLength: minLength}
+			c.Response().Writer = w
+			defer func() {
+				if err := w.finish(); err != nil {
+					c.Logger().Error(err)
+				}
+				c.Response().Writer = w.ResponseWriter
+			}()
+			return next(c)
+		}
+	}
+}
+
+// acceptsGzip returns whether an Accept-Encoding header accepts gzip, listing it or, when it
+// does not, the * wildcard with a quality above 0.  gzip;q=0 refuses gzip.
+func acceptsGzip(acceptEncoding string) bool {
+	gzipQuality, anyQuality := -1.0, -1.0
+	for _, accepted := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(accepted, ";")
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, found := strings.Cut(param, "=")
+			if found && strings.EqualFold(strings.TrimSpace(name), "q") {
+				parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				if err != nil {
+					parsed = 0
+				}
+				quality = parsed
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(coding)) {
+		case "gzip", "x-gzip":
+			gzipQuality = quality
+		case "*":
+			anyQuality = quality
+		}
+	}
+	if gzipQuality >= 0 {
+		return gzipQuality > 0
+	}
+	return anyQuality > 0
+}
+
+// gzipResponseWriter buffers the body until it reaches minLength, then either
+// compresses it or, if the response ends first, writes it as is.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	level       int
+	minLength   int
+	status      int
+	buf         []byte
+	gz          *gzip.Writer
+	passThrough bool
+}
+
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	switch {
+	case w.gz != nil:
+		return w.gz.Write(b)
+	case w.passThrough:
+		return w.ResponseWriter.Write(b)
+	}
+	w.buf = append(w.buf, b...)
+	if len(w.buf) < w.minLength {
+		return len(b), nil
+	}
+	if err := w.startGzip(); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (w *gzipResponseWriter) startGzip() error {
+	header := w.Header()
+	if header.Get(echo.HeaderContentEncoding) != "" {
+		// already encoded by the handler
+		return w.startPassThrough()
+	}
+	if strings.HasPrefix(header.Get(echo.HeaderContentType), "text/event-stream") {
+		// events are sent as they happen, compressing would hold them back
+		return w.startPassThrough()
+	}
+	header.Set(echo.HeaderContentEncoding, "gzip")
+	header.Del(echo.HeaderContentLength)
+	w.writeHeader()
+
+	gz, err := gzip.NewWriterLevel(w.ResponseWriter, w.level)
+	if err != nil {
+		return err
+	}
+	w.gz = gz
+	_, err = w.gz.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+func (w *gzipResponseWriter) startPassThrough() error {
+	w.passThrough = true
+	w.writeHeader()
+	_, err := w.ResponseWriter.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+func (w *gzipResponseWriter) writeHeader() {
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+// finish writes what is left of the response.  When the handler wrote nothing, typically as it
+// returned an error, nothing is written, so that the error handler can still send its response.
+func (w *gzipResponseWriter) finish() error {
+	switch {
+	case w.gz != nil:
+		return w.gz.Close()
+	case w.passThrough:
+		return nil
+	case w.status == 0 && len(w.buf) == 0:
+		return nil
+	}
+	return w.startPassThrough()
+}
+
+// Flush sends the buffered body uncompressed if compression has not started yet,
+// streamed responses are not held back until they reach minLength.
+func (w *gzipResponseWriter) Flush() {
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	} else if !w.passThrough {
+		_ = w.startPassThrough()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *gzipResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("response writer does not support hijacking")
+}
@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func neverSkip(c echo.Context) bool {
+	return false
+}
+
+func serveGzipRouter(t *testing.T, level int, body string, acceptEncoding string) *http.Response {
+	e := echo.New()
+	e.Use(Gzip(level, 100, neverSkip))
+	e.GET("/", func(c echo.Context) error {
+		return c.String(http.StatusCreated, body)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if acceptEncoding != "" {
+		req.Header.Set(echo.HeaderAcceptEncoding, acceptEncoding)
+	}
+	rr := httptest.NewRecorder()
+	e.ServeHTTP(rr, req)
+	response := rr.Result()
+	t.Cleanup(func() { response.Body.Close() })
+	return response
+}
+
+func TestGzipLargeBody(t *testing.T) {
+	body := strings.Repeat("package ", 100)
+	response := serveGzipRouter(t, 5, body, "gzip, deflate")
+
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+	assert.Equal(t, "gzip", response.Header.Get(echo.HeaderContentEncoding))
+	assert.Contains(t, response.Header.Values(echo.HeaderVary), echo.HeaderAcceptEncoding)
+	reader, err := gzip.NewReader(response.Body)
+	require.NoError(t, err)
+	uncompressed, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(uncompressed))
+}
+
+func TestGzipSmallBody(t *testing.T) {
+	response := serveGzipRouter(t, 5, "small", "gzip")
+
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+	assert.Empty(t, response.Header.Get(echo.HeaderContentEncoding))
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "small", string(body))
+}
+
+func TestGzipNotAccepted(t *testing.T) {
+	body := strings.Repeat("package ", 100)
+	response := serveGzipRouter(t, 5, body, "")
+
+	assert.Empty(t, response.Header.Get(echo.HeaderContentEncoding))
+	received, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(received))
+}
+
+func TestGzipDisabled(t *testing.T) {
+	body := strings.Repeat("package ", 100)
+	response := serveGzipRouter(t, 0, body, "gzip")
+
+	assert.Empty(t, response.Header.Get(echo.HeaderContentEncoding))
+	received, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(received))
+}
+
+func TestGzipEventStream(t *testing.T) {
+	e := echo.New()
+	e.Use(Gzip(5, 100, neverSkip))
+	body := strings.Repeat("data: {}\n\n", 100)
+	e.GET("/", func(c echo.Context) error {
+		return c.Blob(http.StatusOK, "text/event-stream", []byte(body))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+	rr := httptest.NewRecorder()
+	e.ServeHTTP(rr, req)
+
+	// Events are never held back to be compressed
+	assert.Empty(t, rr.Header().Get(echo.HeaderContentEncoding))
+	assert.Equal(t, body, rr.Body.String())
+}
+
+func TestGzipHandlerError(t *testing.T) {
+	e := echo.New()
+	e.Use(Gzip(5, 100, neverSkip))
+	e.GET("/", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusNotFound, "not found")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+	rr := httptest.NewRecorder()
+	e.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Empty(t, rr.Header().Get(echo.HeaderContentEncoding))
+	assert.Contains(t, rr.Body.String(), "not found")
+}
+
+func TestGzipSkipped(t *testing.T) {
+	e := echo.New()
+	e.Use(Gzip(5, 100, func(c echo.Context) bool { return c.Path() == "/content" }))
+	body := strings.Repeat("package ", 100)
+	e.GET("/content", func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentLength, strconv.Itoa(len(body)))
+		return c.String(http.StatusOK, body)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/content", nil)
+	req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+	rr := httptest.NewRecorder()
+	e.ServeHTTP(rr, req)
+
+	assert.Empty(t, rr.Header().Get(echo.HeaderContentEncoding))
+	assert.Equal(t, strconv.Itoa(len(body)), rr.Header().Get(echo.HeaderContentLength))
+	assert.Equal(t, body, rr.Body.String())
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	for acceptEncoding, accepted := range map[string]bool{
+		"gzip":                  true,
+		"deflate, gzip;q=0.5":   true,
+		"GZIP ; Q=1":            true,
+		"*":                     true,
+		"br, *;q=0.1":           true,
+		"":                      false,
+		"deflate, br":           false,
+		"gzip;q=0":              false,
+		"gzip;q=0.0, deflate":   false,
+		"*;q=1, gzip;q=0":       false,
+		"*;q=0":                 false,
+		"identity, gzip;q=oops": false,
+	} {
+		assert.Equal(t, accepted, acceptsGzip(acceptEncoding), acceptEncoding)
+	}
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/labstack/echo/v4"
+	"github.com/redhatinsights/platform-go-middlewares/identity"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// idempotencyRecorder copies the response written by the handler, to save it for retries
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *idempotencyRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// Idempotency replays the response of a POST request to its retries, when the client sends
+// an Idempotency-Key header, so that a client retrying after a network error does not create
+// the same repositories twice.  Keys are scoped to the org, and a key sent with another
+// request, or while the first request is still in progress, is rejected.  Requests failing
+// with an error are not saved, their retries run again.  The body of the request is read to
+// fingerprint it, so the routes accepting uploads, whose bodies are streamed rather than held
+// in memory, are skipped.
+func Idempotency(idempotencyDao dao.IdempotencyDao, ttl time.Duration, isUpload func(c echo.Context) bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(HeaderIdempotencyKey)
+			if key == "" || c.Request().Method != http.MethodPost || SkipAuth(c) || isUpload(c) {
+				return next(c)
+			}
+			id, ok := c.Request().Context().Value(identity.Key).(identity.XRHID)
+			if !ok {
+				return next(c)
+			}
+			orgID := id.Identity.Internal.OrgID
+			if len(key) > maxIdempotencyKeyLength {
+				return ce.NewErrorResponse(http.StatusBadRequest, "Invalid Idempotency-Key", "Idempotency-Key must not be longer than 255 characters")
+			}
+
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return ce.NewErrorResponse(http.StatusBadRequest, "Error reading request body", err.Error())
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+			fingerprint := requestFingerprint(c.Request().Method, c.Request().URL.Path, body)
+
+			reserved, err := idempotencyDao.Reserve(c.Request().Context(), orgID, key, fingerprint, ttl)
+			if err != nil {
+				log.Error().Err(err).Msg("Error reserving idempotency key, running the request without it")
+				return next(c)
+			}
+			if !reserved {
+				return replay(c, idempotencyDao, orgID, key, fingerprint)
+			}
+
+			recorder := &idempotencyRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+			err = next(c)
+			c.Response().Writer = recorder.ResponseWriter
+
+			status := c.Response().Status
+			if err != nil || !c.Response().Committed || status >= http.StatusInternalServerError {
+				if releaseErr := idempotencyDao.Release(c.Request().Context(), orgID, key); releaseErr != nil {
+					log.Error().Err(releaseErr).Msg("Error releasing idempotency key")
+				}
+				return err
+			}
+			contentType := c.Response().Header().Get(echo.HeaderContentType)
+			if completeErr := idempotencyDao.Complete(c.Request().Context(), orgID, key, status, contentType, recorder.body.Bytes()); completeErr != nil {
+				log.Error().Err(completeErr).Msg("Error saving the response of an idempotent request")
+			}
+			return nil
+		}
+	}
+}
+
+// replay writes the response saved for the key, if the request matches the one it was saved for
+func replay(c echo.Context, idempotencyDao dao.IdempotencyDao, orgID, key, fingerprint string) error {
+	saved, err := idempotencyDao.Fetch(c.Request().Context(), orgID, key)
+	if err != nil {
+		// The first request failed and released the key in between
+		return ce.NewErrorResponse(http.StatusConflict, "Idempotency-Key in use", "A request with this Idempotency-Key is in progress, retry later")
+	}
+	if saved.Fingerprint != fingerprint {
+		return ce.NewErrorResponse(http.StatusUnprocessableEntity, "Idempotency-Key reused", "Idempotency-Key was already used for a different request")
+	}
+	if saved.StatusCode == 0 {
+		return ce.NewErrorResponse(http.StatusConflict, "Idempotency-Key in use", "A request with this Idempotency-Key is in progress, retry later")
+	}
+	c.Response().Header().Set(HeaderIdempotentReplayed, "true")
+	if len(saved.Body) == 0 {
+		return c.NoContent(saved.StatusCode)
+	}
+	return c.Blob(saved.StatusCode, saved.ContentType, saved.Body)
+}
+
+// requestFingerprint identifies a request by its method, path and body
+func requestFingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/labstack/echo/v4"
+	"github.com/redhatinsights/platform-go-middlewares/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const idempotencyPath = "/api/content-sources/v1/repositories/"
+const idempotencyBody = `{"name":"repo"}`
+
+// serveIdempotencyRouter posts the body with the key, returning the response and the
+// number of times the handler ran
+func serveIdempotencyRouter(idempotencyDao dao.IdempotencyDao, key string, handlerErr error) (*httptest.ResponseRecorder, int) {
+	calls := 0
+	e := echo.New()
+	e.HTTPErrorHandler = config.CustomHTTPErrorHandler
+	e.Use(Idempotency(idempotencyDao, time.Hour, neverSkip))
+	e.POST(idempotencyPath, func(c echo.Context) error {
+		calls++
+		if handlerErr != nil {
+			return handlerErr
+		}
+		return c.JSON(http.StatusCreated, map[string]string{"uuid": "abc"})
+	})
+
+	req := httptest.NewRequest(http.MethodPost, idempotencyPath, strings.NewReader(idempotencyBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if key != "" {
+		req.Header.Set(HeaderIdempotencyKey, key)
+	}
+	id := identity.XRHID{Identity: identity.Identity{Internal: identity.Internal{OrgID: "org1"}}}
+	req = req.WithContext(context.WithValue(req.Context(), identity.Key, id))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec, calls
+}
+
+func TestIdempotencyFirstRequest(t *testing.T) {
+	idempotencyDao := dao.NewMockIdempotencyDao(t)
+	fingerprint := requestFingerprint(http.MethodPost, idempotencyPath, []byte(idempotencyBody))
+	idempotencyDao.On("Reserve", mock.Anything, "org1", "key", fingerprint, time.Hour).Return(true, nil)
+	idempotencyDao.On("Complete", mock.Anything, "org1", "key", http.StatusCreated, echo.MIMEApplicationJSONCharsetUTF8, mock.MatchedBy(func(body []byte) bool {
+		return strings.TrimSpace(string(body)) == `{"uuid":"abc"}`
+	})).Return(nil)
+
+	rec, calls := serveIdempotencyRouter(idempotencyDao, "key", nil)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, 1, calls)
+	assert.Empty(t, rec.Header().Get(HeaderIdempotentReplayed))
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	idempotencyDao := dao.NewMockIdempotencyDao(t)
+	fingerprint := requestFingerprint(http.MethodPost, idempotencyPath, []byte(idempotencyBody))
+	idempotencyDao.On("Reserve", mock.Anything, "org1", "key", fingerprint, time.Hour).Return(false, nil)
+	idempotencyDao.On("Fetch", mock.Anything, "org1", "key").Return(models.IdempotencyKey{
+		Fingerprint: fingerprint,
+		StatusCode:  http.StatusCreated,
+		ContentType: echo.MIMEApplicationJSON,
+		Body:        []byte(`{"uuid":"abc"}`),
+	}, nil)
+
+	rec, calls := serveIdempotencyRouter(idempotencyDao, "key", nil)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, "true", rec.Header().Get(HeaderIdempotentReplayed))
+	assert.Equal(t, `{"uuid":"abc"}`, rec.Body.String())
+}
+
+func TestIdempotencyConflicts(t *testing.T) {
+	fingerprint := requestFingerprint(http.MethodPost, idempotencyPath, []byte(idempotencyBody))
+
+	// The key was used for another request
+	idempotencyDao := dao.NewMockIdempotencyDao(t)
+	idempotencyDao.On("Reserve", mock.Anything, "org1", "key", fingerprint, time.Hour).Return(false, nil)
+	idempotencyDao.On("Fetch", mock.Anything, "org1", "key").Return(models.IdempotencyKey{Fingerprint: "other", StatusCode: http.StatusCreated}, nil)
+	rec, calls := serveIdempotencyRouter(idempotencyDao, "key", nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, 0, calls)
+
+	// The first request is in progress
+	idempotencyDao = dao.NewMockIdempotencyDao(t)
+	idempotencyDao.On("Reserve", mock.Anything, "org1", "key", fingerprint, time.Hour).Return(false, nil)
+	idempotencyDao.On("Fetch", mock.Anything, "org1", "key").Return(models.IdempotencyKey{Fingerprint: fingerprint}, nil)
+	rec, calls = serveIdempotencyRouter(idempotencyDao, "key", nil)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, 0, calls)
+}
+
+func TestIdempotencyReleasesFailedRequest(t *testing.T) {
+	idempotencyDao := dao.NewMockIdempotencyDao(t)
+	fingerprint := requestFingerprint(http.MethodPost, idempotencyPath, []byte(idempotencyBody))
+	idempotencyDao.On("Reserve", mock.Anything, "org1", "key", fingerprint, time.Hour).Return(true, nil)
+	idempotencyDao.On("Release", mock.Anything, "org1", "key").Return(nil)
+
+	rec, calls := serveIdempotencyRouter(idempotencyDao, "key", ce.NewErrorResponse(http.StatusBadRequest, "Invalid", "invalid request"))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotencyWithoutKey(t *testing.T) {
+	// No call to the dao is expected
+	idempotencyDao := dao.NewMockIdempotencyDao(t)
+	rec, calls := serveIdempotencyRouter(idempotencyDao, "", nil)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, 1, calls)
+
+	rec, calls = serveIdempotencyRouter(idempotencyDao, strings.Repeat("k", 256), nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, 0, calls)
+}
+
+func TestIdempotencySkipsUploads(t *testing.T) {
+	idempotencyDao := dao.NewMockIdempotencyDao(t)
+	e := echo.New()
+	e.Use(Idempotency(idempotencyDao, time.Hour, func(c echo.Context) bool { return true }))
+	e.POST(idempotencyPath, func(c echo.Context) error {
+		return c.NoContent(http.StatusCreated)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, idempotencyPath, strings.NewReader(idempotencyBody))
+	req.Header.Set(HeaderIdempotencyKey, "key")
+	id := identity.XRHID{Identity: identity.Identity{Internal: identity.Internal{OrgID: "org1"}}}
+	req = req.WithContext(context.WithValue(req.Context(), identity.Key, id))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	// The key is not reserved, the mock failing on any call
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
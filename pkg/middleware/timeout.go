@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/labstack/echo/v4"
+)
+
+// RequestTimeout gives the requests a deadline, after which their context is canceled, so that
+// the queries and the calls to other services made for a request are abandoned once it can no
+// longer be answered in time, as they are when the client disconnects.  A request that ran out of
+// time is answered with 504.  Requests for which isExempt is true, such as streams and uploads
+// whose duration depends on the client, are not given a deadline.  A zero timeout does not limit
+// the requests.
+func RequestTimeout(timeout time.Duration, isExempt func(c echo.Context) bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if timeout <= 0 {
+			return next
+		}
+		return func(c echo.Context) error {
+			if isExempt(c) {
+				return next(c)
+			}
+			ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
+			defer cancel()
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			err := next(c)
+			if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Response().Committed {
+				return ce.NewErrorResponse(http.StatusGatewayTimeout, "Request timed out", fmt.Sprintf("The request could not be completed within %v", timeout))
+			}
+			return err
+		}
+	}
+}
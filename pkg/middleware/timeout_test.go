@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveTimeoutRouter(timeout time.Duration, path string) int {
+	router := echo.New()
+	router.Use(RequestTimeout(timeout, func(c echo.Context) bool {
+		return c.Path() == "/events"
+	}))
+	router.HTTPErrorHandler = config.CustomHTTPErrorHandler
+	for _, route := range []string{"/slow", "/events"} {
+		router.GET(route, func(c echo.Context) error {
+			select {
+			case <-c.Request().Context().Done():
+				return c.Request().Context().Err()
+			case <-time.After(50 * time.Millisecond):
+				return c.NoContent(http.StatusNoContent)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr.Code
+}
+
+func TestRequestTimeout(t *testing.T) {
+	assert.Equal(t, http.StatusGatewayTimeout, serveTimeoutRouter(10*time.Millisecond, "/slow"))
+	assert.Equal(t, http.StatusNoContent, serveTimeoutRouter(time.Second, "/slow"))
+	assert.Equal(t, http.StatusNoContent, serveTimeoutRouter(0, "/slow"))
+}
+
+func TestRequestTimeoutSkipsStreams(t *testing.T) {
+	assert.Equal(t, http.StatusNoContent, serveTimeoutRouter(10*time.Millisecond, "/events"))
+}
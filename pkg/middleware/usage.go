@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	"github.com/labstack/echo/v4"
+	"github.com/redhatinsights/platform-go-middlewares/identity"
+	"github.com/rs/zerolog/log"
+)
+
+const usageFlushInterval = 60 // in seconds
+
+type usageKey struct {
+	orgID  string
+	method string
+	route  string
+	day    time.Time
+}
+
+// UsageRecorder counts requests per org, route and day in memory, and periodically
+// adds the counts to the usage rollup so that requests do not each write to the database.
+type UsageRecorder struct {
+	mutex  sync.Mutex
+	counts map[usageKey]int64
+	dao    dao.UsageDao
+}
+
+func NewUsageRecorder(usageDao dao.UsageDao) *UsageRecorder {
+	return &UsageRecorder{
+		counts: make(map[usageKey]int64),
+		dao:    usageDao,
+	}
+}
+
+// Middleware counts the request, once identity has been enforced
+func (u *UsageRecorder) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		err := next(c)
+		route := MatchedRoute(c)
+		id, ok := c.Request().Context().Value(identity.Key).(identity.XRHID)
+		if !ok || route == "" || SkipAuth(c) {
+			return err
+		}
+		u.add(usageKey{
+			orgID:  id.Identity.Internal.OrgID,
+			method: c.Request().Method,
+			route:  route,
+			day:    time.Now().UTC().Truncate(24 * time.Hour),
+		}, 1)
+		return err
+	}
+}
+
+func (u *UsageRecorder) add(key usageKey, count int64) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	u.counts[key] += count
+}
+
+// Run flushes the counts every usageFlushInterval, and a last time once ctx is done
+func (u *UsageRecorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(usageFlushInterval * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			u.Flush()
+			return
+		case <-ticker.C:
+			u.Flush()
+		}
+	}
+}
+
+// Flush adds the counts to the usage rollup.  Counts that could not be written are
+// kept for the next flush.
+func (u *UsageRecorder) Flush() {
+	u.mutex.Lock()
+	pending := u.counts
+	u.counts = make(map[usageKey]int64)
+	u.mutex.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+	counts := make([]dao.UsageCount, 0, len(pending))
+	for key, count := range pending {
+		counts = append(counts, dao.UsageCount{
+			OrgID:  key.orgID,
+			Method: key.method,
+			Route:  key.route,
+			Day:    key.day,
+			Count:  count,
+		})
+	}
+	if err := u.dao.Record(context.Background(), counts); err != nil {
+		log.Error().Err(err).Msg("Error recording api usage")
+		for key, count := range pending {
+			u.add(key, count)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	"github.com/labstack/echo/v4"
+	"github.com/redhatinsights/platform-go-middlewares/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func serveUsageRouter(recorder *UsageRecorder, path string, orgID string) {
+	e := echo.New()
+	e.Use(recorder.Middleware)
+	e.GET("/api/content-sources/v1/repositories/:uuid", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if orgID != "" {
+		id := identity.XRHID{Identity: identity.Identity{Internal: identity.Internal{OrgID: orgID}}}
+		req = req.WithContext(context.WithValue(req.Context(), identity.Key, id))
+	}
+	e.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestUsageRecorder(t *testing.T) {
+	usageDao := dao.NewMockUsageDao(t)
+	recorder := NewUsageRecorder(usageDao)
+
+	serveUsageRouter(recorder, "/api/content-sources/v1/repositories/abc", "org1")
+	serveUsageRouter(recorder, "/api/content-sources/v1/repositories/def", "org1")
+	serveUsageRouter(recorder, "/api/content-sources/v1/repositories/abc", "org2")
+	// Not counted, no identity or no matching route
+	serveUsageRouter(recorder, "/api/content-sources/v1/repositories/abc", "")
+	serveUsageRouter(recorder, "/api/content-sources/v1/unknown/", "org1")
+
+	usageDao.On("Record", mock.Anything, mock.MatchedBy(func(counts []dao.UsageCount) bool {
+		byOrg := map[string]int64{}
+		for _, count := range counts {
+			assert.Equal(t, "/api/content-sources/v1/repositories/:uuid", count.Route)
+			assert.Equal(t, http.MethodGet, count.Method)
+			byOrg[count.OrgID] += count.Count
+		}
+		return len(counts) == 2 && byOrg["org1"] == 2 && byOrg["org2"] == 1
+	})).Return(nil).Once()
+	recorder.Flush()
+
+	// Nothing left to record
+	recorder.Flush()
+}
+
+func TestUsageRecorderKeepsCountsOnError(t *testing.T) {
+	usageDao := dao.NewMockUsageDao(t)
+	recorder := NewUsageRecorder(usageDao)
+
+	serveUsageRouter(recorder, "/api/content-sources/v1/repositories/abc", "org1")
+
+	usageDao.On("Record", mock.Anything, mock.Anything).Return(errors.New("database down")).Once()
+	recorder.Flush()
+
+	usageDao.On("Record", mock.Anything, mock.MatchedBy(func(counts []dao.UsageCount) bool {
+		return len(counts) == 1 && counts[0].Count == 1
+	})).Return(nil).Once()
+	recorder.Flush()
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+const TableNameApiUsage = "api_usage"
+
+// ApiUsage is the number of requests an org made to a route on a day
+type ApiUsage struct {
+	OrgID        string    `json:"org_id" gorm:"primaryKey"`
+	Method       string    `json:"method" gorm:"primaryKey"`
+	Route        string    `json:"route" gorm:"primaryKey"`
+	Day          time.Time `json:"day" gorm:"primaryKey;type:date"`
+	RequestCount int64     `json:"request_count" gorm:"not null;default:0"`
+}
+
+func (*ApiUsage) TableName() string {
+	return TableNameApiUsage
+}
@@ -2,22 +2,16 @@ package models
 
 import (
 	"time"
-
-	"github.com/google/uuid"
-	"gorm.io/gorm"
 )
 
+// Base holds the columns common to the models.  The uuid is generated by the database
+// and read back on insert.
 type Base struct {
-	UUID      string `gorm:"primary_key" json:"uuid"`
+	UUID      string `gorm:"primary_key;type:uuid;default:gen_random_uuid()" json:"uuid"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
-func (base *Base) BeforeCreate(db *gorm.DB) (err error) {
-	base.UUID = uuid.NewString()
-	return
-}
-
 type Error struct {
 	Message    string
 	Validation bool
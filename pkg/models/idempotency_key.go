@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+const TableNameIdempotencyKey = "idempotency_keys"
+
+// IdempotencyKey is the response to the first request an org sent with an Idempotency-Key
+// header, replayed to the retries of that request until it expires.  StatusCode is 0 while
+// the first request is in progress.
+type IdempotencyKey struct {
+	OrgID       string    `gorm:"primaryKey"`
+	Key         string    `gorm:"primaryKey"`
+	Fingerprint string    `gorm:"not null"` // hash of the request, to detect a key reused for another request
+	StatusCode  int       `gorm:"not null;default:0"`
+	ContentType string    `gorm:"not null;default:''"`
+	Body        []byte    `gorm:"type:bytea"`
+	CreatedAt   time.Time `gorm:"not null"`
+	ExpiresAt   time.Time `gorm:"not null"`
+}
+
+func (*IdempotencyKey) TableName() string {
+	return TableNameIdempotencyKey
+}
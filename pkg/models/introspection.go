@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+const TableNameIntrospection = "introspections"
+
+const (
+	IntrospectionSucceeded = "succeeded" // The metadata changed and the packages were updated
+	IntrospectionUnchanged = "unchanged" // The metadata did not change since the previous introspection
+	IntrospectionFailed    = "failed"
+)
+
+// Introspection is a run of the introspection of a repository, kept for a number of days
+type Introspection struct {
+	Base
+	RepositoryUUID  string    `json:"repository_uuid" gorm:"not null"`
+	StartedAt       time.Time `json:"started_at" gorm:"not null"`
+	FinishedAt      time.Time `json:"finished_at" gorm:"not null"`
+	Status          string    `json:"status" gorm:"not null"`
+	PackagesAdded   int64     `json:"packages_added" gorm:"not null;default:0"`
+	PackagesRemoved int64     `json:"packages_removed" gorm:"not null;default:0"`
+	PackagesUpdated int64     `json:"packages_updated" gorm:"not null;default:0"`
+	Error           *string   `json:"error"`
+}
+
+// PackageChanges counts the packages, identified by name and architecture, added, removed and
+// updated to another version by an introspection
+type PackageChanges struct {
+	Added   int64
+	Removed int64
+	Updated int64
+}
+
+func (*Introspection) TableName() string {
+	return TableNameIntrospection
+}
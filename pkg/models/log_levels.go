@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const TableNameLogLevels = "log_levels"
+
+// LogLevelsID is the id of the single row of log levels
+const LogLevelsID = 1
+
+// LogLevels are the log levels set through the logging admin endpoint, applied by every process
+// until they expire
+type LogLevels struct {
+	ID           int            `json:"id" gorm:"primaryKey"`
+	Level        string         `json:"level" gorm:"not null"`
+	DebugModules pq.StringArray `json:"debug_modules" gorm:"type:text[];not null"` // modules whose debug logs are written whatever the level
+	ExpiresAt    time.Time      `json:"expires_at" gorm:"not null"`
+	UpdatedAt    time.Time      `json:"updated_at" gorm:"not null"`
+	UpdatedBy    string         `json:"updated_by" gorm:"not null"` // username of the admin who set the levels
+}
+
+func (*LogLevels) TableName() string {
+	return TableNameLogLevels
+}
+
+// Expired returns whether the levels reverted to the configured ones
+func (l *LogLevels) Expired(now time.Time) bool {
+	return !now.Before(l.ExpiresAt)
+}
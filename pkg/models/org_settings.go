@@ -0,0 +1,65 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+const TableNameOrgSettings = "org_settings"
+
+// OrgSettings holds the preferences of an org, orgs without a row use the defaults
+type OrgSettings struct {
+	OrgID                       string         `json:"org_id" gorm:"primaryKey"`
+	CreatedAt                   time.Time      `json:"created_at" gorm:"not null"`
+	UpdatedAt                   time.Time      `json:"updated_at" gorm:"not null"`
+	IntrospectionDigestOptOut   bool           `json:"introspection_digest_opt_out" gorm:"not null;default:false"` // org does not receive the weekly digest of failing repositories
+	DefaultDistributionArch     string         `json:"default_distribution_arch" gorm:"not null;default:any"`
+	DefaultDistributionVersions pq.StringArray `json:"default_distribution_versions" gorm:"type:text[];not null"`
+	DefaultMetadataVerification bool           `json:"default_metadata_verification" gorm:"not null;default:false"`
+	SnapshotRetention           int            `json:"snapshot_retention" gorm:"not null;default:0"`      // snapshots kept per repository, all when 0
+	NotificationOptOuts         pq.StringArray `json:"notification_opt_outs" gorm:"type:text[];not null"` // notification events not sent to the org
+}
+
+func (*OrgSettings) TableName() string {
+	return TableNameOrgSettings
+}
+
+// DefaultOrgSettings returns the settings of an org that has not saved any
+func DefaultOrgSettings(orgID string) OrgSettings {
+	return OrgSettings{
+		OrgID:                       orgID,
+		DefaultDistributionArch:     config.ANY_ARCH,
+		DefaultDistributionVersions: pq.StringArray{config.ANY_VERSION},
+		NotificationOptOuts:         pq.StringArray{},
+	}
+}
+
+func (s *OrgSettings) BeforeSave(tx *gorm.DB) error {
+	return s.validate()
+}
+
+func (s *OrgSettings) validate() error {
+	if s.OrgID == "" {
+		return Error{Message: "Org ID cannot be blank.", Validation: true}
+	}
+	if !config.ValidArchLabel(s.DefaultDistributionArch) {
+		return Error{Message: fmt.Sprintf("Specified distribution architecture %s is invalid.", s.DefaultDistributionArch),
+			Validation: true}
+	}
+	if valid, invalidVer := config.ValidDistributionVersionLabels(s.DefaultDistributionVersions); !valid {
+		return Error{Message: fmt.Sprintf("Specified distribution version %s is invalid.", invalidVer),
+			Validation: true}
+	}
+	if versionContainsAnyAndOthers(s.DefaultDistributionVersions) {
+		return Error{Message: fmt.Sprintf("Specified a distribution version of '%s' along with other version types, this is invalid.", config.ANY_VERSION),
+			Validation: true}
+	}
+	if s.SnapshotRetention < 0 {
+		return Error{Message: "Snapshot retention cannot be negative.", Validation: true}
+	}
+	return nil
+}
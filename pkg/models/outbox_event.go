@@ -0,0 +1,27 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const TableNameOutboxEvent = "outbox_events"
+
+// OutboxEvent is an event written in the same transaction as the entity change
+// that produced it, and later published by the outbox relay. Events that could not
+// be published after several attempts are marked as failed and no longer retried.
+type OutboxEvent struct {
+	Base
+	OrgID         string          `json:"org_id" gorm:"not null"`
+	EventName     string          `json:"event_name" gorm:"not null"`
+	Payload       json.RawMessage `json:"payload" gorm:"type:jsonb;not null"`
+	PublishedAt   *time.Time      `json:"published_at"`
+	Attempts      int             `json:"attempts" gorm:"not null;default:0"`
+	LastError     *string         `json:"last_error"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" gorm:"not null"`
+	FailedAt      *time.Time      `json:"failed_at"`
+}
+
+func (*OutboxEvent) TableName() string {
+	return TableNameOutboxEvent
+}
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/content-services/content-sources-backend/pkg/config"
 	"github.com/openlyinc/pointy"
 	"gorm.io/gorm"
 )
@@ -13,11 +14,15 @@ type Repository struct {
 	Base
 	URL                          string `gorm:"unique;not null;default:null"`
 	RepomdChecksum               string `gorm:"default:null"`
+	RepomdRevision               string `gorm:"default:null"`
+	Etag                         string `gorm:"default:null"` // ETag header of the last fetched repomd.xml
+	LastModified                 string `gorm:"default:null"` // Last-Modified header of the last fetched repomd.xml
 	Public                       bool
 	LastIntrospectionTime        *time.Time                `gorm:"default:null"`
 	LastIntrospectionSuccessTime *time.Time                `gorm:"default:null"`
 	LastIntrospectionUpdateTime  *time.Time                `gorm:"default:null"`
 	LastIntrospectionError       *string                   `gorm:"default:null"`
+	LastIntrospectionErrorCode   *string                   `gorm:"default:null"` // Code of LastIntrospectionError, see api.IntrospectionErrorUnknown
 	Status                       string                    `gorm:"default:Pending"`
 	PackageCount                 int                       `gorm:"default:0;not null"`
 	FailedIntrospectionsCount    int                       `gorm:"default:0;not null"`
@@ -26,9 +31,6 @@ type Repository struct {
 }
 
 func (r *Repository) BeforeCreate(tx *gorm.DB) (err error) {
-	if err := r.Base.BeforeCreate(tx); err != nil {
-		return err
-	}
 	if err := r.validate(); err != nil {
 		return err
 	}
@@ -43,9 +45,23 @@ func (r *Repository) validate() error {
 	if stringContainsInternalWhitespace(r.URL) {
 		return Error{Message: "URL cannot contain whitespace.", Validation: true}
 	}
+	if message := UnsupportedVariablesMessage(r.URL); message != "" {
+		return Error{Message: message, Validation: true}
+	}
+	if message := DeniedURLMessage(r.URL); message != "" {
+		return Error{Message: message, Validation: true}
+	}
 	return nil
 }
 
+// DeniedURLMessage describes why the url is denied by the url deny and allow lists, empty if it is allowed
+func DeniedURLMessage(url string) string {
+	if err := config.CheckRepositoryURL(CleanupURL(url)); err != nil {
+		return err.Error() + "."
+	}
+	return ""
+}
+
 // stringContainsInternalWhitespace returns true if string has whitespace, excluding leading/trailing whitespace
 func stringContainsInternalWhitespace(s string) bool {
 	return strings.ContainsAny(strings.TrimSpace(s), " \t\n\v\r\f")
@@ -81,6 +97,7 @@ func (in *Repository) DeepCopyInto(out *Repository) {
 		lastIntrospectionUpdateTime  *time.Time
 		lastIntrospectionSuccessTime *time.Time
 		lastIntrospectionError       *string
+		lastIntrospectionErrorCode   *string
 	)
 
 	if in.LastIntrospectionTime != nil {
@@ -98,12 +115,20 @@ func (in *Repository) DeepCopyInto(out *Repository) {
 	if in.LastIntrospectionError != nil {
 		lastIntrospectionError = pointy.String(*in.LastIntrospectionError)
 	}
+	if in.LastIntrospectionErrorCode != nil {
+		lastIntrospectionErrorCode = pointy.String(*in.LastIntrospectionErrorCode)
+	}
 	out.URL = in.URL
 	out.Public = in.Public
+	out.RepomdChecksum = in.RepomdChecksum
+	out.RepomdRevision = in.RepomdRevision
+	out.Etag = in.Etag
+	out.LastModified = in.LastModified
 	out.LastIntrospectionTime = lastIntrospectionTime
 	out.LastIntrospectionSuccessTime = lastIntrospectionSuccessTime
 	out.LastIntrospectionUpdateTime = lastIntrospectionUpdateTime
 	out.LastIntrospectionError = lastIntrospectionError
+	out.LastIntrospectionErrorCode = lastIntrospectionErrorCode
 	out.Status = in.Status
 	out.PackageCount = in.PackageCount
 	out.FailedIntrospectionsCount = in.FailedIntrospectionsCount
@@ -124,8 +149,12 @@ func (r *Repository) MapForUpdate() map[string]interface{} {
 	forUpdate["URL"] = r.URL
 	forUpdate["Public"] = r.Public
 	forUpdate["RepomdChecksum"] = r.RepomdChecksum
+	forUpdate["RepomdRevision"] = r.RepomdRevision
+	forUpdate["Etag"] = r.Etag
+	forUpdate["LastModified"] = r.LastModified
 	forUpdate["LastIntrospectionTime"] = r.LastIntrospectionTime
 	forUpdate["LastIntrospectionError"] = r.LastIntrospectionError
+	forUpdate["LastIntrospectionErrorCode"] = r.LastIntrospectionErrorCode
 	forUpdate["LastIntrospectionSuccessTime"] = r.LastIntrospectionSuccessTime
 	forUpdate["LastIntrospectionUpdateTime"] = r.LastIntrospectionUpdateTime
 	forUpdate["Status"] = r.Status
@@ -2,7 +2,9 @@ package models
 
 import (
 	"fmt"
+	"net/url"
 	"sort"
+	"time"
 
 	"github.com/content-services/content-sources-backend/pkg/config"
 	"github.com/lib/pq"
@@ -21,6 +23,16 @@ type RepositoryConfiguration struct {
 	RepositoryUUID       string         `json:"repository_uuid" gorm:"not null"`
 	Repository           Repository     `json:"repository,omitempty"`
 	Snapshot             bool           `json:"snapshot"`
+	ContentType          string         `json:"content_type" gorm:"default:binary"`
+	ProxyURL             string         `json:"proxy_url" gorm:"default:''"`
+	ProxyUsername        string         `json:"proxy_username" gorm:"default:''"`
+	ProxyPassword        string         `json:"-" gorm:"default:''"`
+	Username             string         `json:"username" gorm:"default:''"`
+	Password             string         `json:"-" gorm:"default:''"`                         // encrypted with the key of the application
+	LastSnapshotUUID     *string        `json:"last_snapshot_uuid" gorm:"default:null"`      // Latest snapshot of the repository
+	LastSnapshotTaskUUID *string        `json:"last_snapshot_task_uuid" gorm:"default:null"` // Latest task snapshotting the repository
+	LastSnapshotAt       *time.Time     `json:"last_snapshot_at" gorm:"default:null"`        // Datetime of the latest snapshot
+	LastSnapshotTask     *TaskInfo      `json:"-" gorm:"foreignKey:LastSnapshotTaskUUID"`    // Latest task snapshotting the repository, loaded to roll up the status
 	DeletedAt            gorm.DeletedAt `json:"deleted_at"`
 }
 
@@ -39,15 +51,18 @@ func (rc *RepositoryConfiguration) MapForUpdate() map[string]interface{} {
 	forUpdate["OrgID"] = rc.OrgID
 	forUpdate["RepositoryUUID"] = rc.RepositoryUUID
 	forUpdate["snapshot"] = rc.Snapshot
+	forUpdate["ContentType"] = rc.ContentType
+	forUpdate["ProxyURL"] = rc.ProxyURL
+	forUpdate["ProxyUsername"] = rc.ProxyUsername
+	forUpdate["ProxyPassword"] = rc.ProxyPassword
+	forUpdate["Username"] = rc.Username
+	forUpdate["Password"] = rc.Password
 
 	return forUpdate
 }
 
-// BeforeCreate perform validations and sets UUID of Repository Configurations
+// BeforeCreate perform validations of Repository Configurations
 func (rc *RepositoryConfiguration) BeforeCreate(tx *gorm.DB) error {
-	if err := rc.Base.BeforeCreate(tx); err != nil {
-		return err
-	}
 	if err := rc.DedupeVersions(tx); err != nil {
 		return err
 	}
@@ -57,6 +72,9 @@ func (rc *RepositoryConfiguration) BeforeCreate(tx *gorm.DB) error {
 	if err := rc.validate(); err != nil {
 		return err
 	}
+	if err := rc.validateURLVariables(tx); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -71,6 +89,9 @@ func (rc *RepositoryConfiguration) BeforeUpdate(tx *gorm.DB) error {
 	if err := rc.validate(); err != nil {
 		return err
 	}
+	if err := rc.validateURLVariables(tx); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -95,6 +116,17 @@ func (rc *RepositoryConfiguration) ReplaceEmptyValues(tx *gorm.DB) error {
 	if rc.Arch == "" {
 		tx.Statement.SetColumn("Arch", config.ANY_ARCH)
 	}
+	if rc.ContentType == "" {
+		tx.Statement.SetColumn("ContentType", config.ContentTypeBinary)
+	}
+	if rc.ProxyURL == "" && (rc.ProxyUsername != "" || rc.ProxyPassword != "") {
+		// Credentials are only used with a proxy, don't keep them once the proxy is removed
+		tx.Statement.SetColumn("ProxyUsername", "")
+		tx.Statement.SetColumn("ProxyPassword", "")
+	}
+	if rc.Username == "" && rc.Password != "" {
+		tx.Statement.SetColumn("Password", "")
+	}
 	return nil
 }
 
@@ -119,6 +151,10 @@ func (rc *RepositoryConfiguration) validate() error {
 		return Error{Message: fmt.Sprintf("Specified distribution architecture %s is invalid.", rc.Arch),
 			Validation: true}
 	}
+	if rc.ContentType != "" && !config.ValidContentTypeLabel(rc.ContentType) {
+		return Error{Message: fmt.Sprintf("Specified content type %s is invalid.", rc.ContentType),
+			Validation: true}
+	}
 	valid, invalidVer := config.ValidDistributionVersionLabels(rc.Versions)
 	if len(rc.Versions) > 0 && !valid {
 		return Error{Message: fmt.Sprintf("Specified distribution version %s is invalid.", invalidVer),
@@ -130,9 +166,57 @@ func (rc *RepositoryConfiguration) validate() error {
 		return Error{Message: AnyOrErrMsg, Validation: true}
 	}
 
+	if rc.ProxyURL != "" && !validProxyURL(rc.ProxyURL) {
+		return Error{Message: fmt.Sprintf("Specified proxy URL %s is invalid, it must be an absolute http or https url without credentials.", rc.ProxyURL),
+			Validation: true}
+	}
+
+	return nil
+}
+
+// validateURLVariables checks that a repository whose url contains variables declares the distribution
+// versions and architecture to expand them with, and that a snapshotted one is pinned to a single version,
+// the snapshots of a repository being taken from a single url
+func (rc *RepositoryConfiguration) validateURLVariables(tx *gorm.DB) error {
+	url := rc.Repository.URL
+	if url == "" && rc.RepositoryUUID != "" {
+		var repos []Repository
+		err := tx.Session(&gorm.Session{NewDB: true}).Select("url").Where("uuid = ?", rc.RepositoryUUID).Find(&repos).Error
+		if err != nil {
+			return err
+		}
+		if len(repos) > 0 {
+			url = repos[0].URL
+		}
+	}
+	if !HasVariables(url) {
+		return nil
+	}
+	switch UnexpandedVariable(url, rc.Versions, rc.Arch) {
+	case BaseArchVariable:
+		return Error{Message: fmt.Sprintf("A URL containing %s requires a distribution architecture to expand it with.", BaseArchVariable),
+			Validation: true}
+	case ReleaseVerVariable:
+		return Error{Message: fmt.Sprintf("A URL containing %s requires distribution versions to expand it with.", ReleaseVerVariable),
+			Validation: true}
+	}
+	urls := ExpandURL(url, rc.Versions, rc.Arch)
+	if rc.Snapshot && len(urls) > 1 {
+		return Error{Message: fmt.Sprintf("Snapshotting a URL containing %s requires a single distribution version.", ReleaseVerVariable),
+			Validation: true}
+	}
 	return nil
 }
 
+// validProxyURL checks that the proxy is an http(s) url, credentials being set separately
+func validProxyURL(proxyURL string) bool {
+	parsed, err := url.Parse(proxyURL)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != "" && parsed.User == nil
+}
+
 func versionContainsAnyAndOthers(arr []string) bool {
 	if len(arr) <= 1 {
 		return false
@@ -158,6 +242,12 @@ func (in *RepositoryConfiguration) DeepCopyInto(out *RepositoryConfiguration) {
 	out.AccountID = in.AccountID
 	out.OrgID = in.OrgID
 	out.RepositoryUUID = in.RepositoryUUID
+	out.ContentType = in.ContentType
+	out.ProxyURL = in.ProxyURL
+	out.ProxyUsername = in.ProxyUsername
+	out.ProxyPassword = in.ProxyPassword
+	out.Username = in.Username
+	out.Password = in.Password
 }
 
 func (in *RepositoryConfiguration) DeepCopy() *RepositoryConfiguration {
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+)
+
+// The status of a repository configuration rolls up the status of the introspection of its
+// repository (see config.StatusValid) and the results of its snapshots.  The first rule that
+// applies gives the status, so that a failing repository is never reported as pending:
+//
+//  1. Invalid: the repository never introspected successfully, or snapshots are enabled and the
+//     only snapshot attempted failed
+//  2. Unavailable: the repository introspected successfully before but now fails, or snapshots are
+//     enabled and the last snapshot failed while an older snapshot is still served
+//  3. Pending: the repository is not introspected yet, or snapshots are enabled and the first
+//     snapshot is not taken yet
+//  4. Valid: the repository introspects successfully, and is snapshotted if snapshots are enabled
+//
+// A snapshot failed when its task failed, or ran out of retries.
+
+// snapshotFailed returns whether the status of a snapshot task is a failure
+func snapshotFailed(taskStatus string) bool {
+	return taskStatus == config.TaskStatusFailed || taskStatus == config.TaskStatusDead
+}
+
+// RollupStatus returns the status of a repository configuration, following the rules above.
+// hasSnapshot tells whether the configuration has a snapshot, lastSnapshotTaskStatus is the
+// status of the last task snapshotting it, empty if there was none.
+func RollupStatus(introspectionStatus string, snapshot bool, hasSnapshot bool, lastSnapshotTaskStatus string) string {
+	failed := snapshot && snapshotFailed(lastSnapshotTaskStatus)
+	switch {
+	case introspectionStatus == config.StatusInvalid || (failed && !hasSnapshot):
+		return config.StatusInvalid
+	case introspectionStatus == config.StatusUnavailable || failed:
+		return config.StatusUnavailable
+	case introspectionStatus == config.StatusPending || (snapshot && !hasSnapshot):
+		return config.StatusPending
+	default:
+		return config.StatusValid
+	}
+}
+
+// RollupStatus returns the status of the configuration, empty when its repository was not loaded
+func (rc *RepositoryConfiguration) RollupStatus() string {
+	if rc.Repository.Status == "" {
+		return ""
+	}
+	taskStatus := ""
+	if rc.LastSnapshotTask != nil {
+		taskStatus = rc.LastSnapshotTask.Status
+	}
+	return RollupStatus(rc.Repository.Status, rc.Snapshot, rc.LastSnapshotUUID != nil, taskStatus)
+}
+
+// RollupStatusSQL is RollupStatus as an SQL expression over repository_configurations joined with
+// repositories, and with the task of its last snapshot as last_snapshot_task, to filter and sort on
+var RollupStatusSQL = fmt.Sprintf(`(CASE
+	WHEN repositories.status = '%[1]s' OR (%[5]s AND repository_configurations.last_snapshot_uuid IS NULL) THEN '%[1]s'
+	WHEN repositories.status = '%[2]s' OR %[5]s THEN '%[2]s'
+	WHEN repositories.status = '%[3]s' OR (repository_configurations.snapshot AND repository_configurations.last_snapshot_uuid IS NULL) THEN '%[3]s'
+	ELSE '%[4]s' END)`,
+	config.StatusInvalid, config.StatusUnavailable, config.StatusPending, config.StatusValid,
+	fmt.Sprintf("(repository_configurations.snapshot AND last_snapshot_task.status IN ('%s', '%s'))",
+		config.TaskStatusFailed, config.TaskStatusDead))
+
+// JoinLastSnapshotTask joins the task of the last snapshot of repository configurations as
+// last_snapshot_task, see RollupStatusSQL
+const JoinLastSnapshotTask = "left join tasks last_snapshot_task on last_snapshot_task.id = repository_configurations.last_snapshot_task_uuid"
@@ -38,6 +38,13 @@ func (s *RepositorySuite) TestRepositoriesCreate() {
 	assert.True(s.T(), strings.HasSuffix(found.URL, "/")) // test trailing slash added during creation
 }
 
+func (s *RepositorySuite) TestRepositoriesCreateUnsupportedVariable() {
+	repo := Repository{URL: "https://example.com/$releasever/$arch/"}
+	err := s.tx.Create(&repo).Error
+	assert.Error(s.T(), err)
+	assert.True(s.T(), strings.Contains(err.Error(), "unsupported variables $arch"))
+}
+
 func (s *ModelsSuite) TestCleanupURL() {
 	tx := s.tx
 	var found Repository
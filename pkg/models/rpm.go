@@ -25,11 +25,8 @@ type Rpm struct {
 	Repositories []Repository `gorm:"many2many:repositories_rpms"`
 }
 
-// BeforeCreate hook performs validations and sets UUID of RepositoryRpm
+// BeforeCreate hook performs validations of Rpm
 func (r *Rpm) BeforeCreate(tx *gorm.DB) (err error) {
-	if err := r.Base.BeforeCreate(tx); err != nil {
-		return err
-	}
 	if r.Name == "" {
 		return Error{Message: "Name cannot be empty", Validation: true}
 	}
@@ -0,0 +1,26 @@
+package models
+
+const TableNameRpmCapability = "rpm_capabilities"
+
+// Kinds of capabilities listed in the primary metadata of a package
+const (
+	CapabilityProvides  = "provides"
+	CapabilityRequires  = "requires"
+	CapabilityObsoletes = "obsoletes"
+)
+
+// RpmCapability is a provides, requires or obsoletes entry of an rpm.
+// Flags is empty for unversioned entries, otherwise one of EQ, LT, LE, GT or GE.
+type RpmCapability struct {
+	RpmUUID string `json:"rpm_uuid" gorm:"not null"`
+	Kind    string `json:"kind" gorm:"not null"`
+	Name    string `json:"name" gorm:"not null"`
+	Flags   string `json:"flags" gorm:"not null;default:''"`
+	Epoch   int32  `json:"epoch" gorm:"not null;default:0"`
+	Version string `json:"version" gorm:"not null;default:''"`
+	Release string `json:"release" gorm:"not null;default:''"`
+}
+
+func (*RpmCapability) TableName() string {
+	return TableNameRpmCapability
+}
@@ -0,0 +1,15 @@
+package models
+
+const TableNameRpmName = "rpm_names"
+
+// RpmName is a package name available in a repository, kept to search
+// package names without scanning the rpms of every repository
+type RpmName struct {
+	RepositoryUUID string `json:"repository_uuid" gorm:"primaryKey"`
+	Name           string `json:"name" gorm:"primaryKey"`
+	Summary        string `json:"summary" gorm:"not null"`
+}
+
+func (*RpmName) TableName() string {
+	return TableNameRpmName
+}
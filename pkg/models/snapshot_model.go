@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"time"
 )
 
 type Snapshot struct {
@@ -16,6 +17,10 @@ type Snapshot struct {
 	RepositoryConfigurationUUID string `json:"repository_configuration_uuid" gorm:"not null"`
 	RepositoryConfiguration     RepositoryConfiguration
 	ContentCounts               ContentCounts `json:"content_counts" gorm:"not null,default:{}"`
+	SizeBytes                   int64         `json:"size_bytes" gorm:"not null,default:0"` // Bytes of the artifacts of the repository version
+	VerificationError           string        `json:"verification_error" gorm:"not null"`   // Why the last verification found the snapshot corrupted, empty if it is sound
+	VerifiedAt                  *time.Time    `json:"verified_at"`                          // Datetime of the last verification of the snapshot
+	Shared                      bool          `json:"shared" gorm:"not null;default:false"` // References pulp objects of the shared domain, which other orgs use as well
 }
 
 type ContentCounts map[string]int64
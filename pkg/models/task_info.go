@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // Shared by DAO and queue packages
@@ -23,6 +24,10 @@ type TaskInfo struct {
 	Error          *string
 	Status         string
 	RequestID      string
+	Username       string
+	Priority       int
+	Retries        int
+	ErrorHistory   pq.StringArray `gorm:"type:text[]"` // errors of the previous runs of the task
 }
 
 func (*TaskInfo) TableName() string {
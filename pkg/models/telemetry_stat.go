@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+const TableNameTelemetryStats = "telemetry_stats"
+
+const (
+	TelemetryRepositoriesPerOrg = "repositories_per_org" // orgs by number of repositories, keyed by range
+	TelemetryExternalHosts      = "external_hosts"       // orgs with repositories on a host, keyed by host
+	TelemetryFeatureAdoption    = "feature_adoption"     // orgs using a feature, keyed by feature
+)
+
+// TelemetryStat is an anonymous statistic of a day, counting orgs without telling them apart
+type TelemetryStat struct {
+	Day    time.Time `json:"day" gorm:"primaryKey;type:date"`
+	Metric string    `json:"metric" gorm:"primaryKey"`
+	Key    string    `json:"key" gorm:"primaryKey"`
+	Value  int64     `json:"value" gorm:"not null;default:0"`
+}
+
+func (*TelemetryStat) TableName() string {
+	return TableNameTelemetryStats
+}
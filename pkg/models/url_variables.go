@@ -0,0 +1,103 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+)
+
+// The variables yum replaces in the url of a repository, as it does in .repo files
+const (
+	ReleaseVerVariable = "$releasever" // Replaced by each of the distribution versions of the repository
+	BaseArchVariable   = "$basearch"   // Replaced by the distribution architecture of the repository
+)
+
+// urlVariable matches the $name and ${name} spellings of a variable
+var urlVariable = regexp.MustCompile(`\$(\{[A-Za-z0-9_]+\}|[A-Za-z0-9_]+)`)
+
+// urlVariables returns the variables of the url, spelled $name
+func urlVariables(url string) []string {
+	var variables []string
+	for _, match := range urlVariable.FindAllString(url, -1) {
+		variables = append(variables, "$"+strings.Trim(match, "${}"))
+	}
+	return variables
+}
+
+// HasVariables returns whether the url contains variables to expand
+func HasVariables(url string) bool {
+	return urlVariable.MatchString(url)
+}
+
+// UnsupportedVariables returns the variables of the url that are not expanded
+func UnsupportedVariables(url string) []string {
+	var unsupported []string
+	for _, variable := range urlVariables(url) {
+		if variable != ReleaseVerVariable && variable != BaseArchVariable {
+			unsupported = append(unsupported, variable)
+		}
+	}
+	return unsupported
+}
+
+// UnsupportedVariablesMessage describes the unsupported variables of the url, empty if it has none
+func UnsupportedVariablesMessage(url string) string {
+	unsupported := UnsupportedVariables(url)
+	if len(unsupported) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("URL contains unsupported variables %s, only %s and %s are supported.",
+		strings.Join(unsupported, ", "), ReleaseVerVariable, BaseArchVariable)
+}
+
+func hasVariable(url string, variable string) bool {
+	for _, found := range urlVariables(url) {
+		if found == variable {
+			return true
+		}
+	}
+	return false
+}
+
+func replaceVariable(url string, variable string, value string) string {
+	name := strings.TrimPrefix(variable, "$")
+	return strings.ReplaceAll(strings.ReplaceAll(url, "${"+name+"}", value), variable, value)
+}
+
+// ExpandURL returns the concrete urls of the url for each of the distribution versions, in the
+// order of the versions, with $basearch replaced by the distribution architecture.  A url without
+// variables is its own expansion, while a version or an architecture that does not name one, such
+// as any, has none.
+func ExpandURL(url string, versions []string, arch string) []string {
+	if hasVariable(url, BaseArchVariable) {
+		if arch == "" || arch == config.ANY_ARCH {
+			return []string{}
+		}
+		url = replaceVariable(url, BaseArchVariable, arch)
+	}
+	if !hasVariable(url, ReleaseVerVariable) {
+		return []string{url}
+	}
+	urls := []string{}
+	for _, version := range versions {
+		if version == "" || version == config.ANY_VERSION {
+			continue
+		}
+		urls = append(urls, replaceVariable(url, ReleaseVerVariable, version))
+	}
+	return urls
+}
+
+// UnexpandedVariable returns the variable of the url that neither the distribution versions nor the
+// architecture give a value to, empty if the url expands to at least one url
+func UnexpandedVariable(url string, versions []string, arch string) string {
+	if hasVariable(url, BaseArchVariable) && (arch == "" || arch == config.ANY_ARCH) {
+		return BaseArchVariable
+	}
+	if len(ExpandURL(url, versions, arch)) == 0 {
+		return ReleaseVerVariable
+	}
+	return ""
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandURL(t *testing.T) {
+	versions := []string{config.El8, config.El9}
+
+	assert.Equal(t, []string{"http://example.com/os/"}, ExpandURL("http://example.com/os/", versions, config.X8664))
+	assert.Equal(t,
+		[]string{"http://example.com/8/os/", "http://example.com/9/os/"},
+		ExpandURL("http://example.com/$releasever/os/", versions, ""))
+	assert.Equal(t,
+		[]string{"http://example.com/8/os/8/"},
+		ExpandURL("http://example.com/${releasever}/os/$releasever/", []string{config.El8}, ""))
+	assert.Empty(t, ExpandURL("http://example.com/$releasever/os/", []string{config.ANY_VERSION}, ""))
+	assert.Empty(t, ExpandURL("http://example.com/$releasever/os/", nil, ""))
+
+	assert.Equal(t,
+		[]string{"http://example.com/8/x86_64/"},
+		ExpandURL("http://example.com/$releasever/${basearch}/", []string{config.El8}, config.X8664))
+	assert.Equal(t, []string{"http://example.com/x86_64/"}, ExpandURL("http://example.com/$basearch/", nil, config.X8664))
+	assert.Empty(t, ExpandURL("http://example.com/$basearch/", versions, config.ANY_ARCH))
+
+	assert.True(t, HasVariables("http://example.com/${releasever}/"))
+	assert.False(t, HasVariables("http://example.com/8/"))
+}
+
+func TestUnsupportedVariables(t *testing.T) {
+	assert.Empty(t, UnsupportedVariables("http://example.com/$releasever/${basearch}/"))
+	assert.Equal(t, []string{"$arch", "$YUM0"}, UnsupportedVariables("http://example.com/${arch}/$YUM0/$basearch/"))
+	assert.Empty(t, UnsupportedVariablesMessage("http://example.com/os/"))
+	assert.Contains(t, UnsupportedVariablesMessage("http://example.com/$arch/"), "$arch")
+}
+
+func TestUnexpandedVariable(t *testing.T) {
+	assert.Equal(t, "", UnexpandedVariable("http://example.com/os/", nil, ""))
+	assert.Equal(t, BaseArchVariable, UnexpandedVariable("http://example.com/$releasever/$basearch/", []string{config.El8}, config.ANY_ARCH))
+	assert.Equal(t, ReleaseVerVariable, UnexpandedVariable("http://example.com/$releasever/$basearch/", nil, config.X8664))
+	assert.Equal(t, "", UnexpandedVariable("http://example.com/$releasever/$basearch/", []string{config.El8}, config.X8664))
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+const (
+	TableNameWebhook         = "webhooks"
+	TableNameWebhookDelivery = "webhook_deliveries"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook is a url of an org receiving callbacks on repository events, signed with its secret
+type Webhook struct {
+	Base
+	OrgID      string         `json:"org_id" gorm:"not null"`
+	URL        string         `json:"url" gorm:"not null"`
+	Secret     string         `json:"-" gorm:"not null"`                       // encrypted with the keys of pkg/crypto
+	EventTypes pq.StringArray `json:"event_types" gorm:"type:text[];not null"` // events sent to the url, all if empty
+	Enabled    bool           `json:"enabled" gorm:"not null;default:true"`
+}
+
+func (*Webhook) TableName() string {
+	return TableNameWebhook
+}
+
+func (w *Webhook) BeforeCreate(tx *gorm.DB) (err error) {
+	return w.validate()
+}
+
+func (w *Webhook) validate() error {
+	parsed, err := url.Parse(w.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return Error{Message: "URL must be an absolute http or https url.", Validation: true}
+	}
+	if w.Secret == "" {
+		return Error{Message: "Secret cannot be blank.", Validation: true}
+	}
+	return nil
+}
+
+// WebhookDelivery is an event to send to a webhook, and the result of its last attempt
+type WebhookDelivery struct {
+	Base
+	WebhookUUID    string          `json:"webhook_uuid" gorm:"not null"`
+	OrgID          string          `json:"org_id" gorm:"not null"`
+	EventType      string          `json:"event_type" gorm:"not null"`
+	Payload        json.RawMessage `json:"payload" gorm:"type:jsonb;not null"`
+	Status         string          `json:"status" gorm:"not null;default:pending"`
+	Attempts       int             `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at" gorm:"not null"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	ResponseStatus *int            `json:"response_status"`
+	LastError      *string         `json:"last_error"`
+}
+
+func (*WebhookDelivery) TableName() string {
+	return TableNameWebhookDelivery
+}
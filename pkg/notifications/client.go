@@ -2,51 +2,72 @@ package notifications
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/RedHatInsights/event-schemas-go/apps/repositories/v1"
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/content-services/content-sources-backend/pkg/api"
 	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/db"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 )
 
-// SendNotification - Sends a notification
-func SendNotification(orgID string, eventName EventName, repos []repositories.Repositories) {
+// SendNotification - Queues a notification in the outbox to be published by the relay, or
+// sends it directly when no database is connected
+func SendNotification(ctx context.Context, orgID string, eventName EventName, repos []repositories.Repositories) {
+	if db.DB != nil {
+		if err := QueueNotification(db.DB.WithContext(ctx), orgID, eventName, repos); err != nil {
+			log.Error().Err(err).Msg("failed to queue the notification")
+		}
+		return
+	}
 	if config.Get().NotificationsClient != nil && len(repos) > 0 {
-		eventNameStr := eventName.String()
 		newUUID, _ := uuid.NewRandom()
-		e := cloudevents.NewEvent()
-		e.SetSource("urn:redhat:source:console:app:repositories")
-		e.SetID(newUUID.String())
-		e.SetType("com.redhat.console.repositories." + eventNameStr)
-		e.SetSubject("urn:redhat:subject:console:rhel:" + eventNameStr)
-		e.SetTime(time.Now())
-		e.SetExtension("redhatorgid", orgID)
-		e.SetExtension("redhatconsolebundle", "rhel")
-		e.SetDataSchema("https://console.redhat.com/api/schemas/apps/repositories/v1/repository-events.json")
-
-		data := repositories.RepositoryEvents{Repositories: repos}
-		err := e.SetData(cloudevents.ApplicationJSON, data)
-
+		e, err := newEvent(newUUID.String(), orgID, eventName, time.Now(), repositories.RepositoryEvents{Repositories: repos})
 		if err != nil {
 			log.Error().Err(err).Msg("failed to create cloudevents client")
 			return
 		}
-
-		ctx := cloudevents.WithEncodingStructured(context.Background())
-		// Send the event
-		if result := config.Get().NotificationsClient.Send(ctx, e); cloudevents.IsUndelivered(result) {
-			log.Error().Msgf("Notification message failed to send: %v", result)
+		if err = sendEvent(e); err != nil {
+			log.Error().Msgf("Notification message failed to send: %v", err)
 			return
 		}
-		ctx.Done()
 	} else {
 		log.Warn().Msgf("config.Get().NotificationsClient is null")
 	}
 }
 
+// newEvent - Builds the cloudevent for a repository event
+func newEvent(id string, orgID string, eventName EventName, eventTime time.Time, data interface{}) (cloudevents.Event, error) {
+	eventNameStr := eventName.String()
+	e := cloudevents.NewEvent()
+	e.SetSource("urn:redhat:source:console:app:repositories")
+	e.SetID(id)
+	e.SetType("com.redhat.console.repositories." + eventNameStr)
+	e.SetSubject("urn:redhat:subject:console:rhel:" + eventNameStr)
+	e.SetTime(eventTime)
+	e.SetExtension("redhatorgid", orgID)
+	e.SetExtension("redhatconsolebundle", "rhel")
+	e.SetDataSchema("https://console.redhat.com/api/schemas/apps/repositories/v1/repository-events.json")
+
+	err := e.SetData(cloudevents.ApplicationJSON, data)
+	return e, err
+}
+
+// sendEvent - Sends the event with the configured notifications client
+func sendEvent(e cloudevents.Event) error {
+	if config.Get().NotificationsClient == nil {
+		return errors.New("notifications client is not configured")
+	}
+	ctx := cloudevents.WithEncodingStructured(context.Background())
+	if result := config.Get().NotificationsClient.Send(ctx, e); cloudevents.IsUndelivered(result) {
+		return result
+	}
+	return nil
+}
+
 // MapRepositoryResponse - Maps RepositoryResponse to Repositories struct
 func MapRepositoryResponse(importedRepo api.RepositoryResponse) repositories.Repositories {
 	packageCount := int64(importedRepo.PackageCount)
@@ -9,6 +9,7 @@ const (
 	RepositoryUpdated              EventName = "repository-updated"
 	RepositoryIntrospectionFailure EventName = "repository-introspection-failure"
 	RepositoryDeleted              EventName = "repository-deleted"
+	RepositoryIntrospectionDigest  EventName = "repository-introspection-digest"
 )
 
 func (d EventName) String() string {
@@ -23,6 +24,8 @@ func (d EventName) String() string {
 		return "repository-introspection-failure"
 	case RepositoryDeleted:
 		return "repository-deleted"
+	case RepositoryIntrospectionDigest:
+		return "repository-introspection-digest"
 	// Add more cases here when expanding EventName Enum above
 	default:
 		return ""
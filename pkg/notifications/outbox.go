@@ -0,0 +1,182 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/RedHatInsights/event-schemas-go/apps/repositories/v1"
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/db"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	outboxRelayDelay     = 5 // in seconds
+	outboxRelayBatchSize = 100
+	outboxMaxAttempts    = 10
+	outboxRetryBaseDelay = 30 * time.Second
+	outboxClaimLease     = 5 * time.Minute // how long claimed events are skipped by other relays
+)
+
+// QueueNotification - Writes a notification to the outbox using the given transaction,
+// so that it is only published if the entity change that produced it is committed.
+// Notifications the org opted out of are written as already published, they are not
+// sent but still streamed by the repository change feed.
+func QueueNotification(tx *gorm.DB, orgID string, eventName EventName, repos []repositories.Repositories) error {
+	if len(repos) == 0 {
+		return nil
+	}
+	optedOut, err := OptedOut(tx, orgID, eventName)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(repositories.RepositoryEvents{Repositories: repos})
+	if err != nil {
+		return err
+	}
+	event := models.OutboxEvent{
+		OrgID:         orgID,
+		EventName:     eventName.String(),
+		Payload:       payload,
+		NextAttemptAt: time.Now(),
+	}
+	if optedOut {
+		now := time.Now()
+		event.PublishedAt = &now
+	}
+	return tx.Create(&event).Error
+}
+
+// OptedOut returns whether the org opted out of the event in its settings
+func OptedOut(db *gorm.DB, orgID string, eventName EventName) (bool, error) {
+	var count int64
+	err := db.Model(&models.OrgSettings{}).
+		Where("org_id = ? AND ? = ANY(notification_opt_outs)", orgID, eventName.String()).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// DeleteOutboxEventsBefore deletes the events created before the given time, returning the number deleted.
+// Events still pending are deleted as well, as they would be stale by the time they are published.
+func DeleteOutboxEventsBefore(ctx context.Context, tx *gorm.DB, before time.Time) (int64, error) {
+	ctx = db.WithQueryClass(ctx, db.QueryBulk)
+	result := tx.WithContext(ctx).Where("created_at < ?", before).Delete(&models.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
+
+// OutboxRelay publishes pending outbox events and marks them as published, retrying
+// failed ones with an exponential backoff.
+// Events are delivered at least once, consumers should deduplicate on the event id.
+type OutboxRelay struct {
+	context context.Context
+	db      *gorm.DB
+}
+
+func NewOutboxRelay(context context.Context, db *gorm.DB) *OutboxRelay {
+	if context == nil || db == nil {
+		return nil
+	}
+	return &OutboxRelay{
+		context: context,
+		db:      db,
+	}
+}
+
+func (r *OutboxRelay) Run() {
+	log.Info().Msg("Starting notifications outbox relay")
+	if config.Get().NotificationsClient == nil {
+		log.Warn().Msg("No notifications client is configured, outbox events are not published")
+	}
+	ticker := time.NewTicker(outboxRelayDelay * time.Second)
+	for {
+		select {
+		case <-r.context.Done():
+			ticker.Stop()
+			return
+		case <-ticker.C:
+			if err := r.relay(); err != nil {
+				log.Error().Err(err).Msg("Error relaying outbox events")
+			}
+		}
+	}
+}
+
+// relay publishes one batch of due events. They are claimed first, and published
+// outside of the claiming transaction so that no row stays locked while sending.
+func (r *OutboxRelay) relay() error {
+	if config.Get().NotificationsClient == nil {
+		return nil
+	}
+	events, err := r.claim()
+	if err != nil {
+		return err
+	}
+	for i := range events {
+		updates := publishOutboxEvent(events[i])
+		if err := r.db.WithContext(r.context).Model(&events[i]).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// claim locks a batch of due events and postpones their next attempt by a lease, so that
+// several relays can run concurrently without publishing the same event twice.
+// Events whose relay stopped before updating them are retried once the lease expires.
+func (r *OutboxRelay) claim() ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := r.db.WithContext(r.context).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("published_at IS NULL AND failed_at IS NULL AND next_attempt_at <= ?", time.Now()).
+			Order("next_attempt_at ASC").
+			Limit(outboxRelayBatchSize).
+			Find(&events).Error
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		uuids := make([]string, len(events))
+		for i := range events {
+			uuids[i] = events[i].UUID
+		}
+		return tx.Model(&models.OutboxEvent{}).
+			Where("uuid IN ?", uuids).
+			Update("next_attempt_at", time.Now().Add(outboxClaimLease)).Error
+	})
+	return events, err
+}
+
+// publishOutboxEvent sends the event and returns the columns to update on its outbox row
+func publishOutboxEvent(event models.OutboxEvent) map[string]interface{} {
+	attempts := event.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts": attempts,
+	}
+	e, err := newEvent(event.UUID, event.OrgID, EventName(event.EventName), event.CreatedAt, []byte(event.Payload))
+	if err == nil {
+		err = sendEvent(e)
+	}
+	if err == nil {
+		updates["published_at"] = time.Now()
+		updates["last_error"] = nil
+		return updates
+	}
+
+	log.Warn().Err(err).Str("event_uuid", event.UUID).Msg("Failed to publish outbox event")
+	updates["last_error"] = err.Error()
+	if attempts >= outboxMaxAttempts {
+		updates["failed_at"] = time.Now()
+	} else {
+		updates["next_attempt_at"] = time.Now().Add(outboxRetryDelay(attempts))
+	}
+	return updates
+}
+
+// outboxRetryDelay doubles the delay before retrying after each failed attempt
+func outboxRetryDelay(attempts int) time.Duration {
+	return outboxRetryBaseDelay * time.Duration(1<<(attempts-1))
+}
@@ -0,0 +1,46 @@
+package notifications
+
+import (
+	"testing"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishOutboxEventWithoutClient(t *testing.T) {
+	config.Get().NotificationsClient = nil
+	event := models.OutboxEvent{
+		Base:      models.Base{UUID: "a1b2c3", CreatedAt: time.Now()},
+		OrgID:     "1234",
+		EventName: RepositoryCreated.String(),
+		Payload:   []byte(`{"repositories":[]}`),
+		Attempts:  2,
+	}
+
+	updates := publishOutboxEvent(event)
+	assert.Equal(t, 3, updates["attempts"])
+	assert.NotNil(t, updates["last_error"])
+	assert.NotContains(t, updates, "published_at")
+	assert.NotContains(t, updates, "failed_at")
+	assert.WithinDuration(t, time.Now().Add(4*outboxRetryBaseDelay), updates["next_attempt_at"].(time.Time), time.Second)
+
+	event.Attempts = outboxMaxAttempts - 1
+	updates = publishOutboxEvent(event)
+	assert.Contains(t, updates, "failed_at")
+	assert.NotContains(t, updates, "next_attempt_at")
+}
+
+func TestOutboxRetryDelay(t *testing.T) {
+	assert.Equal(t, outboxRetryBaseDelay, outboxRetryDelay(1))
+	assert.Equal(t, 2*outboxRetryBaseDelay, outboxRetryDelay(2))
+	assert.Equal(t, 8*outboxRetryBaseDelay, outboxRetryDelay(4))
+}
+
+func TestRelayWithoutClient(t *testing.T) {
+	config.Get().NotificationsClient = nil
+	// the database is not queried while no client is configured
+	relay := OutboxRelay{}
+	assert.NoError(t, relay.relay())
+}
@@ -27,7 +27,7 @@ func GetPulpClientWithDomain(ctx context.Context, domainName string) PulpClient
 }
 
 func getPulpImpl(ctx context.Context) pulpDaoImpl {
-	ctx2 := context.WithValue(ctx, zest.ContextServerIndex, 0)
+	ctx2 := context.WithValue(config.WithLogModule(ctx, "pulp_client"), zest.ContextServerIndex, 0)
 	timeout := 60 * time.Second
 	transport := &http.Transport{ResponseHeaderTimeout: timeout}
 	httpClient := http.Client{Transport: transport, Timeout: timeout}
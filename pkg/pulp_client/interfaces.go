@@ -16,8 +16,8 @@ type PulpGlobalClient interface {
 //go:generate mockery  --name PulpClient --filename pulp_client_mock.go --inpackage
 type PulpClient interface {
 	// Remotes
-	CreateRpmRemote(name string, url string) (*zest.RpmRpmRemoteResponse, error)
-	UpdateRpmRemoteUrl(pulpHref string, url string) (string, error)
+	CreateRpmRemote(name string, url string, options RemoteOptions) (*zest.RpmRpmRemoteResponse, error)
+	UpdateRpmRemote(pulpHref string, url string, options RemoteOptions) (string, error)
 	GetRpmRemoteByName(name string) (*zest.RpmRpmRemoteResponse, error)
 	GetRpmRemoteList() ([]zest.RpmRpmRemoteResponse, error)
 	DeleteRpmRemote(pulpHref string) (string, error)
@@ -35,6 +35,7 @@ type PulpClient interface {
 
 	// Rpm Repository Version
 	GetRpmRepositoryVersion(href string) (*zest.RepositoryVersionResponse, error)
+	GetRpmRepositoryVersionSize(href string) (int64, error)
 	DeleteRpmRepositoryVersion(href string) (string, error)
 
 	// RpmPublication
@@ -64,25 +64,25 @@ func (_m *MockPulpClient) CreateRpmPublication(versionHref string) (*string, err
 	return r0, r1
 }
 
-// CreateRpmRemote provides a mock function with given fields: name, url
-func (_m *MockPulpClient) CreateRpmRemote(name string, url string) (*zest.RpmRpmRemoteResponse, error) {
-	ret := _m.Called(name, url)
+// CreateRpmRemote provides a mock function with given fields: name, url, options
+func (_m *MockPulpClient) CreateRpmRemote(name string, url string, options RemoteOptions) (*zest.RpmRpmRemoteResponse, error) {
+	ret := _m.Called(name, url, options)
 
 	var r0 *zest.RpmRpmRemoteResponse
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string) (*zest.RpmRpmRemoteResponse, error)); ok {
-		return rf(name, url)
+	if rf, ok := ret.Get(0).(func(string, string, RemoteOptions) (*zest.RpmRpmRemoteResponse, error)); ok {
+		return rf(name, url, options)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) *zest.RpmRpmRemoteResponse); ok {
-		r0 = rf(name, url)
+	if rf, ok := ret.Get(0).(func(string, string, RemoteOptions) *zest.RpmRpmRemoteResponse); ok {
+		r0 = rf(name, url, options)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*zest.RpmRpmRemoteResponse)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(name, url)
+	if rf, ok := ret.Get(1).(func(string, string, RemoteOptions) error); ok {
+		r1 = rf(name, url, options)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -394,6 +394,30 @@ func (_m *MockPulpClient) GetRpmRepositoryVersion(href string) (*zest.Repository
 	return r0, r1
 }
 
+// GetRpmRepositoryVersionSize provides a mock function with given fields: href
+func (_m *MockPulpClient) GetRpmRepositoryVersionSize(href string) (int64, error) {
+	ret := _m.Called(href)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (int64, error)); ok {
+		return rf(href)
+	}
+	if rf, ok := ret.Get(0).(func(string) int64); ok {
+		r0 = rf(href)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(href)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetTask provides a mock function with given fields: taskHref
 func (_m *MockPulpClient) GetTask(taskHref string) (zest.TaskResponse, error) {
 	ret := _m.Called(taskHref)
@@ -546,23 +570,23 @@ func (_m *MockPulpClient) SyncRpmRepository(rpmRpmRepositoryHref string, remoteH
 	return r0, r1
 }
 
-// UpdateRpmRemoteUrl provides a mock function with given fields: pulpHref, url
-func (_m *MockPulpClient) UpdateRpmRemoteUrl(pulpHref string, url string) (string, error) {
-	ret := _m.Called(pulpHref, url)
+// UpdateRpmRemote provides a mock function with given fields: pulpHref, url, options
+func (_m *MockPulpClient) UpdateRpmRemote(pulpHref string, url string, options RemoteOptions) (string, error) {
+	ret := _m.Called(pulpHref, url, options)
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string) (string, error)); ok {
-		return rf(pulpHref, url)
+	if rf, ok := ret.Get(0).(func(string, string, RemoteOptions) (string, error)); ok {
+		return rf(pulpHref, url, options)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) string); ok {
-		r0 = rf(pulpHref, url)
+	if rf, ok := ret.Get(0).(func(string, string, RemoteOptions) string); ok {
+		r0 = rf(pulpHref, url, options)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(pulpHref, url)
+	if rf, ok := ret.Get(1).(func(string, string, RemoteOptions) error); ok {
+		r1 = rf(pulpHref, url, options)
 	} else {
 		r1 = ret.Error(1)
 	}
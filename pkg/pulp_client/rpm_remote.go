@@ -2,10 +2,42 @@ package pulp_client
 
 import zest "github.com/content-services/zest/release/v2023"
 
+// RemoteOptions are the proxy a remote downloads content through, none if ProxyURL is empty,
+// the basic auth credentials of the upstream repository, none if Username is empty, and the
+// PEM client certificate of the upstream repository, none if ClientCert is empty
+type RemoteOptions struct {
+	ProxyURL      string
+	ProxyUsername string
+	ProxyPassword string
+	Username      string
+	Password      string
+	ClientCert    string
+	ClientKey     string
+	CACert        string
+}
+
 // Creates a remote
-func (r *pulpDaoImpl) CreateRpmRemote(name string, url string) (*zest.RpmRpmRemoteResponse, error) {
+func (r *pulpDaoImpl) CreateRpmRemote(name string, url string, options RemoteOptions) (*zest.RpmRpmRemoteResponse, error) {
 	rpmRpmRemote := *zest.NewRpmRpmRemote(name, url)
 	rpmRpmRemote.SetPolicy(zest.POLICY762ENUM_ON_DEMAND)
+	if options.ProxyURL != "" {
+		rpmRpmRemote.SetProxyUrl(options.ProxyURL)
+		if options.ProxyUsername != "" {
+			rpmRpmRemote.SetProxyUsername(options.ProxyUsername)
+			rpmRpmRemote.SetProxyPassword(options.ProxyPassword)
+		}
+	}
+	if options.Username != "" {
+		rpmRpmRemote.SetUsername(options.Username)
+		rpmRpmRemote.SetPassword(options.Password)
+	}
+	if options.ClientCert != "" {
+		rpmRpmRemote.SetClientCert(options.ClientCert)
+		rpmRpmRemote.SetClientKey(options.ClientKey)
+		if options.CACert != "" {
+			rpmRpmRemote.SetCaCert(options.CACert)
+		}
+	}
 	remoteResp, httpResp, err := r.client.RemotesRpmAPI.RemotesRpmRpmCreate(r.ctx, r.domainName).
 		RpmRpmRemote(rpmRpmRemote).Execute()
 
@@ -17,10 +49,42 @@ func (r *pulpDaoImpl) CreateRpmRemote(name string, url string) (*zest.RpmRpmRemo
 	return remoteResp, nil
 }
 
-// Starts an update task on an existing remote
-func (r *pulpDaoImpl) UpdateRpmRemoteUrl(pulpHref string, url string) (string, error) {
+// Starts an update task on an existing remote, setting its url, proxy, credentials and client certificate
+func (r *pulpDaoImpl) UpdateRpmRemote(pulpHref string, url string, options RemoteOptions) (string, error) {
 	patchRpmRemote := zest.PatchedrpmRpmRemote{}
 	patchRpmRemote.SetUrl(url)
+	if options.ProxyURL == "" {
+		patchRpmRemote.SetProxyUrlNil()
+	} else {
+		patchRpmRemote.SetProxyUrl(options.ProxyURL)
+	}
+	if options.ProxyURL == "" || options.ProxyUsername == "" {
+		patchRpmRemote.SetProxyUsernameNil()
+		patchRpmRemote.SetProxyPasswordNil()
+	} else {
+		patchRpmRemote.SetProxyUsername(options.ProxyUsername)
+		patchRpmRemote.SetProxyPassword(options.ProxyPassword)
+	}
+	if options.Username == "" {
+		patchRpmRemote.SetUsernameNil()
+		patchRpmRemote.SetPasswordNil()
+	} else {
+		patchRpmRemote.SetUsername(options.Username)
+		patchRpmRemote.SetPassword(options.Password)
+	}
+	if options.ClientCert == "" {
+		patchRpmRemote.SetClientCertNil()
+		patchRpmRemote.SetClientKeyNil()
+		patchRpmRemote.SetCaCertNil()
+	} else {
+		patchRpmRemote.SetClientCert(options.ClientCert)
+		patchRpmRemote.SetClientKey(options.ClientKey)
+		if options.CACert == "" {
+			patchRpmRemote.SetCaCertNil()
+		} else {
+			patchRpmRemote.SetCaCert(options.CACert)
+		}
+	}
 	updateResp, httpResp, err := r.client.RemotesRpmAPI.RemotesRpmRpmPartialUpdate(r.ctx, pulpHref).
 		PatchedrpmRpmRemote(patchRpmRemote).Execute()
 	if err != nil {
@@ -13,6 +13,38 @@ func (r *pulpDaoImpl) GetRpmRepositoryVersion(href string) (*zest.RepositoryVers
 	return resp, nil
 }
 
+// artifactsPageSize is the number of artifacts fetched per request when sizing a repository version
+const artifactsPageSize = 1000
+
+// GetRpmRepositoryVersionSize returns the bytes stored for the artifacts of a repository version.
+// Artifacts are shared between versions, so the sizes of several versions can not be summed
+// to get the storage used by their repository.
+func (r *pulpDaoImpl) GetRpmRepositoryVersionSize(href string) (int64, error) {
+	var size int64
+	var offset int32
+	for {
+		resp, httpResp, err := r.client.ArtifactsAPI.ArtifactsList(r.ctx, r.domainName).
+			RepositoryVersion(href).
+			Fields([]string{"size"}).
+			Limit(artifactsPageSize).
+			Offset(offset).
+			Execute()
+		if err != nil {
+			return 0, err
+		}
+		httpResp.Body.Close()
+
+		results := resp.GetResults()
+		for _, artifact := range results {
+			size += artifact.GetSize()
+		}
+		offset += int32(len(results))
+		if len(results) == 0 || resp.GetNext() == "" {
+			return size, nil
+		}
+	}
+}
+
 // DeleteRpmRepositoryVersion starts task to delete repository version and returns delete task's href
 func (r *pulpDaoImpl) DeleteRpmRepositoryVersion(href string) (string, error) {
 	resp, httpResp, err := r.client.RepositoriesRpmVersionsAPI.RepositoriesRpmRpmVersionsDelete(r.ctx, href).Execute()
@@ -33,12 +33,17 @@ func ConfigureEcho(allRoutes bool) *echo.Echo {
 		RequestIDKey:    config.RequestIdLoggingKey,
 		Skipper:         config.SkipLogging,
 	}))
-	e.Use(middleware.EnforceJSONContentType)
+	e.Use(middleware.RequestTimeout(config.Get().Options.RequestTimeout, handler.IsLongRunningRoute))
+	e.Use(middleware.BodyLimit(config.Get().Options.BodyLimit, config.Get().Options.UploadBodyLimit, handler.IsUploadRoute))
+	e.Use(middleware.EnforceJSONContentTypeOrUpload(handler.IsUploadRoute))
+	e.Use(middleware.Gzip(config.Get().Options.CompressionLevel, config.Get().Options.CompressionMinLength, handler.IsStreamRoute))
 
 	// Add routes
 	handler.RegisterPing(e)
 	if allRoutes {
 		handler.RegisterRoutes(e)
+		handler.RegisterInternalRoutes(e, middleware.EnforcePSK(config.Get().Clients.ImageBuilder.PSKs))
+		handler.RegisterContentRoutes(e)
 	}
 
 	// Set error handler
@@ -52,6 +57,7 @@ func ConfigureEchoWithMetrics(metrics *instrumentation.Metrics) *echo.Echo {
 	// Add additional global middlewares
 	e.Use(middleware.CreateMetricsMiddleware(metrics))
 	e.Use(middleware.WrapMiddlewareWithSkipper(identity.EnforceIdentity, middleware.SkipAuth))
+	e.Use(middleware.Deprecation(config.Get().Deprecations, metrics))
 	if config.Get().Clients.RbacEnabled {
 		rbacBaseUrl := config.Get().Clients.RbacBaseUrl
 		rbacTimeout := time.Duration(int64(config.Get().Clients.RbacTimeout) * int64(time.Second))
@@ -0,0 +1,152 @@
+package seeds
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/tasks/payloads"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type DemoOptions struct {
+	OrgID     string // org id of the first org, the following ones are suffixed with their index
+	Orgs      int    // number of orgs
+	Repos     int    // number of repositories per org
+	Rpms      int    // number of rpms per repository
+	Snapshots int    // number of snapshots per repository
+}
+
+// DemoResult counts the records created by SeedDemoData
+type DemoResult struct {
+	Orgs      []string
+	Repos     int
+	Rpms      int
+	Snapshots int
+	Tasks     int
+}
+
+// demoOrgID returns the org id of the index-th demo org
+func demoOrgID(base string, index int) string {
+	if index == 0 {
+		return base
+	}
+	return fmt.Sprintf("%s-%d", base, index+1)
+}
+
+// SeedDemoData creates repositories with rpms, snapshots and the tasks that
+// introspected and snapshotted them for options.Orgs orgs, so that the API
+// returns realistic data without calling it first.
+func SeedDemoData(db *gorm.DB, options DemoOptions) (DemoResult, error) {
+	result := DemoResult{}
+	if db == nil {
+		return result, fmt.Errorf("db cannot be nil")
+	}
+	if options.OrgID == "" {
+		return result, fmt.Errorf("org id cannot be empty")
+	}
+	if options.Orgs < 1 || options.Repos < 0 || options.Rpms < 0 || options.Snapshots < 0 {
+		return result, fmt.Errorf("orgs must be at least 1, and repos, rpms and snapshots cannot be lower than 0")
+	}
+
+	status := config.StatusValid
+	for i := 0; i < options.Orgs; i++ {
+		orgID := demoOrgID(options.OrgID, i)
+		result.Orgs = append(result.Orgs, orgID)
+		if options.Repos == 0 {
+			continue
+		}
+
+		// Only the repositories created now are filled, so that seeding again adds to existing data
+		started := time.Now()
+		if err := SeedRepositoryConfigurations(db, options.Repos, SeedOptions{OrgID: orgID, Status: &status}); err != nil {
+			return result, err
+		}
+
+		var repoConfigs []models.RepositoryConfiguration
+		if err := db.Preload("Repository").Where("org_id = ? AND created_at >= ?", orgID, started).Find(&repoConfigs).Error; err != nil {
+			return result, err
+		}
+		for _, repoConfig := range repoConfigs {
+			if err := SeedRpms(db, &repoConfig.Repository, options.Rpms); err != nil {
+				return result, err
+			}
+			if err := seedRpmNames(db, repoConfig.RepositoryUUID); err != nil {
+				return result, err
+			}
+			if err := SeedSnapshots(db, repoConfig.UUID, options.Snapshots); err != nil {
+				return result, err
+			}
+			count, err := seedRepositoryTasks(db, repoConfig, options.Snapshots)
+			if err != nil {
+				return result, err
+			}
+			result.Tasks += count
+		}
+
+		result.Repos += len(repoConfigs)
+		result.Rpms += len(repoConfigs) * options.Rpms
+		result.Snapshots += len(repoConfigs) * options.Snapshots
+	}
+	return result, nil
+}
+
+// seedRpmNames fills the package name index of the repository, used by the rpm search
+func seedRpmNames(db *gorm.DB, repoUuid string) error {
+	return db.Exec(`
+		INSERT INTO rpm_names (repository_uuid, name, summary)
+		SELECT DISTINCT ON (rpms.name) repositories_rpms.repository_uuid, rpms.name, rpms.summary
+		FROM rpms
+		INNER JOIN repositories_rpms ON repositories_rpms.rpm_uuid = rpms.uuid
+		WHERE repositories_rpms.repository_uuid = ?
+		ORDER BY rpms.name, rpms.epoch DESC
+		ON CONFLICT DO NOTHING`, repoUuid).Error
+}
+
+// seedRepositoryTasks creates a completed introspection task and a completed
+// snapshot task per snapshot of the repository configuration
+func seedRepositoryTasks(db *gorm.DB, repoConfig models.RepositoryConfiguration, snapshots int) (int, error) {
+	introspectPayload, err := json.Marshal(payloads.IntrospectPayload{Url: repoConfig.Repository.URL})
+	if err != nil {
+		return 0, err
+	}
+	snapshotPayload, err := json.Marshal(payloads.SnapshotPayload{})
+	if err != nil {
+		return 0, err
+	}
+
+	repoUUID, err := uuid.Parse(repoConfig.RepositoryUUID)
+	if err != nil {
+		return 0, err
+	}
+	newTask := func(typename string, payload []byte, ago time.Duration) models.TaskInfo {
+		queued := time.Now().Add(-ago)
+		started := queued.Add(time.Minute)
+		finished := started.Add(2 * time.Minute)
+		return models.TaskInfo{
+			Id:             uuid.New(),
+			Typename:       typename,
+			Payload:        payload,
+			OrgId:          repoConfig.OrgID,
+			RepositoryUUID: repoUUID,
+			Dependencies:   make([]uuid.UUID, 0),
+			Token:          uuid.New(),
+			Queued:         &queued,
+			Started:        &started,
+			Finished:       &finished,
+			Status:         config.TaskStatusCompleted,
+		}
+	}
+
+	tasks := []models.TaskInfo{newTask(payloads.Introspect, introspectPayload, time.Hour)}
+	for i := 0; i < snapshots; i++ {
+		tasks = append(tasks, newTask(payloads.Snapshot, snapshotPayload, time.Duration(snapshots-i)*24*time.Hour))
+	}
+	if err := db.Create(&tasks).Error; err != nil {
+		return 0, err
+	}
+	return len(tasks), nil
+}
@@ -69,3 +69,42 @@ func (s *SeedSuite) TestSeedTasks() {
 	assert.NoError(t, err)
 	assert.Greater(t, len(task), 0)
 }
+
+func (s *SeedSuite) TestSeedDemoData() {
+	t := s.T()
+	tx := s.tx
+	orgId := RandomOrgId()
+
+	result, err := SeedDemoData(tx, DemoOptions{
+		OrgID:     orgId,
+		Orgs:      2,
+		Repos:     3,
+		Rpms:      10,
+		Snapshots: 2,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{orgId, orgId + "-2"}, result.Orgs)
+	assert.Equal(t, 6, result.Repos)
+	assert.Equal(t, 60, result.Rpms)
+	assert.Equal(t, 12, result.Snapshots)
+	assert.Equal(t, 18, result.Tasks)
+
+	var count int64
+	err = tx.Model(&models.RepositoryConfiguration{}).Where("org_id = ?", orgId+"-2").Count(&count).Error
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	err = tx.Model(&models.Snapshot{}).
+		Joins("inner join repository_configurations on repository_configurations.uuid = snapshots.repository_configuration_uuid").
+		Where("repository_configurations.org_id = ?", orgId).
+		Count(&count).Error
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), count)
+
+	err = tx.Model(&models.TaskInfo{}).Where("org_id = ?", orgId).Count(&count).Error
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9), count)
+
+	_, err = SeedDemoData(tx, DemoOptions{OrgID: orgId, Orgs: 0})
+	assert.Error(t, err)
+}
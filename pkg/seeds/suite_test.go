@@ -1,34 +1,25 @@
 package seeds
 
 import (
-	"github.com/content-services/content-sources-backend/pkg/db"
+	"github.com/content-services/content-sources-backend/pkg/test/database"
 	"github.com/stretchr/testify/suite"
 	"gorm.io/gorm"
 )
 
 type SeedSuite struct {
 	suite.Suite
-	db *gorm.DB
-	tx *gorm.DB
+	schema *database.Schema
+	tx     *gorm.DB
 }
 
-// SetupSuite Initialize the test suite
+// SetupSuite Initialize the test suite in its own schema
 func (s *SeedSuite) SetupSuite() {
-	if err := db.Connect(); err != nil {
-		return
-	}
-	s.db = db.DB
-}
-
-// TearDownSuite Finalize the test suite
-func (s *SeedSuite) TearDownSuite() {
-	s.db = nil
+	s.schema = database.NewSchema(s.T())
 }
 
 // SetupTest Prepare the unit test
 func (s *SeedSuite) SetupTest() {
-	// Remove the content for the 3 involved tables
-	s.tx = s.db.Begin()
+	s.tx = s.schema.DB.Begin()
 }
 
 // TearDownTest Clean up the unit test
@@ -0,0 +1,118 @@
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+)
+
+// ContentPath returns the path signed snapshot contents are served from, under the path
+// prefix and app name of the api, followed by the token and the repository path
+func ContentPath() string {
+	return path.Join("/", config.Get().PathPrefix, config.Get().AppName, "content") + "/"
+}
+
+var (
+	ErrNoKeys           = errors.New("no signing key is configured")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrExpired          = errors.New("signature expired")
+)
+
+// Signer signs tokens granting access to a path prefix until they expire.  Tokens are
+// signed with the first key and verified with any of them, so that a key is rotated
+// by adding the new key first and removing the old one once its tokens have expired.
+type Signer struct {
+	keys       []string
+	expiration time.Duration
+}
+
+func NewSigner(keys []string, expiration time.Duration) (*Signer, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+	return &Signer{keys: keys, expiration: expiration}, nil
+}
+
+// FromConfig returns the signer of the configured content signing keys, or nil
+// when content signing is disabled
+func FromConfig() *Signer {
+	signingConfig := config.Get().Clients.Pulp.ContentSigning
+	if !signingConfig.Enabled {
+		return nil
+	}
+	signer, err := NewSigner(signingConfig.Keys, signingConfig.Expiration)
+	if err != nil {
+		return nil
+	}
+	return signer
+}
+
+// ContentURL returns a signed url of the repository path, served from baseURL
+func (s *Signer) ContentURL(baseURL string, repositoryPath string, now time.Time) string {
+	repositoryPath = strings.Trim(repositoryPath, "/")
+	return strings.TrimSuffix(baseURL, "/") + ContentPath() + s.Token(repositoryPath, now) + "/" + repositoryPath + "/"
+}
+
+// Token returns a token granting access to prefix, and everything under it, until it expires
+func (s *Signer) Token(prefix string, now time.Time) string {
+	expires := now.Add(s.expiration).Unix()
+	key := s.keys[0]
+	return fmt.Sprintf("%d.%s.%s", expires, keyID(key), signature(key, strings.Trim(prefix, "/"), expires))
+}
+
+// Verify returns nil if token grants access to path at the given time
+func (s *Signer) Verify(token string, path string, now time.Time) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ErrInvalidSignature
+	}
+	expires, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	var key string
+	for _, candidate := range s.keys {
+		if keyID(candidate) == parts[1] {
+			key = candidate
+			break
+		}
+	}
+	if key == "" {
+		return ErrInvalidSignature
+	}
+
+	// The token signs a prefix of the path, try each of them
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := len(segments); i > 0; i-- {
+		expected := signature(key, strings.Join(segments[:i], "/"), expires)
+		if hmac.Equal([]byte(expected), []byte(parts[2])) {
+			if now.Unix() > expires {
+				return ErrExpired
+			}
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// keyID identifies a key in tokens without revealing it
+func keyID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:4])
+}
+
+func signature(key string, prefix string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(prefix + "\n" + strconv.FormatInt(expires, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
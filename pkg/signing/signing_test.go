@@ -0,0 +1,56 @@
+package signing
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Now()
+	signer, err := NewSigner([]string{"secret"}, time.Hour)
+	require.NoError(t, err)
+
+	token := signer.Token("domain/snapshot", now)
+	assert.NoError(t, signer.Verify(token, "domain/snapshot/repodata/repomd.xml", now))
+	assert.NoError(t, signer.Verify(token, "/domain/snapshot/", now))
+	assert.ErrorIs(t, signer.Verify(token, "domain/other/repodata/repomd.xml", now), ErrInvalidSignature)
+	assert.ErrorIs(t, signer.Verify(token, "domain", now), ErrInvalidSignature)
+	assert.ErrorIs(t, signer.Verify(token, "domain/snapshot/repomd.xml", now.Add(2*time.Hour)), ErrExpired)
+
+	tampered := strings.Replace(token, strings.Split(token, ".")[0], "9999999999", 1)
+	assert.ErrorIs(t, signer.Verify(tampered, "domain/snapshot/repomd.xml", now), ErrInvalidSignature)
+	assert.ErrorIs(t, signer.Verify("garbage", "domain/snapshot/repomd.xml", now), ErrInvalidSignature)
+}
+
+func TestKeyRotation(t *testing.T) {
+	now := time.Now()
+	oldSigner, err := NewSigner([]string{"old"}, time.Hour)
+	require.NoError(t, err)
+	rotated, err := NewSigner([]string{"new", "old"}, time.Hour)
+	require.NoError(t, err)
+	newSigner, err := NewSigner([]string{"new"}, time.Hour)
+	require.NoError(t, err)
+
+	oldToken := oldSigner.Token("domain/snapshot", now)
+	assert.NoError(t, rotated.Verify(oldToken, "domain/snapshot/repomd.xml", now))
+	assert.ErrorIs(t, newSigner.Verify(oldToken, "domain/snapshot/repomd.xml", now), ErrInvalidSignature)
+
+	newToken := rotated.Token("domain/snapshot", now)
+	assert.NoError(t, newSigner.Verify(newToken, "domain/snapshot/repomd.xml", now))
+}
+
+func TestContentURL(t *testing.T) {
+	signer, err := NewSigner([]string{"secret"}, time.Hour)
+	require.NoError(t, err)
+
+	url := signer.ContentURL("https://console.example.com/", "/domain/snapshot/", time.Now())
+	assert.True(t, strings.HasPrefix(url, "https://console.example.com/api/content-sources/content/"))
+	assert.True(t, strings.HasSuffix(url, "/domain/snapshot/"))
+
+	_, err = NewSigner([]string{}, time.Hour)
+	assert.ErrorIs(t, err, ErrNoKeys)
+}
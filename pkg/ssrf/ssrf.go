@@ -0,0 +1,207 @@
+package ssrf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned when a host resolves to a loopback, private or link-local address,
+// which urls given by users must not reach
+var ErrForbiddenAddress = errors.New("address is not publicly routable")
+
+// lookupIPAddr resolves the addresses of a host, replaced in tests
+var lookupIPAddr = net.DefaultResolver.LookupIPAddr
+
+// CheckHost resolves the host and returns ErrForbiddenAddress if any of its addresses is forbidden
+func CheckHost(ctx context.Context, host string) error {
+	return checkHost(ctx, host, nil)
+}
+
+func checkHost(ctx context.Context, host string, allowed []*net.IPNet) error {
+	if ip := net.ParseIP(host); ip != nil {
+		return checkAllowedIP(ip, allowed)
+	}
+	addrs, err := lookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if err := checkAllowedIP(addr.IP, allowed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Transport returns a transport refusing to connect to forbidden addresses. Requests are not sent
+// through the proxy of the environment.
+func Transport() *http.Transport {
+	return NewGuard(nil).Transport(nil)
+}
+
+// Guard refuses to connect to forbidden addresses outside of its allowed networks
+type Guard struct {
+	allowed []*net.IPNet
+	dialer  *net.Dialer
+}
+
+// NewGuard returns a guard allowing to connect to forbidden addresses of the allowed networks only
+func NewGuard(allowed []*net.IPNet) *Guard {
+	return &Guard{allowed: allowed, dialer: Dialer(allowed)}
+}
+
+// Transport returns a transport sending requests through proxy, nil for none.  A proxy connects to
+// the hosts of the requests itself, so they are checked when resolved before being sent to it, and
+// the address of the proxy is checked when connecting to it, as the proxy may be given by a user.
+func (g *Guard) Transport(proxy func(*http.Request) (*url.URL, error)) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = g.dialer.DialContext
+	if proxy != nil {
+		transport.Proxy = g.checkProxied(proxy, nil)
+	}
+	return transport
+}
+
+// TrustedProxyTransport returns a transport sending requests through proxy, set by the operator such
+// as the proxy of the environment.  The hosts of the requests are checked as for Transport, but the
+// address of the proxy is not, it may be on a private network.
+func (g *Guard) TrustedProxyTransport(proxy func(*http.Request) (*url.URL, error)) *http.Transport {
+	transport := g.Transport(nil)
+	proxies := &proxyAddresses{addresses: map[string]bool{}}
+	transport.Proxy = g.checkProxied(proxy, proxies)
+	direct := &net.Dialer{Timeout: g.dialer.Timeout, KeepAlive: g.dialer.KeepAlive}
+	transport.DialContext = func(ctx context.Context, network string, address string) (net.Conn, error) {
+		if proxies.contains(address) {
+			return direct.DialContext(ctx, network, address)
+		}
+		return g.dialer.DialContext(ctx, network, address)
+	}
+	return transport
+}
+
+// checkProxied wraps proxy to check the hosts of the requests sent through a proxy, recording the
+// addresses of the proxies in proxies if not nil
+func (g *Guard) checkProxied(proxy func(*http.Request) (*url.URL, error), proxies *proxyAddresses) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		proxyURL, err := proxy(req)
+		if err != nil || proxyURL == nil {
+			return proxyURL, err
+		}
+		if err = checkHost(req.Context(), req.URL.Hostname(), g.allowed); err != nil {
+			return nil, err
+		}
+		if proxies != nil {
+			proxies.add(proxyAddress(proxyURL))
+		}
+		return proxyURL, nil
+	}
+}
+
+// proxyAddresses records the addresses of the proxies a transport connects to
+type proxyAddresses struct {
+	mutex     sync.RWMutex
+	addresses map[string]bool
+}
+
+func (p *proxyAddresses) add(address string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.addresses[address] = true
+}
+
+func (p *proxyAddresses) contains(address string) bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.addresses[address]
+}
+
+// proxyAddress returns the address a transport dials to connect to a proxy, the default port of its
+// scheme being added when it has none
+func proxyAddress(proxyURL *url.URL) string {
+	port := proxyURL.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443", "socks5": "1080", "socks5h": "1080"}[proxyURL.Scheme]
+	}
+	return net.JoinHostPort(proxyURL.Hostname(), port)
+}
+
+// Dialer returns a dialer refusing to connect to forbidden addresses outside of the allowed networks.
+// The address is checked when dialing, once resolved, so that a host resolving to a public address
+// when validated and to a private one when used is refused as well.
+func Dialer(allowed []*net.IPNet) *net.Dialer {
+	return &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network string, address string, _ syscall.RawConn) error {
+			return control(address, allowed)
+		},
+	}
+}
+
+// ParseNetworks parses networks in CIDR notation, an IP address being parsed as the network of itself
+func ParseNetworks(networks []string) ([]*net.IPNet, error) {
+	parsed := make([]*net.IPNet, 0, len(networks))
+	for _, network := range networks {
+		ipNet, err := parseNetwork(strings.TrimSpace(network))
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, ipNet)
+	}
+	return parsed, nil
+}
+
+func parseNetwork(network string) (*net.IPNet, error) {
+	if ip := net.ParseIP(network); ip != nil {
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = 8 * net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, ipNet, err := net.ParseCIDR(network)
+	if err != nil {
+		return nil, fmt.Errorf("invalid network %v: %w", network, err)
+	}
+	return ipNet, nil
+}
+
+// control is run by the dialer before connecting to each resolved address
+func control(address string, allowed []*net.IPNet) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("%w: %v", ErrForbiddenAddress, host)
+	}
+	return checkAllowedIP(ip, allowed)
+}
+
+func checkAllowedIP(ip net.IP, allowed []*net.IPNet) error {
+	for _, network := range allowed {
+		if network.Contains(ip) {
+			return nil
+		}
+	}
+	return checkIP(ip)
+}
+
+func checkIP(ip net.IP) error {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return fmt.Errorf("%w: %v", ErrForbiddenAddress, ip)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package ssrf
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckHost(t *testing.T) {
+	defer func(lookup func(context.Context, string) ([]net.IPAddr, error)) { lookupIPAddr = lookup }(lookupIPAddr)
+	lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		switch host {
+		case "public.example.com":
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+		case "internal.example.com":
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}, {IP: net.ParseIP("10.0.0.5")}}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	for _, host := range []string{"public.example.com", "93.184.216.34", "2606:2800:220:1:248:1893:25c8:1946"} {
+		assert.NoError(t, CheckHost(context.Background(), host), host)
+	}
+	for _, host := range []string{"internal.example.com", "127.0.0.1", "::1", "169.254.169.254", "192.168.1.1", "172.16.0.1", "0.0.0.0", "fe80::1", "fd00::1", "::ffff:127.0.0.1"} {
+		assert.ErrorIs(t, CheckHost(context.Background(), host), ErrForbiddenAddress, host)
+	}
+
+	err := CheckHost(context.Background(), "unknown.example.com")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrForbiddenAddress)
+}
+
+func TestTransportRefusesLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := http.Client{Transport: Transport()}
+	_, err := client.Get(server.URL)
+	assert.ErrorIs(t, err, ErrForbiddenAddress)
+}
+
+func TestDialerAllowedNetworks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	allowed, err := ParseNetworks([]string{"127.0.0.0/8", "::1"})
+	require.NoError(t, err)
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = Dialer(allowed).DialContext
+	client := http.Client{Transport: transport}
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// Other forbidden addresses are still refused
+	allowed, err = ParseNetworks([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+	transport = http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = Dialer(allowed).DialContext
+	client = http.Client{Transport: transport}
+	_, err = client.Get(server.URL)
+	assert.ErrorIs(t, err, ErrForbiddenAddress)
+}
+
+func TestParseNetworks(t *testing.T) {
+	networks, err := ParseNetworks([]string{"10.0.0.0/8", " 192.168.1.10 ", "fd00::/8"})
+	require.NoError(t, err)
+	require.Len(t, networks, 3)
+	assert.True(t, networks[1].Contains(net.ParseIP("192.168.1.10")))
+	assert.False(t, networks[1].Contains(net.ParseIP("192.168.1.11")))
+
+	_, err = ParseNetworks([]string{"mirror.example.com"})
+	assert.Error(t, err)
+}
+
+func TestGuardProxy(t *testing.T) {
+	defer func(lookup func(context.Context, string) ([]net.IPAddr, error)) { lookupIPAddr = lookup }(lookupIPAddr)
+	lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		switch host {
+		case "public.example.com":
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+		case "internal.example.com":
+			return []net.IPAddr{{IP: net.ParseIP("10.0.0.5")}}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	// The proxy answers the requests itself, recording their hosts
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.Host)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	allowed, err := ParseNetworks([]string{"192.168.0.0/16"})
+	require.NoError(t, err)
+	guard := NewGuard(allowed)
+	get := func(transport *http.Transport, target string) error {
+		resp, err := (&http.Client{Transport: transport}).Get(target)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	// The address of a trusted proxy is not checked, the hosts of the requests are
+	transport := guard.TrustedProxyTransport(http.ProxyURL(proxyURL))
+	assert.NoError(t, get(transport, "http://public.example.com/repodata/repomd.xml"))
+	assert.NoError(t, get(transport, "http://192.168.1.10/repodata/repomd.xml"))
+	for _, target := range []string{"http://169.254.169.254/latest/meta-data/", "http://10.0.0.5/", "http://internal.example.com/", "http://127.0.0.1/"} {
+		assert.ErrorIs(t, get(transport, target), ErrForbiddenAddress, target)
+	}
+	assert.Equal(t, []string{"public.example.com", "192.168.1.10"}, proxied)
+
+	// The address of a proxy given by a user is checked as well
+	transport = guard.Transport(http.ProxyURL(proxyURL))
+	assert.ErrorIs(t, get(transport, "http://public.example.com/repodata/repomd.xml"), ErrForbiddenAddress)
+	assert.Len(t, proxied, 2)
+}
+
+func TestProxyAddress(t *testing.T) {
+	for proxy, address := range map[string]string{
+		"http://proxy.example.com:3128": "proxy.example.com:3128",
+		"http://proxy.example.com":      "proxy.example.com:80",
+		"https://proxy.example.com":     "proxy.example.com:443",
+		"socks5://[fd00::1]":            "[fd00::1]:1080",
+	} {
+		proxyURL, err := url.Parse(proxy)
+		require.NoError(t, err)
+		assert.Equal(t, address, proxyAddress(proxyURL), proxy)
+	}
+}
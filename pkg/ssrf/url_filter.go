@@ -0,0 +1,97 @@
+package ssrf
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ErrDeniedURL is returned for urls whose host is denied by a URLFilter
+var ErrDeniedURL = errors.New("URL is not allowed")
+
+// URLFilter denies the urls whose host matches a pattern of its deny list, or none of its allow
+// list when it has one.  A pattern is either a network in CIDR notation or an IP address, matching
+// the hosts given as an IP address in it, or a host name in which * matches any characters, such
+// as *.internal.  Host names are not resolved, see Transport to check the addresses connected to.
+// Urls without a host are not filtered.
+type URLFilter struct {
+	deny  []hostPattern
+	allow []hostPattern
+}
+
+type hostPattern struct {
+	network *net.IPNet
+	name    string
+}
+
+// NewURLFilter returns a filter of the given deny and allow lists, failing on an invalid pattern
+func NewURLFilter(deny []string, allow []string) (*URLFilter, error) {
+	var err error
+	filter := URLFilter{}
+	if filter.deny, err = parsePatterns(deny); err != nil {
+		return nil, err
+	}
+	if filter.allow, err = parsePatterns(allow); err != nil {
+		return nil, err
+	}
+	return &filter, nil
+}
+
+func parsePatterns(patterns []string) ([]hostPattern, error) {
+	parsed := make([]hostPattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		if net.ParseIP(pattern) != nil || strings.Contains(pattern, "/") {
+			network, err := parseNetwork(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid url filter pattern %v: %w", pattern, err)
+			}
+			parsed = append(parsed, hostPattern{network: network})
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid url filter pattern %v: %w", pattern, err)
+		}
+		parsed = append(parsed, hostPattern{name: pattern})
+	}
+	return parsed, nil
+}
+
+// Check returns ErrDeniedURL if the host of the url is denied
+func (f *URLFilter) Check(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		// Nothing can be requested from such a url
+		return nil
+	}
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	for _, pattern := range f.deny {
+		if pattern.matches(host) {
+			return fmt.Errorf("%w: host %v is in the deny list", ErrDeniedURL, host)
+		}
+	}
+	if len(f.allow) == 0 {
+		return nil
+	}
+	for _, pattern := range f.allow {
+		if pattern.matches(host) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: host %v is not in the allow list", ErrDeniedURL, host)
+}
+
+func (p hostPattern) matches(host string) bool {
+	if p.network != nil {
+		ip := net.ParseIP(host)
+		return ip != nil && p.network.Contains(ip)
+	}
+	matched, _ := path.Match(p.name, host)
+	return matched
+}
@@ -0,0 +1,41 @@
+package ssrf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLFilter(t *testing.T) {
+	filter, err := NewURLFilter([]string{"localhost", "*.internal", "127.0.0.0/8", "10.0.0.0/8", "169.254.169.254", "fc00::/7"}, nil)
+	require.NoError(t, err)
+
+	for _, url := range []string{"https://example.com/repo/", "http://93.184.216.34/repo/", "https://[2606:2800:220:1::1]/repo/",
+		"https://example.com/$releasever/$basearch/", "https://internal.example.com/", "/repo/", "http://%zz/"} {
+		assert.NoError(t, filter.Check(url), url)
+	}
+	for _, url := range []string{"http://localhost:8080/repo/", "http://LOCALHOST./repo/", "https://mirror.corp.internal/repo/",
+		"http://127.0.0.1/", "http://10.1.2.3/", "http://169.254.169.254/latest/meta-data/", "http://[fd00::1]/",
+		"http://[::ffff:127.0.0.1]/"} {
+		assert.ErrorIs(t, filter.Check(url), ErrDeniedURL, url)
+	}
+}
+
+func TestURLFilterAllowList(t *testing.T) {
+	filter, err := NewURLFilter([]string{"secret.example.com"}, []string{"*.example.com", "93.184.216.0/24"})
+	require.NoError(t, err)
+
+	assert.NoError(t, filter.Check("https://mirror.example.com/repo/"))
+	assert.NoError(t, filter.Check("http://93.184.216.34/repo/"))
+	assert.ErrorIs(t, filter.Check("https://example.org/repo/"), ErrDeniedURL)
+	// The deny list wins over the allow list
+	assert.ErrorIs(t, filter.Check("https://secret.example.com/repo/"), ErrDeniedURL)
+}
+
+func TestURLFilterInvalidPattern(t *testing.T) {
+	_, err := NewURLFilter([]string{"10.0.0.0/33"}, nil)
+	assert.Error(t, err)
+	_, err = NewURLFilter(nil, []string{"[a-"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,69 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/content-services/content-sources-backend/pkg/candlepin_client"
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/pulp_client"
+	"github.com/content-services/content-sources-backend/pkg/tasks/payloads"
+	"github.com/content-services/content-sources-backend/pkg/tasks/queue"
+	"github.com/rs/zerolog"
+)
+
+// BootstrapOrgPayload holds the name of the pulp domain of the org, created with its row by the
+// bootstrap request
+type BootstrapOrgPayload struct {
+	DomainName string
+}
+
+// BootstrapOrg creates what a new org needs outside of the database: its pulp domain and its candlepin
+// owner.  Both are left unchanged when they already exist, so the task can be queued again for an org.
+type BootstrapOrg struct {
+	orgID            string
+	payload          *BootstrapOrgPayload
+	globalPulpClient pulp_client.PulpGlobalClient // nil when pulp is not configured
+	candlepinClient  candlepin_client.CandlepinClient
+	logger           *zerolog.Logger
+}
+
+func BootstrapOrgHandler(ctx context.Context, task *models.TaskInfo, _ *queue.Queue) error {
+	opts := BootstrapOrgPayload{}
+	if err := payloads.Decode(task.Typename, task.Payload, &opts); err != nil {
+		return fmt.Errorf("payload incorrect type for " + config.BootstrapOrgTask)
+	}
+	logger := LogForTask(task.Id.String(), task.Typename, task.RequestID, task.Username)
+	ctxWithLogger := logger.WithContext(ctx)
+
+	bo := BootstrapOrg{
+		orgID:   task.OrgId,
+		payload: &opts,
+		logger:  logger,
+	}
+	if config.PulpConfigured() {
+		bo.globalPulpClient = pulp_client.GetGlobalPulpClient(ctxWithLogger)
+	}
+	if config.CandlepinConfigured() {
+		bo.candlepinClient = candlepin_client.GetCandlepinClient(ctxWithLogger)
+	}
+	return bo.Run()
+}
+
+// Run creates the pulp domain and the candlepin owner of the org, when pulp and candlepin are configured
+func (bo *BootstrapOrg) Run() error {
+	if bo.globalPulpClient != nil && bo.payload.DomainName != "" {
+		if _, err := bo.globalPulpClient.LookupOrCreateDomain(bo.payload.DomainName); err != nil {
+			return err
+		}
+		bo.logger.Info().Msgf("Pulp domain %v of org %v is ready", bo.payload.DomainName, bo.orgID)
+	}
+	if bo.candlepinClient != nil {
+		if err := bo.candlepinClient.CreateOwner(bo.orgID); err != nil {
+			return err
+		}
+		bo.logger.Info().Msgf("Candlepin owner of org %v is ready", bo.orgID)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package tasks
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/candlepin_client"
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/pulp_client"
+	"github.com/openlyinc/pointy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBootstrapOrg(t *testing.T) {
+	pulpClient := pulp_client.NewMockPulpGlobalClient(t)
+	candlepinClient := candlepin_client.NewMockCandlepinClient(t)
+	pulpClient.On("LookupOrCreateDomain", "domain").Return(pointy.String("domainHref"), nil).Once()
+	candlepinClient.On("CreateOwner", "OrgId").Return(nil).Once()
+
+	bo := BootstrapOrg{
+		orgID:            "OrgId",
+		payload:          &BootstrapOrgPayload{DomainName: "domain"},
+		globalPulpClient: pulpClient,
+		candlepinClient:  candlepinClient,
+		logger:           LogForTask("id", config.BootstrapOrgTask, "", ""),
+	}
+	assert.NoError(t, bo.Run())
+}
+
+func TestBootstrapOrgCandlepinFailure(t *testing.T) {
+	candlepinClient := candlepin_client.NewMockCandlepinClient(t)
+	candlepinClient.On("CreateOwner", "OrgId").Return(fmt.Errorf("candlepin returned 500 creating the owner of org OrgId")).Once()
+
+	// Pulp is not configured
+	bo := BootstrapOrg{
+		orgID:           "OrgId",
+		payload:         &BootstrapOrgPayload{DomainName: "domain"},
+		candlepinClient: candlepinClient,
+		logger:          LogForTask("id", config.BootstrapOrgTask, "", ""),
+	}
+	assert.ErrorContains(t, bo.Run(), "500")
+}
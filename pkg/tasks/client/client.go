@@ -4,11 +4,13 @@ import (
 	"github.com/content-services/content-sources-backend/pkg/tasks"
 	"github.com/content-services/content-sources-backend/pkg/tasks/queue"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 )
 
 //go:generate mockery  --name TaskClient --filename client_mock.go --inpackage
 type TaskClient interface {
 	Enqueue(task queue.Task) (uuid.UUID, error)
+	RequeueFailed(taskID uuid.UUID) error
 }
 
 type Client struct {
@@ -26,7 +28,17 @@ func (c *Client) Enqueue(task queue.Task) (uuid.UUID, error) {
 	if err != nil {
 		return uuid.Nil, err
 	}
-	logger := tasks.LogForTask(id.String(), task.Typename, task.RequestID)
+	logger := tasks.LogForTask(id.String(), task.Typename, task.RequestID, task.Username)
 	logger.Info().Msg("[Enqueued Task]")
 	return id, nil
 }
+
+// RequeueFailed queues again a failed or dead task, once the cause of its failure is fixed
+func (c *Client) RequeueFailed(taskID uuid.UUID) error {
+	err := c.queue.RequeueFailed(taskID)
+	if err != nil {
+		return err
+	}
+	log.Logger.Info().Str("task_id", taskID.String()).Msg("[Requeued Failed Task]")
+	return nil
+}
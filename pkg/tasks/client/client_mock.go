@@ -39,6 +39,20 @@ func (_m *MockTaskClient) Enqueue(task queue.Task) (uuid.UUID, error) {
 	return r0, r1
 }
 
+// RequeueFailed provides a mock function with given fields: taskID
+func (_m *MockTaskClient) RequeueFailed(taskID uuid.UUID) error {
+	ret := _m.Called(taskID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID) error); ok {
+		r0 = rf(taskID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewMockTaskClient interface {
 	mock.TestingT
 	Cleanup(func())
@@ -2,7 +2,6 @@ package tasks
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 
 	"github.com/content-services/content-sources-backend/pkg/config"
@@ -10,6 +9,7 @@ import (
 	"github.com/content-services/content-sources-backend/pkg/db"
 	"github.com/content-services/content-sources-backend/pkg/models"
 	"github.com/content-services/content-sources-backend/pkg/pulp_client"
+	"github.com/content-services/content-sources-backend/pkg/tasks/payloads"
 	"github.com/content-services/content-sources-backend/pkg/tasks/queue"
 	zest "github.com/content-services/zest/release/v2023"
 )
@@ -28,7 +28,7 @@ type DeleteRepositorySnapshots struct {
 
 // This org may or may not have a domain created in pulp, so make sure the domain exists and if not, return a nil pulpClient
 func lookupOptionalPulpClient(ctx context.Context, globalClient pulp_client.PulpGlobalClient, task *models.TaskInfo, daoReg *dao.DaoRegistry) (*pulp_client.PulpClient, error) {
-	domainName, err := daoReg.Domain.FetchOrCreateDomain(task.OrgId)
+	domainName, err := daoReg.Domain.FetchOrCreateDomain(ctx, task.OrgId)
 	if err != nil {
 		return nil, err
 	}
@@ -48,11 +48,11 @@ func lookupOptionalPulpClient(ctx context.Context, globalClient pulp_client.Pulp
 
 func DeleteSnapshotHandler(ctx context.Context, task *models.TaskInfo, _ *queue.Queue) error {
 	opts := DeleteRepositorySnapshotsPayload{}
-	if err := json.Unmarshal(task.Payload, &opts); err != nil {
+	if err := payloads.Decode(task.Typename, task.Payload, &opts); err != nil {
 		return fmt.Errorf("payload incorrect type for " + config.DeleteRepositorySnapshotsTask)
 	}
 	daoReg := dao.GetDaoRegistry(db.DB)
-	logger := LogForTask(task.Id.String(), task.Typename, task.RequestID)
+	logger := LogForTask(task.Id.String(), task.Typename, task.RequestID, task.Username)
 	ctxWithLogger := logger.WithContext(ctx)
 	globalPulpClient := pulp_client.GetGlobalPulpClient(ctxWithLogger)
 
@@ -77,9 +77,12 @@ func (d *DeleteRepositorySnapshots) Run() error {
 	if config.PulpConfigured() && d.pulpClient != nil {
 		snaps, _ := d.fetchSnapshots()
 		for _, snap := range snaps {
-			_, err := d.deleteRpmDistribution(snap)
-			if err != nil {
-				return err
+			// The distributions of shared snapshots are used by other orgs
+			if !snap.Shared {
+				_, err := d.deleteRpmDistribution(snap)
+				if err != nil {
+					return err
+				}
 			}
 			err = d.deleteSnapshot(snap.UUID)
 			if err != nil {
@@ -104,7 +107,7 @@ func (d *DeleteRepositorySnapshots) getPulpClient() pulp_client.PulpClient {
 }
 
 func (d *DeleteRepositorySnapshots) fetchSnapshots() ([]models.Snapshot, error) {
-	return d.daoReg.Snapshot.FetchForRepoConfigUUID(d.payload.RepoConfigUUID)
+	return d.daoReg.Snapshot.FetchForRepoConfigUUID(d.ctx, d.payload.RepoConfigUUID)
 }
 
 func (d *DeleteRepositorySnapshots) deleteRpmDistribution(snap models.Snapshot) (*zest.TaskResponse, error) {
@@ -155,7 +158,7 @@ func (d *DeleteRepositorySnapshots) deleteRpmRepoAndRemote() (taskRepo, taskRemo
 }
 
 func (d *DeleteRepositorySnapshots) deleteSnapshot(snapUUID string) error {
-	err := d.daoReg.Snapshot.Delete(snapUUID)
+	err := d.daoReg.Snapshot.Delete(d.ctx, snapUUID)
 	if err != nil {
 		return err
 	}
@@ -163,7 +166,7 @@ func (d *DeleteRepositorySnapshots) deleteSnapshot(snapUUID string) error {
 }
 
 func (d *DeleteRepositorySnapshots) deleteRepoConfig() error {
-	err := d.daoReg.RepositoryConfig.Delete(d.task.OrgId, d.payload.RepoConfigUUID)
+	err := d.daoReg.RepositoryConfig.Delete(d.ctx, d.task.OrgId, d.payload.RepoConfigUUID)
 	if err != nil {
 		return err
 	}
@@ -3,6 +3,7 @@ package tasks
 import (
 	"context"
 	"fmt"
+	"github.com/stretchr/testify/mock"
 	"testing"
 
 	"github.com/content-services/content-sources-backend/pkg/api"
@@ -47,7 +48,7 @@ func (s *DeleteRepositorySnapshotsSuite) TestLookupOptionalPulpClient() {
 		RepositoryUUID: uuid.New(),
 	}
 
-	s.mockDaoRegistry.Domain.On("FetchOrCreateDomain", task.OrgId).Return("myDomain", nil)
+	s.mockDaoRegistry.Domain.On("FetchOrCreateDomain", mock.Anything, task.OrgId).Return("myDomain", nil)
 	s.MockPulpClient.On("LookupDomain", "myDomain").Return(pointy.Pointer("somepath"), nil)
 	found, err := lookupOptionalPulpClient(context.Background(), s.pulpClient(), &task, s.mockDaoRegistry.ToDaoRegistry())
 	assert.NoError(s.T(), err)
@@ -61,7 +62,7 @@ func (s *DeleteRepositorySnapshotsSuite) TestLookupOptionalPulpClientNil() {
 		RepositoryUUID: uuid.New(),
 	}
 
-	s.mockDaoRegistry.Domain.On("FetchOrCreateDomain", task.OrgId).Return("myDomain", nil)
+	s.mockDaoRegistry.Domain.On("FetchOrCreateDomain", mock.Anything, task.OrgId).Return("myDomain", nil)
 	s.MockPulpClient.On("LookupDomain", "myDomain").Return(nil, nil)
 	found, err := lookupOptionalPulpClient(context.Background(), s.pulpClient(), &task, s.mockDaoRegistry.ToDaoRegistry())
 	assert.NoError(s.T(), err)
@@ -78,8 +79,8 @@ func (s *DeleteRepositorySnapshotsSuite) TestDeleteNoSnapshotsWithoutClient() {
 		RepositoryUUID: repoUuid,
 	}
 
-	s.mockDaoRegistry.Snapshot.On("FetchForRepoConfigUUID", repoConfig.UUID).Return([]models.Snapshot{}, nil).Once()
-	s.mockDaoRegistry.RepositoryConfig.On("Delete", repoConfig.OrgID, repoConfig.UUID).Return(nil).Once()
+	s.mockDaoRegistry.Snapshot.On("FetchForRepoConfigUUID", mock.Anything, repoConfig.UUID).Return([]models.Snapshot{}, nil).Once()
+	s.mockDaoRegistry.RepositoryConfig.On("Delete", mock.Anything, repoConfig.OrgID, repoConfig.UUID).Return(nil).Once()
 
 	payload := DeleteRepositorySnapshotsPayload{
 		RepoConfigUUID: repoConfig.UUID,
@@ -105,8 +106,8 @@ func (s *DeleteRepositorySnapshotsSuite) TestDeleteNoSnapshotsWithClient() {
 		RepositoryUUID: repoUuid,
 	}
 
-	s.mockDaoRegistry.Snapshot.On("FetchForRepoConfigUUID", repoConfig.UUID).Return([]models.Snapshot{}, nil).Once()
-	s.mockDaoRegistry.RepositoryConfig.On("Delete", repoConfig.OrgID, repoConfig.UUID).Return(nil).Once()
+	s.mockDaoRegistry.Snapshot.On("FetchForRepoConfigUUID", mock.Anything, repoConfig.UUID).Return([]models.Snapshot{}, nil).Once()
+	s.mockDaoRegistry.RepositoryConfig.On("Delete", mock.Anything, repoConfig.OrgID, repoConfig.UUID).Return(nil).Once()
 
 	s.MockPulpClient.On("GetRpmRemoteByName", repoConfig.UUID).Return(nil).Return(nil, nil).Once()
 	s.MockPulpClient.On("GetRpmRepositoryByName", repoConfig.UUID).Return(nil, nil).Once()
@@ -151,9 +152,9 @@ func (s *DeleteRepositorySnapshotsSuite) TestDeleteSnapshotFull() {
 	remoteResp := zest.RpmRpmRemoteResponse{PulpHref: pointy.String("remoteHref"), Url: repoConfig.URL}
 	repoResp := zest.RpmRpmRepositoryResponse{PulpHref: pointy.String("repoHref")}
 
-	s.mockDaoRegistry.Snapshot.On("FetchForRepoConfigUUID", repoConfig.UUID).Return([]models.Snapshot{expectedSnap}, nil).Once()
-	s.mockDaoRegistry.Snapshot.On("Delete", expectedSnap.UUID).Return(nil).Once()
-	s.mockDaoRegistry.RepositoryConfig.On("Delete", repoConfig.OrgID, repoConfig.UUID).Return(nil).Once()
+	s.mockDaoRegistry.Snapshot.On("FetchForRepoConfigUUID", mock.Anything, repoConfig.UUID).Return([]models.Snapshot{expectedSnap}, nil).Once()
+	s.mockDaoRegistry.Snapshot.On("Delete", mock.Anything, expectedSnap.UUID).Return(nil).Once()
+	s.mockDaoRegistry.RepositoryConfig.On("Delete", mock.Anything, repoConfig.OrgID, repoConfig.UUID).Return(nil).Once()
 
 	s.MockPulpClient.On("PollTask", "taskHref").Return(&taskResp, nil).Times(3)
 	s.MockPulpClient.On("DeleteRpmRepositoryVersion", expectedSnap.VersionHref).Return(nil).Once()
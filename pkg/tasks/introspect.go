@@ -2,9 +2,9 @@ package tasks
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 
+	"github.com/content-services/content-sources-backend/pkg/config"
 	"github.com/content-services/content-sources-backend/pkg/external_repos"
 	"github.com/content-services/content-sources-backend/pkg/models"
 	"github.com/content-services/content-sources-backend/pkg/tasks/payloads"
@@ -18,9 +18,9 @@ import (
 func IntrospectHandler(ctx context.Context, task *models.TaskInfo, _ *queue.Queue) error {
 	var p payloads.IntrospectPayload
 
-	logger := LogForTask(task.Id.String(), task.Typename, task.RequestID)
+	logger := LogForTask(task.Id.String(), task.Typename, task.RequestID, task.Username)
 
-	if err := json.Unmarshal(task.Payload, &p); err != nil {
+	if err := payloads.Decode(task.Typename, task.Payload, &p); err != nil {
 		return fmt.Errorf("payload incorrect type for IntrospectHandler")
 	}
 	// https://github.com/go-playground/validator
@@ -30,7 +30,7 @@ func IntrospectHandler(ctx context.Context, task *models.TaskInfo, _ *queue.Queu
 		return err
 	}
 
-	newRpms, nonFatalErrs, errs := external_repos.IntrospectUrl(logger.WithContext(context.Background()), p.Url, p.Force)
+	newRpms, nonFatalErrs, errs := external_repos.IntrospectUrl(logger.WithContext(ctx), p.Url, p.Force)
 	for i := 0; i < len(nonFatalErrs); i++ {
 		logger.Warn().Err(nonFatalErrs[i]).Msgf("Error %v introspecting repository %v", i, p.Url)
 	}
@@ -43,11 +43,13 @@ func IntrospectHandler(ctx context.Context, task *models.TaskInfo, _ *queue.Queu
 	return nil
 }
 
-func LogForTask(taskID, typename, requestID string) *zerolog.Logger {
+func LogForTask(taskID, typename, requestID, username string) *zerolog.Logger {
 	logger := log.Logger.With().
+		Str(config.ModuleLoggingKey, "tasks").
 		Str("task_type", typename).
 		Str("task_id", taskID).
 		Str("request_id", requestID).
+		Str("username", username).
 		Logger()
 	return &logger
 }
@@ -0,0 +1,59 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	"github.com/content-services/content-sources-backend/pkg/db"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/tasks/payloads"
+	"github.com/content-services/content-sources-backend/pkg/tasks/queue"
+	"github.com/rs/zerolog"
+)
+
+// MigrateOrgPayload selects the org whose repository configs and snapshots move to another org,
+// the account of the moved repository configs is left unchanged when AccountID is empty
+type MigrateOrgPayload struct {
+	SourceOrgID string
+	TargetOrgID string
+	AccountID   string
+}
+
+type MigrateOrg struct {
+	daoReg  *dao.DaoRegistry
+	payload *MigrateOrgPayload
+	ctx     context.Context
+	logger  *zerolog.Logger
+}
+
+func MigrateOrgHandler(ctx context.Context, task *models.TaskInfo, _ *queue.Queue) error {
+	opts := MigrateOrgPayload{}
+	if err := payloads.Decode(task.Typename, task.Payload, &opts); err != nil {
+		return fmt.Errorf("payload incorrect type for " + config.MigrateOrgTask)
+	}
+	mo := MigrateOrg{
+		daoReg:  dao.GetDaoRegistry(db.DB),
+		payload: &opts,
+		ctx:     ctx,
+		logger:  LogForTask(task.Id.String(), task.Typename, task.RequestID, task.Username),
+	}
+	return mo.Run()
+}
+
+// Run moves the repository configs of the source org to the target org, in a single transaction
+// so that a failed task can be retried
+func (mo *MigrateOrg) Run() error {
+	migration, err := mo.daoReg.RepositoryConfig.InternalOnly_MigrateOrg(mo.ctx, mo.payload.SourceOrgID, mo.payload.TargetOrgID, mo.payload.AccountID)
+	if err != nil {
+		return err
+	}
+	mo.logger.Info().
+		Int("moved", migration.Moved).
+		Int("merged", migration.Merged).
+		Int("renamed", migration.Renamed).
+		Bool("domain_moved", migration.DomainMoved).
+		Msgf("Migrated repositories of org %v to org %v", mo.payload.SourceOrgID, mo.payload.TargetOrgID)
+	return nil
+}
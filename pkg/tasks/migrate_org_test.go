@@ -0,0 +1,52 @@
+package tasks
+
+import (
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	ce "github.com/content-services/content-sources-backend/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MigrateOrgSuite struct {
+	suite.Suite
+	mockDaoRegistry *dao.MockDaoRegistry
+}
+
+func TestMigrateOrgSuite(t *testing.T) {
+	suite.Run(t, new(MigrateOrgSuite))
+}
+
+func (s *MigrateOrgSuite) SetupTest() {
+	s.mockDaoRegistry = dao.GetMockDaoRegistry(s.T())
+}
+
+func (s *MigrateOrgSuite) migrateOrg(payload MigrateOrgPayload) MigrateOrg {
+	return MigrateOrg{
+		daoReg:  s.mockDaoRegistry.ToDaoRegistry(),
+		payload: &payload,
+		logger:  LogForTask(uuid.NewString(), config.MigrateOrgTask, "", ""),
+	}
+}
+
+func (s *MigrateOrgSuite) TestRun() {
+	payload := MigrateOrgPayload{SourceOrgID: "sourceOrg", TargetOrgID: "targetOrg", AccountID: "account"}
+	s.mockDaoRegistry.RepositoryConfig.On("InternalOnly_MigrateOrg", mock.Anything, "sourceOrg", "targetOrg", "account").
+		Return(dao.OrgMigration{Moved: 2, Merged: 1, DomainMoved: true}, nil).Once()
+
+	mo := s.migrateOrg(payload)
+	assert.NoError(s.T(), mo.Run())
+}
+
+func (s *MigrateOrgSuite) TestRunFails() {
+	payload := MigrateOrgPayload{SourceOrgID: "sourceOrg", TargetOrgID: "sourceOrg"}
+	s.mockDaoRegistry.RepositoryConfig.On("InternalOnly_MigrateOrg", mock.Anything, "sourceOrg", "sourceOrg", "").
+		Return(dao.OrgMigration{}, &ce.DaoError{BadValidation: true, Message: "The source and target orgs must be given and differ"}).Once()
+
+	mo := s.migrateOrg(payload)
+	assert.Error(s.T(), mo.Run())
+}
@@ -0,0 +1,103 @@
+package payloads
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// VersionKey is the key of the payload version in the encoded payload of a task
+const VersionKey = "Version"
+
+// Upgrade changes a decoded payload of one version into the next version
+type Upgrade func(payload map[string]interface{}) error
+
+// Codec describes the versions of the payload of a task type.  Version is the version written by
+// this build of the service, and Upgrades[n] changes a payload of version n into version n + 1, so
+// that the tasks queued before a deploy changing the payload still run after it.
+type Codec struct {
+	Version  int
+	Upgrades map[int]Upgrade
+}
+
+// codecs of the task types, set with Register.  A task type without a codec has version 1
+// payloads without upgrades.
+var codecs = map[string]Codec{}
+
+// Register sets the codec of the payloads of a task type
+func Register(typename string, codec Codec) {
+	codecs[typename] = codec
+}
+
+func codecFor(typename string) Codec {
+	if codec, ok := codecs[typename]; ok {
+		return codec
+	}
+	return Codec{Version: 1}
+}
+
+// Encode marshals the payload of a task of the given type, adding the current payload version
+func Encode(typename string, payload interface{}) (json.RawMessage, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding %s payload: %w", typename, err)
+	}
+	fields := map[string]interface{}{}
+	if err = unmarshalFields(encoded, &fields); err != nil || fields == nil {
+		// Payloads which are not objects, such as null, have no room for a version
+		return encoded, nil
+	}
+	fields[VersionKey] = codecFor(typename).Version
+	return json.Marshal(fields)
+}
+
+// Decode unmarshals the payload of a task of the given type into payload.  Payloads of older
+// versions are upgraded to the current version first.  Payloads of newer versions, written by a
+// newer build during a deploy, are decoded as well as possible: fields unknown to this build are
+// ignored.
+func Decode(typename string, raw json.RawMessage, payload interface{}) error {
+	fields := map[string]interface{}{}
+	if err := unmarshalFields(raw, &fields); err != nil {
+		return fmt.Errorf("error decoding %s payload: %w", typename, err)
+	}
+	if fields == nil {
+		return nil
+	}
+
+	codec := codecFor(typename)
+	version := 1 // payloads queued before payloads were versioned
+	if v, ok := fields[VersionKey].(json.Number); ok {
+		n, err := v.Int64()
+		if err != nil {
+			return fmt.Errorf("invalid %s payload version %s", typename, v)
+		}
+		version = int(n)
+	}
+	for ; version < codec.Version; version++ {
+		upgrade, ok := codec.Upgrades[version]
+		if !ok {
+			return fmt.Errorf("no upgrade of %s payloads from version %d", typename, version)
+		}
+		if err := upgrade(fields); err != nil {
+			return fmt.Errorf("error upgrading %s payload from version %d: %w", typename, version, err)
+		}
+	}
+	delete(fields, VersionKey)
+
+	upgraded, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("error decoding %s payload: %w", typename, err)
+	}
+	if err = json.Unmarshal(upgraded, payload); err != nil {
+		return fmt.Errorf("error decoding %s payload: %w", typename, err)
+	}
+	return nil
+}
+
+// unmarshalFields decodes the fields of an encoded payload, keeping numbers as json.Number so
+// that they are encoded again without losing precision
+func unmarshalFields(raw []byte, fields *map[string]interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	return decoder.Decode(fields)
+}
@@ -0,0 +1,105 @@
+package payloads
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testType = "test-versions"
+
+type testPayloadV1 struct {
+	Name string
+}
+
+type testPayloadV2 struct {
+	Names []string
+	Count int64
+}
+
+func registerTestCodec(t *testing.T, codec Codec) {
+	Register(testType, codec)
+	t.Cleanup(func() {
+		delete(codecs, testType)
+	})
+}
+
+func TestEncodeAddsVersion(t *testing.T) {
+	registerTestCodec(t, Codec{Version: 2})
+
+	encoded, err := Encode(testType, testPayloadV2{Names: []string{"a"}})
+	require.NoError(t, err)
+
+	fields := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(encoded, &fields))
+	assert.Equal(t, float64(2), fields[VersionKey])
+
+	// Types without a codec are version 1
+	encoded, err = Encode(Introspect, IntrospectPayload{Url: "http://example.com/"})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(encoded, &fields))
+	assert.Equal(t, float64(1), fields[VersionKey])
+
+	encoded, err = Encode(Introspect, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "null", string(encoded))
+}
+
+func TestDecodeRoundTrip(t *testing.T) {
+	payload := SnapshotPayload{SnapshotIdent: stringPointer("ident")}
+	encoded, err := Encode(Snapshot, payload)
+	require.NoError(t, err)
+
+	decoded := SnapshotPayload{}
+	require.NoError(t, Decode(Snapshot, encoded, &decoded))
+	assert.Equal(t, payload, decoded)
+}
+
+func TestDecodeUpgradesOlderVersions(t *testing.T) {
+	registerTestCodec(t, Codec{
+		Version: 2,
+		Upgrades: map[int]Upgrade{
+			1: func(payload map[string]interface{}) error {
+				payload["Names"] = []interface{}{payload["Name"]}
+				delete(payload, "Name")
+				return nil
+			},
+		},
+	})
+
+	// A payload queued before payloads were versioned
+	unversioned, err := json.Marshal(testPayloadV1{Name: "a"})
+	require.NoError(t, err)
+	decoded := testPayloadV2{}
+	require.NoError(t, Decode(testType, unversioned, &decoded))
+	assert.Equal(t, []string{"a"}, decoded.Names)
+
+	// A payload of the current version is not upgraded
+	decoded = testPayloadV2{}
+	require.NoError(t, Decode(testType, []byte(`{"Version": 2, "Names": ["b"], "Count": 9007199254740993}`), &decoded))
+	assert.Equal(t, testPayloadV2{Names: []string{"b"}, Count: 9007199254740993}, decoded)
+}
+
+func TestDecodeMissingUpgrade(t *testing.T) {
+	registerTestCodec(t, Codec{Version: 3, Upgrades: map[int]Upgrade{
+		2: func(payload map[string]interface{}) error { return nil },
+	}})
+
+	decoded := testPayloadV2{}
+	err := Decode(testType, []byte(`{"Version": 1, "Name": "a"}`), &decoded)
+	assert.ErrorContains(t, err, "no upgrade of test-versions payloads from version 1")
+}
+
+func TestDecodeNewerVersion(t *testing.T) {
+	// A payload written by a newer build keeps the fields this build knows
+	decoded := IntrospectPayload{}
+	err := Decode(Introspect, []byte(`{"Version": 5, "Url": "http://example.com/", "Force": true, "Origin": "new"}`), &decoded)
+	require.NoError(t, err)
+	assert.Equal(t, IntrospectPayload{Url: "http://example.com/", Force: true}, decoded)
+}
+
+func stringPointer(s string) *string {
+	return &s
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/content-services/content-sources-backend/pkg/config"
 	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/tasks/payloads"
 	"github.com/google/uuid"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
@@ -18,28 +19,70 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-const taskInfoReturning = ` id, type, payload, queued_at, started_at, finished_at, status, error, org_id, repository_uuid, token, request_id ` // fields to return when returning taskInfo
+const taskInfoReturning = ` id, type, payload, queued_at, started_at, finished_at, status, error, org_id, repository_uuid, token, request_id, username, priority, retries, error_history ` // fields to return when returning taskInfo
+
+// errWorkerStopped is recorded in the error history of the tasks requeued because their worker stopped
+const errWorkerStopped = "the worker running the task stopped before finishing it"
 
 const (
 	sqlNotify   = `NOTIFY tasks`
 	sqlListen   = `LISTEN tasks`
 	sqlUnlisten = `UNLISTEN tasks`
 
-	sqlEnqueue = `INSERT INTO tasks(id, type, payload, queued_at, org_id, repository_uuid, status, request_id) VALUES ($1, $2, $3, statement_timestamp(), $4, $5, $6, $7)`
+	sqlEnqueue = `INSERT INTO tasks(id, type, payload, queued_at, org_id, repository_uuid, status, request_id, username, priority) VALUES ($1, $2, $3, statement_timestamp(), $4, $5, $6, $7, $8, $9)`
+	// sqlDequeue takes, among the ready tasks of the requested types with the highest priority,
+	// the task of the org running the fewest tasks of that type, the oldest first, so that an
+	// org queuing many tasks does not delay the tasks of the other orgs.  Tasks of a type with a concurrency
+	// limit ($3 types, $4 limits per org, $5 global limits, 0 meaning unlimited) are skipped
+	// while the limit is reached.  The running tasks are counted once by type and org, rather than
+	// for each ready task.
 	sqlDequeue = `
+		WITH limits AS (
+		  SELECT * FROM unnest($3::text[], $4::int[], $5::int[]) AS l(type, per_org, global)
+		), running_by_org AS (
+		  SELECT type, org_id, count(*) AS running
+		  FROM tasks
+		  WHERE status = 'running' AND type = ANY($2)
+		  GROUP BY type, org_id
+		), running_by_type AS (
+		  SELECT type, sum(running) AS running
+		  FROM running_by_org
+		  GROUP BY type
+		)
 		UPDATE tasks
 		SET token = $1, started_at = statement_timestamp(), status = 'running'
 		WHERE id = (
-		  SELECT id
+		  SELECT ready_tasks.id
 		  FROM ready_tasks
+		  LEFT JOIN running_by_org ON running_by_org.type = ready_tasks.type AND running_by_org.org_id = ready_tasks.org_id
+		  LEFT JOIN running_by_type ON running_by_type.type = ready_tasks.type
+		  LEFT JOIN limits ON limits.type = ready_tasks.type
 			  -- use ANY here, because "type in ()" doesn't work with bound parameters
 			  -- literal syntax for this is '{"a", "b"}': https://www.postgresql.org/docs/13/arrays.html
-		  WHERE type = ANY($2)
+		  WHERE ready_tasks.type = ANY($2)
+		  AND (limits.per_org IS NULL OR limits.per_org = 0 OR coalesce(running_by_org.running, 0) < limits.per_org)
+		  AND (limits.global IS NULL OR limits.global = 0 OR coalesce(running_by_type.running, 0) < limits.global)
+		  ORDER BY ready_tasks.priority DESC, coalesce(running_by_org.running, 0), ready_tasks.queued_at
 		  LIMIT 1
-		  FOR UPDATE SKIP LOCKED
+		  FOR UPDATE OF ready_tasks SKIP LOCKED
 		)
 		RETURNING ` + taskInfoReturning
 
+	// sqlLockDequeueType serializes the dequeues of the tasks of a type with a concurrency limit,
+	// so that two workers can not both take the last slot of the limit.  Dequeuing tasks of other
+	// types takes no lock.  The lock is released with the transaction, so it holds behind a pooler
+	// in transaction mode, unlike a session lock.
+	sqlLockDequeueType = `SELECT pg_advisory_xact_lock(hashtext('tasks_dequeue:' || $1))`
+
+	// sqlLimitReached checks again, holding the lock of the type, whether the running tasks of the
+	// type ($1) other than the dequeued task ($3) reach its limits per org ($2 org, $4 limit) or
+	// across orgs ($5 limit)
+	sqlLimitReached = `
+		SELECT ($4::int > 0 AND $4::int <= count(*) FILTER (WHERE org_id = $2))
+		    OR ($5::int > 0 AND $5::int <= count(*))
+		FROM tasks
+		WHERE type = $1 AND status = 'running' AND id != $3`
+
 	//nolint:unused,deadcode,varcheck
 	sqlDequeueByID = `
 		UPDATE tasks
@@ -55,8 +98,18 @@ const (
 
 	sqlRequeue = `
 		UPDATE tasks
-		SET started_at = NULL, token = NULL, status = 'pending'
+		SET started_at = NULL, token = NULL, status = 'pending', retries = retries + 1, error_history = array_append(error_history, $2::text)
 		WHERE id = $1 AND started_at IS NOT NULL AND finished_at IS NULL`
+	// sqlDeadLetter finishes a running task that ran out of retries
+	sqlDeadLetter = `
+		UPDATE tasks
+		SET finished_at = statement_timestamp(), status = 'dead', error = $2, error_history = array_append(error_history, $2::text)
+		WHERE id = $1 AND started_at IS NOT NULL AND finished_at IS NULL`
+	// sqlRequeueFailed queues again a failed or dead task, keeping the errors of its previous runs
+	sqlRequeueFailed = `
+		UPDATE tasks
+		SET queued_at = statement_timestamp(), started_at = NULL, finished_at = NULL, token = NULL, error = NULL, status = 'pending', retries = 0
+		WHERE id = $1 AND status IN ('failed', 'dead')`
 
 	sqlInsertDependency  = `INSERT INTO task_dependencies VALUES ($1, $2)`
 	sqlQueryDependencies = `
@@ -80,7 +133,8 @@ const (
                 SELECT id, status FROM tasks WHERE token = $1`
 	sqlFinishTask = `
 		UPDATE tasks
-		SET finished_at = statement_timestamp(), status = $1, error = $2
+		SET finished_at = statement_timestamp(), status = $1, error = $2,
+		    error_history = CASE WHEN $2::varchar IS NULL THEN error_history ELSE array_append(error_history, $2::text) END
 		WHERE id = $3 AND finished_at IS NULL
 		RETURNING finished_at`
 	sqlCancelTask = `
@@ -141,6 +195,26 @@ type PgQueue struct {
 	Pool         Pool
 	dequeuers    *dequeuers
 	stopListener func()
+	limits       map[string]ConcurrencyLimit // concurrency limits by typename
+}
+
+// ConcurrencyLimit bounds the number of running tasks of a type, 0 meaning unlimited
+type ConcurrencyLimit struct {
+	PerOrg int // running tasks of a single org
+	Global int // running tasks of every org
+}
+
+// SetConcurrencyLimit limits the number of tasks of typename dequeued and not finished yet,
+// across every worker using the queue.  It must be called before dequeuing tasks.
+func (p *PgQueue) SetConcurrencyLimit(typename string, limit ConcurrencyLimit) {
+	if p.limits == nil {
+		p.limits = make(map[string]ConcurrencyLimit)
+	}
+	if limit.PerOrg <= 0 && limit.Global <= 0 {
+		delete(p.limits, typename)
+		return
+	}
+	p.limits[typename] = limit
 }
 
 // thread-safe list of dequeuers
@@ -193,6 +267,11 @@ func newPgxQueue(url string) (*pgxpool.Pool, error) {
 	if config.Get().Tasking.PGXLogging {
 		pxConfig.ConnConfig.Logger = zerologadapter.NewLogger(log.Logger)
 	}
+	if config.Get().Database.TransactionPooling {
+		// A statement prepared on a server connection is not found by the next transaction
+		pxConfig.ConnConfig.PreferSimpleProtocol = true
+		pxConfig.ConnConfig.BuildStatementCache = nil
+	}
 	pool, err := pgxpool.ConnectConfig(context.Background(), pxConfig)
 	if err != nil {
 		return nil, fmt.Errorf("error establishing connection: %w", err)
@@ -215,6 +294,11 @@ func NewPgQueue(url string) (PgQueue, error) {
 		stopListener: cancel,
 	}
 
+	if config.Get().Database.TransactionPooling {
+		go q.poll(listenContext, transactionPoolingPollInterval)
+		return q, nil
+	}
+
 	listenerReady := make(chan struct{})
 	go q.listen(listenContext, listenerReady)
 
@@ -224,6 +308,29 @@ func NewPgQueue(url string) (PgQueue, error) {
 	return q, nil
 }
 
+// errConcurrencyLimitRace is returned when another worker took the last slot of the concurrency
+// limit of the dequeued task first
+var errConcurrencyLimitRace = errors.New("concurrency limit reached by another dequeue")
+
+// transactionPoolingPollInterval is the interval at which the dequeuers look for tasks behind a
+// pooler in transaction mode, which can not keep the session listening for notifications
+const transactionPoolingPollInterval = time.Second
+
+// poll wakes the dequeuers up at every interval, in place of the notifications of the listener
+func (q *PgQueue) poll(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Logger.Info().Msg("Shutting down the poller")
+			return
+		case <-ticker.C:
+			q.dequeuers.notifyAll()
+		}
+	}
+}
+
 func (q *PgQueue) listen(ctx context.Context, ready chan<- struct{}) {
 	ready <- struct{}{}
 
@@ -283,6 +390,10 @@ func (q *PgQueue) waitAndNotify(ctx context.Context) error {
 
 func (p *PgQueue) Enqueue(task *Task) (uuid.UUID, error) {
 	taskID := uuid.New()
+	payload, err := payloads.Encode(task.Typename, task.Payload)
+	if err != nil {
+		return uuid.Nil, err
+	}
 	conn, err := p.Pool.Acquire(context.Background())
 	if err != nil {
 		return uuid.Nil, err
@@ -301,7 +412,7 @@ func (p *PgQueue) Enqueue(task *Task) (uuid.UUID, error) {
 	}()
 
 	_, err = tx.Exec(context.Background(), sqlEnqueue,
-		taskID.String(), task.Typename, task.Payload, task.OrgId, task.RepositoryUUID, config.TaskStatusPending, task.RequestID)
+		taskID.String(), task.Typename, payload, task.OrgId, task.RepositoryUUID, config.TaskStatusPending, task.RequestID, task.Username, task.Priority)
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("error enqueuing task: %w", err)
 	}
@@ -340,6 +451,10 @@ func (p *PgQueue) Dequeue(ctx context.Context, taskTypes []string) (*models.Task
 		if err == nil {
 			break
 		}
+		if errors.Is(err, errConcurrencyLimitRace) {
+			// the next attempt sees the task that took the slot, and skips its type
+			continue
+		}
 		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 			if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
 				return nil, ErrContextCanceled
@@ -358,8 +473,11 @@ func (p *PgQueue) Dequeue(ctx context.Context, taskTypes []string) (*models.Task
 }
 
 func (p *PgQueue) UpdatePayload(task *models.TaskInfo, payload interface{}) (*models.TaskInfo, error) {
-	var err error
-	_, err = p.Pool.Exec(context.Background(), sqlUpdatePayload, payload, task.Id.String())
+	encoded, err := payloads.Encode(task.Typename, payload)
+	if err != nil {
+		return task, err
+	}
+	_, err = p.Pool.Exec(context.Background(), sqlUpdatePayload, encoded, task.Id.String())
 	return task, err
 }
 
@@ -380,15 +498,41 @@ func (p *PgQueue) dequeueMaybe(ctx context.Context, token uuid.UUID, taskTypes [
 		}
 	}()
 
-	err = tx.QueryRow(ctx, sqlDequeue, token, taskTypes).Scan(
+	limitTypes := make([]string, 0, len(p.limits))
+	perOrgLimits := make([]int32, 0, len(p.limits))
+	globalLimits := make([]int32, 0, len(p.limits))
+	for typename, limit := range p.limits {
+		limitTypes = append(limitTypes, typename)
+		perOrgLimits = append(perOrgLimits, int32(limit.PerOrg))
+		globalLimits = append(globalLimits, int32(limit.Global))
+	}
+	err = tx.QueryRow(ctx, sqlDequeue, token, taskTypes, limitTypes, perOrgLimits, globalLimits).Scan(
 		&info.Id, &info.Typename, &info.Payload, &info.Queued, &info.Started, &info.Finished, &info.Status,
-		&info.Error, &info.OrgId, &info.RepositoryUUID, &info.Token, &info.RequestID,
+		&info.Error, &info.OrgId, &info.RepositoryUUID, &info.Token, &info.RequestID, &info.Username, &info.Priority, &info.Retries, &info.ErrorHistory,
 	)
 
-	if err != nil && errors.Is(err, pgx.ErrNoRows) {
+	if err != nil {
 		return nil, err
 	}
 
+	// The limits were checked without a lock, while the tasks other workers are dequeuing are
+	// not committed yet.  They are checked again holding the lock of the type, which the other
+	// workers dequeuing a task of the type hold until their task is committed.
+	if limit, ok := p.limits[info.Typename]; ok {
+		_, err = tx.Exec(ctx, sqlLockDequeueType, info.Typename)
+		if err != nil {
+			return nil, fmt.Errorf("error locking the queue: %w", err)
+		}
+		var reached bool
+		err = tx.QueryRow(ctx, sqlLimitReached, info.Typename, info.OrgId, info.Id, limit.PerOrg, limit.Global).Scan(&reached)
+		if err != nil {
+			return nil, fmt.Errorf("error checking the concurrency limit: %w", err)
+		}
+		if reached {
+			return nil, errConcurrencyLimitRace
+		}
+	}
+
 	// insert heartbeat
 	_, err = tx.Exec(ctx, sqlInsertHeartbeat, token, info.Id)
 	if err != nil {
@@ -470,7 +614,7 @@ func (p *PgQueue) Status(taskId uuid.UUID) (*models.TaskInfo, error) {
 	defer conn.Release()
 	err = conn.QueryRow(context.Background(), sqlQueryTaskStatus, taskId).Scan(
 		&info.Id, &info.Typename, &info.Payload, &info.Queued, &info.Started, &info.Finished, &info.Status,
-		&info.Error, &info.OrgId, &info.RepositoryUUID, &info.Token, &info.RequestID,
+		&info.Error, &info.OrgId, &info.RepositoryUUID, &info.Token, &info.RequestID, &info.Username, &info.Priority, &info.Retries, &info.ErrorHistory,
 	)
 	if err != nil {
 		return nil, err
@@ -607,7 +751,13 @@ func (p *PgQueue) Requeue(taskId uuid.UUID) error {
 		return ErrNotExist
 	}
 
-	tag, err = tx.Exec(context.Background(), sqlRequeue, taskId)
+	// A task whose worker keeps stopping is dead-lettered rather than retried forever
+	deadLetter := info.Retries >= config.Get().Tasking.MaxRetries
+	if deadLetter {
+		tag, err = tx.Exec(context.Background(), sqlDeadLetter, taskId, errWorkerStopped)
+	} else {
+		tag, err = tx.Exec(context.Background(), sqlRequeue, taskId, errWorkerStopped)
+	}
 	if err != nil {
 		return fmt.Errorf("error requeueing task %s: %v", taskId, err)
 	}
@@ -626,6 +776,55 @@ func (p *PgQueue) Requeue(taskId uuid.UUID) error {
 		return fmt.Errorf("unable to commit database transaction: %v", err)
 	}
 
+	if deadLetter {
+		return ErrMaxRetries
+	}
+	return nil
+}
+
+func (p *PgQueue) RequeueFailed(taskId uuid.UUID) error {
+	var err error
+
+	info, err := p.Status(taskId)
+	if err == pgx.ErrNoRows {
+		return ErrNotExist
+	}
+	if err != nil {
+		return err
+	}
+	if info.Status != config.TaskStatusFailed && info.Status != config.TaskStatusDead {
+		return ErrNotFailed
+	}
+
+	tx, err := p.Pool.Begin(context.Background())
+	if err != nil {
+		return fmt.Errorf("error starting database transaction: %v", err)
+	}
+	defer func() {
+		err = tx.Rollback(context.Background())
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Logger.Error().Err(err).Msg(fmt.Sprintf("Error rolling back requeue failed task transaction. Task id %v", taskId.String()))
+		}
+	}()
+
+	tag, err := tx.Exec(context.Background(), sqlRequeueFailed, taskId)
+	if err != nil {
+		return fmt.Errorf("error requeueing task %s: %v", taskId, err)
+	}
+	if tag.RowsAffected() != 1 {
+		return ErrNotFailed
+	}
+
+	_, err = tx.Exec(context.Background(), sqlNotify)
+	if err != nil {
+		return fmt.Errorf("error notifying tasks channel: %v", err)
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return fmt.Errorf("unable to commit database transaction: %v", err)
+	}
+
 	return nil
 }
 
@@ -3,6 +3,7 @@ package queue
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -66,6 +67,8 @@ var testTask = Task{
 	Dependencies:   nil,
 	OrgId:          "12345",
 	RepositoryUUID: uuid.NewString(),
+	RequestID:      "request-id",
+	Username:       "user",
 }
 
 func (s *QueueSuite) TestEnqueue() {
@@ -81,6 +84,8 @@ func (s *QueueSuite) TestEnqueue() {
 	assert.Nil(s.T(), info.Finished)
 	assert.Equal(s.T(), testTask.OrgId, info.OrgId)
 	assert.Equal(s.T(), testTask.RepositoryUUID, info.RepositoryUUID.String())
+	assert.Equal(s.T(), testTask.RequestID, info.RequestID)
+	assert.Equal(s.T(), testTask.Username, info.Username)
 }
 
 func (s *QueueSuite) TestUpdatePayload() {
@@ -187,6 +192,69 @@ func (s *QueueSuite) TestRequeue() {
 	assert.ErrorIs(s.T(), err, ErrNotRunning)
 }
 
+func (s *QueueSuite) TestRequeueDeadLetter() {
+	maxRetries := config.Get().Tasking.MaxRetries
+	config.Get().Tasking.MaxRetries = 1
+	defer func() {
+		config.Get().Tasking.MaxRetries = maxRetries
+	}()
+
+	id, err := s.queue.Enqueue(&testTask)
+	require.NoError(s.T(), err)
+
+	// The first requeue is a retry
+	_, err = s.queue.Dequeue(context.Background(), []string{testTaskType})
+	require.NoError(s.T(), err)
+	err = s.queue.Requeue(id)
+	require.NoError(s.T(), err)
+
+	info, err := s.queue.Status(id)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), config.TaskStatusPending, info.Status)
+	assert.Equal(s.T(), 1, info.Retries)
+	assert.Len(s.T(), info.ErrorHistory, 1)
+
+	// The task then runs out of retries
+	_, err = s.queue.Dequeue(context.Background(), []string{testTaskType})
+	require.NoError(s.T(), err)
+	err = s.queue.Requeue(id)
+	require.ErrorIs(s.T(), err, ErrMaxRetries)
+
+	info, err = s.queue.Status(id)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), config.TaskStatusDead, info.Status)
+	assert.NotNil(s.T(), info.Finished)
+	assert.Len(s.T(), info.ErrorHistory, 2)
+}
+
+func (s *QueueSuite) TestRequeueFailed() {
+	id, err := s.queue.Enqueue(&testTask)
+	require.NoError(s.T(), err)
+
+	// Test cannot requeue pending task
+	err = s.queue.RequeueFailed(id)
+	require.ErrorIs(s.T(), err, ErrNotFailed)
+
+	_, err = s.queue.Dequeue(context.Background(), []string{testTaskType})
+	require.NoError(s.T(), err)
+	err = s.queue.Finish(id, fmt.Errorf("something went wrong"))
+	require.NoError(s.T(), err)
+
+	err = s.queue.RequeueFailed(id)
+	require.NoError(s.T(), err)
+
+	info, err := s.queue.Status(id)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), config.TaskStatusPending, info.Status)
+	assert.Nil(s.T(), info.Started)
+	assert.Nil(s.T(), info.Finished)
+	assert.Nil(s.T(), info.Error)
+	assert.Equal(s.T(), []string{"something went wrong"}, []string(info.ErrorHistory))
+
+	err = s.queue.RequeueFailed(uuid.New())
+	assert.ErrorIs(s.T(), err, ErrNotExist)
+}
+
 func (s *QueueSuite) TestCancel() {
 	id, err := s.queue.Enqueue(&testTask)
 	require.NoError(s.T(), err)
@@ -253,3 +321,232 @@ func (s *QueueSuite) TestIdFromToken() {
 	_, _, err = s.queue.IdFromToken(uuid.New())
 	assert.ErrorIs(s.T(), err, ErrNotExist)
 }
+
+func (s *QueueSuite) enqueueForOrg(orgID string) uuid.UUID {
+	task := testTask
+	task.OrgId = orgID
+	id, err := s.queue.Enqueue(&task)
+	require.NoError(s.T(), err)
+	return id
+}
+
+func (s *QueueSuite) TestDequeueFairAcrossOrgs() {
+	first := s.enqueueForOrg("busy")
+	s.enqueueForOrg("busy")
+	s.enqueueForOrg("busy")
+	other := s.enqueueForOrg("other")
+
+	info, err := s.queue.Dequeue(context.Background(), []string{testTaskType})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), first, info.Id)
+
+	// The org running no task goes first, even though its task was queued last
+	info, err = s.queue.Dequeue(context.Background(), []string{testTaskType})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), other, info.Id)
+}
+
+func (s *QueueSuite) TestDequeueConcurrencyLimit() {
+	s.queue.SetConcurrencyLimit(testTaskType, ConcurrencyLimit{PerOrg: 1, Global: 2})
+	defer s.queue.SetConcurrencyLimit(testTaskType, ConcurrencyLimit{})
+
+	first := s.enqueueForOrg("busy")
+	second := s.enqueueForOrg("busy")
+	other := s.enqueueForOrg("other")
+	s.enqueueForOrg("third")
+
+	info, err := s.queue.Dequeue(context.Background(), []string{testTaskType})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), first, info.Id)
+
+	info, err = s.queue.Dequeue(context.Background(), []string{testTaskType})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), other, info.Id)
+
+	// Two tasks are running, the global limit is reached
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = s.queue.Dequeue(ctx, []string{testTaskType})
+	assert.ErrorIs(s.T(), err, ErrContextCanceled)
+
+	// Tasks of types without a limit are not held back
+	untyped := testTask
+	untyped.Typename = "unlimited"
+	id, err := s.queue.Enqueue(&untyped)
+	require.NoError(s.T(), err)
+	info, err = s.queue.Dequeue(context.Background(), []string{testTaskType, untyped.Typename})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), id, info.Id)
+
+	// A finished task frees a slot for its org
+	require.NoError(s.T(), s.queue.Finish(first, nil))
+	info, err = s.queue.Dequeue(context.Background(), []string{testTaskType})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), second, info.Id)
+}
+
+func (s *QueueSuite) TestDequeuePriority() {
+	background := s.enqueueForOrg("other")
+	task := testTask
+	task.Priority = PriorityUser
+	user, err := s.queue.Enqueue(&task)
+	require.NoError(s.T(), err)
+
+	// A user task goes first, even though its org is running a task already
+	info, err := s.queue.Dequeue(context.Background(), []string{testTaskType})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), user, info.Id)
+	assert.Equal(s.T(), PriorityUser, info.Priority)
+
+	info, err = s.queue.Dequeue(context.Background(), []string{testTaskType})
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), background, info.Id)
+	assert.Equal(s.T(), PriorityBackground, info.Priority)
+
+	status, err := s.queue.Status(user)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), PriorityUser, status.Priority)
+}
+
+func TestPollWakesDequeuers(t *testing.T) {
+	q := PgQueue{dequeuers: newDequeuers()}
+	c := make(chan struct{}, 1)
+	q.dequeuers.pushBack(c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.poll(ctx, 10*time.Millisecond)
+
+	select {
+	case <-c:
+	case <-time.After(time.Second):
+		assert.Fail(t, "dequeuer was not woken up by the poller")
+	}
+}
+
+// benchmarkDequeue dequeues b.N tasks of orgs running tasks already, with the given limits
+func benchmarkDequeue(b *testing.B, limit ConcurrencyLimit) {
+	pgxQueue, err := newPgxQueue(db.GetUrl())
+	require.NoError(b, err)
+	pgxConn, err := pgxQueue.Acquire(context.Background())
+	require.NoError(b, err)
+	defer pgxConn.Release()
+	tx, err := pgxConn.Begin(context.Background())
+	require.NoError(b, err)
+	defer func() { _ = tx.Rollback(context.Background()) }()
+
+	q := PgQueue{Pool: &FakePgxPoolWrapper{tx: &tx, conn: pgxConn}, dequeuers: newDequeuers()}
+	require.NoError(b, q.RemoveAllTasks())
+	q.SetConcurrencyLimit(testTaskType, limit)
+	for i := 0; i < b.N; i++ {
+		task := testTask
+		task.OrgId = fmt.Sprintf("org-%d", i%10)
+		_, err := q.Enqueue(&task)
+		require.NoError(b, err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := q.Dequeue(context.Background(), []string{testTaskType})
+		require.NoError(b, err)
+	}
+}
+
+func BenchmarkDequeue(b *testing.B) {
+	benchmarkDequeue(b, ConcurrencyLimit{})
+}
+
+func BenchmarkDequeueConcurrencyLimit(b *testing.B) {
+	benchmarkDequeue(b, ConcurrencyLimit{Global: 1 << 30})
+}
+
+// BenchmarkDequeueManyOrgs dequeues tasks among thousands of tasks queued by hundreds of orgs, each
+// running tasks already, as when the snapshots of every repository are queued at night
+func BenchmarkDequeueManyOrgs(b *testing.B) {
+	const orgs = 500
+	pgxQueue, err := newPgxQueue(db.GetUrl())
+	require.NoError(b, err)
+	pgxConn, err := pgxQueue.Acquire(context.Background())
+	require.NoError(b, err)
+	defer pgxConn.Release()
+	tx, err := pgxConn.Begin(context.Background())
+	require.NoError(b, err)
+	defer func() { _ = tx.Rollback(context.Background()) }()
+
+	q := PgQueue{Pool: &FakePgxPoolWrapper{tx: &tx, conn: pgxConn}, dequeuers: newDequeuers()}
+	require.NoError(b, q.RemoveAllTasks())
+	q.SetConcurrencyLimit(testTaskType, ConcurrencyLimit{PerOrg: 5, Global: 1 << 30})
+	enqueue := func(i int) {
+		task := testTask
+		task.OrgId = fmt.Sprintf("org-%d", i%orgs)
+		_, err := q.Enqueue(&task)
+		require.NoError(b, err)
+	}
+	for i := 0; i < 10*orgs; i++ {
+		enqueue(i)
+	}
+	for i := 0; i < 2*orgs; i++ {
+		_, err := q.Dequeue(context.Background(), []string{testTaskType})
+		require.NoError(b, err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		info, err := q.Dequeue(context.Background(), []string{testTaskType})
+		require.NoError(b, err)
+
+		// The task is finished and another one queued, so that as many tasks are queued and running
+		b.StopTimer()
+		require.NoError(b, q.Finish(info.Id, nil))
+		enqueue(i)
+		b.StartTimer()
+	}
+}
+
+var errScan = errors.New("scan failed")
+
+// scanErrorRow is a row failing to scan
+type scanErrorRow struct{}
+
+func (scanErrorRow) Scan(_ ...interface{}) error {
+	return errScan
+}
+
+// scanErrorTx is a transaction whose single row queries fail to scan
+type scanErrorTx struct {
+	pgx.Tx
+}
+
+func (t *scanErrorTx) QueryRow(_ context.Context, _ string, _ ...interface{}) pgx.Row {
+	return scanErrorRow{}
+}
+
+// scanErrorPool begins transactions whose single row queries fail to scan
+type scanErrorPool struct {
+	*FakePgxPoolWrapper
+}
+
+func (p *scanErrorPool) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := p.FakePgxPoolWrapper.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &scanErrorTx{Tx: tx}, nil
+}
+
+func (s *QueueSuite) TestDequeueScanError() {
+	s.queue.SetConcurrencyLimit(testTaskType, ConcurrencyLimit{PerOrg: 1})
+	defer s.queue.SetConcurrencyLimit(testTaskType, ConcurrencyLimit{})
+	id := s.enqueueForOrg("busy")
+
+	q := s.queue
+	q.Pool = &scanErrorPool{FakePgxPoolWrapper: s.queue.Pool.(*FakePgxPoolWrapper)}
+	info, err := q.dequeueMaybe(context.Background(), uuid.New(), []string{testTaskType})
+	assert.ErrorIs(s.T(), err, errScan)
+	assert.Nil(s.T(), info)
+
+	// The task was not dequeued
+	status, err := s.queue.Status(id)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), config.TaskStatusPending, status.Status)
+}
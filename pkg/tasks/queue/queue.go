@@ -18,6 +18,21 @@ type Task struct {
 	OrgId          string
 	RepositoryUUID string
 	RequestID      string
+	Username       string // user who triggered the task, empty for background jobs
+	Priority       int    // tasks with a higher priority are dequeued first
+}
+
+const (
+	PriorityBackground = 0  // bulk jobs, such as the nightly introspection
+	PriorityUser       = 10 // tasks triggered by a user, who is waiting for them
+)
+
+// PriorityClass returns the name of the class of a priority, to label metrics
+func PriorityClass(priority int) string {
+	if priority >= PriorityUser {
+		return "user"
+	}
+	return "background"
 }
 
 //go:generate mockery  --name Queue --filename queue_mock.go --inpackage
@@ -33,8 +48,10 @@ type Queue interface {
 	Finish(taskId uuid.UUID, taskError error) error
 	// Cancel sets status of given task to canceled
 	Cancel(taskId uuid.UUID) error
-	// Requeue requeues the given task
+	// Requeue requeues the given running task, or marks it dead, returning ErrMaxRetries, once it ran out of retries
 	Requeue(taskId uuid.UUID) error
+	// RequeueFailed queues again a failed or dead task
+	RequeueFailed(taskId uuid.UUID) error
 	// Heartbeats returns the tokens of all tasks older than given duration
 	Heartbeats(olderThan time.Duration) []uuid.UUID
 	// IdFromToken returns a task's ID given its token
@@ -51,4 +68,6 @@ var (
 	ErrCanceled        = fmt.Errorf("task was canceled")
 	ErrContextCanceled = fmt.Errorf("dequeue context timed out or was canceled")
 	ErrRowsNotAffected = fmt.Errorf("no rows were affected")
+	ErrMaxRetries      = fmt.Errorf("task ran out of retries")
+	ErrNotFailed       = fmt.Errorf("task has not failed")
 )
@@ -175,6 +175,20 @@ func (_m *MockQueue) Requeue(taskId uuid.UUID) error {
 	return r0
 }
 
+// RequeueFailed provides a mock function with given fields: taskId
+func (_m *MockQueue) RequeueFailed(taskId uuid.UUID) error {
+	ret := _m.Called(taskId)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID) error); ok {
+		r0 = rf(taskId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Status provides a mock function with given fields: taskId
 func (_m *MockQueue) Status(taskId uuid.UUID) (*models.TaskInfo, error) {
 	ret := _m.Called(taskId)
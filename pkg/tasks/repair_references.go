@@ -0,0 +1,150 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	"github.com/content-services/content-sources-backend/pkg/db"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/pulp_client"
+	"github.com/content-services/content-sources-backend/pkg/tasks/payloads"
+	"github.com/content-services/content-sources-backend/pkg/tasks/queue"
+	"github.com/rs/zerolog"
+)
+
+// RepairReferencesPayload has no field, the references of the org of the task are repaired
+type RepairReferencesPayload struct{}
+
+// RepairReferences re-resolves the pulp references stored for an org once pulp was restored from a
+// backup or rebuilt: the domain of the org is created again when missing, and each snapshot is pointed
+// at the repository version, publication and distribution pulp now has for it.
+type RepairReferences struct {
+	daoReg           *dao.DaoRegistry
+	globalPulpClient pulp_client.PulpGlobalClient
+	pulpClient       pulp_client.PulpClient
+	sharedPulpClient pulp_client.PulpClient // nil when the org has no shared snapshot
+	task             *models.TaskInfo
+	ctx              context.Context
+	logger           *zerolog.Logger
+}
+
+func RepairReferencesHandler(ctx context.Context, task *models.TaskInfo, _ *queue.Queue) error {
+	opts := RepairReferencesPayload{}
+	if err := payloads.Decode(task.Typename, task.Payload, &opts); err != nil {
+		return fmt.Errorf("payload incorrect type for " + config.RepairReferencesTask)
+	}
+	if !config.PulpConfigured() {
+		return nil
+	}
+	daoReg := dao.GetDaoRegistry(db.DB)
+	logger := LogForTask(task.Id.String(), task.Typename, task.RequestID, task.Username)
+	ctxWithLogger := logger.WithContext(ctx)
+
+	domainName, err := daoReg.Domain.FetchOrCreateDomain(ctx, task.OrgId)
+	if err != nil {
+		return err
+	}
+	rr := RepairReferences{
+		daoReg:           daoReg,
+		globalPulpClient: pulp_client.GetGlobalPulpClient(ctxWithLogger),
+		pulpClient:       pulp_client.GetPulpClientWithDomain(ctxWithLogger, domainName),
+		task:             task,
+		ctx:              ctx,
+		logger:           logger,
+	}
+	if config.Get().Clients.Pulp.SharedRedHatSnapshots {
+		sharedDomainName, err := daoReg.Domain.FetchOrCreateDomain(ctx, config.SharedSnapshotsOrg)
+		if err != nil {
+			return err
+		}
+		rr.sharedPulpClient = pulp_client.GetPulpClientWithDomain(ctxWithLogger, sharedDomainName)
+	}
+	return rr.Run(domainName)
+}
+
+// Run repairs the domain and the snapshots of the org, failing when some snapshot could not be repaired
+func (rr *RepairReferences) Run(domainName string) error {
+	// A rebuilt pulp has lost the domains, which snapshotting would otherwise only create for new orgs
+	if _, err := rr.globalPulpClient.LookupOrCreateDomain(domainName); err != nil {
+		return err
+	}
+
+	snaps, err := rr.daoReg.Snapshot.FetchForOrg(rr.ctx, rr.task.OrgId)
+	if err != nil {
+		return err
+	}
+	unrepaired := 0
+	for _, snap := range snaps {
+		client := rr.pulpClient
+		if snap.Shared {
+			if rr.sharedPulpClient == nil {
+				rr.logger.Warn().Msgf("Skipping shared snapshot %v, shared snapshots are disabled", snap.UUID)
+				continue
+			}
+			client = rr.sharedPulpClient
+		}
+		snap, err = rr.repair(client, snap)
+		if err != nil {
+			return err
+		}
+		if snap.VerificationError != "" {
+			unrepaired++
+		}
+		if err = rr.daoReg.Snapshot.UpdateVerification(rr.ctx, snap); err != nil {
+			return err
+		}
+	}
+	if unrepaired > 0 {
+		return fmt.Errorf("%d of %d snapshots could not be repaired", unrepaired, len(snaps))
+	}
+	return nil
+}
+
+// repair points the snapshot at the repository version pulp has for it, then repairs its publication
+// and distribution as a verification does
+func (rr *RepairReferences) repair(client pulp_client.PulpClient, snap models.Snapshot) (models.Snapshot, error) {
+	versionHref, err := rr.resolveVersion(client, snap)
+	if err != nil {
+		return snap, err
+	}
+	if versionHref != "" && versionHref != snap.VersionHref {
+		rr.logger.Info().Msgf("Snapshot %v now references repository version %v instead of %v", snap.UUID, versionHref, snap.VersionHref)
+		snap.VersionHref = versionHref
+	}
+	vs := VerifySnapshots{
+		daoReg:     rr.daoReg,
+		pulpClient: &client,
+		task:       rr.task,
+		ctx:        rr.ctx,
+		logger:     rr.logger,
+	}
+	return vs.verify(snap)
+}
+
+// resolveVersion returns the href of the repository version of a snapshot.  A recreated repository has a
+// new href, its version with the same number is then looked up in the repository named after the first
+// element of the distribution path of the snapshot.  An empty href is returned when neither exists.
+func (rr *RepairReferences) resolveVersion(client pulp_client.PulpClient, snap models.Snapshot) (string, error) {
+	version, err := client.GetRpmRepositoryVersion(snap.VersionHref)
+	if err == nil && version != nil {
+		return snap.VersionHref, nil
+	}
+
+	repo, err := client.GetRpmRepositoryByName(path.Dir(snap.DistributionPath))
+	if err != nil {
+		return "", err
+	}
+	if repo == nil || repo.PulpHref == nil {
+		return "", nil
+	}
+	versionHref := strings.TrimSuffix(*repo.PulpHref, "/") + "/versions/" + path.Base(snap.VersionHref) + "/"
+	version, err = client.GetRpmRepositoryVersion(versionHref)
+	if err != nil || version == nil {
+		return "", nil
+	}
+	return versionHref, nil
+}
@@ -0,0 +1,105 @@
+package tasks
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/pulp_client"
+	zest "github.com/content-services/zest/release/v2023"
+	"github.com/google/uuid"
+	"github.com/openlyinc/pointy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type RepairReferencesSuite struct {
+	suite.Suite
+	mockDaoRegistry      *dao.MockDaoRegistry
+	MockPulpClient       pulp_client.MockPulpClient
+	MockPulpGlobalClient pulp_client.MockPulpGlobalClient
+}
+
+func TestRepairReferencesSuite(t *testing.T) {
+	suite.Run(t, new(RepairReferencesSuite))
+}
+
+func (s *RepairReferencesSuite) SetupTest() {
+	s.mockDaoRegistry = dao.GetMockDaoRegistry(s.T())
+	s.MockPulpClient = *pulp_client.NewMockPulpClient(s.T())
+	s.MockPulpGlobalClient = *pulp_client.NewMockPulpGlobalClient(s.T())
+}
+
+func (s *RepairReferencesSuite) repairReferences(orgID string) RepairReferences {
+	task := models.TaskInfo{
+		Id:    uuid.New(),
+		OrgId: orgID,
+	}
+	return RepairReferences{
+		daoReg:           s.mockDaoRegistry.ToDaoRegistry(),
+		globalPulpClient: &s.MockPulpGlobalClient,
+		pulpClient:       &s.MockPulpClient,
+		task:             &task,
+		logger:           LogForTask(task.Id.String(), config.RepairReferencesTask, "", ""),
+	}
+}
+
+func (s *RepairReferencesSuite) snapshot(repoConfigUUID string) models.Snapshot {
+	return models.Snapshot{
+		Base:                        models.Base{UUID: uuid.NewString()},
+		VersionHref:                 "/pulp/myDomain/api/v3/repositories/rpm/rpm/old-repo/versions/2/",
+		PublicationHref:             "pub-href",
+		DistributionHref:            "dist-href",
+		DistributionPath:            fmt.Sprintf("%s/%s", repoConfigUUID, uuid.NewString()),
+		RepositoryConfigurationUUID: repoConfigUUID,
+		ContentCounts:               models.ContentCounts{"rpm.package": 3},
+	}
+}
+
+func (s *RepairReferencesSuite) TestRepairRecreatedRepository() {
+	repoConfigUUID := uuid.NewString()
+	snap := s.snapshot(repoConfigUUID)
+	newVersionHref := "/pulp/myDomain/api/v3/repositories/rpm/rpm/new-repo/versions/2/"
+
+	s.MockPulpGlobalClient.On("LookupOrCreateDomain", "myDomain").Return(pointy.String("domainHref"), nil).Once()
+	s.mockDaoRegistry.Snapshot.On("FetchForOrg", mock.Anything, "OrgId").Return([]models.Snapshot{snap}, nil).Once()
+
+	// The repository was recreated, its versions have new hrefs
+	s.MockPulpClient.On("GetRpmRepositoryVersion", snap.VersionHref).Return(nil, fmt.Errorf("404 Not Found")).Once()
+	s.MockPulpClient.On("GetRpmRepositoryByName", repoConfigUUID).
+		Return(&zest.RpmRpmRepositoryResponse{PulpHref: pointy.String("/pulp/myDomain/api/v3/repositories/rpm/rpm/new-repo/")}, nil).Once()
+	s.MockPulpClient.On("GetRpmRepositoryVersion", newVersionHref).Return(versionResponse(3), nil).Twice()
+	s.MockPulpClient.On("FindRpmPublicationByVersion", newVersionHref).
+		Return(&zest.RpmRpmPublicationResponse{PulpHref: pointy.String(snap.PublicationHref)}, nil).Once()
+	dist := zest.RpmRpmDistributionResponse{PulpHref: pointy.String(snap.DistributionHref)}
+	dist.SetPublication(snap.PublicationHref)
+	s.MockPulpClient.On("FindDistributionByPath", snap.DistributionPath).Return(&dist, nil).Once()
+
+	s.mockDaoRegistry.Snapshot.On("UpdateVerification", mock.Anything, mock.MatchedBy(func(repaired models.Snapshot) bool {
+		return repaired.UUID == snap.UUID && repaired.VerificationError == "" && repaired.VersionHref == newVersionHref
+	})).Return(nil).Once()
+
+	rr := s.repairReferences("OrgId")
+	assert.NoError(s.T(), rr.Run("myDomain"))
+}
+
+func (s *RepairReferencesSuite) TestRepairMissingRepository() {
+	repoConfigUUID := uuid.NewString()
+	snap := s.snapshot(repoConfigUUID)
+
+	s.MockPulpGlobalClient.On("LookupOrCreateDomain", "myDomain").Return(pointy.String("domainHref"), nil).Once()
+	s.mockDaoRegistry.Snapshot.On("FetchForOrg", mock.Anything, "OrgId").Return([]models.Snapshot{snap}, nil).Once()
+	s.MockPulpClient.On("GetRpmRepositoryVersion", snap.VersionHref).Return(nil, fmt.Errorf("404 Not Found")).Twice()
+	s.MockPulpClient.On("GetRpmRepositoryByName", repoConfigUUID).Return(nil, nil).Once()
+	s.mockDaoRegistry.Snapshot.On("UpdateVerification", mock.Anything, mock.MatchedBy(func(repaired models.Snapshot) bool {
+		return repaired.UUID == snap.UUID && repaired.VerificationError != "" && repaired.VersionHref == snap.VersionHref
+	})).Return(nil).Once()
+
+	rr := s.repairReferences("OrgId")
+	err := rr.Run("myDomain")
+	assert.Error(s.T(), err)
+	assert.Equal(s.T(), "1 of 1 snapshots could not be repaired", err.Error())
+}
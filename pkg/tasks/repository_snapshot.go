@@ -2,13 +2,15 @@ package tasks
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"path/filepath"
 
 	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/candlepin_client"
+	"github.com/content-services/content-sources-backend/pkg/config"
 	"github.com/content-services/content-sources-backend/pkg/dao"
 	"github.com/content-services/content-sources-backend/pkg/db"
+	"github.com/content-services/content-sources-backend/pkg/external_repos"
 	"github.com/content-services/content-sources-backend/pkg/models"
 	"github.com/content-services/content-sources-backend/pkg/pulp_client"
 	"github.com/content-services/content-sources-backend/pkg/tasks/payloads"
@@ -20,45 +22,63 @@ import (
 
 func SnapshotHandler(ctx context.Context, task *models.TaskInfo, queue *queue.Queue) error {
 	opts := payloads.SnapshotPayload{}
-	if err := json.Unmarshal(task.Payload, &opts); err != nil {
+	if err := payloads.Decode(task.Typename, task.Payload, &opts); err != nil {
 		return fmt.Errorf("payload incorrect type for Snapshot")
 	}
-	logger := LogForTask(task.Id.String(), task.Typename, task.RequestID)
-	ctxWithLogger := logger.WithContext(context.Background())
+	logger := LogForTask(task.Id.String(), task.Typename, task.RequestID, task.Username)
+	ctxWithLogger := logger.WithContext(ctx)
 
 	daoReg := dao.GetDaoRegistry(db.DB)
-	domainName, err := daoReg.Domain.FetchOrCreateDomain(task.OrgId)
+	domainName, err := daoReg.Domain.FetchOrCreateDomain(ctx, task.OrgId)
 	if err != nil {
 		return err
 	}
 	pulpClient := pulp_client.GetPulpClientWithDomain(ctxWithLogger, domainName)
+	candlepinClient := candlepin_client.GetCandlepinClient(ctxWithLogger)
+
+	var sharedDomainName string
+	var sharedPulpClient pulp_client.PulpClient
+	if config.Get().Clients.Pulp.SharedRedHatSnapshots {
+		sharedDomainName, err = daoReg.Domain.FetchOrCreateDomain(ctx, config.SharedSnapshotsOrg)
+		if err != nil {
+			return err
+		}
+		sharedPulpClient = pulp_client.GetPulpClientWithDomain(ctxWithLogger, sharedDomainName)
+	}
 
 	sr := SnapshotRepository{
-		orgId:          task.OrgId,
-		domainName:     domainName,
-		repositoryUUID: task.RepositoryUUID,
-		daoReg:         daoReg,
-		pulpClient:     pulpClient,
-		task:           task,
-		payload:        &opts,
-		queue:          queue,
-		ctx:            ctx,
-		logger:         logger,
+		orgId:            task.OrgId,
+		domainName:       domainName,
+		repositoryUUID:   task.RepositoryUUID,
+		daoReg:           daoReg,
+		pulpClient:       pulpClient,
+		candlepinClient:  candlepinClient,
+		sharedDomainName: sharedDomainName,
+		sharedPulpClient: sharedPulpClient,
+		task:             task,
+		payload:          &opts,
+		queue:            queue,
+		ctx:              ctx,
+		logger:           logger,
 	}
 	return sr.Run()
 }
 
 type SnapshotRepository struct {
-	orgId          string
-	domainName     string
-	repositoryUUID uuid.UUID
-	daoReg         *dao.DaoRegistry
-	pulpClient     pulp_client.PulpClient
-	payload        *payloads.SnapshotPayload
-	task           *models.TaskInfo
-	queue          *queue.Queue
-	ctx            context.Context
-	logger         *zerolog.Logger
+	orgId           string
+	domainName      string
+	repositoryUUID  uuid.UUID
+	daoReg          *dao.DaoRegistry
+	pulpClient      pulp_client.PulpClient
+	candlepinClient candlepin_client.CandlepinClient
+	// Red Hat repositories are snapshotted once for every org in the shared domain, when set
+	sharedDomainName string
+	sharedPulpClient pulp_client.PulpClient
+	payload          *payloads.SnapshotPayload
+	task             *models.TaskInfo
+	queue            *queue.Queue
+	ctx              context.Context
+	logger           *zerolog.Logger
 }
 
 // SnapshotRepository creates a snapshot of a given repository config
@@ -66,11 +86,27 @@ func (sr *SnapshotRepository) Run() error {
 	var remoteHref string
 	var repoHref string
 	var publicationHref string
-	_, err := sr.pulpClient.LookupOrCreateDomain(sr.domainName)
+	repoConfig, err := sr.lookupRepoObjects()
 	if err != nil {
 		return err
 	}
-	repoConfig, err := sr.lookupRepoObjects()
+	if !repoConfig.Snapshot {
+		// Snapshots were turned off after the task was queued, the existing ones are kept
+		sr.logger.Info().Msgf("Skipping snapshot of repository %v, snapshots are turned off", repoConfig.UUID)
+		return nil
+	}
+	shared, err := sr.sharesSnapshots(repoConfig)
+	if err != nil {
+		return err
+	}
+	if shared {
+		return sr.runShared(repoConfig)
+	}
+	_, err = sr.pulpClient.LookupOrCreateDomain(sr.domainName)
+	if err != nil {
+		return err
+	}
+	err = sr.daoReg.RepositoryConfig.UpdateLastSnapshotTask(sr.ctx, sr.task.Id.String(), sr.orgId, sr.repositoryUUID.String())
 	if err != nil {
 		return err
 	}
@@ -119,6 +155,12 @@ func (sr *SnapshotRepository) Run() error {
 		sr.logger.Error().Msgf("Found nil content Summary for version %v", *versionHref)
 	}
 
+	// The size is only reported to users, a snapshot is still usable without it
+	size, err := sr.pulpClient.GetRpmRepositoryVersionSize(*versionHref)
+	if err != nil {
+		sr.logger.Error().Err(err).Msgf("Could not compute the size of version %v", *versionHref)
+	}
+
 	snap := models.Snapshot{
 		VersionHref:                 *versionHref,
 		PublicationHref:             publicationHref,
@@ -127,15 +169,163 @@ func (sr *SnapshotRepository) Run() error {
 		DistributionHref:            distHref,
 		RepositoryConfigurationUUID: repoConfigUuid,
 		ContentCounts:               ContentSummaryToContentCounts(version.ContentSummary),
+		SizeBytes:                   size,
 	}
 	sr.logger.Debug().Msgf("Snapshot created at: %v", distPath)
-	err = sr.daoReg.Snapshot.Create(&snap)
+	err = sr.daoReg.Snapshot.Create(sr.ctx, &snap)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// sharesSnapshots returns whether the repository is snapshotted in the shared domain, which is the
+// case of the public Red Hat repositories once shared snapshots are enabled.  The content of these
+// repositories is the same for every org, so it is synced once instead of once per org.
+func (sr *SnapshotRepository) sharesSnapshots(repoConfig api.RepositoryResponse) (bool, error) {
+	if sr.sharedPulpClient == nil || !external_repos.IsRedHat(repoConfig.URL) || models.HasVariables(repoConfig.URL) {
+		return false, nil
+	}
+	repo, err := sr.daoReg.Repository.FetchForUrl(sr.ctx, repoConfig.URL)
+	if err != nil {
+		return false, err
+	}
+	return repo.Public, nil
+}
+
+// runShared syncs the repository in the shared domain, where its pulp objects are named after the
+// repository and shared by every configuration of it, then records a snapshot of the repository
+// configuration referencing the distribution of the latest version.  Each version is distributed
+// once, at a path derived from its href, whichever org snapshotted it first.
+func (sr *SnapshotRepository) runShared(repoConfig api.RepositoryResponse) error {
+	sr.pulpClient = sr.sharedPulpClient
+	sr.domainName = sr.sharedDomainName
+	name := sr.repositoryUUID.String()
+
+	_, err := sr.pulpClient.LookupOrCreateDomain(sr.domainName)
+	if err != nil {
+		return err
+	}
+	err = sr.daoReg.RepositoryConfig.UpdateLastSnapshotTask(sr.ctx, sr.task.Id.String(), sr.orgId, sr.repositoryUUID.String())
+	if err != nil {
+		return err
+	}
+
+	remoteHref, err := sr.findOrCreateSharedRemote(name, repoConfig.URL)
+	if err != nil {
+		return err
+	}
+	repoHref, err := sr.findOrCreatePulpRepo(name, remoteHref)
+	if err != nil {
+		return err
+	}
+	versionHref, err := sr.syncRepository(repoHref)
+	if err != nil {
+		return err
+	}
+	if versionHref == nil {
+		// Nothing updated since another org synced it, which this org may not have a snapshot of yet
+		repoResp, err := sr.pulpClient.GetRpmRepositoryByName(name)
+		if err != nil {
+			return err
+		}
+		if repoResp == nil || repoResp.LatestVersionHref == nil {
+			return fmt.Errorf("could not find the latest version of the shared repository %v", name)
+		}
+		versionHref = repoResp.LatestVersionHref
+	}
+
+	snaps, err := sr.daoReg.Snapshot.FetchForRepoConfigUUID(sr.ctx, repoConfig.UUID)
+	if err != nil {
+		return err
+	}
+	for _, snap := range snaps {
+		if snap.VersionHref == *versionHref {
+			// The org already has a snapshot of this version
+			return nil
+		}
+	}
+
+	publicationHref, err := sr.findOrCreatePublication(versionHref)
+	if err != nil {
+		return err
+	}
+	ident := uuid.NewSHA1(sr.repositoryUUID, []byte(*versionHref)).String()
+	sr.payload.SnapshotIdent = &ident
+	distPath := fmt.Sprintf("%v/%v", name, ident)
+	var distHref string
+	dist, err := sr.pulpClient.FindDistributionByPath(distPath)
+	if err != nil {
+		return err
+	}
+	if dist != nil && dist.PulpHref != nil {
+		distHref = *dist.PulpHref
+	} else {
+		distHref, distPath, err = sr.createDistribution(publicationHref, name, ident)
+		if err != nil {
+			return err
+		}
+	}
+
+	version, err := sr.pulpClient.GetRpmRepositoryVersion(*versionHref)
+	if err != nil {
+		return err
+	}
+	size, err := sr.pulpClient.GetRpmRepositoryVersionSize(*versionHref)
+	if err != nil {
+		sr.logger.Error().Err(err).Msgf("Could not compute the size of version %v", *versionHref)
+	}
+
+	snap := models.Snapshot{
+		VersionHref:                 *versionHref,
+		PublicationHref:             publicationHref,
+		DistributionPath:            distPath,
+		RepositoryPath:              filepath.Join(sr.domainName, distPath),
+		DistributionHref:            distHref,
+		RepositoryConfigurationUUID: repoConfig.UUID,
+		ContentCounts:               ContentSummaryToContentCounts(version.ContentSummary),
+		SizeBytes:                   size,
+		Shared:                      true,
+	}
+	sr.logger.Debug().Msgf("Shared snapshot referenced at: %v", distPath)
+	return sr.daoReg.Snapshot.Create(sr.ctx, &snap)
+}
+
+// findOrCreateSharedRemote returns the remote of a repository of the shared domain, downloading
+// content with the cdn certificate of the service rather than the proxy and credentials of an org
+func (sr *SnapshotRepository) findOrCreateSharedRemote(name string, url string) (string, error) {
+	cert, err := config.CdnCertPEM()
+	if err != nil {
+		return "", err
+	}
+	if cert == nil {
+		return "", fmt.Errorf("no cdn certificate to snapshot %v with", url)
+	}
+	caCert, err := external_repos.LoadCA()
+	if err != nil {
+		return "", err
+	}
+	options := pulp_client.RemoteOptions{ClientCert: string(cert), ClientKey: string(cert), CACert: string(caCert)}
+
+	remoteResp, err := sr.pulpClient.GetRpmRemoteByName(name)
+	if err != nil {
+		return "", err
+	}
+	if remoteResp == nil {
+		remoteResp, err = sr.pulpClient.CreateRpmRemote(name, url, options)
+		if err != nil {
+			return "", err
+		}
+	} else if remoteResp.PulpHref != nil {
+		// The certificate is renewed from time to time, and pulp does not return it
+		_, err = sr.pulpClient.UpdateRpmRemote(*remoteResp.PulpHref, url, options)
+		if err != nil {
+			return "", err
+		}
+	}
+	return *remoteResp.PulpHref, nil
+}
+
 func (sr *SnapshotRepository) createDistribution(publicationHref string, repoConfigUUID string, snapshotId string) (string, string, error) {
 	distPath := fmt.Sprintf("%v/%v", repoConfigUUID, snapshotId)
 
@@ -250,17 +440,27 @@ func (sr *SnapshotRepository) findOrCreatePulpRepo(repoConfigUUID string, remote
 }
 
 func (sr *SnapshotRepository) findOrCreateRemote(repoConfig api.RepositoryResponse) (string, error) {
+	url, err := remoteURL(repoConfig)
+	if err != nil {
+		return "", err
+	}
+	options, err := sr.remoteOptions(repoConfig)
+	if err != nil {
+		return "", err
+	}
 	remoteResp, err := sr.pulpClient.GetRpmRemoteByName(repoConfig.UUID)
 	if err != nil {
 		return "", err
 	}
 	if remoteResp == nil {
-		remoteResp, err = sr.pulpClient.CreateRpmRemote(repoConfig.UUID, repoConfig.URL)
+		remoteResp, err = sr.pulpClient.CreateRpmRemote(repoConfig.UUID, url, options)
 		if err != nil {
 			return "", err
 		}
-	} else if remoteResp.Url != repoConfig.URL && remoteResp.PulpHref != nil {
-		_, err = sr.pulpClient.UpdateRpmRemoteUrl(*remoteResp.PulpHref, repoConfig.URL)
+	} else if remoteResp.PulpHref != nil && (remoteResp.Url != url || remoteResp.GetProxyUrl() != options.ProxyURL ||
+		options.ProxyUsername != "" || options.Username != "" || options.ClientCert != "") {
+		// Pulp does not return the credentials, so they are updated each time in case they changed
+		_, err = sr.pulpClient.UpdateRpmRemote(*remoteResp.PulpHref, url, options)
 		if err != nil {
 			return "", err
 		}
@@ -268,8 +468,52 @@ func (sr *SnapshotRepository) findOrCreateRemote(repoConfig api.RepositoryRespon
 	return *remoteResp.PulpHref, nil
 }
 
+// remoteURL returns the url the repository is snapshotted from, its url expanded with its
+// distribution version and architecture when it contains variables
+func remoteURL(repoConfig api.RepositoryResponse) (string, error) {
+	urls := models.ExpandURL(repoConfig.URL, repoConfig.DistributionVersions, repoConfig.DistributionArch)
+	if len(urls) != 1 {
+		return "", fmt.Errorf("snapshotting %s requires a single distribution version and an architecture to expand its variables with", repoConfig.URL)
+	}
+	return urls[0], nil
+}
+
+// remoteOptions returns the proxy, credentials and client certificate the remote of the repository uses
+func (sr *SnapshotRepository) remoteOptions(repoConfig api.RepositoryResponse) (pulp_client.RemoteOptions, error) {
+	proxy, err := sr.daoReg.RepositoryConfig.FetchProxy(sr.ctx, sr.orgId, repoConfig.UUID)
+	if err != nil {
+		return pulp_client.RemoteOptions{}, err
+	}
+	credentials, err := sr.daoReg.RepositoryConfig.FetchCredentials(sr.ctx, sr.orgId, repoConfig.UUID)
+	if err != nil {
+		return pulp_client.RemoteOptions{}, err
+	}
+	options := pulp_client.RemoteOptions{
+		ProxyURL:      proxy.URL,
+		ProxyUsername: proxy.Username,
+		ProxyPassword: proxy.Password,
+		Username:      credentials.Username,
+		Password:      credentials.Password,
+	}
+	if external_repos.IsRedHat(repoConfig.URL) && config.CandlepinConfigured() && sr.candlepinClient != nil {
+		// Content of the Red Hat CDN is synced with the entitlement certificate of the org
+		entitlement, err := sr.candlepinClient.FetchEntitlementCertificate(sr.orgId)
+		if err != nil {
+			return pulp_client.RemoteOptions{}, fmt.Errorf("could not fetch the entitlement certificate of org %v: %w", sr.orgId, err)
+		}
+		caCert, err := external_repos.LoadCA()
+		if err != nil {
+			return pulp_client.RemoteOptions{}, err
+		}
+		options.ClientCert = entitlement.Cert
+		options.ClientKey = entitlement.Key
+		options.CACert = string(caCert)
+	}
+	return options, nil
+}
+
 func (sr *SnapshotRepository) lookupRepoObjects() (api.RepositoryResponse, error) {
-	repoConfig, err := sr.daoReg.RepositoryConfig.FetchByRepoUuid(sr.orgId, sr.repositoryUUID.String())
+	repoConfig, err := sr.daoReg.RepositoryConfig.FetchByRepoUuid(sr.ctx, sr.orgId, sr.repositoryUUID.String())
 	if err != nil {
 		return api.RepositoryResponse{}, err
 	}
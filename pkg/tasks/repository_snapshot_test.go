@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/candlepin_client"
+	"github.com/content-services/content-sources-backend/pkg/config"
 	"github.com/content-services/content-sources-backend/pkg/dao"
 	"github.com/content-services/content-sources-backend/pkg/models"
 	"github.com/content-services/content-sources-backend/pkg/pulp_client"
@@ -43,7 +45,7 @@ func (s *SnapshotSuite) TestSnapshotFull() {
 	repoUuid := uuid.New()
 
 	repo := dao.Repository{UUID: repoUuid.String(), URL: "http://random.example.com/thing"}
-	repoConfig := api.RepositoryResponse{OrgID: "OrgId", UUID: uuid.NewString(), URL: repo.URL}
+	repoConfig := api.RepositoryResponse{OrgID: "OrgId", UUID: uuid.NewString(), URL: repo.URL, Snapshot: true}
 	task := models.TaskInfo{
 		Id:             uuid.UUID{},
 		OrgId:          repoConfig.OrgID,
@@ -51,9 +53,10 @@ func (s *SnapshotSuite) TestSnapshotFull() {
 	}
 
 	domainName := repoConfig.OrgID
-	s.mockDaoRegistry.RepositoryConfig.On("FetchByRepoUuid", repoConfig.OrgID, repo.UUID).Return(repoConfig, nil)
-	s.mockDaoRegistry.RepositoryConfig.On("Fetch", repoConfig.OrgID, repoConfig.UUID).Return(repoConfig, nil)
-	s.mockDaoRegistry.Repository.On("FetchForUrl", repoConfig.URL).Return(repo, nil)
+	s.mockDaoRegistry.RepositoryConfig.On("FetchByRepoUuid", mock.Anything, repoConfig.OrgID, repo.UUID).Return(repoConfig, nil)
+	s.mockDaoRegistry.RepositoryConfig.On("Fetch", mock.Anything, repoConfig.OrgID, repoConfig.UUID).Return(repoConfig, nil)
+	s.mockDaoRegistry.Repository.On("FetchForUrl", mock.Anything, repoConfig.URL).Return(repo, nil)
+	s.mockDaoRegistry.RepositoryConfig.On("UpdateLastSnapshotTask", mock.Anything, task.Id.String(), repoConfig.OrgID, repo.UUID).Return(nil)
 
 	remoteHref := s.mockRemoteCreate(repoConfig, false)
 	repoResp := s.mockRepoCreate(repoConfig, remoteHref, false)
@@ -91,6 +94,7 @@ func (s *SnapshotSuite) TestSnapshotFull() {
 		ContentSummary: &counts,
 	}
 	s.MockPulpClient.On("GetRpmRepositoryVersion", *versionHref).Return(&rpmVersion, nil)
+	s.MockPulpClient.On("GetRpmRepositoryVersionSize", *versionHref).Return(int64(2048), nil)
 
 	distPath := fmt.Sprintf("%s/%s", repoConfig.UUID, snapshotId)
 	expectedSnap := models.Snapshot{
@@ -101,6 +105,7 @@ func (s *SnapshotSuite) TestSnapshotFull() {
 		RepositoryConfigurationUUID: repoConfig.UUID,
 		ContentCounts:               ContentSummaryToContentCounts(&counts),
 		RepositoryPath:              fmt.Sprintf("%v/%v", domainName, distPath),
+		SizeBytes:                   2048,
 	}
 
 	payload := payloads.SnapshotPayload{
@@ -120,7 +125,7 @@ func (s *SnapshotSuite) TestSnapshotFull() {
 		logger:         &log.Logger,
 	}
 
-	s.mockDaoRegistry.Snapshot.On("Create", &expectedSnap).Return(nil).Once()
+	s.mockDaoRegistry.Snapshot.On("Create", mock.Anything, &expectedSnap).Return(nil).Once()
 
 	snapErr := snap.Run()
 	assert.NoError(s.T(), snapErr)
@@ -129,11 +134,12 @@ func (s *SnapshotSuite) TestSnapshotFull() {
 func (s *SnapshotSuite) TestSnapshotResync() {
 	repoUuid := uuid.New()
 	repo := dao.Repository{UUID: repoUuid.String(), URL: "http://random.example.com/thing"}
-	repoConfig := api.RepositoryResponse{OrgID: "OrgId", UUID: uuid.NewString(), URL: repo.URL}
+	repoConfig := api.RepositoryResponse{OrgID: "OrgId", UUID: uuid.NewString(), URL: repo.URL, Snapshot: true}
 
-	s.mockDaoRegistry.RepositoryConfig.On("FetchByRepoUuid", repoConfig.OrgID, repo.UUID).Return(repoConfig, nil)
-	s.mockDaoRegistry.RepositoryConfig.On("Fetch", repoConfig.OrgID, repoConfig.UUID).Return(repoConfig, nil)
-	s.mockDaoRegistry.Repository.On("FetchForUrl", repoConfig.URL).Return(repo, nil)
+	s.mockDaoRegistry.RepositoryConfig.On("FetchByRepoUuid", mock.Anything, repoConfig.OrgID, repo.UUID).Return(repoConfig, nil)
+	s.mockDaoRegistry.RepositoryConfig.On("Fetch", mock.Anything, repoConfig.OrgID, repoConfig.UUID).Return(repoConfig, nil)
+	s.mockDaoRegistry.Repository.On("FetchForUrl", mock.Anything, repoConfig.URL).Return(repo, nil)
+	s.mockDaoRegistry.RepositoryConfig.On("UpdateLastSnapshotTask", mock.Anything, uuid.UUID{}.String(), repoConfig.OrgID, repo.UUID).Return(nil)
 
 	remoteHref := s.mockRemoteCreate(repoConfig, true)
 	repoResp := s.mockRepoCreate(repoConfig, remoteHref, true)
@@ -177,16 +183,17 @@ func (s *SnapshotSuite) TestSnapshotRestartAfterSync() {
 	repoUuid := uuid.New()
 
 	repo := dao.Repository{UUID: repoUuid.String(), URL: "http://random.example.com/thing"}
-	repoConfig := api.RepositoryResponse{OrgID: "OrgId", UUID: uuid.NewString(), URL: repo.URL}
+	repoConfig := api.RepositoryResponse{OrgID: "OrgId", UUID: uuid.NewString(), URL: repo.URL, Snapshot: true}
 	task := models.TaskInfo{
 		Id:             uuid.UUID{},
 		OrgId:          repoConfig.OrgID,
 		RepositoryUUID: repoUuid,
 	}
 
-	s.mockDaoRegistry.RepositoryConfig.On("FetchByRepoUuid", repoConfig.OrgID, repo.UUID).Return(repoConfig, nil)
-	s.mockDaoRegistry.RepositoryConfig.On("Fetch", repoConfig.OrgID, repoConfig.UUID).Return(repoConfig, nil)
-	s.mockDaoRegistry.Repository.On("FetchForUrl", repoConfig.URL).Return(repo, nil)
+	s.mockDaoRegistry.RepositoryConfig.On("FetchByRepoUuid", mock.Anything, repoConfig.OrgID, repo.UUID).Return(repoConfig, nil)
+	s.mockDaoRegistry.RepositoryConfig.On("Fetch", mock.Anything, repoConfig.OrgID, repoConfig.UUID).Return(repoConfig, nil)
+	s.mockDaoRegistry.Repository.On("FetchForUrl", mock.Anything, repoConfig.URL).Return(repo, nil)
+	s.mockDaoRegistry.RepositoryConfig.On("UpdateLastSnapshotTask", mock.Anything, task.Id.String(), repoConfig.OrgID, repo.UUID).Return(nil)
 	s.MockPulpClient.On("LookupOrCreateDomain", repoConfig.OrgID).Return(pointy.Pointer("found"), nil)
 
 	remoteHref := s.mockRemoteCreate(repoConfig, false)
@@ -226,6 +233,7 @@ func (s *SnapshotSuite) TestSnapshotRestartAfterSync() {
 		ContentSummary: &counts,
 	}
 	s.MockPulpClient.On("GetRpmRepositoryVersion", versionHref).Return(&rpmVersion, nil)
+	s.MockPulpClient.On("GetRpmRepositoryVersionSize", versionHref).Return(int64(0), fmt.Errorf("artifacts unavailable"))
 
 	expectedSnap := models.Snapshot{
 		VersionHref:                 versionHref,
@@ -254,7 +262,7 @@ func (s *SnapshotSuite) TestSnapshotRestartAfterSync() {
 		logger:         &log.Logger,
 	}
 
-	s.mockDaoRegistry.Snapshot.On("Create", &expectedSnap).Return(nil).Once()
+	s.mockDaoRegistry.Snapshot.On("Create", mock.Anything, &expectedSnap).Return(nil).Once()
 
 	snapErr := snap.Run()
 	assert.NoError(s.T(), snapErr)
@@ -330,11 +338,163 @@ func (s *SnapshotSuite) mockRepoCreate(repoConfig api.RepositoryResponse, remote
 
 func (s *SnapshotSuite) mockRemoteCreate(repoConfig api.RepositoryResponse, existingRemote bool) string {
 	remoteResp := zest.RpmRpmRemoteResponse{PulpHref: pointy.String("remoteHref"), Url: repoConfig.URL}
+	s.mockDaoRegistry.RepositoryConfig.On("FetchProxy", mock.Anything, repoConfig.OrgID, repoConfig.UUID).Return(dao.RepositoryProxy{}, nil).Once()
+	s.mockDaoRegistry.RepositoryConfig.On("FetchCredentials", mock.Anything, repoConfig.OrgID, repoConfig.UUID).Return(dao.RepositoryCredentials{}, nil).Once()
 	if existingRemote {
 		s.MockPulpClient.On("GetRpmRemoteByName", repoConfig.UUID).Return(&remoteResp, nil).Once()
 	} else {
 		s.MockPulpClient.On("GetRpmRemoteByName", repoConfig.UUID).Return(nil, nil).Once()
-		s.MockPulpClient.On("CreateRpmRemote", repoConfig.UUID, repoConfig.URL).Return(&remoteResp, nil).Once()
+		s.MockPulpClient.On("CreateRpmRemote", repoConfig.UUID, repoConfig.URL, pulp_client.RemoteOptions{}).Return(&remoteResp, nil).Once()
 	}
 	return *remoteResp.PulpHref
 }
+
+func (s *SnapshotSuite) TestRemoteURL() {
+	url, err := remoteURL(api.RepositoryResponse{URL: "http://example.com/os/", DistributionVersions: []string{config.El8, config.El9}})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "http://example.com/os/", url)
+
+	url, err = remoteURL(api.RepositoryResponse{URL: "http://example.com/$releasever/os/", DistributionVersions: []string{config.El9}})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "http://example.com/9/os/", url)
+
+	_, err = remoteURL(api.RepositoryResponse{URL: "http://example.com/$releasever/os/", DistributionVersions: []string{config.El8, config.El9}})
+	assert.Error(s.T(), err)
+
+	url, err = remoteURL(api.RepositoryResponse{URL: "http://example.com/$releasever/$basearch/", DistributionVersions: []string{config.El9}, DistributionArch: config.X8664})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "http://example.com/9/x86_64/", url)
+
+	_, err = remoteURL(api.RepositoryResponse{URL: "http://example.com/$basearch/", DistributionArch: config.ANY_ARCH})
+	assert.Error(s.T(), err)
+}
+
+func (s *SnapshotSuite) TestSnapshotTurnedOff() {
+	repoUuid := uuid.New()
+	repoConfig := api.RepositoryResponse{OrgID: "OrgId", UUID: uuid.NewString(), URL: "http://random.example.com/thing", Snapshot: false}
+	task := models.TaskInfo{
+		Id:             uuid.New(),
+		OrgId:          repoConfig.OrgID,
+		RepositoryUUID: repoUuid,
+	}
+	s.mockDaoRegistry.RepositoryConfig.On("FetchByRepoUuid", mock.Anything, repoConfig.OrgID, repoUuid.String()).Return(repoConfig, nil).Once()
+
+	snap := SnapshotRepository{
+		orgId:          repoConfig.OrgID,
+		domainName:     repoConfig.OrgID,
+		repositoryUUID: repoUuid,
+		daoReg:         s.mockDaoRegistry.ToDaoRegistry(),
+		pulpClient:     &s.MockPulpClient,
+		payload:        &payloads.SnapshotPayload{},
+		task:           &task,
+		queue:          &s.Queue,
+		logger:         &log.Logger,
+	}
+	// Nothing is synced nor created in pulp
+	assert.NoError(s.T(), snap.Run())
+}
+
+func (s *SnapshotSuite) TestRemoteOptionsEntitlementCertificate() {
+	t := s.T()
+	previous := config.Get().Clients.Candlepin
+	defer func() { config.Get().Clients.Candlepin = previous }()
+	config.Get().Clients.Candlepin.Server = "https://candlepin.example.com"
+
+	repoConfig := api.RepositoryResponse{OrgID: "OrgId", UUID: uuid.NewString(), URL: "https://cdn.redhat.com/content/dist/rhel9/9/x86_64/baseos/os/"}
+	s.mockDaoRegistry.RepositoryConfig.On("FetchProxy", mock.Anything, repoConfig.OrgID, repoConfig.UUID).Return(dao.RepositoryProxy{}, nil).Once()
+	s.mockDaoRegistry.RepositoryConfig.On("FetchCredentials", mock.Anything, repoConfig.OrgID, repoConfig.UUID).Return(dao.RepositoryCredentials{}, nil).Once()
+	mockCandlepinClient := candlepin_client.NewMockCandlepinClient(t)
+	mockCandlepinClient.On("FetchEntitlementCertificate", repoConfig.OrgID).Return(&candlepin_client.EntitlementCertificate{Cert: "cert", Key: "key"}, nil).Once()
+
+	sr := SnapshotRepository{
+		orgId:           repoConfig.OrgID,
+		daoReg:          s.mockDaoRegistry.ToDaoRegistry(),
+		candlepinClient: mockCandlepinClient,
+	}
+	options, err := sr.remoteOptions(repoConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, "cert", options.ClientCert)
+	assert.Equal(t, "key", options.ClientKey)
+	assert.NotEmpty(t, options.CACert)
+}
+
+// TestSnapshotShared this test simulates a Red Hat repository another org already snapshotted in the shared domain
+func (s *SnapshotSuite) TestSnapshotShared() {
+	previousCertPath := config.Get().Certs.CertPath
+	defer func() { config.Get().Certs.CertPath = previousCertPath }()
+	config.Get().Certs.CertPath = "../config/test_files/cert.crt"
+	s.T().Setenv(config.RhCertEnv, "")
+
+	repoUuid := uuid.New()
+	repo := dao.Repository{UUID: repoUuid.String(), URL: "https://cdn.redhat.com/content/dist/rhel9/9/x86_64/baseos/os/", Public: true}
+	repoConfig := api.RepositoryResponse{OrgID: "OrgId", UUID: uuid.NewString(), URL: repo.URL, Snapshot: true}
+	task := models.TaskInfo{
+		Id:             uuid.UUID{},
+		OrgId:          repoConfig.OrgID,
+		RepositoryUUID: repoUuid,
+	}
+	versionHref := "/pulp/api/v3/repositories/rpm/rpm/" + uuid.NewString() + "/versions/3/"
+
+	s.mockDaoRegistry.RepositoryConfig.On("FetchByRepoUuid", mock.Anything, repoConfig.OrgID, repo.UUID).Return(repoConfig, nil)
+	s.mockDaoRegistry.Repository.On("FetchForUrl", mock.Anything, repoConfig.URL).Return(repo, nil)
+	s.mockDaoRegistry.RepositoryConfig.On("UpdateLastSnapshotTask", mock.Anything, task.Id.String(), repoConfig.OrgID, repo.UUID).Return(nil)
+	s.MockPulpClient.On("LookupOrCreateDomain", "shared").Return(pointy.Pointer("found"), nil)
+
+	// The pulp objects are named after the repository, not the repository configuration
+	remoteResp := zest.RpmRpmRemoteResponse{PulpHref: pointy.String("remoteHref"), Url: repo.URL}
+	s.MockPulpClient.On("GetRpmRemoteByName", repo.UUID).Return(&remoteResp, nil).Once()
+	s.MockPulpClient.On("UpdateRpmRemote", "remoteHref", repo.URL, mock.MatchedBy(func(options pulp_client.RemoteOptions) bool {
+		return options.ClientCert != "" && options.ProxyURL == ""
+	})).Return("updateTaskHref", nil).Once()
+	repoResp := zest.RpmRpmRepositoryResponse{PulpHref: pointy.String("repoHref"), LatestVersionHref: &versionHref}
+	s.MockPulpClient.On("GetRpmRepositoryByName", repo.UUID).Return(&repoResp, nil).Twice()
+
+	taskHref := "SyncTaskHref"
+	s.MockPulpClient.On("SyncRpmRepository", *(repoResp.PulpHref), (*string)(nil)).Return(taskHref, nil)
+	_, syncTask := s.mockSync(taskHref, false)
+	s.MockQueue.On("UpdatePayload", &task, payloads.SnapshotPayload{
+		SyncTaskHref: &syncTask,
+	}).Return(&task, nil)
+
+	s.mockDaoRegistry.Snapshot.On("FetchForRepoConfigUUID", mock.Anything, repoConfig.UUID).Return([]models.Snapshot{}, nil).Once()
+	pubHref, _ := s.mockPublish(versionHref, true)
+	distHref := "/pulp/api/v3/distributions/rpm/rpm/" + uuid.NewString() + "/"
+	ident := uuid.NewSHA1(repoUuid, []byte(versionHref)).String()
+	distPath := fmt.Sprintf("%s/%s", repo.UUID, ident)
+	s.MockPulpClient.On("FindDistributionByPath", distPath).Return(&zest.RpmRpmDistributionResponse{PulpHref: &distHref}, nil).Once()
+
+	counts := zest.RepositoryVersionResponseContentSummary{
+		Present: map[string]map[string]interface{}{},
+	}
+	s.MockPulpClient.On("GetRpmRepositoryVersion", versionHref).Return(&zest.RepositoryVersionResponse{PulpHref: &versionHref, ContentSummary: &counts}, nil)
+	s.MockPulpClient.On("GetRpmRepositoryVersionSize", versionHref).Return(int64(4096), nil)
+
+	expectedSnap := models.Snapshot{
+		VersionHref:                 versionHref,
+		PublicationHref:             pubHref,
+		DistributionHref:            distHref,
+		DistributionPath:            distPath,
+		RepositoryPath:              fmt.Sprintf("shared/%s", distPath),
+		RepositoryConfigurationUUID: repoConfig.UUID,
+		ContentCounts:               ContentSummaryToContentCounts(&counts),
+		SizeBytes:                   4096,
+		Shared:                      true,
+	}
+	s.mockDaoRegistry.Snapshot.On("Create", mock.Anything, &expectedSnap).Return(nil).Once()
+
+	snap := SnapshotRepository{
+		orgId:            repoConfig.OrgID,
+		domainName:       repoConfig.OrgID,
+		repositoryUUID:   repoUuid,
+		daoReg:           s.mockDaoRegistry.ToDaoRegistry(),
+		sharedDomainName: "shared",
+		sharedPulpClient: &s.MockPulpClient,
+		payload:          &payloads.SnapshotPayload{},
+		task:             &task,
+		queue:            &s.Queue,
+		ctx:              nil,
+		logger:           &log.Logger,
+	}
+	snapErr := snap.Run()
+	assert.NoError(s.T(), snapErr)
+}
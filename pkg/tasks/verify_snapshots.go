@@ -0,0 +1,204 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"reflect"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	"github.com/content-services/content-sources-backend/pkg/db"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/pulp_client"
+	"github.com/content-services/content-sources-backend/pkg/tasks/payloads"
+	"github.com/content-services/content-sources-backend/pkg/tasks/queue"
+	"github.com/rs/zerolog"
+)
+
+// VerifySnapshotsPayload selects the snapshots to verify, all the snapshots of the org
+// of the task are verified when RepoConfigUUID is empty
+type VerifySnapshotsPayload struct {
+	RepoConfigUUID string
+}
+
+type VerifySnapshots struct {
+	daoReg     *dao.DaoRegistry
+	pulpClient *pulp_client.PulpClient
+	payload    *VerifySnapshotsPayload
+	task       *models.TaskInfo
+	ctx        context.Context
+	logger     *zerolog.Logger
+}
+
+func VerifySnapshotHandler(ctx context.Context, task *models.TaskInfo, _ *queue.Queue) error {
+	opts := VerifySnapshotsPayload{}
+	if err := payloads.Decode(task.Typename, task.Payload, &opts); err != nil {
+		return fmt.Errorf("payload incorrect type for " + config.VerifySnapshotsTask)
+	}
+	daoReg := dao.GetDaoRegistry(db.DB)
+	logger := LogForTask(task.Id.String(), task.Typename, task.RequestID, task.Username)
+	ctxWithLogger := logger.WithContext(ctx)
+	globalPulpClient := pulp_client.GetGlobalPulpClient(ctxWithLogger)
+
+	pulpClient, err := lookupOptionalPulpClient(ctxWithLogger, globalPulpClient, task, daoReg)
+	if err != nil {
+		return err
+	}
+	vs := VerifySnapshots{
+		daoReg:     daoReg,
+		pulpClient: pulpClient,
+		payload:    &opts,
+		task:       task,
+		ctx:        ctx,
+		logger:     logger,
+	}
+	return vs.Run()
+}
+
+// Run verifies that the repository version, publication and distribution of each snapshot
+// still exist in pulp.  A missing publication or distribution is recreated, while a snapshot
+// whose repository version is missing or whose content changed can not be repaired and is flagged.
+func (vs *VerifySnapshots) Run() error {
+	// If pulp client is nil, the org never had a domain created, so has no snapshots
+	if !config.PulpConfigured() || vs.pulpClient == nil {
+		return nil
+	}
+	snaps, err := vs.fetchSnapshots()
+	if err != nil {
+		return err
+	}
+	corrupted := 0
+	for _, snap := range snaps {
+		if snap.Shared {
+			// Shared snapshots live in the shared domain, not the one of the org
+			continue
+		}
+		snap, err = vs.verify(snap)
+		if err != nil {
+			return err
+		}
+		if snap.VerificationError != "" {
+			corrupted++
+		}
+		err = vs.daoReg.Snapshot.UpdateVerification(vs.ctx, snap)
+		if err != nil {
+			return err
+		}
+	}
+	if corrupted > 0 {
+		return fmt.Errorf("%d of %d snapshots are corrupted and could not be repaired", corrupted, len(snaps))
+	}
+	return nil
+}
+
+func (vs *VerifySnapshots) getPulpClient() pulp_client.PulpClient {
+	return *vs.pulpClient
+}
+
+func (vs *VerifySnapshots) fetchSnapshots() ([]models.Snapshot, error) {
+	if vs.payload.RepoConfigUUID != "" {
+		return vs.daoReg.Snapshot.FetchForRepoConfigUUID(vs.ctx, vs.payload.RepoConfigUUID)
+	}
+	return vs.daoReg.Snapshot.FetchForOrg(vs.ctx, vs.task.OrgId)
+}
+
+// verify checks a snapshot against pulp, repairing its publication and distribution when missing,
+// and returns the snapshot with the result of the verification
+func (vs *VerifySnapshots) verify(snap models.Snapshot) (models.Snapshot, error) {
+	now := time.Now()
+	snap.VerifiedAt = &now
+	snap.VerificationError = ""
+
+	version, err := vs.getPulpClient().GetRpmRepositoryVersion(snap.VersionHref)
+	if err != nil || version == nil {
+		vs.logger.Warn().Err(err).Msgf("Repository version %v of snapshot %v could not be found", snap.VersionHref, snap.UUID)
+		snap.VerificationError = fmt.Sprintf("Repository version %v could not be found", snap.VersionHref)
+		return snap, nil
+	}
+	// Snapshots created before content counts were recorded have none to compare with
+	counts := ContentSummaryToContentCounts(version.ContentSummary)
+	if len(snap.ContentCounts) > 0 && !reflect.DeepEqual(counts, snap.ContentCounts) {
+		snap.VerificationError = fmt.Sprintf("Content of repository version %v does not match the snapshot", snap.VersionHref)
+		return snap, nil
+	}
+
+	publicationHref, err := vs.findOrCreatePublication(snap.VersionHref)
+	if err != nil {
+		return snap, err
+	}
+	distributionHref, err := vs.findOrCreateDistribution(publicationHref, snap.DistributionPath)
+	if err != nil {
+		return snap, err
+	}
+	if publicationHref != snap.PublicationHref || distributionHref != snap.DistributionHref {
+		vs.logger.Info().Msgf("Repaired snapshot %v, now published at %v and distributed at %v", snap.UUID, publicationHref, distributionHref)
+	}
+	snap.PublicationHref = publicationHref
+	snap.DistributionHref = distributionHref
+	return snap, nil
+}
+
+func (vs *VerifySnapshots) findOrCreatePublication(versionHref string) (string, error) {
+	publication, err := vs.getPulpClient().FindRpmPublicationByVersion(versionHref)
+	if err != nil {
+		return "", err
+	}
+	if publication != nil && publication.PulpHref != nil {
+		return *publication.PulpHref, nil
+	}
+
+	publicationTaskHref, err := vs.getPulpClient().CreateRpmPublication(versionHref)
+	if err != nil {
+		return "", err
+	}
+	publicationTask, err := vs.getPulpClient().PollTask(*publicationTaskHref)
+	if err != nil {
+		return "", err
+	}
+	publicationHref := pulp_client.SelectPublicationHref(publicationTask)
+	if publicationHref == nil {
+		return "", fmt.Errorf("Could not find a publication href in task: %v", publicationTask.PulpHref)
+	}
+	return *publicationHref, nil
+}
+
+// findOrCreateDistribution returns the distribution serving the publication at the path of the snapshot,
+// replacing a distribution serving another publication at this path
+func (vs *VerifySnapshots) findOrCreateDistribution(publicationHref string, distPath string) (string, error) {
+	dist, err := vs.getPulpClient().FindDistributionByPath(distPath)
+	if err != nil {
+		return "", err
+	}
+	if dist != nil && dist.PulpHref != nil {
+		if dist.GetPublication() == publicationHref {
+			return *dist.PulpHref, nil
+		}
+		deleteTaskHref, err := vs.getPulpClient().DeleteRpmDistribution(*dist.PulpHref)
+		if err != nil {
+			return "", err
+		}
+		if deleteTaskHref != "" {
+			_, err = vs.getPulpClient().PollTask(deleteTaskHref)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	// Snapshot distributions are named after the last element of their path
+	distTaskHref, err := vs.getPulpClient().CreateRpmDistribution(publicationHref, path.Base(distPath), distPath)
+	if err != nil {
+		return "", err
+	}
+	distTask, err := vs.getPulpClient().PollTask(*distTaskHref)
+	if err != nil {
+		return "", err
+	}
+	distHref := pulp_client.SelectRpmDistributionHref(distTask)
+	if distHref == nil {
+		return "", fmt.Errorf("Could not find a distribution href in task: %v", distTask.PulpHref)
+	}
+	return *distHref, nil
+}
@@ -0,0 +1,151 @@
+package tasks
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/dao"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/pulp_client"
+	zest "github.com/content-services/zest/release/v2023"
+	"github.com/google/uuid"
+	"github.com/openlyinc/pointy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type VerifySnapshotsSuite struct {
+	suite.Suite
+	mockDaoRegistry *dao.MockDaoRegistry
+	MockPulpClient  pulp_client.MockPulpClient
+	pulpServer      string
+}
+
+func TestVerifySnapshotsSuite(t *testing.T) {
+	suite.Run(t, new(VerifySnapshotsSuite))
+}
+
+func (s *VerifySnapshotsSuite) SetupTest() {
+	s.mockDaoRegistry = dao.GetMockDaoRegistry(s.T())
+	s.MockPulpClient = *pulp_client.NewMockPulpClient(s.T())
+	// Snapshots are only verified when pulp is configured
+	s.pulpServer = config.Get().Clients.Pulp.Server
+	config.Get().Clients.Pulp.Server = "http://pulp.example.com"
+}
+
+func (s *VerifySnapshotsSuite) TearDownTest() {
+	config.Get().Clients.Pulp.Server = s.pulpServer
+}
+
+func (s *VerifySnapshotsSuite) verifySnapshots(payload VerifySnapshotsPayload, orgID string) VerifySnapshots {
+	var pulpClient pulp_client.PulpClient = &s.MockPulpClient
+	task := models.TaskInfo{
+		Id:    uuid.New(),
+		OrgId: orgID,
+	}
+	return VerifySnapshots{
+		daoReg:     s.mockDaoRegistry.ToDaoRegistry(),
+		pulpClient: &pulpClient,
+		payload:    &payload,
+		task:       &task,
+		logger:     LogForTask(task.Id.String(), config.VerifySnapshotsTask, "", ""),
+	}
+}
+
+func (s *VerifySnapshotsSuite) snapshot(repoConfigUUID string) models.Snapshot {
+	snapshotId := uuid.NewString()
+	return models.Snapshot{
+		Base:                        models.Base{UUID: uuid.NewString()},
+		VersionHref:                 "version-href",
+		PublicationHref:             "pub-href",
+		DistributionHref:            "dist-href",
+		DistributionPath:            fmt.Sprintf("%s/%s", repoConfigUUID, snapshotId),
+		RepositoryConfigurationUUID: repoConfigUUID,
+		ContentCounts:               models.ContentCounts{"rpm.package": 3},
+	}
+}
+
+func versionResponse(packages float64) *zest.RepositoryVersionResponse {
+	return &zest.RepositoryVersionResponse{
+		ContentSummary: &zest.RepositoryVersionResponseContentSummary{
+			Present: map[string]map[string]interface{}{"rpm.package": {"count": packages}},
+		},
+	}
+}
+
+func (s *VerifySnapshotsSuite) TestVerifySound() {
+	repoConfigUUID := uuid.NewString()
+	snap := s.snapshot(repoConfigUUID)
+
+	s.mockDaoRegistry.Snapshot.On("FetchForRepoConfigUUID", mock.Anything, repoConfigUUID).Return([]models.Snapshot{snap}, nil).Once()
+	s.MockPulpClient.On("GetRpmRepositoryVersion", snap.VersionHref).Return(versionResponse(3), nil).Once()
+	s.MockPulpClient.On("FindRpmPublicationByVersion", snap.VersionHref).
+		Return(&zest.RpmRpmPublicationResponse{PulpHref: pointy.String(snap.PublicationHref)}, nil).Once()
+	dist := zest.RpmRpmDistributionResponse{PulpHref: pointy.String(snap.DistributionHref)}
+	dist.SetPublication(snap.PublicationHref)
+	s.MockPulpClient.On("FindDistributionByPath", snap.DistributionPath).Return(&dist, nil).Once()
+	s.mockDaoRegistry.Snapshot.On("UpdateVerification", mock.Anything, mock.MatchedBy(func(verified models.Snapshot) bool {
+		return verified.UUID == snap.UUID && verified.VerificationError == "" && verified.VerifiedAt != nil &&
+			verified.PublicationHref == snap.PublicationHref && verified.DistributionHref == snap.DistributionHref
+	})).Return(nil).Once()
+
+	vs := s.verifySnapshots(VerifySnapshotsPayload{RepoConfigUUID: repoConfigUUID}, "OrgId")
+	assert.NoError(s.T(), vs.Run())
+}
+
+func (s *VerifySnapshotsSuite) TestVerifyRepairs() {
+	repoConfigUUID := uuid.NewString()
+	snap := s.snapshot(repoConfigUUID)
+	taskResp := zest.TaskResponse{PulpHref: pointy.String("taskHref")}
+	pubTaskResp := zest.TaskResponse{CreatedResources: []string{"/pulp/myDomain/api/v3/publications/rpm/rpm/new-pub/"}}
+	distTaskResp := zest.TaskResponse{CreatedResources: []string{"/pulp/myDomain/api/v3/distributions/rpm/rpm/new-dist/"}}
+
+	s.mockDaoRegistry.Snapshot.On("FetchForOrg", mock.Anything, "OrgId").Return([]models.Snapshot{snap}, nil).Once()
+	s.MockPulpClient.On("GetRpmRepositoryVersion", snap.VersionHref).Return(versionResponse(3), nil).Once()
+
+	// The publication is missing and the distribution still serves the removed one
+	s.MockPulpClient.On("FindRpmPublicationByVersion", snap.VersionHref).Return(nil, nil).Once()
+	s.MockPulpClient.On("CreateRpmPublication", snap.VersionHref).Return(pointy.String("pubTaskHref"), nil).Once()
+	s.MockPulpClient.On("PollTask", "pubTaskHref").Return(&pubTaskResp, nil).Once()
+	dist := zest.RpmRpmDistributionResponse{PulpHref: pointy.String(snap.DistributionHref)}
+	dist.SetPublication(snap.PublicationHref)
+	s.MockPulpClient.On("FindDistributionByPath", snap.DistributionPath).Return(&dist, nil).Once()
+	s.MockPulpClient.On("DeleteRpmDistribution", snap.DistributionHref).Return("taskHref", nil).Once()
+	s.MockPulpClient.On("PollTask", "taskHref").Return(&taskResp, nil).Once()
+	s.MockPulpClient.On("CreateRpmDistribution", pubTaskResp.CreatedResources[0], snap.DistributionPath[len(repoConfigUUID)+1:], snap.DistributionPath).
+		Return(pointy.String("distTaskHref"), nil).Once()
+	s.MockPulpClient.On("PollTask", "distTaskHref").Return(&distTaskResp, nil).Once()
+
+	s.mockDaoRegistry.Snapshot.On("UpdateVerification", mock.Anything, mock.MatchedBy(func(verified models.Snapshot) bool {
+		return verified.UUID == snap.UUID && verified.VerificationError == "" &&
+			verified.PublicationHref == pubTaskResp.CreatedResources[0] &&
+			verified.DistributionHref == distTaskResp.CreatedResources[0]
+	})).Return(nil).Once()
+
+	vs := s.verifySnapshots(VerifySnapshotsPayload{}, "OrgId")
+	assert.NoError(s.T(), vs.Run())
+}
+
+func (s *VerifySnapshotsSuite) TestVerifyCorrupted() {
+	repoConfigUUID := uuid.NewString()
+	missing := s.snapshot(repoConfigUUID)
+	missing.VersionHref = "missing-version-href"
+	changed := s.snapshot(repoConfigUUID)
+
+	s.mockDaoRegistry.Snapshot.On("FetchForRepoConfigUUID", mock.Anything, repoConfigUUID).Return([]models.Snapshot{missing, changed}, nil).Once()
+	s.MockPulpClient.On("GetRpmRepositoryVersion", missing.VersionHref).Return(nil, fmt.Errorf("404 Not Found")).Once()
+	s.MockPulpClient.On("GetRpmRepositoryVersion", changed.VersionHref).Return(versionResponse(2), nil).Once()
+	s.mockDaoRegistry.Snapshot.On("UpdateVerification", mock.Anything, mock.MatchedBy(func(verified models.Snapshot) bool {
+		return verified.UUID == missing.UUID && verified.VerificationError == "Repository version missing-version-href could not be found"
+	})).Return(nil).Once()
+	s.mockDaoRegistry.Snapshot.On("UpdateVerification", mock.Anything, mock.MatchedBy(func(verified models.Snapshot) bool {
+		return verified.UUID == changed.UUID && verified.VerificationError == "Content of repository version version-href does not match the snapshot"
+	})).Return(nil).Once()
+
+	vs := s.verifySnapshots(VerifySnapshotsPayload{RepoConfigUUID: repoConfigUUID}, "OrgId")
+	err := vs.Run()
+	assert.Error(s.T(), err)
+	assert.Equal(s.T(), "2 of 2 snapshots are corrupted and could not be repaired", err.Error())
+}
@@ -1,5 +1,7 @@
 package worker
 
+import "runtime"
+
 func contains[T comparable](elems []T, v T) bool {
 	for _, s := range elems {
 		if v == s {
@@ -8,3 +10,10 @@ func contains[T comparable](elems []T, v T) bool {
 	}
 	return false
 }
+
+// goroutineDump returns the stack traces of every goroutine, to find where a task is stuck
+func goroutineDump() string {
+	buf := make([]byte, 1<<20)
+	n := runtime.Stack(buf, true)
+	return string(buf[:n])
+}
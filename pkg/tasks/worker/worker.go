@@ -2,6 +2,8 @@ package worker
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -15,6 +17,9 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// ErrMaxTaskDuration fails the tasks still running after the max task duration
+var ErrMaxTaskDuration = errors.New("task exceeded the max task duration")
+
 type worker struct {
 	queue       queue.Queue
 	workerWg    *sync.WaitGroup // wait for worker loop to exit
@@ -38,6 +43,7 @@ type runningTask struct {
 	token     uuid.UUID
 	typename  string
 	requestID string
+	username  string
 }
 
 func (t *runningTask) set(info *models.TaskInfo) {
@@ -45,6 +51,7 @@ func (t *runningTask) set(info *models.TaskInfo) {
 	t.token = info.Token
 	t.typename = info.Typename
 	t.requestID = info.RequestID
+	t.username = info.Username
 }
 
 func (t *runningTask) clear() {
@@ -52,6 +59,7 @@ func (t *runningTask) clear() {
 	t.token = uuid.Nil
 	t.typename = ""
 	t.requestID = ""
+	t.username = ""
 }
 
 func newWorker(config workerConfig, metrics *m.Metrics) worker {
@@ -129,6 +137,7 @@ func (w *worker) dequeue(ctx context.Context) (*models.TaskInfo, error) {
 	}
 
 	w.metrics.RecordMessageLatency(*info.Queued)
+	w.metrics.RecordTaskQueueWait(*info.Queued, queue.PriorityClass(info.Priority))
 	w.runningTask.set(info)
 	logForTask(w.runningTask).Info().Msg("[Dequeued Task]")
 
@@ -140,9 +149,15 @@ func (w *worker) requeue(id uuid.UUID) error {
 	defer recoverOnPanic(*logger)
 
 	err := w.queue.Requeue(id)
+	if errors.Is(err, queue.ErrMaxRetries) {
+		w.metrics.RecordTaskDeadLetter(w.runningTask.typename)
+		logger.Error().Msg("[Dead Task] task ran out of retries")
+		return nil
+	}
 	if err != nil {
 		return err
 	}
+	w.metrics.RecordTaskRetry(w.runningTask.typename)
 	logger.Info().Msg("[Requeued Task]")
 	return nil
 }
@@ -153,12 +168,14 @@ func (w *worker) process(ctx context.Context, taskInfo *models.TaskInfo) {
 	defer recoverOnPanic(*logger)
 
 	if handler, ok := w.handlers[taskInfo.Typename]; ok {
-		err := handler(ctx, taskInfo, &w.queue)
+		started := time.Now()
+		err := w.runHandler(ctx, handler, taskInfo)
 		if err != nil {
 			w.metrics.RecordMessageResult(false)
 		} else {
 			w.metrics.RecordMessageResult(true)
 		}
+		w.metrics.RecordTaskDuration(taskInfo.Typename, started, err == nil)
 
 		err = w.queue.Finish(taskInfo.Id, err)
 		if err != nil {
@@ -172,6 +189,40 @@ func (w *worker) process(ctx context.Context, taskInfo *models.TaskInfo) {
 	w.readyChan <- struct{}{}
 }
 
+// runHandler runs the handler of the task, giving up on it once it has run for the max task duration so
+// that a stuck handler does not hold the worker, and its heartbeat, forever
+func (w *worker) runHandler(ctx context.Context, handler TaskHandler, taskInfo *models.TaskInfo) error {
+	maxDuration := config.Get().Tasking.MaxTaskDuration
+	if maxDuration <= 0 {
+		return handler(ctx, taskInfo, &w.queue)
+	}
+	logger := logForTask(w.runningTask)
+
+	handlerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		// The handler runs in its own goroutine, where a panic would not be recovered by process
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error().Stack().Msgf("recovered panic in task handler: %v", r)
+				done <- fmt.Errorf("task handler panicked: %v", r)
+			}
+		}()
+		done <- handler(handlerCtx, taskInfo, &w.queue)
+	}()
+
+	timer := time.NewTimer(maxDuration)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		logger.Error().Str("goroutines", goroutineDump()).Msgf("task still running after %v, failing it", maxDuration)
+		return fmt.Errorf("%w of %v", ErrMaxTaskDuration, maxDuration)
+	}
+}
+
 func (w *worker) stop() {
 	w.stopChan <- struct{}{}
 }
@@ -186,6 +237,6 @@ func recoverOnPanic(logger zerolog.Logger) {
 }
 
 func logForTask(task *runningTask) *zerolog.Logger {
-	logger := tasks.LogForTask(task.id.String(), task.typename, task.requestID)
+	logger := tasks.LogForTask(task.id.String(), task.typename, task.requestID, task.username)
 	return logger
 }
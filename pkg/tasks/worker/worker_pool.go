@@ -2,13 +2,16 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"time"
 
 	"github.com/content-services/content-sources-backend/pkg/config"
 	m "github.com/content-services/content-sources-backend/pkg/instrumentation"
 	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/tasks"
 	"github.com/content-services/content-sources-backend/pkg/tasks/queue"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 )
 
@@ -61,8 +64,12 @@ func (w *WorkerPool) HeartbeatListener() {
 
 					if isRunning {
 						err = w.queue.Requeue(id)
-						if err != nil {
+						if errors.Is(err, queue.ErrMaxRetries) {
+							w.recordDeadLetter(id)
+						} else if err != nil {
 							log.Logger.Warn().Err(err).Msg("error requeuing task")
+						} else {
+							w.recordRetry(id)
 						}
 					}
 				}
@@ -71,6 +78,27 @@ func (w *WorkerPool) HeartbeatListener() {
 	}()
 }
 
+// recordRetry counts the requeue of a task whose worker stopped sending heartbeats
+func (w *WorkerPool) recordRetry(id uuid.UUID) {
+	info, err := w.queue.Status(id)
+	if err != nil {
+		log.Logger.Warn().Err(err).Msg("error getting requeued task")
+		return
+	}
+	w.metrics.RecordTaskRetry(info.Typename)
+}
+
+// recordDeadLetter counts a task that ran out of retries, which is only run again when an admin requeues it
+func (w *WorkerPool) recordDeadLetter(id uuid.UUID) {
+	info, err := w.queue.Status(id)
+	if err != nil {
+		log.Logger.Warn().Err(err).Msg("error getting dead task")
+		return
+	}
+	tasks.LogForTask(id.String(), info.Typename, info.RequestID, info.Username).Error().Msgf("task ran out of retries after %d attempts", info.Retries+1)
+	w.metrics.RecordTaskDeadLetter(info.Typename)
+}
+
 func (w *WorkerPool) StartWorkers(ctx context.Context) {
 	for i := 0; i < config.Get().Tasking.WorkerCount; i++ {
 		wrk := newWorker(workerConfig{
@@ -2,10 +2,17 @@ package worker
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/models"
 	"github.com/content-services/content-sources-backend/pkg/tasks/queue"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/goleak"
 )
@@ -35,3 +42,53 @@ func (s *WorkerSuite) TestStartStopWorkers() {
 	time.Sleep(time.Millisecond * 5)
 	workerPool.Stop()
 }
+
+func (s *WorkerSuite) TestMaxTaskDuration() {
+	maxDuration := config.Get().Tasking.MaxTaskDuration
+	defer func() { config.Get().Tasking.MaxTaskDuration = maxDuration }()
+	config.Get().Tasking.MaxTaskDuration = 10 * time.Millisecond
+
+	mockQueue := queue.NewMockQueue(s.T())
+	wrk := newWorker(workerConfig{queue: mockQueue}, nil)
+	taskInfo := &models.TaskInfo{Id: uuid.New(), Typename: "stuck"}
+	wrk.runningTask.set(taskInfo)
+
+	// The handler ignores the cancellation of its context, as a stuck handler would
+	canceled := make(chan struct{})
+	handler := func(ctx context.Context, task *models.TaskInfo, queue *queue.Queue) error {
+		<-ctx.Done()
+		close(canceled)
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}
+	wrk.handlers = map[string]TaskHandler{"stuck": handler}
+	mockQueue.On("Finish", taskInfo.Id, mock.MatchedBy(func(err error) bool {
+		return errors.Is(err, ErrMaxTaskDuration)
+	})).Return(nil).Once()
+
+	wrk.process(context.Background(), taskInfo)
+	<-wrk.readyChan
+	<-canceled
+	assert.Equal(s.T(), uuid.Nil, wrk.runningTask.id)
+	time.Sleep(60 * time.Millisecond)
+}
+
+func (s *WorkerSuite) TestTaskHandlerPanic() {
+	maxDuration := config.Get().Tasking.MaxTaskDuration
+	defer func() { config.Get().Tasking.MaxTaskDuration = maxDuration }()
+	config.Get().Tasking.MaxTaskDuration = time.Minute
+
+	mockQueue := queue.NewMockQueue(s.T())
+	wrk := newWorker(workerConfig{queue: mockQueue}, nil)
+	taskInfo := &models.TaskInfo{Id: uuid.New(), Typename: "panics"}
+	wrk.runningTask.set(taskInfo)
+	wrk.handlers = map[string]TaskHandler{"panics": func(ctx context.Context, task *models.TaskInfo, queue *queue.Queue) error {
+		panic("handler bug")
+	}}
+	mockQueue.On("Finish", taskInfo.Id, mock.MatchedBy(func(err error) bool {
+		return err != nil && strings.Contains(err.Error(), "handler bug")
+	})).Return(nil).Once()
+
+	wrk.process(context.Background(), taskInfo)
+	<-wrk.readyChan
+}
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/tasks/payloads"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Factory creates models with valid defaults, the fields a test cares about
+// being set by the optional functions passed to each method
+type Factory struct {
+	DB    *gorm.DB
+	OrgID string
+	count int
+}
+
+// NewFactory returns a factory creating models in db, owned by orgID
+func NewFactory(db *gorm.DB, orgID string) *Factory {
+	return &Factory{DB: db, OrgID: orgID}
+}
+
+// next returns a number unique to the factory, to build unique names and urls
+func (f *Factory) next() int {
+	f.count++
+	return f.count
+}
+
+func (f *Factory) create(t testing.TB, value interface{}) {
+	t.Helper()
+	if err := f.DB.Create(value).Error; err != nil {
+		t.Fatalf("could not create %T: %v", value, err)
+	}
+}
+
+// Repository creates a public repository
+func (f *Factory) Repository(t testing.TB, overrides ...func(*models.Repository)) models.Repository {
+	t.Helper()
+	repo := models.Repository{
+		URL:    fmt.Sprintf("https://factory.example.com/%s/%d/", uuid.NewString(), f.next()),
+		Public: true,
+		Status: config.StatusValid,
+	}
+	for _, override := range overrides {
+		override(&repo)
+	}
+	f.create(t, &repo)
+	return repo
+}
+
+// RepositoryConfig creates a repository configuration of the factory org, and its
+// repository unless RepositoryUUID is set
+func (f *Factory) RepositoryConfig(t testing.TB, overrides ...func(*models.RepositoryConfiguration)) models.RepositoryConfiguration {
+	t.Helper()
+	repoConfig := models.RepositoryConfiguration{
+		Name:      fmt.Sprintf("Factory repository %d", f.next()),
+		Arch:      config.X8664,
+		Versions:  []string{config.El9},
+		AccountID: f.OrgID,
+		OrgID:     f.OrgID,
+	}
+	for _, override := range overrides {
+		override(&repoConfig)
+	}
+	if repoConfig.RepositoryUUID == "" {
+		repo := f.Repository(t, func(repo *models.Repository) { repo.Public = false })
+		repoConfig.RepositoryUUID = repo.UUID
+		repoConfig.Repository = repo
+	}
+	f.create(t, &repoConfig)
+	return repoConfig
+}
+
+// Rpm creates a package in the repository
+func (f *Factory) Rpm(t testing.TB, repo models.Repository, overrides ...func(*models.Rpm)) models.Rpm {
+	t.Helper()
+	n := f.next()
+	rpm := models.Rpm{
+		Name:     fmt.Sprintf("factory-package-%d", n),
+		Arch:     config.X8664,
+		Version:  "1.0.0",
+		Release:  "1",
+		Summary:  "Factory package",
+		Checksum: fmt.Sprintf("%064d", n),
+	}
+	for _, override := range overrides {
+		override(&rpm)
+	}
+	f.create(t, &rpm)
+	if err := f.DB.Table(models.TableNameRpmsRepositories).Create(map[string]interface{}{
+		"repository_uuid": repo.UUID,
+		"rpm_uuid":        rpm.UUID,
+	}).Error; err != nil {
+		t.Fatalf("could not add rpm to repository: %v", err)
+	}
+	return rpm
+}
+
+// Snapshot creates a snapshot of the repository configuration
+func (f *Factory) Snapshot(t testing.TB, repoConfig models.RepositoryConfiguration, overrides ...func(*models.Snapshot)) models.Snapshot {
+	t.Helper()
+	path := fmt.Sprintf("%s/%d", repoConfig.UUID, f.next())
+	snapshot := models.Snapshot{
+		VersionHref:                 "/pulp/api/v3/repositories/rpm/rpm/" + path + "/versions/1/",
+		PublicationHref:             "/pulp/api/v3/publications/rpm/rpm/" + path + "/",
+		DistributionPath:            path,
+		RepositoryPath:              "factory/" + path,
+		DistributionHref:            "/pulp/api/v3/distributions/rpm/rpm/" + path + "/",
+		RepositoryConfigurationUUID: repoConfig.UUID,
+		ContentCounts:               models.ContentCounts{},
+	}
+	for _, override := range overrides {
+		override(&snapshot)
+	}
+	f.create(t, &snapshot)
+	return snapshot
+}
+
+// Task creates a completed introspection task of the repository configuration
+func (f *Factory) Task(t testing.TB, repoConfig models.RepositoryConfiguration, overrides ...func(*models.TaskInfo)) models.TaskInfo {
+	t.Helper()
+	payload, err := json.Marshal(map[string]string{"url": repoConfig.Repository.URL})
+	if err != nil {
+		t.Fatalf("could not encode task payload: %v", err)
+	}
+	now := time.Now()
+	task := models.TaskInfo{
+		Id:             uuid.New(),
+		Typename:       payloads.Introspect,
+		Payload:        payload,
+		OrgId:          repoConfig.OrgID,
+		RepositoryUUID: uuid.MustParse(repoConfig.RepositoryUUID),
+		Dependencies:   make([]uuid.UUID, 0),
+		Token:          uuid.New(),
+		Queued:         &now,
+		Started:        &now,
+		Finished:       &now,
+		Status:         config.TaskStatusCompleted,
+	}
+	for _, override := range overrides {
+		override(&task)
+	}
+	f.create(t, &task)
+	return task
+}
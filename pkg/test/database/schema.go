@@ -0,0 +1,135 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/db"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// extensions are installed in the public schema, shared by every test schema,
+// so that dropping a test schema does not drop objects other schemas depend on
+var extensions = []string{`"uuid-ossp"`, "pg_trgm"}
+
+// Schema is a postgres schema, migrated to the latest version, owned by a single
+// test suite.  Suites using their own schema do not see the rows of each other,
+// and can run in parallel without rolling back a transaction on the global db.DB.
+type Schema struct {
+	Name string
+	// URL connects to the database with the schema first in the search path,
+	// for code opening its own connections, such as the task queue
+	URL string
+	DB  *gorm.DB
+}
+
+// NewSchema creates and migrates a schema with a random name in the configured
+// database.  The schema is dropped once the test, or the suite, using it completes.
+func NewSchema(t testing.TB) *Schema {
+	t.Helper()
+	name := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	schema := &Schema{
+		Name: name,
+		URL:  fmt.Sprintf("%s search_path=%s,public", db.GetUrl(), name),
+	}
+
+	admin, err := sql.Open("postgres", db.GetUrl())
+	if err != nil {
+		t.Fatalf("could not connect to database: %v", err)
+	}
+	defer admin.Close()
+	for _, extension := range extensions {
+		if _, err = admin.Exec(fmt.Sprintf("CREATE EXTENSION IF NOT EXISTS %s SCHEMA public", extension)); err != nil {
+			t.Fatalf("could not create extension %s: %v", extension, err)
+		}
+	}
+	if _, err = admin.Exec(fmt.Sprintf("CREATE SCHEMA %s", name)); err != nil {
+		t.Fatalf("could not create schema %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		if err := schema.drop(); err != nil {
+			t.Errorf("could not drop schema %s: %v", name, err)
+		}
+	})
+
+	if err = schema.migrate(); err != nil {
+		t.Fatalf("could not migrate schema %s: %v", name, err)
+	}
+
+	// Connected as db.DB, with the statement timeouts and batch sizes of the application
+	schema.DB, err = db.Open(schema.URL)
+	if err != nil {
+		t.Fatalf("could not connect to schema %s: %v", name, err)
+	}
+	return schema
+}
+
+// migrate runs every up migration in the schema
+func (s *Schema) migrate() error {
+	dir, err := migrationsDir()
+	if err != nil {
+		return err
+	}
+	conn, err := sql.Open("postgres", s.URL)
+	if err != nil {
+		return err
+	}
+	driver, err := postgres.WithInstance(conn, &postgres.Config{SchemaName: s.Name})
+	if err != nil {
+		return err
+	}
+	m, err := migrate.NewWithDatabaseInstance("file://"+dir, "postgres", driver)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	if err = m.Up(); err != nil && err != migrate.ErrNoChange {
+		return err
+	}
+	return nil
+}
+
+// drop closes the connections to the schema and drops it with its content
+func (s *Schema) drop() error {
+	if s.DB != nil {
+		if sqlDB, err := s.DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
+	admin, err := sql.Open("postgres", db.GetUrl())
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+	_, err = admin.Exec(fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", s.Name))
+	return err
+}
+
+// migrationsDir looks for db/migrations in the working directory and its
+// parents, as tests run from the directory of their package
+func migrationsDir() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		candidate := filepath.Join(dir, "db", "migrations")
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return candidate, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("could not find db/migrations from the working directory")
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSchemasAreIsolated(t *testing.T) {
+	for _, name := range []string{"first", "second"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			schema := NewSchema(t)
+			factory := NewFactory(schema.DB, name)
+
+			repoConfig := factory.RepositoryConfig(t)
+			factory.Rpm(t, repoConfig.Repository)
+			factory.Snapshot(t, repoConfig)
+			factory.Task(t, repoConfig)
+
+			var orgIDs []string
+			require.NoError(t, schema.DB.Model(&models.RepositoryConfiguration{}).Pluck("org_id", &orgIDs).Error)
+			assert.Equal(t, []string{name}, orgIDs)
+
+			var count int64
+			require.NoError(t, schema.DB.Model(&models.Rpm{}).Count(&count).Error)
+			assert.Equal(t, int64(1), count)
+		})
+	}
+}
+
+type FactorySuite struct {
+	Suite
+}
+
+func TestFactorySuite(t *testing.T) {
+	suite.Run(t, new(FactorySuite))
+}
+
+func (s *FactorySuite) TestRepositoryConfig() {
+	repoConfig := s.Factory.RepositoryConfig(s.T(), func(rc *models.RepositoryConfiguration) {
+		rc.Name = "named"
+	})
+	assert.Equal(s.T(), "named", repoConfig.Name)
+	assert.Equal(s.T(), s.OrgID, repoConfig.OrgID)
+	assert.False(s.T(), repoConfig.Repository.Public)
+
+	var found models.RepositoryConfiguration
+	require.NoError(s.T(), s.Tx.Preload("Repository").First(&found, "uuid = ?", repoConfig.UUID).Error)
+	assert.Equal(s.T(), repoConfig.Repository.URL, found.Repository.URL)
+}
+
+func (s *FactorySuite) TestRolledBackBetweenTests() {
+	s.Factory.Repository(s.T())
+
+	var count int64
+	require.NoError(s.T(), s.Schema.DB.Model(&models.Repository{}).Count(&count).Error)
+	assert.Zero(s.T(), count, "rows created in the test transaction are visible outside of it")
+}
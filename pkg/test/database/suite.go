@@ -0,0 +1,36 @@
+package database
+
+import (
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// Suite is a test suite running against its own schema.  Each test runs in a
+// transaction, tx, rolled back once the test completes, with a factory creating
+// models in it.
+type Suite struct {
+	suite.Suite
+	Schema  *Schema
+	Tx      *gorm.DB
+	Factory *Factory
+	OrgID   string
+}
+
+// SetupSuite creates the schema of the suite, it is dropped once the suite completes
+func (s *Suite) SetupSuite() {
+	s.Schema = NewSchema(s.T())
+	if s.OrgID == "" {
+		s.OrgID = s.Schema.Name
+	}
+}
+
+// SetupTest begins the transaction of the test
+func (s *Suite) SetupTest() {
+	s.Tx = s.Schema.DB.Begin()
+	s.Factory = NewFactory(s.Tx, s.OrgID)
+}
+
+// TearDownTest rolls back the transaction of the test
+func (s *Suite) TearDownTest() {
+	s.Tx.Rollback()
+}
@@ -11,6 +11,7 @@ import (
 
 var MockAccountNumber = seeds.RandomAccountId()
 var MockOrgId = seeds.RandomOrgId()
+var MockUsername = "mock-user"
 
 func EncodedIdentity(t *testing.T) string {
 	mockIdentity := identity.XRHID{
@@ -19,6 +20,9 @@ func EncodedIdentity(t *testing.T) string {
 			Internal: identity.Internal{
 				OrgID: MockOrgId,
 			},
+			User: identity.User{
+				Username: MockUsername,
+			},
 			Type: "Associate",
 		},
 	}
@@ -0,0 +1,213 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/crypto"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/ssrf"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	relayDelay      = 5 // in seconds
+	relayBatchSize  = 50
+	deliveryTimeout = 10 * time.Second
+	maxAttempts     = 8
+	retryBaseDelay  = 30 * time.Second
+	claimLease      = 5 * time.Minute // how long claimed deliveries are skipped by other relays
+)
+
+// Headers sent with each event
+const (
+	HeaderEvent     = "X-Content-Sources-Event"
+	HeaderDelivery  = "X-Content-Sources-Delivery"
+	HeaderSignature = "X-Content-Sources-Signature"
+)
+
+// Sign returns the signature of an event body, the hex encoded HMAC-SHA256 of the body
+// keyed with the secret of the webhook, prefixed with sha256=
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Relay posts pending webhook deliveries, retrying failed ones with an exponential backoff.
+// Events are delivered at least once, receivers should deduplicate on the event id.
+type Relay struct {
+	context context.Context
+	db      *gorm.DB
+	client  *http.Client
+}
+
+func NewRelay(context context.Context, db *gorm.DB) *Relay {
+	if context == nil || db == nil {
+		return nil
+	}
+	return &Relay{
+		context: context,
+		db:      db,
+		client:  &http.Client{Timeout: deliveryTimeout, Transport: ssrf.Transport()},
+	}
+}
+
+func (r *Relay) Run() {
+	log.Info().Msg("Starting webhooks relay")
+	ticker := time.NewTicker(relayDelay * time.Second)
+	for {
+		select {
+		case <-r.context.Done():
+			ticker.Stop()
+			return
+		case <-ticker.C:
+			if err := r.relay(); err != nil {
+				log.Error().Err(err).Msg("Error relaying webhook deliveries")
+			}
+		}
+	}
+}
+
+// relay posts one batch of due deliveries. They are claimed first, and posted
+// outside of the claiming transaction so that no row stays locked while waiting on receivers.
+func (r *Relay) relay() error {
+	deliveries, err := r.claim()
+	if err != nil || len(deliveries) == 0 {
+		return err
+	}
+
+	webhookUUIDs := make([]string, len(deliveries))
+	for i := range deliveries {
+		webhookUUIDs[i] = deliveries[i].WebhookUUID
+	}
+	var webhooks []models.Webhook
+	if err = r.db.WithContext(r.context).Where("uuid IN ?", webhookUUIDs).Find(&webhooks).Error; err != nil {
+		return err
+	}
+	byUUID := make(map[string]models.Webhook, len(webhooks))
+	for _, webhook := range webhooks {
+		byUUID[webhook.UUID] = webhook
+	}
+
+	for i := range deliveries {
+		updates := r.deliver(byUUID[deliveries[i].WebhookUUID], deliveries[i])
+		if err := r.db.WithContext(r.context).Model(&deliveries[i]).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// claim locks a batch of due deliveries and postpones their next attempt by a lease, so that
+// several relays can run concurrently without posting the same delivery twice.
+// Deliveries whose relay stopped before updating them are retried once the lease expires.
+func (r *Relay) claim() ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.WithContext(r.context).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, time.Now()).
+			Order("next_attempt_at ASC").
+			Limit(relayBatchSize).
+			Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		uuids := make([]string, len(deliveries))
+		for i := range deliveries {
+			uuids[i] = deliveries[i].UUID
+		}
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("uuid IN ?", uuids).
+			Update("next_attempt_at", time.Now().Add(claimLease)).Error
+	})
+	return deliveries, err
+}
+
+// deliver posts the delivery to its webhook and returns the columns to update on its row
+func (r *Relay) deliver(webhook models.Webhook, delivery models.WebhookDelivery) map[string]interface{} {
+	attempts := delivery.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts": attempts,
+	}
+	status, err := r.post(webhook, delivery)
+	if status != 0 {
+		updates["response_status"] = status
+	}
+	if err == nil {
+		updates["status"] = models.WebhookDeliveryDelivered
+		updates["delivered_at"] = time.Now()
+		updates["last_error"] = nil
+		return updates
+	}
+
+	log.Warn().Err(err).Str("delivery_uuid", delivery.UUID).Msg("Failed to deliver webhook event")
+	updates["last_error"] = err.Error()
+	if attempts >= maxAttempts || !webhook.Enabled {
+		updates["status"] = models.WebhookDeliveryFailed
+	} else {
+		updates["next_attempt_at"] = time.Now().Add(retryDelay(attempts))
+	}
+	return updates
+}
+
+// post sends the event to the url of the webhook, returning the status of the response
+func (r *Relay) post(webhook models.Webhook, delivery models.WebhookDelivery) (int, error) {
+	if webhook.UUID == "" {
+		return 0, fmt.Errorf("webhook %v no longer exists", delivery.WebhookUUID)
+	}
+	if !webhook.Enabled {
+		return 0, fmt.Errorf("webhook %v is disabled", webhook.UUID)
+	}
+	secret, err := crypto.Decrypt(webhook.Secret)
+	if err != nil {
+		return 0, fmt.Errorf("could not decrypt the secret of webhook %v: %w", webhook.UUID, err)
+	}
+	body, err := json.Marshal(api.WebhookEvent{
+		ID:        delivery.UUID,
+		Event:     delivery.EventType,
+		OrgID:     delivery.OrgID,
+		CreatedAt: delivery.CreatedAt,
+		Data:      delivery.Payload,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(r.context, deliveryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.UUID)
+	req.Header.Set(HeaderSignature, Sign(secret, body))
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %v", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryDelay doubles the delay before retrying after each failed attempt
+func retryDelay(attempts int) time.Duration {
+	return retryBaseDelay * time.Duration(1<<(attempts-1))
+}
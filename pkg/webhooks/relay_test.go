@@ -0,0 +1,120 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/content-services/content-sources-backend/pkg/config"
+	"github.com/content-services/content-sources-backend/pkg/crypto"
+	"github.com/content-services/content-sources-backend/pkg/models"
+	"github.com/content-services/content-sources-backend/pkg/ssrf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func testDelivery(webhookUUID string, attempts int) models.WebhookDelivery {
+	return models.WebhookDelivery{
+		Base:        models.Base{UUID: "delivery-uuid", CreatedAt: time.Now()},
+		WebhookUUID: webhookUUID,
+		OrgID:       "1234",
+		EventType:   api.WebhookEventRepositoryDeleted,
+		Payload:     json.RawMessage(`{"repository":{"uuid":"abc","name":"repo","url":"https://example.com/repo/"}}`),
+		Status:      models.WebhookDeliveryPending,
+		Attempts:    attempts,
+	}
+}
+
+// encryptSecret configures an encryption key for the test, returning the secret encrypted as stored
+func encryptSecret(t *testing.T, secret string) string {
+	previousKeys := config.Get().Encryption.Keys
+	config.Get().Encryption.Keys = []string{"test:" + base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", crypto.KeySize)))}
+	t.Cleanup(func() { config.Get().Encryption.Keys = previousKeys })
+	encrypted, err := crypto.Encrypt(secret)
+	require.NoError(t, err)
+	return encrypted
+}
+
+func TestDeliverSignsEvent(t *testing.T) {
+	secret := "secret"
+	var received api.WebhookEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, Sign(secret, body), r.Header.Get(HeaderSignature))
+		assert.Equal(t, api.WebhookEventRepositoryDeleted, r.Header.Get(HeaderEvent))
+		assert.Equal(t, "delivery-uuid", r.Header.Get(HeaderDelivery))
+		require.NoError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	relay := Relay{context: context.Background(), client: server.Client()}
+	webhook := models.Webhook{Base: models.Base{UUID: "webhook-uuid"}, URL: server.URL, Secret: encryptSecret(t, secret), Enabled: true}
+
+	updates := relay.deliver(webhook, testDelivery(webhook.UUID, 0))
+	assert.Equal(t, models.WebhookDeliveryDelivered, updates["status"])
+	assert.Equal(t, 1, updates["attempts"])
+	assert.Equal(t, http.StatusNoContent, updates["response_status"])
+	assert.Contains(t, updates, "delivered_at")
+
+	assert.Equal(t, "delivery-uuid", received.ID)
+	assert.Equal(t, "1234", received.OrgID)
+	data := api.WebhookEventData{}
+	require.NoError(t, json.Unmarshal(received.Data, &data))
+	assert.Equal(t, "repo", data.Repository.Name)
+
+	// A secret that cannot be decrypted is not used to sign events
+	webhook.Secret = secret
+	updates = relay.deliver(webhook, testDelivery(webhook.UUID, 0))
+	assert.NotContains(t, updates, "response_status")
+	assert.Contains(t, updates["last_error"], crypto.ErrInvalidCipher.Error())
+}
+
+func TestDeliverRetriesFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	relay := Relay{context: context.Background(), client: server.Client()}
+	webhook := models.Webhook{Base: models.Base{UUID: "webhook-uuid"}, URL: server.URL, Secret: encryptSecret(t, "secret"), Enabled: true}
+
+	updates := relay.deliver(webhook, testDelivery(webhook.UUID, 1))
+	assert.NotContains(t, updates, "status")
+	assert.Equal(t, http.StatusInternalServerError, updates["response_status"])
+	assert.NotNil(t, updates["last_error"])
+	nextAttemptAt, ok := updates["next_attempt_at"].(time.Time)
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(2*retryBaseDelay), nextAttemptAt, time.Second)
+
+	// Failed after the last attempt
+	updates = relay.deliver(webhook, testDelivery(webhook.UUID, maxAttempts-1))
+	assert.Equal(t, models.WebhookDeliveryFailed, updates["status"])
+
+	// Failed when the webhook was deleted
+	updates = relay.deliver(models.Webhook{}, testDelivery(webhook.UUID, 0))
+	assert.Equal(t, models.WebhookDeliveryFailed, updates["status"])
+}
+
+func TestDeliverRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	relay := NewRelay(context.Background(), &gorm.DB{})
+	webhook := models.Webhook{Base: models.Base{UUID: "webhook-uuid"}, URL: server.URL, Secret: encryptSecret(t, "secret"), Enabled: true}
+
+	updates := relay.deliver(webhook, testDelivery(webhook.UUID, 0))
+	assert.NotContains(t, updates, "response_status")
+	assert.Contains(t, updates["last_error"], ssrf.ErrForbiddenAddress.Error())
+}
@@ -60,7 +60,7 @@ func (s *DeleteTest) TestSnapshot() {
 
 	// Setup the repository
 	accountId := uuid2.NewString()
-	repo, err := s.dao.RepositoryConfig.Create(api.RepositoryRequest{
+	repo, err := s.dao.RepositoryConfig.Create(context.Background(), api.RepositoryRequest{
 		Name:      pointy.Pointer(uuid2.NewString()),
 		URL:       pointy.Pointer("https://fixtures.pulpproject.org/rpm-unsigned/"),
 		AccountID: pointy.Pointer(accountId),
@@ -84,7 +84,7 @@ func (s *DeleteTest) TestSnapshot() {
 	assert.NoError(s.T(), err)
 	s.WaitOnTask(taskUuid)
 
-	results, _, err := s.dao.RepositoryConfig.List(accountId, api.PaginationData{}, api.FilterData{
+	results, _, err := s.dao.RepositoryConfig.List(context.Background(), accountId, api.PaginationData{}, api.FilterData{
 		Name: repo.Name,
 	})
 	assert.NoError(s.T(), err)
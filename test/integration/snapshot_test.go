@@ -65,7 +65,7 @@ func (s *SnapshotSuite) TestSnapshot() {
 
 	// Setup the repository
 	accountId := uuid2.NewString()
-	repo, err := s.dao.RepositoryConfig.Create(api.RepositoryRequest{
+	repo, err := s.dao.RepositoryConfig.Create(context.Background(), api.RepositoryRequest{
 		Name:      pointy.String(uuid2.NewString()),
 		URL:       pointy.String("https://fixtures.pulpproject.org/rpm-unsigned/"),
 		AccountID: pointy.String(accountId),
@@ -80,7 +80,7 @@ func (s *SnapshotSuite) TestSnapshot() {
 	s.snapshotAndWait(taskClient, repo, repoUuid, accountId)
 
 	// Verify the snapshot was created
-	snaps, _, err := s.dao.Snapshot.List(repo.UUID, api.PaginationData{}, api.FilterData{})
+	snaps, _, err := s.dao.Snapshot.List(context.Background(), repo.UUID, api.PaginationData{}, api.FilterData{})
 	assert.NoError(s.T(), err)
 	assert.NotEmpty(s.T(), snaps)
 	time.Sleep(5 * time.Second)
@@ -99,9 +99,9 @@ func (s *SnapshotSuite) TestSnapshot() {
 
 	// Update the url
 	newUrl := "https://fixtures.pulpproject.org/rpm-with-sha-512/"
-	urlUpdated, err := s.dao.RepositoryConfig.Update(accountId, repo.UUID, api.RepositoryRequest{URL: &newUrl})
+	urlUpdated, err := s.dao.RepositoryConfig.Update(context.Background(), accountId, repo.UUID, api.RepositoryRequest{URL: &newUrl})
 	assert.NoError(s.T(), err)
-	repo, err = s.dao.RepositoryConfig.Fetch(accountId, repo.UUID)
+	repo, err = s.dao.RepositoryConfig.Fetch(context.Background(), accountId, repo.UUID)
 	assert.NoError(s.T(), err)
 	repoUuid, err = uuid2.Parse(repo.RepositoryUUID)
 	assert.NoError(s.T(), err)
@@ -110,7 +110,7 @@ func (s *SnapshotSuite) TestSnapshot() {
 
 	s.snapshotAndWait(taskClient, repo, repoUuid, accountId)
 
-	domainName, err := s.dao.Domain.FetchOrCreateDomain(accountId)
+	domainName, err := s.dao.Domain.FetchOrCreateDomain(context.Background(), accountId)
 	assert.NoError(s.T(), err)
 
 	pulpClient := pulp_client.GetPulpClientWithDomain(context.Background(), domainName)
@@ -131,7 +131,7 @@ func (s *SnapshotSuite) TestSnapshot() {
 	s.WaitOnTask(taskUuid)
 
 	// Verify the snapshot was deleted
-	snaps, _, err = s.dao.Snapshot.List(repo.UUID, api.PaginationData{}, api.FilterData{})
+	snaps, _, err = s.dao.Snapshot.List(context.Background(), repo.UUID, api.PaginationData{}, api.FilterData{})
 	assert.NoError(s.T(), err)
 	assert.Empty(s.T(), snaps.Data)
 	time.Sleep(5 * time.Second)
@@ -155,7 +155,7 @@ func (s *SnapshotSuite) snapshotAndWait(taskClient client.TaskClient, repo api.R
 	s.WaitOnTask(taskUuid)
 
 	// Verify the snapshot was created
-	snaps, _, err := s.dao.Snapshot.List(repo.UUID, api.PaginationData{}, api.FilterData{})
+	snaps, _, err := s.dao.Snapshot.List(context.Background(), repo.UUID, api.PaginationData{}, api.FilterData{})
 	assert.NoError(s.T(), err)
 	assert.NotEmpty(s.T(), snaps)
 	time.Sleep(5 * time.Second)
@@ -0,0 +1,208 @@
+// Package load drives the API with concurrent requests and compares the latencies
+// observed with the ones recorded in a baseline file, so that performance
+// regressions are caught before they are released.
+package load
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Scenario is a request sent repeatedly to the API
+type Scenario struct {
+	Name   string
+	Method string
+	// Path is relative to the base url of the API
+	Path string
+	// Body returns the body of the i-th request, nil for requests without a body
+	Body func(i int) interface{}
+	// Expected is the http status expected for every request
+	Expected int
+}
+
+// Options sets how many requests a scenario sends, and how many at once
+type Options struct {
+	Requests    int
+	Concurrency int
+	Timeout     time.Duration
+}
+
+// Result holds the latencies of the successful requests of a scenario
+type Result struct {
+	Name      string
+	Latencies []time.Duration
+	Errors    int
+	// FirstError describes the first failed request, to help find why it failed
+	FirstError string
+}
+
+// Percentile returns the latency below which p percent of the requests completed
+func (r Result) Percentile(p float64) time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(r.Latencies))
+	copy(sorted, r.Latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	index := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	return sorted[index]
+}
+
+// Runner sends the requests of scenarios to an API on behalf of an org
+type Runner struct {
+	BaseURL  string
+	Identity string
+	Client   *http.Client
+}
+
+// NewRunner returns a runner sending requests to baseURL as a user of orgID
+func NewRunner(baseURL string, orgID string) Runner {
+	return Runner{
+		BaseURL:  baseURL,
+		Identity: Identity(orgID),
+		Client:   &http.Client{},
+	}
+}
+
+// Identity returns the encoded x-rh-identity header of a user of orgID
+func Identity(orgID string) string {
+	identity := fmt.Sprintf(`{"identity":{"type":"User","user":{"username":"load"},"account_number":"%s","internal":{"org_id":"%s"}}}`, orgID, orgID)
+	return base64.StdEncoding.EncodeToString([]byte(identity))
+}
+
+// Run sends options.Requests requests of the scenario, options.Concurrency at a time
+func (r Runner) Run(ctx context.Context, scenario Scenario, options Options) Result {
+	result := Result{Name: scenario.Name}
+	var mutex sync.Mutex
+	requests := make(chan int)
+	wg := sync.WaitGroup{}
+
+	for w := 0; w < options.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range requests {
+				latency, err := r.do(ctx, scenario, i, options.Timeout)
+				mutex.Lock()
+				if err != nil {
+					result.Errors++
+					if result.FirstError == "" {
+						result.FirstError = err.Error()
+					}
+				} else {
+					result.Latencies = append(result.Latencies, latency)
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < options.Requests; i++ {
+		requests <- i
+	}
+	close(requests)
+	wg.Wait()
+	return result
+}
+
+// do sends the i-th request of the scenario and returns how long the response took
+func (r Runner) do(ctx context.Context, scenario Scenario, i int, timeout time.Duration) (time.Duration, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var body io.Reader
+	if scenario.Body != nil {
+		encoded, err := json.Marshal(scenario.Body(i))
+		if err != nil {
+			return 0, err
+		}
+		body = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, scenario.Method, r.BaseURL+scenario.Path, body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("x-rh-identity", r.Identity)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	start := time.Now()
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if _, err = io.Copy(io.Discard, resp.Body); err != nil {
+		return 0, err
+	}
+	latency := time.Since(start)
+	if resp.StatusCode != scenario.Expected {
+		return 0, fmt.Errorf("%s %s returned http status %d, expected %d", scenario.Method, scenario.Path, resp.StatusCode, scenario.Expected)
+	}
+	return latency, nil
+}
+
+// Baseline is the p95 latency recorded for each scenario, in milliseconds,
+// with the regression tolerated before a run fails, in percent
+type Baseline struct {
+	Tolerance float64            `json:"tolerance_percent"`
+	P95       map[string]float64 `json:"p95_ms"`
+}
+
+// LoadBaseline reads the baseline file at path
+func LoadBaseline(path string) (Baseline, error) {
+	baseline := Baseline{P95: map[string]float64{}}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return baseline, err
+	}
+	if err = json.Unmarshal(data, &baseline); err != nil {
+		return baseline, fmt.Errorf("error parsing baseline %s: %w", path, err)
+	}
+	return baseline, nil
+}
+
+// Save writes the baseline to path
+func (b Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Record sets the p95 latency of the result as the baseline of its scenario
+func (b *Baseline) Record(result Result) {
+	b.P95[result.Name] = math.Round(float64(result.Percentile(95).Microseconds())) / 1000
+}
+
+// Check returns an error if the p95 latency of the result exceeds the baseline
+// of its scenario by more than the tolerance.  Scenarios without a baseline pass.
+func (b Baseline) Check(result Result) error {
+	recorded, ok := b.P95[result.Name]
+	if !ok {
+		return nil
+	}
+	limit := recorded * (1 + b.Tolerance/100)
+	observed := float64(result.Percentile(95).Microseconds()) / 1000
+	if observed > limit {
+		return fmt.Errorf("%s: p95 latency %.1fms exceeds the baseline of %.1fms by more than %.0f%%", result.Name, observed, recorded, b.Tolerance)
+	}
+	return nil
+}
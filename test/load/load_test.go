@@ -0,0 +1,194 @@
+package load
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/content-services/content-sources-backend/pkg/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const baselineFile = "baseline.json"
+
+// TestLoad runs the scenarios against the API at PERF_BASE_URL, seeded with
+// `make db-migrate-seed`, and fails if a p95 latency regressed beyond the baseline.
+// Set PERF_UPDATE_BASELINE=true to record the latencies observed as the new baseline.
+func TestLoad(t *testing.T) {
+	baseURL := os.Getenv("PERF_BASE_URL")
+	if baseURL == "" {
+		t.Skip("PERF_BASE_URL is not set, run with `make perf`")
+	}
+	orgID := envOrDefault("PERF_ORG_ID", "acme")
+	options := Options{
+		Requests:    envIntOrDefault(t, "PERF_REQUESTS", 200),
+		Concurrency: envIntOrDefault(t, "PERF_CONCURRENCY", 10),
+		Timeout:     30 * time.Second,
+	}
+	runner := NewRunner(baseURL, orgID)
+	runID := strconv.FormatInt(time.Now().Unix(), 10)
+
+	baseline, err := LoadBaseline(baselineFile)
+	require.NoError(t, err)
+	defer cleanup(t, runner, runID)
+
+	for _, scenario := range scenarios(runID) {
+		result := runner.Run(context.Background(), scenario, options)
+		t.Logf("%s: %d requests, %d errors, p50 %v, p95 %v, p99 %v", result.Name, len(result.Latencies)+result.Errors,
+			result.Errors, result.Percentile(50), result.Percentile(95), result.Percentile(99))
+		assert.Zero(t, result.Errors, "%s failed: %s", result.Name, result.FirstError)
+
+		if os.Getenv("PERF_UPDATE_BASELINE") == "true" {
+			baseline.Record(result)
+		} else {
+			assert.NoError(t, baseline.Check(result))
+		}
+	}
+
+	if os.Getenv("PERF_UPDATE_BASELINE") == "true" {
+		require.NoError(t, baseline.Save(baselineFile))
+		path, _ := filepath.Abs(baselineFile)
+		t.Logf("Baseline saved to %s", path)
+	}
+}
+
+func scenarios(runID string) []Scenario {
+	limit := 100
+	return []Scenario{
+		{
+			Name:     "list_repositories",
+			Method:   http.MethodGet,
+			Path:     "/repositories/?limit=100",
+			Expected: http.StatusOK,
+		},
+		{
+			Name:     "search_repositories",
+			Method:   http.MethodGet,
+			Path:     "/repositories/?search=TestRepo&sort_by=name",
+			Expected: http.StatusOK,
+		},
+		{
+			Name:     "list_tasks",
+			Method:   http.MethodGet,
+			Path:     "/tasks/?limit=100",
+			Expected: http.StatusOK,
+		},
+		{
+			Name:   "search_rpm_names",
+			Method: http.MethodPost,
+			Path:   "/rpms/names",
+			Body: func(i int) interface{} {
+				return api.SearchRpmRequest{URLs: []string{}, UUIDs: []string{}, Search: "a", Limit: &limit}
+			},
+			Expected: http.StatusOK,
+		},
+		{
+			Name:   "create_repository",
+			Method: http.MethodPost,
+			Path:   "/repositories/",
+			Body: func(i int) interface{} {
+				name := fmt.Sprintf("load-%s-%d", runID, i)
+				url := fmt.Sprintf("https://load.example.com/%s/%d/", runID, i)
+				return api.RepositoryRequest{Name: &name, URL: &url}
+			},
+			Expected: http.StatusCreated,
+		},
+	}
+}
+
+// cleanup deletes the repositories created by the run
+func cleanup(t *testing.T, runner Runner, runID string) {
+	for {
+		req, err := http.NewRequest(http.MethodGet, runner.BaseURL+"/repositories/?limit=100&search=load-"+runID, nil)
+		require.NoError(t, err)
+		req.Header.Set("x-rh-identity", runner.Identity)
+		resp, err := runner.Client.Do(req)
+		require.NoError(t, err)
+		var collection api.RepositoryCollectionResponse
+		err = json.NewDecoder(resp.Body).Decode(&collection)
+		resp.Body.Close()
+		require.NoError(t, err)
+		if len(collection.Data) == 0 {
+			return
+		}
+
+		uuids := make([]string, 0, len(collection.Data))
+		for _, repo := range collection.Data {
+			uuids = append(uuids, repo.UUID)
+		}
+		result := runner.Run(context.Background(), Scenario{
+			Name:     "cleanup",
+			Method:   http.MethodPost,
+			Path:     "/repositories/bulk_delete/",
+			Body:     func(i int) interface{} { return api.UUIDListRequest{UUIDs: uuids} },
+			Expected: http.StatusNoContent,
+		}, Options{Requests: 1, Concurrency: 1})
+		require.Zero(t, result.Errors, result.FirstError)
+	}
+}
+
+func envOrDefault(name string, value string) string {
+	if env := os.Getenv(name); env != "" {
+		return env
+	}
+	return value
+}
+
+func envIntOrDefault(t *testing.T, name string, value int) int {
+	env := os.Getenv(name)
+	if env == "" {
+		return value
+	}
+	parsed, err := strconv.Atoi(env)
+	require.NoError(t, err, "%s must be a number", name)
+	return parsed
+}
+
+func TestPercentile(t *testing.T) {
+	result := Result{}
+	assert.Equal(t, time.Duration(0), result.Percentile(95))
+
+	for i := 100; i > 0; i-- {
+		result.Latencies = append(result.Latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 50*time.Millisecond, result.Percentile(50))
+	assert.Equal(t, 95*time.Millisecond, result.Percentile(95))
+	assert.Equal(t, 100*time.Millisecond, result.Percentile(100))
+	assert.Equal(t, 1*time.Millisecond, result.Percentile(0))
+}
+
+func TestBaselineCheck(t *testing.T) {
+	baseline := Baseline{Tolerance: 20, P95: map[string]float64{"list": 100}}
+	result := func(name string, latency time.Duration) Result {
+		return Result{Name: name, Latencies: []time.Duration{latency}}
+	}
+
+	assert.NoError(t, baseline.Check(result("list", 110*time.Millisecond)))
+	assert.NoError(t, baseline.Check(result("list", 120*time.Millisecond)))
+	assert.Error(t, baseline.Check(result("list", 121*time.Millisecond)))
+	assert.NoError(t, baseline.Check(result("unknown", time.Second)))
+
+	baseline.Record(result("create", 42500*time.Microsecond))
+	assert.Equal(t, 42.5, baseline.P95["create"])
+}
+
+func TestBaselineFile(t *testing.T) {
+	baseline, err := LoadBaseline(baselineFile)
+	require.NoError(t, err)
+	for _, scenario := range scenarios("") {
+		assert.Contains(t, baseline.P95, scenario.Name, "scenario %s has no baseline", scenario.Name)
+	}
+
+	path := filepath.Join(t.TempDir(), baselineFile)
+	require.NoError(t, baseline.Save(path))
+	saved, err := LoadBaseline(path)
+	require.NoError(t, err)
+	assert.Equal(t, baseline, saved)
+}